@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// dockerSource reads image pulls and pushes from the Docker Engine API's event stream,
+// the same events `docker events` shows
+type dockerSource struct {
+	host string
+	// since is the time of the last event read, so a reconnect replays what was missed
+	since time.Time
+}
+
+// dockerMessage is the part of a Docker event the agent reads
+type dockerMessage struct {
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+func (d *dockerSource) Run(ctx context.Context, out chan<- event) error {
+	client, base, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"filters": {`{"type":["image"],"event":["pull","push"]}`}}
+	if !d.since.IsZero() {
+		// Whole seconds, so the last event may come again; the server skips it as a duplicate
+		query.Set("since", strconv.FormatInt(d.since.Unix(), 10))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/events?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker events: %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg dockerMessage
+		if err := decoder.Decode(&msg); err != nil {
+			return err
+		}
+		at := time.Unix(0, msg.TimeNano)
+		d.since = at
+
+		// The actor is the image reference that was pulled or pushed
+		image := msg.Actor.ID
+		if name := msg.Actor.Attributes["name"]; name != "" && image == "" {
+			image = name
+		}
+		select {
+		case out <- event{Timestamp: at.UTC().Format(time.RFC3339Nano), Action: msg.Action, Image: image}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// client returns an HTTP client for the daemon and the base URL to use with it. Event
+// streams stay open, so the client has no timeout.
+func (d *dockerSource) client() (*http.Client, string, error) {
+	u, err := url.Parse(d.host)
+	if err != nil {
+		return nil, "", err
+	}
+	switch u.Scheme {
+	case "unix":
+		dialer := net.Dialer{}
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", u.Path)
+			},
+		}
+		return &http.Client{Transport: transport}, "http://docker", nil
+	case "tcp", "http":
+		return &http.Client{}, "http://" + u.Host, nil
+	}
+	return nil, "", fmt.Errorf("unsupported Docker host %q; use unix:// or tcp://", d.host)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into every pod
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// pulledImagePattern finds the image in a kubelet "Pulled" event. Events saying an image
+// was already present on the machine don't match, since nothing was pulled.
+var pulledImagePattern = regexp.MustCompile(`[Ss]uccessfully pulled image "([^"]+)"`)
+
+// kubernetesSource watches the API server for the kubelet's image pull events on one node,
+// using the pod's service account. It is meant to run as a DaemonSet with NODE_NAME set
+// from the downward API. Nodes don't push images, so it only reports pulls.
+type kubernetesSource struct {
+	node   string
+	base   string
+	client *http.Client
+	// resourceVersion is where the watch resumes after a reconnect
+	resourceVersion string
+}
+
+// kubeWatchEvent is the part of a watched Event the agent reads
+type kubeWatchEvent struct {
+	Type   string `json:"type"`
+	Object struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Message string `json:"message"`
+		Source  struct {
+			Host string `json:"host"`
+		} `json:"source"`
+		ReportingInstance string `json:"reportingInstance"`
+		LastTimestamp     string `json:"lastTimestamp"`
+		EventTime         string `json:"eventTime"`
+		FirstTimestamp    string `json:"firstTimestamp"`
+	} `json:"object"`
+}
+
+func newKubernetesSource(node string) (*kubernetesSource, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes pod")
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid service account CA certificate")
+	}
+
+	return &kubernetesSource{
+		node:   node,
+		base:   "https://" + net.JoinHostPort(host, port),
+		client: &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}},
+	}, nil
+}
+
+func (k *kubernetesSource) Run(ctx context.Context, out chan<- event) error {
+	// Read on every connect, since projected service account tokens are rotated
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"watch": {"true"}, "fieldSelector": {"reason=Pulled"}}
+	if k.resourceVersion != "" {
+		query.Set("resourceVersion", k.resourceVersion)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.base+"/api/v1/events?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("watch events: %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var e kubeWatchEvent
+		if err := decoder.Decode(&e); err != nil {
+			return err
+		}
+		if e.Type == "ERROR" {
+			// Usually 410 Gone: the resume point is too old, so start over. Pulls seen
+			// again are skipped by the server as duplicates.
+			k.resourceVersion = ""
+			return fmt.Errorf("watch error: %s", e.Object.Message)
+		}
+		k.resourceVersion = e.Object.Metadata.ResourceVersion
+		if e.Type == "DELETED" {
+			continue
+		}
+
+		host := e.Object.Source.Host
+		if host == "" {
+			host = e.Object.ReportingInstance
+		}
+		match := pulledImagePattern.FindStringSubmatch(e.Object.Message)
+		if host != k.node || match == nil {
+			continue
+		}
+		at, ok := kubeEventTime(e.Object.LastTimestamp, e.Object.EventTime, e.Object.FirstTimestamp)
+		if !ok {
+			continue
+		}
+
+		select {
+		case out <- event{Timestamp: at.UTC().Format(time.RFC3339Nano), Action: "pull", Image: match[1]}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// kubeEventTime returns the first of an event's timestamps that is set
+func kubeEventTime(values ...string) (time.Time, bool) {
+	for _, value := range values {
+		if at, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
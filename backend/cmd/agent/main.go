@@ -0,0 +1,159 @@
+// Command agent reports image pulls and pushes on a node to Docker Heatmap, so on-cluster
+// activity shows up alongside registry pushes. It watches the Docker daemon's events or,
+// on Kubernetes, the kubelet's image pull events, and sends them in signed batches to
+// /api/v1/ingest/agent with an ingest key.
+//
+//	HEATMAP_INGEST_KEY=dhik_... HEATMAP_INGEST_SECRET=... \
+//	  go run ./cmd/agent -url https://api.dockerheatmap.dev -source docker
+//
+// The key and secret are only read from the environment, so they don't show up in
+// process lists. The agent uses nothing outside the standard library.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// agentName identifies the agent in reports, for troubleshooting
+const agentName = "docker-heatmap-agent/1"
+
+// event is an image pull or push seen on the node, as the agent protocol sends it
+type event struct {
+	Timestamp string `json:"timestamp"`
+	Action    string `json:"action"`
+	Image     string `json:"image"`
+}
+
+// source streams the node's image events into out until ctx is done or the connection
+// drops. A source resumes where it left off when run again.
+type source interface {
+	Run(ctx context.Context, out chan<- event) error
+}
+
+func main() {
+	apiURL := flag.String("url", envOr("HEATMAP_URL", "https://api.dockerheatmap.dev"), "Docker Heatmap deployment")
+	sourceName := flag.String("source", envOr("HEATMAP_AGENT_SOURCE", "docker"), "where to watch for image events: docker or kubernetes")
+	node := flag.String("node", envOr("NODE_NAME", hostname()), "node ID sent with every report")
+	dockerHost := flag.String("docker-host", envOr("DOCKER_HOST", "unix:///var/run/docker.sock"), "Docker daemon address, for -source docker")
+	interval := flag.Duration("flush-interval", 30*time.Second, "how often collected events are sent")
+	namespaces := flag.String("namespaces", "", "comma-separated image namespaces to report, such as alice,library (empty reports all)")
+	flag.Parse()
+
+	keyID, secret := os.Getenv("HEATMAP_INGEST_KEY"), os.Getenv("HEATMAP_INGEST_SECRET")
+	if keyID == "" || secret == "" {
+		log.Fatal("HEATMAP_INGEST_KEY and HEATMAP_INGEST_SECRET must be set; create an ingest key from the dashboard")
+	}
+
+	var src source
+	switch *sourceName {
+	case "docker":
+		src = &dockerSource{host: *dockerHost}
+	case "kubernetes":
+		k, err := newKubernetesSource(*node)
+		if err != nil {
+			log.Fatalf("Failed to set up the Kubernetes source: %v", err)
+		}
+		src = k
+	default:
+		log.Fatalf("Unknown source %q; use docker or kubernetes", *sourceName)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := &reporter{
+		endpoint: strings.TrimSuffix(*apiURL, "/") + "/api/v1/ingest/agent",
+		keyID:    keyID,
+		secret:   secret,
+		node:     *node,
+		interval: *interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	events := make(chan event, 1000)
+	go watch(ctx, src, events, splitList(*namespaces))
+
+	log.Printf("Reporting %s image events for node %s to %s", *sourceName, *node, r.endpoint)
+	r.Run(ctx, events)
+}
+
+// watch runs the source until ctx is done, reconnecting when it stops, and passes on
+// events for the wanted namespaces
+func watch(ctx context.Context, src source, out chan<- event, namespaces []string) {
+	raw := make(chan event)
+	go func() {
+		for e := range raw {
+			if len(namespaces) == 0 || contains(namespaces, imageNamespace(e.Image)) {
+				out <- e
+			}
+		}
+	}()
+
+	for {
+		err := src.Run(ctx, raw)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Event source stopped: %v; reconnecting in 5s", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// imageNamespace is the Docker Hub namespace of an image reference, "library" for
+// official images. References to other registries return their first path component.
+func imageNamespace(image string) string {
+	parts := strings.Split(strings.SplitN(image, "@", 2)[0], "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		parts = parts[1:]
+	}
+	if len(parts) == 1 {
+		return "library"
+	}
+	return strings.ToLower(parts[0])
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// protocolVersion is the agent report format this agent sends
+	protocolVersion = 1
+	// maxBatch is the most events the server takes in one report
+	maxBatch = 500
+	// maxPending bounds the events kept while the server can't be reached; the oldest go first
+	maxPending = 10000
+)
+
+// reporter collects events and sends them in signed batches
+type reporter struct {
+	endpoint string
+	keyID    string
+	secret   string
+	node     string
+	interval time.Duration
+	client   *http.Client
+
+	pending []event
+}
+
+// report is the body of one agent report
+type report struct {
+	Protocol int     `json:"protocol"`
+	NodeID   string  `json:"node_id"`
+	Agent    string  `json:"agent"`
+	Events   []event `json:"events"`
+}
+
+// rejection is the server's answer to a batch it won't take. Events lists the invalid
+// events by index, when the batch itself was fine.
+type rejection struct {
+	Status int    `json:"-"`
+	Err    string `json:"error"`
+	Events []struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	} `json:"events"`
+}
+
+func (r *rejection) Error() string {
+	return fmt.Sprintf("%d: %s", r.Status, r.Err)
+}
+
+// Run collects events and sends them every interval, or sooner once a full batch is
+// waiting. Whatever is left is sent once more when ctx is done.
+func (r *reporter) Run(ctx context.Context, events <-chan event) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-events:
+			r.pending = append(r.pending, e)
+			if len(r.pending) > maxPending {
+				log.Printf("Dropping %d unsent events; the server has been unreachable too long", len(r.pending)-maxPending)
+				r.pending = r.pending[len(r.pending)-maxPending:]
+			}
+			if len(r.pending) >= maxBatch {
+				r.flush(ctx)
+			}
+		case <-ticker.C:
+			r.flush(ctx)
+		case <-ctx.Done():
+			// The last attempt gets a deadline of its own, since ctx is already done
+			final, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			r.flush(final)
+			cancel()
+			return
+		}
+	}
+}
+
+// flush sends pending events a batch at a time. Events are kept for the next flush if the
+// server can't be reached; events the server rejects are logged and dropped, since
+// sending them again won't help.
+func (r *reporter) flush(ctx context.Context) {
+	for len(r.pending) > 0 {
+		n := len(r.pending)
+		if n > maxBatch {
+			n = maxBatch
+		}
+
+		err := r.send(ctx, r.pending[:n])
+		rejected, ok := err.(*rejection)
+		switch {
+		case err == nil:
+			r.pending = r.pending[n:]
+		case ok && rejected.Status == http.StatusBadRequest && r.dropInvalid(n, rejected) > 0:
+			// Only the invalid events were dropped; the rest go again
+		case ok && rejected.Status == http.StatusBadRequest:
+			log.Printf("Dropped %d events the server rejected: %v", n, err)
+			r.pending = r.pending[n:]
+		default:
+			log.Printf("Failed to send %d events, will retry: %v", n, err)
+			return
+		}
+	}
+}
+
+// dropInvalid removes the events a rejection lists from the first n pending ones and
+// returns how many it removed
+func (r *reporter) dropInvalid(n int, rejected *rejection) int {
+	// Highest index first, so removing one doesn't shift the ones still to remove
+	sort.Slice(rejected.Events, func(i, j int) bool { return rejected.Events[i].Index > rejected.Events[j].Index })
+	dropped := 0
+	for _, invalid := range rejected.Events {
+		if invalid.Index < 0 || invalid.Index >= n {
+			continue
+		}
+		e := r.pending[invalid.Index]
+		log.Printf("Dropped event %s %s: %s", e.Action, e.Image, invalid.Error)
+		r.pending = append(r.pending[:invalid.Index], r.pending[invalid.Index+1:]...)
+		dropped++
+	}
+	return dropped
+}
+
+// send posts one batch, signed with the HMAC-SHA256 of "<timestamp>.<body>"
+func (r *reporter) send(ctx context.Context, events []event) error {
+	body, err := json.Marshal(report{Protocol: protocolVersion, NodeID: r.node, Agent: agentName, Events: events})
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(r.secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", agentName)
+	req.Header.Set("X-Ingest-Key", r.keyID)
+	req.Header.Set("X-Ingest-Timestamp", timestamp)
+	req.Header.Set("X-Ingest-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	payload, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode != http.StatusOK {
+		rejected := &rejection{Status: resp.StatusCode}
+		if json.Unmarshal(payload, rejected) != nil || rejected.Err == "" {
+			rejected.Err = http.StatusText(resp.StatusCode)
+		}
+		return rejected
+	}
+
+	var result struct {
+		Accepted   int `json:"accepted"`
+		Duplicates int `json:"duplicates"`
+	}
+	if err := json.Unmarshal(payload, &result); err == nil {
+		log.Printf("Reported %d events (%d already recorded)", result.Accepted, result.Duplicates)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/utils"
+)
+
+const archiveUsage = `usage: main archive <command>
+
+commands:
+  run                                 archive activity older than the retention window now
+  list [username]                     list archived months per account
+  restore <username> <from> [<to>]    move archived months (YYYY-MM) back into activity`
+
+// runArchive handles the archive subcommand, which moves old activity in and out of the
+// compressed archive without starting the server
+func runArchive(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, archiveUsage)
+		os.Exit(2)
+	}
+
+	config.Load()
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	switch args[0] {
+	case "run":
+		cutoff := time.Now().AddDate(0, 0, -services.ActivityRetentionDays())
+		archived, err := services.ArchiveActivity(cutoff)
+		if err != nil {
+			log.Fatalf("Archiving failed after archiving %d events: %v", archived, err)
+		}
+		log.Printf("Archived %d events dated before %s", archived, cutoff.Format("2006-01-02"))
+
+	case "list":
+		var accountID uint
+		if len(args) > 1 {
+			accountID = archiveAccount(args[1]).ID
+		}
+		months, err := services.ListArchivedActivity(accountID)
+		if err != nil {
+			log.Fatalf("Failed to list archives: %v", err)
+		}
+		for _, m := range months {
+			fmt.Printf("account %-6d  %s  %7d events  %3d chunks\n", m.DockerAccountID, m.Month.Format("2006-01"), m.Events, m.Chunks)
+		}
+
+	case "restore":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, archiveUsage)
+			os.Exit(2)
+		}
+		account := archiveAccount(args[1])
+		from := parseArchiveMonth(args[2])
+		to := from
+		if len(args) > 3 {
+			to = parseArchiveMonth(args[3])
+		}
+		hub := services.NewDockerHubAPI(config.AppConfig.DockerHubAPIURL, utils.HTTPClient)
+		restored, err := services.NewDockerHubService(hub, services.NewDatabaseActivityRepository(), services.SystemClock).RestoreActivity(account.ID, from, to)
+		if err != nil {
+			log.Fatalf("Restore failed: %v", err)
+		}
+		log.Printf("Restored %d events for %s", restored, account.DockerUsername)
+
+	default:
+		fmt.Fprintln(os.Stderr, archiveUsage)
+		os.Exit(2)
+	}
+}
+
+func archiveAccount(username string) models.DockerAccount {
+	var account models.DockerAccount
+	if err := database.DB.Where("docker_username = ?", username).First(&account).Error; err != nil {
+		log.Fatalf("No account with Docker username %q", username)
+	}
+	return account
+}
+
+func parseArchiveMonth(value string) time.Time {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		log.Fatalf("Expected a month as YYYY-MM, got %q", value)
+	}
+	return month
+}
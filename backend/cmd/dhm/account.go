@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sagargujarathi/docker-heatmap/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *cli) connectCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "connect <docker-username>",
+		Short: "Connect a Docker Hub account and start its first sync",
+		Long: `Connect a Docker Hub account and start its first sync. The access token is read
+from DOCKER_HUB_TOKEN or stdin; a read-only personal access token is enough.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.authedClient()
+			if err != nil {
+				return err
+			}
+
+			accessToken := os.Getenv("DOCKER_HUB_TOKEN")
+			if accessToken == "" {
+				fmt.Fprintln(os.Stderr, "Paste a Docker Hub access token:")
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("reading the access token: %w", err)
+				}
+				accessToken = strings.TrimSpace(line)
+			}
+			if accessToken == "" {
+				return errors.New("no access token given")
+			}
+
+			account, err := c.ConnectDocker(cmd.Context(), args[0], accessToken)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Connected %s; the first sync has started\n", account.DockerUsername)
+			return nil
+		},
+	}
+}
+
+func (a *cli) statusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the connected account and its last sync",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.authedClient()
+			if err != nil {
+				return err
+			}
+			account, err := c.GetDockerAccount(cmd.Context())
+			if client.IsNotFound(err) {
+				return errors.New("no Docker account connected; run dhm connect")
+			}
+			if err != nil {
+				return err
+			}
+			printAccount(account)
+			return nil
+		},
+	}
+}
+
+func (a *cli) syncCommand() *cobra.Command {
+	var wait bool
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync the connected account now",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.authedClient()
+			if err != nil {
+				return err
+			}
+
+			err = c.TriggerSync(cmd.Context())
+			var apiErr *client.APIError
+			switch {
+			case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+				fmt.Println("A sync is already running")
+			case err != nil:
+				return err
+			default:
+				fmt.Println("Sync queued")
+			}
+			if !wait {
+				return nil
+			}
+
+			// Poll until the sync is done, then report how it went
+			for {
+				select {
+				case <-cmd.Context().Done():
+					return cmd.Context().Err()
+				case <-time.After(3 * time.Second):
+				}
+				account, err := c.GetDockerAccount(cmd.Context())
+				if err != nil {
+					return err
+				}
+				if account.SyncInProgress {
+					continue
+				}
+				printAccount(account)
+				if account.LastSyncError != "" {
+					return errors.New("sync failed")
+				}
+				return nil
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the sync to finish")
+	return cmd
+}
+
+// printAccount prints an account's sync state
+func printAccount(account *client.DockerAccount) {
+	fmt.Printf("Account:    %s\n", account.DockerUsername)
+	switch {
+	case account.SyncInProgress:
+		fmt.Println("Sync:       running")
+	case account.LastSyncAt != nil:
+		fmt.Printf("Last sync:  %s\n", account.LastSyncAt.Local().Format(time.RFC1123))
+	default:
+		fmt.Println("Last sync:  never")
+	}
+	if account.LastSyncError != "" {
+		fmt.Printf("Sync error: %s\n", account.LastSyncError)
+	}
+	if account.DisconnectScheduledAt != nil {
+		fmt.Printf("Disconnects %s\n", account.DisconnectScheduledAt.Local().Format(time.RFC1123))
+	}
+}
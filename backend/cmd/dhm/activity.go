@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sagargujarathi/docker-heatmap/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// activityFlags are the activity filters the activity and heatmap commands share
+type activityFlags struct {
+	days     int
+	timezone string
+	events   []string
+	tags     []string
+}
+
+func (f *activityFlags) register(cmd *cobra.Command, defaultDays int) {
+	cmd.Flags().IntVar(&f.days, "days", defaultDays, "trailing window in days (0 for the deployment's default)")
+	cmd.Flags().StringVar(&f.timezone, "tz", "", "IANA timezone for day bucketing (default: the account's)")
+	cmd.Flags().StringSliceVar(&f.events, "events", nil, "only count these event types: push, pull, build")
+	cmd.Flags().StringSliceVar(&f.tags, "tag", nil, "only count tags matching these glob patterns")
+}
+
+func (f *activityFlags) options() *client.ActivityOptions {
+	return &client.ActivityOptions{Days: f.days, Timezone: f.timezone, EventTypes: f.events, Tags: f.tags}
+}
+
+func (a *cli) activityCommand() *cobra.Command {
+	var flags activityFlags
+	var granularity string
+	var breakdown bool
+	cmd := &cobra.Command{
+		Use:   "activity [docker-username]",
+		Short: "Print activity as JSON",
+		Long: `Print an account's activity as JSON, as the API returns it. Without a username it
+prints the connected account's.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			username, err := a.username(cmd.Context(), args)
+			if err != nil {
+				return err
+			}
+			opts := flags.options()
+			opts.Granularity = granularity
+			opts.RepoBreakdown = breakdown
+			activity, err := a.client().GetActivity(cmd.Context(), username, opts)
+			if err != nil {
+				return err
+			}
+
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(activity)
+		},
+	}
+	flags.register(cmd, 0)
+	cmd.Flags().StringVar(&granularity, "granularity", "", "roll days up into week or month buckets")
+	cmd.Flags().BoolVar(&breakdown, "breakdown", false, "include per-repository counts")
+	return cmd
+}
+
+func (a *cli) heatmapCommand() *cobra.Command {
+	var flags activityFlags
+	var noColor, monday bool
+	cmd := &cobra.Command{
+		Use:   "heatmap [docker-username]",
+		Short: "Draw the heatmap in the terminal",
+		Long: `Draw an account's heatmap in the terminal from its activity. Without a username it
+draws the connected account's. Colors are left out when NO_COLOR is set or with
+--no-color, using shading instead.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			username, err := a.username(cmd.Context(), args)
+			if err != nil {
+				return err
+			}
+			activity, err := a.client().GetActivity(cmd.Context(), username, flags.options())
+			if err != nil {
+				return err
+			}
+
+			firstDay := time.Sunday
+			if monday {
+				firstDay = time.Monday
+			}
+			fmt.Print(renderHeatmap(activity, firstDay, noColor || os.Getenv("NO_COLOR") != ""))
+			return nil
+		},
+	}
+	flags.register(cmd, 365)
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "shade cells instead of coloring them")
+	cmd.Flags().BoolVar(&monday, "monday", false, "start weeks on Monday")
+	return cmd
+}
+
+// username is the username argument, or the connected account's when there is none
+func (a *cli) username(ctx context.Context, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if a.token() == "" {
+		return "", errors.New("give a Docker username, or run dhm login to use your own")
+	}
+	account, err := a.client().GetDockerAccount(ctx)
+	if client.IsNotFound(err) {
+		return "", errors.New("no Docker account connected; give a Docker username")
+	}
+	if err != nil {
+		return "", err
+	}
+	return account.DockerUsername, nil
+}
+
+var (
+	// heatmapColors are the GitHub theme's level colors
+	heatmapColors = [][3]int{{22, 27, 34}, {14, 68, 41}, {0, 109, 50}, {38, 166, 65}, {57, 211, 83}}
+	// heatmapBlocks are used per level without colors
+	heatmapBlocks = []string{"·", "░", "▒", "▓", "█"}
+)
+
+// renderHeatmap lays out daily activity as text, one row per weekday, like the
+// deployment's .txt heatmap
+func renderHeatmap(activity *client.Activity, firstDay time.Weekday, noColor bool) string {
+	loc, err := time.LoadLocation(activity.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -activity.Days+1)
+
+	levels := make(map[string]int, len(activity.Activity))
+	for _, day := range activity.Activity {
+		if day.Level >= 0 && day.Level < len(heatmapBlocks) {
+			levels[day.Date] = day.Level
+		}
+	}
+
+	cell := func(level int) string {
+		if noColor {
+			return heatmapBlocks[level]
+		}
+		c := heatmapColors[level]
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm■\x1b[0m", c[0], c[1], c[2])
+	}
+
+	gridStart := start
+	for gridStart.Weekday() != firstDay {
+		gridStart = gridStart.AddDate(0, 0, -1)
+	}
+	numWeeks := int(today.Sub(gridStart).Hours()/24)/7 + 1
+
+	var b strings.Builder
+
+	// Month labels; each cell is two columns wide
+	header := []byte(strings.Repeat(" ", 4+numWeeks*2))
+	lastMonth := time.Month(0)
+	lastEnd := 0
+	for w := 0; w < numWeeks; w++ {
+		weekStart := gridStart.AddDate(0, 0, w*7)
+		if weekStart.Before(start) {
+			weekStart = start
+		}
+		col := 4 + w*2
+		if weekStart.Month() != lastMonth && col >= lastEnd && col+3 <= len(header) {
+			copy(header[col:], weekStart.Format("Jan"))
+			lastMonth = weekStart.Month()
+			lastEnd = col + 4
+		}
+	}
+	b.WriteString(strings.TrimRight(string(header), " ") + "\n")
+
+	for row := 0; row < 7; row++ {
+		weekday := time.Weekday((int(firstDay) + row) % 7)
+		label := "   "
+		if weekday == time.Monday || weekday == time.Wednesday || weekday == time.Friday {
+			label = weekday.String()[:3]
+		}
+		b.WriteString(label + " ")
+		for w := 0; w < numWeeks; w++ {
+			date := gridStart.AddDate(0, 0, w*7+row)
+			if date.Before(start) || date.After(today) {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(cell(levels[date.Format("2006-01-02")]) + " ")
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\n@%s • %d activities in %d days    Less ", activity.Username, activity.Totals.Activities, activity.Days)
+	for level := range heatmapBlocks {
+		b.WriteString(cell(level) + " ")
+	}
+	b.WriteString("More\n")
+	return b.String()
+}
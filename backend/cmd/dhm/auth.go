@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sagargujarathi/docker-heatmap/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *cli) loginCommand() *cobra.Command {
+	var token string
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to the deployment",
+		Long: `Log in to the deployment. dhm shows a code to enter on the website, which works
+from any browser, even on another device, and waits until you approve it.
+
+With --token, dhm saves a token you already have instead; --token - reads it from
+stdin, so it can be piped in without showing up in the shell history.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if token == "" {
+				return a.deviceLogin(cmd)
+			}
+			if token == "-" {
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("reading the token: %w", err)
+				}
+				token = line
+			}
+			token = strings.TrimSpace(token)
+			if token == "" {
+				return fmt.Errorf("no token given")
+			}
+			return a.saveLogin(cmd, &client.Tokens{AccessToken: token})
+		},
+	}
+	cmd.Flags().StringVar(&token, "token", "", "save this token instead of logging in with a code (- reads it from stdin)")
+	return cmd
+}
+
+// deviceLogin logs in with the device flow: the user approves the login on the website
+func (a *cli) deviceLogin(cmd *cobra.Command) error {
+	c := client.New(a.url(), client.WithUserAgent(userAgent))
+	login, err := c.StartDeviceLogin(cmd.Context(), "dhm on "+hostname())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("To log in, open %s and enter the code %s\n", login.VerificationURI, login.UserCode)
+	fmt.Printf("Or open %s\n", login.VerificationURIComplete)
+	fmt.Println("Waiting for approval...")
+
+	tokens, err := c.WaitForDeviceLogin(cmd.Context(), login)
+	if err != nil {
+		return err
+	}
+	return a.saveLogin(cmd, tokens)
+}
+
+// saveLogin checks the tokens against the deployment and saves them with it
+func (a *cli) saveLogin(cmd *cobra.Command, tokens *client.Tokens) error {
+	c := client.New(a.url(), client.WithToken(tokens.AccessToken), client.WithUserAgent(userAgent))
+	user, err := c.GetCurrentUser(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("checking the token: %w", err)
+	}
+
+	a.config.URL, a.config.Token, a.config.RefreshToken = a.url(), tokens.AccessToken, tokens.RefreshToken
+	if err := a.config.save(); err != nil {
+		return err
+	}
+	fmt.Printf("Logged in to %s as %s\n", a.config.URL, user.GitHubUsername)
+	return nil
+}
+
+func (a *cli) logoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "End the session and forget the saved login",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Forget the login even if the server can't be told, such as when offline
+			if a.config.Token != "" {
+				if err := a.client().Logout(cmd.Context()); err != nil {
+					fmt.Fprintln(os.Stderr, "dhm: ending the session on the server:", err)
+				}
+			}
+			a.config.Token, a.config.RefreshToken = "", ""
+			if err := a.config.save(); err != nil {
+				return err
+			}
+			fmt.Println("Logged out")
+			return nil
+		},
+	}
+}
+
+func (a *cli) whoamiCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "whoami",
+		Short: "Show who the token belongs to",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.authedClient()
+			if err != nil {
+				return err
+			}
+			user, err := c.GetCurrentUser(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s on %s\n", user.GitHubUsername, a.url())
+			return nil
+		},
+	}
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown host"
+	}
+	return name
+}
@@ -0,0 +1,116 @@
+// Command dhm is a command-line client for Docker Heatmap. It can log in, connect a
+// Docker Hub account, start a sync, print activity as JSON and draw the heatmap in the
+// terminal, for scripts and for machines without a browser.
+//
+//	dhm login
+//	dhm connect alice
+//	dhm sync --wait
+//	dhm heatmap alice --days 180
+//
+// The deployment and token are kept in dhm/config.json under the user's config
+// directory. DHM_URL and DHM_TOKEN override them.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sagargujarathi/docker-heatmap/backend/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// userAgent identifies the CLI in requests
+const userAgent = "dhm/1"
+
+// cli holds what every command shares: the saved config and the --url override
+type cli struct {
+	config  *config
+	baseURL string
+}
+
+func main() {
+	app := &cli{}
+
+	root := &cobra.Command{
+		Use:           "dhm",
+		Short:         "Docker Heatmap from the command line",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			app.config = cfg
+			return nil
+		},
+	}
+	root.PersistentFlags().StringVar(&app.baseURL, "url", "", "Docker Heatmap deployment (default: the saved one, DHM_URL or "+client.DefaultBaseURL+")")
+
+	root.AddCommand(
+		app.loginCommand(),
+		app.logoutCommand(),
+		app.whoamiCommand(),
+		app.connectCommand(),
+		app.statusCommand(),
+		app.syncCommand(),
+		app.activityCommand(),
+		app.heatmapCommand(),
+	)
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "dhm:", err)
+		os.Exit(1)
+	}
+}
+
+// url is the deployment to talk to: --url, then DHM_URL, then the saved one
+func (a *cli) url() string {
+	if a.baseURL != "" {
+		return a.baseURL
+	}
+	if env := os.Getenv("DHM_URL"); env != "" {
+		return env
+	}
+	if a.config.URL != "" {
+		return a.config.URL
+	}
+	return client.DefaultBaseURL
+}
+
+// token is DHM_TOKEN or the saved token, if it was saved for this deployment
+func (a *cli) token() string {
+	if env := os.Getenv("DHM_TOKEN"); env != "" {
+		return env
+	}
+	if a.config.URL == a.url() {
+		return a.config.Token
+	}
+	return ""
+}
+
+// client returns an API client for the deployment, with the token when there is one.
+// A saved login is refreshed as it expires, and the rotated tokens saved.
+func (a *cli) client() *client.Client {
+	opts := []client.Option{client.WithUserAgent(userAgent)}
+	if token := a.token(); token != "" {
+		opts = append(opts, client.WithToken(token))
+		if token == a.config.Token && a.config.RefreshToken != "" {
+			opts = append(opts, client.WithRefreshToken(a.config.RefreshToken, func(accessToken, refreshToken string) {
+				a.config.Token, a.config.RefreshToken = accessToken, refreshToken
+				if err := a.config.save(); err != nil {
+					fmt.Fprintln(os.Stderr, "dhm: saving the refreshed login:", err)
+				}
+			}))
+		}
+	}
+	return client.New(a.url(), opts...)
+}
+
+// authedClient is client for commands that need a login
+func (a *cli) authedClient() (*client.Client, error) {
+	if a.token() == "" {
+		return nil, fmt.Errorf("not logged in to %s; run dhm login", a.url())
+	}
+	return a.client(), nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/utils"
+)
+
+const keysUsage = `usage: main keys <command>
+
+commands:
+  status    count stored secrets by the encryption key protecting them
+  rotate    re-encrypt every secret not under the primary key (ENCRYPTION_KEY_ID)`
+
+// runKeys handles the keys subcommand, which re-encrypts stored secrets after the
+// primary encryption key changes
+func runKeys(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		os.Exit(2)
+	}
+
+	config.Load()
+	if err := utils.LoadKeys(); err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	switch args[0] {
+	case "status":
+		primary, _ := utils.PrimaryKeyID()
+		counts, err := services.CountSecretsByKey()
+		if err != nil {
+			log.Fatalf("Failed to count secrets: %v", err)
+		}
+		ids := make([]string, 0, len(counts))
+		for id := range counts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			marker := ""
+			if id == primary {
+				marker = "  (primary)"
+			}
+			fmt.Printf("%-32s %7d secrets%s\n", id, counts[id], marker)
+		}
+
+	case "rotate":
+		rewritten, err := services.ReencryptSecrets()
+		if err != nil {
+			log.Fatalf("Re-encryption failed after rewriting %d secrets: %v", rewritten, err)
+		}
+		log.Printf("Re-encrypted %d secrets", rewritten)
+
+	default:
+		fmt.Fprintln(os.Stderr, keysUsage)
+		os.Exit(2)
+	}
+}
@@ -1,42 +1,96 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"docker-heatmap/internal/cache"
 	"docker-heatmap/internal/config"
 	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/logging"
+	"docker-heatmap/internal/reporting"
 	"docker-heatmap/internal/router"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/storage"
+	"docker-heatmap/internal/utils"
 	"docker-heatmap/internal/worker"
 )
 
 func main() {
+	// Maintenance commands run instead of the server: main migrate|archive|keys <command>
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "archive":
+			runArchive(os.Args[2:])
+			return
+		case "keys":
+			runKeys(os.Args[2:])
+			return
+		}
+	}
+
 	// Load configuration
 	config.Load()
-	log.Println("Configuration loaded")
+	slog.Info("Configuration loaded")
+
+	// Report errors to Sentry (optional: they're only logged without it)
+	if err := reporting.Init(config.AppConfig.SentryDSN, config.AppConfig.Environment); err != nil {
+		slog.Warn("Error reporting disabled", "error", err)
+	}
+	defer reporting.Flush(2 * time.Second)
+
+	// Load encryption keys now, so a missing or malformed key is caught at startup
+	if err := utils.LoadKeys(); err != nil {
+		if config.AppConfig.Environment == "production" {
+			logging.Fatal("Failed to load encryption keys", "error", err)
+		}
+		slog.Warn("Stored secrets can't be encrypted or decrypted", "error", err)
+	}
 
 	// Connect to database
 	if err := database.Connect(); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		logging.Fatal("Failed to connect to database", "error", err)
 	}
 	defer database.Close()
-	log.Println("Database connected")
+	slog.Info("Database connected")
 
 	// Run migrations
 	if err := database.Migrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		logging.Fatal("Failed to run migrations", "error", err)
+	}
+	slog.Info("Database migrations completed")
+
+	// Connect to Redis (optional: renders are just uncached without it)
+	if err := cache.Connect(); err != nil {
+		slog.Warn("Render cache disabled, failed to connect to Redis", "error", err)
 	}
-	log.Println("Database migrations completed")
+	defer cache.Close()
+
+	// Connect to standby storage (optional: renders aren't mirrored without it)
+	if err := storage.Connect(); err != nil {
+		slog.Warn("Warm standby disabled, failed to connect to object storage", "error", err)
+	}
+
+	// Build the services the worker and handlers share
+	svc := services.NewServices(
+		services.NewDockerHubAPI(config.AppConfig.DockerHubAPIURL, utils.HTTPClient),
+		services.NewDatabaseActivityRepository(),
+		services.SystemClock,
+	)
 
 	// Start background worker
-	syncWorker := worker.NewSyncWorker()
+	syncWorker := worker.NewSyncWorker(svc)
 	syncWorker.Start()
 	defer syncWorker.Stop()
 
 	// Setup router
-	app := router.SetupRouter()
+	app := router.SetupRouter(svc, syncWorker)
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -44,16 +98,16 @@ func main() {
 
 	go func() {
 		<-quit
-		log.Println("Shutting down server...")
+		slog.Info("Shutting down server")
 		if err := app.Shutdown(); err != nil {
-			log.Printf("Error shutting down server: %v", err)
+			slog.Error("Error shutting down server", "error", err)
 		}
 	}()
 
 	// Start server
 	port := config.AppConfig.Port
-	log.Printf("Starting server on port %s", port)
+	slog.Info("Starting server", "port", port)
 	if err := app.Listen(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		logging.Fatal("Failed to start server", "error", err)
 	}
 }
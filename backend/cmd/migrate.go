@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/migrations"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up        apply all pending migrations
+  down [n]  revert the last n migrations (default 1)
+  status    list migrations and when each was applied
+  version   print the current and expected schema versions`
+
+// runMigrate handles the migrate subcommand, which manages the schema without starting
+// the server
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	config.Load()
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(database.DB)
+		if err != nil {
+			log.Fatalf("Migration failed after applying %d: %v", applied, err)
+		}
+		log.Printf("Applied %d migrations", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("down expects a positive number of steps, got %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(database.DB, steps)
+		if err != nil {
+			log.Fatalf("Revert failed after reverting %d: %v", reverted, err)
+		}
+		log.Printf("Reverted %d migrations", reverted)
+
+	case "status":
+		statuses, err := migrations.Status(database.DB)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d  %-32s  %s\n", s.Version, s.Name, applied)
+		}
+
+	case "version":
+		current, err := migrations.Current(database.DB)
+		if err != nil {
+			log.Fatalf("Failed to read schema version: %v", err)
+		}
+		latest, err := migrations.Latest(database.DB)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		fmt.Printf("current: %d\nexpected: %d\n", current, latest)
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,62 @@
+// Command seed generates synthetic users and activity for staging and load testing.
+//
+//	go run ./cmd/seed -users 200 -days 365
+//	go run ./cmd/seed -purge
+package main
+
+import (
+	"flag"
+	"log"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/services"
+)
+
+func main() {
+	users := flag.Int("users", 10, "number of synthetic users to create")
+	days := flag.Int("days", 365, "days of activity history per user")
+	prefix := flag.String("prefix", "seed", "username prefix for generated accounts")
+	seed := flag.Int64("seed", 0, "random seed for reproducible data (0 = random)")
+	purge := flag.Bool("purge", false, "remove all previously seeded users instead of creating new ones")
+	force := flag.Bool("force", false, "allow running against a production environment")
+	flag.Parse()
+
+	config.Load()
+
+	if config.AppConfig.Environment == "production" && !*force {
+		log.Fatal("Refusing to seed a production database (pass -force to override)")
+	}
+
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.Migrate(); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	seedService := services.NewSeedService()
+
+	if *purge {
+		removed, err := seedService.Purge()
+		if err != nil {
+			log.Fatalf("Failed to purge seeded data: %v", err)
+		}
+		log.Printf("Removed %d seeded users", removed)
+		return
+	}
+
+	result, err := seedService.Seed(services.SeedOptions{
+		Users:  *users,
+		Days:   *days,
+		Prefix: *prefix,
+		Seed:   *seed,
+	})
+	if err != nil {
+		log.Fatalf("Seeding failed after %d users: %v", result.Users, err)
+	}
+
+	log.Printf("Seeded %d users with %d activity events", result.Users, result.Events)
+}
@@ -0,0 +1,31 @@
+// Package apidocs holds the OpenAPI description of the API. The spec is maintained by hand
+// alongside the handlers, so update it whenever a route, parameter or response changes.
+package apidocs
+
+import _ "embed"
+
+// Spec is the OpenAPI 3 document describing /api/v1
+//
+//go:embed openapi.json
+var Spec []byte
+
+// SwaggerUI is a page that renders the spec served at specURL with Swagger UI
+func SwaggerUI(specURL string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>Docker Heatmap API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "` + specURL + `", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Client is nil when REDIS_URL is not set, which disables caching
+var Client *redis.Client
+
+func Connect() error {
+	if config.AppConfig.RedisURL == "" {
+		slog.Info("REDIS_URL not set, render cache disabled")
+		return nil
+	}
+
+	opts, err := redis.ParseURL(config.AppConfig.RedisURL)
+	if err != nil {
+		return err
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return err
+	}
+
+	Client = client
+	slog.Info("Redis connected")
+	return nil
+}
+
+// Enabled reports whether a Redis connection is available
+func Enabled() bool {
+	return Client != nil
+}
+
+func Close() error {
+	if Client == nil {
+		return nil
+	}
+	return Client.Close()
+}
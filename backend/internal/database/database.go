@@ -1,14 +1,14 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"docker-heatmap/internal/config"
-	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/migrations"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -18,13 +18,29 @@ var DB *gorm.DB
 func Connect() error {
 	var err error
 
+	// Slow queries are logged in every environment so N+1 patterns show up in production;
+	// development logs every query
+	slowThreshold := time.Duration(config.AppConfig.DBSlowQueryMillis) * time.Millisecond
 	logLevel := logger.Silent
+	if slowThreshold > 0 {
+		logLevel = logger.Warn
+	}
 	if config.AppConfig.Environment == "development" {
 		logLevel = logger.Info
 	}
 
-	DB, err = gorm.Open(postgres.Open(config.AppConfig.DatabaseURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+	dialector, err := openDialector(config.AppConfig.DatabaseURL)
+	if err != nil {
+		return err
+	}
+
+	DB, err = gorm.Open(dialector, &gorm.Config{
+		// Each query log line is one record of the structured log
+		Logger: logger.New(slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn), logger.Config{
+			SlowThreshold:             slowThreshold,
+			LogLevel:                  logLevel,
+			IgnoreRecordNotFoundError: true,
+		}),
 	})
 	if err != nil {
 		return err
@@ -37,29 +53,38 @@ func Connect() error {
 	}
 
 	// Connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(config.AppConfig.DBMaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.AppConfig.DBMaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.AppConfig.DBConnMaxLifetime)
 
-	log.Println("Database connected successfully")
+	slog.Info("Database connected")
 	return nil
 }
 
+// Migrate brings the schema up to date when AUTO_MIGRATE is on, then checks it is at
+// the version this build expects
 func Migrate() error {
-	log.Println("Running database migrations...")
+	slog.Info("Running database migrations")
 
-	// Drop existing tables if they have wrong schema (development only)
-	if config.AppConfig.Environment == "development" {
+	// Drop existing tables if they have wrong schema (development only; these schemas
+	// predate MySQL support)
+	if config.AppConfig.Environment == "development" && !IsMySQL() {
 		if err := fixSchemaIfNeeded(); err != nil {
-			log.Printf("Schema fix warning: %v", err)
+			slog.Warn("Schema fix failed", "error", err)
 		}
 	}
 
-	return DB.AutoMigrate(
-		&models.User{},
-		&models.DockerAccount{},
-		&models.ActivityEvent{},
-	)
+	if config.AppConfig.AutoMigrate {
+		applied, err := migrations.Up(DB)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			slog.Info("Applied schema migrations", "count", applied)
+		}
+	}
+
+	return migrations.Verify(DB)
 }
 
 // fixSchemaIfNeeded checks for column naming issues and fixes them
@@ -76,7 +101,7 @@ func fixSchemaIfNeeded() error {
 	}
 
 	if count > 0 {
-		log.Println("Fixing schema: renaming columns with wrong naming convention...")
+		slog.Info("Fixing schema: renaming columns with wrong naming convention")
 
 		// Rename columns in users table
 		migrations := []string{
@@ -87,7 +112,7 @@ func fixSchemaIfNeeded() error {
 
 		for _, migration := range migrations {
 			if err := DB.Exec(migration).Error; err != nil {
-				log.Printf("Migration warning (may already be correct): %v", err)
+				slog.Warn("Column rename failed, it may already be correct", "error", err)
 			}
 		}
 
@@ -95,7 +120,7 @@ func fixSchemaIfNeeded() error {
 		DB.Exec(`DROP INDEX IF EXISTS idx_users_git_hub_id`)
 		DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_github_id ON users(github_id)`)
 
-		log.Println("Schema fix completed")
+		slog.Info("Schema fix completed")
 	}
 
 	// Drop raw_data column from activity_events if it exists (causes JSON insert issues)
@@ -106,17 +131,28 @@ func fixSchemaIfNeeded() error {
 	`).Scan(&rawDataExists)
 
 	if rawDataExists > 0 {
-		log.Println("Dropping raw_data column from activity_events...")
+		slog.Info("Dropping raw_data column from activity_events")
 		if err := DB.Exec(`ALTER TABLE activity_events DROP COLUMN raw_data`).Error; err != nil {
-			log.Printf("Failed to drop raw_data column: %v", err)
+			slog.Error("Failed to drop raw_data column", "error", err)
 		} else {
-			log.Println("Dropped raw_data column successfully")
+			slog.Info("Dropped raw_data column")
 		}
 	}
 
 	return nil
 }
 
+// Available reports whether the database currently answers a ping
+func Available() bool {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return sqlDB.PingContext(ctx) == nil
+}
+
 func Close() error {
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -0,0 +1,312 @@
+package graphapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+)
+
+var (
+	errInvalidDays        = errors.New("days is out of range")
+	errInvalidGranularity = errors.New("granularity must be day, week or month")
+)
+
+// Limits mirror the REST repositories endpoint
+const (
+	maxRepositoryDays  = 365
+	maxRepositoryLimit = 100
+)
+
+type resolver struct {
+	dockerService *services.DockerHubService
+}
+
+func newResolver(dockerService *services.DockerHubService) *resolver {
+	return &resolver{dockerService: dockerService}
+}
+
+type usernameArgs struct {
+	Username string
+}
+
+func (r *resolver) DockerAccount(args usernameArgs) (*dockerAccountResolver, error) {
+	account, err := r.dockerService.GetDockerAccountByUsername(args.Username)
+	if err == services.ErrDockerAccountNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Private profiles are only shown through signed embeds and embed tokens, which GraphQL
+	// doesn't take
+	owner, err := services.GetUserByID(account.UserID)
+	if err == services.ErrUserNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !owner.PublicProfile {
+		return nil, nil
+	}
+	return &dockerAccountResolver{root: r, account: account}, nil
+}
+
+func (r *resolver) User(args usernameArgs) (*userResolver, error) {
+	account, err := r.DockerAccount(args)
+	if account == nil || err != nil {
+		return nil, err
+	}
+	return account.User()
+}
+
+type userResolver struct {
+	user    *models.User
+	account *dockerAccountResolver
+}
+
+func (u *userResolver) GithubUsername() string { return u.user.GitHubUsername }
+func (u *userResolver) Name() string           { return u.user.Name }
+func (u *userResolver) AvatarURL() string      { return u.user.AvatarURL }
+func (u *userResolver) Bio() string            { return u.user.Bio }
+func (u *userResolver) BioHTML() string        { return services.RenderMarkdown(u.user.Bio) }
+
+func (u *userResolver) DockerAccount() *dockerAccountResolver { return u.account }
+
+type dockerAccountResolver struct {
+	root    *resolver
+	account *models.DockerAccount
+}
+
+func (a *dockerAccountResolver) Username() string { return a.account.DockerUsername }
+
+func (a *dockerAccountResolver) LastSyncAt() *string {
+	if a.account.LastSyncAt == nil {
+		return nil
+	}
+	value := a.account.LastSyncAt.UTC().Format(time.RFC3339)
+	return &value
+}
+
+// User returns the account's owner, or null when their profile is private
+func (a *dockerAccountResolver) User() (*userResolver, error) {
+	user, err := services.GetUserByID(a.account.UserID)
+	if err == services.ErrUserNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.PublicProfile {
+		return nil, nil
+	}
+	return &userResolver{user: user, account: a}, nil
+}
+
+type rangeArgs struct {
+	Days *int32
+	From *string
+	To   *string
+	Tz   *string
+}
+
+// summaries loads the account's daily activity for a trailing window or explicit range,
+// returning it with the number of days covered and the location days were bucketed in
+func (a *dockerAccountResolver) summaries(args rangeArgs) ([]models.ActivitySummary, int, *time.Location, error) {
+	username := a.account.DockerUsername
+	days := config.AppConfig.Render.DefaultDays
+	if args.Days != nil {
+		days = int(*args.Days)
+		if !services.ValidDays(days) {
+			return nil, 0, nil, errInvalidDays
+		}
+	}
+
+	from, to, hasRange, err := services.ParseDateRange(deref(args.From), deref(args.To), time.Now())
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	loc := a.root.dockerService.ResolveLocation(username, deref(args.Tz))
+	filter := services.ActivityFilter{Location: loc}
+	var activities []models.ActivitySummary
+	if hasRange {
+		// Dates are calendar days in the resolved timezone, not UTC instants
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+		to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+		days = int(to.Sub(from).Hours()/24) + 1
+		activities, err = a.root.dockerService.GetActivitySummaryRange(username, from, to, filter)
+	} else {
+		activities, err = a.root.dockerService.GetActivitySummary(username, days, filter)
+	}
+	return activities, days, loc, err
+}
+
+type activityArgs struct {
+	rangeArgs
+	Granularity string
+	WeekStart   *string
+}
+
+func (a *dockerAccountResolver) Activity(args activityArgs) (*activityResolver, error) {
+	granularity := strings.ToLower(args.Granularity)
+	if !services.IsValidGranularity(granularity) {
+		return nil, errInvalidGranularity
+	}
+
+	activities, days, loc, err := a.summaries(args.rangeArgs)
+	if err != nil {
+		return nil, err
+	}
+	totals := summarize(activities)
+	if granularity != services.GranularityDay {
+		firstDay := a.root.dockerService.ResolveWeekStart(a.account.DockerUsername, deref(args.WeekStart))
+		activities = services.RollupActivity(activities, granularity, firstDay)
+	}
+
+	buckets := make([]*activityBucket, 0, len(activities))
+	for _, day := range activities {
+		buckets = append(buckets, &activityBucket{
+			Date:   day.Date,
+			Count:  int32(day.TotalCount),
+			Pushes: int32(day.Pushes),
+			Pulls:  int32(day.Pulls),
+			Builds: int32(day.Builds),
+			Level:  int32(day.Level),
+		})
+	}
+	return &activityResolver{
+		Days:        int32(days),
+		Granularity: granularity,
+		Timezone:    loc.String(),
+		Totals:      totals,
+		Buckets:     buckets,
+	}, nil
+}
+
+func (a *dockerAccountResolver) Stats(args rangeArgs) (*stats, error) {
+	activities, _, _, err := a.summaries(args)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(activities), nil
+}
+
+type repositoriesArgs struct {
+	Days  int32
+	Sort  string
+	Limit int32
+}
+
+func (a *dockerAccountResolver) Repositories(args repositoriesArgs) ([]*repository, error) {
+	if args.Days < 1 || args.Days > maxRepositoryDays {
+		return nil, errInvalidDays
+	}
+	limit := int(args.Limit)
+	if limit < 1 || limit > maxRepositoryLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxRepositoryLimit)
+	}
+	sortBy := strings.ToLower(args.Sort)
+	if !isRepoSort(sortBy) {
+		return nil, fmt.Errorf("sort must be one of %s", strings.Join(services.RepoSortOptions, ", "))
+	}
+
+	username := a.account.DockerUsername
+	repos, err := a.root.dockerService.GetRepositoryStats(username, int(args.Days), services.ActivityFilter{
+		Location: a.root.dockerService.ResolveLocation(username, ""),
+	}, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) > limit {
+		repos = repos[:limit]
+	}
+
+	result := make([]*repository, 0, len(repos))
+	for _, repo := range repos {
+		result = append(result, &repository{
+			Name:         repo.Repository,
+			Events:       int32(repo.Events),
+			LastActivity: repo.LastActivity.UTC().Format(time.RFC3339),
+			Heat:         repo.Heat,
+			RelativeHeat: repo.RelativeHeat,
+		})
+	}
+	return result, nil
+}
+
+type activityResolver struct {
+	Days        int32
+	Granularity string
+	Timezone    string
+	Totals      *stats
+	Buckets     []*activityBucket
+}
+
+type activityBucket struct {
+	Date   string
+	Count  int32
+	Pushes int32
+	Pulls  int32
+	Builds int32
+	Level  int32
+}
+
+type stats struct {
+	Activities int32
+	Pushes     int32
+	Pulls      int32
+	Builds     int32
+	ActiveDays int32
+	BusiestDay *string
+}
+
+type repository struct {
+	Name         string
+	Events       int32
+	LastActivity string
+	Heat         float64
+	RelativeHeat float64
+}
+
+// summarize totals daily summaries; the busiest day is the earliest of any ties
+func summarize(activities []models.ActivitySummary) *stats {
+	result := &stats{}
+	busiest := 0
+	for i, day := range activities {
+		result.Activities += int32(day.TotalCount)
+		result.Pushes += int32(day.Pushes)
+		result.Pulls += int32(day.Pulls)
+		result.Builds += int32(day.Builds)
+		if day.TotalCount > 0 {
+			result.ActiveDays++
+		}
+		if day.TotalCount > busiest {
+			busiest = day.TotalCount
+			result.BusiestDay = &activities[i].Date
+		}
+	}
+	return result
+}
+
+func isRepoSort(value string) bool {
+	for _, option := range services.RepoSortOptions {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,37 @@
+package graphapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+)
+
+func TestDockerAccountHidesPrivateProfiles(t *testing.T) {
+	testutil.OpenDB(t)
+	testutil.CreateAccount(t, "alice", true)
+	testutil.CreateAccount(t, "bob", false)
+
+	schema := NewSchema(services.NewDockerHubService(nil, nil, services.SystemClock))
+	tests := []struct {
+		username string
+		want     string
+	}{
+		{"alice", `{"dockerAccount":{"username":"alice"}}`},
+		{"bob", `{"dockerAccount":null}`},
+		{"carol", `{"dockerAccount":null}`},
+	}
+	for _, tt := range tests {
+		result := schema.Exec(context.Background(), `query($u: String!) { dockerAccount(username: $u) { username } }`,
+			"", map[string]interface{}{"u": tt.username})
+		if len(result.Errors) > 0 {
+			t.Fatalf("%s: %v", tt.username, result.Errors)
+		}
+		got, _ := json.Marshal(result.Data)
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.username, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package graphapi
+
+import (
+	"docker-heatmap/internal/services"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// maxQueryDepth bounds how deeply a query may nest, so a single request can't fan out
+// into an unbounded number of resolver calls
+const maxQueryDepth = 6
+
+const schemaString = `
+schema {
+	query: Query
+}
+
+type Query {
+	# A connected Docker Hub account by its username; null when none is connected or the
+	# owner's profile is private
+	dockerAccount(username: String!): DockerAccount
+	# The owner of a Docker Hub account; null when none is connected or the profile is private
+	user(username: String!): User
+}
+
+type User {
+	githubUsername: String!
+	name: String!
+	avatarUrl: String!
+	bio: String!
+	# Bio rendered from Markdown and sanitized, safe to inject
+	bioHtml: String!
+	dockerAccount: DockerAccount!
+}
+
+type DockerAccount {
+	username: String!
+	# RFC 3339; null until the first sync
+	lastSyncAt: String
+	# Null when the owner's profile is private
+	user: User
+	# Activity over the last days, or between from and to (YYYY-MM-DD, inclusive) when given
+	activity(days: Int, from: String, to: String, granularity: String = "day", tz: String, weekStart: String): Activity!
+	repositories(days: Int = 90, sort: String = "heat", limit: Int = 20): [Repository!]!
+	stats(days: Int, from: String, to: String, tz: String): Stats!
+}
+
+type Activity {
+	days: Int!
+	granularity: String!
+	timezone: String!
+	totals: Stats!
+	buckets: [ActivityBucket!]!
+}
+
+type ActivityBucket {
+	# First day of the bucket (YYYY-MM-DD)
+	date: String!
+	count: Int!
+	pushes: Int!
+	pulls: Int!
+	builds: Int!
+	level: Int!
+}
+
+type Stats {
+	activities: Int!
+	pushes: Int!
+	pulls: Int!
+	builds: Int!
+	activeDays: Int!
+	# Day with the most activity (YYYY-MM-DD); null when there was none
+	busiestDay: String
+}
+
+type Repository {
+	name: String!
+	events: Int!
+	# RFC 3339
+	lastActivity: String!
+	heat: Float!
+	# Heat scaled against the hottest repository (0-1)
+	relativeHeat: Float!
+}
+`
+
+// NewSchema parses the API schema and binds it to its resolvers
+func NewSchema(dockerService *services.DockerHubService) *graphql.Schema {
+	return graphql.MustParseSchema(schemaString, newResolver(dockerService),
+		graphql.UseFieldResolvers(), graphql.MaxDepth(maxQueryDepth))
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeleteAccount mails the user a link to confirm deleting their account. Nothing is deleted
+// until the link is followed.
+func (h *UserHandler) DeleteAccount(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	err := h.dockerService.RequestAccountDeletion(user)
+	if err == services.ErrAccountDeletionScheduled || err == services.ErrAccountDeletionNoEmail {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send confirmation link",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditAccountDeleteRequest, "", c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "A link to confirm deleting your account is on its way. Check your email.",
+	})
+}
+
+// ConfirmAccountDeletion schedules the account's deletion with the token from a
+// confirmation link. The token is what authorizes it, so the link works from any browser.
+// Body: {"token": "..."}
+func (h *UserHandler) ConfirmAccountDeletion(c *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	user, deleteAt, err := h.dockerService.ConfirmAccountDeletion(req.Token)
+	if err == services.ErrEmailTokenInvalid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err == services.ErrAccountDeletionScheduled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete account",
+		})
+	}
+
+	if deleteAt.IsZero() {
+		return c.JSON(fiber.Map{
+			"message": "Account deleted",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditAccountDeleteConfirm, "", c.IP(), c.Get("User-Agent"))
+	return c.JSON(fiber.Map{
+		"message":   "Account scheduled for deletion",
+		"delete_at": deleteAt,
+	})
+}
+
+// CancelAccountDeletion keeps the account while its deletion is still pending
+func (h *UserHandler) CancelAccountDeletion(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	err := h.dockerService.CancelAccountDeletion(user.ID)
+	if err == services.ErrNoAccountDeletion {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel account deletion",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditAccountDeleteCancel, "", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Account deletion cancelled",
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportActivity restores activity history from an export, or seeds it from a GitHub
+// contributions calendar, into the connected Docker account
+// Query params: format (csv, json or github; csv for a text/csv body and json otherwise
+// by default)
+// Body: the file to import
+func (h *UserHandler) ImportActivity(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+	if user.DeletionScheduledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Your account is scheduled to be deleted; cancel the deletion first",
+		})
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = services.ImportFormatJSON
+		if strings.HasPrefix(c.Get(fiber.HeaderContentType), "text/csv") {
+			format = services.ImportFormatCSV
+		}
+	}
+
+	result, err := h.dockerService.ImportActivity(user.ID, format, c.Body())
+	if err == services.ErrDockerAccountNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+	if invalid, ok := err.(*services.IngestValidationError); ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Import rejected: " + invalid.Error(),
+			"events": invalid.Events,
+		})
+	}
+	if _, ok := err.(*services.ImportParseError); ok || err == services.ErrImportFormat || err == services.ErrImportSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import activity",
+		})
+	}
+	if result.Imported > 0 {
+		h.auditService.Record(user.ID, models.AuditActivityImport, fmt.Sprintf("%d events", result.Imported), c.IP(), c.Get("User-Agent"))
+	}
+
+	return c.JSON(result)
+}
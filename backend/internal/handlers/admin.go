@@ -0,0 +1,589 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/logging"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/worker"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AdminHandler serves the operator endpoints, for admins
+type AdminHandler struct {
+	dockerService *services.DockerHubService
+	auditService  *services.AuditService
+	syncWorker    *worker.SyncWorker
+}
+
+func NewAdminHandler(svc *services.Services, syncWorker *worker.SyncWorker) *AdminHandler {
+	return &AdminHandler{
+		dockerService: svc.Docker,
+		auditService:  svc.Audit,
+		syncWorker:    syncWorker,
+	}
+}
+
+// ListJobs returns the background worker's cron jobs on the instance that answers: when
+// each runs next, and how its last run went and how long it took
+func (h *AdminHandler) ListJobs(c *fiber.Ctx) error {
+	hostname, _ := os.Hostname()
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"instance": hostname,
+		"jobs":     h.syncWorker.Jobs(),
+	})
+}
+
+// ListDeadLetteredSyncs returns the accounts that stopped being synced automatically after
+// too many failed syncs in a row, most recent first
+func (h *AdminHandler) ListDeadLetteredSyncs(c *fiber.Ctx) error {
+	entries, err := services.ListDeadLetteredSyncs()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load dead-lettered syncs",
+		})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"accounts": entries,
+	})
+}
+
+// RequeueDeadLetteredSync takes a Docker account out of the dead-letter state and queues a
+// sync of it
+func (h *AdminHandler) RequeueDeadLetteredSync(c *fiber.Ctx) error {
+	accountID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account id",
+		})
+	}
+
+	err = h.dockerService.RequeueDeadLetteredSync(uint(accountID))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Docker account not found",
+		})
+	case err == services.ErrSyncNotDeadLettered:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The account's syncs aren't dead-lettered",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to requeue sync",
+		})
+	}
+
+	logging.FromContext(c.UserContext()).Info("Admin requeued dead-lettered syncs",
+		"admin_user_id", middleware.GetUserFromContext(c).ID, "docker_account_id", accountID)
+	return c.JSON(fiber.Map{
+		"message": "Sync queued",
+	})
+}
+
+type DisableAccountRequest struct {
+	Reason string `json:"reason"`
+}
+
+type SetUserAdminRequest struct {
+	IsAdmin bool `json:"is_admin"`
+}
+
+type SetReadOnlyRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type BlockDockerUsernameRequest struct {
+	DockerUsername string `json:"docker_username"`
+	Reason         string `json:"reason"`
+}
+
+// GetStats returns totals across the instance: users, Docker accounts, events and accounts
+// by sync state; then day by day, signups, connected accounts, ingested events, API requests
+// and syncs; and the most embedded usernames
+// Query params:
+//   - days: trailing UTC days to break down (default 30, at most 365)
+func (h *AdminHandler) GetStats(c *fiber.Ctx) error {
+	days := services.DefaultInstanceStatsDays
+	if d := c.Query("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > services.MaxInstanceStatsDays {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("days must be between 1 and %d", services.MaxInstanceStatsDays),
+			})
+		}
+		days = parsed
+	}
+
+	stats, err := services.GetInstanceStats(time.Now(), days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load instance stats",
+		})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(stats)
+}
+
+// ListUsers returns users, newest first, with their Docker accounts and the state of
+// their syncs
+// Query params:
+//   - q: start of a username or email
+//   - suspended: "true" for only suspended users
+//   - limit: users per page (default 50, at most 200)
+//   - before: next_before from the previous page
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	before, limit, err := adminPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	users, err := services.ListAdminUsers(c.Query("q"), c.QueryBool("suspended"), before, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load users",
+		})
+	}
+
+	var nextBefore *uint
+	if len(users) == limit {
+		nextBefore = &users[len(users)-1].ID
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"users":       users,
+		"next_before": nextBefore,
+	})
+}
+
+// ListAccounts returns Docker accounts, newest first, with the state of their syncs
+// Query params:
+//   - filter: failing, dead_lettered, disabled or syncing (default all)
+//   - limit: accounts per page (default 50, at most 200)
+//   - before: next_before from the previous page
+func (h *AdminHandler) ListAccounts(c *fiber.Ctx) error {
+	before, limit, err := adminPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	accounts, err := services.ListAdminAccounts(c.Query("filter"), before, limit)
+	if errors.Is(err, services.ErrUnknownAccountsFilter) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "filter must be failing, dead_lettered, disabled or syncing",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load Docker accounts",
+		})
+	}
+
+	var nextBefore *uint
+	if len(accounts) == limit {
+		nextBefore = &accounts[len(accounts)-1].ID
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"accounts":    accounts,
+		"next_before": nextBefore,
+	})
+}
+
+// SyncAccount queues a sync of a Docker account to run now, even if its syncs were
+// dead-lettered
+func (h *AdminHandler) SyncAccount(c *fiber.Ctx) error {
+	accountID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account id",
+		})
+	}
+
+	account, err := h.dockerService.ForceSync(uint(accountID))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Docker account not found",
+		})
+	case errors.Is(err, services.ErrAccountDisabled):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The account is disabled; enable it to sync",
+		})
+	case errors.Is(err, services.ErrUserSuspended):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The account's owner is suspended",
+		})
+	case errors.Is(err, services.ErrAccountDeletionScheduled):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The account's owner is about to be deleted",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue sync",
+		})
+	}
+
+	h.recordAdminAction(c, account.UserID, models.AuditAdminSync, account.DockerUsername)
+	return c.JSON(fiber.Map{
+		"message": "Sync queued",
+	})
+}
+
+// DisableAccount stops a Docker account being synced until it's enabled again. The owner
+// sees the reason.
+// Body: {"reason": "Abusive request volume"}
+func (h *AdminHandler) DisableAccount(c *fiber.Ctx) error {
+	accountID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account id",
+		})
+	}
+	var req DisableAccountRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	account, err := h.dockerService.DisableAccount(uint(accountID), req.Reason)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Docker account not found",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to disable account",
+		})
+	}
+
+	h.recordAdminAction(c, account.UserID, models.AuditAdminDisable, account.DockerUsername)
+	return c.JSON(fiber.Map{
+		"message": "Account disabled",
+	})
+}
+
+// EnableAccount lets a disabled Docker account be synced again
+func (h *AdminHandler) EnableAccount(c *fiber.Ctx) error {
+	accountID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account id",
+		})
+	}
+
+	account, err := h.dockerService.EnableAccount(uint(accountID))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Docker account not found",
+		})
+	case errors.Is(err, services.ErrAccountNotDisabled):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The account isn't disabled",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to enable account",
+		})
+	}
+
+	h.recordAdminAction(c, account.UserID, models.AuditAdminEnable, account.DockerUsername)
+	return c.JSON(fiber.Map{
+		"message": "Account enabled",
+	})
+}
+
+// SetUserAdmin grants or takes away a user's admin role. Admins can't take away their own,
+// so there's always one left.
+// Body: {"is_admin": true}
+func (h *AdminHandler) SetUserAdmin(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+	var req SetUserAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if uint(userID) == middleware.GetUserFromContext(c).ID && !req.IsAdmin {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You can't remove your own admin role",
+		})
+	}
+
+	user, err := services.SetUserAdmin(uint(userID), req.IsAdmin)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update user",
+		})
+	}
+
+	h.recordAdminAction(c, user.ID, models.AuditAdminRole, fmt.Sprintf("is_admin=%t", req.IsAdmin))
+	return c.JSON(fiber.Map{
+		"message":  "User updated",
+		"is_admin": middleware.IsAdmin(user),
+	})
+}
+
+// SuspendUser suspends a user: they're signed out and can't sign in again, their heatmaps
+// aren't served and their accounts aren't synced. Their data is kept. Admins can't be
+// suspended; take away their role first.
+// Body: {"reason": "Impersonating another user"}
+func (h *AdminHandler) SuspendUser(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+	var req SuspendUserRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	var target models.User
+	if err := database.DB.First(&target, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if middleware.IsAdmin(&target) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Admins can't be suspended; remove their admin role first",
+		})
+	}
+
+	user, err := h.dockerService.SuspendUser(target.ID, req.Reason)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to suspend user",
+		})
+	}
+
+	h.recordAdminAction(c, user.ID, models.AuditAdminSuspend, user.GitHubUsername)
+	return c.JSON(fiber.Map{
+		"message": "User suspended",
+	})
+}
+
+// UnsuspendUser lifts a user's suspension
+func (h *AdminHandler) UnsuspendUser(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+
+	user, err := h.dockerService.UnsuspendUser(uint(userID))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case errors.Is(err, services.ErrUserNotSuspended):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The user isn't suspended",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unsuspend user",
+		})
+	}
+
+	h.recordAdminAction(c, user.ID, models.AuditAdminUnsuspend, user.GitHubUsername)
+	return c.JSON(fiber.Map{
+		"message": "Suspension lifted",
+	})
+}
+
+// ListBlockedUsernames returns the Docker usernames that can't be connected, most recently
+// blocked first
+// Query params:
+//   - limit: entries per page (default 50, at most 200)
+//   - before: next_before from the previous page
+func (h *AdminHandler) ListBlockedUsernames(c *fiber.Ctx) error {
+	before, limit, err := adminPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	blocked, err := services.ListBlockedDockerUsernames(before, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load blocked usernames",
+		})
+	}
+
+	var nextBefore *uint
+	if len(blocked) == limit {
+		nextBefore = &blocked[len(blocked)-1].ID
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"blocked_usernames": blocked,
+		"next_before":       nextBefore,
+	})
+}
+
+// BlockUsername stops a Docker username being connected. An account already connected with
+// it stays up; disable it or suspend its owner as well.
+// Body: {"docker_username": "acme", "reason": "Impersonation report"}
+func (h *AdminHandler) BlockUsername(c *fiber.Ctx) error {
+	var req BlockDockerUsernameRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if !dockerUsernameRegex.MatchString(req.DockerUsername) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid Docker username format",
+		})
+	}
+
+	admin := middleware.GetUserFromContext(c)
+	blocked, err := services.BlockDockerUsername(req.DockerUsername, req.Reason, admin.ID)
+	if err == services.ErrDockerUsernameBlockExists {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The username is already blocked",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to block username",
+		})
+	}
+
+	logging.FromContext(c.UserContext()).Info("Admin blocked Docker username",
+		"admin_user_id", admin.ID, "docker_username", blocked.DockerUsername)
+	return c.Status(fiber.StatusCreated).JSON(blocked)
+}
+
+// UnblockUsername lets a blocked Docker username be connected again
+func (h *AdminHandler) UnblockUsername(c *fiber.Ctx) error {
+	err := services.UnblockDockerUsername(c.Params("username"))
+	if err == services.ErrDockerUsernameNotBlocked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "The username isn't blocked",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unblock username",
+		})
+	}
+
+	logging.FromContext(c.UserContext()).Info("Admin unblocked Docker username",
+		"admin_user_id", middleware.GetUserFromContext(c).ID, "docker_username", c.Params("username"))
+	return c.JSON(fiber.Map{
+		"message": "Username unblocked",
+	})
+}
+
+// recordAdminAction adds an admin's action to the affected user's audit log, naming the
+// admin, and logs it
+func (h *AdminHandler) recordAdminAction(c *fiber.Ctx, userID uint, action, detail string) {
+	admin := middleware.GetUserFromContext(c)
+	h.auditService.Record(userID, action, fmt.Sprintf("%s (by admin %s)", detail, admin.GitHubUsername),
+		c.IP(), c.Get("User-Agent"))
+	logging.FromContext(c.UserContext()).Info("Admin action", "action", action,
+		"admin_user_id", admin.ID, "user_id", userID, "detail", detail)
+}
+
+// SetReadOnly turns read-only maintenance mode on or off on the answering instance until
+// it restarts, when READ_ONLY_MODE applies again. The change is recorded in the admin's
+// audit log while writes are still allowed.
+// Body: {"enabled": true}
+func (h *AdminHandler) SetReadOnly(c *fiber.Ctx) error {
+	var req SetReadOnlyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	admin := middleware.GetUserFromContext(c)
+	if req.Enabled {
+		h.recordAdminAction(c, admin.ID, models.AuditAdminReadOnly, "read-only mode on")
+		config.SetReadOnly(true)
+	} else {
+		config.SetReadOnly(false)
+		h.recordAdminAction(c, admin.ID, models.AuditAdminReadOnly, "read-only mode off")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Read-only mode updated",
+		"read_only": config.IsReadOnly(),
+	})
+}
+
+// adminPage reads the before and limit query params of the admin lists
+func adminPage(c *fiber.Ctx) (uint, int, error) {
+	limit := services.DefaultAdminPageSize
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= services.MaxAdminPageSize {
+			limit = parsed
+		}
+	}
+	var before uint64
+	if b := c.Query("before"); b != "" {
+		parsed, err := strconv.ParseUint(b, 10, 64)
+		if err != nil {
+			return 0, 0, errors.New("before must be an id")
+		}
+		before = parsed
+	}
+	return uint(before), limit, nil
+}
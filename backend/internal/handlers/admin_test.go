@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSetReadOnly(t *testing.T) {
+	testutil.OpenDB(t)
+	defer config.SetReadOnly(false)
+
+	admin := models.User{Provider: "password", GitHubUsername: "root", IsAdmin: true}
+	if err := database.DB.Create(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := NewAdminHandler(services.NewServices(nil, nil, services.SystemClock), nil)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserContextKey, &admin)
+		return c.Next()
+	})
+	app.Use(middleware.ReadOnlyMiddleware())
+	app.Put("/api/v1/admin/read-only", h.SetReadOnly)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantMode   bool
+	}{
+		{"on", `{"enabled": true}`, fiber.StatusOK, true},
+		{"invalid body", `{"enabled": "yes"}`, fiber.StatusBadRequest, true},
+		{"off while read-only", `{"enabled": false}`, fiber.StatusOK, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodPut, "/api/v1/admin/read-only", strings.NewReader(tt.body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+		if config.IsReadOnly() != tt.wantMode {
+			t.Errorf("%s: read-only %v, want %v", tt.name, config.IsReadOnly(), tt.wantMode)
+		}
+	}
+
+	var audited int64
+	database.DB.Model(&models.AuditLog{}).Where("user_id = ? AND action = ?", admin.ID, models.AuditAdminReadOnly).Count(&audited)
+	if audited != 2 {
+		t.Errorf("%d switches audited, want 2", audited)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"strconv"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAuditLog returns the user's audit log, newest first: Docker Hub connections, syncs,
+// profile changes, sign-ins and token changes, with the IP address and user agent of each
+// Query params:
+//   - limit: entries per page (default 50, at most 200)
+//   - before: next_before from the previous page
+func (h *UserHandler) GetAuditLog(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	limit := services.DefaultAuditPageSize
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= services.MaxAuditPageSize {
+			limit = parsed
+		}
+	}
+	var before uint64
+	if b := c.Query("before"); b != "" {
+		parsed, err := strconv.ParseUint(b, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "before must be an entry id",
+			})
+		}
+		before = parsed
+	}
+
+	entries, err := h.auditService.List(user.ID, uint(before), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load audit log",
+		})
+	}
+
+	var nextBefore *uint
+	if len(entries) == limit {
+		nextBefore = &entries[len(entries)-1].ID
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"events":      entries,
+		"next_before": nextBefore,
+	})
+}
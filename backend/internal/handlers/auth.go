@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
+	"net/url"
 	"time"
 
 	"docker-heatmap/internal/config"
 	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
 	"docker-heatmap/internal/services"
 	"docker-heatmap/internal/utils"
 
@@ -15,83 +17,175 @@ import (
 )
 
 type AuthHandler struct {
-	authService *services.GitHubAuthService
+	providers       []services.AuthProvider
+	deviceService   *services.DeviceAuthService
+	sessionService  *services.SessionService
+	passwordService *services.PasswordAuthService
+	auditService    *services.AuditService
 }
 
-func NewAuthHandler() *AuthHandler {
+func NewAuthHandler(svc *services.Services) *AuthHandler {
 	return &AuthHandler{
-		authService: services.NewGitHubAuthService(),
+		providers:       svc.AuthProviders,
+		deviceService:   svc.DeviceAuth,
+		sessionService:  svc.Sessions,
+		passwordService: svc.PasswordAuth,
+		auditService:    svc.Audit,
 	}
 }
 
-// OAuthState stores temporary state for OAuth flow
+// OAuthState stores temporary state for OAuth flow, with the provider it was issued for
 var (
-	oauthStates = make(map[string]time.Time)
+	oauthStates = make(map[string]oauthState)
 	stateMutex  sync.Mutex
 )
 
-// InitiateGitHubAuth starts the GitHub OAuth flow
-func (h *AuthHandler) InitiateGitHubAuth(c *fiber.Ctx) error {
-	state, err := utils.GenerateStateToken()
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate state",
-		})
-	}
-
-	// Store state with expiry
-	stateMutex.Lock()
-	oauthStates[state] = time.Now().Add(10 * time.Minute)
-	stateMutex.Unlock()
-
-	// Clean old states
-	go cleanupOAuthStates()
+type oauthState struct {
+	provider string
+	expiry   time.Time
+}
 
-	authURL := h.authService.GetAuthURL(state)
+// Providers returns the enabled sign-in providers, which each get a pair of login routes
+func (h *AuthHandler) Providers() []services.AuthProvider {
+	return h.providers
+}
 
+// ListProviders returns the names of the enabled OAuth sign-in providers and whether
+// email and password sign-in is on, for the login page
+func (h *AuthHandler) ListProviders(c *fiber.Ctx) error {
+	names := make([]string, len(h.providers))
+	for i, provider := range h.providers {
+		names[i] = provider.Name()
+	}
 	return c.JSON(fiber.Map{
-		"auth_url": authURL,
+		"providers": names,
+		"password":  config.AppConfig.AuthPasswordEnabled,
 	})
 }
 
-// GitHubCallback handles the OAuth callback
-func (h *AuthHandler) GitHubCallback(c *fiber.Ctx) error {
-	code := c.Query("code")
-	state := c.Query("state")
+// InitiateOAuth returns a handler that starts the OAuth flow for provider
+func (h *AuthHandler) InitiateOAuth(provider services.AuthProvider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state, err := utils.GenerateStateToken()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to generate state",
+			})
+		}
 
-	if code == "" || state == "" {
-		return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=missing_params")
-	}
+		// Store state with expiry
+		stateMutex.Lock()
+		oauthStates[state] = oauthState{provider: provider.Name(), expiry: time.Now().Add(10 * time.Minute)}
+		stateMutex.Unlock()
 
-	// Validate state
-	stateMutex.Lock()
-	expiry, exists := oauthStates[state]
-	if exists {
-		delete(oauthStates, state)
-	}
-	stateMutex.Unlock()
+		// Clean old states
+		go cleanupOAuthStates()
 
-	if !exists || time.Now().After(expiry) {
-		return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=invalid_state")
+		return c.JSON(fiber.Map{
+			"auth_url": provider.AuthURL(state),
+		})
 	}
+}
 
-	// Exchange code for user
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// OAuthCallback returns a handler for provider's redirect back after sign-in. The login
+// is linked to an existing account by verified email when it is new.
+func (h *AuthHandler) OAuthCallback(provider services.AuthProvider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		code := c.Query("code")
+		state := c.Query("state")
 
-	user, err := h.authService.ExchangeCode(ctx, code)
-	if err != nil {
-		return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=auth_failed")
+		// Login creates or updates the user record, which isn't possible during maintenance
+		if config.IsReadOnly() {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=read_only")
+		}
+
+		if code == "" || state == "" {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=missing_params")
+		}
+
+		// Validate state
+		stateMutex.Lock()
+		saved, exists := oauthStates[state]
+		if exists {
+			delete(oauthStates, state)
+		}
+		stateMutex.Unlock()
+
+		if !exists || saved.provider != provider.Name() || time.Now().After(saved.expiry) {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=invalid_state")
+		}
+
+		// Exchange code for user
+		ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+		defer cancel()
+
+		identity, err := provider.Identify(ctx, code)
+		if err != nil {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=auth_failed")
+		}
+		user, err := services.FindOrCreateUser(identity)
+		if err != nil {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=auth_failed")
+		}
+
+		// Start a session for this browser
+		tokens, err := h.sessionService.Create(user, "", c.Get("User-Agent"), c.IP())
+		if err == services.ErrUserSuspended {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=suspended")
+		}
+		if err != nil {
+			return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=token_failed")
+		}
+		h.auditService.Record(user.ID, models.AuditLogin, provider.Name(), c.IP(), c.Get("User-Agent"))
+
+		// Redirect to frontend with the tokens
+		return c.Redirect(config.AppConfig.FrontendURL + "/auth/callback?token=" + tokens.AccessToken +
+			"&refresh_token=" + url.QueryEscape(tokens.RefreshToken))
 	}
+}
 
-	// Generate JWT
-	token, err := utils.GenerateToken(user.ID, user.GitHubUsername)
+// RefreshToken trades a refresh token for a new access token and refresh token. The
+// refresh token rotates: the one sent stops working, and sending it again after that
+// revokes the session.
+// Body: {"refresh_token": "..."}
+func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "refresh_token is required",
+		})
+	}
+
+	tokens, err := h.sessionService.Refresh(req.RefreshToken, c.Get("User-Agent"), c.IP())
+	if err == services.ErrRefreshTokenInvalid || err == services.ErrRefreshTokenReused {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err == services.ErrUserSuspended {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account suspended",
+		})
+	}
 	if err != nil {
-		return c.Redirect(config.AppConfig.FrontendURL + "/auth/error?message=token_failed")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to refresh session",
+		})
 	}
 
-	// Redirect to frontend with token
-	return c.Redirect(config.AppConfig.FrontendURL + "/auth/callback?token=" + token)
+	return c.JSON(tokenResponse(tokens))
+}
+
+// tokenResponse is the body of every response that issues tokens
+func tokenResponse(tokens *services.TokenPair) fiber.Map {
+	return fiber.Map{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    tokens.ExpiresIn,
+	}
 }
 
 // GetCurrentUser returns the authenticated user
@@ -108,10 +202,24 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 	})
 }
 
-// Logout invalidates the current session
+// Logout ends the current session, so its refresh token and access tokens stop working
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	// Since we use stateless JWT, just return success
-	// Client should delete the token
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	// Tokens from before sessions existed have nothing to revoke; they simply expire
+	if sessionID := middleware.GetSessionIDFromContext(c); sessionID != 0 {
+		if err := h.sessionService.Revoke(user.ID, sessionID); err != nil && err != services.ErrSessionNotFound {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to log out",
+			})
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"message": "Logged out successfully",
 	})
@@ -122,8 +230,8 @@ func cleanupOAuthStates() {
 	defer stateMutex.Unlock()
 
 	now := time.Now()
-	for state, expiry := range oauthStates {
-		if now.After(expiry) {
+	for state, saved := range oauthStates {
+		if now.After(saved.expiry) {
 			delete(oauthStates, state)
 		}
 	}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestDataExport starts building an archive of everything stored about the user. It's
+// built in the background; GetDataExport reports when it's ready to download.
+func (h *UserHandler) RequestDataExport(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	export, err := h.exportService.RequestDataExport(user.ID)
+	if err == services.ErrDataExportTooSoon {
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start data export",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditDataExportRequest, "", c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusAccepted).JSON(dataExportResponse(c, export))
+}
+
+// GetDataExport returns the user's latest data export, with its download link once it's
+// ready
+func (h *UserHandler) GetDataExport(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	export, err := h.exportService.LatestDataExport(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No data export requested",
+		})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(dataExportResponse(c, export))
+}
+
+// DownloadDataExport sends a finished data export. The token in the link is what
+// authorizes it, so the link works from a browser or a script without signing in.
+func (h *UserHandler) DownloadDataExport(c *fiber.Ctx) error {
+	export, err := h.exportService.DataExportByToken(c.Params("token"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Data export not found or expired",
+		})
+	}
+	h.auditService.Record(export.UserID, models.AuditDataExportDownload, "", c.IP(), c.Get("User-Agent"))
+
+	c.Set("Cache-Control", "no-store")
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="docker-heatmap-export-%s.zip"`,
+		export.CompletedAt.UTC().Format("2006-01-02")))
+	return c.Send(export.Data)
+}
+
+// dataExportResponse is a data export as the API returns it, with its download link once
+// it's ready
+func dataExportResponse(c *fiber.Ctx, export *models.DataExport) fiber.Map {
+	response := fiber.Map{
+		"id":           export.ID,
+		"status":       export.Status,
+		"created_at":   export.CreatedAt,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+		"size_bytes":   export.SizeBytes,
+	}
+	if export.Error != "" {
+		response["failure"] = export.Error
+	}
+	if export.Status == models.DataExportReady {
+		if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+			response["status"] = "expired"
+		} else {
+			response["download_url"] = c.BaseURL() + middleware.APIV1Prefix + "/export/" + export.Token
+		}
+	}
+	return response
+}
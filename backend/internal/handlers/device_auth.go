@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/url"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StartDeviceAuth begins a login for a device without a browser, such as the CLI
+// (RFC 8628). The device shows the user code and verification URL, then polls
+// PollDeviceAuth with the device code.
+// Body (optional): {"client_name": "dhm on laptop"}
+func (h *AuthHandler) StartDeviceAuth(c *fiber.Ctx) error {
+	var req struct {
+		ClientName string `json:"client_name"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	start, err := h.deviceService.Start(req.ClientName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start device login",
+		})
+	}
+
+	verificationURI := config.AppConfig.FrontendURL + "/device"
+	return c.JSON(fiber.Map{
+		"device_code":               start.DeviceCode,
+		"user_code":                 start.UserCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": verificationURI + "?user_code=" + url.QueryEscape(start.UserCode),
+		"expires_in":                start.ExpiresIn,
+		"interval":                  start.Interval,
+	})
+}
+
+// PollDeviceAuth returns a token once the device's login is approved. Until then it
+// answers 400 with an RFC 8628 error code: authorization_pending, slow_down,
+// expired_token, access_denied or invalid_grant.
+// Body: {"device_code": "..."}
+func (h *AuthHandler) PollDeviceAuth(c *fiber.Ctx) error {
+	var req struct {
+		DeviceCode string `json:"device_code"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.DeviceCode == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":             "invalid_request",
+			"error_description": "device_code is required",
+		})
+	}
+
+	user, clientName, err := h.deviceService.Poll(req.DeviceCode)
+	if err != nil {
+		code := ""
+		switch err {
+		case services.ErrAuthorizationPending:
+			code = "authorization_pending"
+		case services.ErrSlowDown:
+			code = "slow_down"
+		case services.ErrDeviceCodeExpired:
+			code = "expired_token"
+		case services.ErrDeviceAccessDenied:
+			code = "access_denied"
+		case services.ErrDeviceCodeInvalid, services.ErrUserNotFound:
+			code = "invalid_grant"
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check device login",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":             code,
+			"error_description": err.Error(),
+		})
+	}
+
+	tokens, err := h.sessionService.Create(user, clientName, c.Get("User-Agent"), c.IP())
+	if err == services.ErrUserSuspended {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":             "access_denied",
+			"error_description": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	response := tokenResponse(tokens)
+	response["user"] = user
+	return c.JSON(response)
+}
+
+// GetDeviceAuth shows the signed-in user a pending device login before they approve it
+// Query params: user_code
+func (h *AuthHandler) GetDeviceAuth(c *fiber.Ctx) error {
+	auth, err := h.deviceService.Lookup(c.Query("user_code"))
+	if err == services.ErrUserCodeNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Code not found or expired",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to look up code",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"device": auth,
+	})
+}
+
+// DecideDeviceAuth approves or denies a pending device login for the signed-in user
+// Body: {"user_code": "BCDF-GHJK", "approve": true}
+func (h *AuthHandler) DecideDeviceAuth(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req struct {
+		UserCode string `json:"user_code"`
+		Approve  bool   `json:"approve"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err := h.deviceService.Decide(user.ID, req.UserCode, req.Approve)
+	if err == services.ErrUserCodeNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Code not found or expired",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update device login",
+		})
+	}
+
+	message := "Device login denied"
+	if req.Approve {
+		message = "Device login approved"
+		h.auditService.Record(user.ID, models.AuditDeviceApprove, req.UserCode, c.IP(), c.Get("User-Agent"))
+	}
+	return c.JSON(fiber.Map{
+		"message": message,
+	})
+}
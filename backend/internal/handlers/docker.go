@@ -1,14 +1,21 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
 	"time"
 
 	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
 	"docker-heatmap/internal/services"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
 )
 
 // Docker username validation: 4-30 chars, alphanumeric with allowed special chars
@@ -16,11 +23,13 @@ var dockerUsernameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,29}$`
 
 type DockerHandler struct {
 	dockerService *services.DockerHubService
+	auditService  *services.AuditService
 }
 
-func NewDockerHandler() *DockerHandler {
+func NewDockerHandler(svc *services.Services) *DockerHandler {
 	return &DockerHandler{
-		dockerService: services.NewDockerHubService(),
+		dockerService: svc.Docker,
+		auditService:  svc.Audit,
 	}
 }
 
@@ -37,6 +46,11 @@ func (h *DockerHandler) ConnectDocker(c *fiber.Ctx) error {
 			"error": "Unauthorized",
 		})
 	}
+	if user.DeletionScheduledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Your account is scheduled to be deleted; cancel the deletion first",
+		})
+	}
 
 	var req ConnectDockerRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -65,16 +79,26 @@ func (h *DockerHandler) ConnectDocker(c *fiber.Ctx) error {
 		})
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
 	defer cancel()
 
 	account, err := h.dockerService.ConnectAccount(ctx, user.ID, req.DockerUsername, req.AccessToken)
+	if errors.Is(err, services.ErrInvalidDockerToken) {
+		h.auditService.Record(user.ID, models.AuditDockerTokenInvalid, req.DockerUsername, c.IP(), c.Get("User-Agent"))
+	}
+	if err == services.ErrDockerUsernameBlocked {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	h.auditService.Record(user.ID, models.AuditDockerConnect, account.DockerUsername, c.IP(), c.Get("User-Agent"))
+
 	return c.JSON(fiber.Map{
 		"message": "Docker account connected successfully",
 		"account": fiber.Map{
@@ -102,19 +126,91 @@ func (h *DockerHandler) GetDockerAccount(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"account": fiber.Map{
-			"id":               account.ID,
-			"docker_username":  account.DockerUsername,
-			"is_active":        account.IsActive,
-			"auto_refresh":     account.AutoRefresh,
-			"last_sync_at":     account.LastSyncAt,
-			"last_sync_error":  account.LastSyncError,
-			"sync_in_progress": account.SyncInProgress,
-		},
+		"account": dockerAccountResponse(account),
+	})
+}
+
+// dockerAccountResponse is the owner's view of their Docker account
+func dockerAccountResponse(account *models.DockerAccount) fiber.Map {
+	var nextSyncAt *time.Time
+	if next, ok := services.NextExpectedSync(account, time.Now()); ok {
+		nextSyncAt = &next
+	}
+
+	return fiber.Map{
+		"id":                    account.ID,
+		"docker_username":       account.DockerUsername,
+		"is_active":             account.IsActive,
+		"auto_refresh":          account.AutoRefresh,
+		"last_sync_at":          account.LastSyncAt,
+		"last_sync_error":       account.LastSyncError,
+		"sync_in_progress":      account.SyncInProgress,
+		"sync_interval_minutes": account.SyncIntervalMinutes,
+		"next_sync_at":          nextSyncAt,
+		"sync_dead_lettered_at": account.SyncDeadLetteredAt,
+		"disabled_at":           account.DisabledAt,
+		"disabled_reason":       account.DisabledReason,
+
+		"disconnect_scheduled_at": account.DisconnectScheduledAt,
+	}
+}
+
+type UpdateDockerAccountRequest struct {
+	SyncIntervalMinutes *int `json:"sync_interval_minutes"`
+}
+
+// UpdateDockerAccount changes the Docker account's settings
+// Body: {"sync_interval_minutes": 60} (30 to 10080, or 0 to follow the scheduled sync)
+func (h *DockerHandler) UpdateDockerAccount(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req UpdateDockerAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	if req.SyncIntervalMinutes != nil {
+		err := h.dockerService.SetSyncInterval(account, *req.SyncIntervalMinutes)
+		if err == services.ErrSyncIntervalOutOfRange {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("sync_interval_minutes must be 0 or between %d and %d",
+					int(services.MinAccountSyncInterval.Minutes()), int(services.MaxAccountSyncInterval.Minutes())),
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update Docker account",
+			})
+		}
+		detail := "sync interval: scheduled"
+		if account.SyncIntervalMinutes > 0 {
+			detail = fmt.Sprintf("sync interval: %d minutes", account.SyncIntervalMinutes)
+		}
+		h.auditService.Record(user.ID, models.AuditDockerUpdate, detail, c.IP(), c.Get("User-Agent"))
+	}
+
+	return c.JSON(fiber.Map{
+		"account": dockerAccountResponse(account),
 	})
 }
 
-// DisconnectDocker removes the Docker Hub account connection
+// DisconnectDocker schedules the Docker Hub account connection for removal. The account
+// stops being served immediately and is purged after the grace period unless the
+// disconnect is undone.
 func (h *DockerHandler) DisconnectDocker(c *fiber.Ctx) error {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -130,14 +226,60 @@ func (h *DockerHandler) DisconnectDocker(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.dockerService.DisconnectAccount(user.ID, account.ID); err != nil {
+	purgeAt, err := h.dockerService.ScheduleDisconnect(user.ID, account.ID)
+	if err == services.ErrDisconnectPending {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Account is already scheduled to be disconnected",
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to disconnect account",
 		})
 	}
+	h.auditService.Record(user.ID, models.AuditDockerDisconnect, account.DockerUsername, c.IP(), c.Get("User-Agent"))
+
+	if purgeAt.IsZero() {
+		return c.JSON(fiber.Map{
+			"message": "Docker account disconnected successfully",
+		})
+	}
 
 	return c.JSON(fiber.Map{
-		"message": "Docker account disconnected successfully",
+		"message":  "Docker account will be disconnected",
+		"purge_at": purgeAt,
+	})
+}
+
+// UndoDisconnect restores an account whose disconnect is still within its grace period
+func (h *DockerHandler) UndoDisconnect(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.UndoDisconnect(user.ID)
+	if err == services.ErrNoDisconnectPending {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No pending disconnect to undo",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore account",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditDockerRestore, account.DockerUsername, c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Docker account restored",
+		"account": fiber.Map{
+			"id":              account.ID,
+			"docker_username": account.DockerUsername,
+			"is_active":       account.IsActive,
+		},
 	})
 }
 
@@ -149,6 +291,11 @@ func (h *DockerHandler) SyncDockerActivity(c *fiber.Ctx) error {
 			"error": "Unauthorized",
 		})
 	}
+	if user.DeletionScheduledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Your account is scheduled to be deleted; cancel the deletion first",
+		})
+	}
 
 	account, err := h.dockerService.GetDockerAccount(user.ID)
 	if err != nil {
@@ -162,11 +309,225 @@ func (h *DockerHandler) SyncDockerActivity(c *fiber.Ctx) error {
 			"error": "Sync already in progress",
 		})
 	}
+	if account.DisconnectScheduledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Account is scheduled to be disconnected; undo the disconnect to sync",
+		})
+	}
+	if account.DisabledAt != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Syncs of this account were disabled by an administrator",
+		})
+	}
+
+	h.auditService.Record(user.ID, models.AuditDockerSync, account.DockerUsername, c.IP(), c.Get("User-Agent"))
+
+	// The sync worker picks it up within seconds, and retries it if it fails
+	if err := services.EnqueueSync(account.ID, models.SyncTriggerManual, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue sync",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Sync queued",
+	})
+}
+
+// GetSyncHistory returns the account's recent syncs, newest first, with what each did:
+// how long it took, the repositories scanned, new events, Docker Hub requests and any error
+// Query params:
+//   - limit: runs per page (default 20, at most 100)
+//   - before: next_before from the previous page
+func (h *DockerHandler) GetSyncHistory(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	limit := services.DefaultSyncRunPageSize
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= services.MaxSyncRunPageSize {
+			limit = parsed
+		}
+	}
+	var before uint64
+	if b := c.Query("before"); b != "" {
+		parsed, err := strconv.ParseUint(b, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "before must be a run id",
+			})
+		}
+		before = parsed
+	}
+
+	runs, err := h.dockerService.ListSyncRuns(account.ID, uint(before), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load sync history",
+		})
+	}
+
+	var nextBefore *uint
+	if len(runs) == limit {
+		nextBefore = &runs[len(runs)-1].ID
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"runs":        runs,
+		"next_before": nextBefore,
+	})
+}
+
+// syncEventsHeartbeat keeps idle progress streams from being closed by proxies, and
+// notices clients that went away
+const syncEventsHeartbeat = 15 * time.Second
+
+// StreamSyncEvents streams the user's sync progress as Server-Sent Events. The stream opens
+// with a "status" event holding the account's sync state, followed by a "progress" event
+// for each step of every sync (queued, fetching, syncing with done/total repositories, done
+// or failed) until the client disconnects.
+func (h *DockerHandler) StreamSyncEvents(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	status, err := json.Marshal(fiber.Map{
+		"sync_in_progress": account.SyncInProgress,
+		"last_sync_at":     account.LastSyncAt,
+		"last_sync_error":  account.LastSyncError,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before answering, so no step between the status and the stream is missed
+	progress, cancel := services.SubscribeSyncProgress(account.ID)
 
-	// Trigger sync in background
-	go h.dockerService.SyncActivity(context.Background(), account.ID)
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		fmt.Fprintf(w, "retry: 5000\nevent: status\ndata: %s\n\n", status)
+		if w.Flush() != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(syncEventsHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case step := <-progress:
+				data, err := json.Marshal(step)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	}))
+	return nil
+}
+
+// rawEventJSON is how the API shows a raw event; its Source must already be resolved
+func rawEventJSON(event models.ActivityEvent) fiber.Map {
+	return fiber.Map{
+		"id":         event.ID,
+		"event_type": event.EventType,
+		"event_date": event.EventDate.Format("2006-01-02"),
+		"event_at":   event.EventAt,
+		"count":      event.Count,
+		"repository": event.Repository,
+		"tag":        event.Tag,
+		"source":     event.Source,
+		"confidence": event.Source.Confidence().String(),
+		"run_url":    event.RunURL,
+	}
+}
+
+// GetActivityEvents returns the user's raw activity events with their timestamp source
+// Query params:
+//   - days: how far back to look (1-365, default 30)
+//   - limit: maximum events returned (1-1000, default 200)
+//   - min_confidence: skip events from less reliable sources (low, medium, high)
+func (h *DockerHandler) GetActivityEvents(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	days := 30
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
+			days = parsed
+		}
+	}
+
+	limit := 200
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	events, err := h.dockerService.GetRawEvents(account.ID, days, limit, parseMinConfidence(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch events",
+		})
+	}
+
+	result := make([]fiber.Map, 0, len(events))
+	for _, event := range events {
+		result = append(result, rawEventJSON(event))
+	}
 
 	return c.JSON(fiber.Map{
-		"message": "Sync started",
+		"days":   days,
+		"events": result,
+		"sources": fiber.Map{
+			string(models.EventSourceTagPush):    models.EventSourceTagPush.Confidence().String(),
+			string(models.EventSourceRepoUpdate): models.EventSourceRepoUpdate.Confidence().String(),
+			string(models.EventSourceWebhook):    models.EventSourceWebhook.Confidence().String(),
+			string(models.EventSourceImport):     models.EventSourceImport.Confidence().String(),
+		},
 	})
 }
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newDockerApp serves the Docker account routes against hub, signed in as user
+func newDockerApp(hub *testutil.DockerHub, user *models.User) *fiber.App {
+	svc := services.NewServices(services.NewDockerHubAPI(hub.URL, hub.Client()), services.NewDatabaseActivityRepository(), services.SystemClock)
+	h := NewDockerHandler(svc)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserContextKey, user)
+		return c.Next()
+	})
+	app.Post("/docker/connect", h.ConnectDocker)
+	app.Post("/docker/sync", h.SyncDockerActivity)
+	return app
+}
+
+func TestConnectDocker(t *testing.T) {
+	testutil.OpenDB(t)
+	hub := testutil.NewDockerHub(t)
+	hub.AddUser("alice", "alice-access-token")
+
+	user := models.User{Provider: "password", GitHubUsername: "someone"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newDockerApp(hub, &user)
+
+	tests := []struct {
+		name       string
+		username   string
+		token      string
+		wantStatus int
+		wantError  string
+	}{
+		{"unknown username", "mallory", "alice-access-token", fiber.StatusBadRequest, "docker hub username not found"},
+		{"wrong token", "alice", "not-alices-token", fiber.StatusBadRequest, "invalid access token"},
+		{"connected", "alice", "alice-access-token", fiber.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		body := `{"docker_username":"` + tt.username + `","access_token":"` + tt.token + `"}`
+		req := httptest.NewRequest(fiber.MethodPost, "/docker/connect", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+		var got struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&got)
+		if !strings.Contains(got.Error, tt.wantError) {
+			t.Errorf("%s: error %q, want %q", tt.name, got.Error, tt.wantError)
+		}
+	}
+
+	var account models.DockerAccount
+	if err := database.DB.Where("user_id = ?", user.ID).First(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	if account.DockerUsername != "alice" {
+		t.Errorf("connected %q, want alice", account.DockerUsername)
+	}
+	var jobs int64
+	database.DB.Model(&models.SyncJob{}).Where("docker_account_id = ? AND triggered_by = ?", account.ID, models.SyncTriggerConnect).Count(&jobs)
+	if jobs != 1 {
+		t.Errorf("%d initial syncs queued, want 1", jobs)
+	}
+	var rejected int64
+	database.DB.Model(&models.AuditLog{}).Where("user_id = ? AND action = ?", user.ID, models.AuditDockerTokenInvalid).Count(&rejected)
+	if rejected != 1 {
+		t.Errorf("%d rejected tokens audited, want 1", rejected)
+	}
+}
+
+func TestSyncDockerActivity(t *testing.T) {
+	testutil.OpenDB(t)
+	hub := testutil.NewDockerHub(t)
+	account := testutil.CreateAccount(t, "alice", true)
+
+	var owner models.User
+	if err := database.DB.First(&owner, account.UserID).Error; err != nil {
+		t.Fatal(err)
+	}
+	stranger := models.User{Provider: "password", GitHubUsername: "bob"}
+	if err := database.DB.Create(&stranger).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantStatus int
+	}{
+		{"no account", &stranger, fiber.StatusNotFound},
+		{"queued", &owner, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, err := newDockerApp(hub, tt.user).Test(httptest.NewRequest(fiber.MethodPost, "/docker/sync", nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	var job models.SyncJob
+	if err := database.DB.Where("docker_account_id = ?", account.ID).First(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job.TriggeredBy != models.SyncTriggerManual || job.Status != models.SyncJobPending {
+		t.Errorf("queued a %s sync, %s; want a pending %s sync", job.TriggeredBy, job.Status, models.SyncTriggerManual)
+	}
+}
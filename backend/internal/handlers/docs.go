@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"docker-heatmap/internal/apidocs"
+	"docker-heatmap/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// GetOpenAPISpec serves the OpenAPI 3 description of the API
+func (h *DocsHandler) GetOpenAPISpec(c *fiber.Ctx) error {
+	c.Set("Content-Type", fiber.MIMEApplicationJSON)
+	c.Set("Cache-Control", "public, max-age=3600")
+	return c.Send(apidocs.Spec)
+}
+
+// GetAPIDocs serves Swagger UI for the OpenAPI spec
+func (h *DocsHandler) GetAPIDocs(c *fiber.Ctx) error {
+	c.Set("Content-Type", fiber.MIMETextHTMLCharsetUTF8)
+	c.Set("Cache-Control", "public, max-age=3600")
+	return c.SendString(apidocs.SwaggerUI(middleware.APIPrefix + "/openapi.json"))
+}
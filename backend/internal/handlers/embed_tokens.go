@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateEmbedTokenRequest struct {
+	Name           string   `json:"name"`
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+type UpdateEmbedTokenRequest struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// ListEmbedTokens returns the user's embed tokens, without their values
+func (h *UserHandler) ListEmbedTokens(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	tokens, err := h.embedTokenService.List(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load embed tokens",
+		})
+	}
+	return c.JSON(fiber.Map{"tokens": tokens})
+}
+
+// CreateEmbedToken mints a named token that shows the user's heatmap when appended to an
+// image URL as ?token=, even with a private profile. Its value is only returned here.
+// With allowed_origins, only pages on those sites can use it.
+// Body: {"name": "company-wiki", "allowed_origins": ["https://wiki.example.com"]}
+func (h *UserHandler) CreateEmbedToken(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateEmbedTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required and must be at most 100 characters",
+		})
+	}
+
+	token, value, err := h.embedTokenService.Create(user.ID, req.Name, req.AllowedOrigins)
+	if err == services.ErrEmbedTokenOrigins {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err == services.ErrEmbedTokenLimit {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create embed token",
+		})
+	}
+
+	h.auditService.Record(user.ID, models.AuditEmbedTokenCreate, token.Name, c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Embed token created; copy it now, it won't be shown again",
+		"token":   token,
+		"value":   value,
+	})
+}
+
+// UpdateEmbedToken changes which sites may use an embed token; an empty list allows any
+// Body: {"allowed_origins": ["https://wiki.example.com"]}
+func (h *UserHandler) UpdateEmbedToken(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid token id",
+		})
+	}
+	var req UpdateEmbedTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	token, err := h.embedTokenService.SetAllowedOrigins(user.ID, uint(id), req.AllowedOrigins)
+	if err == services.ErrEmbedTokenOrigins {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err == services.ErrEmbedTokenNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update embed token",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditEmbedTokenUpdate, token.Name, c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Embed token updated",
+		"token":   token,
+	})
+}
+
+// RevokeEmbedToken deletes an embed token; URLs carrying it stop showing a private heatmap
+func (h *UserHandler) RevokeEmbedToken(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid token id",
+		})
+	}
+
+	if err := h.embedTokenService.Revoke(user.ID, uint(id)); err != nil {
+		if err == services.ErrEmbedTokenNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke embed token",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditEmbedTokenRevoke, strconv.FormatUint(id, 10), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Embed token revoked",
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"docker-heatmap/internal/graphapi"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+func NewGraphQLHandler(svc *services.Services) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: graphapi.NewSchema(svc.Docker),
+	}
+}
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query executes a GraphQL query, sent as a JSON body or, for GET, as query parameters.
+// Query errors are reported in the response's errors list with a 200, per GraphQL convention.
+func (h *GraphQLHandler) Query(c *fiber.Ctx) error {
+	var req GraphQLRequest
+	if c.Method() == fiber.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "variables must be a JSON object",
+				})
+			}
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "query is required",
+		})
+	}
+
+	return c.JSON(h.schema.Exec(c.UserContext(), req.Query, req.OperationName, req.Variables))
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const serviceName = "docker-heatmap-api"
+
+// HealthHandler serves the health checks load balancers and Kubernetes probes poll
+type HealthHandler struct {
+	healthService *services.HealthService
+	startedAt     time.Time
+}
+
+func NewHealthHandler(svc *services.Services) *HealthHandler {
+	return &HealthHandler{
+		healthService: svc.Health,
+		startedAt:     time.Now(),
+	}
+}
+
+// Live reports the process is up and serving requests, without checking any dependency:
+// restarting the process wouldn't fix a database outage
+func (h *HealthHandler) Live(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"status":         "alive",
+		"service":        serviceName,
+		"uptime_seconds": int64(time.Since(h.startedAt).Seconds()),
+	})
+}
+
+// Ready reports whether the instance should receive traffic, with the status of each
+// dependency. 503 when a required dependency (the database) is down; a degraded instance,
+// with Redis or Docker Hub down, is still ready.
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	report := h.healthService.Check(c.UserContext())
+
+	status := fiber.StatusOK
+	if !report.Ready() {
+		status = fiber.StatusServiceUnavailable
+	}
+	c.Set("Cache-Control", "no-store")
+	return c.Status(status).JSON(fiber.Map{
+		"status":    report.Status,
+		"service":   serviceName,
+		"read_only": config.IsReadOnly(),
+		"checks":    report.Checks,
+	})
+}
+
+// Health is the original health check, kept for existing monitors: healthy when the
+// database answers
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	report := h.healthService.Check(c.UserContext())
+
+	c.Set("Cache-Control", "no-store")
+	if !report.Ready() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":   "unhealthy",
+			"database": "disconnected",
+			"service":  serviceName,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"status":    "healthy",
+		"database":  "connected",
+		"service":   serviceName,
+		"read_only": config.IsReadOnly(),
+	})
+}
@@ -1,104 +1,1291 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
 	"docker-heatmap/internal/services"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type HeatmapHandler struct {
-	heatmapService *services.HeatmapService
-	dockerService  *services.DockerHubService
+	heatmapService  *services.HeatmapService
+	dockerService   *services.DockerHubService
+	snapshotService *services.SnapshotService
+	wrappedService  *services.WrappedService
+	renderCache     *services.RenderCache
+	standby         *services.StandbyStore
+
+	embedTokenService *services.EmbedTokenService
+}
+
+func NewHeatmapHandler(svc *services.Services) *HeatmapHandler {
+	return &HeatmapHandler{
+		heatmapService:  svc.Heatmap,
+		dockerService:   svc.Docker,
+		snapshotService: svc.Snapshot,
+		wrappedService:  svc.Wrapped,
+		renderCache:     svc.RenderCache,
+		standby:         svc.Standby,
+
+		embedTokenService: svc.EmbedTokens,
+	}
+}
+
+// GetHeatmapSVG returns the heatmap as an SVG image with customization options
+// Query params:
+//   - days: number of days (1-365 and default 365 unless the deployment sets RENDER_* limits)
+//   - years: stack one row per calendar year (2-5), overrides days
+//   - from, to: explicit date range (YYYY-MM-DD, inclusive, up to 5 years), overrides days and years
+//   - theme: color theme (github, docker, dracula, nord, etc.) or "custom"
+//   - cell_size: size of each cell (5-20, default 11, per RENDER_* limits)
+//   - radius: border radius of cells (0-10, default 2, per RENDER_* limits)
+//   - hide_legend: hide the color legend (true/false)
+//   - hide_total: hide the total count (true/false)
+//   - hide_labels: hide month/day labels (true/false)
+//   - title: custom title text
+//   - tz: IANA timezone for day bucketing (defaults to the owner's preference, then UTC)
+//   - week_start: first row of each week, sunday or monday (defaults to the owner's preference)
+//   - events: comma-separated event types to include (push,pull,build)
+//   - weight_push, weight_pull, weight_build: per-type weight for levels (0-10, default 1)
+//   - min_confidence: skip events whose timestamp source is less reliable (low, medium, high)
+//   - tag: comma-separated tag names or glob patterns to include (e.g. latest, nightly-*, v1.*)
+//   - bg_color: custom background color (hex without #)
+//   - text_color: custom text color (hex without #)
+//   - color0-color4: custom level colors (hex without #)
+//   - border_color: cell border color (hex without #), overrides the theme's
+//   - gradient: soft gradient cell fills (true/false), overrides the theme's
+//   - auto_contrast: adjust the text color if it is unreadable on the background (true/false)
+//   - font: default, system-ui, monospace, serif or go-mono (bundled, embedded in the SVG)
+//   - font_size: base label size in px (8-16, default 10)
+//   - refresh: with the owner's Authorization header, skip shared caches (rate-limited)
+func (h *HeatmapHandler) GetHeatmapSVG(c *fiber.Ctx) error {
+	username := c.Params("username")
+
+	// Remove .svg extension if present
+	username = strings.TrimSuffix(username, ".svg")
+
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	opts := parseSVGOptions(c)
+	if err := applyDateRange(c, &opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	var warnings []services.ThemeWarning
+	svg, cached, err := h.cachedRender(c, username, "image/svg+xml", func() ([]byte, error) {
+		svg, renderWarnings, err := h.heatmapService.GenerateSVGWithWarnings(username, opts)
+		warnings = renderWarnings
+		return svg, err
+	})
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate heatmap",
+		})
+	}
+
+	if cached {
+		_, warnings = services.ValidateOptionsContrast(opts)
+	}
+	if len(warnings) > 0 {
+		c.Set("X-Heatmap-Warnings", services.FormatWarningsHeader(warnings))
+	}
+	c.Set("Content-Type", "image/svg+xml")
+	h.setCacheHeaders(c, username)
+	return c.Send(svg)
+}
+
+// GetSampleSVG renders the heatmap from generated sample data, so themes and options can
+// be previewed without a connected account
+// Query params:
+//   - seed: sample data seed (positive integer, default 42)
+//   - all GetHeatmapSVG customization params
+func (h *HeatmapHandler) GetSampleSVG(c *fiber.Ctx) error {
+	var seed int64
+	if v := c.Query("seed"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			seed = parsed
+		}
+	}
+
+	svg, warnings, err := h.heatmapService.GenerateSampleSVG(parseSVGOptions(c), seed)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate preview",
+		})
+	}
+
+	if len(warnings) > 0 {
+		c.Set("X-Heatmap-Warnings", services.FormatWarningsHeader(warnings))
+	}
+	c.Set("Content-Type", "image/svg+xml")
+	// Sample data only changes when the day rolls over
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", services.DefaultCacheMaxAge))
+	return c.Send(svg)
+}
+
+// GetHeatmapText returns the heatmap as text for terminals (curl, MOTD)
+// Query params:
+//   - no_color: use block shading instead of ANSI colors (true/false)
+//   - days, theme, tz, week_start, events, hide_* and title as in GetHeatmapSVG
+func (h *HeatmapHandler) GetHeatmapText(c *fiber.Ctx) error {
+	username := c.Params("username")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	opts := parseSVGOptions(c)
+	if err := applyDateRange(c, &opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	noColor := c.Query("no_color") == "true" || c.Query("no_color") == "1"
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	text, err := h.heatmapService.GenerateTextHeatmap(username, opts, noColor)
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate heatmap",
+		})
+	}
+
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	h.setCacheHeaders(c, username)
+	return c.SendString(text)
+}
+
+// GetHeatmapPNG returns the heatmap as a PNG for places that don't render SVG. Clients
+// that list image/webp in Accept get the same image as a WebP instead.
+// Query params: as in GetHeatmapSVG (gradient fills are flattened to the level colors)
+func (h *HeatmapHandler) GetHeatmapPNG(c *fiber.Ctx) error {
+	c.Vary(fiber.HeaderAccept)
+	if acceptsWebP(c) {
+		c.Locals(rasterFormatKey, "webp")
+		return h.sendRaster(c, "image/webp", h.heatmapService.GenerateWebP)
+	}
+	return h.sendRaster(c, "image/png", h.heatmapService.GeneratePNG)
+}
+
+// GetHeatmapWebP returns the heatmap as a lossless WebP, smaller than the PNG
+// Query params: as in GetHeatmapPNG
+func (h *HeatmapHandler) GetHeatmapWebP(c *fiber.Ctx) error {
+	return h.sendRaster(c, "image/webp", h.heatmapService.GenerateWebP)
+}
+
+// rasterFormatKey records the format the PNG route negotiated, which its ETag and caches
+// tell apart
+const rasterFormatKey = "raster_format"
+
+// acceptsWebP reports whether the client lists image/webp in Accept, without q=0.
+// Wildcards don't count, since they also match the PNG the URL names.
+func acceptsWebP(c *fiber.Ctx) bool {
+	for _, value := range strings.Split(c.Get(fiber.HeaderAccept), ",") {
+		mediaType, params, _ := strings.Cut(value, ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), "image/webp") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if q, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				weight, err := strconv.ParseFloat(q, 64)
+				return err == nil && weight > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// GetHeatmapGIF returns the heatmap as a small looping GIF that fills in over time
+// Query params: as in GetHeatmapSVG
+func (h *HeatmapHandler) GetHeatmapGIF(c *fiber.Ctx) error {
+	return h.sendRaster(c, "image/gif", h.heatmapService.GenerateAnimatedGIF)
+}
+
+func (h *HeatmapHandler) sendRaster(c *fiber.Ctx, contentType string, render func(string, services.SVGOptions) ([]byte, error)) error {
+	username := c.Params("username")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	opts := parseSVGOptions(c)
+	if err := applyDateRange(c, &opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	image, _, err := h.cachedRender(c, username, contentType, func() ([]byte, error) {
+		return render(username, opts)
+	})
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate heatmap",
+		})
+	}
+
+	c.Set("Content-Type", contentType)
+	h.setCacheHeaders(c, username)
+	return c.Send(image)
+}
+
+// GetHeatmapSnapshot returns an immutable SVG frozen at the given date
+// Query params:
+//   - until: last day included in the snapshot (YYYY-MM-DD, must be in the past)
+//   - all GetHeatmapSVG customization params
+func (h *HeatmapHandler) GetHeatmapSnapshot(c *fiber.Ctx) error {
+	username := c.Params("username")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	until, err := time.Parse("2006-01-02", c.Query("until"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "until must be a date in YYYY-MM-DD format",
+		})
+	}
+
+	opts := parseSVGOptions(c)
+
+	svg, err := h.snapshotService.GetOrCreateSnapshot(username, until, opts)
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
+			})
+		}
+		if err == services.ErrSnapshotDateInvalid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err == services.ErrReadOnly {
+			return middleware.ReadOnlyError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate snapshot",
+		})
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	if maxAge, signed := signedEmbedMaxAge(c, 31536000); signed {
+		c.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+		return c.Send(svg)
+	}
+	c.Set("Cache-Control", "public, max-age=31536000, immutable") // Snapshots never change
+	return c.Send(svg)
+}
+
+// GetMonthlyChartSVG returns a 12-month bar chart of activity
+// Accepts the same theme, color, title, tz and event params as GetHeatmapSVG
+func (h *HeatmapHandler) GetMonthlyChartSVG(c *fiber.Ctx) error {
+	username := c.Params("username")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	svg, _, err := h.cachedRender(c, username, "image/svg+xml", func() ([]byte, error) {
+		return h.heatmapService.GenerateMonthlyChartSVG(username, parseSVGOptions(c))
+	})
+	if err != nil {
+		return renderErrorResponse(c, err)
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	h.setCacheHeaders(c, username)
+	return c.Send(svg)
+}
+
+// GetSparklineSVG returns a compact 52-week activity trend line
+// Accepts the same theme, color, title, tz and event params as GetHeatmapSVG
+func (h *HeatmapHandler) GetSparklineSVG(c *fiber.Ctx) error {
+	username := strings.TrimSuffix(c.Params("username"), ".svg")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	svg, _, err := h.cachedRender(c, username, "image/svg+xml", func() ([]byte, error) {
+		return h.heatmapService.GenerateSparklineSVG(username, parseSVGOptions(c))
+	})
+	if err != nil {
+		return renderErrorResponse(c, err)
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	h.setCacheHeaders(c, username)
+	return c.Send(svg)
+}
+
+// GetPunchcardSVG returns a day-of-week × hour-of-day punchcard of push times
+// Accepts the same theme, color, title, tz, week_start, events and days params as GetHeatmapSVG
+func (h *HeatmapHandler) GetPunchcardSVG(c *fiber.Ctx) error {
+	username := strings.TrimSuffix(c.Params("username"), ".svg")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	svg, _, err := h.cachedRender(c, username, "image/svg+xml", func() ([]byte, error) {
+		return h.heatmapService.GeneratePunchcardSVG(username, parseSVGOptions(c))
+	})
+	if err != nil {
+		return renderErrorResponse(c, err)
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	h.setCacheHeaders(c, username)
+	return c.Send(svg)
+}
+
+// GetTeamHeatmapSVG renders several accounts on one shared calendar, coloring each day by
+// which members were active
+// Query params:
+//   - members: comma-separated Docker usernames (required, up to 5)
+//   - mode: split (one stripe per active member, sized by their share; default) or mix
+//     (member colors blended by share)
+//   - member_colors: comma-separated member colors (hex without #) in member order; blank
+//     entries keep the default palette
+//   - all GetHeatmapSVG customization params except color levels and gradient; the theme's
+//     empty-cell color is used as the background of each day, and tz and week_start default
+//     to the first member's preferences
+func (h *HeatmapHandler) GetTeamHeatmapSVG(c *fiber.Ctx) error {
+	members, err := services.ParseTeamMembers(c.Query("members"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if ok, err := h.requirePublicProfiles(c, members); !ok {
+		return err
+	}
+
+	mode := strings.ToLower(c.Query("mode", services.TeamModeSplit))
+	if mode != services.TeamModeSplit && mode != services.TeamModeMix {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mode must be split or mix",
+		})
+	}
+
+	var memberColors []string
+	if v := c.Query("member_colors"); v != "" {
+		for _, clr := range strings.Split(v, ",") {
+			if clr = strings.TrimSpace(clr); clr != "" {
+				clr = parseHexColor(clr)
+			}
+			memberColors = append(memberColors, clr)
+		}
+	}
+
+	opts := parseSVGOptions(c)
+	if err := applyDateRange(c, &opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	etag, err := h.dockerService.TeamResponseETag(members, c.Query("tz"), responseVariant(c), time.Now())
+	if err == nil {
+		c.Set("ETag", etag)
+		if etagMatches(c, etag) {
+			h.setTeamCacheHeaders(c, members)
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	svg, err := h.heatmapService.GenerateTeamSVG(members, mode, memberColors, opts)
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "One or more team members not found or have no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate team heatmap",
+		})
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	h.setTeamCacheHeaders(c, members)
+	return c.Send(svg)
+}
+
+// setTeamCacheHeaders caches a team render for as long as its shortest-lived member allows,
+// and starts background syncs for members that are stale
+func (h *HeatmapHandler) setTeamCacheHeaders(c *fiber.Ctx, members []string) {
+	for _, name := range members {
+		h.dockerService.SyncIfStale(name)
+	}
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", h.dockerService.TeamCacheMaxAge(members)))
+}
+
+// GetComparison returns two accounts' totals and streaks side by side over the same days,
+// bucketed in the first user's timezone
+// Query params:
+//   - days: trailing window including today (default as in GetActivityJSON)
+//   - from, to: explicit date range (YYYY-MM-DD, inclusive), overrides days
+//   - tz, events, weight_*, min_confidence, tag: as in GetHeatmapSVG
+func (h *HeatmapHandler) GetComparison(c *fiber.Ctx) error {
+	users := []string{c.Params("userA"), c.Params("userB")}
+	if ok, err := h.requirePublicProfiles(c, users); !ok {
+		return err
+	}
+
+	days := config.AppConfig.Render.DefaultDays
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && services.ValidDays(parsed) {
+			days = parsed
+		}
+	}
+
+	from, to, hasRange, err := services.ParseDateRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	etag, err := h.dockerService.TeamResponseETag(users, c.Query("tz"), responseVariant(c), time.Now())
+	if err == nil {
+		c.Set("ETag", etag)
+		if etagMatches(c, etag) {
+			h.setTeamCacheHeaders(c, users)
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	loc := h.dockerService.ResolveLocation(users[0], c.Query("tz"))
+	if hasRange {
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+		to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	} else {
+		to = time.Now().In(loc)
+		from = to.AddDate(0, 0, -days+1)
+	}
+
+	eventTypes, weights := parseEventFilter(c)
+	comparison, err := h.dockerService.CompareAccounts(users[0], users[1], from, to, services.ActivityFilter{
+		Location:      loc,
+		EventTypes:    eventTypes,
+		Weights:       weights,
+		MinConfidence: parseMinConfidence(c),
+		Tags:          services.ParseTagPatterns(c.Query("tag")),
+	})
+	if err != nil {
+		if err == services.ErrCompareSameUser {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "One or both users not found or have no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compare activity",
+		})
+	}
+
+	h.setTeamCacheHeaders(c, users)
+	return c.JSON(fiber.Map{
+		"timezone":         loc.String(),
+		"from":             comparison.From,
+		"to":               comparison.To,
+		"users":            comparison.Users,
+		"leader":           comparison.Leader,
+		"both_active_days": comparison.BothActiveDays,
+	})
+}
+
+// GetComparisonSVG renders two accounts over the same days, aligned on the first user's
+// timezone and week start
+// Query params:
+//   - mode: stack (one grid per user on a shared scale; default) or diff (one calendar where
+//     each day takes the color of whoever was more active)
+//   - member_colors: the two diff colors (hex without #), comma-separated
+//   - all GetHeatmapSVG customization params except years
+func (h *HeatmapHandler) GetComparisonSVG(c *fiber.Ctx) error {
+	users := []string{c.Params("userA"), strings.TrimSuffix(c.Params("userB"), ".svg")}
+	if ok, err := h.requirePublicProfiles(c, users); !ok {
+		return err
+	}
+
+	mode := strings.ToLower(c.Query("mode", services.CompareModeStack))
+	if mode != services.CompareModeStack && mode != services.CompareModeDiff {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mode must be stack or diff",
+		})
+	}
+
+	var memberColors []string
+	if v := c.Query("member_colors"); v != "" {
+		for _, clr := range strings.Split(v, ",") {
+			if clr = strings.TrimSpace(clr); clr != "" {
+				clr = parseHexColor(clr)
+			}
+			memberColors = append(memberColors, clr)
+		}
+	}
+
+	opts := parseSVGOptions(c)
+	if err := applyDateRange(c, &opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	etag, err := h.dockerService.TeamResponseETag(users, c.Query("tz"), responseVariant(c), time.Now())
+	if err == nil {
+		c.Set("ETag", etag)
+		if etagMatches(c, etag) {
+			h.setTeamCacheHeaders(c, users)
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	svg, err := h.heatmapService.GenerateCompareSVG(users[0], users[1], mode, memberColors, opts)
+	if err != nil {
+		if err == services.ErrCompareSameUser {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "One or both users not found or have no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate comparison",
+		})
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	h.setTeamCacheHeaders(c, users)
+	return c.Send(svg)
+}
+
+// cachedRender returns the body for this response from the render cache, or renders and
+// stores it. Entries are keyed by the ETag set by notModified, so it must run after it;
+// owner refreshes skip the lookup but still store the fresh render. Fresh renders are also
+// copied to standby storage, which answers instead when rendering fails with the database down.
+func (h *HeatmapHandler) cachedRender(c *fiber.Ctx, username, contentType string, render func() ([]byte, error)) ([]byte, bool, error) {
+	etag := c.GetRespHeader("ETag")
+	if etag != "" && !h.bypassCache(c, username) {
+		if body, ok := h.renderCache.Get(username, etag); ok {
+			return body, true, nil
+		}
+	}
+
+	variant := responseVariant(c)
+	body, err := render()
+	if err != nil {
+		if !database.Available() {
+			if body, ok := h.standby.Get(username, variant); ok {
+				c.Locals(standbyKey, true)
+				return body, true, nil
+			}
+		}
+		return nil, false, err
+	}
+
+	if etag != "" {
+		h.renderCache.Set(username, etag, body)
+	}
+	// Standby answers without checking who may see it, so private profiles stay out
+	if _, signed := c.Locals(signedEmbedKey).(int64); !signed {
+		h.standby.Put(username, variant, contentType, body)
+	}
+	return body, false, nil
+}
+
+// standbyKey marks a response served from standby storage
+const standbyKey = "served_from_standby"
+
+// renderErrorResponse maps errors from the render services to HTTP responses
+func renderErrorResponse(c *fiber.Ctx, err error) error {
+	if err == services.ErrDockerAccountNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found or no Docker account connected",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to generate image",
+	})
+}
+
+// setCacheHeaders caches rendered activity until just after the account's next expected sync.
+// Stale accounts are served as-is while a background sync runs, and the short max-age
+// of an in-progress sync lets clients pick up the result soon after.
+func (h *HeatmapHandler) setCacheHeaders(c *fiber.Ctx, username string) {
+	if standby, _ := c.Locals(standbyKey).(bool); standby {
+		c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", services.MinCacheMaxAge))
+		c.Set("X-Served-From", "standby")
+		return
+	}
+
+	h.dockerService.SyncIfStale(username)
+
+	if h.bypassCache(c, username) {
+		c.Set("Cache-Control", "private, no-store")
+		c.Set("X-Cache-Bypass", "owner-refresh")
+		return
+	}
+	// A private profile's signed response is kept out of shared caches, which could
+	// otherwise go on serving it after the signature expires
+	if maxAge, signed := signedEmbedMaxAge(c, h.dockerService.CacheMaxAge(username)); signed {
+		c.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+		return
+	}
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", h.dockerService.CacheMaxAge(username)))
+}
+
+// notModified sets the response ETag and reports whether the client's cached copy
+// (If-None-Match) is still current, in which case the caller should answer 304.
+// Owner refreshes always get a full response.
+func (h *HeatmapHandler) notModified(c *fiber.Ctx, username string) bool {
+	etag, err := h.dockerService.ResponseETag(username, c.Query("tz"), responseVariant(c), time.Now())
+	if err != nil {
+		return false
+	}
+	c.Set("ETag", etag)
+	if h.bypassCache(c, username) {
+		return false
+	}
+
+	return etagMatches(c, etag)
+}
+
+// etagMatches reports whether the request's If-None-Match lists etag
+func etagMatches(c *fiber.Ctx, etag string) bool {
+	for _, candidate := range strings.Split(c.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sendNotModified answers a conditional GET whose cached copy is current
+func (h *HeatmapHandler) sendNotModified(c *fiber.Ctx, username string) error {
+	h.setCacheHeaders(c, username)
+	return c.SendStatus(fiber.StatusNotModified)
+}
+
+// responseVariant identifies what was requested: the path plus its query params in a
+// stable order, and the format when it was negotiated. refresh only affects caching, so it
+// is left out.
+func responseVariant(c *fiber.Ctx) string {
+	queries := c.Queries()
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		if key != "refresh" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(c.Path())
+	for _, key := range keys {
+		b.WriteString("&" + key + "=" + queries[key])
+	}
+	if format, ok := c.Locals(rasterFormatKey).(string); ok {
+		b.WriteString("#" + format)
+	}
+	return b.String()
+}
+
+// ownerRefreshLimiter bounds how often an owner can force uncached renders
+var ownerRefreshLimiter = middleware.NewRateLimiter(10, 10*time.Minute)
+
+const cacheBypassKey = "cache_bypass"
+
+// bypassCache reports whether the request is the account owner's ?refresh=1, which skips
+// shared caches so a manual sync shows up immediately. Refreshes from anyone else, or from
+// an owner over the rate limit, are ignored. The decision is made once per request.
+func (h *HeatmapHandler) bypassCache(c *fiber.Ctx, username string) bool {
+	if bypass, ok := c.Locals(cacheBypassKey).(bool); ok {
+		return bypass
+	}
+
+	bypass := false
+	if r := c.Query("refresh"); r == "1" || r == "true" {
+		if user := middleware.GetUserFromContext(c); user != nil {
+			if owner, err := h.dockerService.GetAccountOwner(username); err == nil && owner.ID == user.ID {
+				bypass = ownerRefreshLimiter.Allow(fmt.Sprintf("user:%d", user.ID))
+			}
+		}
+	}
+
+	c.Locals(cacheBypassKey, bypass)
+	return bypass
+}
+
+// parseSVGOptions reads the SVG customization query params shared by all heatmap renders
+func parseSVGOptions(c *fiber.Ctx) services.SVGOptions {
+	opts := services.DefaultSVGOptions()
+	opts.Theme = c.Query("theme", "github")
+	opts.HideLegend = c.Query("hide_legend") == "true" || c.Query("hide_legend") == "1"
+	opts.HideTotal = c.Query("hide_total") == "true" || c.Query("hide_total") == "1"
+	opts.HideLabels = c.Query("hide_labels") == "true" || c.Query("hide_labels") == "1"
+	opts.CustomTitle = c.Query("title")
+	opts.Timezone = c.Query("tz")
+	opts.WeekStart = strings.ToLower(c.Query("week_start"))
+	opts.AutoContrast = c.Query("auto_contrast") == "true" || c.Query("auto_contrast") == "1"
+	opts.Font = strings.ToLower(c.Query("font"))
+
+	if fs := c.Query("font_size"); fs != "" {
+		if parsed, err := strconv.Atoi(fs); err == nil && parsed >= services.MinFontSize && parsed <= services.MaxFontSize {
+			opts.FontSize = parsed
+		}
+	}
+
+	opts.EventTypes, opts.EventWeights = parseEventFilter(c)
+	opts.MinConfidence = parseMinConfidence(c)
+	opts.Tags = services.ParseTagPatterns(c.Query("tag"))
+
+	// Parse numeric options with validation
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && services.ValidDays(parsed) {
+			opts.Days = parsed
+		}
+	}
+
+	if y := c.Query("years"); y != "" {
+		if parsed, err := strconv.Atoi(y); err == nil && parsed > 0 && parsed <= services.MaxHeatmapYears {
+			opts.Years = parsed
+		}
+	}
+
+	if cs := c.Query("cell_size"); cs != "" {
+		if parsed, err := strconv.Atoi(cs); err == nil && services.ValidCellSize(parsed) {
+			opts.CellSize = parsed
+		}
+	}
+
+	if r := c.Query("radius"); r != "" {
+		if parsed, err := strconv.Atoi(r); err == nil && services.ValidRadius(parsed) {
+			opts.CellRadius = parsed
+		}
+	}
+
+	// Parse custom colors
+	if bg := c.Query("bg_color"); bg != "" {
+		opts.BgColor = parseHexColor(bg)
+	}
+	if txt := c.Query("text_color"); txt != "" {
+		opts.TextColor = parseHexColor(txt)
+	}
+	if border := c.Query("border_color"); border != "" {
+		opts.BorderColor = parseHexColor(border)
+	}
+	if g := c.Query("gradient"); g != "" {
+		gradient := g == "true" || g == "1"
+		opts.Gradient = &gradient
+	}
+
+	// Custom level colors
+	customColors := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		if clr := c.Query(fmt.Sprintf("color%d", i)); clr != "" {
+			customColors = append(customColors, parseHexColor(clr))
+		}
+	}
+	if len(customColors) == 5 {
+		opts.CustomColors = customColors
+		opts.Theme = "custom"
+	}
+
+	return opts
+}
+
+// applyDateRange sets an explicit from/to range on opts, replacing the days window
+func applyDateRange(c *fiber.Ctx, opts *services.SVGOptions) error {
+	from, to, ok, err := services.ParseDateRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		return err
+	}
+	if ok {
+		opts.StartDate, opts.EndDate = from, to
+	}
+	return nil
+}
+
+// parseEventFilter reads the events=push,pull,build filter and weight_<type> params (0-10)
+func parseEventFilter(c *fiber.Ctx) ([]models.EventType, map[models.EventType]float64) {
+	var eventTypes []models.EventType
+	if e := c.Query("events"); e != "" {
+		eventTypes = services.ParseEventTypes(e)
+	}
+
+	var weights map[models.EventType]float64
+	for _, eventType := range []models.EventType{models.EventTypePush, models.EventTypePull, models.EventTypeBuild} {
+		w := c.Query("weight_" + string(eventType))
+		if w == "" {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(w, 64); err == nil && parsed >= 0 && parsed <= 10 {
+			if weights == nil {
+				weights = make(map[models.EventType]float64)
+			}
+			weights[eventType] = parsed
+		}
+	}
+
+	return eventTypes, weights
+}
+
+// parseMinConfidence reads min_confidence=low|medium|high; anything else includes all sources
+func parseMinConfidence(c *fiber.Ctx) models.Confidence {
+	confidence, _ := models.ParseConfidence(strings.ToLower(c.Query("min_confidence")))
+	return confidence
+}
+
+// parseHexColor ensures color has # prefix, returning "" unless it is a valid hex color
+func parseHexColor(color string) string {
+	color = strings.TrimSpace(color)
+	if color == "" {
+		return ""
+	}
+	if !strings.HasPrefix(color, "#") {
+		color = "#" + color
+	}
+	return services.SanitizeColor(color)
+}
+
+// GetAvailableThemes returns all available SVG themes with details
+func (h *HeatmapHandler) GetAvailableThemes(c *fiber.Ctx) error {
+	themes := make([]fiber.Map, 0)
+
+	for _, name := range services.ThemeOrder {
+		if theme, ok := services.Themes[name]; ok {
+			themes = append(themes, fiber.Map{
+				"id":           name,
+				"name":         theme.Name,
+				"bg_color":     theme.BgColor,
+				"text_color":   theme.TextColor,
+				"colors":       theme.Colors,
+				"gradient":     theme.Gradient,
+				"border_color": theme.BorderColor,
+				"warnings":     services.ValidateThemeContrast(theme),
+			})
+		}
+	}
+
+	// Validate custom colors passed as query params, so theme builders get feedback
+	var custom fiber.Map
+	if opts := parseSVGOptions(c); opts.Theme == "custom" {
+		theme := services.Theme{
+			Name:      "Custom",
+			BgColor:   opts.BgColor,
+			TextColor: opts.TextColor,
+			Colors:    opts.CustomColors,
+		}
+		custom = fiber.Map{
+			"bg_color":             theme.BgColor,
+			"text_color":           theme.TextColor,
+			"colors":               theme.Colors,
+			"warnings":             services.ValidateThemeContrast(theme),
+			"suggested_text_color": services.AdjustTextContrast(theme),
+		}
+	}
+
+	limits := config.AppConfig.Render
+	return c.JSON(fiber.Map{
+		"themes": themes,
+		"custom": custom,
+		"limits": fiber.Map{
+			"days":      fiber.Map{"default": limits.DefaultDays, "min": 1, "max": limits.MaxDays},
+			"cell_size": fiber.Map{"default": limits.DefaultCellSize, "min": limits.MinCellSize, "max": limits.MaxCellSize},
+			"radius":    fiber.Map{"default": limits.DefaultRadius, "min": 0, "max": limits.MaxRadius},
+		},
+		"customization": fiber.Map{
+			"description": "You can also create custom themes using query parameters",
+			"params": fiber.Map{
+				"bg_color":      "Background color (hex without #)",
+				"text_color":    "Text color (hex without #)",
+				"color0":        "Level 0 (no activity) color",
+				"color1":        "Level 1 (low) color",
+				"color2":        "Level 2 (medium) color",
+				"color3":        "Level 3 (high) color",
+				"color4":        "Level 4 (max) color",
+				"border_color":  "Cell border color (hex without #)",
+				"gradient":      "Gradient cell fills between levels (true/false)",
+				"auto_contrast": "Adjust the text color when it fails WCAG contrast (true/false)",
+				"font":          "Font: " + strings.Join(services.GetAvailableFonts(), ", "),
+				"font_size":     "Base label size in px (8-16, default 10)",
+			},
+			"example": "/api/v1/heatmap/username.svg?theme=custom&bg_color=1a1a2e&color0=16213e&color1=0f3460&color2=533483&color3=e94560&color4=ff6b6b",
+		},
+	})
+}
+
+// ValidateTheme checks a theme for legibility without rendering anything
+// Accepts theme, the custom color params and auto_contrast, like GetHeatmapSVG
+func (h *HeatmapHandler) ValidateTheme(c *fiber.Ctx) error {
+	opts := parseSVGOptions(c)
+	if opts.Theme != "custom" {
+		if _, ok := services.Themes[opts.Theme]; !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Unknown theme",
+			})
+		}
+	}
+
+	theme, warnings := services.ValidateOptionsContrast(opts)
+	return c.JSON(fiber.Map{
+		"theme":                opts.Theme,
+		"bg_color":             theme.BgColor,
+		"text_color":           theme.TextColor,
+		"colors":               theme.Colors,
+		"valid":                len(warnings) == 0,
+		"warnings":             warnings,
+		"suggested_text_color": services.AdjustTextContrast(theme),
+		"minimums": fiber.Map{
+			"text":           services.MinTextContrast,
+			"level":          services.MinLevelContrast,
+			"adjacent_level": services.MinAdjacentLevelContrast,
+		},
+	})
+}
+
+// GetActivityJSON returns activity data as JSON
+// Query params:
+//   - days: number of days (1-365 and default 365 unless the deployment sets RENDER_* limits)
+//   - from, to: explicit date range (YYYY-MM-DD, inclusive, up to 5 years), overrides days
+//   - breakdown: "repo" adds a per-day map of repository to count
+//   - granularity: day (default), week or month; buckets are dated by their first day
+//   - week_start: first day of weekly buckets, sunday or monday (defaults to the owner's preference)
+//   - tz, events, weight_*, min_confidence, tag: as in GetHeatmapSVG
+func (h *HeatmapHandler) GetActivityJSON(c *fiber.Ctx) error {
+	return h.sendActivity(c, strings.TrimSuffix(c.Params("username"), ".json"), fiber.MIMEApplicationJSON, func(username string, activity fiber.Map) ([]byte, error) {
+		return json.Marshal(activity)
+	})
+}
+
+// GetActivityCSV returns the same activity as GetActivityJSON as CSV, one row per day, week
+// or month, for spreadsheets and BI tools
+// Query params: as in GetActivityJSON, except breakdown
+func (h *HeatmapHandler) GetActivityCSV(c *fiber.Ctx) error {
+	return h.sendActivity(c, strings.TrimSuffix(c.Params("username"), ".csv"), "text/csv; charset=utf-8", func(username string, activity fiber.Map) ([]byte, error) {
+		return services.ActivityCSV(activity["activity"].([]models.ActivitySummary))
+	})
 }
 
-func NewHeatmapHandler() *HeatmapHandler {
-	return &HeatmapHandler{
-		heatmapService: services.NewHeatmapService(),
-		dockerService:  services.NewDockerHubService(),
+// GetActivityJWS returns the same activity as GetActivityJSON as a compact JWS (EdDSA),
+// so third parties can verify it came from this deployment. The claims hold the response
+// under "activity", the username as "sub" and the deployment's URL as "iss"; the public
+// key is published at /.well-known/jwks.json under the token's "kid".
+// Query params: as in GetActivityJSON
+func (h *HeatmapHandler) GetActivityJWS(c *fiber.Ctx) error {
+	if len(services.SigningKeys()) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Signed activity is not enabled on this server",
+		})
 	}
-}
 
-// GetHeatmapSVG returns the heatmap as an SVG image with customization options
-// Query params:
-//   - days: number of days (1-365, default 365)
-//   - theme: color theme (github, docker, dracula, nord, etc.) or "custom"
-//   - cell_size: size of each cell (5-20, default 11)
-//   - radius: border radius of cells (0-10, default 2)
-//   - hide_legend: hide the color legend (true/false)
-//   - hide_total: hide the total count (true/false)
-//   - hide_labels: hide month/day labels (true/false)
-//   - title: custom title text
-//   - bg_color: custom background color (hex without #)
-//   - text_color: custom text color (hex without #)
-//   - color0-color4: custom level colors (hex without #)
-func (h *HeatmapHandler) GetHeatmapSVG(c *fiber.Ctx) error {
-	username := c.Params("username")
+	return h.sendActivity(c, strings.TrimSuffix(c.Params("username"), ".jws"), "application/jose", func(username string, activity fiber.Map) ([]byte, error) {
+		token, err := services.SignActivity(username, activity)
+		return []byte(token), err
+	})
+}
 
-	// Remove .svg extension if present
-	username = strings.TrimSuffix(username, ".svg")
+// GetJWKS publishes the public keys signed activity can be verified with
+func (h *HeatmapHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=3600")
+	return c.JSON(fiber.Map{
+		"keys": services.SigningKeys(),
+	})
+}
 
+// sendActivity answers an activity request, encoding the activity response with encode
+func (h *HeatmapHandler) sendActivity(c *fiber.Ctx, username, contentType string, encode func(string, fiber.Map) ([]byte, error)) error {
 	if username == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Username is required",
 		})
 	}
 
-	// Parse options from query params
-	opts := services.SVGOptions{
-		Theme:       c.Query("theme", "github"),
-		Days:        365,
-		CellSize:    11,
-		CellRadius:  2,
-		HideLegend:  c.Query("hide_legend") == "true" || c.Query("hide_legend") == "1",
-		HideTotal:   c.Query("hide_total") == "true" || c.Query("hide_total") == "1",
-		HideLabels:  c.Query("hide_labels") == "true" || c.Query("hide_labels") == "1",
-		CustomTitle: c.Query("title"),
-	}
-
-	// Parse numeric options with validation
+	days := config.AppConfig.Render.DefaultDays
 	if d := c.Query("days"); d != "" {
-		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
-			opts.Days = parsed
+		if parsed, err := strconv.Atoi(d); err == nil && services.ValidDays(parsed) {
+			days = parsed
 		}
 	}
 
-	if cs := c.Query("cell_size"); cs != "" {
-		if parsed, err := strconv.Atoi(cs); err == nil && parsed >= 5 && parsed <= 20 {
-			opts.CellSize = parsed
+	granularity := strings.ToLower(c.Query("granularity", services.GranularityDay))
+	if !services.IsValidGranularity(granularity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "granularity must be day, week or month",
+		})
+	}
+
+	from, to, hasRange, err := services.ParseDateRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	body, _, err := h.cachedRender(c, username, contentType, func() ([]byte, error) {
+		loc := h.dockerService.ResolveLocation(username, c.Query("tz"))
+		eventTypes, weights := parseEventFilter(c)
+		filter := services.ActivityFilter{
+			Location:      loc,
+			EventTypes:    eventTypes,
+			Weights:       weights,
+			MinConfidence: parseMinConfidence(c),
+			Tags:          services.ParseTagPatterns(c.Query("tag")),
+			RepoBreakdown: c.Query("breakdown") == "repo",
+		}
+		var activities []models.ActivitySummary
+		var err error
+		if hasRange {
+			// Dates are calendar days in the resolved timezone, not UTC instants
+			from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+			to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+			days = int(to.Sub(from).Hours()/24) + 1
+			activities, err = h.dockerService.GetActivitySummaryRange(username, from, to, filter)
+		} else {
+			activities, err = h.dockerService.GetActivitySummary(username, days, filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Calculate totals
+		var totalActivities, totalPushes, totalPulls, totalBuilds int
+		for _, a := range activities {
+			totalActivities += a.TotalCount
+			totalPushes += a.Pushes
+			totalPulls += a.Pulls
+			totalBuilds += a.Builds
 		}
+
+		if granularity != services.GranularityDay {
+			firstDay := h.dockerService.ResolveWeekStart(username, c.Query("week_start"))
+			activities = services.RollupActivity(activities, granularity, firstDay)
+		}
+
+		return encode(username, fiber.Map{
+			"username":    username,
+			"days":        days,
+			"granularity": granularity,
+			"timezone":    loc.String(),
+			"totals": fiber.Map{
+				"activities": totalActivities,
+				"pushes":     totalPushes,
+				"pulls":      totalPulls,
+				"builds":     totalBuilds,
+			},
+			"activity": activities,
+		})
+	})
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch activity",
+		})
 	}
 
-	if r := c.Query("radius"); r != "" {
-		if parsed, err := strconv.Atoi(r); err == nil && parsed >= 0 && parsed <= 10 {
-			opts.CellRadius = parsed
+	c.Set("Content-Type", contentType)
+	h.setCacheHeaders(c, username)
+	return c.Send(body)
+}
+
+// GetActivityDay returns the repositories and tags behind a single day of activity.
+// Only available for public profiles, since it reveals more than the heatmap itself.
+// Query params:
+//   - tz, events, min_confidence, tag: as in GetHeatmapSVG
+func (h *HeatmapHandler) GetActivityDay(c *fiber.Ctx) error {
+	username := c.Params("username")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	owner, err := h.dockerService.GetAccountOwner(username)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found or no Docker account connected",
+		})
+	}
+	if !owner.PublicProfile {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Profile is private",
+		})
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	eventTypes, _ := parseEventFilter(c)
+	detail, err := h.dockerService.GetDayDetail(username, c.Params("date"), services.ActivityFilter{
+		Location:      h.dockerService.ResolveLocation(username, c.Query("tz")),
+		EventTypes:    eventTypes,
+		MinConfidence: parseMinConfidence(c),
+		Tags:          services.ParseTagPatterns(c.Query("tag")),
+	})
+	if err != nil {
+		switch err {
+		case services.ErrDayInvalid:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case services.ErrDockerAccountNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
+			})
 		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch activity",
+		})
 	}
 
-	// Parse custom colors
-	if bg := c.Query("bg_color"); bg != "" {
-		opts.BgColor = parseHexColor(bg)
+	h.setCacheHeaders(c, username)
+	return c.JSON(fiber.Map{
+		"username": username,
+		"day":      detail,
+	})
+}
+
+// feedMaxEntries caps how many active days a feed lists
+const feedMaxEntries = 50
+
+// GetActivityFeed returns an Atom feed with one entry per day of activity, such as
+// "3 pushes to app:latest". Only available for public profiles, like GetActivityDay.
+// Query params:
+//   - days: how far back to look (1-90, default 30)
+//   - tz, events, min_confidence, tag: as in GetHeatmapSVG
+func (h *HeatmapHandler) GetActivityFeed(c *fiber.Ctx) error {
+	username := strings.TrimSuffix(c.Params("username"), ".atom")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
 	}
-	if txt := c.Query("text_color"); txt != "" {
-		opts.TextColor = parseHexColor(txt)
+
+	owner, err := h.dockerService.GetAccountOwner(username)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found or no Docker account connected",
+		})
+	}
+	if !owner.PublicProfile {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Profile is private",
+		})
 	}
 
-	// Custom level colors
-	customColors := make([]string, 0, 5)
-	for i := 0; i < 5; i++ {
-		if clr := c.Query(fmt.Sprintf("color%d", i)); clr != "" {
-			customColors = append(customColors, parseHexColor(clr))
+	days := 30
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 90 {
+			days = parsed
 		}
 	}
-	if len(customColors) == 5 {
-		opts.CustomColors = customColors
-		opts.Theme = "custom"
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
 	}
 
-	svg, err := h.heatmapService.GenerateSVGWithOptions(username, opts)
+	const contentType = "application/atom+xml; charset=utf-8"
+	body, _, err := h.cachedRender(c, username, contentType, func() ([]byte, error) {
+		eventTypes, _ := parseEventFilter(c)
+		active, err := h.dockerService.GetActiveDays(username, days, feedMaxEntries, services.ActivityFilter{
+			Location:      h.dockerService.ResolveLocation(username, c.Query("tz")),
+			EventTypes:    eventTypes,
+			MinConfidence: parseMinConfidence(c),
+			Tags:          services.ParseTagPatterns(c.Query("tag")),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		apiURL := c.BaseURL() + middleware.APIV1Prefix
+		return services.RenderActivityFeed(active, services.ActivityFeedOptions{
+			Username:   username,
+			SelfURL:    apiURL + "/feed/" + username + ".atom",
+			ProfileURL: config.AppConfig.FrontendURL + "/profile/" + username,
+			DayURL:     apiURL + "/activity/" + username,
+		})
+	})
 	if err != nil {
 		if err == services.ErrDockerAccountNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -106,91 +1293,139 @@ func (h *HeatmapHandler) GetHeatmapSVG(c *fiber.Ctx) error {
 			})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate heatmap",
+			"error": "Failed to build activity feed",
 		})
 	}
 
-	c.Set("Content-Type", "image/svg+xml")
-	c.Set("Cache-Control", "public, max-age=7200") // Cache for 2 hours
-	return c.Send(svg)
+	c.Set("Content-Type", contentType)
+	h.setCacheHeaders(c, username)
+	return c.Send(body)
 }
 
-// parseHexColor ensures color has # prefix
-func parseHexColor(color string) string {
-	color = strings.TrimSpace(color)
-	if color == "" {
-		return ""
+// GetActivityCalendar returns an iCalendar feed with an all-day event for each day of
+// activity, plus milestones such as record streaks and the 100th push. Only available
+// for public profiles, like GetActivityDay.
+// Query params:
+//   - days: how far back active days go (default 90); milestones cover all retained activity
+//   - tz, events, min_confidence, tag: as in GetHeatmapSVG, for the active days
+func (h *HeatmapHandler) GetActivityCalendar(c *fiber.Ctx) error {
+	username := strings.TrimSuffix(c.Params("username"), ".ics")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
 	}
-	if !strings.HasPrefix(color, "#") {
-		return "#" + color
+
+	owner, err := h.dockerService.GetAccountOwner(username)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found or no Docker account connected",
+		})
+	}
+	if !owner.PublicProfile {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Profile is private",
+		})
 	}
-	return color
-}
 
-// GetAvailableThemes returns all available SVG themes with details
-func (h *HeatmapHandler) GetAvailableThemes(c *fiber.Ctx) error {
-	themes := make([]fiber.Map, 0)
+	days := 90
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && services.ValidDays(parsed) {
+			days = parsed
+		}
+	}
 
-	// Define order for themes
-	order := []string{
-		"github", "github-light", "docker",
-		"dracula", "nord", "monokai", "one-dark", "tokyo-night", "catppuccin",
-		"ocean", "sunset", "forest", "purple", "rose",
-		"minimal", "minimal-dark",
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
 	}
 
-	for _, name := range order {
-		if theme, ok := services.Themes[name]; ok {
-			themes = append(themes, fiber.Map{
-				"id":         name,
-				"name":       theme.Name,
-				"bg_color":   theme.BgColor,
-				"text_color": theme.TextColor,
-				"colors":     theme.Colors,
+	const contentType = "text/calendar; charset=utf-8"
+	body, _, err := h.cachedRender(c, username, contentType, func() ([]byte, error) {
+		loc := h.dockerService.ResolveLocation(username, c.Query("tz"))
+		eventTypes, _ := parseEventFilter(c)
+		active, err := h.dockerService.GetActiveDays(username, days, 0, services.ActivityFilter{
+			Location:      loc,
+			EventTypes:    eventTypes,
+			MinConfidence: parseMinConfidence(c),
+			Tags:          services.ParseTagPatterns(c.Query("tag")),
+		})
+		if err != nil {
+			return nil, err
+		}
+		milestones, err := h.dockerService.GetMilestones(username, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		return services.RenderActivityCalendar(active, milestones, services.ActivityCalendarOptions{
+			Username:   username,
+			ProfileURL: config.AppConfig.FrontendURL + "/profile/" + username,
+			Domain:     c.Hostname(),
+		}), nil
+	})
+	if err != nil {
+		if err == services.ErrDockerAccountNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found or no Docker account connected",
 			})
 		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build activity calendar",
+		})
 	}
 
-	return c.JSON(fiber.Map{
-		"themes": themes,
-		"customization": fiber.Map{
-			"description": "You can also create custom themes using query parameters",
-			"params": fiber.Map{
-				"bg_color":   "Background color (hex without #)",
-				"text_color": "Text color (hex without #)",
-				"color0":     "Level 0 (no activity) color",
-				"color1":     "Level 1 (low) color",
-				"color2":     "Level 2 (medium) color",
-				"color3":     "Level 3 (high) color",
-				"color4":     "Level 4 (max) color",
-			},
-			"example": "/api/heatmap/username.svg?theme=custom&bg_color=1a1a2e&color0=16213e&color1=0f3460&color2=533483&color3=e94560&color4=ff6b6b",
-		},
-	})
+	c.Set("Content-Type", contentType)
+	c.Set("Content-Disposition", `inline; filename="`+username+`.ics"`)
+	h.setCacheHeaders(c, username)
+	return c.Send(body)
 }
 
-// GetActivityJSON returns activity data as JSON
-func (h *HeatmapHandler) GetActivityJSON(c *fiber.Ctx) error {
-	username := c.Params("username")
-
-	// Remove .json extension if present
-	username = strings.TrimSuffix(username, ".json")
-
+// GetRepositories returns a user's repositories ranked by recent activity
+// Query params:
+//   - days: how far back to look (1-365, default 90)
+//   - sort: heat (default), events, recent or name
+//   - limit: maximum repositories returned (1-100, default 20)
+//   - events, weight_*, min_confidence, tag: as in GetHeatmapSVG
+func (h *HeatmapHandler) GetRepositories(c *fiber.Ctx) error {
+	username := strings.TrimSuffix(c.Params("username"), ".json")
 	if username == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Username is required",
 		})
 	}
 
-	// Get days parameter (default 365)
-	days := 365
+	days := 90
 	if d := c.Query("days"); d != "" {
 		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
 			days = parsed
 		}
 	}
 
-	activities, err := h.dockerService.GetActivitySummary(username, days)
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	sortBy := services.RepoSortHeat
+	for _, option := range services.RepoSortOptions {
+		if strings.EqualFold(c.Query("sort"), option) {
+			sortBy = option
+		}
+	}
+
+	if h.notModified(c, username) {
+		return h.sendNotModified(c, username)
+	}
+
+	eventTypes, weights := parseEventFilter(c)
+	repos, err := h.dockerService.GetRepositoryStats(username, days, services.ActivityFilter{
+		EventTypes:    eventTypes,
+		Weights:       weights,
+		MinConfidence: parseMinConfidence(c),
+		Tags:          services.ParseTagPatterns(c.Query("tag")),
+	}, sortBy)
 	if err != nil {
 		if err == services.ErrDockerAccountNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -198,30 +1433,24 @@ func (h *HeatmapHandler) GetActivityJSON(c *fiber.Ctx) error {
 			})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch activity",
+			"error": "Failed to fetch repositories",
 		})
 	}
 
-	// Calculate totals
-	var totalActivities, totalPushes, totalPulls, totalBuilds int
-	for _, a := range activities {
-		totalActivities += a.TotalCount
-		totalPushes += a.Pushes
-		totalPulls += a.Pulls
-		totalBuilds += a.Builds
+	total := len(repos)
+	if len(repos) > limit {
+		repos = repos[:limit]
 	}
 
-	c.Set("Cache-Control", "public, max-age=7200") // Cache for 2 hours
+	h.setCacheHeaders(c, username)
 	return c.JSON(fiber.Map{
-		"username": username,
-		"days":     days,
-		"totals": fiber.Map{
-			"activities": totalActivities,
-			"pushes":     totalPushes,
-			"pulls":      totalPulls,
-			"builds":     totalBuilds,
-		},
-		"activity": activities,
+		"username":       username,
+		"days":           days,
+		"sort":           sortBy,
+		"sort_options":   services.RepoSortOptions,
+		"half_life_days": int(services.RepoHeatHalfLife.Hours() / 24),
+		"total":          total,
+		"repositories":   repos,
 	})
 }
 
@@ -258,19 +1487,31 @@ func (h *HeatmapHandler) GetProfilePage(c *fiber.Ctx) error {
 	}
 
 	// Get activity summary
-	activities, _ := h.dockerService.GetActivitySummary(username, 365)
+	activities, _ := h.dockerService.GetActivitySummary(username, 365, services.ActivityFilter{
+		Location: h.dockerService.ResolveLocation(username, ""),
+	})
 
 	var totalActivities int
 	for _, a := range activities {
 		totalActivities += a.TotalCount
 	}
 
+	achievements, err := h.dockerService.GetAchievements(account.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load achievements",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"user": fiber.Map{
 			"github_username": user.GitHubUsername,
+			"provider":        user.Provider,
+			"profile_url":     services.ProfileURL(user),
 			"name":            user.Name,
 			"avatar_url":      user.AvatarURL,
 			"bio":             user.Bio,
+			"bio_html":        services.RenderMarkdown(user.Bio), // sanitized, safe to inject
 		},
 		"docker": fiber.Map{
 			"username":     account.DockerUsername,
@@ -279,6 +1520,66 @@ func (h *HeatmapHandler) GetProfilePage(c *fiber.Ctx) error {
 		"stats": fiber.Map{
 			"total_activities": totalActivities,
 		},
+		"achievements":     achievements,
 		"available_themes": services.GetAvailableThemes(),
 	})
 }
+
+// GetProfileRepositories returns the public repositories of a public profile with their
+// Docker Hub pull and star counts and activity totals
+// Query params:
+//   - days: window for activity totals (1-365, default 365)
+func (h *HeatmapHandler) GetProfileRepositories(c *fiber.Ctx) error {
+	username := c.Params("username")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Username is required",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccountByUsername(username)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	user, err := services.GetUserByID(account.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if !user.PublicProfile {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Profile is private",
+		})
+	}
+
+	days := 365
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
+			days = parsed
+		}
+	}
+
+	if h.notModified(c, account.DockerUsername) {
+		return h.sendNotModified(c, account.DockerUsername)
+	}
+
+	repos, err := h.dockerService.ListRepositories(account.ID, days, false)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch repositories",
+		})
+	}
+
+	h.setCacheHeaders(c, account.DockerUsername)
+	return c.JSON(fiber.Map{
+		"username":     account.DockerUsername,
+		"days":         days,
+		"last_sync_at": account.LastSyncAt,
+		"total":        len(repos),
+		"repositories": repos,
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAcceptsWebP(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"image/*", false},
+		{"image/png", false},
+		{"image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8", true},
+		{"image/WebP", true},
+		{"image/webp;q=0.5, image/png", true},
+		{"image/webp; q=0, image/png", false},
+		{"image/webp;q=0.0", false},
+	}
+
+	app := fiber.New()
+	var got bool
+	app.Get("/", func(c *fiber.Ctx) error {
+		got = acceptsWebP(c)
+		return nil
+	})
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAccept, tt.accept)
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Accept %q: got %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestResponseVariantIncludesNegotiatedFormat(t *testing.T) {
+	app := fiber.New()
+	var plain, webp string
+	app.Get("/heatmap/:username.png", func(c *fiber.Ctx) error {
+		plain = responseVariant(c)
+		c.Locals(rasterFormatKey, "webp")
+		webp = responseVariant(c)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/heatmap/alice.png?theme=nord", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if plain == webp {
+		t.Errorf("PNG and WebP share the variant %q", plain)
+	}
+}
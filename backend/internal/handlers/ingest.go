@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateIngestKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// IngestEventsRequest is the signed body of an ingest request
+type IngestEventsRequest struct {
+	Events []services.IngestEvent `json:"events"`
+}
+
+// ListIngestKeys returns the user's ingest keys, without their secrets
+func (h *DockerHandler) ListIngestKeys(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	keys, err := h.dockerService.ListIngestKeys(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load ingest keys",
+		})
+	}
+	return c.JSON(fiber.Map{"keys": keys})
+}
+
+// CreateIngestKey creates a signing key for the ingest API. Its secret is only returned here.
+func (h *DockerHandler) CreateIngestKey(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateIngestKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required and must be at most 100 characters",
+		})
+	}
+
+	key, secret, err := h.dockerService.CreateIngestKey(user.ID, req.Name)
+	if err == services.ErrIngestKeyLimit {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create ingest key",
+		})
+	}
+
+	h.auditService.Record(user.ID, models.AuditIngestKeyCreate, key.Name, c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Ingest key created; copy the secret now, it won't be shown again",
+		"key":     key,
+		"secret":  secret,
+	})
+}
+
+// DeleteIngestKey revokes an ingest key
+func (h *DockerHandler) DeleteIngestKey(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid key id",
+		})
+	}
+
+	if err := h.dockerService.DeleteIngestKey(user.ID, uint(id)); err != nil {
+		if err == services.ErrIngestKeyNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete ingest key",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditIngestKeyRevoke, strconv.FormatUint(id, 10), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Ingest key deleted",
+	})
+}
+
+// ingestKeyKey holds the ingest key that signed the request
+const ingestKeyKey = "ingest_key"
+
+// RequireIngestSignature runs before every signed ingest route. It checks the request's
+// signature and timestamp, then records the signature as delivered so the same request
+// can't be replayed; a request that fails on the server is forgotten again so the client
+// can retry it.
+func (h *DockerHandler) RequireIngestSignature(c *fiber.Ctx) error {
+	signature := c.Get("X-Ingest-Signature")
+	key, err := h.dockerService.AuthenticateIngest(c.Get("X-Ingest-Key"), c.Get("X-Ingest-Timestamp"), signature, c.Body())
+	if err != nil {
+		return ingestErrorResponse(c, err)
+	}
+	if err := h.dockerService.ClaimIngestDelivery(key, signature); err != nil {
+		return ingestErrorResponse(c, err)
+	}
+
+	c.Locals(ingestKeyKey, key)
+	err = c.Next()
+	if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+		h.dockerService.ReleaseIngestDelivery(key, signature)
+	}
+	return err
+}
+
+// IngestEvents records a batch of events signed with an ingest key. The request carries
+// X-Ingest-Key (the key id), X-Ingest-Timestamp (unix seconds) and X-Ingest-Signature
+// ("sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret).
+func (h *DockerHandler) IngestEvents(c *fiber.Ctx) error {
+	key := ingestKeyFromContext(c)
+
+	var req IngestEventsRequest
+	if err := decodeStrict(c.Body(), &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body: " + err.Error(),
+		})
+	}
+
+	result, err := h.dockerService.IngestEvents(key, req.Events)
+	if err != nil {
+		return ingestErrorResponse(c, err)
+	}
+	return c.JSON(result)
+}
+
+// IngestBuild records one build, typically reported by a CI step right after docker push.
+// It is signed like IngestEvents; the body is a single build with an optional run_url.
+func (h *DockerHandler) IngestBuild(c *fiber.Ctx) error {
+	key := ingestKeyFromContext(c)
+
+	var report services.BuildReport
+	if err := decodeStrict(c.Body(), &report); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body: " + err.Error(),
+		})
+	}
+
+	result, err := h.dockerService.IngestBuild(key, report)
+	if err != nil {
+		return ingestErrorResponse(c, err)
+	}
+	return c.JSON(result)
+}
+
+// IngestAgentReport records a batch of image pulls and pushes seen by an agent on one node
+// (see cmd/agent). It is signed like IngestEvents.
+func (h *DockerHandler) IngestAgentReport(c *fiber.Ctx) error {
+	key := ingestKeyFromContext(c)
+
+	var report services.AgentReport
+	if err := decodeStrict(c.Body(), &report); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body: " + err.Error(),
+		})
+	}
+
+	result, err := h.dockerService.IngestAgentReport(key, report)
+	if err != nil {
+		return ingestErrorResponse(c, err)
+	}
+	return c.JSON(result)
+}
+
+// ingestKeyFromContext returns the ingest key RequireIngestSignature found
+func ingestKeyFromContext(c *fiber.Ctx) *models.IngestKey {
+	key, _ := c.Locals(ingestKeyKey).(*models.IngestKey)
+	return key
+}
+
+// decodeStrict decodes a JSON body, rejecting unknown fields so a misspelled one fails
+// loudly instead of recording a bare event
+func decodeStrict(body []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// ingestErrorResponse answers a failed ingest request
+func ingestErrorResponse(c *fiber.Ctx, err error) error {
+	if invalid, ok := err.(*services.IngestValidationError); ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Batch rejected: " + invalid.Error(),
+			"events": invalid.Events,
+		})
+	}
+	switch err {
+	case services.ErrIngestSignature, services.ErrIngestTimestamp:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrUserSuspended:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account suspended",
+		})
+	case services.ErrAccountDeletionScheduled:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account is scheduled to be deleted",
+		})
+	case services.ErrIngestReplay:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrIngestBatchSize, services.ErrAgentProtocol, services.ErrAgentNodeID:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrDockerAccountNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record events",
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ClaimNamespaceRequest struct {
+	Namespace string `json:"namespace"`
+}
+
+// ListNamespaces returns the extra namespaces claimed for the user's account
+func (h *DockerHandler) ListNamespaces(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	claims, err := h.dockerService.ListNamespaceClaims(account.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load namespaces",
+		})
+	}
+
+	result := make([]fiber.Map, 0, len(claims))
+	for i := range claims {
+		result = append(result, namespaceClaimResponse(&claims[i]))
+	}
+	return c.JSON(fiber.Map{"namespaces": result})
+}
+
+// ClaimNamespace starts a claim on another namespace (such as an organization) and returns
+// the token that proves control of it
+func (h *DockerHandler) ClaimNamespace(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	var req ClaimNamespaceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if !dockerUsernameRegex.MatchString(req.Namespace) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid namespace format",
+		})
+	}
+
+	claim, err := h.dockerService.ClaimNamespace(account, req.Namespace)
+	switch err {
+	case nil:
+	case services.ErrNamespaceOwn, services.ErrNamespaceClaimLimit:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrNamespaceTaken:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to claim namespace",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"namespace": namespaceClaimResponse(claim),
+	})
+}
+
+// VerifyNamespace checks the namespace's public repositories for the claim's token
+func (h *DockerHandler) VerifyNamespace(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+	defer cancel()
+
+	claim, err := h.dockerService.VerifyNamespace(ctx, account, c.Params("namespace"))
+	switch err {
+	case nil:
+	case services.ErrNamespaceClaimNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrNamespaceClaimExpired, services.ErrNamespaceNotVerified:
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrNamespaceTaken:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to read the namespace from Docker Hub",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Namespace verified; its activity will appear after the next sync",
+		"namespace": namespaceClaimResponse(claim),
+	})
+}
+
+// RemoveNamespace drops a namespace claim and the activity synced from it
+func (h *DockerHandler) RemoveNamespace(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	err = h.dockerService.RemoveNamespace(account, c.Params("namespace"))
+	if err == services.ErrNamespaceClaimNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove namespace",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Namespace removed",
+	})
+}
+
+// namespaceClaimResponse describes a claim, with proof instructions while it is pending
+func namespaceClaimResponse(claim *models.NamespaceClaim) fiber.Map {
+	result := fiber.Map{
+		"namespace":  claim.Namespace,
+		"verified":   claim.Verified(),
+		"created_at": claim.CreatedAt,
+	}
+	if claim.Verified() {
+		result["verified_at"] = claim.VerifiedAt
+		return result
+	}
+
+	result["token"] = claim.Token
+	result["expires_at"] = claim.ExpiresAt
+	result["expired"] = time.Now().After(claim.ExpiresAt)
+	result["instructions"] = []string{
+		"Push a tag named " + claim.Token + " to any public repository in " + claim.Namespace,
+		"Or add " + claim.Token + " to the description of any public repository in " + claim.Namespace,
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/notify"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	auditService        *services.AuditService
+}
+
+func NewNotificationHandler(svc *services.Services) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: svc.Notifications,
+		auditService:        svc.Audit,
+	}
+}
+
+type CreateChannelRequest struct {
+	Type     string            `json:"type"`
+	Name     string            `json:"name"`
+	Settings map[string]string `json:"settings"`
+	Events   []string          `json:"events"`
+}
+
+// ListChannels returns the user's notification channels and what can be configured
+func (h *NotificationHandler) ListChannels(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	channels, err := h.notificationService.ListChannels(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch notification channels",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"channels":        channels,
+		"available_types": notify.Types(),
+		"events":          services.NotificationEvents,
+	})
+}
+
+// CreateChannel adds a notification channel
+func (h *NotificationHandler) CreateChannel(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Type == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Channel type is required",
+		})
+	}
+	if len(req.Name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name must be at most 100 characters",
+		})
+	}
+
+	channel, err := h.notificationService.CreateChannel(user.ID, req.Type, req.Name, req.Settings, req.Events)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyChannels) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		// Unknown type, unknown event or invalid settings; the message says which
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Notification channel created",
+		"channel": channel,
+	})
+}
+
+// DeleteChannel removes a notification channel
+func (h *NotificationHandler) DeleteChannel(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid channel id",
+		})
+	}
+
+	if err := h.notificationService.DeleteChannel(user.ID, uint(id)); err != nil {
+		if err == services.ErrChannelNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Notification channel not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete notification channel",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notification channel deleted",
+	})
+}
+
+// TestChannel sends a test notification through a channel right away
+func (h *NotificationHandler) TestChannel(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid channel id",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	if err := h.notificationService.SendTest(ctx, user.ID, uint(id)); err != nil {
+		if err == services.ErrChannelNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Notification channel not found",
+			})
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Test delivery failed: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Test notification sent",
+	})
+}
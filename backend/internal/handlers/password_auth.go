@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SignupRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+type PasswordLoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// checkEmailMessage answers signups and reset requests alike, whether or not the email
+// has an account
+const checkEmailMessage = "If the address can be used, a link is on its way. Check your email."
+
+// Signup creates an email and password account and mails a link to verify the address
+// Body: {"email": "...", "password": "...", "name": "..."}
+func (h *AuthHandler) Signup(c *fiber.Ctx) error {
+	var req SignupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err := h.passwordService.Signup(req.Email, req.Password, req.Name)
+	if err == services.ErrInvalidEmail || err == services.ErrPasswordLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to sign up",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": checkEmailMessage,
+	})
+}
+
+// PasswordLogin signs in with an email and password
+// Body: {"email": "...", "password": "..."}
+func (h *AuthHandler) PasswordLogin(c *fiber.Ctx) error {
+	var req PasswordLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	user, err := h.passwordService.Login(req.Email, req.Password)
+	if err == services.ErrInvalidCredentials {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err == services.ErrEmailNotVerified {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to sign in",
+		})
+	}
+
+	return h.signedIn(c, user)
+}
+
+// VerifyEmail confirms an address with the token from a verification link and signs in
+// Body: {"token": "..."}
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	user, err := h.passwordService.VerifyEmail(req.Token)
+	if err == services.ErrEmailTokenInvalid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify email",
+		})
+	}
+
+	return h.signedIn(c, user)
+}
+
+// ForgotPassword mails a password reset link. The response is the same whether or not
+// the email has an account.
+// Body: {"email": "..."}
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err := h.passwordService.RequestPasswordReset(req.Email)
+	if err == services.ErrInvalidEmail {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send reset link",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": checkEmailMessage,
+	})
+}
+
+// ResetPassword sets a new password with the token from a reset link, logs out every
+// existing session and signs in
+// Body: {"token": "...", "password": "..."}
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	user, err := h.passwordService.ResetPassword(req.Token, req.Password)
+	if err == services.ErrEmailTokenInvalid || err == services.ErrPasswordLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reset password",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditPasswordReset, "", c.IP(), c.Get("User-Agent"))
+
+	return h.signedIn(c, user)
+}
+
+// signedIn starts a session for user and responds with its tokens
+func (h *AuthHandler) signedIn(c *fiber.Ctx, user *models.User) error {
+	tokens, err := h.sessionService.Create(user, "", c.Get("User-Agent"), c.IP())
+	if err == services.ErrUserSuspended {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Account suspended",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditLogin, "password", c.IP(), c.Get("User-Agent"))
+
+	response := tokenResponse(tokens)
+	response["user"] = user
+	return c.JSON(response)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strconv"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListSessions returns the browsers and devices the user is logged in on, marking the
+// one making the request
+func (h *UserHandler) ListSessions(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	sessions, err := h.sessionService.List(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load sessions",
+		})
+	}
+
+	current := middleware.GetSessionIDFromContext(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return c.JSON(fiber.Map{"sessions": sessions})
+}
+
+// RevokeSession logs one of the user's sessions out. Revoking the current session is
+// the same as logging out.
+func (h *UserHandler) RevokeSession(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid session id",
+		})
+	}
+
+	if err := h.sessionService.Revoke(user.ID, uint(id)); err != nil {
+		if err == services.ErrSessionNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke session",
+		})
+	}
+	h.auditService.Record(user.ID, models.AuditSessionRevoke, strconv.FormatUint(id, 10), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Session revoked",
+	})
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// signedEmbedKey marks a private user's response allowed by a signature or embed token.
+// It holds the signature's expiry in unix seconds, or 0 for a token, which doesn't expire.
+const signedEmbedKey = "signed_embed_expires"
+
+type SignEmbedRequest struct {
+	DockerUsername string `json:"docker_username"`
+	Days           int    `json:"days"`
+	// Query is the display options to put on the URLs, such as "theme=nord&days=180"
+	Query string `json:"query"`
+}
+
+// RequireEmbedAccess runs before a route that shows one user's activity. Private
+// profiles are only shown with a valid signature from SignEmbed or one of the owner's
+// embed tokens; public ones, and usernames without an account, pass straight through.
+// A token limited to some sites is refused to pages elsewhere, and only those sites may
+// read its responses from the browser.
+func (h *HeatmapHandler) RequireEmbedAccess(c *fiber.Ctx) error {
+	username := strings.TrimSuffix(strings.TrimSuffix(c.Params("username"), ".svg"), ".json")
+	owner, err := h.dockerService.GetAccountOwner(username)
+	if err != nil || owner.PublicProfile {
+		return c.Next()
+	}
+
+	if value := c.Query("token"); value != "" {
+		token, err := h.embedTokenService.Authenticate(owner.ID, value)
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		origin := embedRequestOrigin(c)
+		if origin != "" && origin != services.OriginOf(config.AppConfig.FrontendURL) && !token.AllowsOrigin(origin) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "This embed token can't be used from " + origin,
+			})
+		}
+		if token.AllowedOrigins != "" && c.Get(fiber.HeaderOrigin) != "" {
+			c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+			c.Response().Header.Del(fiber.HeaderAccessControlAllowCredentials)
+			c.Vary(fiber.HeaderOrigin)
+		}
+		c.Locals(signedEmbedKey, int64(0))
+		return c.Next()
+	}
+
+	err = services.VerifyEmbed(username, c.Query("exp"), c.Query("sig"))
+	if err == services.ErrEmbedSignatureExpired {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This signed embed URL has expired",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Profile is private; use a signed embed URL",
+		})
+	}
+
+	expires, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+	c.Locals(signedEmbedKey, expires)
+	return c.Next()
+}
+
+// requirePublicProfiles checks that every account a team or comparison render draws has a
+// public profile, since a signed embed or embed token only vouches for one account.
+// Usernames without an account pass, and are reported by the render. When a profile is
+// private, it has already sent the error response and returns false.
+func (h *HeatmapHandler) requirePublicProfiles(c *fiber.Ctx, usernames []string) (bool, error) {
+	for _, username := range usernames {
+		owner, err := h.dockerService.GetAccountOwner(username)
+		if err == nil && !owner.PublicProfile {
+			return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Profile is private: " + username,
+			})
+		}
+	}
+	return true, nil
+}
+
+// embedRequestOrigin is the site of the page making the request: its Origin header, sent by
+// scripts, or else its Referer, sent by most images. It is "" when the browser sends
+// neither, which is let through since there is nothing to check.
+func embedRequestOrigin(c *fiber.Ctx) string {
+	if origin := c.Get(fiber.HeaderOrigin); origin != "" {
+		if parsed := services.OriginOf(origin); parsed != "" {
+			return parsed
+		}
+		return origin // such as "null" from a sandboxed frame, which no list allows
+	}
+	return services.OriginOf(c.Get(fiber.HeaderReferer))
+}
+
+// signedEmbedMaxAge returns how long a response allowed by a signature or token may be
+// cached, never past the signature's expiry, and whether the request was signed
+func signedEmbedMaxAge(c *fiber.Ctx, maxAge int) (int, bool) {
+	expires, ok := c.Locals(signedEmbedKey).(int64)
+	if !ok {
+		return maxAge, false
+	}
+	if expires == 0 {
+		// A revoked token should stop working soon, even from a browser's cache
+		if maxAge > services.DefaultCacheMaxAge {
+			maxAge = services.DefaultCacheMaxAge
+		}
+		return maxAge, true
+	}
+	if remaining := int(expires - time.Now().Unix()); remaining < maxAge {
+		maxAge = remaining
+	}
+	return maxAge, true
+}
+
+// SignEmbed returns expiring, signed URLs for the user's heatmap, for embedding a
+// private profile's heatmap somewhere only some people can see, such as an internal wiki
+// Body: {"docker_username": "...", "days": 30, "query": "theme=nord"}
+func (h *UserHandler) SignEmbed(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req SignEmbedRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Days == 0 {
+		req.Days = services.DefaultEmbedSignatureDays
+	}
+	if req.Days < 1 || req.Days > services.MaxEmbedSignatureDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "days must be between 1 and " + strconv.Itoa(services.MaxEmbedSignatureDays),
+		})
+	}
+	query, err := url.ParseQuery(req.Query)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query",
+		})
+	}
+
+	owner, err := h.dockerService.GetAccountOwner(req.DockerUsername)
+	if err != nil || owner.ID != user.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Docker account not found",
+		})
+	}
+
+	expires := time.Now().AddDate(0, 0, req.Days)
+	exp, sig := services.SignEmbed(req.DockerUsername, expires)
+	query.Del("refresh")
+	query.Set("exp", exp)
+	query.Set("sig", sig)
+
+	base := c.BaseURL() + middleware.APIV1Prefix
+	svgURL := base + "/heatmap/" + req.DockerUsername + ".svg?" + query.Encode()
+	return c.JSON(fiber.Map{
+		"exp":        exp,
+		"sig":        sig,
+		"expires_at": expires.UTC(),
+		"svg_url":    svgURL,
+		"png_url":    base + "/heatmap/" + req.DockerUsername + ".png?" + query.Encode(),
+		"json_url":   base + "/activity/" + req.DockerUsername + ".json?" + query.Encode(),
+		"markdown":   "![Docker Activity](" + svgURL + ")",
+		"html":       `<img src="` + svgURL + `" alt="Docker Activity Heatmap" />`,
+	})
+}
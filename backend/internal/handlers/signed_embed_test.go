@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPrivateProfilesStayOutOfMultiAccountViews(t *testing.T) {
+	testutil.OpenDB(t)
+	testutil.CreateAccount(t, "alice", true)
+	testutil.CreateAccount(t, "bob", false)
+
+	h := NewHeatmapHandler(services.NewServices(nil, nil, services.SystemClock))
+	app := fiber.New()
+	app.Get("/team/heatmap.svg", h.GetTeamHeatmapSVG)
+	app.Get("/compare/:userA/:userB.svg", h.GetComparisonSVG)
+	app.Get("/compare/:userA/:userB", h.GetComparison)
+	app.Get("/repos/:username", h.RequireEmbedAccess, h.GetRepositories)
+
+	for _, path := range []string{
+		"/team/heatmap.svg?members=alice,bob",
+		"/compare/alice/bob.svg",
+		"/compare/bob/alice",
+		"/repos/bob",
+		"/repos/bob.json",
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("%s: status %d, want %d", path, resp.StatusCode, fiber.StatusForbidden)
+		}
+	}
+}
@@ -1,25 +1,54 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/config"
 	"docker-heatmap/internal/database"
 	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-type UserHandler struct{}
+type UserHandler struct {
+	diagnosticsService *services.DiagnosticsService
+	exportService      *services.ExportService
+	dockerService      *services.DockerHubService
+	sessionService     *services.SessionService
+	embedTokenService  *services.EmbedTokenService
+	auditService       *services.AuditService
+}
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+func NewUserHandler(svc *services.Services) *UserHandler {
+	return &UserHandler{
+		diagnosticsService: svc.Diagnostics,
+		exportService:      svc.Export,
+		dockerService:      svc.Docker,
+		sessionService:     svc.Sessions,
+		embedTokenService:  svc.EmbedTokens,
+		auditService:       svc.Audit,
+	}
 }
 
 type UpdateProfileRequest struct {
-	Name          string `json:"name"`
-	Bio           string `json:"bio"`
-	PublicProfile *bool  `json:"public_profile"`
+	Name          string  `json:"name"`
+	Bio           string  `json:"bio"`
+	PublicProfile *bool   `json:"public_profile"`
+	Timezone      *string `json:"timezone"`
+	WeekStart     *string `json:"week_start"`
+
+	HideAttribution *bool `json:"hide_attribution"`
+	WeeklyDigest    *bool `json:"weekly_digest"`
 }
 
-// GetProfile returns the current user's profile
+// GetProfile returns the current user's profile, with their recent security events
 func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -28,8 +57,19 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	events, err := h.auditService.RecentSecurityEvents(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load security events",
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"user": user,
+		"user":             user,
+		"bio_html":         services.RenderMarkdown(user.Bio),
+		"attribution_mode": config.AppConfig.AttributionMode,
+		"security_events":  events,
+		"is_admin":         middleware.IsAdmin(user),
 	})
 }
 
@@ -49,15 +89,59 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update fields
+	// Update fields, noting which ones changed for the audit log
+	var changed []string
 	if req.Name != "" {
 		user.Name = req.Name
+		changed = append(changed, "name")
 	}
 	if req.Bio != "" {
+		if len(req.Bio) > services.MaxBioLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Bio must be at most %d characters", services.MaxBioLength),
+			})
+		}
 		user.Bio = req.Bio
+		changed = append(changed, "bio")
 	}
 	if req.PublicProfile != nil {
 		user.PublicProfile = *req.PublicProfile
+		changed = append(changed, "public_profile")
+	}
+	if req.Timezone != nil {
+		if *req.Timezone != "" {
+			if _, err := time.LoadLocation(*req.Timezone); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid timezone",
+				})
+			}
+		}
+		user.Timezone = *req.Timezone
+		changed = append(changed, "timezone")
+	}
+	if req.WeekStart != nil {
+		if *req.WeekStart != "" {
+			if _, ok := services.ParseWeekStart(*req.WeekStart); !ok {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "week_start must be sunday or monday",
+				})
+			}
+		}
+		user.WeekStart = strings.ToLower(*req.WeekStart)
+		changed = append(changed, "week_start")
+	}
+	if req.HideAttribution != nil {
+		if *req.HideAttribution && config.AppConfig.AttributionMode == services.AttributionRequired {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Attribution is required on this deployment",
+			})
+		}
+		user.HideAttribution = *req.HideAttribution
+		changed = append(changed, "hide_attribution")
+	}
+	if req.WeeklyDigest != nil {
+		user.WeeklyDigest = *req.WeeklyDigest
+		changed = append(changed, "weekly_digest")
 	}
 
 	if err := database.DB.Save(user).Error; err != nil {
@@ -65,10 +149,14 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 			"error": "Failed to update profile",
 		})
 	}
+	if len(changed) > 0 {
+		h.auditService.Record(user.ID, models.AuditProfileUpdate, strings.Join(changed, ", "), c.IP(), c.Get("User-Agent"))
+	}
 
 	return c.JSON(fiber.Map{
-		"message": "Profile updated successfully",
-		"user":    user,
+		"message":  "Profile updated successfully",
+		"user":     user,
+		"bio_html": services.RenderMarkdown(user.Bio),
 	})
 }
 
@@ -90,8 +178,10 @@ func (h *UserHandler) GetEmbedCode(c *fiber.Ctx) error {
 		})
 	}
 
-	svgURL := baseURL + "/api/heatmap/" + dockerUsername + ".svg"
-	jsonURL := baseURL + "/api/activity/" + dockerUsername + ".json"
+	svgURL := baseURL + middleware.APIV1Prefix + "/heatmap/" + dockerUsername + ".svg"
+	jsonURL := baseURL + middleware.APIV1Prefix + "/activity/" + dockerUsername + ".json"
+	widget := `<div data-docker-heatmap="` + dockerUsername + `"></div>` + "\n" +
+		`<script src="` + baseURL + `/embed.js" async></script>`
 
 	return c.JSON(fiber.Map{
 		"svg_url":   svgURL,
@@ -99,5 +189,345 @@ func (h *UserHandler) GetEmbedCode(c *fiber.Ctx) error {
 		"markdown":  "![Docker Activity](" + svgURL + ")",
 		"html":      `<img src="` + svgURL + `" alt="Docker Activity Heatmap" />`,
 		"html_link": `<a href="` + baseURL + `/profile/` + dockerUsername + `"><img src="` + svgURL + `" alt="Docker Activity Heatmap" /></a>`,
+		"widget":    widget,
+	})
+}
+
+// GetDiagnostics returns a redacted troubleshooting bundle as a downloadable JSON file
+func (h *UserHandler) GetDiagnostics(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	report := h.diagnosticsService.BuildReport(ctx, user)
+
+	filename := fmt.Sprintf("docker-heatmap-diagnostics-%s.json", report.GeneratedAt.Format("20060102-150405"))
+	c.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(report)
+}
+
+// GetLimits returns the caller's rate-limit tier and, per endpoint class, the remaining
+// quota and requests over the last 24 hours. Limits are counted per client IP, so the
+// figures describe the address this request came from.
+func (h *UserHandler) GetLimits(c *fiber.Ctx) error {
+	now := time.Now()
+	classes := middleware.RateLimitClasses()
+	limits := make([]middleware.RateLimitStatus, 0, len(classes))
+	for _, class := range classes {
+		limits = append(limits, class.Status(c.IP(), now))
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"tier":   middleware.RateLimitTier,
+		"ip":     c.IP(),
+		"limits": limits,
+	})
+}
+
+// GetViews returns how often the user's embeds were rendered and their profile viewed,
+// per UTC day. Counts are flushed from memory every minute, so the latest views can
+// take that long to appear.
+// Query params:
+//   - days: trailing window including today (1-365, default 30)
+func (h *UserHandler) GetViews(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	days := 30
+	if d := c.Query("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > services.MaxViewHistoryDays {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("days must be between 1 and %d", services.MaxViewHistoryDays),
+			})
+		}
+		days = parsed
+	}
+
+	history, err := h.dockerService.GetViewHistory(account.ID, days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load view history",
+		})
+	}
+
+	c.Set("Cache-Control", "private, max-age=60")
+	return c.JSON(history)
+}
+
+// GetRepositories returns the user's repositories, private ones included, with their
+// Docker Hub pull and star counts and activity totals
+// Query params:
+//   - days: window for activity totals (1-365, default 365)
+func (h *UserHandler) GetRepositories(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	days := 365
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
+			days = parsed
+		}
+	}
+
+	repos, err := h.dockerService.ListRepositories(account.ID, days, true)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch repositories",
+		})
+	}
+
+	c.Set("Cache-Control", "private, max-age=60")
+	return c.JSON(fiber.Map{
+		"username":     account.DockerUsername,
+		"days":         days,
+		"last_sync_at": account.LastSyncAt,
+		"total":        len(repos),
+		"repositories": repos,
+	})
+}
+
+// ListEvents pages through the raw events the sync recorded for the user's account, so
+// they can audit exactly what was counted
+// Query params:
+//   - repository: only this repository (exact name, e.g. "app" or "org/app")
+//   - tag: comma-separated tag names or glob patterns
+//   - events: comma-separated event types (push, pull, build)
+//   - from, to: YYYY-MM-DD UTC days (inclusive), each optional
+//   - min_confidence: skip events from less reliable sources (low, medium, high)
+//   - sort: newest (default) or oldest
+//   - limit: events per page (1-1000, default 100)
+//   - cursor: next_cursor from the previous page
+func (h *UserHandler) ListEvents(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	query, err := parseEventQuery(c, services.EventOrderNewest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+	query.Cursor = c.Query("cursor")
+	query.Limit = limit
+
+	page, err := h.dockerService.ListEvents(account.ID, query)
+	if err != nil {
+		if err == services.ErrEventCursorInvalid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch events",
+		})
+	}
+
+	events := make([]fiber.Map, 0, len(page.Events))
+	for _, event := range page.Events {
+		events = append(events, rawEventJSON(event))
+	}
+
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
+	}
+
+	c.Set("Cache-Control", "no-store")
+	return c.JSON(fiber.Map{
+		"sort":        query.Order,
+		"limit":       limit,
+		"events":      events,
+		"next_cursor": nextCursor,
 	})
 }
+
+// ExportEventsCSV returns every raw event of the user's account matching the filters as CSV,
+// one row per event, for spreadsheets and BI tools
+// Query params:
+//   - repository, tag, events, from, to, min_confidence: as in ListEvents
+//   - sort: oldest (default) or newest
+func (h *UserHandler) ExportEventsCSV(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	query, err := parseEventQuery(c, services.EventOrderOldest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	data, err := h.dockerService.EventsCSV(account.ID, query)
+	if err == services.ErrEventsCSVTooLarge {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export events",
+		})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", `attachment; filename="docker-events-`+account.DockerUsername+`.csv"`)
+	return c.Send(data)
+}
+
+// parseEventQuery reads the event filters ListEvents and ExportEventsCSV share, and the sort
+func parseEventQuery(c *fiber.Ctx, defaultOrder string) (services.EventQuery, error) {
+	query := services.EventQuery{
+		Repository:    c.Query("repository"),
+		Tags:          services.ParseTagPatterns(c.Query("tag")),
+		EventTypes:    services.ParseEventTypes(c.Query("events")),
+		MinConfidence: parseMinConfidence(c),
+		Order:         strings.ToLower(c.Query("sort", defaultOrder)),
+	}
+	if query.Order != services.EventOrderNewest && query.Order != services.EventOrderOldest {
+		return query, errors.New("sort must be newest or oldest")
+	}
+
+	bounds := []struct {
+		param string
+		day   *time.Time
+	}{{"from", &query.From}, {"to", &query.To}}
+	for _, bound := range bounds {
+		if value := c.Query(bound.param); value != "" {
+			day, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return query, errors.New(bound.param + " must be YYYY-MM-DD")
+			}
+			*bound.day = day
+		}
+	}
+	return query, nil
+}
+
+// GetActivityReport returns an activity report for the user's account over a period
+// Query params:
+//   - from, to: period in YYYY-MM-DD, inclusive (defaults to the last 30 days, at most 366 days)
+//   - format: json (default) or csv
+func (h *UserHandler) GetActivityReport(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	account, err := h.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No Docker account connected",
+		})
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -29)
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "from must be a date in YYYY-MM-DD format",
+			})
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must be a date in YYYY-MM-DD format",
+			})
+		}
+	}
+
+	report, err := h.exportService.BuildActivityReport([]models.DockerAccount{*account}, from, to)
+	if err != nil {
+		if err == services.ErrExportPeriodInvalid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build report",
+		})
+	}
+
+	filename := fmt.Sprintf("docker-activity-%s-%s_%s", account.DockerUsername, report.From, report.To)
+	c.Set("Cache-Control", "no-store")
+
+	if c.Query("format") == "csv" {
+		data, err := report.CSV()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to build report",
+			})
+		}
+		c.Set("Content-Type", "text/csv; charset=utf-8")
+		c.Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		return c.Send(data)
+	}
+
+	c.Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+	return c.JSON(report)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateWebhookRequest struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// ListWebhooks returns the user's webhooks, without their secrets, and the events they can
+// subscribe to
+func (h *NotificationHandler) ListWebhooks(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhooks, err := h.notificationService.ListWebhooks(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load webhooks",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"webhooks": webhooks,
+		"events":   services.NotificationEvents,
+	})
+}
+
+// CreateWebhook registers a URL to receive signed JSON payloads for the events. The
+// signing secret is only returned here.
+// Body: {"url": "...", "name": "...", "events": ["sync_completed", ...]}
+func (h *NotificationHandler) CreateWebhook(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name must be at most 100 characters",
+		})
+	}
+
+	webhook, secret, err := h.notificationService.CreateWebhook(user.ID, req.Name, strings.TrimSpace(req.URL), req.Events)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyChannels) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		// Unknown event or invalid URL; the message says which
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	h.auditService.Record(user.ID, models.AuditWebhookCreate, webhook.URL, c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook created; copy the secret now, it won't be shown again",
+		"webhook": webhook,
+		"secret":  secret,
+	})
+}
+
+// DeleteWebhook removes a webhook and its queued deliveries
+func (h *NotificationHandler) DeleteWebhook(c *fiber.Ctx) error {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook id",
+		})
+	}
+
+	if err := h.notificationService.DeleteWebhook(user.ID, uint(id)); err != nil {
+		if err == services.ErrWebhookNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete webhook",
+		})
+	}
+
+	h.auditService.Record(user.ID, models.AuditWebhookDelete, strconv.FormatUint(id, 10), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook deleted",
+	})
+}
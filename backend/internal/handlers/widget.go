@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/widget"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WidgetHandler struct {
+	script []byte
+}
+
+func NewWidgetHandler() *WidgetHandler {
+	themes := make(map[string]widget.Theme, len(services.ThemeOrder))
+	for _, name := range services.ThemeOrder {
+		theme := services.Themes[name]
+		themes[name] = widget.Theme{
+			Name:        theme.Name,
+			BgColor:     theme.BgColor,
+			TextColor:   theme.TextColor,
+			Colors:      theme.Colors,
+			BorderColor: theme.BorderColor,
+		}
+	}
+
+	script, err := widget.Script(widget.Config{
+		APIPrefix:    middleware.APIV1Prefix,
+		ProfileURL:   config.AppConfig.FrontendURL + "/profile/",
+		DefaultTheme: "github",
+		Order:        services.ThemeOrder,
+		Themes:       themes,
+	})
+	if err != nil {
+		slog.Error("Failed to build embed widget", "error", err)
+	}
+	return &WidgetHandler{script: script}
+}
+
+// GetEmbedScript serves the embeddable widget, which renders an interactive heatmap into
+// elements carrying a data-docker-heatmap attribute
+func (h *WidgetHandler) GetEmbedScript(c *fiber.Ctx) error {
+	if h.script == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Embed widget is unavailable",
+		})
+	}
+	c.Set("Content-Type", fiber.MIMEApplicationJavaScriptCharsetUTF8)
+	c.Set("Cache-Control", "public, max-age=3600")
+	return c.Send(h.script)
+}
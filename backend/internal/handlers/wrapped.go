@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"strconv"
+
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetWrapped returns a public profile's year in review: totals, busiest day, longest
+// streak, top repositories and how it ranks against other users, over the UTC year
+func (h *HeatmapHandler) GetWrapped(c *fiber.Ctx) error {
+	wrapped, err := h.loadWrapped(c)
+	if err != nil || wrapped == nil {
+		return err
+	}
+
+	h.setWrappedCacheHeaders(c, wrapped)
+	return c.JSON(wrapped)
+}
+
+// GetWrappedPNG returns a public profile's year in review as a 1200x630 share image
+// Query params: theme, custom colors and font as in GetHeatmapSVG
+func (h *HeatmapHandler) GetWrappedPNG(c *fiber.Ctx) error {
+	wrapped, err := h.loadWrapped(c)
+	if err != nil || wrapped == nil {
+		return err
+	}
+
+	image, err := h.wrappedService.RenderWrappedPNG(wrapped, parseSVGOptions(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate image",
+		})
+	}
+
+	c.Set("Content-Type", "image/png")
+	h.setWrappedCacheHeaders(c, wrapped)
+	return c.Send(image)
+}
+
+// loadWrapped looks up the year in review the request is for. When it can't, it has
+// already sent the error response and returns nil.
+func (h *HeatmapHandler) loadWrapped(c *fiber.Ctx) (*services.Wrapped, error) {
+	username := c.Params("username")
+	year, err := strconv.Atoi(c.Params("year"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Year must be a number, like 2024",
+		})
+	}
+
+	owner, err := h.dockerService.GetAccountOwner(username)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found or no Docker account connected",
+		})
+	}
+	if !owner.PublicProfile {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Profile is private",
+		})
+	}
+
+	wrapped, err := h.wrappedService.GetWrapped(username, year)
+	switch err {
+	case nil:
+		return wrapped, nil
+	case services.ErrDockerAccountNotFound:
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found or no Docker account connected",
+		})
+	case services.ErrWrappedYearInvalid:
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case services.ErrWrappedYearUnavailable:
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build year in review",
+		})
+	}
+}
+
+// setWrappedCacheHeaders caches a finished year for a day, since it won't change but the
+// profile could still be made private, and a year under way like the heatmap
+func (h *HeatmapHandler) setWrappedCacheHeaders(c *fiber.Ctx, wrapped *services.Wrapped) {
+	if wrapped.Final {
+		c.Set("Cache-Control", "public, max-age=86400")
+		return
+	}
+	h.setCacheHeaders(c, wrapped.Username)
+}
@@ -0,0 +1,59 @@
+// Package logging sets up the structured logger the server writes through, and carries
+// request-scoped attributes, such as the request ID, from handlers into services.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Setup makes slog's default logger write at level and above ("debug", "info", "warn" or
+// "error") in format ("json", or "text" for key=value lines). The standard log package
+// writes through it too, at info.
+func Setup(level, format string) {
+	slog.SetDefault(slog.New(newHandler(os.Stderr, level, format)))
+}
+
+func newHandler(w io.Writer, level, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	if strings.EqualFold(format, "json") {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// ParseLevel returns the level named by name, or info for names it doesn't know
+func ParseLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// With returns a copy of ctx whose logger adds args (key-value pairs or slog.Attrs) to
+// every record, on top of any ctx already carries
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).With(args...))
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// Fatal logs msg at error level and exits, like log.Fatal
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminMiddleware only lets through admins. It goes after AuthMiddleware, and answers 404 to
+// everyone else so the admin routes aren't advertised.
+func AdminMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := GetUserFromContext(c)
+		if user == nil || !IsAdmin(user) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Not found",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// IsAdmin reports whether the user may use the admin API: they have the admin flag, or are
+// listed in ADMIN_USER_IDS, which is how the first admin is made
+func IsAdmin(user *models.User) bool {
+	return user.IsAdmin || config.IsAdmin(user.ID)
+}
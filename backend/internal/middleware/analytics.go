@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strings"
+
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AnalyticsMiddleware counts successfully served renders and profile views per username,
+// including 304s, since a revalidated embed was still displayed
+func AnalyticsMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		if c.Method() != fiber.MethodGet {
+			return err
+		}
+		status := c.Response().StatusCode()
+		if status != fiber.StatusOK && status != fiber.StatusNotModified {
+			return err
+		}
+
+		// Routes without an extension also match "name.svg" and "name.json". The username
+		// outlives the request as a counter key, so it's copied out of fiber's buffer.
+		username := strings.Clone(strings.TrimSuffix(strings.TrimSuffix(c.Params("username"), ".svg"), ".json"))
+		if username == "" {
+			return err
+		}
+		if kind := viewKind(c.Route().Path); kind != "" {
+			services.RecordView(username, kind)
+		}
+		return err
+	}
+}
+
+// viewKind classifies a public route by what kind of view it serves
+func viewKind(route string) string {
+	route = APIRoute(route)
+	switch {
+	case route == "/profile/:username":
+		// Not its sub-resources, which the profile page fetches alongside it
+		return models.ViewKindProfile
+	case strings.HasPrefix(route, "/heatmap/"),
+		strings.HasPrefix(route, "/chart/"),
+		strings.HasPrefix(route, "/sparkline/"),
+		strings.HasPrefix(route, "/punchcard/"):
+		return models.ViewKindRender
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAnalyticsCountsExtensionRoutesUnderTheUsername(t *testing.T) {
+	testutil.OpenDB(t)
+	account := testutil.CreateAccount(t, "alice", true)
+
+	app := fiber.New()
+	app.Use(AnalyticsMiddleware())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	// Registered in the router's order, so the bare route takes "alice.svg"
+	app.Get("/api/v1/heatmap/:username", ok)
+	app.Get("/api/v1/heatmap/:username.svg", ok)
+	app.Get("/api/v1/sparkline/:username", ok)
+	app.Get("/api/v1/profile/:username", ok)
+
+	for _, path := range []string{
+		"/api/v1/heatmap/alice.svg",
+		"/api/v1/heatmap/alice",
+		"/api/v1/sparkline/alice.svg",
+		"/api/v1/profile/alice",
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: status %d", path, resp.StatusCode)
+		}
+	}
+	services.FlushViews()
+
+	var rows []models.DailyViewCount
+	if err := database.DB.Where("docker_account_id = ?", account.ID).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int64)
+	for _, row := range rows {
+		counts[row.Kind] += row.Count
+	}
+	if counts[models.ViewKindRender] != 3 || counts[models.ViewKindProfile] != 1 {
+		t.Errorf("counts %v, want 3 renders and 1 profile view", counts)
+	}
+
+	history, err := services.NewDockerHubService(nil, nil, services.SystemClock).GetViewHistory(account.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.TotalRenders != 3 {
+		t.Errorf("view history has %d renders on %s, want 3", history.TotalRenders, time.Now().UTC().Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// API path prefixes. /api/v1 is canonical; the unversioned routes under /api are aliases
+// kept for existing embeds and clients.
+const (
+	APIPrefix   = "/api"
+	APIV1Prefix = "/api/v1"
+)
+
+// APIVersionHeader lets clients of the unversioned routes name the version they were
+// written against. Responses report the version that served them in it.
+const APIVersionHeader = "API-Version"
+
+// CurrentAPIVersion is the version unversioned requests get when they don't name one
+const CurrentAPIVersion = "1"
+
+// supportedAPIVersions are the versions this build serves, as sent in APIVersionHeader
+var supportedAPIVersions = []string{"1"}
+
+// legacyAPIDeprecatedAt is when the unversioned routes were deprecated in favor of /api/v1
+var legacyAPIDeprecatedAt = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+// APIRoute returns a request path or route pattern relative to the API root, so
+// "/api/v1/heatmap/:username" and "/api/heatmap/:username" both become "/heatmap/:username"
+func APIRoute(path string) string {
+	if path == APIV1Prefix || strings.HasPrefix(path, APIV1Prefix+"/") {
+		return strings.TrimPrefix(path, APIV1Prefix)
+	}
+	return strings.TrimPrefix(path, APIPrefix)
+}
+
+// LegacyAPIMiddleware marks responses from the unversioned routes as deprecated
+// (RFC 9745), with a Sunset header (RFC 8594) when LEGACY_API_SUNSET is set and a Link
+// to the same route under /api/v1
+func LegacyAPIMiddleware() fiber.Handler {
+	deprecation := fmt.Sprintf("@%d", legacyAPIDeprecatedAt.Unix())
+
+	var sunset string
+	if value := config.AppConfig.LegacyAPISunset; value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			sunset = date.Format(http.TimeFormat)
+		} else {
+			slog.Warn("LEGACY_API_SUNSET must be a YYYY-MM-DD date", "value", value)
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		// Also reached by /api/v1 requests that matched no route
+		if path == APIV1Prefix || strings.HasPrefix(path, APIV1Prefix+"/") {
+			return c.Next()
+		}
+
+		c.Set("Deprecation", deprecation)
+		if sunset != "" {
+			c.Set("Sunset", sunset)
+		}
+		c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`, APIV1Prefix, APIRoute(path)))
+		return c.Next()
+	}
+}
+
+// APIVersionMiddleware resolves which version a request is for: the one in its path, else
+// the one named in its API-Version header ("1" or "v1"), else CurrentAPIVersion. Unknown
+// versions, and a header that contradicts the path, are refused before any route runs.
+func APIVersionMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		pathVersion, versioned := apiPathVersion(c.Path())
+		headerVersion := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(c.Get(APIVersionHeader))), "v")
+
+		version := CurrentAPIVersion
+		switch {
+		case versioned && !isSupportedAPIVersion(pathVersion):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":              "Unknown API version v" + pathVersion,
+				"supported_versions": supportedAPIVersions,
+			})
+		case headerVersion != "" && !isSupportedAPIVersion(headerVersion):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":              "Unsupported " + APIVersionHeader + " " + c.Get(APIVersionHeader),
+				"supported_versions": supportedAPIVersions,
+			})
+		case versioned && headerVersion != "" && headerVersion != pathVersion:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": APIVersionHeader + " doesn't match the version in the path",
+			})
+		case versioned:
+			version = pathVersion
+		case headerVersion != "":
+			version = headerVersion
+		}
+
+		c.Set(APIVersionHeader, version)
+		return c.Next()
+	}
+}
+
+// apiPathVersion returns the version in a path like /api/v1/..., and whether it has one
+func apiPathVersion(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, APIPrefix+"/v")
+	if !ok {
+		return "", false
+	}
+	version, _, _ := strings.Cut(rest, "/")
+	if version == "" || strings.Trim(version, "0123456789") != "" {
+		return "", false
+	}
+	return version, true
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range supportedAPIVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newVersionedApp mounts one route under /api/v1 and, deprecated, under /api, the way the
+// router does
+func newVersionedApp() *fiber.App {
+	app := fiber.New()
+	api := app.Group(APIPrefix)
+	api.Use(APIVersionMiddleware())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Group(APIV1Prefix).Get("/heatmap/:username", ok)
+	app.Group(APIPrefix, LegacyAPIMiddleware()).Get("/heatmap/:username", ok)
+	return app
+}
+
+func TestAPIVersionNegotiation(t *testing.T) {
+	testutil.LoadConfig()
+	app := newVersionedApp()
+
+	tests := []struct {
+		name        string
+		path        string
+		header      string
+		wantStatus  int
+		wantVersion string
+	}{
+		{"path", "/api/v1/heatmap/alice", "", fiber.StatusOK, "1"},
+		{"path and matching header", "/api/v1/heatmap/alice", "1", fiber.StatusOK, "1"},
+		{"default", "/api/heatmap/alice", "", fiber.StatusOK, CurrentAPIVersion},
+		{"header", "/api/heatmap/alice", "1", fiber.StatusOK, "1"},
+		{"header with v", "/api/heatmap/alice", "V1", fiber.StatusOK, "1"},
+		{"unknown path version", "/api/v2/heatmap/alice", "", fiber.StatusNotFound, ""},
+		{"unknown header version", "/api/heatmap/alice", "2", fiber.StatusBadRequest, ""},
+		{"garbage header", "/api/heatmap/alice", "latest", fiber.StatusBadRequest, ""},
+		{"header contradicts path", "/api/v1/heatmap/alice", "2", fiber.StatusBadRequest, ""},
+		{"not a version", "/api/vitals", "", fiber.StatusNotFound, CurrentAPIVersion},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set(APIVersionHeader, tt.header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+		if got := resp.Header.Get(APIVersionHeader); got != tt.wantVersion {
+			t.Errorf("%s: %s %q, want %q", tt.name, APIVersionHeader, got, tt.wantVersion)
+		}
+	}
+}
+
+func TestLegacyAPIDeprecationHeaders(t *testing.T) {
+	testutil.LoadConfig()
+	previous := config.AppConfig.LegacyAPISunset
+	config.AppConfig.LegacyAPISunset = "2027-06-30"
+	defer func() { config.AppConfig.LegacyAPISunset = previous }()
+	app := newVersionedApp()
+
+	tests := []struct {
+		name           string
+		path           string
+		header         string
+		wantDeprecated bool
+		wantLink       string
+	}{
+		{"versioned", "/api/v1/heatmap/alice", "", false, ""},
+		{"legacy", "/api/heatmap/alice", "", true, `</api/v1/heatmap/alice>; rel="successor-version"`},
+		// Naming the version doesn't make the unversioned URL any less deprecated
+		{"legacy with header", "/api/heatmap/alice", "1", true, `</api/v1/heatmap/alice>; rel="successor-version"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set(APIVersionHeader, tt.header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		deprecation, sunset := resp.Header.Get("Deprecation"), resp.Header.Get("Sunset")
+		if !tt.wantDeprecated {
+			if deprecation != "" || sunset != "" || resp.Header.Get(fiber.HeaderLink) != "" {
+				t.Errorf("%s: deprecation headers on a versioned route", tt.name)
+			}
+			continue
+		}
+		if deprecation != "@1792108800" {
+			t.Errorf("%s: Deprecation %q, want @1792108800", tt.name, deprecation)
+		}
+		if sunset != "Wed, 30 Jun 2027 00:00:00 GMT" {
+			t.Errorf("%s: Sunset %q", tt.name, sunset)
+		}
+		if link := resp.Header.Get(fiber.HeaderLink); link != tt.wantLink {
+			t.Errorf("%s: Link %q, want %q", tt.name, link, tt.wantLink)
+		}
+	}
+}
+
+func TestAPIRoute(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/heatmap/:username": "/heatmap/:username",
+		"/api/heatmap/:username":    "/heatmap/:username",
+		"/api/v1":                   "",
+		"/api/v1x/heatmap":          "/v1x/heatmap",
+		"/health":                   "/health",
+	}
+	for path, want := range tests {
+		if got := APIRoute(path); got != want {
+			t.Errorf("APIRoute(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
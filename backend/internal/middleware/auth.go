@@ -5,16 +5,23 @@ import (
 
 	"docker-heatmap/internal/database"
 	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
 	"docker-heatmap/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 const (
-	UserContextKey = "user"
+	UserContextKey    = "user"
+	SessionContextKey = "session_id"
 )
 
-// AuthMiddleware validates JWT tokens and adds user to context
+var sessionService = services.NewSessionService()
+
+// AuthMiddleware validates JWT tokens and adds user to context. The token is only read
+// from the Authorization header, never a cookie: browsers don't attach it to cross-site
+// requests, so state-changing routes need no CSRF token. Keep it that way, or add CSRF
+// protection along with any cookie-based auth.
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
@@ -42,6 +49,13 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
+		// Tokens end with their session, such as after logout
+		if claims.SessionID != 0 && !sessionService.IsActive(claims.UserID, claims.SessionID) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Session has been revoked",
+			})
+		}
+
 		// Fetch user from database
 		var user models.User
 		if err := database.DB.First(&user, claims.UserID).Error; err != nil {
@@ -49,9 +63,15 @@ func AuthMiddleware() fiber.Handler {
 				"error": "User not found",
 			})
 		}
+		if user.Suspended() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Account suspended",
+			})
+		}
 
 		// Add user to context
 		c.Locals(UserContextKey, &user)
+		c.Locals(SessionContextKey, claims.SessionID)
 
 		return c.Next()
 	}
@@ -75,13 +95,17 @@ func OptionalAuthMiddleware() fiber.Handler {
 		if err != nil {
 			return c.Next()
 		}
+		if claims.SessionID != 0 && !sessionService.IsActive(claims.UserID, claims.SessionID) {
+			return c.Next()
+		}
 
 		var user models.User
-		if err := database.DB.First(&user, claims.UserID).Error; err != nil {
+		if err := database.DB.First(&user, claims.UserID).Error; err != nil || user.Suspended() {
 			return c.Next()
 		}
 
 		c.Locals(UserContextKey, &user)
+		c.Locals(SessionContextKey, claims.SessionID)
 		return c.Next()
 	}
 }
@@ -94,3 +118,10 @@ func GetUserFromContext(c *fiber.Ctx) *models.User {
 	}
 	return user
 }
+
+// GetSessionIDFromContext returns the session the request's token belongs to, or 0 for
+// tokens issued before sessions existed
+func GetSessionIDFromContext(c *fiber.Ctx) uint {
+	sessionID, _ := c.Locals(SessionContextKey).(uint)
+	return sessionID
+}
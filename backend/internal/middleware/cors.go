@@ -0,0 +1,22 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// ExposedHeaders are the response headers pages may read from the browser
+const ExposedHeaders = "X-Heatmap-Warnings,X-Cache-Bypass,X-Served-From,ETag,Deprecation,Sunset,Link," +
+	"Retry-After,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,X-RateLimit-Scope,X-Request-ID,API-Version"
+
+// PublicCORSMiddleware lets pages on any origin read a public, read-only response from the
+// browser, as the embeddable widget does with activity JSON and users' own sites do with
+// images and JSON. The global CORS middleware only allows FRONTEND_URL, and runs first, so
+// origins that config already allows keep their credentialed access.
+func PublicCORSMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderOrigin) != "" && len(c.Response().Header.Peek(fiber.HeaderAccessControlAllowOrigin)) == 0 {
+			c.Set(fiber.HeaderAccessControlAllowOrigin, "*")
+			c.Set(fiber.HeaderAccessControlExposeHeaders, ExposedHeaders)
+			c.Response().Header.Del(fiber.HeaderAccessControlAllowCredentials)
+		}
+		return c.Next()
+	}
+}
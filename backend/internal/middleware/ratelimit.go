@@ -86,6 +86,29 @@ func (rl *RateLimiter) Allow(key string) bool {
 	return true
 }
 
+// Remaining returns how many more requests key can make in the current window, and when
+// the oldest counted request falls out of it (now, if none are counted)
+func (rl *RateLimiter) Remaining(key string, now time.Time) (int, time.Time) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	used := 0
+	resetAt := now
+	for _, t := range rl.requests[key] {
+		if now.Sub(t) < rl.window {
+			if used == 0 || t.Add(rl.window).Before(resetAt) {
+				resetAt = t.Add(rl.window)
+			}
+			used++
+		}
+	}
+
+	if used >= rl.limit {
+		return 0, resetAt
+	}
+	return rl.limit - used, resetAt
+}
+
 // RateLimitMiddleware creates a rate limiting middleware
 func RateLimitMiddleware(limit int, window time.Duration) fiber.Handler {
 	limiter := NewRateLimiter(limit, window)
@@ -106,17 +129,17 @@ func RateLimitMiddleware(limit int, window time.Duration) fiber.Handler {
 
 // StrictRateLimitMiddleware for sensitive endpoints (lower limits)
 func StrictRateLimitMiddleware() fiber.Handler {
-	return RateLimitMiddleware(10, time.Minute)
+	return AuthRateLimit.Middleware()
 }
 
 // APIRateLimitMiddleware for general API endpoints
 func APIRateLimitMiddleware() fiber.Handler {
-	return RateLimitMiddleware(100, time.Minute)
+	return APIRateLimit.Middleware()
 }
 
 // PublicRateLimitMiddleware for public endpoints like SVG/JSON
 func PublicRateLimitMiddleware() fiber.Handler {
-	return RateLimitMiddleware(60, time.Minute)
+	return PublicRateLimit.Middleware()
 }
 
 // EnforceJSONMiddleware ensures that the client accepts JSON responses
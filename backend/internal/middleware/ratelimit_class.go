@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitTier is the rate-limit tier every client is on; limits aren't per-account yet
+const RateLimitTier = "standard"
+
+// usageWindow is how far back per-class usage is reported
+const usageWindow = 24 * time.Hour
+
+// RateLimitClass is a group of endpoints sharing one per-client limit. Besides enforcing
+// the limit it counts each client's requests per hour, for /api/user/limits.
+type RateLimitClass struct {
+	Name    string
+	Limit   int
+	Window  time.Duration
+	limiter *RateLimiter
+
+	mu    sync.Mutex
+	usage map[string]*[24]usageBucket
+}
+
+// usageBucket counts one client's requests within one hour
+type usageBucket struct {
+	hour     int64 // Unix hour the counts belong to
+	allowed  int
+	rejected int
+}
+
+// Endpoint classes, in the order they are reported
+var (
+	PublicRateLimit = newRateLimitClass("public", 60, time.Minute)
+	APIRateLimit    = newRateLimitClass("api", 100, time.Minute)
+	AuthRateLimit   = newRateLimitClass("auth", 10, time.Minute)
+)
+
+// RateLimitClasses lists every endpoint class
+func RateLimitClasses() []*RateLimitClass {
+	return []*RateLimitClass{PublicRateLimit, APIRateLimit, AuthRateLimit}
+}
+
+func newRateLimitClass(name string, limit int, window time.Duration) *RateLimitClass {
+	rc := &RateLimitClass{
+		Name:    name,
+		Limit:   limit,
+		Window:  window,
+		limiter: NewRateLimiter(limit, window),
+		usage:   make(map[string]*[24]usageBucket),
+	}
+
+	// Drop clients with no requests in the reporting window
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		for range ticker.C {
+			rc.cleanupUsage()
+		}
+	}()
+
+	return rc
+}
+
+// Middleware enforces the class limit per client IP
+func (rc *RateLimitClass) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.IP()
+
+		allowed := rc.limiter.Allow(key)
+		rc.record(key, allowed, time.Now())
+		if !allowed {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":       "Rate limit exceeded",
+				"retry_after": rc.Window.Seconds(),
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func (rc *RateLimitClass) record(key string, allowed bool, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	buckets, ok := rc.usage[key]
+	if !ok {
+		// Same cap as the limiter, so unique IPs can't exhaust memory
+		if len(rc.usage) >= rc.limiter.maxKeys {
+			return
+		}
+		buckets = &[24]usageBucket{}
+		rc.usage[key] = buckets
+	}
+
+	hour := now.Unix() / 3600
+	bucket := &buckets[hour%24]
+	if bucket.hour != hour {
+		*bucket = usageBucket{hour: hour}
+	}
+	if allowed {
+		bucket.allowed++
+	} else {
+		bucket.rejected++
+	}
+}
+
+func (rc *RateLimitClass) cleanupUsage() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	oldest := time.Now().Add(-usageWindow).Unix() / 3600
+	for key, buckets := range rc.usage {
+		active := false
+		for _, bucket := range buckets {
+			if bucket.hour > oldest {
+				active = true
+				break
+			}
+		}
+		if !active {
+			delete(rc.usage, key)
+		}
+	}
+}
+
+// RateLimitStatus is a client's standing within one endpoint class
+type RateLimitStatus struct {
+	Class         string    `json:"class"`
+	Limit         int       `json:"limit"`
+	WindowSeconds int       `json:"window_seconds"`
+	Remaining     int       `json:"remaining"`
+	ResetAt       time.Time `json:"reset_at"`
+	Requests24h   int       `json:"requests_24h"`
+	Rejected24h   int       `json:"rejected_24h"`
+}
+
+// Status reports the client's remaining quota and its usage over the last 24 hours
+func (rc *RateLimitClass) Status(key string, now time.Time) RateLimitStatus {
+	remaining, resetAt := rc.limiter.Remaining(key, now)
+	status := RateLimitStatus{
+		Class:         rc.Name,
+		Limit:         rc.Limit,
+		WindowSeconds: int(rc.Window.Seconds()),
+		Remaining:     remaining,
+		ResetAt:       resetAt,
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if buckets, ok := rc.usage[key]; ok {
+		oldest := now.Add(-usageWindow).Unix() / 3600
+		for _, bucket := range buckets {
+			if bucket.hour > oldest {
+				status.Requests24h += bucket.allowed + bucket.rejected
+				status.Rejected24h += bucket.rejected
+			}
+		}
+	}
+	return status
+}
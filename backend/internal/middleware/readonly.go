@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"docker-heatmap/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadOnlyErrorCode is returned to clients when a write is rejected during maintenance
+const ReadOnlyErrorCode = "read_only_mode"
+
+// readOnlyExemptRoutes keep working in read-only mode. The session routes only write the
+// rows that keep people signed in, not user data, and without them everyone would be
+// logged out once their access token expired. The admin switch has to work too, or
+// read-only mode could only be left by a restart.
+var readOnlyExemptRoutes = map[string]bool{
+	"/auth/refresh":        true,
+	"/auth/password/login": true,
+	"/auth/logout":         true,
+	"/admin/read-only":     true,
+}
+
+// ReadOnlyMiddleware rejects state-changing requests while the deployment is in read-only
+// mode, except the GraphQL endpoint, the session routes and the admin switch
+func ReadOnlyMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !config.IsReadOnly() {
+			return c.Next()
+		}
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		route := APIRoute(c.Path())
+		// The GraphQL schema has no mutations, so its POSTs never write
+		if route == "/graphql" || readOnlyExemptRoutes[route] {
+			return c.Next()
+		}
+
+		return ReadOnlyError(c)
+	}
+}
+
+// ReadOnlyError writes the standard 503 response for writes attempted in read-only mode
+func ReadOnlyError(c *fiber.Ctx) error {
+	c.Set("Retry-After", "300")
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "The service is in read-only maintenance mode, please try again later",
+		"code":  ReadOnlyErrorCode,
+	})
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"docker-heatmap/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	config.SetReadOnly(true)
+	defer config.SetReadOnly(false)
+
+	app := fiber.New()
+	app.Use(ReadOnlyMiddleware())
+	app.All("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{fiber.MethodGet, "/api/v1/heatmap/alice", fiber.StatusOK},
+		{fiber.MethodHead, "/api/v1/heatmap/alice", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/graphql", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/auth/refresh", fiber.StatusOK},
+		{fiber.MethodPost, "/api/auth/refresh", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/auth/password/login", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/auth/logout", fiber.StatusOK},
+		{fiber.MethodPut, "/api/v1/admin/read-only", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/admin/users/1/suspend", fiber.StatusServiceUnavailable},
+		{fiber.MethodPost, "/api/v1/auth/password/signup", fiber.StatusServiceUnavailable},
+		{fiber.MethodPost, "/api/v1/docker/sync", fiber.StatusServiceUnavailable},
+		{fiber.MethodPut, "/api/v1/user/me", fiber.StatusServiceUnavailable},
+		{fiber.MethodDelete, "/api/v1/user/me", fiber.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyMiddlewareOff(t *testing.T) {
+	config.SetReadOnly(false)
+
+	app := fiber.New()
+	app.Use(ReadOnlyMiddleware())
+	app.Post("/api/v1/docker/sync", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusAccepted)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/api/v1/docker/sync", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Errorf("status %d, want %d", resp.StatusCode, fiber.StatusAccepted)
+	}
+}
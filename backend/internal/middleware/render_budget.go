@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/cache"
+	"docker-heatmap/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// renderBudgetTimeout keeps a slow Redis from holding up the render it is guarding
+const renderBudgetTimeout = 200 * time.Millisecond
+
+// rateLimitedMaxAge is how long clients and proxies may keep the rate limited image
+const rateLimitedMaxAge = 60
+
+// rateLimitedSVG is served instead of a render once a budget is spent. It is a fixed
+// image, so answering with it costs nothing however hard an endpoint is hit.
+var rateLimitedSVG = []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="300" height="40" viewBox="0 0 300 40" role="img" aria-label="Docker Heatmap: rate limited">` +
+	`<rect width="300" height="40" rx="6" fill="#f6f8fa" stroke="#d0d7de"/>` +
+	`<text x="150" y="25" text-anchor="middle" font-family="-apple-system,BlinkMacSystemFont,Segoe UI,Helvetica,Arial,sans-serif" font-size="13" fill="#57606a">Heatmap rate limited, try again later</text>` +
+	`</svg>`)
+
+// renderBudgetScript trims a sliding window kept as a sorted set of request times, counts
+// this request if the budget allows it, and returns whether it did, how many requests the
+// window now holds and when its oldest request leaves it (unix ms)
+var renderBudgetScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', KEYS[1], now, ARGV[4])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = now + window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+  reset = tonumber(oldest[2]) + window
+end
+return {allowed, count, reset}
+`)
+
+var (
+	renderBudgetOnce     sync.Once
+	ipRenderBudget       *renderBudget
+	usernameRenderBudget *renderBudget
+)
+
+// renderBudget is a sliding-window budget of renders per key. It is shared across
+// instances through Redis when configured, and kept per instance otherwise or while
+// Redis is failing.
+type renderBudget struct {
+	scope  string // What keys are: "username" or "ip"
+	limit  int
+	window time.Duration
+	local  *RateLimiter
+}
+
+// budgetResult is the state of one budget after a request
+type budgetResult struct {
+	scope     string
+	limit     int
+	allowed   bool
+	remaining int
+	resetAt   time.Time
+}
+
+func newRenderBudget(scope string, limit int, window time.Duration) *renderBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &renderBudget{
+		scope:  scope,
+		limit:  limit,
+		window: window,
+		local:  NewRateLimiter(limit, window),
+	}
+}
+
+// take counts a request against key's budget if there is room
+func (b *renderBudget) take(key string) budgetResult {
+	now := time.Now()
+	if cache.Enabled() {
+		result, err := b.takeShared(key, now)
+		if err == nil {
+			return result
+		}
+		slog.Warn("Render budget falling back to this instance", "error", err)
+	}
+
+	allowed := b.local.Allow(key)
+	remaining, resetAt := b.local.Remaining(key, now)
+	return budgetResult{scope: b.scope, limit: b.limit, allowed: allowed, remaining: remaining, resetAt: resetAt}
+}
+
+func (b *renderBudget) takeShared(key string, now time.Time) (budgetResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), renderBudgetTimeout)
+	defer cancel()
+
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+	values, err := renderBudgetScript.Run(ctx, cache.Client, []string{"budget:" + b.scope + ":" + key},
+		now.UnixMilli(), b.window.Milliseconds(), b.limit, member).Int64Slice()
+	if err != nil {
+		return budgetResult{}, err
+	}
+	if len(values) != 3 {
+		return budgetResult{}, fmt.Errorf("unexpected render budget reply %v", values)
+	}
+	return budgetResult{
+		scope:     b.scope,
+		limit:     b.limit,
+		allowed:   values[0] == 1,
+		remaining: max(b.limit-int(values[1]), 0),
+		resetAt:   time.UnixMilli(values[2]),
+	}, nil
+}
+
+// maxBudgetedMembers caps how many team members one request is charged to; the team
+// endpoint rejects more than this anyway
+const maxBudgetedMembers = 5
+
+// renderedUsernames returns the accounts a render request draws, lowercased: the username,
+// both sides of a comparison, or each team member
+func renderedUsernames(c *fiber.Ctx) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		// Params point into the request buffer, which fiber reuses, and the names outlive it
+		// as budget keys
+		name = strings.Clone(strings.ToLower(strings.TrimSpace(strings.TrimSuffix(name, ".svg"))))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			usernames = append(usernames, name)
+		}
+	}
+
+	for _, param := range []string{"username", "userA", "userB"} {
+		add(c.Params(param))
+	}
+	if members := c.Query("members"); members != "" {
+		for _, member := range strings.Split(members, ",") {
+			if len(usernames) == maxBudgetedMembers {
+				break
+			}
+			add(member)
+		}
+	}
+	return usernames
+}
+
+// RenderBudgetMiddleware guards the image endpoints with per-client-IP and per-username
+// budgets of renders (RENDER_BUDGET_*), so hammering one heatmap, or many, can't wear down
+// the database. Comparisons and team heatmaps are charged to every account they draw. Over
+// budget, it answers 429 with a fixed "rate limited" image, which shows where the heatmap
+// was embedded instead of a broken image. The X-RateLimit-* headers
+// report whichever budget has less room left. Every API version shares the same budgets.
+func RenderBudgetMiddleware() fiber.Handler {
+	renderBudgetOnce.Do(func() {
+		limits := config.AppConfig.Render
+		ipRenderBudget = newRenderBudget("ip", limits.BudgetPerIP, limits.BudgetWindow)
+		usernameRenderBudget = newRenderBudget("username", limits.BudgetPerUsername, limits.BudgetWindow)
+	})
+
+	return func(c *fiber.Ctx) error {
+		var results []budgetResult
+		if ipRenderBudget != nil {
+			results = append(results, ipRenderBudget.take(c.IP()))
+		}
+		// An IP over its own budget doesn't use up the usernames'
+		if usernameRenderBudget != nil && (len(results) == 0 || results[0].allowed) {
+			for _, username := range renderedUsernames(c) {
+				results = append(results, usernameRenderBudget.take(username))
+			}
+		}
+		if len(results) == 0 {
+			return c.Next()
+		}
+
+		tightest := results[0]
+		for _, result := range results[1:] {
+			if !result.allowed || (tightest.allowed && result.remaining < tightest.remaining) {
+				tightest = result
+			}
+		}
+		c.Set("X-RateLimit-Limit", strconv.Itoa(tightest.limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(tightest.remaining))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(tightest.resetAt.Unix(), 10))
+		c.Set("X-RateLimit-Scope", tightest.scope)
+		if tightest.allowed {
+			return c.Next()
+		}
+
+		retryAfter := max(int(time.Until(tightest.resetAt).Seconds())+1, 1)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", min(retryAfter, rateLimitedMaxAge)))
+		if strings.HasSuffix(c.Path(), ".txt") {
+			c.Set("Content-Type", "text/plain; charset=utf-8")
+			return c.Status(fiber.StatusTooManyRequests).SendString("Heatmap rate limited, try again later\n")
+		}
+		c.Set("Content-Type", "image/svg+xml")
+		return c.Status(fiber.StatusTooManyRequests).Send(rateLimitedSVG)
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// useRenderBudgets replaces the configured budgets for one test
+func useRenderBudgets(t *testing.T, perIP, perUsername int) {
+	renderBudgetOnce.Do(func() {})
+	prevIP, prevUsername := ipRenderBudget, usernameRenderBudget
+	ipRenderBudget = newRenderBudget("ip", perIP, time.Hour)
+	usernameRenderBudget = newRenderBudget("username", perUsername, time.Hour)
+	t.Cleanup(func() {
+		ipRenderBudget, usernameRenderBudget = prevIP, prevUsername
+	})
+}
+
+func TestRenderBudgetChargesEveryRenderedAccount(t *testing.T) {
+	useRenderBudgets(t, 0, 1)
+
+	app := fiber.New()
+	budget := RenderBudgetMiddleware()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/heatmap/:username", budget, ok)
+	app.Get("/compare/:userA/:userB.svg", budget, ok)
+	app.Get("/team/heatmap.svg", budget, ok)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/compare/alice/bob.svg", fiber.StatusOK},
+		// Both sides of a comparison were charged
+		{"/heatmap/alice.svg", fiber.StatusTooManyRequests},
+		{"/heatmap/Bob", fiber.StatusTooManyRequests},
+		{"/team/heatmap.svg?members=carol,%20dave,carol", fiber.StatusOK},
+		// And every team member
+		{"/heatmap/carol", fiber.StatusTooManyRequests},
+		{"/compare/erin/dave.svg", fiber.StatusTooManyRequests},
+		{"/heatmap/frank", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestRenderedUsernames(t *testing.T) {
+	app := fiber.New()
+	var got []string
+	app.Get("/team/heatmap", func(c *fiber.Ctx) error {
+		got = renderedUsernames(c)
+		return nil
+	})
+
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/team/heatmap?members=a,,B,b,c,d,e,f,g", nil)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "c", "d", "e"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
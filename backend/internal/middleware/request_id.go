@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"docker-heatmap/internal/logging"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDHeader carries the request ID, from a proxy in front that set one and back in
+// every response
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDContextKey holds the request ID in fiber locals
+const RequestIDContextKey = "request_id"
+
+// maxRequestIDLength bounds a request ID taken from the client, which ends up in every log line
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware gives every request an ID: the X-Request-ID it came with, when that
+// looks like one, or a new one. The ID is sent back in the response, and the logger of the
+// request's user context (c.UserContext()) adds it to every line, so services handed that
+// context log it too.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Locals(RequestIDContextKey, id)
+		c.Set(RequestIDHeader, id)
+		c.SetUserContext(logging.With(c.UserContext(), "request_id", id))
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the ID RequestIDMiddleware gave the request
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDContextKey).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
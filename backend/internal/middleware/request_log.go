@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/logging"
+	"docker-heatmap/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestLogMiddleware logs every request once it's answered, through the request's
+// logger, at warn for server errors, debug for passing health checks and info otherwise,
+// and counts API requests for the admin stats. It goes after RequestIDMiddleware.
+func RequestLogMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		if err != nil {
+			// Answer now, so the status logged is the one the client gets
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if strings.HasPrefix(c.Path(), APIPrefix) {
+			services.RecordRequest(status)
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelWarn
+		case strings.HasPrefix(c.Path(), "/health"):
+			// Probes poll every few seconds; only failures are worth seeing by default
+			level = slog.LevelDebug
+		}
+		logging.FromContext(c.UserContext()).Log(c.UserContext(), level, "request",
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"ip", c.IP(),
+		)
+		return nil
+	}
+}
@@ -0,0 +1,320 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/postgres/*.sql sql/mysql/*.sql
+var files embed.FS
+
+// lockKey serializes migrations across instances starting at the same time
+const lockKey = 72170514
+
+// lockName is lockKey's counterpart for MySQL's named locks
+const lockName = "docker_heatmap_migrations"
+
+var (
+	ErrSchemaBehind = errors.New("database schema is behind this build; run the migrate up command")
+	ErrSchemaAhead  = errors.New("database schema is newer than this build")
+)
+
+// Migration is one versioned schema change with its SQL in both directions
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Applied records a migration that has been run against the database
+type Applied struct {
+	Version   int       `gorm:"column:version;primaryKey" json:"version"`
+	Name      string    `gorm:"column:name;not null" json:"name"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null" json:"applied_at"`
+}
+
+func (Applied) TableName() string {
+	return "schema_migrations"
+}
+
+// Load returns the embedded migrations for a GORM dialect ("postgres" or "mysql") in
+// version order. Files are named NNNN_name.up.sql and NNNN_name.down.sql, and every
+// version needs both.
+func Load(dialect string) ([]Migration, error) {
+	dir := "sql/" + dialect
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for the %s dialect", dialect)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", name)
+		}
+		prefix, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: missing version prefix", name)
+		}
+
+		body, err := files.ReadFile(dir + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the version this build expects the schema to be at
+func Latest(db *gorm.DB) (int, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Current returns the highest applied version, or 0 for an unmigrated database
+func Current(db *gorm.DB) (int, error) {
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.Model(&Applied{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Up applies every pending migration in order, each in its own transaction, and returns
+// how many ran
+func Up(db *gorm.DB) (int, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+
+	ran := 0
+	for _, m := range migrations {
+		applied, err := apply(db, m)
+		if err != nil {
+			return ran, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		if applied {
+			ran++
+		}
+	}
+	return ran, nil
+}
+
+// Down reverts the most recently applied migrations, newest first, and returns how many
+// were reverted
+func Down(db *gorm.DB, steps int) (int, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	reverted := 0
+	for reverted < steps {
+		var last Applied
+		result := db.Order("version DESC").Limit(1).Find(&last)
+		if result.Error != nil {
+			return reverted, result.Error
+		}
+		if result.RowsAffected == 0 {
+			break
+		}
+
+		m, ok := byVersion[last.Version]
+		if !ok {
+			return reverted, fmt.Errorf("migration %d_%s is not known to this build", last.Version, last.Name)
+		}
+		if err := revert(db, m); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// MigrationStatus reports whether one known migration has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Status lists every migration known to this build alongside when it was applied
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	var applied []Applied
+	if err := db.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Verify checks the database is at exactly the version this build expects, so the
+// server refuses to start against a schema it wasn't written for
+func Verify(db *gorm.DB) error {
+	latest, err := Latest(db)
+	if err != nil {
+		return err
+	}
+	current, err := Current(db)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case current < latest:
+		return fmt.Errorf("%w (at version %d, expected %d)", ErrSchemaBehind, current, latest)
+	case current > latest:
+		return fmt.Errorf("%w (at version %d, expected %d)", ErrSchemaAhead, current, latest)
+	}
+	return nil
+}
+
+func ensureTable(db *gorm.DB) error {
+	if db.Dialector.Name() == "mysql" {
+		return db.Exec(`
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version    BIGINT PRIMARY KEY,
+				name       VARCHAR(255) NOT NULL,
+				applied_at DATETIME(3) NOT NULL
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4
+		`).Error
+	}
+
+	return db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)
+	`).Error
+}
+
+// locked runs fn while holding the migration lock, so instances starting at the same time
+// apply each migration once. On Postgres fn runs in a transaction holding an advisory
+// lock, so a failed migration leaves nothing behind. MySQL commits DDL implicitly and
+// can't roll a migration back, so fn runs on one connection holding a named lock instead.
+func locked(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if db.Dialector.Name() != "mysql" {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+			return fn(tx)
+		})
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		var acquired int
+		if err := conn.Raw("SELECT GET_LOCK(?, 60)", lockName).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if acquired != 1 {
+			return errors.New("timed out waiting for the migration lock")
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		return fn(conn)
+	})
+}
+
+// apply runs one migration unless another instance already has
+func apply(db *gorm.DB, m Migration) (bool, error) {
+	applied := false
+	err := locked(db, func(tx *gorm.DB) error {
+		var exists int64
+		if err := tx.Model(&Applied{}).Where("version = ?", m.Version).Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists > 0 {
+			return nil
+		}
+
+		if err := tx.Exec(m.Up).Error; err != nil {
+			return err
+		}
+		applied = true
+		return tx.Create(&Applied{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+	return applied, err
+}
+
+func revert(db *gorm.DB, m Migration) error {
+	return locked(db, func(tx *gorm.DB) error {
+		if err := tx.Exec(m.Down).Error; err != nil {
+			return err
+		}
+		return tx.Where("version = ?", m.Version).Delete(&Applied{}).Error
+	})
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS notification_deliveries;
+DROP TABLE IF EXISTS notification_channels;
+DROP TABLE IF EXISTS heatmap_snapshots;
+DROP TABLE IF EXISTS activity_events;
+DROP TABLE IF EXISTS docker_accounts;
+DROP TABLE IF EXISTS users;
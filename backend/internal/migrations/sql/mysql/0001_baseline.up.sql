@@ -0,0 +1,105 @@
+-- Schema as it stood when versioned migrations were introduced, for MySQL 8 and
+-- MariaDB 10.6+. MySQL support is newer than the baseline, so this always runs on an
+-- empty database.
+
+CREATE TABLE users (
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at       DATETIME(3),
+    updated_at       DATETIME(3),
+    deleted_at       DATETIME(3),
+    github_id        BIGINT NOT NULL,
+    github_username  TEXT NOT NULL,
+    github_email     TEXT,
+    avatar_url       TEXT,
+    name             TEXT,
+    public_profile   BOOLEAN DEFAULT true,
+    bio              TEXT,
+    timezone         TEXT,
+    week_start       TEXT,
+    hide_attribution BOOLEAN DEFAULT false,
+    UNIQUE INDEX idx_users_github_id (github_id),
+    INDEX idx_users_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE docker_accounts (
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at       DATETIME(3),
+    updated_at       DATETIME(3),
+    deleted_at       DATETIME(3),
+    user_id          BIGINT UNSIGNED NOT NULL,
+    docker_username  VARCHAR(255) NOT NULL,
+    encrypted_token  TEXT NOT NULL,
+    token_iv         TEXT NOT NULL,
+    last_sync_at     DATETIME(3),
+    last_sync_error  TEXT,
+    sync_in_progress BOOLEAN DEFAULT false,
+    is_active        BOOLEAN DEFAULT true,
+    auto_refresh     BOOLEAN DEFAULT true,
+    UNIQUE INDEX idx_docker_accounts_docker_username (docker_username),
+    INDEX idx_docker_accounts_user_id (user_id),
+    INDEX idx_docker_accounts_deleted_at (deleted_at),
+    CONSTRAINT fk_users_docker_accounts FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE activity_events (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    updated_at        DATETIME(3),
+    deleted_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    event_type        VARCHAR(16) NOT NULL,
+    event_date        DATETIME(3) NOT NULL,
+    event_at          DATETIME(3),
+    count             BIGINT NOT NULL DEFAULT 1,
+    source            VARCHAR(32),
+    repository        VARCHAR(255),
+    tag               VARCHAR(128),
+    INDEX idx_activity_account_date (docker_account_id, event_date),
+    INDEX idx_activity_events_event_type (event_type),
+    INDEX idx_activity_events_deleted_at (deleted_at),
+    CONSTRAINT fk_docker_accounts_activity_events FOREIGN KEY (docker_account_id) REFERENCES docker_accounts (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE heatmap_snapshots (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    `until`           DATETIME(3) NOT NULL,
+    options_hash      VARCHAR(64) NOT NULL,
+    svg               LONGBLOB NOT NULL,
+    UNIQUE INDEX idx_snapshot_account_until_options (docker_account_id, `until`, options_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE notification_channels (
+    id                 BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at         DATETIME(3),
+    updated_at         DATETIME(3),
+    deleted_at         DATETIME(3),
+    user_id            BIGINT UNSIGNED NOT NULL,
+    type               VARCHAR(32) NOT NULL,
+    name               TEXT,
+    encrypted_settings TEXT NOT NULL,
+    settings_iv        TEXT NOT NULL,
+    events             TEXT,
+    enabled            BOOLEAN DEFAULT true,
+    last_delivered_at  DATETIME(3),
+    last_error         TEXT,
+    INDEX idx_notification_channels_user_id (user_id),
+    INDEX idx_notification_channels_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE notification_deliveries (
+    id              BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at      DATETIME(3),
+    updated_at      DATETIME(3),
+    channel_id      BIGINT UNSIGNED NOT NULL,
+    event           VARCHAR(64) NOT NULL,
+    payload         TEXT NOT NULL,
+    status          VARCHAR(16) NOT NULL,
+    attempts        BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    last_error      TEXT,
+    INDEX idx_notification_deliveries_channel_id (channel_id),
+    INDEX idx_delivery_due (status, next_attempt_at),
+    CONSTRAINT fk_notification_deliveries_channel FOREIGN KEY (channel_id) REFERENCES notification_channels (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
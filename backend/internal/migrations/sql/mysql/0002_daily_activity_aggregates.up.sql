@@ -0,0 +1,10 @@
+-- Per-day, per-type totals maintained after every sync, so UTC summaries are a single
+-- indexed range scan instead of a scan over every event
+CREATE TABLE daily_activity_aggregates (
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    event_date        DATETIME(3) NOT NULL,
+    event_type        VARCHAR(16) NOT NULL,
+    count             BIGINT NOT NULL,
+    updated_at        DATETIME(3),
+    PRIMARY KEY (docker_account_id, event_date, event_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE activity_events
+    DROP INDEX idx_activity_event_identity,
+    DROP COLUMN live_marker;
@@ -0,0 +1,6 @@
+-- One live row per account, day, repository, tag and event type, which sync upserts rely on.
+-- MySQL has no partial indexes, so live rows carry a marker that is NULL once soft-deleted;
+-- NULLs never collide in a unique index.
+ALTER TABLE activity_events
+    ADD COLUMN live_marker TINYINT AS (IF(deleted_at IS NULL, 1, NULL)) VIRTUAL,
+    ADD UNIQUE INDEX idx_activity_event_identity (docker_account_id, event_date, repository, tag, event_type, live_marker);
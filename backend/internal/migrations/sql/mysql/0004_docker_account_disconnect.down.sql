@@ -0,0 +1 @@
+ALTER TABLE docker_accounts DROP COLUMN disconnect_scheduled_at;
@@ -0,0 +1,8 @@
+-- Per-day render and profile view counts, flushed from memory by each instance
+CREATE TABLE daily_view_counts (
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    day               DATE NOT NULL,
+    kind              VARCHAR(16) NOT NULL,
+    count             BIGINT NOT NULL,
+    PRIMARY KEY (docker_account_id, day, kind)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- Additional namespaces an account syncs once the owner proves control of them
+CREATE TABLE namespace_claims (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    namespace         VARCHAR(255) NOT NULL,
+    token             VARCHAR(64) NOT NULL,
+    expires_at        DATETIME(3) NOT NULL,
+    verified_at       DATETIME(3),
+    UNIQUE INDEX idx_namespace_claim_account (docker_account_id, namespace),
+    INDEX idx_namespace_claims_namespace (namespace)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,10 @@
+-- Compressed chunks of activity events moved out of activity_events after the retention window
+CREATE TABLE activity_archives (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    month             DATE NOT NULL,
+    event_count       BIGINT NOT NULL,
+    data              LONGBLOB NOT NULL,
+    INDEX idx_activity_archive_account_month (docker_account_id, month)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- Docker Hub metadata (pulls, stars) for each synced repository
+CREATE TABLE docker_repositories (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    updated_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    name              VARCHAR(255) NOT NULL,
+    description       TEXT,
+    pull_count        BIGINT NOT NULL DEFAULT 0,
+    star_count        INT NOT NULL DEFAULT 0,
+    is_private        BOOLEAN NOT NULL DEFAULT FALSE,
+    last_updated      DATETIME(3),
+    UNIQUE INDEX idx_docker_repository_account_name (docker_account_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
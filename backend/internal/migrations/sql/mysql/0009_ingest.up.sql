@@ -0,0 +1,23 @@
+-- Signing keys for the ingest API; secrets are encrypted, since verifying an HMAC needs them
+CREATE TABLE ingest_keys (
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at       DATETIME(3),
+    user_id          BIGINT UNSIGNED NOT NULL,
+    name             VARCHAR(100) NOT NULL,
+    key_id           VARCHAR(32) NOT NULL,
+    encrypted_secret TEXT NOT NULL,
+    secret_iv        TEXT NOT NULL,
+    last_used_at     DATETIME(3),
+    UNIQUE INDEX idx_ingest_keys_key_id (key_id),
+    INDEX idx_ingest_keys_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Fingerprints of ingested events, so retried batches aren't counted twice
+CREATE TABLE ingest_receipts (
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    fingerprint       VARCHAR(64) NOT NULL,
+    event_at          DATETIME(3) NOT NULL,
+    created_at        DATETIME(3),
+    PRIMARY KEY (docker_account_id, fingerprint),
+    INDEX idx_ingest_receipts_event_at (event_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
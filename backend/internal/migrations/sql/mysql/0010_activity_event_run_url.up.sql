@@ -0,0 +1,2 @@
+-- Link to the CI run behind a reported build
+ALTER TABLE activity_events ADD COLUMN run_url VARCHAR(512);
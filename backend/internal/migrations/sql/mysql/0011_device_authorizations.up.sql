@@ -0,0 +1,16 @@
+-- Pending device logins; the device code is stored hashed, since it becomes a token once approved
+CREATE TABLE device_authorizations (
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at       DATETIME(3),
+    device_code_hash VARCHAR(64) NOT NULL,
+    user_code        VARCHAR(9) NOT NULL,
+    client_name      VARCHAR(64),
+    expires_at       DATETIME(3) NOT NULL,
+    interval_seconds INT NOT NULL,
+    last_polled_at   DATETIME(3),
+    user_id          BIGINT UNSIGNED,
+    denied           BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE INDEX idx_device_authorizations_device_code_hash (device_code_hash),
+    UNIQUE INDEX idx_device_authorizations_user_code (user_code),
+    INDEX idx_device_authorizations_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
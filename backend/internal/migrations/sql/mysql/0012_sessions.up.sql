@@ -0,0 +1,18 @@
+-- Logins, each with a rotating refresh token; only token hashes are stored
+CREATE TABLE sessions (
+    id                  BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at          DATETIME(3),
+    user_id             BIGINT UNSIGNED NOT NULL,
+    refresh_token_hash  VARCHAR(64) NOT NULL,
+    previous_token_hash VARCHAR(64),
+    name                VARCHAR(64),
+    user_agent          VARCHAR(255),
+    ip_address          VARCHAR(45),
+    last_used_at        DATETIME(3) NOT NULL,
+    expires_at          DATETIME(3) NOT NULL,
+    revoked_at          DATETIME(3),
+    UNIQUE INDEX idx_sessions_refresh_token_hash (refresh_token_hash),
+    INDEX idx_sessions_previous_token_hash (previous_token_hash),
+    INDEX idx_sessions_user_id (user_id),
+    INDEX idx_sessions_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
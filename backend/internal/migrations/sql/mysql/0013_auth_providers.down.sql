@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_identities;
+ALTER TABLE users DROP COLUMN email_verified;
+ALTER TABLE users DROP COLUMN provider;
+-- Fails while accounts made with another provider exist, rather than guessing a GitHub id
+ALTER TABLE users MODIFY github_id BIGINT NOT NULL;
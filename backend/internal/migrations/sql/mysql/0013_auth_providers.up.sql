@@ -0,0 +1,17 @@
+-- Sign-in with GitLab and Google as well as GitHub. Accounts made with another provider
+-- have no GitHub id, and logins from other providers are kept as identities.
+ALTER TABLE users MODIFY github_id BIGINT NULL;
+ALTER TABLE users ADD COLUMN provider VARCHAR(20) NOT NULL DEFAULT 'github';
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE user_identities (
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at       DATETIME(3),
+    user_id          BIGINT UNSIGNED NOT NULL,
+    provider         VARCHAR(20) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    username         VARCHAR(255),
+    email            VARCHAR(255),
+    UNIQUE INDEX idx_user_identities_provider_user (provider, provider_user_id),
+    INDEX idx_user_identities_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
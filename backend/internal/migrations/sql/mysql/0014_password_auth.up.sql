@@ -0,0 +1,16 @@
+-- Email and password sign-in for deployments without an OAuth app. Tokens mailed for
+-- verifying an address or resetting a password are stored hashed.
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(255);
+
+CREATE TABLE email_tokens (
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3),
+    user_id    BIGINT UNSIGNED NOT NULL,
+    purpose    VARCHAR(20) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at    DATETIME(3),
+    UNIQUE INDEX idx_email_tokens_token_hash (token_hash),
+    INDEX idx_email_tokens_user_id (user_id),
+    INDEX idx_email_tokens_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
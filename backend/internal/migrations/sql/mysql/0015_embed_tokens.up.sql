@@ -0,0 +1,13 @@
+-- Named tokens that show a private profile's heatmap where a URL is pasted, until revoked.
+-- Tokens are stored hashed; only a short prefix is kept to tell them apart.
+CREATE TABLE embed_tokens (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(3),
+    user_id      BIGINT UNSIGNED NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    token_hash   VARCHAR(64) NOT NULL,
+    last_used_at DATETIME(3),
+    UNIQUE INDEX idx_embed_tokens_token_hash (token_hash),
+    INDEX idx_embed_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- Account-level actions, such as connecting Docker Hub or signing in, with where they
+-- came from, so users can review what happened to their account.
+CREATE TABLE audit_logs (
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3),
+    user_id    BIGINT UNSIGNED NOT NULL,
+    action     VARCHAR(50) NOT NULL,
+    detail     VARCHAR(255),
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    INDEX idx_audit_logs_user_created (user_id, created_at),
+    INDEX idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+-- Signed ingest requests already accepted, so a captured request can't be sent again
+-- while its timestamp is still fresh.
+CREATE TABLE ingest_deliveries (
+    key_id      VARCHAR(32) NOT NULL,
+    delivery_id VARCHAR(64) NOT NULL,
+    created_at  DATETIME(3),
+    PRIMARY KEY (key_id, delivery_id),
+    INDEX idx_ingest_deliveries_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE embed_tokens DROP COLUMN allowed_origins;
@@ -0,0 +1,16 @@
+-- Queued syncs, so a sync accepted before a restart still runs and failed syncs are
+-- retried with backoff.
+CREATE TABLE sync_jobs (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    updated_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    triggered_by      VARCHAR(16) NOT NULL,
+    status            VARCHAR(16) NOT NULL,
+    attempts          BIGINT NOT NULL DEFAULT 0,
+    next_run_at       DATETIME(3) NOT NULL,
+    started_at        DATETIME(3),
+    last_error        TEXT,
+    INDEX idx_sync_jobs_docker_account_id (docker_account_id),
+    INDEX idx_sync_jobs_due (status, next_run_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
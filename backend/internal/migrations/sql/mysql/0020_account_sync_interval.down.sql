@@ -0,0 +1 @@
+ALTER TABLE docker_accounts DROP COLUMN sync_interval_minutes;
@@ -0,0 +1,17 @@
+-- One row per sync with what it did, so owners can see why their counts changed.
+CREATE TABLE sync_runs (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    triggered_by      VARCHAR(16) NOT NULL,
+    started_at        DATETIME(3) NOT NULL,
+    finished_at       DATETIME(3) NOT NULL,
+    duration_ms       BIGINT NOT NULL,
+    repositories      BIGINT NOT NULL,
+    events_created    BIGINT NOT NULL,
+    api_calls         BIGINT NOT NULL,
+    status            VARCHAR(16) NOT NULL,
+    error             TEXT,
+    INDEX idx_sync_runs_docker_account_id (docker_account_id),
+    INDEX idx_sync_runs_started_at (started_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+-- Accounts whose syncs keep failing stop being synced automatically until they're requeued.
+ALTER TABLE docker_accounts ADD COLUMN sync_failures BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE docker_accounts ADD COLUMN sync_dead_lettered_at DATETIME(3);
@@ -0,0 +1,3 @@
+ALTER TABLE docker_accounts DROP COLUMN disabled_reason;
+ALTER TABLE docker_accounts DROP COLUMN disabled_at;
+ALTER TABLE users DROP COLUMN is_admin;
@@ -0,0 +1,4 @@
+-- Admins, besides the users listed in ADMIN_USER_IDS, and Docker accounts an admin disabled.
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE docker_accounts ADD COLUMN disabled_at DATETIME(3);
+ALTER TABLE docker_accounts ADD COLUMN disabled_reason VARCHAR(255);
@@ -0,0 +1,2 @@
+DROP INDEX idx_activity_events_created_at ON activity_events;
+DROP TABLE IF EXISTS daily_request_counts;
@@ -0,0 +1,9 @@
+-- Per-day API request counts by status class, flushed from memory by each instance, and an
+-- index for counting the events ingested each day.
+CREATE TABLE daily_request_counts (
+    day          DATE NOT NULL,
+    status_class VARCHAR(3) NOT NULL,
+    count        BIGINT NOT NULL,
+    PRIMARY KEY (day, status_class)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE INDEX idx_activity_events_created_at ON activity_events (created_at);
@@ -0,0 +1,12 @@
+-- Users an admin suspended, and Docker usernames that can't be connected.
+ALTER TABLE users ADD COLUMN suspended_at DATETIME(3);
+ALTER TABLE users ADD COLUMN suspended_reason VARCHAR(255);
+
+CREATE TABLE blocked_docker_usernames (
+    id                 BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at         DATETIME(3) NOT NULL,
+    docker_username    VARCHAR(255) NOT NULL,
+    reason             VARCHAR(255),
+    created_by_user_id BIGINT UNSIGNED,
+    UNIQUE INDEX idx_blocked_docker_usernames_username (docker_username)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,18 @@
+-- Archives of everything stored about a user, built in the background and downloaded
+-- through a link that expires.
+CREATE TABLE data_exports (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(3) NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    started_at   DATETIME(3),
+    completed_at DATETIME(3),
+    expires_at   DATETIME(3),
+    error        VARCHAR(255),
+    token        VARCHAR(64) NOT NULL,
+    size_bytes   BIGINT NOT NULL DEFAULT 0,
+    data         LONGBLOB,
+    INDEX idx_data_exports_user (user_id),
+    UNIQUE INDEX idx_data_exports_token (token),
+    INDEX idx_data_exports_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP INDEX idx_users_deletion_scheduled_at ON users;
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
@@ -0,0 +1,3 @@
+-- When a user's confirmed deletion of their account is carried out.
+ALTER TABLE users ADD COLUMN deletion_scheduled_at DATETIME(3);
+CREATE INDEX idx_users_deletion_scheduled_at ON users (deletion_scheduled_at);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN weekly_digest_sent_at;
+ALTER TABLE users DROP COLUMN weekly_digest;
@@ -0,0 +1,3 @@
+-- Users who opted in to the weekly digest, and when theirs was last sent.
+ALTER TABLE users ADD COLUMN weekly_digest BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN weekly_digest_sent_at DATETIME(3);
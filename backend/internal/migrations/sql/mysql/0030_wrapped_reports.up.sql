@@ -0,0 +1,9 @@
+-- Year-in-review highlights, frozen once the year is over so they outlive activity retention
+CREATE TABLE wrapped_reports (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at        DATETIME(3),
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    year              INT NOT NULL,
+    highlights        TEXT NOT NULL,
+    UNIQUE INDEX idx_wrapped_account_year (docker_account_id, year)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+-- Milestones an account has reached, kept once earned even after the activity behind
+-- them ages out of retention
+CREATE TABLE achievements (
+    id                BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    docker_account_id BIGINT UNSIGNED NOT NULL,
+    achievement       VARCHAR(32) NOT NULL,
+    earned_at         DATETIME(3) NOT NULL,
+    UNIQUE INDEX idx_achievement_account (docker_account_id, achievement)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,104 @@
+-- Schema as it stood when versioned migrations were introduced. Every statement is
+-- idempotent so databases created by GORM AutoMigrate can adopt it unchanged.
+
+CREATE TABLE IF NOT EXISTS users (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ,
+    deleted_at      TIMESTAMPTZ,
+    github_id       BIGINT NOT NULL,
+    github_username TEXT NOT NULL,
+    github_email    TEXT,
+    avatar_url      TEXT,
+    name            TEXT,
+    public_profile  BOOLEAN DEFAULT true,
+    bio             TEXT
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS week_start TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_attribution BOOLEAN DEFAULT false;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_github_id ON users (github_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS docker_accounts (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    deleted_at       TIMESTAMPTZ,
+    user_id          BIGINT NOT NULL,
+    docker_username  TEXT NOT NULL,
+    encrypted_token  TEXT NOT NULL,
+    token_iv         TEXT NOT NULL,
+    last_sync_at     TIMESTAMPTZ,
+    last_sync_error  TEXT,
+    sync_in_progress BOOLEAN DEFAULT false,
+    is_active        BOOLEAN DEFAULT true,
+    auto_refresh     BOOLEAN DEFAULT true,
+    CONSTRAINT fk_users_docker_accounts FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_docker_accounts_docker_username ON docker_accounts (docker_username);
+CREATE INDEX IF NOT EXISTS idx_docker_accounts_user_id ON docker_accounts (user_id);
+CREATE INDEX IF NOT EXISTS idx_docker_accounts_deleted_at ON docker_accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS activity_events (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    updated_at        TIMESTAMPTZ,
+    deleted_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    event_type        TEXT NOT NULL,
+    event_date        TIMESTAMPTZ NOT NULL,
+    count             BIGINT NOT NULL DEFAULT 1,
+    repository        TEXT,
+    tag               TEXT,
+    CONSTRAINT fk_docker_accounts_activity_events FOREIGN KEY (docker_account_id) REFERENCES docker_accounts (id)
+);
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS event_at TIMESTAMPTZ;
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS source VARCHAR(32);
+CREATE INDEX IF NOT EXISTS idx_activity_account_date ON activity_events (docker_account_id, event_date);
+CREATE INDEX IF NOT EXISTS idx_activity_events_event_type ON activity_events (event_type);
+CREATE INDEX IF NOT EXISTS idx_activity_events_deleted_at ON activity_events (deleted_at);
+
+CREATE TABLE IF NOT EXISTS heatmap_snapshots (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    until             TIMESTAMPTZ NOT NULL,
+    options_hash      VARCHAR(64) NOT NULL,
+    svg               BYTEA NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snapshot_account_until_options ON heatmap_snapshots (docker_account_id, until, options_hash);
+
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id                 BIGSERIAL PRIMARY KEY,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ,
+    deleted_at         TIMESTAMPTZ,
+    user_id            BIGINT NOT NULL,
+    type               VARCHAR(32) NOT NULL,
+    name               TEXT,
+    encrypted_settings TEXT NOT NULL,
+    settings_iv        TEXT NOT NULL,
+    events             TEXT,
+    enabled            BOOLEAN DEFAULT true,
+    last_delivered_at  TIMESTAMPTZ,
+    last_error         TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_notification_channels_user_id ON notification_channels (user_id);
+CREATE INDEX IF NOT EXISTS idx_notification_channels_deleted_at ON notification_channels (deleted_at);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ,
+    channel_id      BIGINT NOT NULL,
+    event           VARCHAR(64) NOT NULL,
+    payload         TEXT NOT NULL,
+    status          VARCHAR(16) NOT NULL,
+    attempts        BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT,
+    CONSTRAINT fk_notification_deliveries_channel FOREIGN KEY (channel_id) REFERENCES notification_channels (id)
+);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_channel_id ON notification_deliveries (channel_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_due ON notification_deliveries (status, next_attempt_at);
@@ -0,0 +1,17 @@
+-- Per-day, per-type totals maintained after every sync, so UTC summaries are a single
+-- indexed range scan instead of a scan over every event
+CREATE TABLE IF NOT EXISTS daily_activity_aggregates (
+    docker_account_id BIGINT NOT NULL,
+    event_date        TIMESTAMPTZ NOT NULL,
+    event_type        TEXT NOT NULL,
+    count             BIGINT NOT NULL,
+    updated_at        TIMESTAMPTZ,
+    PRIMARY KEY (docker_account_id, event_date, event_type)
+);
+
+INSERT INTO daily_activity_aggregates (docker_account_id, event_date, event_type, count, updated_at)
+SELECT docker_account_id, event_date, event_type, SUM(count), NOW()
+FROM activity_events
+WHERE deleted_at IS NULL
+GROUP BY docker_account_id, event_date, event_type
+ON CONFLICT DO NOTHING;
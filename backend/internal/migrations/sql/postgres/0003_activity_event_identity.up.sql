@@ -0,0 +1,14 @@
+-- One live row per account, day, repository, tag and event type, which sync upserts rely on.
+-- Duplicates left by racing syncs recorded the same push twice, so only the oldest is kept.
+WITH ranked AS (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY docker_account_id, event_date, repository, tag, event_type ORDER BY id) AS rn
+    FROM activity_events
+    WHERE deleted_at IS NULL
+)
+DELETE FROM activity_events e
+USING ranked r
+WHERE e.id = r.id AND r.rn > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activity_event_identity
+    ON activity_events (docker_account_id, event_date, repository, tag, event_type)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE docker_accounts DROP COLUMN IF EXISTS disconnect_scheduled_at;
@@ -0,0 +1,2 @@
+-- Disconnects are scheduled rather than immediate, so an accidental click can be undone
+ALTER TABLE docker_accounts ADD COLUMN IF NOT EXISTS disconnect_scheduled_at TIMESTAMPTZ;
@@ -0,0 +1,12 @@
+-- Additional namespaces an account syncs once the owner proves control of them
+CREATE TABLE IF NOT EXISTS namespace_claims (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    namespace         VARCHAR(255) NOT NULL,
+    token             VARCHAR(64) NOT NULL,
+    expires_at        TIMESTAMPTZ NOT NULL,
+    verified_at       TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_namespace_claim_account ON namespace_claims (docker_account_id, namespace);
+CREATE INDEX IF NOT EXISTS idx_namespace_claims_namespace ON namespace_claims (namespace);
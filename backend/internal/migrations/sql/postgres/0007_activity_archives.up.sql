@@ -0,0 +1,10 @@
+-- Compressed chunks of activity events moved out of activity_events after the retention window
+CREATE TABLE IF NOT EXISTS activity_archives (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    month             DATE NOT NULL,
+    event_count       BIGINT NOT NULL,
+    data              BYTEA NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_activity_archive_account_month ON activity_archives (docker_account_id, month);
@@ -0,0 +1,13 @@
+-- Docker Hub metadata (pulls, stars) for each synced repository
+CREATE TABLE IF NOT EXISTS docker_repositories (
+    id                BIGSERIAL PRIMARY KEY,
+    updated_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    name              VARCHAR(255) NOT NULL,
+    description       TEXT,
+    pull_count        BIGINT NOT NULL DEFAULT 0,
+    star_count        INTEGER NOT NULL DEFAULT 0,
+    is_private        BOOLEAN NOT NULL DEFAULT FALSE,
+    last_updated      TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_docker_repository_account_name ON docker_repositories (docker_account_id, name);
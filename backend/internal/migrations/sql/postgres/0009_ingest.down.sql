@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ingest_receipts;
+DROP TABLE IF EXISTS ingest_keys;
@@ -0,0 +1,23 @@
+-- Signing keys for the ingest API; secrets are encrypted, since verifying an HMAC needs them
+CREATE TABLE IF NOT EXISTS ingest_keys (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    user_id          BIGINT NOT NULL,
+    name             VARCHAR(100) NOT NULL,
+    key_id           VARCHAR(32) NOT NULL,
+    encrypted_secret TEXT NOT NULL,
+    secret_iv        TEXT NOT NULL,
+    last_used_at     TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingest_keys_key_id ON ingest_keys (key_id);
+CREATE INDEX IF NOT EXISTS idx_ingest_keys_user_id ON ingest_keys (user_id);
+
+-- Fingerprints of ingested events, so retried batches aren't counted twice
+CREATE TABLE IF NOT EXISTS ingest_receipts (
+    docker_account_id BIGINT NOT NULL,
+    fingerprint       VARCHAR(64) NOT NULL,
+    event_at          TIMESTAMPTZ NOT NULL,
+    created_at        TIMESTAMPTZ,
+    PRIMARY KEY (docker_account_id, fingerprint)
+);
+CREATE INDEX IF NOT EXISTS idx_ingest_receipts_event_at ON ingest_receipts (event_at);
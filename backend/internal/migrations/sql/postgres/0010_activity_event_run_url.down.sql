@@ -0,0 +1 @@
+ALTER TABLE activity_events DROP COLUMN IF EXISTS run_url;
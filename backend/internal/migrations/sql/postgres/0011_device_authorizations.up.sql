@@ -0,0 +1,16 @@
+-- Pending device logins; the device code is stored hashed, since it becomes a token once approved
+CREATE TABLE IF NOT EXISTS device_authorizations (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    device_code_hash VARCHAR(64) NOT NULL,
+    user_code        VARCHAR(9) NOT NULL,
+    client_name      VARCHAR(64),
+    expires_at       TIMESTAMPTZ NOT NULL,
+    interval_seconds INTEGER NOT NULL,
+    last_polled_at   TIMESTAMPTZ,
+    user_id          BIGINT,
+    denied           BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_authorizations_device_code_hash ON device_authorizations (device_code_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_authorizations_user_code ON device_authorizations (user_code);
+CREATE INDEX IF NOT EXISTS idx_device_authorizations_expires_at ON device_authorizations (expires_at);
@@ -0,0 +1,18 @@
+-- Logins, each with a rotating refresh token; only token hashes are stored
+CREATE TABLE IF NOT EXISTS sessions (
+    id                  BIGSERIAL PRIMARY KEY,
+    created_at          TIMESTAMPTZ,
+    user_id             BIGINT NOT NULL,
+    refresh_token_hash  VARCHAR(64) NOT NULL,
+    previous_token_hash VARCHAR(64),
+    name                VARCHAR(64),
+    user_agent          VARCHAR(255),
+    ip_address          VARCHAR(45),
+    last_used_at        TIMESTAMPTZ NOT NULL,
+    expires_at          TIMESTAMPTZ NOT NULL,
+    revoked_at          TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON sessions (refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions (previous_token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
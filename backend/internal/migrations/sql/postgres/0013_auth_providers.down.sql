@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_identities;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
+ALTER TABLE users DROP COLUMN IF EXISTS provider;
+-- Fails while accounts made with another provider exist, rather than guessing a GitHub id
+ALTER TABLE users ALTER COLUMN github_id SET NOT NULL;
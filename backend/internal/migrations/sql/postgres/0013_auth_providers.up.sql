@@ -0,0 +1,17 @@
+-- Sign-in with GitLab and Google as well as GitHub. Accounts made with another provider
+-- have no GitHub id, and logins from other providers are kept as identities.
+ALTER TABLE users ALTER COLUMN github_id DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS provider VARCHAR(20) NOT NULL DEFAULT 'github';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    user_id          BIGINT NOT NULL,
+    provider         VARCHAR(20) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    username         VARCHAR(255),
+    email            VARCHAR(255)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_user ON user_identities (provider, provider_user_id);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
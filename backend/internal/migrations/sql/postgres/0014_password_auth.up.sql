@@ -0,0 +1,16 @@
+-- Email and password sign-in for deployments without an OAuth app. Tokens mailed for
+-- verifying an address or resetting a password are stored hashed.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS email_tokens (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    user_id    BIGINT NOT NULL,
+    purpose    VARCHAR(20) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_tokens_token_hash ON email_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_email_tokens_user_id ON email_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_email_tokens_expires_at ON email_tokens (expires_at);
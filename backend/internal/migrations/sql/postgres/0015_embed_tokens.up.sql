@@ -0,0 +1,13 @@
+-- Named tokens that show a private profile's heatmap where a URL is pasted, until revoked.
+-- Tokens are stored hashed; only a short prefix is kept to tell them apart.
+CREATE TABLE IF NOT EXISTS embed_tokens (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ,
+    user_id      BIGINT NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    token_hash   VARCHAR(64) NOT NULL,
+    last_used_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_embed_tokens_token_hash ON embed_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_embed_tokens_user_id ON embed_tokens (user_id);
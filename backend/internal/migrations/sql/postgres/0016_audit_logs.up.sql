@@ -0,0 +1,13 @@
+-- Account-level actions, such as connecting Docker Hub or signing in, with where they
+-- came from, so users can review what happened to their account.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    user_id    BIGINT NOT NULL,
+    action     VARCHAR(50) NOT NULL,
+    detail     VARCHAR(255),
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created ON audit_logs (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
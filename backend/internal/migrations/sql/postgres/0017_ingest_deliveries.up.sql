@@ -0,0 +1,9 @@
+-- Signed ingest requests already accepted, so a captured request can't be sent again
+-- while its timestamp is still fresh.
+CREATE TABLE IF NOT EXISTS ingest_deliveries (
+    key_id      VARCHAR(32) NOT NULL,
+    delivery_id VARCHAR(64) NOT NULL,
+    created_at  TIMESTAMPTZ,
+    PRIMARY KEY (key_id, delivery_id)
+);
+CREATE INDEX IF NOT EXISTS idx_ingest_deliveries_created_at ON ingest_deliveries (created_at);
@@ -0,0 +1 @@
+ALTER TABLE embed_tokens DROP COLUMN IF EXISTS allowed_origins;
@@ -0,0 +1,2 @@
+-- Sites allowed to use an embed token, as a comma-separated list of origins; empty allows any
+ALTER TABLE embed_tokens ADD COLUMN IF NOT EXISTS allowed_origins VARCHAR(1000) NOT NULL DEFAULT '';
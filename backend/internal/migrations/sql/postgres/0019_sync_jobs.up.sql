@@ -0,0 +1,16 @@
+-- Queued syncs, so a sync accepted before a restart still runs and failed syncs are
+-- retried with backoff.
+CREATE TABLE IF NOT EXISTS sync_jobs (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    updated_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    triggered_by      VARCHAR(16) NOT NULL,
+    status            VARCHAR(16) NOT NULL,
+    attempts          BIGINT NOT NULL DEFAULT 0,
+    next_run_at       TIMESTAMPTZ NOT NULL,
+    started_at        TIMESTAMPTZ,
+    last_error        TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_sync_jobs_docker_account_id ON sync_jobs (docker_account_id);
+CREATE INDEX IF NOT EXISTS idx_sync_jobs_due ON sync_jobs (status, next_run_at);
@@ -0,0 +1 @@
+ALTER TABLE docker_accounts DROP COLUMN IF EXISTS sync_interval_minutes;
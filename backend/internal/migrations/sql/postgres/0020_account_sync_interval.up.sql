@@ -0,0 +1,2 @@
+-- Accounts can be synced on their own interval instead of the scheduled sync.
+ALTER TABLE docker_accounts ADD COLUMN IF NOT EXISTS sync_interval_minutes BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- One row per sync with what it did, so owners can see why their counts changed.
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    triggered_by      VARCHAR(16) NOT NULL,
+    started_at        TIMESTAMPTZ NOT NULL,
+    finished_at       TIMESTAMPTZ NOT NULL,
+    duration_ms       BIGINT NOT NULL,
+    repositories      BIGINT NOT NULL,
+    events_created    BIGINT NOT NULL,
+    api_calls         BIGINT NOT NULL,
+    status            VARCHAR(16) NOT NULL,
+    error             TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_sync_runs_docker_account_id ON sync_runs (docker_account_id);
+CREATE INDEX IF NOT EXISTS idx_sync_runs_started_at ON sync_runs (started_at);
@@ -0,0 +1,2 @@
+ALTER TABLE docker_accounts DROP COLUMN IF EXISTS sync_dead_lettered_at;
+ALTER TABLE docker_accounts DROP COLUMN IF EXISTS sync_failures;
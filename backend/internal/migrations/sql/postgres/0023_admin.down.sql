@@ -0,0 +1,3 @@
+ALTER TABLE docker_accounts DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE docker_accounts DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,4 @@
+-- Admins, besides the users listed in ADMIN_USER_IDS, and Docker accounts an admin disabled.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE docker_accounts ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE docker_accounts ADD COLUMN IF NOT EXISTS disabled_reason VARCHAR(255);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS blocked_docker_usernames;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
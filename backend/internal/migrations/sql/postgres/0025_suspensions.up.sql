@@ -0,0 +1,12 @@
+-- Users an admin suspended, and Docker usernames that can't be connected.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS blocked_docker_usernames (
+    id                 BIGSERIAL PRIMARY KEY,
+    created_at         TIMESTAMPTZ NOT NULL,
+    docker_username    VARCHAR(255) NOT NULL,
+    reason             VARCHAR(255),
+    created_by_user_id BIGINT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_blocked_docker_usernames_username ON blocked_docker_usernames (docker_username);
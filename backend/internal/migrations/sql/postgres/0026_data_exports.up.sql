@@ -0,0 +1,18 @@
+-- Archives of everything stored about a user, built in the background and downloaded
+-- through a link that expires.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL,
+    user_id      BIGINT NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    started_at   TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    error        VARCHAR(255),
+    token        VARCHAR(64) NOT NULL,
+    size_bytes   BIGINT NOT NULL DEFAULT 0,
+    data         BYTEA
+);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user ON data_exports (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_token ON data_exports (token);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports (status);
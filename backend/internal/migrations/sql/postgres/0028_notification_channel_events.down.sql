@@ -0,0 +1 @@
+UPDATE notification_channels SET events = '' WHERE events = 'sync_failed';
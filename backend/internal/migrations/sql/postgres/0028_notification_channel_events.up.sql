@@ -0,0 +1,3 @@
+-- Channels that subscribed to every event only ever got sync failures; keep it that way now
+-- that syncs and new activity are notified too.
+UPDATE notification_channels SET events = 'sync_failed' WHERE events IS NULL OR events = '';
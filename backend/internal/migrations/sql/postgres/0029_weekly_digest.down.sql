@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS weekly_digest_sent_at;
+ALTER TABLE users DROP COLUMN IF EXISTS weekly_digest;
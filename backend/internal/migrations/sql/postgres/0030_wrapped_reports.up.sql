@@ -0,0 +1,9 @@
+-- Year-in-review highlights, frozen once the year is over so they outlive activity retention
+CREATE TABLE IF NOT EXISTS wrapped_reports (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ,
+    docker_account_id BIGINT NOT NULL,
+    year              INTEGER NOT NULL,
+    highlights        TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wrapped_account_year ON wrapped_reports (docker_account_id, year);
@@ -0,0 +1,9 @@
+-- Milestones an account has reached, kept once earned even after the activity behind
+-- them ages out of retention
+CREATE TABLE IF NOT EXISTS achievements (
+    id                BIGSERIAL PRIMARY KEY,
+    docker_account_id BIGINT NOT NULL,
+    achievement       VARCHAR(32) NOT NULL,
+    earned_at         TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_achievement_account ON achievements (docker_account_id, achievement);
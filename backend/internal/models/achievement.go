@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Achievement is a milestone a Docker account has reached. It is kept once earned, even
+// after the activity that earned it ages out of retention.
+type Achievement struct {
+	ID uint `gorm:"primaryKey" json:"-"`
+
+	// Foreign Key
+	DockerAccountID uint `gorm:"column:docker_account_id;not null;uniqueIndex:idx_achievement_account" json:"-"`
+
+	Achievement string    `gorm:"column:achievement;size:32;not null;uniqueIndex:idx_achievement_account" json:"achievement"`
+	EarnedAt    time.Time `gorm:"column:earned_at;not null" json:"earned_at"`
+}
+
+// TableName specifies the table name
+func (Achievement) TableName() string {
+	return "achievements"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ActivityArchive is a compressed chunk of activity events moved out of activity_events
+// once they passed the retention window. Each chunk holds events from one account and
+// one UTC month; a month archived over several runs spans several chunks.
+type ActivityArchive struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	DockerAccountID uint      `gorm:"column:docker_account_id;not null;index:idx_activity_archive_account_month" json:"docker_account_id"`
+	// Month is the first day of the UTC month the events fall in
+	Month      time.Time `gorm:"column:month;type:date;not null;index:idx_activity_archive_account_month" json:"month"`
+	EventCount int       `gorm:"column:event_count;not null" json:"event_count"`
+	// Data is the events as gzip-compressed JSON, one event per line
+	Data []byte `gorm:"column:data;not null" json:"-"`
+}
+
+// TableName specifies the table name
+func (ActivityArchive) TableName() string {
+	return "activity_archives"
+}
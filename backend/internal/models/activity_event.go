@@ -14,9 +14,66 @@ const (
 	EventTypeBuild EventType = "build"
 )
 
+// EventSource records where an event's timestamp came from
+type EventSource string
+
+const (
+	EventSourceTagPush    EventSource = "tag_last_pushed"   // Docker Hub tag_last_pushed
+	EventSourceRepoUpdate EventSource = "repo_last_updated" // Docker Hub repository last_updated
+	EventSourceWebhook    EventSource = "webhook"
+	EventSourceImport     EventSource = "manual_import"
+)
+
+// Confidence ranks how reliably a source reflects when activity actually happened
+type Confidence int
+
+const (
+	ConfidenceLow Confidence = iota + 1
+	ConfidenceMedium
+	ConfidenceHigh
+)
+
+// Confidence returns the confidence level of the source.
+// Repository last_updated also moves on description edits, so it ranks lowest.
+func (s EventSource) Confidence() Confidence {
+	switch s {
+	case EventSourceTagPush, EventSourceWebhook:
+		return ConfidenceHigh
+	case EventSourceImport:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// String returns the confidence name used in the API
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceHigh:
+		return "high"
+	case ConfidenceMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// ParseConfidence converts "low", "medium" or "high" into a confidence level
+func ParseConfidence(value string) (Confidence, bool) {
+	switch value {
+	case "low":
+		return ConfidenceLow, true
+	case "medium":
+		return ConfidenceMedium, true
+	case "high":
+		return ConfidenceHigh, true
+	}
+	return 0, false
+}
+
 type ActivityEvent struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `gorm:"index" json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
@@ -27,11 +84,18 @@ type ActivityEvent struct {
 	// Event Data
 	EventType EventType `gorm:"column:event_type;not null;index" json:"event_type"`
 	EventDate time.Time `gorm:"column:event_date;not null;index:idx_activity_account_date" json:"event_date"`
-	Count     int       `gorm:"column:count;not null;default:1" json:"count"`
+	// EventAt keeps the original timestamp so days can be bucketed in the viewer's timezone
+	EventAt *time.Time `gorm:"column:event_at" json:"event_at,omitempty"`
+	Count   int        `gorm:"column:count;not null;default:1" json:"count"`
+	// Source is empty for events recorded before sources were tracked; see ResolvedSource
+	Source EventSource `gorm:"column:source;size:32" json:"source"`
 
 	// Repository Info
 	Repository string `gorm:"column:repository" json:"repository,omitempty"`
 	Tag        string `gorm:"column:tag" json:"tag,omitempty"`
+
+	// RunURL links the CI run behind a reported build; the latest run when a day has several
+	RunURL string `gorm:"column:run_url;size:512" json:"run_url,omitempty"`
 }
 
 // TableName specifies the table name
@@ -53,6 +117,27 @@ func (a *ActivityEvent) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// LocalDate returns the event's calendar day in the given location.
+// Events recorded before timestamps were kept fall back to their UTC day.
+func (a *ActivityEvent) LocalDate(loc *time.Location) string {
+	if a.EventAt == nil || loc == nil {
+		return a.EventDate.Format("2006-01-02")
+	}
+	return a.EventAt.In(loc).Format("2006-01-02")
+}
+
+// ResolvedSource returns the event's source, inferring it for events recorded before
+// sources were tracked (sync only ever produced tag and repository timestamps)
+func (a *ActivityEvent) ResolvedSource() EventSource {
+	if a.Source != "" {
+		return a.Source
+	}
+	if a.Tag != "" {
+		return EventSourceTagPush
+	}
+	return EventSourceRepoUpdate
+}
+
 // ActivitySummary represents aggregated activity for a specific date
 type ActivitySummary struct {
 	Date       string `json:"date"`
@@ -61,4 +146,6 @@ type ActivitySummary struct {
 	Pulls      int    `json:"pulls"`
 	Builds     int    `json:"builds"`
 	Level      int    `json:"level"`
+	// Repositories maps repository name to count; only filled when a breakdown is requested
+	Repositories map[string]int `json:"repositories,omitempty"`
 }
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Audit log actions
+const (
+	AuditDockerConnect        = "docker.connect"
+	AuditDockerDisconnect     = "docker.disconnect"
+	AuditDockerRestore        = "docker.restore"
+	AuditDockerSync           = "docker.sync"
+	AuditDockerUpdate         = "docker.update"
+	AuditDockerTokenInvalid   = "docker.token_invalid"
+	AuditProfileUpdate        = "profile.update"
+	AuditLogin                = "auth.login"
+	AuditPasswordReset        = "auth.password_reset"
+	AuditDeviceApprove        = "auth.device_approve"
+	AuditSessionRevoke        = "session.revoke"
+	AuditEmbedTokenCreate     = "embed_token.create"
+	AuditEmbedTokenUpdate     = "embed_token.update"
+	AuditEmbedTokenRevoke     = "embed_token.revoke"
+	AuditIngestKeyCreate      = "ingest_key.create"
+	AuditIngestKeyRevoke      = "ingest_key.revoke"
+	AuditWebhookCreate        = "webhook.create"
+	AuditWebhookDelete        = "webhook.delete"
+	AuditDataExportRequest    = "data_export.request"
+	AuditDataExportDownload   = "data_export.download"
+	AuditActivityImport       = "activity.import"
+	AuditAccountDeleteRequest = "account.delete_request"
+	AuditAccountDeleteConfirm = "account.delete_confirm"
+	AuditAccountDeleteCancel  = "account.delete_cancel"
+	// Taken by an admin on the user's behalf
+	AuditAdminSync      = "admin.sync"
+	AuditAdminDisable   = "admin.disable"
+	AuditAdminEnable    = "admin.enable"
+	AuditAdminRole      = "admin.role"
+	AuditAdminSuspend   = "admin.suspend"
+	AuditAdminUnsuspend = "admin.unsuspend"
+	AuditAdminReadOnly  = "admin.read_only"
+)
+
+// SecurityAuditActions are the actions shown as recent security events on the profile:
+// sign-ins, credential changes, rejected tokens, downloads of the user's data and steps
+// towards deleting the account
+var SecurityAuditActions = []string{
+	AuditDockerConnect,
+	AuditDockerDisconnect,
+	AuditDockerTokenInvalid,
+	AuditLogin,
+	AuditPasswordReset,
+	AuditDeviceApprove,
+	AuditSessionRevoke,
+	AuditEmbedTokenCreate,
+	AuditIngestKeyCreate,
+	AuditWebhookCreate,
+	AuditDataExportDownload,
+	AuditAccountDeleteRequest,
+	AuditAccountDeleteConfirm,
+	AuditAccountDeleteCancel,
+}
+
+// AuditLog records an account-level action and where it came from. Actions taken by the
+// sync worker rather than a request have no IP address or user agent.
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index;index:idx_audit_logs_user_created,priority:2" json:"created_at"`
+
+	UserID    uint   `gorm:"column:user_id;not null;index:idx_audit_logs_user_created,priority:1" json:"-"`
+	Action    string `gorm:"column:action;size:50;not null" json:"action"`
+	Detail    string `gorm:"column:detail;size:255" json:"detail,omitempty"`
+	IPAddress string `gorm:"column:ip_address;size:45" json:"ip_address,omitempty"`
+	UserAgent string `gorm:"column:user_agent;size:255" json:"user_agent,omitempty"`
+}
+
+// TableName specifies the table name
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
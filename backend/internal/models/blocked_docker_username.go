@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// BlockedDockerUsername is a Docker Hub username an admin blocked from being connected,
+// usually after an abuse or impersonation report. Usernames are stored lowercased.
+type BlockedDockerUsername struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+
+	DockerUsername  string `gorm:"column:docker_username;size:255;not null;uniqueIndex:idx_blocked_docker_usernames_username" json:"docker_username"`
+	Reason          string `gorm:"column:reason;size:255" json:"reason,omitempty"`
+	CreatedByUserID *uint  `gorm:"column:created_by_user_id" json:"created_by_user_id,omitempty"`
+}
+
+// TableName specifies the table name
+func (BlockedDockerUsername) TableName() string {
+	return "blocked_docker_usernames"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DailyActivityAggregate is the total count of one event type on one UTC day for an account.
+// It is rebuilt from activity_events after every sync, so summaries that don't need
+// per-event detail can be read with a single indexed range scan.
+type DailyActivityAggregate struct {
+	DockerAccountID uint      `gorm:"column:docker_account_id;primaryKey" json:"docker_account_id"`
+	EventDate       time.Time `gorm:"column:event_date;primaryKey" json:"event_date"`
+	EventType       EventType `gorm:"column:event_type;primaryKey" json:"event_type"`
+	Count           int       `gorm:"column:count;not null" json:"count"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DailyActivityAggregate) TableName() string {
+	return "daily_activity_aggregates"
+}
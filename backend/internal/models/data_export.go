@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Data export statuses
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is a zip of everything stored about a user, built in the background at their
+// request. It's downloaded through a link carrying Token until ExpiresAt, when the archive
+// is deleted. The token is kept as is, not hashed: it only guards the archive stored
+// beside it, and the link has to be shown again each time the export is looked at.
+type DataExport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID      uint       `gorm:"column:user_id;not null;index" json:"-"`
+	Status      string     `gorm:"column:status;size:16;not null;index" json:"status"`
+	StartedAt   *time.Time `gorm:"column:started_at" json:"started_at,omitempty"`
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at" json:"expires_at,omitempty"`
+	Error       string     `gorm:"column:error;size:255" json:"error,omitempty"`
+	Token       string     `gorm:"column:token;size:64;not null;uniqueIndex" json:"-"`
+	SizeBytes   int64      `gorm:"column:size_bytes;not null;default:0" json:"size_bytes"`
+	// Data is the zip, once the export is ready
+	Data []byte `gorm:"column:data" json:"-"`
+}
+
+// TableName specifies the table name
+func (DataExport) TableName() string {
+	return "data_exports"
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// DeviceAuthorization is a pending login from a device without a browser, such as the
+// CLI. The device shows the user code, the user enters it on the website while signed
+// in, and the device polls with its device code until the login is approved or denied.
+// Only a hash of the device code is stored, since it becomes a token once approved.
+type DeviceAuthorization struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+
+	DeviceCodeHash string `gorm:"column:device_code_hash;size:64;not null;uniqueIndex" json:"-"`
+	UserCode       string `gorm:"column:user_code;size:9;not null;uniqueIndex" json:"user_code"`
+	ClientName     string `gorm:"column:client_name;size:64" json:"client_name,omitempty"`
+
+	ExpiresAt    time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	Interval     int        `gorm:"column:interval_seconds;not null" json:"-"` // Minimum seconds between polls
+	LastPolledAt *time.Time `gorm:"column:last_polled_at" json:"-"`
+
+	// UserID is set once a signed-in user approves the login
+	UserID *uint `gorm:"column:user_id" json:"-"`
+	Denied bool  `gorm:"column:denied;not null;default:false" json:"-"`
+}
+
+// TableName specifies the table name
+func (DeviceAuthorization) TableName() string {
+	return "device_authorizations"
+}
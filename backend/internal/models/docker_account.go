@@ -28,10 +28,26 @@ type DockerAccount struct {
 	LastSyncError  string     `gorm:"column:last_sync_error" json:"last_sync_error,omitempty"`
 	SyncInProgress bool       `gorm:"column:sync_in_progress;default:false" json:"sync_in_progress"`
 
+	// Syncs that have failed in a row, and when the account stopped being synced
+	// automatically because of them (see config.SyncDeadLetterAfter)
+	SyncFailures       int        `gorm:"column:sync_failures;not null;default:0" json:"-"`
+	SyncDeadLetteredAt *time.Time `gorm:"column:sync_dead_lettered_at" json:"-"`
+
+	// Set when an admin disabled the account, which stops it being synced until re-enabled
+	DisabledAt     *time.Time `gorm:"column:disabled_at" json:"disabled_at,omitempty"`
+	DisabledReason string     `gorm:"column:disabled_reason;size:255" json:"disabled_reason,omitempty"`
+
 	// Settings
 	IsActive    bool `gorm:"column:is_active;default:true" json:"is_active"`
 	AutoRefresh bool `gorm:"column:auto_refresh;default:true" json:"auto_refresh"`
 
+	// How often this account is synced, in minutes; 0 follows the scheduled sync
+	SyncIntervalMinutes int `gorm:"column:sync_interval_minutes;not null;default:0" json:"sync_interval_minutes"`
+
+	// Set while a disconnect is pending; the account and its activity are purged at this time
+	// unless the owner undoes it first
+	DisconnectScheduledAt *time.Time `gorm:"column:disconnect_scheduled_at" json:"disconnect_scheduled_at,omitempty"`
+
 	// Relationships
 	ActivityEvents []ActivityEvent `gorm:"foreignKey:DockerAccountID" json:"activity_events,omitempty"`
 }
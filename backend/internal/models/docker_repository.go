@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// DockerRepository is Docker Hub's metadata for one of an account's repositories, as of
+// the last sync. Repositories from extra namespaces are named namespace/repo, matching
+// their activity events.
+type DockerRepository struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UpdatedAt time.Time `json:"-"`
+
+	DockerAccountID uint `gorm:"column:docker_account_id;not null;uniqueIndex:idx_docker_repository_account_name" json:"-"`
+
+	Name        string     `gorm:"column:name;size:255;not null;uniqueIndex:idx_docker_repository_account_name" json:"name"`
+	Description string     `gorm:"column:description" json:"description,omitempty"`
+	PullCount   int64      `gorm:"column:pull_count;not null;default:0" json:"pull_count"`
+	StarCount   int        `gorm:"column:star_count;not null;default:0" json:"star_count"`
+	IsPrivate   bool       `gorm:"column:is_private;not null;default:false" json:"is_private"`
+	LastUpdated *time.Time `gorm:"column:last_updated" json:"last_updated,omitempty"`
+}
+
+// TableName specifies the table name
+func (DockerRepository) TableName() string {
+	return "docker_repositories"
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Purposes of an EmailToken
+const (
+	EmailTokenVerify = "verify" // Confirms the user owns their email address
+	EmailTokenReset  = "reset"  // Sets a new password
+	EmailTokenDelete = "delete" // Confirms deleting the account
+)
+
+// EmailToken is a single-use link mailed to a user, for verifying their address,
+// resetting their password or confirming their account's deletion. Only the token's hash
+// is stored.
+type EmailToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID    uint       `gorm:"column:user_id;not null;index" json:"-"`
+	Purpose   string     `gorm:"column:purpose;size:20;not null" json:"purpose"`
+	TokenHash string     `gorm:"column:token_hash;size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty"`
+}
+
+// TableName specifies the table name
+func (EmailToken) TableName() string {
+	return "email_tokens"
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// EmbedToken lets heatmap URLs carrying it (?token=) show a private profile until it is
+// revoked, as an alternative to expiring signed URLs. Only the token's hash is stored.
+type EmbedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID uint   `gorm:"column:user_id;not null;index" json:"-"`
+	Name   string `gorm:"column:name;size:100;not null" json:"name"`
+	// Prefix is the token's first characters, to tell tokens apart in a list
+	Prefix    string `gorm:"column:prefix;size:16;not null" json:"prefix"`
+	TokenHash string `gorm:"column:token_hash;size:64;not null;uniqueIndex" json:"-"`
+
+	// AllowedOrigins is a comma-separated list of the sites, such as https://wiki.example.com,
+	// that may use the token (empty means any)
+	AllowedOrigins string `gorm:"column:allowed_origins;size:1000;not null;default:''" json:"allowed_origins"`
+
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+}
+
+// TableName specifies the table name
+func (EmbedToken) TableName() string {
+	return "embed_tokens"
+}
+
+// AllowsOrigin reports whether a page on origin may use the token
+func (t *EmbedToken) AllowsOrigin(origin string) bool {
+	if t.AllowedOrigins == "" {
+		return true
+	}
+	for _, allowed := range strings.Split(t.AllowedOrigins, ",") {
+		if allowed == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HeatmapSnapshot is an immutable SVG rendering frozen at a specific date.
+// Snapshots are never regenerated so that published documents keep showing the same image.
+type HeatmapSnapshot struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Foreign Key
+	DockerAccountID uint `gorm:"column:docker_account_id;not null;uniqueIndex:idx_snapshot_account_until_options" json:"docker_account_id"`
+
+	// Snapshot identity
+	Until       time.Time `gorm:"column:until;not null;uniqueIndex:idx_snapshot_account_until_options" json:"until"`
+	OptionsHash string    `gorm:"column:options_hash;not null;size:64;uniqueIndex:idx_snapshot_account_until_options" json:"options_hash"`
+
+	// Rendered content
+	SVG []byte `gorm:"column:svg;not null" json:"-"`
+}
+
+// TableName specifies the table name
+func (HeatmapSnapshot) TableName() string {
+	return "heatmap_snapshots"
+}
+
+func (h *HeatmapSnapshot) BeforeCreate(tx *gorm.DB) error {
+	h.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// IngestKey lets a CI pipeline, private registry or script record activity the Docker Hub
+// API can't see. Requests are signed with HMAC-SHA256 over the key's secret, so the secret
+// is stored encrypted (AES-256) rather than hashed and is only shown when the key is created.
+type IngestKey struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID uint   `gorm:"column:user_id;not null;index" json:"-"`
+	Name   string `gorm:"column:name;size:100;not null" json:"name"`
+	// KeyID is the public half, sent with every request to say which secret signed it
+	KeyID string `gorm:"column:key_id;size:32;not null;uniqueIndex" json:"key_id"`
+
+	EncryptedSecret string `gorm:"column:encrypted_secret;not null" json:"-"`
+	SecretIV        string `gorm:"column:secret_iv;not null" json:"-"`
+
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+}
+
+// TableName specifies the table name
+func (IngestKey) TableName() string {
+	return "ingest_keys"
+}
+
+// IngestReceipt remembers an ingested event, so a retried batch isn't counted twice. The
+// fingerprint hashes the event's repository, tag, type and exact timestamp.
+type IngestReceipt struct {
+	DockerAccountID uint      `gorm:"column:docker_account_id;primaryKey" json:"-"`
+	Fingerprint     string    `gorm:"column:fingerprint;size:64;primaryKey" json:"-"`
+	EventAt         time.Time `gorm:"column:event_at;not null;index" json:"-"`
+	CreatedAt       time.Time `json:"-"`
+}
+
+// TableName specifies the table name
+func (IngestReceipt) TableName() string {
+	return "ingest_receipts"
+}
+
+// IngestDelivery remembers a signed ingest request that was accepted, so the same request
+// sent again is rejected as a replay. The delivery id is the request's signature, which
+// differs for every timestamp and body.
+type IngestDelivery struct {
+	KeyID      string    `gorm:"column:key_id;size:32;primaryKey" json:"-"`
+	DeliveryID string    `gorm:"column:delivery_id;size:64;primaryKey" json:"-"`
+	CreatedAt  time.Time `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (IngestDelivery) TableName() string {
+	return "ingest_deliveries"
+}
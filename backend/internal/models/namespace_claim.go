@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// NamespaceClaim is a request to include another Docker Hub namespace (usually an
+// organization) in an account's heatmap. It stays pending until the owner proves control
+// of the namespace by publishing the claim's token, and only verified claims are synced.
+type NamespaceClaim struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+
+	DockerAccountID uint `gorm:"column:docker_account_id;not null;uniqueIndex:idx_namespace_claim_account" json:"-"`
+
+	Namespace  string     `gorm:"column:namespace;size:255;not null;uniqueIndex:idx_namespace_claim_account" json:"namespace"`
+	Token      string     `gorm:"column:token;size:64;not null" json:"token,omitempty"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	VerifiedAt *time.Time `gorm:"column:verified_at" json:"verified_at,omitempty"`
+}
+
+// TableName specifies the table name
+func (NamespaceClaim) TableName() string {
+	return "namespace_claims"
+}
+
+// Verified reports whether control of the namespace has been proven
+func (n *NamespaceClaim) Verified() bool {
+	return n.VerifiedAt != nil
+}
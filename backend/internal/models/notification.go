@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationChannel is a user's configured delivery target (Slack webhook, ntfy topic...)
+type NotificationChannel struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	UserID uint   `gorm:"column:user_id;not null;index" json:"-"`
+	Type   string `gorm:"column:type;size:32;not null" json:"type"`
+	Name   string `gorm:"column:name" json:"name"`
+
+	// Channel settings hold webhook URLs and tokens, so they are stored encrypted (AES-256)
+	EncryptedSettings string `gorm:"column:encrypted_settings;not null" json:"-"`
+	SettingsIV        string `gorm:"column:settings_iv;not null" json:"-"`
+
+	// Events is a comma-separated list of subscribed events (empty means all)
+	Events  string `gorm:"column:events" json:"events"`
+	Enabled bool   `gorm:"column:enabled;default:true" json:"enabled"`
+
+	LastDeliveredAt *time.Time `gorm:"column:last_delivered_at" json:"last_delivered_at,omitempty"`
+	LastError       string     `gorm:"column:last_error" json:"last_error,omitempty"`
+}
+
+// TableName specifies the table name
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+func (n *NotificationChannel) BeforeCreate(tx *gorm.DB) error {
+	n.CreatedAt = time.Now()
+	n.UpdatedAt = time.Now()
+	return nil
+}
+
+// Subscribes reports whether the channel wants notifications for an event
+func (n *NotificationChannel) Subscribes(event string) bool {
+	if n.Events == "" {
+		return true
+	}
+	for _, e := range strings.Split(n.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery statuses
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// NotificationDelivery is one queued message for one channel, retried with backoff
+type NotificationDelivery struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ChannelID uint                `gorm:"column:channel_id;not null;index" json:"channel_id"`
+	Channel   NotificationChannel `gorm:"foreignKey:ChannelID" json:"-"`
+
+	Event         string    `gorm:"column:event;size:64;not null" json:"event"`
+	Payload       string    `gorm:"column:payload;type:text;not null" json:"-"` // JSON-encoded notify.Message
+	Status        string    `gorm:"column:status;size:16;not null;index:idx_delivery_due" json:"status"`
+	Attempts      int       `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at;not null;index:idx_delivery_due" json:"next_attempt_at"`
+	LastError     string    `gorm:"column:last_error" json:"last_error,omitempty"`
+}
+
+// TableName specifies the table name
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+func (d *NotificationDelivery) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = time.Now()
+	return nil
+}
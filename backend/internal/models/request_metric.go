@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// DailyRequestCount is how many API requests were answered on one UTC day with one class
+// of status code: "2xx", "3xx", "4xx" or "5xx"
+type DailyRequestCount struct {
+	Day         time.Time `gorm:"column:day;primaryKey" json:"day"`
+	StatusClass string    `gorm:"column:status_class;size:3;primaryKey" json:"status_class"`
+	Count       int64     `gorm:"column:count;not null" json:"count"`
+}
+
+// TableName specifies the table name
+func (DailyRequestCount) TableName() string {
+	return "daily_request_counts"
+}
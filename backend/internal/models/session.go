@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Session is a login on one browser or device. It holds the refresh token that keeps
+// the login going as short-lived access tokens expire. Refresh tokens rotate on every
+// use and only their hashes are stored; the previous one is kept so a stolen token
+// being used after its owner refreshed can be caught.
+type Session struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID uint `gorm:"column:user_id;not null;index" json:"-"`
+
+	RefreshTokenHash  string `gorm:"column:refresh_token_hash;size:64;not null;uniqueIndex" json:"-"`
+	PreviousTokenHash string `gorm:"column:previous_token_hash;size:64;index" json:"-"`
+
+	// Name is what the device called itself when logging in with the device flow
+	Name       string     `gorm:"column:name;size:64" json:"name,omitempty"`
+	UserAgent  string     `gorm:"column:user_agent;size:255" json:"user_agent,omitempty"`
+	IPAddress  string     `gorm:"column:ip_address;size:45" json:"ip_address,omitempty"`
+	LastUsedAt time.Time  `gorm:"column:last_used_at;not null" json:"last_used_at"` // Last refresh
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"-"`
+
+	// Current marks the session making the request when sessions are listed
+	Current bool `gorm:"-" json:"current"`
+}
+
+// TableName specifies the table name
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// Active reports whether the session can still be refreshed
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Sync job statuses
+const (
+	SyncJobPending   = "pending"
+	SyncJobRunning   = "running"
+	SyncJobSucceeded = "succeeded"
+	SyncJobFailed    = "failed"
+	// The job failed and its account has failed too many syncs in a row to keep trying
+	SyncJobDeadLettered = "dead_lettered"
+)
+
+// What queued a sync job
+const (
+	SyncTriggerConnect   = "connect"
+	SyncTriggerManual    = "manual"
+	SyncTriggerScheduled = "scheduled"
+	SyncTriggerNamespace = "namespace"
+	SyncTriggerStale     = "stale"   // a public request found the account out of date
+	SyncTriggerRequeue   = "requeue" // an admin requeued a dead-lettered sync
+	SyncTriggerAdmin     = "admin"   // an admin forced a sync
+)
+
+// SyncJob is one queued sync of a Docker account, retried with backoff. Jobs live in the
+// database, so a sync queued before a restart still runs after it.
+type SyncJob struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	DockerAccountID uint       `gorm:"column:docker_account_id;not null;index" json:"docker_account_id"`
+	TriggeredBy     string     `gorm:"column:triggered_by;size:16;not null" json:"triggered_by"`
+	Status          string     `gorm:"column:status;size:16;not null;index:idx_sync_jobs_due" json:"status"`
+	Attempts        int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextRunAt       time.Time  `gorm:"column:next_run_at;not null;index:idx_sync_jobs_due" json:"next_run_at"`
+	StartedAt       *time.Time `gorm:"column:started_at" json:"started_at,omitempty"`
+	LastError       string     `gorm:"column:last_error" json:"last_error,omitempty"`
+}
+
+// TableName specifies the table name
+func (SyncJob) TableName() string {
+	return "sync_jobs"
+}
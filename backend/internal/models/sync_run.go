@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Sync run statuses
+const (
+	SyncRunSucceeded = "succeeded"
+	SyncRunFailed    = "failed"
+)
+
+// SyncRun records one sync of a Docker account and what it did, so owners can see why
+// their counts changed between runs
+type SyncRun struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"-"`
+
+	DockerAccountID uint      `gorm:"column:docker_account_id;not null;index" json:"-"`
+	TriggeredBy     string    `gorm:"column:triggered_by;size:16;not null" json:"triggered_by"`
+	StartedAt       time.Time `gorm:"column:started_at;not null;index" json:"started_at"`
+	FinishedAt      time.Time `gorm:"column:finished_at;not null" json:"finished_at"`
+	DurationMS      int64     `gorm:"column:duration_ms;not null" json:"duration_ms"`
+	Repositories    int       `gorm:"column:repositories;not null" json:"repositories"`     // repositories scanned
+	EventsCreated   int       `gorm:"column:events_created;not null" json:"events_created"` // new activity rows
+	APICalls        int       `gorm:"column:api_calls;not null" json:"api_calls"`           // Docker Hub requests made
+	Status          string    `gorm:"column:status;size:16;not null" json:"status"`
+	Error           string    `gorm:"column:error" json:"error,omitempty"`
+}
+
+// TableName specifies the table name
+func (SyncRun) TableName() string {
+	return "sync_runs"
+}
@@ -12,16 +12,47 @@ type User struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	// GitHub OAuth Data
-	GitHubID       int64  `gorm:"column:github_id;uniqueIndex;not null" json:"github_id"`
+	// Provider is the sign-in provider the account was created with: "github", "gitlab",
+	// "google", or "password" for email and password sign-in. Logins from other
+	// providers linked later are UserIdentity records.
+	Provider string `gorm:"column:provider;size:20;not null;default:github" json:"provider"`
+
+	// OAuth Data. GitHubID is set once the account has signed in with GitHub. The
+	// username and email columns predate other providers: they hold the handle and email
+	// from the provider the account was created with.
+	GitHubID       *int64 `gorm:"column:github_id;uniqueIndex" json:"github_id,omitempty"`
 	GitHubUsername string `gorm:"column:github_username;not null" json:"github_username"`
 	GitHubEmail    string `gorm:"column:github_email" json:"email,omitempty"`
-	AvatarURL      string `gorm:"column:avatar_url" json:"avatar_url,omitempty"`
-	Name           string `gorm:"column:name" json:"name,omitempty"`
+	// EmailVerified is whether the provider vouched for the email, or the user followed
+	// a link mailed to it; only then can a login from another provider with the same
+	// email be linked to this account
+	EmailVerified bool `gorm:"column:email_verified;not null;default:false" json:"-"`
+	// PasswordHash is the bcrypt hash for email and password sign-in; nil for accounts
+	// that only sign in with a provider
+	PasswordHash *string `gorm:"column:password_hash;size:255" json:"-"`
+	AvatarURL    string  `gorm:"column:avatar_url" json:"avatar_url,omitempty"`
+	Name         string  `gorm:"column:name" json:"name,omitempty"`
 
 	// Profile Settings
 	PublicProfile bool   `gorm:"column:public_profile;default:true" json:"public_profile"`
-	Bio           string `gorm:"column:bio" json:"bio,omitempty"`
+	Bio           string `gorm:"column:bio" json:"bio,omitempty"`               // Markdown; see services.RenderMarkdown
+	Timezone      string `gorm:"column:timezone" json:"timezone,omitempty"`     // IANA name used for day bucketing
+	WeekStart     string `gorm:"column:week_start" json:"week_start,omitempty"` // "sunday" or "monday"
+	// HideAttribution opts out of the credit line when the deployment makes it optional
+	HideAttribution bool `gorm:"column:hide_attribution;default:false" json:"hide_attribution"`
+	// WeeklyDigest opts in to a weekly summary sent to the channels subscribed to it
+	WeeklyDigest       bool       `gorm:"column:weekly_digest;not null;default:false" json:"weekly_digest"`
+	WeeklyDigestSentAt *time.Time `gorm:"column:weekly_digest_sent_at" json:"-"`
+
+	// IsAdmin grants the admin API, as does listing the user in ADMIN_USER_IDS
+	IsAdmin bool `gorm:"column:is_admin;not null;default:false" json:"-"`
+	// SuspendedAt is when an admin suspended the user: they can't sign in, their heatmaps
+	// aren't served and their accounts aren't synced until the suspension is lifted
+	SuspendedAt     *time.Time `gorm:"column:suspended_at" json:"-"`
+	SuspendedReason string     `gorm:"column:suspended_reason;size:255" json:"-"`
+	// DeletionScheduledAt is when the account and everything stored about it is deleted,
+	// once the user confirmed its deletion; until then they can sign in and cancel it
+	DeletionScheduledAt *time.Time `gorm:"column:deletion_scheduled_at;index" json:"deletion_scheduled_at,omitempty"`
 
 	// Relationships
 	DockerAccounts []DockerAccount `gorm:"foreignKey:UserID" json:"docker_accounts,omitempty"`
@@ -32,6 +63,11 @@ func (User) TableName() string {
 	return "users"
 }
 
+// Suspended reports whether an admin suspended the user
+func (u *User) Suspended() bool {
+	return u.SuspendedAt != nil
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// UserIdentity links a login from a sign-in provider other than GitHub, such as GitLab
+// or Google, to a user. GitHub logins are matched by User.GitHubID instead.
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID         uint   `gorm:"column:user_id;not null;index" json:"-"`
+	Provider       string `gorm:"column:provider;size:20;not null;uniqueIndex:idx_user_identities_provider_user" json:"provider"`
+	ProviderUserID string `gorm:"column:provider_user_id;size:255;not null;uniqueIndex:idx_user_identities_provider_user" json:"-"`
+	Username       string `gorm:"column:username;size:255" json:"username,omitempty"`
+	Email          string `gorm:"column:email;size:255" json:"email,omitempty"`
+}
+
+// TableName specifies the table name
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// View kinds counted per account
+const (
+	ViewKindRender  = "render"  // an embeddable image was served
+	ViewKindProfile = "profile" // the public profile page was loaded
+)
+
+// DailyViewCount is how many times an account's public endpoints of one kind were served
+// on one UTC day. Rows are only ever added or incremented, so they form the account's
+// view history.
+type DailyViewCount struct {
+	DockerAccountID uint      `gorm:"column:docker_account_id;primaryKey" json:"-"`
+	Day             time.Time `gorm:"column:day;primaryKey" json:"day"`
+	Kind            string    `gorm:"column:kind;size:16;primaryKey" json:"kind"`
+	Count           int64     `gorm:"column:count;not null" json:"count"`
+}
+
+// TableName specifies the table name
+func (DailyViewCount) TableName() string {
+	return "daily_view_counts"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WrappedReport is an account's year-in-review, stored once the year is over so it keeps
+// showing the same highlights after the year's activity ages out of retention
+type WrappedReport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Foreign Key
+	DockerAccountID uint `gorm:"column:docker_account_id;not null;uniqueIndex:idx_wrapped_account_year" json:"docker_account_id"`
+
+	Year int `gorm:"column:year;not null;uniqueIndex:idx_wrapped_account_year" json:"year"`
+
+	// Highlights is the year's summary as JSON
+	Highlights string `gorm:"column:highlights;type:text;not null" json:"-"`
+}
+
+// TableName specifies the table name
+func (WrappedReport) TableName() string {
+	return "wrapped_reports"
+}
+
+func (w *WrappedReport) BeforeCreate(tx *gorm.DB) error {
+	w.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"docker-heatmap/internal/config"
+)
+
+func init() {
+	Register(emailChannel{})
+}
+
+// emailChannel sends plain-text mail through the deployment's SMTP server
+type emailChannel struct{}
+
+func (emailChannel) Type() string { return "email" }
+
+func (emailChannel) Validate(settings map[string]string) error {
+	if config.AppConfig.SMTPHost == "" {
+		return errors.New("email notifications are not configured on this server")
+	}
+	if _, err := mail.ParseAddress(settings["to"]); err != nil {
+		return errors.New("to must be a valid email address")
+	}
+	return nil
+}
+
+func (emailChannel) Send(ctx context.Context, settings map[string]string, msg Message) error {
+	cfg := config.AppConfig
+	if cfg.SMTPHost == "" {
+		return errors.New("smtp not configured")
+	}
+	to, err := mail.ParseAddress(settings["to"])
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(cfg.SMTPFrom)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+
+	// Header values can't contain line breaks, or they could inject extra headers
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Title)
+	body := msg.Body
+	if msg.URL != "" {
+		body += "\n\n" + msg.URL
+	}
+	data := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		from.String(), to.String(), subject, body)
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort), auth, from.Address, []string{to.Address}, []byte(data))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,87 @@
+// Package notify delivers notifications through pluggable channels. Producers build a
+// Message and hand it to the notification service; channels only know how to deliver it.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Event names producers use; users subscribe channels to a subset of them
+const (
+	EventSyncFailed      = "sync_failed"
+	EventSyncCompleted   = "sync_completed"
+	EventNewActivityDay  = "new_activity_day"
+	EventStreakMilestone = "streak_milestone"
+	EventWeeklyDigest    = "weekly_digest"
+	EventTest            = "test"
+)
+
+var ErrUnknownChannel = errors.New("unknown notification channel type")
+
+// Message is the channel-independent notification payload
+type Message struct {
+	Event string            `json:"event"`
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	URL   string            `json:"url,omitempty"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// Text renders the message as a single plain-text block for chat-style channels
+func (m Message) Text() string {
+	text := m.Title
+	if m.Body != "" {
+		text += "\n" + m.Body
+	}
+	if m.URL != "" {
+		text += "\n" + m.URL
+	}
+	return text
+}
+
+// Channel is a delivery mechanism. Settings are the per-user channel configuration
+// (webhook URL, topic, address...), validated when the user saves the channel.
+type Channel interface {
+	Type() string
+	Validate(settings map[string]string) error
+	Send(ctx context.Context, settings map[string]string, msg Message) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Channel)
+)
+
+// Register makes a channel available by its type name; built-in channels register in init
+func Register(ch Channel) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[ch.Type()] = ch
+}
+
+// Get returns the channel registered for a type
+func Get(channelType string) (Channel, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	ch, ok := registry[channelType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channelType)
+	}
+	return ch, nil
+}
+
+// Types returns the registered channel types
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(ntfyChannel{})
+}
+
+const defaultNtfyServer = "https://ntfy.sh"
+
+var ntfyTopicRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ntfyChannel publishes to an ntfy topic, on ntfy.sh or a self-hosted server
+type ntfyChannel struct{}
+
+func (ntfyChannel) Type() string { return "ntfy" }
+
+func (ntfyChannel) Validate(settings map[string]string) error {
+	if !ntfyTopicRegex.MatchString(settings["topic"]) {
+		return errors.New("topic must be 1-64 letters, digits, - or _")
+	}
+	if settings["server"] != "" {
+		return requireHTTPSURL(settings, "server")
+	}
+	return nil
+}
+
+func (ntfyChannel) Send(ctx context.Context, settings map[string]string, msg Message) error {
+	server := settings["server"]
+	if server == "" {
+		server = defaultNtfyServer
+	}
+
+	headers := map[string]string{"Title": msg.Title}
+	if msg.URL != "" {
+		headers["Click"] = msg.URL
+	}
+	if token := settings["token"]; token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	body := msg.Body
+	if body == "" {
+		body = msg.Title
+	}
+	return post(ctx, strings.TrimRight(server, "/")+"/"+settings["topic"], "text/plain", []byte(body), headers)
+}
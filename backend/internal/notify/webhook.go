@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"docker-heatmap/internal/utils"
+)
+
+func init() {
+	Register(webhookChannel{})
+	Register(slackChannel{})
+	Register(discordChannel{})
+}
+
+// requireHTTPSURL validates a settings URL; plain http is only allowed outside production
+func requireHTTPSURL(settings map[string]string, key string) error {
+	raw := settings[key]
+	if raw == "" {
+		return fmt.Errorf("%s is required", key)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%s must be a valid URL", key)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && !utils.IsProduction()) {
+		return fmt.Errorf("%s must use https", key)
+	}
+	// Security: don't let users point the server at its own network. This catches the
+	// obvious cases early; deliveryClient checks the address each delivery connects to.
+	if isInternalHost(u.Hostname()) {
+		return fmt.Errorf("%s must be a public address", key)
+	}
+	return nil
+}
+
+func isInternalHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && isInternalIP(ip)
+}
+
+// isInternalIP reports whether ip is one notifications may not be sent to
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast()
+}
+
+var errInternalAddress = errors.New("refusing to deliver to an internal address")
+
+// refuseInternalAddress is the dialer's Control hook, which runs once the host has been
+// resolved, so a public name pointing at the server's own network is refused as well
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("%w: %s", errInternalAddress, host)
+	}
+	return nil
+}
+
+// deliveryClient sends notifications to the URLs users configure. It only connects to
+// public addresses, goes direct rather than through a proxy, which would connect for it,
+// and doesn't follow redirects, which could lead anywhere; a redirect is a failed delivery.
+var deliveryClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: refuseInternalAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     60 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// postJSON sends a JSON body and treats any non-2xx response as a failed delivery
+func postJSON(ctx context.Context, target string, body interface{}, headers map[string]string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return post(ctx, target, "application/json", payload, headers)
+}
+
+func post(ctx context.Context, target, contentType string, payload []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "docker-heatmap-notifier")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := deliveryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("delivery failed with status " + resp.Status)
+	}
+	return nil
+}
+
+// webhookSignaturePrefix starts the X-Webhook-Signature header, ahead of the hex digest
+const webhookSignaturePrefix = "sha256="
+
+// minWebhookSecret is the shortest secret a webhook can be signed with
+const minWebhookSecret = 16
+
+// webhookChannel posts the message as JSON to any URL. With a secret, the request is
+// signed like ingest requests are: X-Webhook-Signature is the hex HMAC-SHA256 of
+// "<X-Webhook-Timestamp>.<body>", so the receiver can check who sent it and when.
+type webhookChannel struct{}
+
+func (webhookChannel) Type() string { return "webhook" }
+
+func (webhookChannel) Validate(settings map[string]string) error {
+	if secret, ok := settings["secret"]; ok && len(secret) < minWebhookSecret {
+		return fmt.Errorf("secret must be at least %d characters", minWebhookSecret)
+	}
+	return requireHTTPSURL(settings, "url")
+}
+
+func (webhookChannel) Send(ctx context.Context, settings map[string]string, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"X-Webhook-Event": msg.Event}
+	if secret := settings["secret"]; secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-Webhook-Timestamp"] = timestamp
+		headers["X-Webhook-Signature"] = signWebhook(secret, timestamp, payload)
+	}
+	return post(ctx, settings["url"], "application/json", payload, headers)
+}
+
+// signWebhook returns the X-Webhook-Signature of a payload sent at timestamp
+func signWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// slackChannel posts to a Slack incoming webhook
+type slackChannel struct{}
+
+func (slackChannel) Type() string { return "slack" }
+
+func (slackChannel) Validate(settings map[string]string) error {
+	return requireHTTPSURL(settings, "webhook_url")
+}
+
+func (slackChannel) Send(ctx context.Context, settings map[string]string, msg Message) error {
+	return postJSON(ctx, settings["webhook_url"], map[string]string{"text": msg.Text()}, nil)
+}
+
+// discordChannel posts to a Discord channel webhook
+type discordChannel struct{}
+
+func (discordChannel) Type() string { return "discord" }
+
+func (discordChannel) Validate(settings map[string]string) error {
+	return requireHTTPSURL(settings, "webhook_url")
+}
+
+func (discordChannel) Send(ctx context.Context, settings map[string]string, msg Message) error {
+	return postJSON(ctx, settings["webhook_url"], map[string]string{"content": msg.Text()}, nil)
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireHTTPSURLRefusesInternalHosts(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://hooks.example.com/notify", true},
+		{"https://localhost/notify", false},
+		{"https://LOCALHOST./notify", false},
+		{"https://metadata.google.internal/notify", false},
+		{"https://127.0.0.1/notify", false},
+		{"https://10.1.2.3/notify", false},
+		{"https://169.254.169.254/latest", false},
+		{"https://[::1]/notify", false},
+		{"https://0.0.0.0/notify", false},
+		{"https://224.0.0.1/notify", false},
+	}
+	for _, tt := range tests {
+		err := requireHTTPSURL(map[string]string{"url": tt.url}, "url")
+		if (err == nil) != tt.want {
+			t.Errorf("%s: error %v, want allowed %v", tt.url, err, tt.want)
+		}
+	}
+}
+
+func TestDeliveryRefusesInternalAddresses(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	err := post(context.Background(), server.URL, "text/plain", []byte("hello"), nil)
+	if !errors.Is(err, errInternalAddress) {
+		t.Errorf("error %v, want %v", err, errInternalAddress)
+	}
+	if hit {
+		t.Error("delivery reached a loopback server")
+	}
+}
+
+func TestDeliveryDoesNotFollowRedirects(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "https://hooks.example.com/notify", nil)
+	if err := deliveryClient.CheckRedirect(req, []*http.Request{req}); err != http.ErrUseLastResponse {
+		t.Errorf("CheckRedirect returned %v, want %v", err, http.ErrUseLastResponse)
+	}
+}
@@ -0,0 +1,185 @@
+// Package reporting sends errors and panics to Sentry when SENTRY_DSN is set, so production
+// failures surface somewhere other than the log. Events are sent in the background by the
+// Sentry SDK; without a DSN, capturing does nothing.
+package reporting
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"runtime"
+	"time"
+
+	"docker-heatmap/internal/utils"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Event levels
+const (
+	LevelFatal   = "fatal"
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Event is an error, or a message when there's no error, with what it happened to
+type Event struct {
+	Err     error
+	Message string
+	Level   string // LevelError when empty
+
+	UserID uint              // the signed-in user, if any
+	Tags   map[string]string // searchable: request_id, docker_account_id, cron_job...
+	Extra  map[string]any    // anything else worth seeing on the event
+}
+
+// Init starts reporting to the Sentry project of dsn, tagging events with environment.
+// With an empty dsn reporting stays off.
+func Init(dsn, environment string) error {
+	if dsn == "" {
+		return nil
+	}
+
+	if err := sentry.Init(clientOptions(dsn, environment)); err != nil {
+		return fmt.Errorf("invalid SENTRY_DSN: %w", err)
+	}
+	slog.Info("Error reporting enabled")
+	return nil
+}
+
+func clientOptions(dsn, environment string) sentry.ClientOptions {
+	name, _ := os.Hostname()
+	return sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+		ServerName:  name,
+		HTTPClient:  utils.HTTPClient,
+		BeforeSend:  scrubRequest,
+	}
+}
+
+// scrubRequest drops what the Fiber integration copies from the request beyond its method,
+// URL and a few harmless headers. Bodies carry passwords and tokens, and query strings
+// carry embed signatures.
+func scrubRequest(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	if event.Request == nil {
+		return event
+	}
+	headers := make(map[string]string)
+	for _, name := range []string{"Content-Type", "User-Agent", "Host"} {
+		if value, ok := event.Request.Headers[name]; ok {
+			headers[name] = value
+		}
+	}
+	event.Request = &sentry.Request{
+		URL:     event.Request.URL,
+		Method:  event.Request.Method,
+		Headers: headers,
+	}
+	return event
+}
+
+// Enabled reports whether events are being sent
+func Enabled() bool {
+	return sentry.CurrentHub().Client() != nil
+}
+
+// Capture sends e in the background, without waiting for it
+func Capture(e Event) {
+	capture(nil, e)
+}
+
+// CaptureOn sends e through hub, which adds what it knows, such as the request the Fiber
+// integration attached to it. A nil hub is the global one.
+func CaptureOn(hub *sentry.Hub, e Event) {
+	capture(hub, e)
+}
+
+// capture reports e as having happened where Capture or CaptureOn was called
+func capture(hub *sentry.Hub, e Event) {
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	if hub.Client() == nil {
+		return
+	}
+	hub.CaptureEvent(newEvent(e, callers(4)))
+}
+
+// Flush waits up to timeout for queued events to be sent, for use before exiting
+func Flush(timeout time.Duration) {
+	if !Enabled() {
+		return
+	}
+	if !sentry.Flush(timeout) {
+		slog.Warn("Gave up waiting for error reports to be sent")
+	}
+}
+
+// newEvent converts e to a Sentry event; stack is where it happened
+func newEvent(e Event, stack []uintptr) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = sentry.Level(e.Level)
+	if e.Level == "" {
+		event.Level = sentry.LevelError
+	}
+	event.Logger = "docker-heatmap"
+	for key, value := range e.Tags {
+		event.Tags[key] = value
+	}
+	for key, value := range e.Extra {
+		event.Extra[key] = value
+	}
+	if e.UserID != 0 {
+		event.User = sentry.User{ID: fmt.Sprint(e.UserID)}
+	}
+
+	if e.Err == nil {
+		event.Message = e.Message
+		return event
+	}
+	if e.Message != "" {
+		event.Extra["message"] = e.Message
+	}
+	event.Exception = []sentry.Exception{{
+		Type:       errorType(e.Err),
+		Value:      e.Err.Error(),
+		Stacktrace: stacktrace(stack),
+	}}
+	return event
+}
+
+// callers returns the program counters of the stack, skipping skip frames
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	return pcs[:runtime.Callers(skip, pcs)]
+}
+
+// stacktrace converts a stack to Sentry's, which lists the outermost call first
+func stacktrace(stack []uintptr) *sentry.Stacktrace {
+	var frames []sentry.Frame
+	callerFrames := runtime.CallersFrames(stack)
+	for {
+		frame, more := callerFrames.Next()
+		frames = append([]sentry.Frame{sentry.NewFrame(frame)}, frames...)
+		if !more {
+			break
+		}
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// errorType names the innermost error's type, which groups events better than the
+// wrappers around it
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			break
+		}
+		err = next
+	}
+	return reflect.TypeOf(err).String()
+}
@@ -0,0 +1,156 @@
+package reporting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// recordingTransport keeps the events it's given instead of sending them
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions) {}
+func (t *recordingTransport) Flush(time.Duration) bool       { return true }
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+// record sends events from hub to a recordingTransport, configured as Init configures the
+// real client
+func record(t *testing.T) (*sentry.Hub, *recordingTransport) {
+	t.Helper()
+
+	transport := &recordingTransport{}
+	options := clientOptions("https://public@sentry.example.com/42", "staging")
+	options.Transport = transport
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), transport
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "not found" }
+
+func TestCaptureOn(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     Event
+		level     sentry.Level
+		message   string
+		errorType string
+	}{
+		{
+			name:    "message",
+			event:   Event{Message: "sync stuck", Level: LevelWarning},
+			level:   sentry.LevelWarning,
+			message: "sync stuck",
+		},
+		{
+			name:      "error defaults to error level",
+			event:     Event{Err: errors.New("boom")},
+			level:     sentry.LevelError,
+			errorType: "*errors.errorString",
+		},
+		{
+			name:      "wrapped error is grouped by the innermost type",
+			event:     Event{Err: fmt.Errorf("loading account: %w", notFoundError{}), Level: LevelFatal},
+			level:     sentry.LevelFatal,
+			errorType: "reporting.notFoundError",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, transport := record(t)
+			tt.event.UserID = 7
+			tt.event.Tags = map[string]string{"request_id": "req-1"}
+			CaptureOn(hub, tt.event)
+
+			if len(transport.events) != 1 {
+				t.Fatalf("sent %d events, want 1", len(transport.events))
+			}
+			event := transport.events[0]
+			if event.Environment != "staging" {
+				t.Errorf("environment = %q, want staging", event.Environment)
+			}
+			if event.Level != tt.level {
+				t.Errorf("level = %q, want %q", event.Level, tt.level)
+			}
+			if event.Tags["request_id"] != "req-1" {
+				t.Errorf("request_id tag = %q, want req-1", event.Tags["request_id"])
+			}
+			if event.User.ID != "7" {
+				t.Errorf("user ID = %q, want 7", event.User.ID)
+			}
+			if event.Message != tt.message {
+				t.Errorf("message = %q, want %q", event.Message, tt.message)
+			}
+
+			if tt.errorType == "" {
+				if len(event.Exception) != 0 {
+					t.Errorf("exception = %+v, want none", event.Exception)
+				}
+				return
+			}
+			if len(event.Exception) != 1 {
+				t.Fatalf("sent %d exceptions, want 1", len(event.Exception))
+			}
+			exception := event.Exception[0]
+			if exception.Type != tt.errorType {
+				t.Errorf("exception type = %q, want %q", exception.Type, tt.errorType)
+			}
+			frames := exception.Stacktrace.Frames
+			if len(frames) == 0 || !strings.HasSuffix(frames[len(frames)-1].Function, "TestCaptureOn.func1") {
+				t.Errorf("innermost frame = %+v, want the caller of CaptureOn", frames[len(frames)-1])
+			}
+		})
+	}
+}
+
+func TestScrubRequest(t *testing.T) {
+	event := &sentry.Event{Request: &sentry.Request{
+		URL:         "https://heatmap.example.com/api/auth/login",
+		Method:      "POST",
+		Data:        `{"password":"hunter2"}`,
+		QueryString: "sig=secret",
+		Cookies:     "session=secret",
+		Headers: map[string]string{
+			"Authorization": "Bearer secret",
+			"Content-Type":  "application/json",
+			"User-Agent":    "curl/8.0",
+		},
+	}}
+
+	request := scrubRequest(event, nil).Request
+	if request.URL != "https://heatmap.example.com/api/auth/login" || request.Method != "POST" {
+		t.Errorf("request = %s %s, want the method and URL kept", request.Method, request.URL)
+	}
+	if request.Data != "" || request.QueryString != "" || request.Cookies != "" {
+		t.Errorf("request = %+v, want body, query string and cookies dropped", request)
+	}
+	if _, ok := request.Headers["Authorization"]; ok {
+		t.Error("Authorization header kept")
+	}
+	if request.Headers["User-Agent"] != "curl/8.0" {
+		t.Errorf("User-Agent = %q, want it kept", request.Headers["User-Agent"])
+	}
+}
+
+func TestInitRejectsInvalidDSN(t *testing.T) {
+	if err := Init("not a dsn", "test"); err == nil {
+		t.Error("Init accepted an invalid DSN")
+	}
+}
@@ -1,18 +1,24 @@
 package router
 
 import (
+	"fmt"
+	"runtime/debug"
+
 	"docker-heatmap/internal/config"
-	"docker-heatmap/internal/database"
 	"docker-heatmap/internal/handlers"
+	"docker-heatmap/internal/logging"
 	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/reporting"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/worker"
 
+	sentryfiber "github.com/getsentry/sentry-go/fiber"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
-func SetupRouter() *fiber.App {
+func SetupRouter(svc *services.Services, syncWorker *worker.SyncWorker) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: customErrorHandler,
 		AppName:      "Docker Heatmap API",
@@ -23,6 +29,14 @@ func SetupRouter() *fiber.App {
 		DisableStartupMessage: config.AppConfig.Environment == "production",
 	})
 
+	// Request IDs come first, so everything after can log them
+	app.Use(middleware.RequestIDMiddleware())
+
+	// Gives each request its own Sentry hub carrying the request, which the errors and
+	// panics reported below are sent through. It sits outside recover, which reports panics
+	// with the request's tags, so it never sees one itself.
+	app.Use(sentryfiber.New(sentryfiber.Options{}))
+
 	// Security headers middleware
 	app.Use(func(c *fiber.Ctx) error {
 		c.Set("X-Content-Type-Options", "nosniff")
@@ -36,10 +50,11 @@ func SetupRouter() *fiber.App {
 	})
 
 	// Global middleware
-	app.Use(recover.New())
-	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${method} ${path} ${latency}\n",
+	app.Use(recover.New(recover.Config{
+		EnableStackTrace:  true,
+		StackTraceHandler: reportPanic,
 	}))
+	app.Use(middleware.RequestLogMiddleware())
 
 	// CORS
 	origins := config.AppConfig.FrontendURL
@@ -51,61 +66,152 @@ func SetupRouter() *fiber.App {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With,X-Request-ID,API-Version",
+		ExposeHeaders:    middleware.ExposedHeaders,
 		AllowCredentials: true,
 	}))
 
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		status := "healthy"
-		dbStatus := "connected"
-
-		// Also check database connection
-		sqlDB, err := database.DB.DB()
-		if err != nil || sqlDB.Ping() != nil {
-			status = "unhealthy"
-			dbStatus = "disconnected"
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status":   status,
-				"database": dbStatus,
-				"service":  "docker-heatmap-api",
-			})
-		}
-
-		return c.JSON(fiber.Map{
-			"status":   status,
-			"database": dbStatus,
-			"service":  "docker-heatmap-api",
-		})
-	})
+	// Health checks: liveness for restarts, readiness for traffic
+	health := handlers.NewHealthHandler(svc)
+	app.Get("/health", health.Health)
+	app.Get("/health/live", health.Live)
+	app.Get("/health/ready", health.Ready)
 
 	// API routes
-	api := app.Group("/api")
+	api := app.Group(middleware.APIPrefix)
+	api.Use(middleware.APIVersionMiddleware())
 	api.Use(middleware.EnforceJSONMiddleware())
+	api.Use(middleware.ReadOnlyMiddleware())
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler()
-	dockerHandler := handlers.NewDockerHandler()
-	heatmapHandler := handlers.NewHeatmapHandler()
-	userHandler := handlers.NewUserHandler()
+	h := &routeHandlers{
+		auth:         handlers.NewAuthHandler(svc),
+		docker:       handlers.NewDockerHandler(svc),
+		heatmap:      handlers.NewHeatmapHandler(svc),
+		user:         handlers.NewUserHandler(svc),
+		notification: handlers.NewNotificationHandler(svc),
+		graphql:      handlers.NewGraphQLHandler(svc),
+		admin:        handlers.NewAdminHandler(svc, syncWorker),
+	}
+
+	// Public keys for verifying signed activity (outside /api, where verifiers expect them)
+	app.Get("/.well-known/jwks.json", h.heatmap.GetJWKS)
+
+	// Embeddable interactive widget, loaded by other sites with a script tag
+	app.Get("/embed.js", handlers.NewWidgetHandler().GetEmbedScript)
 
+	// API description and its browsable docs, ahead of the deprecated aliases
+	docsHandler := handlers.NewDocsHandler()
+	api.Get("/openapi.json", docsHandler.GetOpenAPISpec)
+	api.Get("/docs", docsHandler.GetAPIDocs)
+
+	// Breaking changes go in a new version; registered first so the unversioned
+	// aliases below never shadow it
+	registerRoutes(app.Group(middleware.APIV1Prefix), h)
+	// Deprecated unversioned aliases, kept so existing embeds keep working
+	registerRoutes(app.Group(middleware.APIPrefix, middleware.LegacyAPIMiddleware()), h)
+
+	return app
+}
+
+// routeHandlers are shared by every API version, so caches and state aren't duplicated
+type routeHandlers struct {
+	auth         *handlers.AuthHandler
+	docker       *handlers.DockerHandler
+	heatmap      *handlers.HeatmapHandler
+	user         *handlers.UserHandler
+	notification *handlers.NotificationHandler
+	graphql      *handlers.GraphQLHandler
+	admin        *handlers.AdminHandler
+}
+
+// registerRoutes mounts the API on api, which is the root of one version
+func registerRoutes(api fiber.Router, h *routeHandlers) {
 	// Public routes (with rate limiting)
 	public := api.Group("")
 	public.Use(middleware.PublicRateLimitMiddleware())
+	public.Use(middleware.AnalyticsMiddleware())
+	// Identify owners asking to bypass caches with ?refresh=1; other requests skip the lookup
+	optionalAuth := middleware.OptionalAuthMiddleware()
+	public.Use(func(c *fiber.Ctx) error {
+		if c.Query("refresh") == "" {
+			return c.Next()
+		}
+		return optionalAuth(c)
+	})
 
-	// SVG and JSON endpoints (public, embeddable)
-	public.Get("/heatmap/:username", heatmapHandler.GetHeatmapSVG)
-	public.Get("/heatmap/:username.svg", heatmapHandler.GetHeatmapSVG)
-	public.Get("/activity/:username", heatmapHandler.GetActivityJSON)
-	public.Get("/activity/:username.json", heatmapHandler.GetActivityJSON)
-	public.Get("/profile/:username", heatmapHandler.GetProfilePage)
-	public.Get("/themes", heatmapHandler.GetAvailableThemes)
+	// SVG and JSON endpoints (public, embeddable; private profiles need a signed URL or
+	// an embed token). They are read-only, so any site may read them from the browser, as
+	// the widget and users' own pages do.
+	anyOrigin := middleware.PublicCORSMiddleware()
+	// Images are rendered from the database, so each has a per-IP and per-username budget
+	budget := middleware.RenderBudgetMiddleware()
+	embed := h.heatmap.RequireEmbedAccess
+	public.Get("/heatmap/:username.txt", anyOrigin, budget, embed, h.heatmap.GetHeatmapText) // before :username, which would match it
+	public.Get("/heatmap/:username.png", anyOrigin, budget, embed, h.heatmap.GetHeatmapPNG)
+	public.Get("/heatmap/:username.gif", anyOrigin, budget, embed, h.heatmap.GetHeatmapGIF)
+	public.Get("/heatmap/:username.webp", anyOrigin, budget, embed, h.heatmap.GetHeatmapWebP)
+	public.Get("/heatmap/:username", anyOrigin, budget, embed, h.heatmap.GetHeatmapSVG)
+	public.Get("/heatmap/:username.svg", anyOrigin, budget, embed, h.heatmap.GetHeatmapSVG)
+	public.Get("/heatmap/:username/snapshot", anyOrigin, budget, embed, h.heatmap.GetHeatmapSnapshot)
+	public.Get("/chart/:username/monthly.svg", anyOrigin, budget, embed, h.heatmap.GetMonthlyChartSVG)
+	public.Get("/sparkline/:username", anyOrigin, budget, embed, h.heatmap.GetSparklineSVG)
+	public.Get("/sparkline/:username.svg", anyOrigin, budget, embed, h.heatmap.GetSparklineSVG)
+	public.Get("/punchcard/:username", anyOrigin, budget, embed, h.heatmap.GetPunchcardSVG)
+	public.Get("/punchcard/:username.svg", anyOrigin, budget, embed, h.heatmap.GetPunchcardSVG)
+	public.Get("/team/heatmap", anyOrigin, budget, h.heatmap.GetTeamHeatmapSVG)
+	public.Get("/team/heatmap.svg", anyOrigin, budget, h.heatmap.GetTeamHeatmapSVG)
+	public.Get("/compare/:userA/:userB.svg", anyOrigin, budget, h.heatmap.GetComparisonSVG) // before :userB, which would match it
+	public.Get("/compare/:userA/:userB", anyOrigin, h.heatmap.GetComparison)
+	public.Get("/activity/:username.jws", anyOrigin, embed, h.heatmap.GetActivityJWS) // before :username, which would match it
+	public.Get("/activity/:username.csv", anyOrigin, embed, h.heatmap.GetActivityCSV)
+	public.Get("/activity/:username", anyOrigin, embed, h.heatmap.GetActivityJSON)
+	public.Get("/activity/:username.json", anyOrigin, embed, h.heatmap.GetActivityJSON)
+	public.Get("/activity/:username/:date", anyOrigin, h.heatmap.GetActivityDay)
+	public.Get("/feed/:username.atom", anyOrigin, h.heatmap.GetActivityFeed)
+	public.Get("/calendar/:username.ics", anyOrigin, h.heatmap.GetActivityCalendar)
+	public.Get("/wrapped/:username/:year.png", anyOrigin, budget, h.heatmap.GetWrappedPNG) // before :year, which would match it
+	public.Get("/wrapped/:username/:year", anyOrigin, h.heatmap.GetWrapped)
+	public.Get("/repos/:username", anyOrigin, embed, h.heatmap.GetRepositories)
+	public.Get("/profile/:username", anyOrigin, h.heatmap.GetProfilePage)
+	public.Get("/profile/:username/repositories", anyOrigin, h.heatmap.GetProfileRepositories)
+	public.Get("/themes", anyOrigin, h.heatmap.GetAvailableThemes)
+	public.Get("/themes/validate", anyOrigin, h.heatmap.ValidateTheme)
+	public.Get("/preview/sample.svg", anyOrigin, h.heatmap.GetSampleSVG)
+	public.Get("/graphql", h.graphql.Query)
+	public.Get("/export/:token", h.user.DownloadDataExport) // the token in the link authorizes it
+	public.Post("/account/delete/confirm", middleware.StrictRateLimitMiddleware(), h.user.ConfirmAccountDeletion)
+	public.Post("/graphql", h.graphql.Query)
 
 	// Auth routes (strict rate limiting)
 	auth := api.Group("/auth")
 	auth.Use(middleware.StrictRateLimitMiddleware())
-	auth.Get("/github", authHandler.InitiateGitHubAuth)
-	auth.Get("/github/callback", authHandler.GitHubCallback)
+	auth.Get("/providers", h.auth.ListProviders)
+	// A login pair per sign-in provider, named rather than /:provider so /auth/device and
+	// the other auth routes can't be taken for one
+	for _, provider := range h.auth.Providers() {
+		auth.Get("/"+provider.Name(), h.auth.InitiateOAuth(provider))
+		auth.Get("/"+provider.Name()+"/callback", h.auth.OAuthCallback(provider))
+	}
+	if config.AppConfig.AuthPasswordEnabled {
+		auth.Post("/password/signup", h.auth.Signup)
+		auth.Post("/password/login", h.auth.PasswordLogin)
+		auth.Post("/password/verify", h.auth.VerifyEmail)
+		auth.Post("/password/forgot", h.auth.ForgotPassword)
+		auth.Post("/password/reset", h.auth.ResetPassword)
+	}
+	auth.Post("/device", h.auth.StartDeviceAuth)
+	auth.Post("/device/token", h.auth.PollDeviceAuth)
+	auth.Post("/refresh", h.auth.RefreshToken)
+
+	// Signed event ingest for CI pipelines and scripts, authenticated by an ingest key
+	// rather than a session
+	ingest := api.Group("/ingest")
+	ingest.Use(middleware.APIRateLimitMiddleware())
+	ingest.Use(h.docker.RequireIngestSignature)
+	ingest.Post("/events", h.docker.IngestEvents)
+	ingest.Post("/build", h.docker.IngestBuild)
+	ingest.Post("/agent", h.docker.IngestAgentReport)
 
 	// Protected routes (require authentication)
 	protected := api.Group("")
@@ -113,18 +219,78 @@ func SetupRouter() *fiber.App {
 	protected.Use(middleware.APIRateLimitMiddleware())
 
 	// User routes
-	protected.Get("/user/me", userHandler.GetProfile)
-	protected.Put("/user/me", userHandler.UpdateProfile)
-	protected.Get("/user/embed", userHandler.GetEmbedCode)
-	protected.Post("/auth/logout", authHandler.Logout)
+	protected.Get("/user/me", h.user.GetProfile)
+	protected.Put("/user/me", h.user.UpdateProfile)
+	protected.Delete("/user/me", h.user.DeleteAccount)
+	protected.Delete("/user/me/deletion", h.user.CancelAccountDeletion)
+	protected.Get("/user/embed", h.user.GetEmbedCode)
+	protected.Post("/user/embed/sign", h.user.SignEmbed)
+	protected.Get("/user/embed/tokens", h.user.ListEmbedTokens)
+	protected.Post("/user/embed/tokens", h.user.CreateEmbedToken)
+	protected.Put("/user/embed/tokens/:id", h.user.UpdateEmbedToken)
+	protected.Delete("/user/embed/tokens/:id", h.user.RevokeEmbedToken)
+	protected.Get("/user/diagnostics", h.user.GetDiagnostics)
+	protected.Get("/user/activity-report", h.user.GetActivityReport)
+	protected.Post("/user/export", h.user.RequestDataExport)
+	protected.Get("/user/export", h.user.GetDataExport)
+	protected.Get("/user/limits", h.user.GetLimits)
+	protected.Get("/user/views", h.user.GetViews)
+	protected.Get("/user/repositories", h.user.GetRepositories)
+	protected.Get("/user/events", h.user.ListEvents)
+	protected.Get("/user/events.csv", h.user.ExportEventsCSV)
+	protected.Post("/user/import", h.user.ImportActivity)
+	protected.Get("/user/sessions", h.user.ListSessions)
+	protected.Delete("/user/sessions/:id", h.user.RevokeSession)
+	protected.Get("/user/audit", h.user.GetAuditLog)
+	protected.Post("/auth/logout", h.auth.Logout)
+	protected.Get("/auth/device", h.auth.GetDeviceAuth)
+	protected.Post("/auth/device/approve", h.auth.DecideDeviceAuth)
 
 	// Docker routes
-	protected.Post("/docker/connect", dockerHandler.ConnectDocker)
-	protected.Get("/docker/account", dockerHandler.GetDockerAccount)
-	protected.Delete("/docker/disconnect", dockerHandler.DisconnectDocker)
-	protected.Post("/docker/sync", dockerHandler.SyncDockerActivity)
+	protected.Post("/docker/connect", h.docker.ConnectDocker)
+	protected.Get("/docker/account", h.docker.GetDockerAccount)
+	protected.Put("/docker/account", h.docker.UpdateDockerAccount)
+	protected.Delete("/docker/disconnect", h.docker.DisconnectDocker)
+	protected.Post("/docker/disconnect/undo", h.docker.UndoDisconnect)
+	protected.Post("/docker/sync", h.docker.SyncDockerActivity)
+	protected.Get("/docker/sync/events", h.docker.StreamSyncEvents)
+	protected.Get("/docker/sync/history", h.docker.GetSyncHistory)
+	protected.Get("/docker/events", h.docker.GetActivityEvents)
+	protected.Get("/docker/namespaces", h.docker.ListNamespaces)
+	protected.Post("/docker/namespaces", h.docker.ClaimNamespace)
+	protected.Post("/docker/namespaces/:namespace/verify", h.docker.VerifyNamespace)
+	protected.Delete("/docker/namespaces/:namespace", h.docker.RemoveNamespace)
+	protected.Get("/docker/ingest-keys", h.docker.ListIngestKeys)
+	protected.Post("/docker/ingest-keys", h.docker.CreateIngestKey)
+	protected.Delete("/docker/ingest-keys/:id", h.docker.DeleteIngestKey)
 
-	return app
+	// Notification routes
+	protected.Get("/notifications/channels", h.notification.ListChannels)
+	protected.Post("/notifications/channels", h.notification.CreateChannel)
+	protected.Delete("/notifications/channels/:id", h.notification.DeleteChannel)
+	protected.Post("/notifications/channels/:id/test", h.notification.TestChannel)
+	protected.Get("/user/webhooks", h.notification.ListWebhooks)
+	protected.Post("/user/webhooks", h.notification.CreateWebhook)
+	protected.Delete("/user/webhooks/:id", h.notification.DeleteWebhook)
+
+	// Admin routes (ADMIN_USER_IDS)
+	admin := protected.Group("/admin", middleware.AdminMiddleware())
+	admin.Get("/stats", h.admin.GetStats)
+	admin.Get("/users", h.admin.ListUsers)
+	admin.Put("/users/:id/admin", h.admin.SetUserAdmin)
+	admin.Post("/users/:id/suspend", h.admin.SuspendUser)
+	admin.Post("/users/:id/unsuspend", h.admin.UnsuspendUser)
+	admin.Get("/blocked-usernames", h.admin.ListBlockedUsernames)
+	admin.Post("/blocked-usernames", h.admin.BlockUsername)
+	admin.Delete("/blocked-usernames/:username", h.admin.UnblockUsername)
+	admin.Get("/accounts", h.admin.ListAccounts)
+	admin.Post("/accounts/:id/sync", h.admin.SyncAccount)
+	admin.Post("/accounts/:id/disable", h.admin.DisableAccount)
+	admin.Post("/accounts/:id/enable", h.admin.EnableAccount)
+	admin.Get("/jobs", h.admin.ListJobs)
+	admin.Get("/sync/dead-letter", h.admin.ListDeadLetteredSyncs)
+	admin.Post("/sync/dead-letter/:id/requeue", h.admin.RequeueDeadLetteredSync)
+	admin.Put("/read-only", h.admin.SetReadOnly)
 }
 
 func customErrorHandler(c *fiber.Ctx, err error) error {
@@ -135,11 +301,52 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 		code = e.Code
 	}
 
-	if code == fiber.StatusInternalServerError && config.AppConfig.Environment == "production" {
-		message = "An internal error occurred"
+	if code == fiber.StatusInternalServerError {
+		logging.FromContext(c.UserContext()).Error("Request failed", "path", c.Path(), "error", err)
+		if c.Locals(panicReportedKey) == nil {
+			reporting.CaptureOn(sentryfiber.GetHubFromContext(c), requestEvent(c, err, reporting.LevelError))
+		}
+		if config.AppConfig.Environment == "production" {
+			message = "An internal error occurred"
+		}
 	}
 
 	return c.Status(code).JSON(fiber.Map{
 		"error": message,
 	})
 }
+
+// panicReportedKey marks a request whose panic was already reported, so the error the
+// recover middleware turns it into isn't reported again
+const panicReportedKey = "panicReported"
+
+// reportPanic logs and reports a panic in a handler. It runs while the panic unwinds, so the
+// stack reported is where it happened.
+func reportPanic(c *fiber.Ctx, e interface{}) {
+	err, ok := e.(error)
+	if !ok {
+		err = fmt.Errorf("%v", e)
+	}
+	logging.FromContext(c.UserContext()).Error("Panic handling request", "path", c.Path(), "error", err,
+		"stack", string(debug.Stack()))
+	reporting.CaptureOn(sentryfiber.GetHubFromContext(c), requestEvent(c, err, reporting.LevelFatal))
+	c.Locals(panicReportedKey, true)
+}
+
+// requestEvent describes an error in handling the request for error reporting
+func requestEvent(c *fiber.Ctx, err error, level string) reporting.Event {
+	event := reporting.Event{
+		Err:   err,
+		Level: level,
+		Tags: map[string]string{
+			"request_id": middleware.GetRequestID(c),
+			"method":     c.Method(),
+			"route":      c.Route().Path,
+		},
+		Extra: map[string]any{"path": c.Path()},
+	}
+	if user := middleware.GetUserFromContext(c); user != nil {
+		event.UserID = user.ID
+	}
+	return event
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAccountDeletionScheduled is returned for syncs and ingests of a user whose account
+	// is about to be deleted
+	ErrAccountDeletionScheduled = errors.New("account is scheduled to be deleted")
+	ErrNoAccountDeletion        = errors.New("no deletion is pending for this account")
+	ErrAccountDeletionNoEmail   = errors.New("the account has no email address to confirm its deletion with")
+)
+
+// AccountDeletionGracePeriod returns how long a confirmed account deletion can be cancelled
+func AccountDeletionGracePeriod() time.Duration {
+	if config.AppConfig.AccountDeletionDays <= 0 {
+		return 0
+	}
+	return time.Duration(config.AppConfig.AccountDeletionDays) * 24 * time.Hour
+}
+
+// RequestAccountDeletion mails the user a link to confirm deleting their account. Nothing
+// changes until the link is followed.
+func (s *DockerHubService) RequestAccountDeletion(user *models.User) error {
+	if user.DeletionScheduledAt != nil {
+		return ErrAccountDeletionScheduled
+	}
+	if user.GitHubEmail == "" && config.AppConfig.SMTPHost != "" {
+		return ErrAccountDeletionNoEmail
+	}
+	return sendEmailToken(user, models.EmailTokenDelete)
+}
+
+// ConfirmAccountDeletion uses a deletion link's token and schedules the account's deletion
+// after the grace period. Its heatmaps stop being served and its accounts synced straight
+// away; the user can still sign in to cancel. Without a grace period the account is deleted
+// immediately and the returned time is zero.
+func (s *DockerHubService) ConfirmAccountDeletion(token string) (*models.User, time.Time, error) {
+	user, err := useEmailToken(token, models.EmailTokenDelete)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	grace := AccountDeletionGracePeriod()
+	if grace == 0 {
+		return user, time.Time{}, s.DeleteUser(user.ID)
+	}
+
+	deleteAt := s.clock.Now().Add(grace)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND deletion_scheduled_at IS NULL", user.ID).
+			Update("deletion_scheduled_at", deleteAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAccountDeletionScheduled
+		}
+		return tx.Model(&models.SyncJob{}).
+			Where("status = ? AND docker_account_id IN (?)", models.SyncJobPending,
+				tx.Model(&models.DockerAccount{}).Select("id").Where("user_id = ?", user.ID)).
+			Updates(map[string]interface{}{"status": models.SyncJobFailed, "last_error": ErrAccountDeletionScheduled.Error()}).Error
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	user.DeletionScheduledAt = &deleteAt
+	s.invalidateUserRenders(user.ID)
+	return user, deleteAt, nil
+}
+
+// CancelAccountDeletion keeps an account whose deletion is still pending
+func (s *DockerHubService) CancelAccountDeletion(userID uint) error {
+	result := database.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", userID).
+		Update("deletion_scheduled_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNoAccountDeletion
+	}
+
+	s.invalidateUserRenders(userID)
+	return nil
+}
+
+// PurgeDeletedUsers deletes the users whose deletion grace period has ended, and returns how
+// many were deleted
+func (s *DockerHubService) PurgeDeletedUsers() (int, error) {
+	var userIDs []uint
+	err := database.DB.Model(&models.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", s.clock.Now()).
+		Pluck("id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, userID := range userIDs {
+		if err := s.DeleteUser(userID); err != nil {
+			slog.Error("Failed to delete user", "user_id", userID, "error", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// DeleteUser permanently removes a user and everything stored about them: their Docker
+// accounts and all their activity, sessions, audit log, tokens, keys, notification channels
+// and data exports. It's one transaction, so a failure partway deletes nothing.
+func (s *DockerHubService) DeleteUser(userID uint) error {
+	var accounts []models.DockerAccount
+	if err := database.DB.Unscoped().Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
+		return err
+	}
+	accountIDs := make([]uint, len(accounts))
+	for i, account := range accounts {
+		accountIDs[i] = account.ID
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		byAccount := []interface{}{
+			&models.ActivityEvent{},
+			&models.DailyActivityAggregate{},
+			&models.DailyViewCount{},
+			&models.NamespaceClaim{},
+			&models.ActivityArchive{},
+			&models.DockerRepository{},
+			&models.IngestReceipt{},
+			&models.HeatmapSnapshot{},
+			&models.WrappedReport{},
+			&models.Achievement{},
+			&models.SyncJob{},
+			&models.SyncRun{},
+		}
+		for _, model := range byAccount {
+			if err := tx.Unscoped().Where("docker_account_id IN ?", accountIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.DockerAccount{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("channel_id IN (?)",
+			tx.Unscoped().Model(&models.NotificationChannel{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&models.NotificationDelivery{}).Error; err != nil {
+			return err
+		}
+		byUser := []interface{}{
+			&models.NotificationChannel{},
+			&models.Session{},
+			&models.AuditLog{},
+			&models.UserIdentity{},
+			&models.EmailToken{},
+			&models.EmbedToken{},
+			&models.IngestKey{},
+			&models.DataExport{},
+			&models.DeviceAuthorization{},
+		}
+		for _, model := range byUser {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Blocks an admin made outlive them
+		if err := tx.Model(&models.BlockedDockerUsername{}).Where("created_by_user_id = ?", userID).
+			Update("created_by_user_id", nil).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("id = ?", userID).Delete(&models.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		s.renderCache.Invalidate(account.DockerUsername)
+	}
+	return nil
+}
+
+// UnavailableUserIDs selects the ids of users whose accounts aren't served or synced: those
+// an admin suspended and those about to be deleted
+func UnavailableUserIDs() *gorm.DB {
+	return database.DB.Model(&models.User{}).Select("id").
+		Where("suspended_at IS NOT NULL OR deletion_scheduled_at IS NOT NULL")
+}
+
+// userUnavailable returns why the user's accounts can't be synced or ingested into, or nil
+func userUnavailable(userID uint) error {
+	var user models.User
+	if err := database.DB.Select("id, suspended_at, deletion_scheduled_at").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Suspended() {
+		return ErrUserSuspended
+	}
+	if user.DeletionScheduledAt != nil {
+		return ErrAccountDeletionScheduled
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// Achievement keys
+const (
+	AchievementFirstPush     = "first_push"
+	AchievementActiveDays100 = "active_days_100"
+	AchievementStreak30      = "streak_30"
+	AchievementPulls1M       = "pulls_1m"
+)
+
+// AchievementInfo describes an achievement for display
+type AchievementInfo struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Achievements are the achievements an account can earn, in display order
+var Achievements = []AchievementInfo{
+	{AchievementFirstPush, "First Push", "Pushed an image to Docker Hub"},
+	{AchievementActiveDays100, "Centurion", "Active on 100 different days"},
+	{AchievementStreak30, "On a Roll", "Active every day for 30 days in a row"},
+	{AchievementPulls1M, "Millionaire", "Repositories pulled a million times"},
+}
+
+// EarnedAchievement is an achievement an account has, with when it was earned
+type EarnedAchievement struct {
+	AchievementInfo
+	EarnedAt time.Time `json:"earned_at"`
+}
+
+// GetAchievements returns the account's achievements in display order
+func (s *DockerHubService) GetAchievements(accountID uint) ([]EarnedAchievement, error) {
+	var rows []models.Achievement
+	if err := database.DB.Where("docker_account_id = ?", accountID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	earnedAt := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		earnedAt[row.Achievement] = row.EarnedAt
+	}
+
+	earned := make([]EarnedAchievement, 0, len(rows))
+	for _, info := range Achievements {
+		if at, ok := earnedAt[info.Key]; ok {
+			earned = append(earned, EarnedAchievement{AchievementInfo: info, EarnedAt: at})
+		}
+	}
+	return earned, nil
+}
+
+// evaluateAchievements records the achievements the account's activity and repositories
+// now qualify for. Activity is only checked as far back as it's retained, but achievements
+// already earned are kept.
+func (s *DockerHubService) evaluateAchievements(account *models.DockerAccount) error {
+	var have []string
+	if err := database.DB.Model(&models.Achievement{}).Where("docker_account_id = ?", account.ID).Pluck("achievement", &have).Error; err != nil {
+		return err
+	}
+	earned := make(map[string]bool, len(have))
+	for _, key := range have {
+		earned[key] = true
+	}
+
+	qualifies := make(map[string]bool)
+	if !earned[AchievementFirstPush] {
+		var pushes int64
+		err := database.DB.Model(&models.DailyActivityAggregate{}).
+			Where("docker_account_id = ? AND event_type = ?", account.ID, models.EventTypePush).
+			Count(&pushes).Error
+		if err != nil {
+			return err
+		}
+		qualifies[AchievementFirstPush] = pushes > 0
+	}
+
+	if !earned[AchievementActiveDays100] || !earned[AchievementStreak30] {
+		var days []time.Time
+		err := database.DB.Model(&models.DailyActivityAggregate{}).
+			Where("docker_account_id = ?", account.ID).
+			Distinct("event_date").
+			Order("event_date").
+			Pluck("event_date", &days).Error
+		if err != nil {
+			return err
+		}
+		qualifies[AchievementActiveDays100] = len(days) >= 100
+		qualifies[AchievementStreak30] = longestDayRun(days) >= 30
+	}
+
+	if !earned[AchievementPulls1M] {
+		var pulls int64
+		err := database.DB.Model(&models.DockerRepository{}).
+			Where("docker_account_id = ?", account.ID).
+			Select("COALESCE(SUM(pull_count), 0)").
+			Scan(&pulls).Error
+		if err != nil {
+			return err
+		}
+		qualifies[AchievementPulls1M] = pulls >= 1000000
+	}
+
+	now := s.clock.Now()
+	for _, info := range Achievements {
+		if earned[info.Key] || !qualifies[info.Key] {
+			continue
+		}
+		row := models.Achievement{DockerAccountID: account.ID, Achievement: info.Key, EarnedAt: now}
+		// A sync and an ingest finishing together may both record it
+		if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// longestDayRun returns the longest run of consecutive UTC days in days, which must be
+// distinct and in order
+func longestDayRun(days []time.Time) int {
+	longest, run := 0, 0
+	for i, day := range days {
+		if i > 0 && day.UTC().Sub(days[i-1].UTC()) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Import formats
+const (
+	ImportFormatCSV    = "csv"    // the raw events CSV from /user/events.csv
+	ImportFormatJSON   = "json"   // raw events from /user/events or the data export's activity_events.jsonl
+	ImportFormatGitHub = "github" // a GitHub contributions calendar
+)
+
+const (
+	// MaxImportRows bounds how many rows one import holds; a longer history is imported a
+	// date range at a time
+	MaxImportRows = 10000
+
+	// maxImportCount bounds one row's count, which a real day's events come nowhere near
+	maxImportCount = 10000
+
+	// maxImportErrors bounds how many invalid rows a rejected import lists
+	maxImportErrors = 100
+
+	// GitHubImportRepository is the repository GitHub contributions are imported under, as
+	// pushes, since the calendar doesn't say where they were made
+	GitHubImportRepository = "github"
+)
+
+var (
+	ErrImportFormat = errors.New("format must be csv, json or github")
+	ErrImportSize   = fmt.Errorf("an import must have between 1 and %d rows", MaxImportRows)
+)
+
+// ImportParseError reports an import that couldn't be read as its format
+type ImportParseError struct {
+	Err error
+}
+
+func (e *ImportParseError) Error() string {
+	return e.Err.Error()
+}
+
+// ImportResult counts what an import recorded
+type ImportResult struct {
+	Imported int `json:"imported"`
+	// Duplicates are days the account already has events for with the same repository,
+	// tag and type, or rows repeated within the import
+	Duplicates int `json:"duplicates"`
+	// Expired rows are older than the retention window, so they'd be deleted straight away
+	Expired int `json:"expired"`
+}
+
+// importRow is one day's events of a repository, tag and type
+type importRow struct {
+	EventType  string          `json:"event_type"`
+	EventDate  string          `json:"event_date"` // YYYY-MM-DD or RFC 3339
+	EventAt    string          `json:"event_at"`   // Optional RFC 3339
+	Count      json.RawMessage `json:"count"`      // Optional, 1 by default
+	Repository string          `json:"repository"`
+	Tag        string          `json:"tag"`
+}
+
+// ImportActivity restores history from an export of this or another instance, or seeds it
+// from a GitHub contributions calendar. Every row is validated before any is recorded.
+// Imported events are marked as manually imported whatever their original source, and a
+// day's events are only imported when the account has none for the same repository, tag
+// and type, so importing the same file twice, or history the sync already found, doesn't
+// count anything twice.
+func (s *DockerHubService) ImportActivity(userID uint, format string, body []byte) (*ImportResult, error) {
+	var rows []importRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseImportCSV(body)
+	case ImportFormatJSON:
+		rows, err = parseImportJSON(body)
+	case ImportFormatGitHub:
+		rows, err = parseImportGitHub(body)
+	default:
+		return nil, ErrImportFormat
+	}
+	if err == ErrImportSize {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &ImportParseError{Err: err}
+	}
+	if len(rows) == 0 || len(rows) > MaxImportRows {
+		return nil, ErrImportSize
+	}
+
+	now := s.clock.Now()
+	oldest := now.AddDate(0, 0, -ActivityRetentionDays())
+	result := &ImportResult{}
+	events := make([]models.ActivityEvent, 0, len(rows))
+	seen := make(map[string]bool, len(rows))
+	var invalid []IngestEventError
+	for i, row := range rows {
+		event, err := validateImportRow(row, now)
+		if err != nil {
+			if len(invalid) < maxImportErrors {
+				invalid = append(invalid, IngestEventError{Index: i, Error: err.Error()})
+			}
+			continue
+		}
+		if event.EventAt.Before(oldest) {
+			result.Expired++
+			continue
+		}
+		key := event.EventDate.Format("2006-01-02") + "\x00" + event.Repository + "\x00" + event.Tag + "\x00" + string(event.EventType)
+		if seen[key] {
+			result.Duplicates++
+			continue
+		}
+		seen[key] = true
+		events = append(events, event)
+	}
+	if len(invalid) > 0 {
+		return nil, &IngestValidationError{Events: invalid}
+	}
+
+	account, err := s.GetDockerAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].DockerAccountID = account.ID
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			// A day the account already has a row for is left alone: the unique index on
+			// account, day, repository, tag and type is the dedup check
+			created := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(&events, 500)
+			if created.Error != nil {
+				return created.Error
+			}
+			result.Imported = int(created.RowsAffected)
+			result.Duplicates += len(events) - result.Imported
+		}
+		if result.Imported == 0 {
+			return nil
+		}
+		// Moves the account's ETag, so cached heatmaps aren't revalidated as current
+		if err := tx.Model(account).Update("updated_at", now).Error; err != nil {
+			return err
+		}
+		return rebuildDailyAggregates(tx, account.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Imported > 0 {
+		s.renderCache.Invalidate(account.DockerUsername)
+		if err := s.evaluateAchievements(account); err != nil {
+			slog.Error("Failed to evaluate achievements", "docker_username", account.DockerUsername, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// validateImportRow checks one row and returns it as an event to record
+func validateImportRow(row importRow, now time.Time) (models.ActivityEvent, error) {
+	event := models.ActivityEvent{
+		EventType:  models.EventType(row.EventType),
+		Repository: row.Repository,
+		Tag:        row.Tag,
+		Source:     models.EventSourceImport,
+		Count:      1,
+	}
+	switch event.EventType {
+	case models.EventTypePush, models.EventTypePull, models.EventTypeBuild:
+	default:
+		return event, errors.New("event_type must be push, pull or build")
+	}
+	if len(row.Repository) > 255 || !ingestRepositoryPattern.MatchString(row.Repository) {
+		return event, errors.New("repository must be a Docker repository name, such as app or namespace/app")
+	}
+	if row.Tag != "" && !ingestTagPattern.MatchString(row.Tag) {
+		return event, errors.New("tag must be a Docker tag name")
+	}
+
+	if len(row.Count) > 0 && string(row.Count) != "null" {
+		count, err := strconv.Atoi(strings.Trim(string(row.Count), `"`))
+		if err != nil || count < 1 || count > maxImportCount {
+			return event, fmt.Errorf("count must be a whole number from 1 to %d", maxImportCount)
+		}
+		event.Count = count
+	}
+
+	var at time.Time
+	var err error
+	switch {
+	case row.EventAt != "":
+		if at, err = time.Parse(time.RFC3339Nano, row.EventAt); err != nil {
+			return event, errors.New("event_at must be RFC 3339, such as 2024-05-01T12:00:00Z")
+		}
+	case len(row.EventDate) == len("2006-01-02"):
+		if at, err = time.Parse("2006-01-02", row.EventDate); err != nil {
+			return event, errors.New("event_date must be YYYY-MM-DD")
+		}
+	default:
+		if at, err = time.Parse(time.RFC3339Nano, row.EventDate); err != nil {
+			return event, errors.New("event_date must be YYYY-MM-DD")
+		}
+	}
+	at = at.UTC()
+	if at.After(now.Add(IngestClockSkew)) {
+		return event, errors.New("the event is in the future")
+	}
+	event.EventAt = &at
+	event.EventDate = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	return event, nil
+}
+
+// parseImportCSV reads the raw events CSV. Columns are found by their header, so other
+// columns, such as id and source, are ignored.
+func parseImportCSV(body []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.New("the CSV has no header row")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"event_type", "event_date", "repository"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the CSV has no %s column", required)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows) == MaxImportRows {
+			return nil, ErrImportSize
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := importRow{
+			EventType:  field("event_type"),
+			EventDate:  field("event_date"),
+			EventAt:    field("event_at"),
+			Repository: field("repository"),
+			Tag:        field("tag"),
+		}
+		if count := field("count"); count != "" {
+			row.Count = json.RawMessage(strconv.Quote(count))
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseImportJSON reads raw events as a /user/events page ({"events": [...]}), an array, or
+// JSON Lines such as the data export's activity_events.jsonl
+func parseImportJSON(body []byte) ([]importRow, error) {
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var rows []importRow
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return rows, nil
+	}
+
+	var page struct {
+		Events []importRow `json:"events"`
+	}
+	if err := json.Unmarshal(trimmed, &page); err == nil && page.Events != nil {
+		return page.Events, nil
+	}
+
+	var rows []importRow
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 64*1024), len(trimmed)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, ErrImportSize
+		}
+		var row importRow
+		if err := json.Unmarshal(line, &row); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", len(rows)+1, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// githubContributionDay is one day of a contributions calendar, as third-party APIs
+// ({"date", "count"}) and GitHub's GraphQL API ({"date", "contributionCount"}) give it
+type githubContributionDay struct {
+	Date              string `json:"date"`
+	Count             int    `json:"count"`
+	ContributionCount int    `json:"contributionCount"`
+}
+
+type githubContributionCalendar struct {
+	Weeks []struct {
+		ContributionDays []githubContributionDay `json:"contributionDays"`
+	} `json:"weeks"`
+}
+
+// parseImportGitHub reads a contributions calendar: {"contributions": [{"date", "count"}]},
+// GitHub's GraphQL contributionCalendar, or a GraphQL response holding one. Each day with
+// contributions becomes that many pushes to GitHubImportRepository.
+func parseImportGitHub(body []byte) ([]importRow, error) {
+	var doc struct {
+		Contributions []githubContributionDay `json:"contributions"`
+		githubContributionCalendar
+		Data struct {
+			User struct {
+				ContributionsCollection struct {
+					ContributionCalendar githubContributionCalendar `json:"contributionCalendar"`
+				} `json:"contributionsCollection"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	days := doc.Contributions
+	for _, calendar := range []githubContributionCalendar{doc.githubContributionCalendar, doc.Data.User.ContributionsCollection.ContributionCalendar} {
+		for _, week := range calendar.Weeks {
+			days = append(days, week.ContributionDays...)
+		}
+	}
+
+	var rows []importRow
+	for _, day := range days {
+		count := day.Count + day.ContributionCount
+		if count == 0 {
+			continue
+		}
+		rows = append(rows, importRow{
+			EventType:  string(models.EventTypePush),
+			EventDate:  day.Date,
+			Count:      json.RawMessage(strconv.Itoa(count)),
+			Repository: GitHubImportRepository,
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/notify"
+)
+
+// StreakMilestones are the streak lengths, in UTC days, a streak_milestone is sent for
+var StreakMilestones = []int{7, 30, 100, 365}
+
+// latestActivityDay returns the last UTC day the account has activity on, or nil if it
+// has none
+func latestActivityDay(accountID uint) *time.Time {
+	var days []time.Time
+	database.DB.Model(&models.DailyActivityAggregate{}).
+		Where("docker_account_id = ?", accountID).
+		Order("event_date DESC").
+		Limit(1).
+		Pluck("event_date", &days)
+	if len(days) == 0 {
+		return nil
+	}
+	day := days[0].UTC()
+	return &day
+}
+
+// notifySyncCompleted tells the user's integrations a sync finished
+func (s *DockerHubService) notifySyncCompleted(account *models.DockerAccount, run *models.SyncRun) {
+	s.notifications.Notify(account.UserID, notify.Message{
+		Event: notify.EventSyncCompleted,
+		Title: "Docker Hub sync finished for " + account.DockerUsername,
+		Body:  fmt.Sprintf("%d new events across %d repositories.", run.EventsCreated, run.Repositories),
+		URL:   config.AppConfig.FrontendURL + "/dashboard",
+		Extra: map[string]string{
+			"docker_username": account.DockerUsername,
+			"triggered_by":    run.TriggeredBy,
+			"events_created":  strconv.Itoa(run.EventsCreated),
+			"repositories":    strconv.Itoa(run.Repositories),
+		},
+	})
+}
+
+// notifyNewActivity tells the user when recording activity moved the account's latest
+// active day past previous, and when that brought its streak to a milestone. History found
+// for an account that had no activity before, such as by its first sync, isn't notified.
+func (s *DockerHubService) notifyNewActivity(account *models.DockerAccount, previous *time.Time) {
+	if previous == nil {
+		return
+	}
+	latest := latestActivityDay(account.ID)
+	if latest == nil || !latest.After(*previous) {
+		return
+	}
+	date := latest.Format("2006-01-02")
+
+	var count int64
+	database.DB.Model(&models.DailyActivityAggregate{}).
+		Where("docker_account_id = ? AND event_date = ?", account.ID, *latest).
+		Select("COALESCE(SUM(count), 0)").
+		Scan(&count)
+	s.notifications.Notify(account.UserID, notify.Message{
+		Event: notify.EventNewActivityDay,
+		Title: "New Docker activity for " + account.DockerUsername,
+		Body:  fmt.Sprintf("%d events on %s.", count, date),
+		URL:   config.AppConfig.FrontendURL + "/dashboard",
+		Extra: map[string]string{
+			"docker_username": account.DockerUsername,
+			"date":            date,
+			"count":           strconv.FormatInt(count, 10),
+		},
+	})
+
+	// The streak before this activity is the part of it up to the previous latest day, so
+	// a milestone passed by catching up several days at once is still sent
+	streak := activityStreakTo(account.ID, *latest)
+	before := streak - int(latest.Sub(*previous).Hours()/24)
+	milestone := 0
+	for _, m := range StreakMilestones {
+		if before < m && streak >= m {
+			milestone = m
+		}
+	}
+	if milestone == 0 {
+		return
+	}
+	s.notifications.Notify(account.UserID, notify.Message{
+		Event: notify.EventStreakMilestone,
+		Title: fmt.Sprintf("%s reached a %d-day streak", account.DockerUsername, milestone),
+		Body:  fmt.Sprintf("Docker activity every day for %d days, up to %s.", streak, date),
+		URL:   config.AppConfig.FrontendURL + "/dashboard",
+		Extra: map[string]string{
+			"docker_username": account.DockerUsername,
+			"date":            date,
+			"milestone":       strconv.Itoa(milestone),
+			"streak":          strconv.Itoa(streak),
+		},
+	})
+}
+
+// activityStreakTo counts the consecutive active UTC days ending on day, up to the
+// longest milestone
+func activityStreakTo(accountID uint, day time.Time) int {
+	longest := StreakMilestones[len(StreakMilestones)-1]
+	var days []time.Time
+	database.DB.Model(&models.DailyActivityAggregate{}).
+		Where("docker_account_id = ? AND event_date > ? AND event_date <= ?", accountID, day.AddDate(0, 0, -longest), day).
+		Distinct("event_date").
+		Order("event_date DESC").
+		Pluck("event_date", &days)
+
+	streak := 0
+	for i, d := range days {
+		if d.UTC().Format("2006-01-02") != day.AddDate(0, 0, -i).Format("2006-01-02") {
+			break
+		}
+		streak++
+	}
+	return streak
+}
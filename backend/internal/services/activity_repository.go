@@ -0,0 +1,41 @@
+package services
+
+import (
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// ActivityRepository stores what a sync finds. DatabaseActivityRepository is the real one;
+// tests can substitute one that keeps it in memory.
+type ActivityRepository interface {
+	// StoreRepositories replaces the metadata of one namespace's repositories, whose names
+	// get prefix
+	StoreRepositories(accountID uint, prefix string, repos []DockerHubRepository) error
+	// UpsertActivity records an event, or bumps the count of the existing row for the same
+	// day, repository, tag and type if it happened at another time, and reports whether a
+	// new row was created
+	UpsertActivity(accountID uint, eventType models.EventType, source models.EventSource, eventDate time.Time, repo, tag string) (bool, error)
+	// RebuildDailyAggregates recomputes an account's daily aggregates from its events
+	RebuildDailyAggregates(accountID uint) error
+}
+
+// DatabaseActivityRepository stores activity in database.DB
+type DatabaseActivityRepository struct{}
+
+func NewDatabaseActivityRepository() *DatabaseActivityRepository {
+	return &DatabaseActivityRepository{}
+}
+
+func (r *DatabaseActivityRepository) StoreRepositories(accountID uint, prefix string, repos []DockerHubRepository) error {
+	return storeRepositories(accountID, prefix, repos)
+}
+
+func (r *DatabaseActivityRepository) UpsertActivity(accountID uint, eventType models.EventType, source models.EventSource, eventDate time.Time, repo, tag string) (bool, error) {
+	return upsertActivity(database.DB, accountID, eventType, source, eventDate, repo, tag)
+}
+
+func (r *DatabaseActivityRepository) RebuildDailyAggregates(accountID uint) error {
+	return rebuildDailyAggregates(database.DB, accountID)
+}
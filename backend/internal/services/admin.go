@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultAdminPageSize = 50
+	MaxAdminPageSize     = 200
+)
+
+// Admin account filters
+const (
+	AdminAccountsFailing      = "failing"       // the last sync failed
+	AdminAccountsDeadLettered = "dead_lettered" // automatic syncs stopped after repeated failures
+	AdminAccountsDisabled     = "disabled"      // disabled by an admin
+	AdminAccountsSyncing      = "syncing"       // a sync is under way
+)
+
+var (
+	// ErrAccountDisabled is returned for syncs of an account an admin disabled
+	ErrAccountDisabled       = errors.New("docker account disabled by an administrator")
+	ErrAccountNotDisabled    = errors.New("docker account isn't disabled")
+	ErrUnknownAccountsFilter = errors.New("unknown accounts filter")
+)
+
+// AdminAccount is a Docker account and the state of its syncs, as admins see it
+type AdminAccount struct {
+	ID                    uint       `json:"id"`
+	UserID                uint       `json:"user_id"`
+	DockerUsername        string     `json:"docker_username"`
+	CreatedAt             time.Time  `json:"created_at"`
+	IsActive              bool       `json:"is_active"`
+	AutoRefresh           bool       `json:"auto_refresh"`
+	SyncInProgress        bool       `json:"sync_in_progress"`
+	LastSyncAt            *time.Time `json:"last_sync_at,omitempty"`
+	LastSyncError         string     `json:"last_sync_error,omitempty"`
+	SyncFailures          int        `json:"sync_failures"`
+	SyncDeadLetteredAt    *time.Time `json:"sync_dead_lettered_at,omitempty"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason        string     `json:"disabled_reason,omitempty"`
+	DisconnectScheduledAt *time.Time `json:"disconnect_scheduled_at,omitempty"`
+	NextSyncJobAt         *time.Time `json:"next_sync_job_at,omitempty"` // when its pending sync job runs
+}
+
+// AdminUser is a user and their Docker accounts, as admins see it
+type AdminUser struct {
+	ID              uint           `json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Provider        string         `json:"provider"`
+	Username        string         `json:"username"`
+	Name            string         `json:"name,omitempty"`
+	Email           string         `json:"email,omitempty"`
+	IsAdmin         bool           `json:"is_admin"`
+	PublicProfile   bool           `json:"public_profile"`
+	SuspendedAt     *time.Time     `json:"suspended_at,omitempty"`
+	SuspendedReason string         `json:"suspended_reason,omitempty"`
+	DockerAccounts  []AdminAccount `json:"docker_accounts"`
+}
+
+// ListAdminUsers returns a page of users, newest first, with their Docker accounts. search
+// matches the start of the username or email; suspended leaves out users who aren't.
+// before is the id of the last user of the previous page, or 0 for the first page.
+func ListAdminUsers(search string, suspended bool, before uint, limit int) ([]AdminUser, error) {
+	query := database.DB.Model(&models.User{})
+	if search = strings.TrimSpace(search); search != "" {
+		pattern := likeEscaper.Replace(strings.ToLower(search)) + "%"
+		query = query.Where("LOWER(github_username) LIKE ? OR LOWER(github_email) LIKE ?", pattern, pattern)
+	}
+	if suspended {
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var users []models.User
+	if err := query.Order("id DESC").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	var accounts []models.DockerAccount
+	if err := database.DB.Where("user_id IN ?", userIDs).Order("id").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	adminAccounts, err := adminAccounts(accounts)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[uint][]AdminAccount)
+	for _, account := range adminAccounts {
+		byUser[account.UserID] = append(byUser[account.UserID], account)
+	}
+
+	entries := make([]AdminUser, len(users))
+	for i, user := range users {
+		entries[i] = AdminUser{
+			ID:              user.ID,
+			CreatedAt:       user.CreatedAt,
+			Provider:        user.Provider,
+			Username:        user.GitHubUsername,
+			Name:            user.Name,
+			Email:           user.GitHubEmail,
+			IsAdmin:         user.IsAdmin || config.IsAdmin(user.ID),
+			PublicProfile:   user.PublicProfile,
+			SuspendedAt:     user.SuspendedAt,
+			SuspendedReason: user.SuspendedReason,
+			DockerAccounts:  byUser[user.ID],
+		}
+		if entries[i].DockerAccounts == nil {
+			entries[i].DockerAccounts = []AdminAccount{}
+		}
+	}
+	return entries, nil
+}
+
+// ListAdminAccounts returns a page of Docker accounts, newest first, optionally only those
+// matching filter (one of the AdminAccounts* filters). before is the id of the last account
+// of the previous page, or 0 for the first page.
+func ListAdminAccounts(filter string, before uint, limit int) ([]AdminAccount, error) {
+	query := database.DB.Model(&models.DockerAccount{})
+	switch filter {
+	case "":
+	case AdminAccountsFailing:
+		query = query.Where("last_sync_error <> ''")
+	case AdminAccountsDeadLettered:
+		query = query.Where("sync_dead_lettered_at IS NOT NULL")
+	case AdminAccountsDisabled:
+		query = query.Where("disabled_at IS NOT NULL")
+	case AdminAccountsSyncing:
+		query = query.Where("sync_in_progress = ?", true)
+	default:
+		return nil, ErrUnknownAccountsFilter
+	}
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var accounts []models.DockerAccount
+	if err := query.Order("id DESC").Limit(limit).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return adminAccounts(accounts)
+}
+
+// adminAccounts adds when each account's pending sync job runs
+func adminAccounts(accounts []models.DockerAccount) ([]AdminAccount, error) {
+	accountIDs := make([]uint, len(accounts))
+	for i, account := range accounts {
+		accountIDs[i] = account.ID
+	}
+	var jobs []models.SyncJob
+	if err := database.DB.Where("docker_account_id IN ? AND status = ?", accountIDs, models.SyncJobPending).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	nextJob := make(map[uint]time.Time, len(jobs))
+	for _, job := range jobs {
+		if next, ok := nextJob[job.DockerAccountID]; !ok || job.NextRunAt.Before(next) {
+			nextJob[job.DockerAccountID] = job.NextRunAt
+		}
+	}
+
+	entries := make([]AdminAccount, len(accounts))
+	for i, account := range accounts {
+		entries[i] = AdminAccount{
+			ID:                    account.ID,
+			UserID:                account.UserID,
+			DockerUsername:        account.DockerUsername,
+			CreatedAt:             account.CreatedAt,
+			IsActive:              account.IsActive,
+			AutoRefresh:           account.AutoRefresh,
+			SyncInProgress:        account.SyncInProgress,
+			LastSyncAt:            account.LastSyncAt,
+			LastSyncError:         account.LastSyncError,
+			SyncFailures:          account.SyncFailures,
+			SyncDeadLetteredAt:    account.SyncDeadLetteredAt,
+			DisabledAt:            account.DisabledAt,
+			DisabledReason:        account.DisabledReason,
+			DisconnectScheduledAt: account.DisconnectScheduledAt,
+		}
+		if next, ok := nextJob[account.ID]; ok {
+			entries[i].NextSyncJobAt = &next
+		}
+	}
+	return entries, nil
+}
+
+// ForceSync queues a sync of the account to run now, whatever its schedule. A dead-lettered
+// account gets a fresh count of failures, as when it's requeued.
+func (s *DockerHubService) ForceSync(accountID uint) (*models.DockerAccount, error) {
+	var account models.DockerAccount
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		return nil, err
+	}
+	if account.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if err := userUnavailable(account.UserID); err != nil {
+		return nil, err
+	}
+
+	if account.SyncDeadLetteredAt != nil {
+		if err := database.DB.Model(&account).Updates(map[string]interface{}{
+			"sync_failures":         0,
+			"sync_dead_lettered_at": nil,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &account, EnqueueSync(account.ID, models.SyncTriggerAdmin, s.clock.Now())
+}
+
+// DisableAccount stops the account being synced, automatically or by its owner, until it's
+// enabled again. Its pending sync jobs are failed; its heatmap stays up as of its last sync.
+func (s *DockerHubService) DisableAccount(accountID uint, reason string) (*models.DockerAccount, error) {
+	var account models.DockerAccount
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&account).Updates(map[string]interface{}{
+			"disabled_at":     now,
+			"disabled_reason": SanitizeText(reason, 255),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SyncJob{}).
+			Where("docker_account_id = ? AND status = ?", account.ID, models.SyncJobPending).
+			Updates(map[string]interface{}{"status": models.SyncJobFailed, "last_error": ErrAccountDisabled.Error()}).Error
+	})
+	return &account, err
+}
+
+// EnableAccount lets a disabled account be synced again
+func (s *DockerHubService) EnableAccount(accountID uint) (*models.DockerAccount, error) {
+	var account models.DockerAccount
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		return nil, err
+	}
+	if account.DisabledAt == nil {
+		return nil, ErrAccountNotDisabled
+	}
+
+	err := database.DB.Model(&account).Updates(map[string]interface{}{
+		"disabled_at":     nil,
+		"disabled_reason": "",
+	}).Error
+	return &account, err
+}
+
+// SetUserAdmin grants or takes away the user's admin flag. Users listed in ADMIN_USER_IDS
+// stay admins either way.
+func SetUserAdmin(userID uint, isAdmin bool) (*models.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(&user).Update("is_admin", isAdmin).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// AgentProtocolVersion is the version of the agent report format. Agents send it so the
+// format can change without breaking agents already deployed.
+const AgentProtocolVersion = 1
+
+var (
+	ErrAgentProtocol = fmt.Errorf("unsupported agent protocol version; this server speaks version %d", AgentProtocolVersion)
+	ErrAgentNodeID   = errors.New("node_id must be a hostname or node name")
+)
+
+// agentNodePattern is a node name, such as a hostname or Kubernetes node name
+var agentNodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,252}$`)
+
+// AgentReport is a batch of image events an agent saw on one node, from the Docker
+// daemon's event stream or the kubelet's events
+type AgentReport struct {
+	Protocol int          `json:"protocol"`
+	NodeID   string       `json:"node_id"`
+	Agent    string       `json:"agent"` // Agent name and version, for troubleshooting
+	Events   []AgentEvent `json:"events"`
+}
+
+// AgentEvent is one image pulled or pushed on the node
+type AgentEvent struct {
+	Timestamp string `json:"timestamp"` // RFC 3339
+	Action    string `json:"action"`    // push or pull
+	// Image is the reference as the runtime reported it, such as nginx:1.25,
+	// alice/app@sha256:... or registry.example.com/team/app:v2
+	Image string `json:"image"`
+}
+
+// IngestAgentReport records an agent's batch for the key owner's account. Like
+// IngestEvents, a batch with an invalid event is rejected whole. Events are told apart by
+// node, so the same image pulled on two nodes at once counts twice, while an agent
+// resending a batch after a restart doesn't count it again.
+func (s *DockerHubService) IngestAgentReport(key *models.IngestKey, report AgentReport) (*IngestResult, error) {
+	if report.Protocol != AgentProtocolVersion {
+		return nil, ErrAgentProtocol
+	}
+	if !agentNodePattern.MatchString(report.NodeID) {
+		return nil, ErrAgentNodeID
+	}
+	if len(report.Events) == 0 || len(report.Events) > MaxIngestBatch {
+		return nil, ErrIngestBatchSize
+	}
+
+	account, err := s.GetDockerAccount(key.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	records := make([]ingestRecord, len(report.Events))
+	var invalid []IngestEventError
+	for i, event := range report.Events {
+		var eventType models.EventType
+		switch event.Action {
+		case "pull":
+			eventType = models.EventTypePull
+		case "push":
+			eventType = models.EventTypePush
+		default:
+			invalid = append(invalid, IngestEventError{Index: i, Error: "action must be push or pull"})
+			continue
+		}
+		repository, tag, err := imageRepository(event.Image, account.DockerUsername)
+		if err != nil {
+			invalid = append(invalid, IngestEventError{Index: i, Error: err.Error()})
+			continue
+		}
+		at, err := validateIngestEvent(IngestEvent{Timestamp: event.Timestamp, Repository: repository, Tag: tag, EventType: string(eventType)}, now)
+		if err != nil {
+			invalid = append(invalid, IngestEventError{Index: i, Error: err.Error()})
+			continue
+		}
+		records[i] = ingestRecord{
+			at:          at,
+			eventType:   eventType,
+			repository:  repository,
+			tag:         tag,
+			fingerprint: ingestFingerprint(repository, tag, string(eventType), report.NodeID+"\x00"+at.Format(time.RFC3339Nano)),
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, &IngestValidationError{Events: invalid}
+	}
+	return s.recordIngested(key, account, records, now)
+}
+
+// dockerHubRegistries are the registry hosts that mean Docker Hub in an image reference
+var dockerHubRegistries = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+// imageRepository maps an image reference to the repository and tag activity is recorded
+// under. Repositories in the account's own namespace drop it, as synced ones do; official
+// images keep "library/". Images from other registries drop the registry and keep at most
+// the last two path components. A reference by digest alone has no tag.
+func imageRepository(image, ownNamespace string) (string, string, error) {
+	invalid := errors.New("image must be an image reference, such as nginx:1.25 or namespace/app:tag")
+
+	name := strings.TrimSpace(image)
+	digest := false
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], true
+	}
+
+	tag := ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	} else if !digest {
+		tag = "latest"
+	}
+
+	parts := strings.Split(name, "/")
+	hub := true
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		hub = dockerHubRegistries[parts[0]]
+		parts = parts[1:]
+		if !hub && len(parts) > 2 {
+			parts = parts[len(parts)-2:]
+		}
+	}
+	if hub && len(parts) == 1 {
+		parts = []string{"library", parts[0]}
+	}
+	if len(parts) > 2 {
+		return "", "", invalid
+	}
+	if strings.EqualFold(parts[0], ownNamespace) {
+		parts = parts[1:]
+	}
+
+	repository := strings.Join(parts, "/")
+	if !ingestRepositoryPattern.MatchString(repository) || (tag != "" && !ingestTagPattern.MatchString(tag)) {
+		return "", "", invalid
+	}
+	return repository, tag, nil
+}
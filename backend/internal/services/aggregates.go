@@ -0,0 +1,58 @@
+package services
+
+import (
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// rebuildDailyAggregates recomputes an account's daily aggregates from its events
+func rebuildDailyAggregates(db *gorm.DB, accountID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.DailyActivityAggregate{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO daily_activity_aggregates (docker_account_id, event_date, event_type, count, updated_at)
+			SELECT docker_account_id, event_date, event_type, SUM(count), NOW()
+			FROM activity_events
+			WHERE docker_account_id = ? AND deleted_at IS NULL
+			GROUP BY docker_account_id, event_date, event_type
+		`, accountID).Error
+	})
+}
+
+// usesDailyAggregates reports whether a summary can be read from the aggregate table.
+// Aggregates are per UTC day and type only, so other timezones, confidence and tag
+// filtering, and repository breakdowns still need the individual events.
+func usesDailyAggregates(filter ActivityFilter, loc *time.Location) bool {
+	return loc == time.UTC && filter.MinConfidence == 0 && len(filter.Tags) == 0 && !filter.RepoBreakdown
+}
+
+// loadDailyAggregates returns the accounts' aggregates between two UTC midnights (inclusive)
+// as one pseudo-event per account, day and type, ready for summarizeEvents
+func loadDailyAggregates(accountIDs []uint, startDate, endDate time.Time, filter ActivityFilter) ([]models.ActivityEvent, error) {
+	query := database.DB.Where("docker_account_id IN ? AND event_date >= ? AND event_date <= ?", accountIDs, startDate, endDate)
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+
+	var rows []models.DailyActivityAggregate
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]models.ActivityEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, models.ActivityEvent{
+			DockerAccountID: row.DockerAccountID,
+			EventType:       row.EventType,
+			EventDate:       row.EventDate,
+			Count:           row.Count,
+		})
+	}
+	return events, nil
+}
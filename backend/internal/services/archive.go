@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// archiveBatchSize bounds how many events are moved per transaction, so archiving a large
+// backlog doesn't hold one long transaction
+const archiveBatchSize = 5000
+
+var ErrNoArchivedActivity = errors.New("no archived activity in that range")
+
+// ArchiveActivity moves events dated before cutoff out of activity_events into compressed
+// monthly chunks in activity_archives and returns how many were moved. Events already
+// soft-deleted by an earlier cleanup are archived too, so the table actually shrinks.
+func ArchiveActivity(cutoff time.Time) (int, error) {
+	var accountIDs []uint
+	err := database.DB.Unscoped().Model(&models.ActivityEvent{}).
+		Where("event_date < ?", cutoff).Distinct().Pluck("docker_account_id", &accountIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, accountID := range accountIDs {
+		for {
+			n, err := archiveBatch(accountID, cutoff)
+			moved += n
+			if err != nil {
+				return moved, fmt.Errorf("account %d: %w", accountID, err)
+			}
+			if n < archiveBatchSize {
+				break
+			}
+		}
+	}
+	return moved, nil
+}
+
+// archiveBatch archives up to archiveBatchSize of an account's oldest events, writing one
+// chunk per month and deleting the events in the same transaction
+func archiveBatch(accountID uint, cutoff time.Time) (int, error) {
+	moved := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var events []models.ActivityEvent
+		err := tx.Unscoped().Where("docker_account_id = ? AND event_date < ?", accountID, cutoff).
+			Order("event_date, id").Limit(archiveBatchSize).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		var months []time.Time
+		byMonth := make(map[time.Time][]models.ActivityEvent)
+		ids := make([]uint, 0, len(events))
+		for _, event := range events {
+			date := event.EventDate.UTC()
+			month := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+			if _, ok := byMonth[month]; !ok {
+				months = append(months, month)
+			}
+			byMonth[month] = append(byMonth[month], event)
+			ids = append(ids, event.ID)
+		}
+
+		for _, month := range months {
+			data, err := compressEvents(byMonth[month])
+			if err != nil {
+				return err
+			}
+			chunk := models.ActivityArchive{
+				CreatedAt:       time.Now(),
+				DockerAccountID: accountID,
+				Month:           month,
+				EventCount:      len(byMonth[month]),
+				Data:            data,
+			}
+			if err := tx.Create(&chunk).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.ActivityEvent{}).Error; err != nil {
+			return err
+		}
+		moved = len(events)
+		return nil
+	})
+	return moved, err
+}
+
+// ArchivedMonth summarizes one account's archived events in one month
+type ArchivedMonth struct {
+	DockerAccountID uint      `json:"docker_account_id"`
+	Month           time.Time `json:"month"`
+	Chunks          int       `json:"chunks"`
+	Events          int       `json:"events"`
+}
+
+// ListArchivedActivity returns the archived months of one account, or of every account
+// when accountID is 0, oldest first
+func ListArchivedActivity(accountID uint) ([]ArchivedMonth, error) {
+	query := database.DB.Model(&models.ActivityArchive{}).
+		Select("docker_account_id, month, COUNT(*) AS chunks, SUM(event_count) AS events").
+		Group("docker_account_id, month").Order("docker_account_id, month")
+	if accountID != 0 {
+		query = query.Where("docker_account_id = ?", accountID)
+	}
+
+	var months []ArchivedMonth
+	err := query.Scan(&months).Error
+	return months, err
+}
+
+// RestoreActivity moves an account's archived events for the months from through to
+// (inclusive, any day within each month) back into activity_events, rebuilds its daily
+// aggregates and returns how many events were restored. Restored events are live again;
+// unless ACTIVITY_RETENTION_DAYS now covers them, the next cleanup archives them again.
+func (s *DockerHubService) RestoreActivity(accountID uint, from, to time.Time) (int, error) {
+	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	restored := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var chunks []models.ActivityArchive
+		err := tx.Where("docker_account_id = ? AND month >= ? AND month <= ?", accountID, from, to).
+			Order("month, id").Find(&chunks).Error
+		if err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return ErrNoArchivedActivity
+		}
+
+		for _, chunk := range chunks {
+			events, err := decompressEvents(chunk.Data)
+			if err != nil {
+				return fmt.Errorf("archive chunk %d: %w", chunk.ID, err)
+			}
+			// Keep the archived timestamps, and skip events that were recorded again since
+			result := tx.Session(&gorm.Session{SkipHooks: true}).Omit(clause.Associations).
+				Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(events, 500)
+			if result.Error != nil {
+				return result.Error
+			}
+			restored += int(result.RowsAffected)
+			if err := tx.Delete(&chunk).Error; err != nil {
+				return err
+			}
+		}
+		return rebuildDailyAggregates(tx, accountID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var account models.DockerAccount
+	if err := database.DB.First(&account, accountID).Error; err == nil {
+		s.renderCache.Invalidate(account.DockerUsername)
+	}
+	return restored, nil
+}
+
+func compressEvents(events []models.ActivityEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressEvents(data []byte) ([]models.ActivityEvent, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var events []models.ActivityEvent
+	dec := json.NewDecoder(zr)
+	for {
+		var event models.ActivityEvent
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
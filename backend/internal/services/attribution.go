@@ -0,0 +1,58 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"docker-heatmap/internal/config"
+)
+
+// Attribution modes for ATTRIBUTION_MODE
+const (
+	AttributionRequired = "required" // Always rendered
+	AttributionOptional = "optional" // Rendered unless the owner opted out
+	AttributionNone     = "none"     // Never rendered (self-hosted default)
+)
+
+// AttributionFor returns the credit line to render for an account, or "" when none is due.
+// This is the single place branding policy is decided for every SVG the service renders.
+func (s *DockerHubService) AttributionFor(dockerUsername string) string {
+	switch config.AppConfig.AttributionMode {
+	case AttributionRequired:
+		return config.AppConfig.AttributionText
+	case AttributionOptional:
+		if owner, err := s.GetAccountOwner(dockerUsername); err == nil && owner.HideAttribution {
+			return ""
+		}
+		return config.AppConfig.AttributionText
+	default:
+		return ""
+	}
+}
+
+// applyAttribution stamps the deployment's credit line into the bottom-right corner of a
+// rendered SVG. Every Generate* render path finishes here.
+func (s *HeatmapService) applyAttribution(dockerUsername string, svg []byte) []byte {
+	return stampAttribution(svg, s.dockerService.AttributionFor(dockerUsername))
+}
+
+func stampAttribution(svg []byte, text string) []byte {
+	if text == "" {
+		return svg
+	}
+
+	idx := bytes.LastIndex(svg, []byte("</svg>"))
+	if idx < 0 {
+		return svg
+	}
+
+	// Percentages resolve against the viewBox, so this works for every chart size
+	credit := fmt.Sprintf(`  <text x="100%%" y="100%%" dx="-6" dy="-3" text-anchor="end" font-size="7" fill="#8b949e" fill-opacity="0.8" font-family="%s">%s</text>
+`, EscapeSVGText(defaultFontFamily), EscapeSVGText(text))
+
+	out := make([]byte, 0, len(svg)+len(credit))
+	out = append(out, svg[:idx]...)
+	out = append(out, credit...)
+	out = append(out, svg[idx:]...)
+	return out
+}
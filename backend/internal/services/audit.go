@@ -0,0 +1,70 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+const (
+	// AuditRetentionDays is how long audit log entries are kept before cleanup
+	AuditRetentionDays = 365
+
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+
+	// recentSecurityEvents and recentSecurityWindow bound the security events shown on
+	// the profile
+	recentSecurityEvents = 5
+	recentSecurityWindow = 30 * 24 * time.Hour
+)
+
+// AuditService records and lists account-level actions
+type AuditService struct{}
+
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// Record adds an entry to the user's audit log. ipAddress and userAgent are empty for
+// actions the app takes by itself. A failure is logged rather than returned, so it never
+// fails the action being recorded.
+func (s *AuditService) Record(userID uint, action, detail, ipAddress, userAgent string) {
+	entry := &models.AuditLog{
+		UserID:    userID,
+		Action:    action,
+		Detail:    SanitizeText(detail, 255),
+		IPAddress: ipAddress,
+		UserAgent: SanitizeText(userAgent, 255),
+	}
+	if err := database.DB.Create(entry).Error; err != nil {
+		slog.Error("Failed to record audit log entry", "action", action, "user_id", userID, "error", err)
+	}
+}
+
+// List returns a page of the user's audit log, newest first. before is the id of the last
+// entry of the previous page, or 0 for the first page.
+func (s *AuditService) List(userID, before uint, limit int) ([]models.AuditLog, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// RecentSecurityEvents returns the user's latest sign-ins, credential changes and
+// rejected tokens from the last 30 days
+func (s *AuditService) RecentSecurityEvents(userID uint) ([]models.AuditLog, error) {
+	entries := []models.AuditLog{}
+	err := database.DB.
+		Where("user_id = ? AND action IN ? AND created_at > ?", userID, models.SecurityAuditActions, time.Now().Add(-recentSecurityWindow)).
+		Order("id DESC").
+		Limit(recentSecurityEvents).
+		Find(&entries).Error
+	return entries, err
+}
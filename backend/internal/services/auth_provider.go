@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrOAuthFailed is returned when a sign-in provider rejects the code or its user API fails
+var ErrOAuthFailed = errors.New("sign-in provider authentication failed")
+
+// OAuthIdentity is who signed in, as a sign-in provider describes them
+type OAuthIdentity struct {
+	Provider string
+	ID       string // The provider's stable user id
+	Username string
+	Email    string
+	// EmailVerified is whether the provider confirmed the user owns Email; only verified
+	// emails link a login to an existing account
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
+
+// AuthProvider is a sign-in provider using the OAuth authorization code flow
+type AuthProvider interface {
+	// Name identifies the provider in routes and on users, such as "github"
+	Name() string
+	// AuthURL is where to send the user to sign in
+	AuthURL(state string) string
+	// Identify exchanges the code from the provider's redirect for who signed in
+	Identify(ctx context.Context, code string) (*OAuthIdentity, error)
+}
+
+// EnabledAuthProviders returns the OAuth sign-in providers this deployment offers.
+// GitHub is first, and left out only when it isn't configured and email and password
+// sign-in is enabled instead; GitLab and Google are added when their client ids are
+// configured.
+func EnabledAuthProviders() []AuthProvider {
+	var providers []AuthProvider
+	if config.AppConfig.GitHubClientID != "" || !config.AppConfig.AuthPasswordEnabled {
+		providers = append(providers, NewGitHubAuthService())
+	}
+	if config.AppConfig.GitLabClientID != "" {
+		providers = append(providers, NewGitLabAuthService())
+	}
+	if config.AppConfig.GoogleClientID != "" {
+		providers = append(providers, NewGoogleAuthService())
+	}
+	return providers
+}
+
+// ProfileURL links to the user's profile on the provider their account was created
+// with, or is empty when it has no public profiles
+func ProfileURL(user *models.User) string {
+	switch user.Provider {
+	case "gitlab":
+		return config.AppConfig.GitLabURL + "/" + user.GitHubUsername
+	case "google", "password":
+		return ""
+	default:
+		return "https://github.com/" + user.GitHubUsername
+	}
+}
+
+// FindOrCreateUser returns the user for a provider login. A login seen before returns
+// its user. A new login with a verified email is linked to the account that has the
+// same verified email, so one person signing in with GitHub and Google gets one
+// account. Otherwise a new account is created.
+func FindOrCreateUser(identity *OAuthIdentity) (*models.User, error) {
+	user, err := findUserByIdentity(identity)
+	if err == nil {
+		// Profile details follow the provider the account was created with
+		if user.Provider == identity.Provider {
+			user.GitHubUsername = identity.Username
+			user.GitHubEmail = identity.Email
+			user.EmailVerified = identity.EmailVerified
+			user.AvatarURL = identity.AvatarURL
+			user.Name = identity.Name
+			database.DB.Save(user)
+		}
+		return user, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if identity.EmailVerified && identity.Email != "" {
+		query := database.DB.Where("LOWER(github_email) = ? AND email_verified = ?", strings.ToLower(identity.Email), true)
+		if identity.Provider == "github" {
+			// An account holds one GitHub login
+			query = query.Where("github_id IS NULL")
+		}
+		var existing models.User
+		err := query.Order("id").First(&existing).Error
+		if err == nil {
+			if err := linkIdentity(database.DB, &existing, identity); err != nil {
+				return nil, err
+			}
+			return &existing, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+
+	user = &models.User{
+		Provider:       identity.Provider,
+		GitHubUsername: identity.Username,
+		GitHubEmail:    identity.Email,
+		EmailVerified:  identity.EmailVerified,
+		AvatarURL:      identity.AvatarURL,
+		Name:           identity.Name,
+		PublicProfile:  true,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return linkIdentity(tx, user, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// findUserByIdentity looks a login up by GitHub id, or by its identity record for
+// other providers
+func findUserByIdentity(identity *OAuthIdentity) (*models.User, error) {
+	var user models.User
+	if identity.Provider == "github" {
+		githubID, err := strconv.ParseInt(identity.ID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if err := database.DB.Where("github_id = ?", githubID).First(&user).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	var link models.UserIdentity
+	err := database.DB.Where("provider = ? AND provider_user_id = ?", identity.Provider, identity.ID).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.First(&user, link.UserID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// linkIdentity records that a provider login belongs to user
+func linkIdentity(tx *gorm.DB, user *models.User, identity *OAuthIdentity) error {
+	if identity.Provider == "github" {
+		githubID, err := strconv.ParseInt(identity.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		user.GitHubID = &githubID
+		return tx.Model(user).Update("github_id", githubID).Error
+	}
+
+	return tx.Create(&models.UserIdentity{
+		UserID:         user.ID,
+		Provider:       identity.Provider,
+		ProviderUserID: identity.ID,
+		Username:       identity.Username,
+		Email:          identity.Email,
+	}).Error
+}
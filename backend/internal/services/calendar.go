@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// Milestone kinds
+const (
+	MilestoneStreak = "streak" // A new longest run of active days
+	MilestonePushes = "pushes" // The account's Nth push
+)
+
+// minRecordStreak is the shortest run of active days worth marking as a record
+const minRecordStreak = 3
+
+// pushMilestones are the push counts marked on the calendar
+var pushMilestones = []int{1, 100, 500, 1000, 5000, 10000}
+
+// Milestone is a notable point in an account's history
+type Milestone struct {
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	// Value is the streak's length in days, or the push count reached
+	Value int `json:"value"`
+	// Start and End are calendar days (inclusive); a push milestone starts and ends on one day
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// GetMilestones finds an account's record streaks and push milestones, oldest first, with
+// days bucketed in loc. They are counted over all retained activity.
+func (s *DockerHubService) GetMilestones(dockerUsername string, loc *time.Location) ([]Milestone, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []models.ActivityEvent
+	if err := database.DB.Select("event_type", "event_date", "event_at", "count").
+		Where("docker_account_id = ?", account.ID).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return []Milestone{}, nil
+	}
+
+	totals := make(map[string]int)
+	pushes := make(map[string]int)
+	first := ""
+	for i := range events {
+		date := events[i].LocalDate(loc)
+		totals[date] += events[i].Count
+		if events[i].EventType == models.EventTypePush {
+			pushes[date] += events[i].Count
+		}
+		if first == "" || date < first {
+			first = date
+		}
+	}
+
+	milestones := []Milestone{}
+	start, _ := time.ParseInLocation("2006-01-02", first, loc)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var runStart string
+	run, best, pushed, next := 0, 0, 0, 0
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+
+		if totals[date] > 0 {
+			if run == 0 {
+				runStart = date
+			}
+			run++
+		}
+		// A run is a record once it ends (or reaches today) longer than every earlier one
+		ended := totals[date] == 0 || d.Equal(today)
+		if ended && run > best {
+			best = run
+			if run >= minRecordStreak {
+				end := date
+				if totals[date] == 0 {
+					end = d.AddDate(0, 0, -1).Format("2006-01-02")
+				}
+				milestones = append(milestones, Milestone{
+					Kind:  MilestoneStreak,
+					Title: fmt.Sprintf("New longest streak: %d days", run),
+					Value: run,
+					Start: runStart,
+					End:   end,
+				})
+			}
+		}
+		if totals[date] == 0 {
+			run = 0
+		}
+
+		pushed += pushes[date]
+		for next < len(pushMilestones) && pushed >= pushMilestones[next] {
+			count := pushMilestones[next]
+			title := ordinal(count) + " push"
+			if count == 1 {
+				title = "First push"
+			}
+			milestones = append(milestones, Milestone{Kind: MilestonePushes, Title: title, Value: count, Start: date, End: date})
+			next++
+		}
+	}
+	return milestones, nil
+}
+
+// ordinal writes n as 1st, 2nd, 3rd, 100th
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// ActivityCalendarOptions describes the calendar a feed is published as
+type ActivityCalendarOptions struct {
+	Username   string
+	ProfileURL string
+	// Domain scopes event UIDs, so they stay unique across deployments
+	Domain string
+}
+
+// RenderActivityCalendar renders active days and milestones as an iCalendar (RFC 5545)
+// feed of all-day events. Events are marked free so they don't block time.
+func RenderActivityCalendar(days []DayDetail, milestones []Milestone, opts ActivityCalendarOptions) []byte {
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	var b strings.Builder
+	line := func(name, value string) {
+		writeICalLine(&b, name+":"+value)
+	}
+	event := func(uid, start, end, summary, description, category string) {
+		line("BEGIN", "VEVENT")
+		line("UID", uid+"@"+opts.Domain)
+		line("DTSTAMP", stamp)
+		line("DTSTART;VALUE=DATE", icalDate(start, 0))
+		line("DTEND;VALUE=DATE", icalDate(end, 1))
+		line("SUMMARY", icalEscape(summary))
+		if description != "" {
+			line("DESCRIPTION", icalEscape(description))
+		}
+		line("CATEGORIES", category)
+		line("URL", opts.ProfileURL)
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Docker Heatmap//Activity Calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", icalEscape(opts.Username+"'s Docker activity"))
+	line("REFRESH-INTERVAL;VALUE=DURATION", "PT6H")
+	line("X-PUBLISHED-TTL", "PT6H")
+
+	for _, day := range days {
+		var counts []string
+		if day.Pushes > 0 {
+			counts = append(counts, pluralize(day.Pushes, "push", "pushes"))
+		}
+		if day.Pulls > 0 {
+			counts = append(counts, pluralize(day.Pulls, "pull", "pulls"))
+		}
+		if day.Builds > 0 {
+			counts = append(counts, pluralize(day.Builds, "build", "builds"))
+		}
+		summary := "Docker: " + strings.Join(counts, ", ")
+		event(opts.Username+"-"+day.Date, day.Date, day.Date, summary, strings.Join(daySummaries(day), "\n"), "Docker activity")
+	}
+
+	for _, m := range milestones {
+		// A streak keeps its UID as it grows, so calendars update the event in place
+		uid := fmt.Sprintf("%s-%s-%s", opts.Username, m.Kind, m.Start)
+		if m.Kind == MilestonePushes {
+			uid = fmt.Sprintf("%s-%s-%d", opts.Username, m.Kind, m.Value)
+		}
+		event(uid, m.Start, m.End, "🏆 "+m.Title, "", "Docker milestone")
+	}
+
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// icalDate formats a YYYY-MM-DD day, plus offset days, as an iCalendar DATE
+func icalDate(date string, offset int) string {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return strings.ReplaceAll(date, "-", "")
+	}
+	return day.AddDate(0, 0, offset).Format("20060102")
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icalEscape escapes a TEXT value
+func icalEscape(value string) string {
+	return icalEscaper.Replace(value)
+}
+
+// writeICalLine writes a content line folded at 75 octets, without splitting a character
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines lose an octet to the leading space
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// MonthBar is a single bar of the monthly chart
+type MonthBar struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+	Color  string
+	Label  string
+	Count  int
+}
+
+// MonthlyChartData represents the data needed to render the monthly bar chart
+type MonthlyChartData struct {
+	Width     int
+	Height    int
+	Bars      []MonthBar
+	Theme     Theme
+	Font      fontStyle
+	Title     string
+	HideTotal bool
+	LabelY    int
+}
+
+// SparklineData represents the data needed to render the weekly sparkline
+type SparklineData struct {
+	Width     int
+	Height    int
+	Line      string
+	Area      string
+	Theme     Theme
+	Font      fontStyle
+	Title     string
+	HideTotal bool
+	TitleY    int
+}
+
+const monthlyChartTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="xMidYMid meet" xmlns="http://www.w3.org/2000/svg">
+  <style>
+    {{.Font.Face}}
+    .label { font-size: {{subtract .Font.Size 1}}px; fill: {{.Theme.TextColor}}; font-family: {{.Font.Family}}; }
+    .count { font-size: {{subtract .Font.Size 2}}px; fill: {{.Theme.TextColor}}; font-family: {{.Font.Family}}; }
+    .title { font-size: {{add .Font.Size 1}}px; fill: {{.Theme.TextColor}}; font-family: {{.Font.Family}}; font-weight: 600; }
+  </style>
+  <rect width="{{.Width}}" height="{{.Height}}" fill="{{.Theme.BgColor}}" rx="6"/>
+  {{if not .HideTotal}}
+  <text x="20" y="18" class="title">{{.Title}}</text>
+  {{end}}
+  {{range .Bars}}
+  <rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}" rx="2">
+    <title>{{.Label}}: {{.Count}} activities</title>
+  </rect>
+  {{if .Count}}<text x="{{.X}}" y="{{subtract .Y 3}}" class="count">{{.Count}}</text>{{end}}
+  <text x="{{.X}}" y="{{$.LabelY}}" class="label">{{.Label}}</text>
+  {{end}}
+</svg>`
+
+const sparklineTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="xMidYMid meet" xmlns="http://www.w3.org/2000/svg">
+  <style>
+    {{.Font.Face}}
+    .title { font-size: {{.Font.Size}}px; fill: {{.Theme.TextColor}}; font-family: {{.Font.Family}}; font-weight: 600; }
+  </style>
+  <rect width="{{.Width}}" height="{{.Height}}" fill="{{.Theme.BgColor}}" rx="4"/>
+  <polygon points="{{.Area}}" fill="{{index .Theme.Colors 2}}" fill-opacity="0.25"/>
+  <polyline points="{{.Line}}" fill="none" stroke="{{index .Theme.Colors 4}}" stroke-width="1.5" stroke-linejoin="round" stroke-linecap="round"/>
+  {{if not .HideTotal}}
+  <text x="6" y="{{.TitleY}}" class="title">{{.Title}}</text>
+  {{end}}
+</svg>`
+
+// PunchcardDot is a single weekday/hour bubble of the punchcard
+type PunchcardDot struct {
+	X      int
+	Y      int
+	Radius float64
+	Color  string
+	Day    string
+	Hour   int
+	Count  int
+}
+
+// PunchcardData represents the data needed to render the punchcard
+type PunchcardData struct {
+	Width      int
+	Height     int
+	Dots       []PunchcardDot
+	DayLabels  []DayLabel
+	HourLabels []MonthLabel
+	Theme      Theme
+	Font       fontStyle
+	Title      string
+	HideTotal  bool
+	HideLabels bool
+	FooterY    int
+}
+
+const punchcardTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="xMidYMid meet" xmlns="http://www.w3.org/2000/svg">
+  <style>
+    {{.Font.Face}}
+    .label { font-size: {{subtract .Font.Size 1}}px; fill: {{.Theme.TextColor}}; font-family: {{.Font.Family}}; }
+    .title { font-size: {{add .Font.Size 1}}px; fill: {{.Theme.TextColor}}; font-family: {{.Font.Family}}; font-weight: 600; }
+  </style>
+  <rect width="{{.Width}}" height="{{.Height}}" fill="{{.Theme.BgColor}}" rx="6"/>
+  {{if not .HideLabels}}
+  {{range .DayLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="label">{{.Label}}</text>
+  {{end}}
+  {{range .HourLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="label" text-anchor="middle">{{.Label}}</text>
+  {{end}}
+  {{end}}
+  {{range .Dots}}
+  <circle cx="{{.X}}" cy="{{.Y}}" r="{{.Radius}}" fill="{{.Color}}">
+    <title>{{.Day}} {{.Hour}}:00: {{.Count}} activities</title>
+  </circle>
+  {{end}}
+  {{if not .HideTotal}}
+  <text x="10" y="{{.FooterY}}" class="title">{{.Title}}</text>
+  {{end}}
+</svg>`
+
+var chartFuncs = template.FuncMap{
+	"add":      func(a, b int) int { return a + b },
+	"subtract": func(a, b int) int { return a - b },
+	"multiply": func(a, b int) int { return a * b },
+}
+
+// GenerateMonthlyChartSVG renders a 12-month bar chart of activity
+func (s *HeatmapService) GenerateMonthlyChartSVG(dockerUsername string, opts SVGOptions) ([]byte, error) {
+	normalizeRenderOptions(&opts)
+
+	loc := s.dockerService.ResolveLocation(dockerUsername, opts.Timezone)
+	now := s.clock.Now().In(loc)
+	firstMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, -11, 0)
+
+	activities, err := s.dockerService.GetActivitySummaryRange(dockerUsername, firstMonth, now, ActivityFilter{
+		Location:      loc,
+		EventTypes:    opts.EventTypes,
+		Weights:       opts.EventWeights,
+		MinConfidence: opts.MinConfidence,
+		Tags:          opts.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, 12)
+	total := 0
+	for _, a := range activities {
+		date, err := time.ParseInLocation("2006-01-02", a.Date, loc)
+		if err != nil {
+			continue
+		}
+		idx := (date.Year()-firstMonth.Year())*12 + int(date.Month()) - int(firstMonth.Month())
+		if idx >= 0 && idx < 12 {
+			counts[idx] += a.TotalCount
+			total += a.TotalCount
+		}
+	}
+
+	title := opts.CustomTitle
+	if title == "" {
+		title = fmt.Sprintf("@%s monthly Docker activity • %d total", dockerUsername, total)
+	}
+
+	svg, err := renderMonthlyChart(firstMonth, counts, resolveChartTheme(opts), resolveFont(opts.Font, opts.FontSize), title, opts.HideTotal)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyAttribution(dockerUsername, svg), nil
+}
+
+func renderMonthlyChart(firstMonth time.Time, counts []int, theme Theme, font fontStyle, title string, hideTotal bool) ([]byte, error) {
+	const (
+		padding    = 20
+		barWidth   = 22
+		barGap     = 8
+		chartTop   = 40
+		chartDepth = 80
+	)
+
+	maxCount := 0
+	for _, c := range counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+
+	baseline := chartTop + chartDepth
+	bars := make([]MonthBar, 0, len(counts))
+	for i, count := range counts {
+		height := 2
+		if maxCount > 0 && count > 0 {
+			height = int(math.Max(2, math.Round(float64(count)/float64(maxCount)*chartDepth)))
+		}
+		bars = append(bars, MonthBar{
+			X:      padding + i*(barWidth+barGap),
+			Y:      baseline - height,
+			Width:  barWidth,
+			Height: height,
+			Color:  theme.Colors[calculateLevel(float64(count), float64(maxCount))],
+			Label:  firstMonth.AddDate(0, i, 0).Format("Jan"),
+			Count:  count,
+		})
+	}
+
+	data := MonthlyChartData{
+		Width:     2*padding + len(counts)*(barWidth+barGap) - barGap,
+		Height:    baseline + 28,
+		Bars:      bars,
+		Theme:     theme,
+		Font:      font,
+		Title:     title,
+		HideTotal: hideTotal,
+		LabelY:    baseline + 14,
+	}
+
+	return executeChartTemplate("monthly", monthlyChartTemplate, data)
+}
+
+// GenerateSparklineSVG renders a compact 52-week activity trend line
+func (s *HeatmapService) GenerateSparklineSVG(dockerUsername string, opts SVGOptions) ([]byte, error) {
+	normalizeRenderOptions(&opts)
+
+	loc := s.dockerService.ResolveLocation(dockerUsername, opts.Timezone)
+	now := s.clock.Now().In(loc)
+	start := now.AddDate(0, 0, -52*7+1)
+
+	activities, err := s.dockerService.GetActivitySummaryRange(dockerUsername, start, now, ActivityFilter{
+		Location:      loc,
+		EventTypes:    opts.EventTypes,
+		Weights:       opts.EventWeights,
+		MinConfidence: opts.MinConfidence,
+		Tags:          opts.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := weeklyTotals(activities, 52)
+	total := 0
+	for _, w := range weeks {
+		total += w
+	}
+
+	title := opts.CustomTitle
+	if title == "" {
+		title = fmt.Sprintf("@%s • %d in 52 weeks", dockerUsername, total)
+	}
+
+	svg, err := renderSparkline(weeks, resolveChartTheme(opts), resolveFont(opts.Font, opts.FontSize), title, opts.HideTotal)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyAttribution(dockerUsername, svg), nil
+}
+
+// weeklyTotals sums daily summaries into consecutive 7-day buckets ending with the last day
+func weeklyTotals(activities []models.ActivitySummary, numWeeks int) []int {
+	weeks := make([]int, numWeeks)
+	for i := range activities {
+		// Walk backwards so the final bucket always ends today
+		fromEnd := len(activities) - 1 - i
+		idx := numWeeks - 1 - fromEnd/7
+		if idx >= 0 {
+			weeks[idx] += activities[i].TotalCount
+		}
+	}
+	return weeks
+}
+
+func renderSparkline(values []int, theme Theme, font fontStyle, title string, hideTotal bool) ([]byte, error) {
+	const (
+		width   = 260
+		padding = 4
+	)
+
+	height := 40
+	top := padding
+	if !hideTotal {
+		top += 14
+		height += 14
+	}
+	bottom := height - padding
+
+	maxValue := 0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	step := float64(width-2*padding) / float64(len(values)-1)
+	points := make([]string, 0, len(values))
+	for i, v := range values {
+		y := float64(bottom)
+		if maxValue > 0 {
+			y = float64(bottom) - float64(v)/float64(maxValue)*float64(bottom-top)
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", float64(padding)+float64(i)*step, y))
+	}
+	line := strings.Join(points, " ")
+	area := fmt.Sprintf("%d,%d %s %d,%d", padding, bottom, line, width-padding, bottom)
+
+	data := SparklineData{
+		Width:     width,
+		Height:    height,
+		Line:      line,
+		Area:      area,
+		Theme:     theme,
+		Font:      font,
+		Title:     title,
+		HideTotal: hideTotal,
+		TitleY:    padding + 10,
+	}
+
+	return executeChartTemplate("sparkline", sparklineTemplate, data)
+}
+
+// resolveChartTheme resolves the theme for a chart, applying auto-contrast when requested
+func resolveChartTheme(opts SVGOptions) Theme {
+	theme := resolveTheme(opts)
+	if opts.AutoContrast {
+		theme.TextColor = AdjustTextContrast(theme)
+	}
+	return theme
+}
+
+func executeChartTemplate(name, text string, data interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(chartFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GeneratePunchcardSVG renders a weekday × hour-of-day grid showing when the user usually pushes
+func (s *HeatmapService) GeneratePunchcardSVG(dockerUsername string, opts SVGOptions) ([]byte, error) {
+	normalizeRenderOptions(&opts)
+
+	loc := s.dockerService.ResolveLocation(dockerUsername, opts.Timezone)
+	firstDay := s.dockerService.ResolveWeekStart(dockerUsername, opts.WeekStart)
+
+	grid, err := s.dockerService.GetPunchcard(dockerUsername, opts.Days, ActivityFilter{
+		Location:      loc,
+		EventTypes:    opts.EventTypes,
+		MinConfidence: opts.MinConfidence,
+		Tags:          opts.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	title := opts.CustomTitle
+	if title == "" {
+		title = fmt.Sprintf("@%s Docker push times (%s)", dockerUsername, loc.String())
+	}
+
+	svg, err := renderPunchcard(grid, firstDay, resolveChartTheme(opts), resolveFont(opts.Font, opts.FontSize), title, opts.HideTotal, opts.HideLabels)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyAttribution(dockerUsername, svg), nil
+}
+
+func renderPunchcard(grid [7][24]int, firstDay time.Weekday, theme Theme, font fontStyle, title string, hideTotal, hideLabels bool) ([]byte, error) {
+	const (
+		slot      = 22
+		maxRadius = 9.0
+	)
+
+	leftMargin := 40
+	if hideLabels {
+		leftMargin = 10
+	}
+	topMargin := 10
+
+	maxCount := 0
+	for _, hours := range grid {
+		for _, count := range hours {
+			if count > maxCount {
+				maxCount = count
+			}
+		}
+	}
+
+	dots := make([]PunchcardDot, 0, 7*24)
+	dayLabels := make([]DayLabel, 0, 7)
+	for row := 0; row < 7; row++ {
+		day := time.Weekday((int(firstDay) + row) % 7)
+		cy := topMargin + row*slot + slot/2
+		dayLabels = append(dayLabels, DayLabel{X: 8, Y: cy + 3, Label: day.String()[:3]})
+
+		for hour := 0; hour < 24; hour++ {
+			count := grid[day][hour]
+			radius := 1.0
+			if maxCount > 0 && count > 0 {
+				// Area scales with count so busy slots don't visually overwhelm the grid
+				radius = math.Max(2, maxRadius*math.Sqrt(float64(count)/float64(maxCount)))
+			}
+			dots = append(dots, PunchcardDot{
+				X:      leftMargin + hour*slot + slot/2,
+				Y:      cy,
+				Radius: math.Round(radius*10) / 10,
+				Color:  theme.Colors[calculateLevel(float64(count), float64(maxCount))],
+				Day:    day.String(),
+				Hour:   hour,
+				Count:  count,
+			})
+		}
+	}
+
+	gridBottom := topMargin + 7*slot
+	hourLabels := make([]MonthLabel, 0, 8)
+	for hour := 0; hour < 24; hour += 3 {
+		hourLabels = append(hourLabels, MonthLabel{
+			X:     leftMargin + hour*slot + slot/2,
+			Y:     gridBottom + 12,
+			Label: fmt.Sprintf("%02d", hour),
+		})
+	}
+
+	height := gridBottom + 20
+	if !hideTotal {
+		height += 18
+	}
+
+	data := PunchcardData{
+		Width:      leftMargin + 24*slot + 10,
+		Height:     height,
+		Dots:       dots,
+		DayLabels:  dayLabels,
+		HourLabels: hourLabels,
+		Theme:      theme,
+		Font:       font,
+		Title:      title,
+		HideTotal:  hideTotal,
+		HideLabels: hideLabels,
+		FooterY:    gridBottom + 32,
+	}
+
+	return executeChartTemplate("punchcard", punchcardTemplate, data)
+}
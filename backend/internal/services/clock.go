@@ -0,0 +1,17 @@
+package services
+
+import "time"
+
+// Clock tells services the current time, so tests can fix it
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
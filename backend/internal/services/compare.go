@@ -0,0 +1,451 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// Compare render modes
+const (
+	CompareModeStack = "stack" // One grid per user, one above the other, on a shared scale
+	CompareModeDiff  = "diff"  // One calendar colored by whoever was more active each day
+)
+
+var ErrCompareSameUser = errors.New("choose two different users to compare")
+
+// CompareStats is one side of a comparison
+type CompareStats struct {
+	Username      string `json:"username"`
+	Total         int    `json:"total"`
+	Pushes        int    `json:"pushes"`
+	Pulls         int    `json:"pulls"`
+	Builds        int    `json:"builds"`
+	ActiveDays    int    `json:"active_days"`
+	CurrentStreak int    `json:"current_streak"`
+	LongestStreak int    `json:"longest_streak"`
+	BusiestDate   string `json:"busiest_date,omitempty"`
+	BusiestCount  int    `json:"busiest_count"`
+}
+
+// Comparison is two accounts' activity over the same days, bucketed in one timezone
+type Comparison struct {
+	From  string          `json:"from"`
+	To    string          `json:"to"`
+	Users [2]CompareStats `json:"users"`
+	// Leader is the username with more activity, empty on a tie
+	Leader         string `json:"leader"`
+	BothActiveDays int    `json:"both_active_days"`
+}
+
+// CompareAccounts summarizes two accounts side by side between startDate and endDate
+// (inclusive), bucketing both into days in the filter's location
+func (s *DockerHubService) CompareAccounts(userA, userB string, startDate, endDate time.Time, filter ActivityFilter) (*Comparison, error) {
+	if strings.EqualFold(userA, userB) {
+		return nil, ErrCompareSameUser
+	}
+
+	comparison := &Comparison{}
+	var summaries [2][]models.ActivitySummary
+	for i, name := range []string{userA, userB} {
+		days, err := s.GetActivitySummaryRange(name, startDate, endDate, filter)
+		if err != nil {
+			return nil, err
+		}
+		summaries[i] = days
+		comparison.Users[i] = compareStats(name, days)
+	}
+
+	if days := summaries[0]; len(days) > 0 {
+		comparison.From = days[0].Date
+		comparison.To = days[len(days)-1].Date
+	}
+	for i := range summaries[0] {
+		if i < len(summaries[1]) && summaries[0][i].TotalCount > 0 && summaries[1][i].TotalCount > 0 {
+			comparison.BothActiveDays++
+		}
+	}
+
+	a, b := comparison.Users[0], comparison.Users[1]
+	switch {
+	case a.Total > b.Total:
+		comparison.Leader = a.Username
+	case b.Total > a.Total:
+		comparison.Leader = b.Username
+	}
+	return comparison, nil
+}
+
+func compareStats(username string, days []models.ActivitySummary) CompareStats {
+	stats := CompareStats{Username: username}
+	for _, day := range days {
+		stats.Total += day.TotalCount
+		stats.Pushes += day.Pushes
+		stats.Pulls += day.Pulls
+		stats.Builds += day.Builds
+		if day.TotalCount > 0 {
+			stats.ActiveDays++
+		}
+		if day.TotalCount > stats.BusiestCount {
+			stats.BusiestCount = day.TotalCount
+			stats.BusiestDate = day.Date
+		}
+	}
+	counts := make([]int, len(days))
+	for i, day := range days {
+		counts[i] = day.TotalCount
+	}
+	stats.CurrentStreak, stats.LongestStreak = activityStreaks(counts)
+	return stats
+}
+
+// activityStreaks returns the run of active days ending on the last day and the longest
+// run. A quiet last day doesn't end the current streak, since that day may not be over.
+func activityStreaks(counts []int) (current, longest int) {
+	run := 0
+	for _, count := range counts {
+		if count > 0 {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	current = run
+	if n := len(counts); n > 1 && counts[n-1] == 0 {
+		for i := n - 2; i >= 0 && counts[i] > 0; i-- {
+			current++
+		}
+	}
+	return current, longest
+}
+
+// compareActivitySource serves one user's side of a stacked comparison from a single
+// query for both. Levels are scaled against the busiest day of either user, so the two
+// grids read on one scale, and both use the first user's timezone and week start so
+// they line up.
+type compareActivitySource struct {
+	s      *DockerHubService
+	users  []string
+	days   []TeamDay
+	maxDay int
+}
+
+func (src *compareActivitySource) ResolveLocation(_, tz string) *time.Location {
+	return src.s.ResolveLocation(src.users[0], tz)
+}
+
+func (src *compareActivitySource) ResolveWeekStart(_, weekStart string) time.Weekday {
+	return src.s.ResolveWeekStart(src.users[0], weekStart)
+}
+
+func (src *compareActivitySource) GetActivitySummaryRange(username string, startDate, endDate time.Time, filter ActivityFilter) ([]models.ActivitySummary, error) {
+	if src.days == nil {
+		days, err := src.s.GetTeamActivityRange(src.users, startDate, endDate, filter)
+		if err != nil {
+			return nil, err
+		}
+		src.days = days
+		for _, day := range days {
+			for _, count := range day.Counts {
+				if count > src.maxDay {
+					src.maxDay = count
+				}
+			}
+		}
+	}
+
+	member := 0
+	if username == src.users[1] {
+		member = 1
+	}
+	summaries := make([]models.ActivitySummary, 0, len(src.days))
+	for _, day := range src.days {
+		count := day.Counts[member]
+		summaries = append(summaries, models.ActivitySummary{
+			Date:       day.Date,
+			TotalCount: count,
+			Level:      calculateLevel(float64(count), float64(src.maxDay)),
+		})
+	}
+	return summaries, nil
+}
+
+// CompareRow is one user's grid in a stacked comparison
+type CompareRow struct {
+	OffsetY   int
+	Cells     []Cell
+	DayLabels []DayLabel
+	Caption   string
+	CaptionY  int
+}
+
+// CompareSVGData represents the data needed to render a stacked comparison
+type CompareSVGData struct {
+	Width        int
+	Height       int
+	Rows         []CompareRow
+	MonthLabels  []MonthLabel
+	Config       HeatmapConfig
+	Title        string
+	HideLegend   bool
+	HideTotal    bool
+	HideLabels   bool
+	LegendX      int
+	LegendY      int
+	FooterY      int
+	CellsOffsetX int
+}
+
+const compareSVGTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="xMidYMid meet" xmlns="http://www.w3.org/2000/svg">
+  <style>
+    {{.Config.FontFace}}
+    .day { shape-rendering: geometricPrecision; {{if .Config.BorderColor}}stroke: {{.Config.BorderColor}}; stroke-width: 1px;{{else}}outline: 1px solid rgba(27, 31, 35, 0.06); outline-offset: -1px;{{end}} }
+    .month-label { font-size: {{.Config.FontSize}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+    .day-label { font-size: {{subtract .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+    .title { font-size: {{add .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; font-weight: 600; }
+    .legend-label { font-size: {{subtract .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+  </style>
+  {{if .Config.Gradients}}
+  <defs>
+    {{range .Config.Gradients}}
+    <linearGradient id="{{.ID}}" x1="0" y1="0" x2="1" y2="1">
+      <stop offset="0%" stop-color="{{.From}}"/>
+      <stop offset="100%" stop-color="{{.To}}"/>
+    </linearGradient>
+    {{end}}
+  </defs>
+  {{end}}
+  <rect width="{{.Width}}" height="{{.Height}}" fill="{{.Config.BgColor}}" rx="6"/>
+  {{if not .HideLabels}}
+  {{range .MonthLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="month-label">{{.Label}}</text>
+  {{end}}
+  {{end}}
+  {{range .Rows}}
+  {{if not $.HideLabels}}
+  {{range .DayLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="day-label">{{.Label}}</text>
+  {{end}}
+  {{end}}
+  <g transform="translate({{$.CellsOffsetX}}, {{.OffsetY}})">
+    {{range .Cells}}
+    <rect class="day" x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}" rx="{{.Radius}}">
+      <title>{{.Date}}: {{.Count}} activities</title>
+    </rect>
+    {{end}}
+  </g>
+  {{if not $.HideTotal}}
+  <text x="{{$.CellsOffsetX}}" y="{{.CaptionY}}" class="title">{{.Caption}}</text>
+  {{end}}
+  {{end}}
+  {{if and .Title (not .HideTotal)}}
+  <text x="{{.CellsOffsetX}}" y="{{.FooterY}}" class="title">{{.Title}}</text>
+  {{end}}
+  {{if not .HideLegend}}
+  <g transform="translate({{.LegendX}}, {{.LegendY}})">
+    <text x="-4" y="10" class="legend-label" text-anchor="end">Less</text>
+    {{range $i, $color := .Config.Fills}}
+    <rect x="{{multiply $i 14}}" y="0" width="11" height="11" fill="{{$color}}" rx="2"/>
+    {{end}}
+    <text x="71" y="10" class="legend-label">More</text>
+  </g>
+  {{end}}
+</svg>`
+
+// GenerateCompareSVG renders two users' activity over the same days. Stack mode draws
+// one grid per user, aligned and on a shared scale; diff mode draws one calendar where
+// each day takes the color of whoever was more active, stronger the bigger the gap.
+// memberColors overrides the diff colors (TeamPalette by default).
+func (s *HeatmapService) GenerateCompareSVG(userA, userB, mode string, memberColors []string, opts SVGOptions) ([]byte, error) {
+	if strings.EqualFold(userA, userB) {
+		return nil, ErrCompareSameUser
+	}
+	normalizeRenderOptions(&opts)
+	// Stacked calendar years would interleave the two users' rows
+	opts.Years = 1
+
+	var svg []byte
+	var err error
+	if mode == CompareModeDiff {
+		svg, err = s.generateCompareDiffSVG([]string{userA, userB}, memberColors, opts)
+	} else {
+		svg, err = s.generateCompareStackSVG([]string{userA, userB}, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{userA, userB} {
+		if text := s.dockerService.AttributionFor(name); text != "" {
+			return stampAttribution(svg, text), nil
+		}
+	}
+	return svg, nil
+}
+
+func (s *HeatmapService) generateCompareStackSVG(users []string, opts SVGOptions) ([]byte, error) {
+	source := &compareActivitySource{s: s.dockerService, users: users}
+	var grids [2]*SVGData
+	for i, name := range users {
+		data, _, err := buildHeatmapDataFrom(source, s.clock.Now(), name, opts)
+		if err != nil {
+			return nil, err
+		}
+		grids[i] = data
+	}
+
+	cellsHeight := 7 * (opts.CellSize + grids[0].Config.CellMargin)
+	captionHeight := 8
+	if !opts.HideTotal {
+		captionHeight = 22
+	}
+
+	rows := make([]CompareRow, 0, len(users))
+	offsetY := 25
+	for i, data := range grids {
+		counts := make([]int, 0, len(source.days))
+		for _, day := range source.days {
+			counts = append(counts, day.Counts[i])
+		}
+		_, longest := activityStreaks(counts)
+
+		dayLabels := make([]DayLabel, 0, len(data.DayLabels))
+		for _, label := range data.DayLabels {
+			label.Y += offsetY - 25
+			dayLabels = append(dayLabels, label)
+		}
+		rows = append(rows, CompareRow{
+			OffsetY:   offsetY,
+			Cells:     data.Cells,
+			DayLabels: dayLabels,
+			Caption:   fmt.Sprintf("@%s • %d total • %d-day longest streak", data.Username, data.TotalCount, longest),
+			CaptionY:  offsetY + cellsHeight + 15,
+		})
+		offsetY += cellsHeight + captionHeight
+	}
+
+	bottom := offsetY
+	height := bottom + 2
+	if !opts.HideLegend || (opts.CustomTitle != "" && !opts.HideTotal) {
+		height = bottom + 24
+	}
+
+	return executeChartTemplate("compare", compareSVGTemplate, CompareSVGData{
+		Width:        grids[0].Width,
+		Height:       height,
+		Rows:         rows,
+		MonthLabels:  grids[0].MonthLabels,
+		Config:       grids[0].Config,
+		Title:        opts.CustomTitle,
+		HideLegend:   opts.HideLegend,
+		HideTotal:    opts.HideTotal,
+		HideLabels:   opts.HideLabels,
+		LegendX:      grids[0].Width - 120,
+		LegendY:      bottom + 2,
+		FooterY:      bottom + 12,
+		CellsOffsetX: grids[0].CellsOffsetX,
+	})
+}
+
+func (s *HeatmapService) generateCompareDiffSVG(users []string, memberColors []string, opts SVGOptions) ([]byte, error) {
+	source := &teamActivitySource{s: s.dockerService, members: users}
+	layoutOpts := opts
+	layoutOpts.HideLegend = true
+	data, _, err := buildHeatmapDataFrom(source, s.clock.Now(), users[0], layoutOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	empty, ok := parseColor(data.Config.Colors[0])
+	if !ok {
+		empty = rgb{0x16, 0x1b, 0x22}
+	}
+	colors := make([]rgb, len(users))
+	for i := range users {
+		color := TeamPalette[i]
+		if i < len(memberColors) && memberColors[i] != "" {
+			color = memberColors[i]
+		}
+		if colors[i], ok = parseColor(color); !ok {
+			colors[i], _ = parseColor(TeamPalette[i])
+		}
+	}
+
+	maxGap := 0
+	for _, day := range source.days {
+		if len(day.Counts) == len(users) {
+			if gap := absInt(day.Counts[0] - day.Counts[1]); gap > maxGap {
+				maxGap = gap
+			}
+		}
+	}
+
+	totals := make([]int, len(users))
+	cells := make([]TeamCell, 0, len(data.Cells))
+	for _, cell := range data.Cells {
+		day := source.days[cell.Day]
+		diffCell := TeamCell{
+			X:     cell.X,
+			Y:     cell.Y,
+			Color: data.Config.Colors[0],
+			Date:  cell.Date,
+			Count: cell.Count,
+		}
+		if cell.Count > 0 && len(day.Counts) == len(users) {
+			totals[0] += day.Counts[0]
+			totals[1] += day.Counts[1]
+			diffCell.Breakdown = teamBreakdown(users, day.Counts)
+
+			gap := day.Counts[0] - day.Counts[1]
+			switch {
+			case gap > 0:
+				diffCell.Color = mixColors(empty, colors[0], teamLevelIntensity[calculateLevel(float64(gap), float64(maxGap))]).hex()
+			case gap < 0:
+				diffCell.Color = mixColors(empty, colors[1], teamLevelIntensity[calculateLevel(float64(-gap), float64(maxGap))]).hex()
+			default:
+				// A tie shows both colors, faintly
+				diffCell.Color = mixColors(empty, blendMemberColors(colors, day.Counts), teamLevelIntensity[1]).hex()
+			}
+		}
+		cells = append(cells, diffCell)
+	}
+
+	title := opts.CustomTitle
+	if title == "" {
+		title = fmt.Sprintf("%s vs %s • %d to %d", SanitizeText(users[0], 0), SanitizeText(users[1], 0), totals[0], totals[1])
+	}
+
+	legend, height, footerY := layoutTeamLegend(data, users, colors, totals, opts)
+	return executeChartTemplate("compare-diff", teamSVGTemplate, TeamSVGData{
+		Width:        data.Width,
+		Height:       height,
+		CellSize:     opts.CellSize,
+		CellRadius:   opts.CellRadius,
+		Cells:        cells,
+		Members:      legend,
+		MonthLabels:  data.MonthLabels,
+		DayLabels:    data.DayLabels,
+		YearLabels:   data.YearLabels,
+		Config:       data.Config,
+		Title:        title,
+		HideLegend:   opts.HideLegend,
+		HideTotal:    opts.HideTotal,
+		HideLabels:   opts.HideLabels,
+		FooterY:      footerY,
+		CellsOffsetX: data.CellsOffsetX,
+	})
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
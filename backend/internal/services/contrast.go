@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WCAG 2.1 contrast minimums used for theme validation
+const (
+	MinTextContrast  = 4.5 // Normal-size text (labels are 9-11px)
+	MinLevelContrast = 1.3 // Activity cells against the background, below this levels are hard to tell apart
+
+	// MinAdjacentLevelContrast applies between neighbouring levels, which may differ by hue alone
+	MinAdjacentLevelContrast = 1.1
+)
+
+// ThemeWarning describes a color pair that falls below the recommended contrast
+type ThemeWarning struct {
+	Field   string  `json:"field"`
+	Against string  `json:"against"`
+	Ratio   float64 `json:"ratio"`
+	Minimum float64 `json:"minimum"`
+	Message string  `json:"message"`
+}
+
+// ValidateThemeContrast checks text and level colors against the theme background, and
+// each level against the one below it. Transparent or unparseable backgrounds can't be
+// evaluated, so only the level-to-level checks apply to them.
+func ValidateThemeContrast(theme Theme) []ThemeWarning {
+	warnings := make([]ThemeWarning, 0)
+
+	for i := 1; i < len(theme.Colors); i++ {
+		prev, ok1 := parseColor(theme.Colors[i-1])
+		level, ok2 := parseColor(theme.Colors[i])
+		if !ok1 || !ok2 {
+			continue
+		}
+		if ratio := contrastRatio(level, prev); ratio < MinAdjacentLevelContrast {
+			warnings = append(warnings, newThemeWarning(fmt.Sprintf("color%d", i), fmt.Sprintf("color%d", i-1), ratio, MinAdjacentLevelContrast))
+		}
+	}
+
+	bg, ok := parseColor(theme.BgColor)
+	if !ok {
+		return warnings
+	}
+
+	if text, ok := parseColor(theme.TextColor); ok {
+		if ratio := contrastRatio(text, bg); ratio < MinTextContrast {
+			warnings = append(warnings, newThemeWarning("text_color", "bg_color", ratio, MinTextContrast))
+		}
+	}
+
+	// Level 0 is "no activity" and is expected to blend into the background
+	for i := 1; i < len(theme.Colors); i++ {
+		level, ok := parseColor(theme.Colors[i])
+		if !ok {
+			continue
+		}
+		if ratio := contrastRatio(level, bg); ratio < MinLevelContrast {
+			warnings = append(warnings, newThemeWarning(fmt.Sprintf("color%d", i), "bg_color", ratio, MinLevelContrast))
+		}
+	}
+
+	return warnings
+}
+
+// ValidateOptionsContrast resolves the theme a render with opts would use (including
+// auto_contrast) and validates it
+func ValidateOptionsContrast(opts SVGOptions) (Theme, []ThemeWarning) {
+	theme := resolveChartTheme(opts)
+	return theme, ValidateThemeContrast(theme)
+}
+
+// FormatWarningsHeader joins warnings into a single header value
+func FormatWarningsHeader(warnings []ThemeWarning) string {
+	messages := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		messages = append(messages, w.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func newThemeWarning(field, against string, ratio, minimum float64) ThemeWarning {
+	ratio = math.Round(ratio*100) / 100
+	return ThemeWarning{
+		Field:   field,
+		Against: against,
+		Ratio:   ratio,
+		Minimum: minimum,
+		Message: fmt.Sprintf("%s has a contrast ratio of %.2f:1 against %s (minimum %.1f:1)", field, ratio, against, minimum),
+	}
+}
+
+// AdjustTextContrast returns a text color readable on the theme background, moving the
+// original color towards black or white only as far as needed to reach MinTextContrast
+func AdjustTextContrast(theme Theme) string {
+	bg, ok := parseColor(theme.BgColor)
+	if !ok {
+		return theme.TextColor
+	}
+	text, ok := parseColor(theme.TextColor)
+	if !ok || contrastRatio(text, bg) >= MinTextContrast {
+		return theme.TextColor
+	}
+
+	target := rgb{0, 0, 0}
+	if contrastRatio(rgb{255, 255, 255}, bg) > contrastRatio(target, bg) {
+		target = rgb{255, 255, 255}
+	}
+
+	for step := 1; step <= 10; step++ {
+		candidate := mixColors(text, target, float64(step)/10)
+		if contrastRatio(candidate, bg) >= MinTextContrast {
+			return candidate.hex()
+		}
+	}
+	return target.hex()
+}
+
+type rgb struct {
+	R, G, B uint8
+}
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// parseColor parses #rgb or #rrggbb colors; anything else (e.g. "transparent") is rejected
+func parseColor(value string) (rgb, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return rgb{}, false
+	}
+	n, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+}
+
+// relativeLuminance implements the WCAG 2.1 relative luminance formula
+func relativeLuminance(c rgb) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// contrastRatio returns the WCAG contrast ratio between two colors (1 to 21)
+func contrastRatio(a, b rgb) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func mixColors(a, b rgb, t float64) rgb {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return rgb{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B)}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// MaxEventsCSVRows bounds how many events one CSV holds; a longer history is exported a
+// date range at a time
+const MaxEventsCSVRows = 100000
+
+var ErrEventsCSVTooLarge = fmt.Errorf("more than %d events match; narrow the range with from and to", MaxEventsCSVRows)
+
+// ActivityCSV encodes activity with one row per day, week or month, oldest first
+func ActivityCSV(activities []models.ActivitySummary) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"date", "count", "pushes", "pulls", "builds", "level"})
+	for _, a := range activities {
+		w.Write([]string{
+			a.Date,
+			strconv.Itoa(a.TotalCount), strconv.Itoa(a.Pushes), strconv.Itoa(a.Pulls), strconv.Itoa(a.Builds),
+			strconv.Itoa(a.Level),
+		})
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// EventsCSV encodes every event of the account matching q, in its order, one row per event.
+// q's Cursor and Limit are ignored.
+func (s *DockerHubService) EventsCSV(accountID uint, q EventQuery) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"id", "event_type", "event_date", "event_at", "count", "repository", "tag", "source", "confidence", "run_url"})
+	q.Cursor, q.Limit = "", 1000
+	rows := 0
+	for {
+		page, err := s.ListEvents(accountID, q)
+		if err != nil {
+			return nil, err
+		}
+		rows += len(page.Events)
+		if rows > MaxEventsCSVRows {
+			return nil, ErrEventsCSVTooLarge
+		}
+
+		for _, event := range page.Events {
+			eventAt := ""
+			if event.EventAt != nil {
+				eventAt = event.EventAt.UTC().Format(time.RFC3339)
+			}
+			w.Write([]string{
+				strconv.FormatUint(uint64(event.ID), 10),
+				string(event.EventType),
+				event.EventDate.Format("2006-01-02"),
+				eventAt,
+				strconv.Itoa(event.Count),
+				csvCell(event.Repository),
+				csvCell(event.Tag),
+				string(event.Source),
+				event.Source.Confidence().String(),
+				csvCell(event.RunURL),
+			})
+		}
+
+		// A page can come back short of the limit with more to read after it
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// csvCell keeps a value a spreadsheet would run as a formula as plain text
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DataExportTTL is how long a finished export can be downloaded before it's deleted
+	DataExportTTL = 7 * 24 * time.Hour
+
+	// DataExportInterval is how often a user can ask for a new export
+	DataExportInterval = 24 * time.Hour
+
+	// dataExportStaleAfter is how long an export can be building before it's taken to have
+	// died with its instance and is built again
+	dataExportStaleAfter = 30 * time.Minute
+
+	// dataExportEventBatch bounds how many activity events are loaded at a time
+	dataExportEventBatch = 5000
+
+	// DataExportFormatVersion is bumped when the files in the archive change incompatibly
+	DataExportFormatVersion = 1
+)
+
+var (
+	ErrDataExportTooSoon  = fmt.Errorf("a data export can be requested once every %s", DataExportInterval)
+	ErrDataExportNotFound = errors.New("data export not found or expired")
+)
+
+// RequestDataExport queues an export of everything stored about the user and starts building
+// it. An export already queued or building is returned instead of a new one.
+func (s *ExportService) RequestDataExport(userID uint) (*models.DataExport, error) {
+	var existing models.DataExport
+	err := database.DB.Where("user_id = ? AND status IN ?", userID,
+		[]string{models.DataExportPending, models.DataExportRunning}).
+		Order("id DESC").First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var recent int64
+	if err := database.DB.Model(&models.DataExport{}).
+		Where("user_id = ? AND status = ? AND created_at > ?", userID, models.DataExportReady, time.Now().Add(-DataExportInterval)).
+		Count(&recent).Error; err != nil {
+		return nil, err
+	}
+	if recent > 0 {
+		return nil, ErrDataExportTooSoon
+	}
+
+	token, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, err
+	}
+	export := &models.DataExport{
+		CreatedAt: time.Now(),
+		UserID:    userID,
+		Status:    models.DataExportPending,
+		Token:     token,
+	}
+	if err := database.DB.Create(export).Error; err != nil {
+		return nil, err
+	}
+
+	go s.ProcessDataExports(context.Background())
+	return export, nil
+}
+
+// LatestDataExport returns the user's most recent export
+func (s *ExportService) LatestDataExport(userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := database.DB.Omit("data").Where("user_id = ?", userID).Order("id DESC").First(&export).Error
+	if err != nil {
+		return nil, ErrDataExportNotFound
+	}
+	return &export, nil
+}
+
+// DataExportByToken returns the finished, unexpired export a download link points to
+func (s *ExportService) DataExportByToken(token string) (*models.DataExport, error) {
+	var export models.DataExport
+	err := database.DB.Where("token = ? AND status = ? AND expires_at > ?", token, models.DataExportReady, time.Now()).
+		First(&export).Error
+	if err != nil {
+		return nil, ErrDataExportNotFound
+	}
+	return &export, nil
+}
+
+// ProcessDataExports builds queued exports, and exports whose build was cut off, one at a
+// time until none are left. Each is claimed first, so instances don't build the same one.
+func (s *ExportService) ProcessDataExports(ctx context.Context) {
+	for ctx.Err() == nil {
+		var export models.DataExport
+		err := database.DB.Omit("data").
+			Where("status = ? OR (status = ? AND started_at < ?)", models.DataExportPending,
+				models.DataExportRunning, time.Now().Add(-dataExportStaleAfter)).
+			Order("id").First(&export).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				slog.Error("Failed to load data exports", "error", err)
+			}
+			return
+		}
+
+		now := time.Now()
+		claim := database.DB.Model(&models.DataExport{}).
+			Where("id = ? AND status = ?", export.ID, export.Status)
+		if export.StartedAt != nil {
+			claim = claim.Where("started_at = ?", *export.StartedAt)
+		}
+		result := claim.Updates(map[string]interface{}{"status": models.DataExportRunning, "started_at": now})
+		if result.Error != nil {
+			slog.Error("Failed to claim data export", "data_export_id", export.ID, "error", result.Error)
+			return
+		}
+		if result.RowsAffected == 0 {
+			continue // another instance took it
+		}
+
+		s.buildDataExport(&export)
+	}
+}
+
+// buildDataExport builds a claimed export and records how it went
+func (s *ExportService) buildDataExport(export *models.DataExport) {
+	logger := slog.With("data_export_id", export.ID, "user_id", export.UserID)
+
+	data, err := BuildDataExport(export.UserID, time.Now())
+	if err != nil {
+		logger.Error("Data export failed", "error", err)
+		database.DB.Model(export).Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  "Failed to build the export",
+		})
+		return
+	}
+
+	completed := time.Now()
+	if err := database.DB.Model(export).Updates(map[string]interface{}{
+		"status":       models.DataExportReady,
+		"completed_at": completed,
+		"expires_at":   completed.Add(DataExportTTL),
+		"size_bytes":   len(data),
+		"data":         data,
+	}).Error; err != nil {
+		logger.Error("Failed to store data export", "error", err)
+		return
+	}
+	logger.Info("Data export ready", "size_bytes", len(data))
+}
+
+// PurgeExpiredDataExports deletes exports past their download window, and failed ones
+// older than that, returning how many were deleted
+func PurgeExpiredDataExports() (int64, error) {
+	now := time.Now()
+	result := database.DB.
+		Where("expires_at <= ? OR (status = ? AND created_at <= ?)", now, models.DataExportFailed, now.Add(-DataExportTTL)).
+		Delete(&models.DataExport{})
+	return result.RowsAffected, result.Error
+}
+
+// dataExportManifest describes an archive, as manifest.json
+type dataExportManifest struct {
+	FormatVersion int               `json:"format_version"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Instance      string            `json:"instance"`
+	UserID        uint              `json:"user_id"`
+	Files         map[string]string `json:"files"`
+}
+
+// BuildDataExport zips everything stored about a user: their profile and linked sign-ins,
+// Docker accounts, every activity event including archived ones, sessions, audit log,
+// notification channels, embed tokens and ingest keys. Secrets are left out: Docker access
+// tokens, password hashes, channel settings and token values.
+func BuildDataExport(userID uint, now time.Time) ([]byte, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	var accounts []models.DockerAccount
+	if err := database.DB.Unscoped().Where("user_id = ?", userID).Order("id").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	accountIDs := make([]uint, len(accounts))
+	for i, account := range accounts {
+		accountIDs[i] = account.ID
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifest := dataExportManifest{
+		FormatVersion: DataExportFormatVersion,
+		GeneratedAt:   now.UTC(),
+		Instance:      config.AppConfig.FrontendURL,
+		UserID:        userID,
+		Files:         map[string]string{},
+	}
+
+	// Each list is one JSON file, loaded whole; only activity can grow large
+	lists := []struct {
+		name        string
+		description string
+		dest        interface{}
+		query       *gorm.DB
+	}{
+		{"identities.json", "Sign-in providers linked to the account", &[]models.UserIdentity{},
+			database.DB.Where("user_id = ?", userID)},
+		{"docker_accounts.json", "Connected Docker Hub accounts, without their access tokens", &accounts, nil},
+		{"namespace_claims.json", "Docker Hub namespaces claimed for the accounts", &[]models.NamespaceClaim{},
+			database.DB.Where("docker_account_id IN ?", accountIDs)},
+		{"repositories.json", "Repositories seen in the accounts' activity", &[]models.DockerRepository{},
+			database.DB.Where("docker_account_id IN ?", accountIDs)},
+		{"sync_runs.json", "History of the accounts' syncs", &[]models.SyncRun{},
+			database.DB.Where("docker_account_id IN ?", accountIDs)},
+		{"achievements.json", "Achievements the accounts have earned", &[]models.Achievement{},
+			database.DB.Where("docker_account_id IN ?", accountIDs)},
+		{"sessions.json", "Signed-in sessions", &[]models.Session{},
+			database.DB.Where("user_id = ?", userID)},
+		{"audit_log.json", "Security and account events", &[]models.AuditLog{},
+			database.DB.Where("user_id = ?", userID)},
+		{"notification_channels.json", "Notification channels, without their settings", &[]models.NotificationChannel{},
+			database.DB.Where("user_id = ?", userID)},
+		{"embed_tokens.json", "Embed tokens, without their values", &[]models.EmbedToken{},
+			database.DB.Where("user_id = ?", userID)},
+		{"ingest_keys.json", "Ingest keys, without their secrets", &[]models.IngestKey{},
+			database.DB.Where("user_id = ?", userID)},
+	}
+
+	if err := writeZipJSON(zw, "profile.json", user); err != nil {
+		return nil, err
+	}
+	manifest.Files["profile.json"] = "Profile and settings"
+	for _, list := range lists {
+		if list.query != nil {
+			if err := list.query.Order("id").Find(list.dest).Error; err != nil {
+				return nil, fmt.Errorf("%s: %w", list.name, err)
+			}
+		}
+		if err := writeZipJSON(zw, list.name, list.dest); err != nil {
+			return nil, err
+		}
+		manifest.Files[list.name] = list.description
+	}
+
+	events, err := zw.Create("activity_events.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeDataExportEvents(json.NewEncoder(events), accountIDs); err != nil {
+		return nil, fmt.Errorf("activity_events.jsonl: %w", err)
+	}
+	manifest.Files["activity_events.jsonl"] = "Every activity event, archived ones included, one JSON object per line"
+
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeDataExportEvents writes the accounts' events still in activity_events, including
+// those cleaned up but not yet archived, then their archived ones
+func writeDataExportEvents(enc *json.Encoder, accountIDs []uint) error {
+	var batch []models.ActivityEvent
+	err := database.DB.Unscoped().Where("docker_account_id IN ?", accountIDs).Order("id").
+		FindInBatches(&batch, dataExportEventBatch, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				if err := enc.Encode(&batch[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	var chunkIDs []uint
+	if err := database.DB.Model(&models.ActivityArchive{}).Where("docker_account_id IN ?", accountIDs).
+		Order("docker_account_id, month, id").Pluck("id", &chunkIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range chunkIDs {
+		var chunk models.ActivityArchive
+		if err := database.DB.First(&chunk, id).Error; err != nil {
+			return err
+		}
+		archived, err := decompressEvents(chunk.Data)
+		if err != nil {
+			return fmt.Errorf("archive chunk %d: %w", chunk.ID, err)
+		}
+		for i := range archived {
+			if err := enc.Encode(&archived[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
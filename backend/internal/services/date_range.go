@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-heatmap/internal/config"
+)
+
+// MaxDateRangeYears caps explicit from/to ranges on the public endpoints
+const MaxDateRangeYears = 5
+
+// MinRetentionDays is the shortest retention the cleanup job will apply, so a trailing
+// year is always available regardless of ACTIVITY_RETENTION_DAYS
+const MinRetentionDays = 365
+
+var ErrDateRangeInvalid = errors.New("invalid date range")
+
+// ActivityRetentionDays returns how many days of activity are kept before cleanup
+func ActivityRetentionDays() int {
+	if config.AppConfig.ActivityRetentionDays < MinRetentionDays {
+		return MinRetentionDays
+	}
+	return config.AppConfig.ActivityRetentionDays
+}
+
+// ParseDateRange parses an explicit from/to pair (YYYY-MM-DD, inclusive). Both must be
+// given together; when neither is, ok is false and the caller keeps its trailing window.
+// The range must end by today, start within retention and span at most MaxDateRangeYears.
+func ParseDateRange(from, to string, now time.Time) (start, end time.Time, ok bool, err error) {
+	if from == "" && to == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	if from == "" || to == "" {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: from and to must be given together", ErrDateRangeInvalid)
+	}
+
+	start, err = time.Parse("2006-01-02", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrDateRangeInvalid)
+	}
+	end, err = time.Parse("2006-01-02", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrDateRangeInvalid)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case end.Before(start):
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: from must not be after to", ErrDateRangeInvalid)
+	case end.After(today):
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: to must not be in the future", ErrDateRangeInvalid)
+	case start.Before(today.AddDate(0, 0, -ActivityRetentionDays())):
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: from is older than the %d-day retention window", ErrDateRangeInvalid, ActivityRetentionDays())
+	case start.Before(end.AddDate(-MaxDateRangeYears, 0, 0)):
+		return time.Time{}, time.Time{}, false, fmt.Errorf("%w: range must not exceed %d years", ErrDateRangeInvalid, MaxDateRangeYears)
+	}
+
+	return start, end, true, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+var ErrDayInvalid = errors.New("date must be a past or current day in YYYY-MM-DD format")
+
+// DayDetail breaks one day's activity down by repository and tag
+type DayDetail struct {
+	Date         string          `json:"date"`
+	Timezone     string          `json:"timezone"`
+	TotalCount   int             `json:"count"`
+	Pushes       int             `json:"pushes"`
+	Pulls        int             `json:"pulls"`
+	Builds       int             `json:"builds"`
+	Repositories []DayRepository `json:"repositories"`
+}
+
+// DayRepository is one repository's share of a day, with its tags in the order they happened
+type DayRepository struct {
+	Repository string   `json:"repository"`
+	Count      int      `json:"count"`
+	Tags       []DayTag `json:"tags"`
+}
+
+// DayTag is a single tag's activity within a day
+type DayTag struct {
+	Tag       string             `json:"tag"`
+	EventType models.EventType   `json:"event_type"`
+	Count     int                `json:"count"`
+	EventAt   *time.Time         `json:"event_at,omitempty"`
+	Source    models.EventSource `json:"source"`
+}
+
+// GetDayDetail returns the repositories and tags behind one calendar day of activity,
+// with the day bucketed in the filter's location as on the heatmap
+func (s *DockerHubService) GetDayDetail(dockerUsername, date string, filter ActivityFilter) (*DayDetail, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil || day.After(time.Now().In(loc)) {
+		return nil, ErrDayInvalid
+	}
+
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	// event_date is the UTC day, so widen the scan by a day on each side to cover any offset
+	query := database.DB.Where("docker_account_id = ? AND event_date >= ? AND event_date <= ?",
+		account.ID, day.AddDate(0, 0, -1).UTC(), day.AddDate(0, 0, 1).UTC())
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+
+	var events []models.ActivityEvent
+	if err := query.Order("event_at, id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	detail := &DayDetail{Date: date, Timezone: loc.String(), Repositories: []DayRepository{}}
+	byRepo := make(map[string]int)
+	for i := range events {
+		if !filter.includes(&events[i]) || events[i].LocalDate(loc) != date {
+			continue
+		}
+		detail.add(&events[i], byRepo)
+	}
+	detail.sortRepositories()
+
+	return detail, nil
+}
+
+// add counts an event toward the day. byRepo indexes Repositories by name.
+func (d *DayDetail) add(event *models.ActivityEvent, byRepo map[string]int) {
+	d.TotalCount += event.Count
+	switch event.EventType {
+	case models.EventTypePush:
+		d.Pushes += event.Count
+	case models.EventTypePull:
+		d.Pulls += event.Count
+	case models.EventTypeBuild:
+		d.Builds += event.Count
+	}
+
+	i, ok := byRepo[event.Repository]
+	if !ok {
+		i = len(d.Repositories)
+		byRepo[event.Repository] = i
+		d.Repositories = append(d.Repositories, DayRepository{Repository: event.Repository, Tags: []DayTag{}})
+	}
+	repo := &d.Repositories[i]
+	repo.Count += event.Count
+	repo.Tags = append(repo.Tags, DayTag{
+		Tag:       event.Tag,
+		EventType: event.EventType,
+		Count:     event.Count,
+		EventAt:   event.EventAt,
+		Source:    event.ResolvedSource(),
+	})
+}
+
+// sortRepositories puts the busiest repositories first
+func (d *DayDetail) sortRepositories() {
+	sort.SliceStable(d.Repositories, func(i, j int) bool {
+		a, b := d.Repositories[i], d.Repositories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Repository < b.Repository
+	})
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DeviceCodeLifetime is how long a device login can wait for approval
+	DeviceCodeLifetime = 10 * time.Minute
+	// DevicePollInterval is the seconds a device waits between polls. The auth routes
+	// allow 10 requests a minute, so polling any faster would be rate limited.
+	DevicePollInterval = 10
+	// deviceSlowDownStep is added to a device's interval each time it polls too soon
+	deviceSlowDownStep = 5
+	// userCodeAlphabet has no vowels, so codes can't spell words, and no digits that look
+	// like letters
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+)
+
+// Errors a polling device can get, each matching an error code of the device
+// authorization grant (RFC 8628)
+var (
+	ErrAuthorizationPending = errors.New("the login hasn't been approved yet")
+	ErrSlowDown             = errors.New("polling too often")
+	ErrDeviceCodeExpired    = errors.New("the device code has expired")
+	ErrDeviceAccessDenied   = errors.New("the login was denied")
+	ErrDeviceCodeInvalid    = errors.New("unknown device code")
+)
+
+// ErrUserCodeNotFound is returned for a user code that is unknown, expired or already used
+var ErrUserCodeNotFound = errors.New("code not found or expired")
+
+// DeviceAuthorizationStart is what a device shows its user and polls with
+type DeviceAuthorizationStart struct {
+	DeviceCode string
+	UserCode   string
+	ExpiresIn  int // Seconds
+	Interval   int // Seconds
+}
+
+type DeviceAuthService struct{}
+
+func NewDeviceAuthService() *DeviceAuthService {
+	return &DeviceAuthService{}
+}
+
+// Start begins a device login. clientName, such as "dhm on laptop", is shown when the
+// user approves it.
+func (s *DeviceAuthService) Start(clientName string) (*DeviceAuthorizationStart, error) {
+	now := time.Now()
+	// Expired logins are only useful until their device gives up
+	database.DB.Where("expires_at < ?", now.Add(-DeviceCodeLifetime)).Delete(&models.DeviceAuthorization{})
+
+	deviceCode, err := utils.GenerateRandomString(40)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &models.DeviceAuthorization{
+		DeviceCodeHash: hashDeviceCode(deviceCode),
+		ClientName:     SanitizeText(clientName, 64),
+		ExpiresAt:      now.Add(DeviceCodeLifetime),
+		Interval:       DevicePollInterval,
+	}
+	// User codes are short, so a clash with a pending one is possible if unlikely
+	for attempt := 0; ; attempt++ {
+		if auth.UserCode, err = generateUserCode(); err != nil {
+			return nil, err
+		}
+		err = database.DB.Create(auth).Error
+		if err == nil {
+			break
+		}
+		if attempt == 2 {
+			return nil, err
+		}
+		auth.ID = 0
+	}
+
+	return &DeviceAuthorizationStart{
+		DeviceCode: deviceCode,
+		UserCode:   auth.UserCode,
+		ExpiresIn:  int(DeviceCodeLifetime.Seconds()),
+		Interval:   DevicePollInterval,
+	}, nil
+}
+
+// Lookup returns the pending login for a user code, so the user can check it before
+// approving
+func (s *DeviceAuthService) Lookup(userCode string) (*models.DeviceAuthorization, error) {
+	code, ok := NormalizeUserCode(userCode)
+	if !ok {
+		return nil, ErrUserCodeNotFound
+	}
+
+	var auth models.DeviceAuthorization
+	err := pendingDeviceAuthorizations(database.DB).Where("user_code = ?", code).First(&auth).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrUserCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+// Decide approves or denies the pending login for a user code. Approving signs the
+// device in as userID the next time it polls.
+func (s *DeviceAuthService) Decide(userID uint, userCode string, approve bool) error {
+	code, ok := NormalizeUserCode(userCode)
+	if !ok {
+		return ErrUserCodeNotFound
+	}
+
+	updates := map[string]interface{}{"denied": true}
+	if approve {
+		updates = map[string]interface{}{"user_id": userID}
+	}
+	// Conditional, so a code can only be decided once
+	result := pendingDeviceAuthorizations(database.DB.Model(&models.DeviceAuthorization{})).
+		Where("user_code = ?", code).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserCodeNotFound
+	}
+	return nil
+}
+
+// Poll checks on a device login and returns the user who approved it, along with the
+// name the device gave. Each approval signs in once: the login is gone after it is
+// returned.
+func (s *DeviceAuthService) Poll(deviceCode string) (*models.User, string, error) {
+	var auth models.DeviceAuthorization
+	err := database.DB.Where("device_code_hash = ?", hashDeviceCode(deviceCode)).First(&auth).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, "", ErrDeviceCodeInvalid
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if now.After(auth.ExpiresAt) {
+		database.DB.Delete(&auth)
+		return nil, "", ErrDeviceCodeExpired
+	}
+	if auth.LastPolledAt != nil && now.Sub(*auth.LastPolledAt) < time.Duration(auth.Interval)*time.Second {
+		database.DB.Model(&auth).Updates(map[string]interface{}{
+			"interval_seconds": auth.Interval + deviceSlowDownStep,
+			"last_polled_at":   now,
+		})
+		return nil, "", ErrSlowDown
+	}
+	if err := database.DB.Model(&auth).Update("last_polled_at", now).Error; err != nil {
+		return nil, "", err
+	}
+
+	if auth.Denied {
+		database.DB.Delete(&auth)
+		return nil, "", ErrDeviceAccessDenied
+	}
+	if auth.UserID == nil {
+		return nil, "", ErrAuthorizationPending
+	}
+
+	// Deleting first means two polls racing can't both sign in
+	result := database.DB.Delete(&auth)
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, "", ErrDeviceCodeInvalid
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, *auth.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", err
+	}
+	return &user, auth.ClientName, nil
+}
+
+// NormalizeUserCode accepts a user code typed with any case, spacing or dashes and
+// returns it as shown, such as BCDF-GHJK
+func NormalizeUserCode(input string) (string, bool) {
+	var letters []byte
+	for _, r := range strings.ToUpper(input) {
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, byte(r))
+		}
+	}
+	if len(letters) != userCodeLength {
+		return "", false
+	}
+	return string(letters[:4]) + "-" + string(letters[4:]), true
+}
+
+// pendingDeviceAuthorizations narrows a query to logins still waiting for a decision
+func pendingDeviceAuthorizations(db *gorm.DB) *gorm.DB {
+	return db.Where("expires_at > ? AND user_id IS NULL AND denied = ?", time.Now(), false)
+}
+
+func generateUserCode() (string, error) {
+	letters := make([]byte, userCodeLength)
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	for i := range letters {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		letters[i] = userCodeAlphabet[n.Int64()]
+	}
+	code, _ := NormalizeUserCode(string(letters))
+	return code, nil
+}
+
+func hashDeviceCode(deviceCode string) string {
+	sum := sha256.Sum256([]byte(deviceCode))
+	return hex.EncodeToString(sum[:])
+}
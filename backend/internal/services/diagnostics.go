@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// maxProbeBodyBytes bounds how much of a Docker Hub response is copied into a report
+const maxProbeBodyBytes = 2048
+
+// DiagnosticReport is a redacted snapshot of everything useful for debugging a user's setup.
+// It never contains tokens, ciphertexts or emails.
+type DiagnosticReport struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	User        DiagnosticUser         `json:"user"`
+	Account     *DiagnosticAccount     `json:"docker_account,omitempty"`
+	Events      *DiagnosticEvents      `json:"events,omitempty"`
+	DockerHub   *DiagnosticProbe       `json:"docker_hub_probe,omitempty"`
+	Config      map[string]interface{} `json:"config"`
+	Errors      []string               `json:"errors,omitempty"`
+}
+
+type DiagnosticUser struct {
+	ID            uint   `json:"id"`
+	CreatedAt     string `json:"created_at"`
+	PublicProfile bool   `json:"public_profile"`
+	Timezone      string `json:"timezone,omitempty"`
+	WeekStart     string `json:"week_start,omitempty"`
+}
+
+type DiagnosticAccount struct {
+	DockerUsername string     `json:"docker_username"`
+	ConnectedAt    time.Time  `json:"connected_at"`
+	IsActive       bool       `json:"is_active"`
+	AutoRefresh    bool       `json:"auto_refresh"`
+	SyncInProgress bool       `json:"sync_in_progress"`
+	LastSyncAt     *time.Time `json:"last_sync_at,omitempty"`
+	LastSyncError  string     `json:"last_sync_error,omitempty"`
+	HasToken       bool       `json:"has_token"`
+}
+
+type DiagnosticEvents struct {
+	Total        int64      `json:"total"`
+	Last30Days   int64      `json:"last_30_days"`
+	FirstEventAt *time.Time `json:"first_event_date,omitempty"`
+	LastEventAt  *time.Time `json:"last_event_date,omitempty"`
+}
+
+type DiagnosticProbe struct {
+	Endpoint   string `json:"endpoint"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Body       string `json:"body_truncated,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type DiagnosticsService struct {
+	dockerService *DockerHubService
+}
+
+func NewDiagnosticsService(dockerService *DockerHubService) *DiagnosticsService {
+	return &DiagnosticsService{
+		dockerService: dockerService,
+	}
+}
+
+// BuildReport assembles the diagnostic bundle for a user
+func (s *DiagnosticsService) BuildReport(ctx context.Context, user *models.User) *DiagnosticReport {
+	report := &DiagnosticReport{
+		GeneratedAt: time.Now().UTC(),
+		User: DiagnosticUser{
+			ID:            user.ID,
+			CreatedAt:     user.CreatedAt.UTC().Format(time.RFC3339),
+			PublicProfile: user.PublicProfile,
+			Timezone:      user.Timezone,
+			WeekStart:     user.WeekStart,
+		},
+		Config: map[string]interface{}{
+			"environment":             config.AppConfig.Environment,
+			"docker_hub_api_url":      config.AppConfig.DockerHubAPIURL,
+			"activity_retention_days": config.AppConfig.ActivityRetentionDays,
+			"github_oauth_configured": config.AppConfig.GitHubClientID != "",
+			"attribution_mode":        config.AppConfig.AttributionMode,
+		},
+	}
+
+	account, err := s.dockerService.GetDockerAccount(user.ID)
+	if err != nil {
+		report.Errors = append(report.Errors, "no docker account connected")
+		return report
+	}
+
+	report.Account = &DiagnosticAccount{
+		DockerUsername: account.DockerUsername,
+		ConnectedAt:    account.CreatedAt,
+		IsActive:       account.IsActive,
+		AutoRefresh:    account.AutoRefresh,
+		SyncInProgress: account.SyncInProgress,
+		LastSyncAt:     account.LastSyncAt,
+		LastSyncError:  account.LastSyncError,
+		HasToken:       account.EncryptedToken != "",
+	}
+
+	events := &DiagnosticEvents{}
+	database.DB.Model(&models.ActivityEvent{}).Where("docker_account_id = ?", account.ID).Count(&events.Total)
+	database.DB.Model(&models.ActivityEvent{}).
+		Where("docker_account_id = ? AND event_date >= ?", account.ID, time.Now().UTC().AddDate(0, 0, -30)).
+		Count(&events.Last30Days)
+	if events.Total > 0 {
+		var first, last models.ActivityEvent
+		if err := database.DB.Where("docker_account_id = ?", account.ID).Order("event_date ASC").First(&first).Error; err == nil {
+			events.FirstEventAt = &first.EventDate
+		}
+		if err := database.DB.Where("docker_account_id = ?", account.ID).Order("event_date DESC").First(&last).Error; err == nil {
+			events.LastEventAt = &last.EventDate
+		}
+	}
+	report.Events = events
+
+	probe := &DiagnosticProbe{Endpoint: "/repositories/" + account.DockerUsername + "/"}
+	started := time.Now()
+	status, body, err := s.dockerService.hub.ProbeRepositories(ctx, account.DockerUsername, maxProbeBodyBytes)
+	probe.LatencyMS = time.Since(started).Milliseconds()
+	probe.StatusCode = status
+	probe.Body = body
+	if err != nil {
+		probe.Error = err.Error()
+	}
+	report.DockerHub = probe
+
+	return report
+}
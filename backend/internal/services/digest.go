@@ -0,0 +1,207 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/notify"
+)
+
+// WeeklyDigestSpec is when weekly digests are sent: Monday mornings, for the week before
+const WeeklyDigestSpec = "0 8 * * 1"
+
+// digestHeatmapDays is how much history the digest's heatmap shows
+const digestHeatmapDays = 84
+
+// WeeklyDigest summarizes an account's activity over the seven UTC days up to To
+type WeeklyDigest struct {
+	DockerUsername string
+	From           time.Time
+	To             time.Time
+
+	Pushes     int
+	Pulls      int
+	Builds     int
+	ActiveDays int
+
+	// TopRepository is the repository with the most events in the week, if any
+	TopRepository      string
+	TopRepositoryCount int
+
+	// Streak is the run of active days up to To
+	Streak int
+
+	// Heatmap is the last few months as a text heatmap, for channels that only take text
+	Heatmap string
+}
+
+// DigestService builds weekly digests and sends them to the users who opted in
+type DigestService struct {
+	docker        *DockerHubService
+	heatmap       *HeatmapService
+	notifications *NotificationService
+	clock         Clock
+}
+
+func NewDigestService(docker *DockerHubService, heatmap *HeatmapService, notifications *NotificationService, clock Clock) *DigestService {
+	return &DigestService{
+		docker:        docker,
+		heatmap:       heatmap,
+		notifications: notifications,
+		clock:         clock,
+	}
+}
+
+// SendWeeklyDigests queues last week's digest for every user who opted in, through the
+// channels they subscribed to it, and returns how many were queued. Each user is claimed
+// before their digest is built, so workers running the job at once don't send it twice.
+func (s *DigestService) SendWeeklyDigests() (int, error) {
+	now := s.clock.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	// A digest sent since the last run, even a late one, isn't sent again this week
+	sentBefore := now.Add(-6 * 24 * time.Hour)
+
+	var users []models.User
+	err := database.DB.Where("weekly_digest = ? AND (weekly_digest_sent_at IS NULL OR weekly_digest_sent_at < ?)", true, sentBefore).
+		Where("id NOT IN (?)", UnavailableUserIDs()).
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, user := range users {
+		claimed := database.DB.Model(&models.User{}).
+			Where("id = ? AND (weekly_digest_sent_at IS NULL OR weekly_digest_sent_at < ?)", user.ID, sentBefore).
+			Update("weekly_digest_sent_at", now)
+		if claimed.Error != nil || claimed.RowsAffected == 0 {
+			continue
+		}
+
+		account, err := s.docker.GetDockerAccount(user.ID)
+		if err != nil {
+			continue
+		}
+		digest, err := s.BuildWeeklyDigest(account, to)
+		if err != nil {
+			slog.Error("Failed to build weekly digest", "docker_username", account.DockerUsername, "error", err)
+			continue
+		}
+		if n, err := s.notifications.Notify(user.ID, digest.Message()); err == nil && n > 0 {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// BuildWeeklyDigest summarizes the account's activity over the seven UTC days up to to
+func (s *DigestService) BuildWeeklyDigest(account *models.DockerAccount, to time.Time) (*WeeklyDigest, error) {
+	digest := &WeeklyDigest{
+		DockerUsername: account.DockerUsername,
+		From:           to.AddDate(0, 0, -6),
+		To:             to,
+	}
+
+	var totals []struct {
+		EventType models.EventType
+		Total     int
+	}
+	err := database.DB.Model(&models.DailyActivityAggregate{}).
+		Select("event_type, SUM(count) AS total").
+		Where("docker_account_id = ? AND event_date BETWEEN ? AND ?", account.ID, digest.From, digest.To).
+		Group("event_type").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range totals {
+		switch t.EventType {
+		case models.EventTypePush:
+			digest.Pushes = t.Total
+		case models.EventTypePull:
+			digest.Pulls = t.Total
+		case models.EventTypeBuild:
+			digest.Builds = t.Total
+		}
+	}
+
+	var activeDays int64
+	err = database.DB.Model(&models.DailyActivityAggregate{}).
+		Where("docker_account_id = ? AND event_date BETWEEN ? AND ?", account.ID, digest.From, digest.To).
+		Distinct("event_date").
+		Count(&activeDays).Error
+	if err != nil {
+		return nil, err
+	}
+	digest.ActiveDays = int(activeDays)
+
+	var top struct {
+		Repository string
+		Total      int
+	}
+	err = database.DB.Model(&models.ActivityEvent{}).
+		Select("repository, SUM(count) AS total").
+		Where("docker_account_id = ? AND event_date BETWEEN ? AND ?", account.ID, digest.From, digest.To).
+		Group("repository").
+		Order("total DESC").
+		Limit(1).
+		Scan(&top).Error
+	if err != nil {
+		return nil, err
+	}
+	digest.TopRepository, digest.TopRepositoryCount = top.Repository, top.Total
+
+	digest.Streak = activityStreakTo(account.ID, to)
+
+	// The digest still goes out without its heatmap, such as while a disconnect is pending
+	heatmap, err := s.heatmap.GenerateTextHeatmap(account.DockerUsername, SVGOptions{
+		Days:       digestHeatmapDays,
+		HideLegend: true,
+		HideTotal:  true,
+	}, true)
+	if err == nil {
+		digest.Heatmap = heatmap
+	}
+	return digest, nil
+}
+
+// Message renders the digest as a notification
+func (d *WeeklyDigest) Message() notify.Message {
+	period := d.From.Format("Jan 2") + " to " + d.To.Format("Jan 2")
+	body := fmt.Sprintf("%d pushes, %d pulls and %d builds from %s, active on %d of 7 days.",
+		d.Pushes, d.Pulls, d.Builds, period, d.ActiveDays)
+	if d.TopRepository != "" {
+		body += fmt.Sprintf("\nMost active repository: %s (%d events)", d.TopRepository, d.TopRepositoryCount)
+	}
+	if d.Streak > 0 {
+		body += fmt.Sprintf("\nCurrent streak: %d days", d.Streak)
+	} else {
+		body += "\nNo current streak"
+	}
+	if d.Heatmap != "" {
+		body += "\n\n```\n" + d.Heatmap + "```"
+	}
+
+	return notify.Message{
+		Event: notify.EventWeeklyDigest,
+		Title: "Your week on Docker Hub: " + d.DockerUsername,
+		Body:  body,
+		URL:   config.AppConfig.FrontendURL + "/dashboard",
+		Extra: map[string]string{
+			"docker_username": d.DockerUsername,
+			"from":            d.From.Format("2006-01-02"),
+			"to":              d.To.Format("2006-01-02"),
+			"pushes":          strconv.Itoa(d.Pushes),
+			"pulls":           strconv.Itoa(d.Pulls),
+			"builds":          strconv.Itoa(d.Builds),
+			"active_days":     strconv.Itoa(d.ActiveDays),
+			"top_repository":  d.TopRepository,
+			"streak":          strconv.Itoa(d.Streak),
+		},
+	}
+}
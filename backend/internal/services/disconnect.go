@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+var (
+	ErrDisconnectPending   = errors.New("account is scheduled to be disconnected")
+	ErrNoDisconnectPending = errors.New("no disconnect is pending for this account")
+)
+
+// DisconnectGracePeriod returns how long a disconnected account stays restorable
+func DisconnectGracePeriod() time.Duration {
+	if config.AppConfig.DisconnectGraceDays <= 0 {
+		return 0
+	}
+	return time.Duration(config.AppConfig.DisconnectGraceDays) * 24 * time.Hour
+}
+
+// ScheduleDisconnect deactivates an account and schedules its purge after the grace
+// period. Public endpoints stop serving it straight away, but nothing is deleted until
+// the purge job runs. Without a grace period the account is purged immediately and the
+// returned time is zero.
+func (s *DockerHubService) ScheduleDisconnect(userID, accountID uint) (time.Time, error) {
+	grace := DisconnectGracePeriod()
+	if grace == 0 {
+		return time.Time{}, s.DisconnectAccount(userID, accountID)
+	}
+
+	purgeAt := time.Now().Add(grace)
+	result := database.DB.Model(&models.DockerAccount{}).
+		Where("id = ? AND user_id = ? AND disconnect_scheduled_at IS NULL", accountID, userID).
+		Updates(map[string]interface{}{
+			"is_active":               false,
+			"disconnect_scheduled_at": purgeAt,
+		})
+	if result.Error != nil {
+		return time.Time{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return time.Time{}, ErrDisconnectPending
+	}
+
+	var account models.DockerAccount
+	if err := database.DB.First(&account, accountID).Error; err == nil {
+		s.renderCache.Invalidate(account.DockerUsername)
+	}
+	return purgeAt, nil
+}
+
+// UndoDisconnect restores a user's account whose disconnect is still pending
+func (s *DockerHubService) UndoDisconnect(userID uint) (*models.DockerAccount, error) {
+	var account models.DockerAccount
+	err := database.DB.Where("user_id = ? AND disconnect_scheduled_at > ?", userID, time.Now()).First(&account).Error
+	if err != nil {
+		return nil, ErrNoDisconnectPending
+	}
+
+	result := database.DB.Model(&models.DockerAccount{}).
+		Where("id = ? AND disconnect_scheduled_at IS NOT NULL", account.ID).
+		Updates(map[string]interface{}{
+			"is_active":               true,
+			"disconnect_scheduled_at": nil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNoDisconnectPending
+	}
+
+	account.IsActive = true
+	account.DisconnectScheduledAt = nil
+	s.renderCache.Invalidate(account.DockerUsername)
+	return &account, nil
+}
+
+// PurgeDisconnectedAccounts permanently removes accounts whose grace period has ended,
+// along with their activity, and returns how many were purged
+func (s *DockerHubService) PurgeDisconnectedAccounts() (int, error) {
+	var accounts []models.DockerAccount
+	err := database.DB.Where("disconnect_scheduled_at IS NOT NULL AND disconnect_scheduled_at <= ?", time.Now()).
+		Find(&accounts).Error
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, account := range accounts {
+		if err := s.DisconnectAccount(account.UserID, account.ID); err != nil {
+			slog.Error("Failed to purge disconnected account", "docker_username", account.DockerUsername, "error", err)
+			continue
+		}
+		s.renderCache.Invalidate(account.DockerUsername)
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+)
+
+func TestDisconnectAccountDeletesItsRows(t *testing.T) {
+	testutil.OpenDB(t)
+	account := testutil.CreateAccount(t, "alice", true)
+	kept := testutil.CreateAccount(t, "bob", true)
+
+	now := time.Now()
+	for _, id := range []uint{account.ID, kept.ID} {
+		rows := []interface{}{
+			&models.SyncJob{DockerAccountID: id, TriggeredBy: models.SyncTriggerScheduled, Status: models.SyncJobPending, NextRunAt: now},
+			&models.SyncRun{DockerAccountID: id, TriggeredBy: models.SyncTriggerScheduled, StartedAt: now, FinishedAt: now, Status: models.SyncRunSucceeded},
+			&models.HeatmapSnapshot{DockerAccountID: id, Until: now, OptionsHash: "default", SVG: []byte("<svg/>")},
+		}
+		for _, row := range rows {
+			if err := database.DB.Create(row).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	svc := services.NewDockerHubService(nil, nil, services.SystemClock)
+	if err := svc.DisconnectAccount(account.UserID, account.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"sync jobs", &models.SyncJob{}},
+		{"sync history", &models.SyncRun{}},
+		{"snapshots", &models.HeatmapSnapshot{}},
+	}
+	for _, tt := range tables {
+		var left, others int64
+		database.DB.Model(tt.model).Where("docker_account_id = ?", account.ID).Count(&left)
+		database.DB.Model(tt.model).Where("docker_account_id = ?", kept.ID).Count(&others)
+		if left != 0 {
+			t.Errorf("%s: %d rows left for the disconnected account", tt.name, left)
+		}
+		if others != 1 {
+			t.Errorf("%s: %d rows left for another account, want 1", tt.name, others)
+		}
+	}
+}
@@ -7,12 +7,37 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
-// login exchanges a PAT for a JWT token
-func (s *DockerHubService) login(ctx context.Context, username, pat string) (string, error) {
+// HTTPDoer sends HTTP requests to Docker Hub. *http.Client satisfies it.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DockerHubClient is the Docker Hub API as DockerHubService uses it. DockerHubAPI is the
+// real one; tests can substitute a fake.
+type DockerHubClient interface {
+	Login(ctx context.Context, username, pat string) (string, error)
+	ValidateUsername(ctx context.Context, username string) error
+	FetchRepositories(ctx context.Context, username, token string) ([]DockerHubRepository, error)
+	FetchTags(ctx context.Context, username, repoName, token string) ([]DockerHubTag, error)
+	ProbeRepositories(ctx context.Context, username string, maxBytes int64) (int, string, error)
+}
+
+// DockerHubAPI calls the Docker Hub API at apiURL through client
+type DockerHubAPI struct {
+	apiURL string
+	client HTTPDoer
+}
+
+func NewDockerHubAPI(apiURL string, client HTTPDoer) *DockerHubAPI {
+	return &DockerHubAPI{apiURL: apiURL, client: client}
+}
+
+// Login exchanges a PAT for a JWT token
+func (s *DockerHubAPI) Login(ctx context.Context, username, pat string) (string, error) {
 	if pat == "" {
 		return "", errors.New("PAT is required for login")
 	}
@@ -35,7 +60,7 @@ func (s *DockerHubService) login(ctx context.Context, username, pat string) (str
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := httpClient.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("login request failed: %w", err)
 	}
@@ -63,8 +88,8 @@ func (s *DockerHubService) login(ctx context.Context, username, pat string) (str
 	return loginResp.Token, nil
 }
 
-// validateUsername checks if a Docker Hub username exists
-func (s *DockerHubService) validateUsername(ctx context.Context, username string) error {
+// ValidateUsername checks if a Docker Hub username exists
+func (s *DockerHubAPI) ValidateUsername(ctx context.Context, username string) error {
 	url := fmt.Sprintf("%s/users/%s", s.apiURL, username)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -72,7 +97,7 @@ func (s *DockerHubService) validateUsername(ctx context.Context, username string
 		return err
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -90,7 +115,7 @@ func (s *DockerHubService) validateUsername(ctx context.Context, username string
 }
 
 // FetchRepositories fetches repositories for a Docker Hub user
-func (s *DockerHubService) FetchRepositories(ctx context.Context, username, token string) ([]DockerHubRepository, error) {
+func (s *DockerHubAPI) FetchRepositories(ctx context.Context, username, token string) ([]DockerHubRepository, error) {
 	url := fmt.Sprintf("%s/repositories/%s/?page_size=100", s.apiURL, username)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -102,7 +127,7 @@ func (s *DockerHubService) FetchRepositories(ctx context.Context, username, toke
 		req.Header.Set("Authorization", "JWT "+token)
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +135,7 @@ func (s *DockerHubService) FetchRepositories(ctx context.Context, username, toke
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		log.Printf("Failed to fetch repos: %d - %s", resp.StatusCode, string(body))
+		slog.Warn("Failed to fetch repositories", "status", resp.StatusCode, "body", string(body))
 		return nil, fmt.Errorf("failed to fetch repositories: status %d", resp.StatusCode)
 	}
 
@@ -125,7 +150,7 @@ func (s *DockerHubService) FetchRepositories(ctx context.Context, username, toke
 }
 
 // FetchTags fetches tags for a specific repository
-func (s *DockerHubService) FetchTags(ctx context.Context, username, repoName, token string) ([]DockerHubTag, error) {
+func (s *DockerHubAPI) FetchTags(ctx context.Context, username, repoName, token string) ([]DockerHubTag, error) {
 	url := fmt.Sprintf("%s/repositories/%s/%s/tags?page_size=100", s.apiURL, username, repoName)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -137,7 +162,7 @@ func (s *DockerHubService) FetchTags(ctx context.Context, username, repoName, to
 		req.Header.Set("Authorization", "JWT "+token)
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -156,3 +181,28 @@ func (s *DockerHubService) FetchTags(ctx context.Context, username, repoName, to
 
 	return result.Results, nil
 }
+
+// ProbeRepositories performs an unauthenticated repository listing and returns the
+// status code and a truncated body, used for troubleshooting reports
+func (s *DockerHubAPI) ProbeRepositories(ctx context.Context, username string, maxBytes int64) (int, string, error) {
+	url := fmt.Sprintf("%s/repositories/%s/?page_size=5", s.apiURL, username)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+)
+
+// recordingDoer is an HTTPDoer that notes each request before sending it through client
+type recordingDoer struct {
+	client *http.Client
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (d *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.mu.Lock()
+	d.requests = append(d.requests, req.Method+" "+req.URL.Path+" "+req.Header.Get("Authorization"))
+	d.mu.Unlock()
+	return d.client.Do(req)
+}
+
+func TestDockerHubAPIUsesInjectedClient(t *testing.T) {
+	hub := testutil.NewDockerHub(t)
+	hub.AddUser("alice", "alice-pat", testutil.DockerHubRepo{
+		Name:        "api",
+		LastUpdated: "2026-10-14T09:30:00Z",
+		PullCount:   42,
+		Tags:        []testutil.DockerHubTag{{Name: "latest", LastPushed: "2026-10-14T09:30:00Z"}},
+	})
+	doer := &recordingDoer{client: hub.Client()}
+	api := services.NewDockerHubAPI(hub.URL, doer)
+	ctx := context.Background()
+
+	if err := api.ValidateUsername(ctx, "mallory"); err == nil {
+		t.Error("validated a username Docker Hub doesn't know")
+	}
+	if _, err := api.Login(ctx, "alice", "wrong-pat"); !errors.Is(err, services.ErrInvalidDockerToken) {
+		t.Errorf("login with a wrong PAT: error %v, want %v", err, services.ErrInvalidDockerToken)
+	}
+
+	token, err := api.Login(ctx, "alice", "alice-pat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := api.FetchRepositories(ctx, "alice", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Name != "api" || repos[0].PullCount != 42 {
+		t.Errorf("repositories %+v, want api with 42 pulls", repos)
+	}
+	tags, err := api.FetchTags(ctx, "alice", "api", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0].Name != "latest" || tags[0].TagLastPushed != "2026-10-14T09:30:00Z" {
+		t.Errorf("tags %+v, want latest", tags)
+	}
+
+	want := []string{
+		"GET /users/mallory ",
+		"POST /users/login ",
+		"POST /users/login ",
+		"GET /repositories/alice/ JWT " + token,
+		"GET /repositories/alice/api/tags JWT " + token,
+	}
+	if got := strings.Join(doer.requests, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("requests sent:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
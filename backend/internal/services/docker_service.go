@@ -2,13 +2,18 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"docker-heatmap/internal/config"
 	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/logging"
 	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/notify"
 	"docker-heatmap/internal/utils"
 
 	"gorm.io/gorm"
@@ -19,18 +24,28 @@ var (
 	ErrDockerAccountNotFound = errors.New("docker account not found")
 	ErrDockerAccountExists   = errors.New("docker account already connected")
 	ErrInvalidDockerToken    = errors.New("invalid docker hub access token")
+	ErrReadOnly              = errors.New("service is in read-only mode")
 )
 
-// Use shared HTTP client from utils package
-var httpClient = utils.HTTPClient
-
 type DockerHubService struct {
-	apiURL string
+	hub           DockerHubClient
+	activity      ActivityRepository
+	clock         Clock
+	notifications *NotificationService
+	renderCache   *RenderCache
+	audit         *AuditService
 }
 
-func NewDockerHubService() *DockerHubService {
+// NewDockerHubService syncs activity fetched through hub into activity, dating what it
+// does by clock
+func NewDockerHubService(hub DockerHubClient, activity ActivityRepository, clock Clock) *DockerHubService {
 	return &DockerHubService{
-		apiURL: config.AppConfig.DockerHubAPIURL,
+		hub:           hub,
+		activity:      activity,
+		clock:         clock,
+		notifications: NewNotificationService(),
+		renderCache:   NewRenderCache(),
+		audit:         NewAuditService(),
 	}
 }
 
@@ -54,6 +69,10 @@ func parseDockerHubTime(dateStr string) (time.Time, error) {
 
 // ConnectAccount validates and connects a Docker Hub account.
 func (s *DockerHubService) ConnectAccount(ctx context.Context, userID uint, dockerUsername, accessToken string) (*models.DockerAccount, error) {
+	if DockerUsernameBlocked(dockerUsername) {
+		return nil, ErrDockerUsernameBlocked
+	}
+
 	var account models.DockerAccount
 
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
@@ -69,14 +88,20 @@ func (s *DockerHubService) ConnectAccount(ctx context.Context, userID uint, dock
 
 		if len(accountIDs) > 0 {
 			tx.Unscoped().Where("docker_account_id IN ?", accountIDs).Delete(&models.ActivityEvent{})
+			tx.Where("docker_account_id IN ?", accountIDs).Delete(&models.DailyActivityAggregate{})
+			tx.Where("docker_account_id IN ?", accountIDs).Delete(&models.DailyViewCount{})
+			tx.Where("docker_account_id IN ?", accountIDs).Delete(&models.NamespaceClaim{})
+			tx.Where("docker_account_id IN ?", accountIDs).Delete(&models.ActivityArchive{})
+			tx.Where("docker_account_id IN ?", accountIDs).Delete(&models.DockerRepository{})
+			tx.Where("docker_account_id IN ?", accountIDs).Delete(&models.IngestReceipt{})
 			tx.Unscoped().Where("id IN ?", accountIDs).Delete(&models.DockerAccount{})
 		}
 
 		// 3. Validation
-		if err := s.validateUsername(ctx, dockerUsername); err != nil {
+		if err := s.hub.ValidateUsername(ctx, dockerUsername); err != nil {
 			return err
 		}
-		if _, err := s.login(ctx, dockerUsername, accessToken); err != nil {
+		if _, err := s.hub.Login(ctx, dockerUsername, accessToken); err != nil {
 			return fmt.Errorf("invalid access token: %w", err)
 		}
 
@@ -103,30 +128,95 @@ func (s *DockerHubService) ConnectAccount(ctx context.Context, userID uint, dock
 	}
 
 	// Initial sync
-	go func() {
-		syncCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		defer cancel()
-		s.SyncActivity(syncCtx, account.ID)
-	}()
+	if err := EnqueueSync(account.ID, models.SyncTriggerConnect, s.clock.Now()); err != nil {
+		logging.FromContext(ctx).Error("Failed to queue initial sync", "docker_username", account.DockerUsername, "error", err)
+	}
 
 	return &account, nil
 }
 
-// SyncActivity syncs Docker Hub activity for an account
-func (s *DockerHubService) SyncActivity(ctx context.Context, accountID uint) error {
+// SyncActivity syncs Docker Hub activity for an account, recording the run in its sync
+// history. triggeredBy says what started it (models.SyncTrigger*). Once too many syncs have
+// failed in a row, the account is dead-lettered and its failures wrap ErrSyncDeadLettered;
+// a sync that succeeds takes it out of that state.
+func (s *DockerHubService) SyncActivity(ctx context.Context, accountID uint, triggeredBy string) (err error) {
+	if config.IsReadOnly() {
+		return ErrReadOnly
+	}
+
 	var account models.DockerAccount
 	if err := database.DB.First(&account, accountID).Error; err != nil {
 		return err
 	}
+	if account.DisabledAt != nil {
+		return ErrAccountDisabled
+	}
+	if err := userUnavailable(account.UserID); err != nil {
+		return err
+	}
+	logger := logging.FromContext(ctx).With("docker_username", account.DockerUsername)
 
 	account.SyncInProgress = true
 	database.DB.Save(&account)
+	publishSyncProgress(account.ID, SyncProgress{Stage: SyncStageFetching})
 
+	previousError := account.LastSyncError
+	previousDay := latestActivityDay(account.ID)
+	progress := SyncProgress{Stage: SyncStageSyncing}
+	hub := &countingDockerHub{DockerHubClient: s.hub}
+	run := models.SyncRun{DockerAccountID: account.ID, TriggeredBy: triggeredBy, StartedAt: s.clock.Now()}
+	eventsCreated := 0
 	defer func() {
 		account.SyncInProgress = false
-		now := time.Now()
+		now := s.clock.Now()
 		account.LastSyncAt = &now
+		deadLettered := countSyncResult(&account, now, err)
 		database.DB.Save(&account)
+
+		run.Repositories = progress.Done
+		run.EventsCreated = eventsCreated
+		run.APICalls = hub.calls
+		recordSyncRun(&run, now, err)
+		if err := s.activity.RebuildDailyAggregates(account.ID); err != nil {
+			logger.Error("Failed to rebuild daily aggregates", "error", err)
+		}
+		s.renderCache.Invalidate(account.DockerUsername)
+
+		progress.Repository = ""
+		if err != nil {
+			progress.Stage = SyncStageFailed
+			progress.Error = account.LastSyncError
+			if progress.Error == "" {
+				progress.Error = "Sync failed"
+			}
+		} else {
+			progress.Stage = SyncStageDone
+		}
+		publishSyncProgress(account.ID, progress)
+
+		// Only notify when syncing starts failing, not on every failed retry
+		if account.LastSyncError != "" && previousError == "" {
+			s.notifications.Notify(account.UserID, notify.Message{
+				Event: notify.EventSyncFailed,
+				Title: "Docker Hub sync failed for " + account.DockerUsername,
+				Body:  account.LastSyncError + ". Check your access token in the dashboard.",
+				URL:   config.AppConfig.FrontendURL + "/dashboard",
+			})
+		}
+		if err == nil {
+			s.notifySyncCompleted(&account, &run)
+			s.notifyNewActivity(&account, previousDay)
+			if err := s.evaluateAchievements(&account); err != nil {
+				logger.Error("Failed to evaluate achievements", "error", err)
+			}
+		}
+		if deadLettered {
+			logger.Warn("Stopped syncing after too many failed syncs in a row", "failures", account.SyncFailures)
+			s.notifySyncDeadLettered(&account)
+		}
+		if err != nil && account.SyncDeadLetteredAt != nil {
+			err = fmt.Errorf("%w: %w", ErrSyncDeadLettered, err)
+		}
 	}()
 
 	pat, err := utils.Decrypt(account.EncryptedToken, account.TokenIV)
@@ -134,113 +224,420 @@ func (s *DockerHubService) SyncActivity(ctx context.Context, accountID uint) err
 		return err
 	}
 
-	token, err := s.login(ctx, account.DockerUsername, pat)
+	token, err := hub.Login(ctx, account.DockerUsername, pat)
 	if err != nil {
 		account.LastSyncError = "Authentication failed"
+		if err == ErrInvalidDockerToken && previousError == "" {
+			s.audit.Record(account.UserID, models.AuditDockerTokenInvalid, "Docker Hub rejected the access token during sync", "", "")
+		}
 		return err
 	}
 
-	repos, err := s.FetchRepositories(ctx, account.DockerUsername, token)
+	repos, err := hub.FetchRepositories(ctx, account.DockerUsername, token)
 	if err != nil {
 		account.LastSyncError = "Failed to fetch repositories"
 		return err
 	}
+	namespaces := []syncNamespace{{name: account.DockerUsername, repos: repos}}
+
+	// Verified extra namespaces are recorded as namespace/repo so they can't collide with
+	// the account's own repositories
+	for _, namespace := range verifiedNamespaces(account.ID) {
+		nsRepos, err := hub.FetchRepositories(ctx, namespace, token)
+		if err != nil {
+			logger.Warn("Failed to fetch repositories of a namespace", "namespace", namespace, "error", err)
+			continue
+		}
+		namespaces = append(namespaces, syncNamespace{name: namespace, prefix: namespace + "/", repos: nsRepos})
+	}
 
+	// Listing every namespace first gives the repository total progress is counted against
+	for _, ns := range namespaces {
+		progress.Total += len(ns.repos)
+	}
+	publishSyncProgress(account.ID, progress)
+
+	for _, ns := range namespaces {
+		if err := s.activity.StoreRepositories(account.ID, ns.prefix, ns.repos); err != nil {
+			logger.Error("Failed to store repositories of a namespace", "namespace", ns.name, "error", err)
+		}
+		eventsCreated += s.recordNamespaceActivity(ctx, hub, &account, ns.name, ns.prefix, ns.repos, token, func(repo string) {
+			progress.Done++
+			progress.Repository = repo
+			publishSyncProgress(account.ID, progress)
+		})
+	}
+
+	account.LastSyncError = ""
+	return nil
+}
+
+// syncNamespace is a namespace whose repositories a sync records, named with prefix
+type syncNamespace struct {
+	name   string
+	prefix string
+	repos  []DockerHubRepository
+}
+
+// recordNamespaceActivity records push events for a namespace's repositories and their
+// tags, fetched through hub, naming each repository with prefix, and returns how many new
+// rows were created. done is called with each repository's name once it is recorded.
+func (s *DockerHubService) recordNamespaceActivity(ctx context.Context, hub DockerHubClient, account *models.DockerAccount, namespace, prefix string, repos []DockerHubRepository, token string, done func(repo string)) int {
 	eventsCreated := 0
 	for _, repo := range repos {
 		if repo.LastUpdated != "" {
 			if t, err := parseDockerHubTime(repo.LastUpdated); err == nil {
-				if s.createActivity(&account, models.EventTypePush, t, repo.Name, "") {
+				if s.createActivity(account, models.EventTypePush, models.EventSourceRepoUpdate, t, prefix+repo.Name, "") {
 					eventsCreated++
 				}
 			}
 		}
 
-		tags, _ := s.FetchTags(ctx, account.DockerUsername, repo.Name, token)
+		tags, _ := hub.FetchTags(ctx, namespace, repo.Name, token)
 		for _, tag := range tags {
 			if tag.TagLastPushed != "" {
 				if t, err := parseDockerHubTime(tag.TagLastPushed); err == nil {
-					if s.createActivity(&account, models.EventTypePush, t, repo.Name, tag.Name) {
+					if s.createActivity(account, models.EventTypePush, models.EventSourceTagPush, t, prefix+repo.Name, tag.Name) {
 						eventsCreated++
 					}
 				}
 			}
 		}
+		done(prefix + repo.Name)
 	}
+	return eventsCreated
+}
 
-	account.LastSyncError = ""
-	return nil
+// createActivity records an event, or bumps the count of the existing row for the same
+// day, repository, tag and type when it happened at a different time. The upsert relies on
+// the unique event index, so syncs that race can't create duplicate rows, and a push seen
+// again by a later sync isn't counted twice. Reports whether a new row was created.
+func (s *DockerHubService) createActivity(account *models.DockerAccount, eventType models.EventType, source models.EventSource, eventDate time.Time, repo, tag string) bool {
+	inserted, err := s.activity.UpsertActivity(account.ID, eventType, source, eventDate, repo, tag)
+	if err != nil {
+		slog.Error("Failed to record activity", "docker_username", account.DockerUsername, "repository", repo, "tag", tag, "error", err)
+		return false
+	}
+	return inserted
 }
 
-func (s *DockerHubService) createActivity(account *models.DockerAccount, eventType models.EventType, eventDate time.Time, repo, tag string) bool {
+// upsertActivity is createActivity on db, which may be a transaction. The row keeps the
+// latest time it saw; rows from before event_at was recorded take the time without
+// counting it, since it is most likely the push they were created for.
+func upsertActivity(db *gorm.DB, accountID uint, eventType models.EventType, source models.EventSource, eventDate time.Time, repo, tag string) (bool, error) {
 	normalizedDate := time.Date(eventDate.Year(), eventDate.Month(), eventDate.Day(), 0, 0, 0, 0, time.UTC)
+	eventAt := eventDate.UTC()
+	now := time.Now()
+
+	if database.IsMySQL() {
+		// MySQL reports one affected row for an insert, two for an update and none when the
+		// row is left as it was. Assignments see the ones before them, so event_at goes last.
+		result := db.Exec(`
+			INSERT INTO activity_events
+				(created_at, updated_at, docker_account_id, event_type, event_date, event_at, count, source, repository, tag)
+			VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				count = IF(event_at <=> VALUES(event_at), count, count + IF(event_at IS NULL, 0, 1)),
+				updated_at = IF(event_at <=> VALUES(event_at), updated_at, VALUES(updated_at)),
+				event_at = GREATEST(COALESCE(event_at, VALUES(event_at)), VALUES(event_at))
+		`, now, now, accountID, eventType, normalizedDate, eventAt, source, repo, tag)
+		return result.RowsAffected == 1, result.Error
+	}
 
-	var existing models.ActivityEvent
-	err := database.DB.Where("docker_account_id = ? AND event_date = ? AND repository = ? AND tag = ?",
-		account.ID, normalizedDate, repo, tag).First(&existing).Error
+	// xmax is 0 only for freshly inserted rows. A row the WHERE leaves alone returns nothing.
+	var inserted bool
+	err := db.Raw(`
+		INSERT INTO activity_events
+			(created_at, updated_at, docker_account_id, event_type, event_date, event_at, count, source, repository, tag)
+		VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?, ?)
+		ON CONFLICT (docker_account_id, event_date, repository, tag, event_type) WHERE deleted_at IS NULL
+		DO UPDATE SET
+			count = activity_events.count + CASE WHEN activity_events.event_at IS NULL THEN 0 ELSE 1 END,
+			updated_at = EXCLUDED.updated_at,
+			event_at = GREATEST(activity_events.event_at, EXCLUDED.event_at)
+		WHERE activity_events.event_at IS DISTINCT FROM EXCLUDED.event_at
+		RETURNING xmax = 0
+	`, now, now, accountID, eventType, normalizedDate, eventAt, source, repo, tag).Row().Scan(&inserted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return inserted, err
+}
+
+// ActivityFilter narrows and weights the events aggregated into an activity summary
+type ActivityFilter struct {
+	Location   *time.Location               // Timezone for day bucketing (nil means UTC)
+	EventTypes []models.EventType           // Event types to include (empty means all)
+	Weights    map[models.EventType]float64 // Per-type weight used for levels (missing means 1)
+	// MinConfidence excludes events whose timestamp source ranks below it (zero means all)
+	MinConfidence models.Confidence
+	// RepoBreakdown fills each day's per-repository counts
+	RepoBreakdown bool
+	// Tags limits events to tags matching any of these glob patterns (empty means all)
+	Tags []string
+}
 
-	if err == nil {
-		existing.Count++
-		database.DB.Save(&existing)
+func (f ActivityFilter) includes(event *models.ActivityEvent) bool {
+	if len(f.Tags) > 0 && !matchesTag(event.Tag, f.Tags) {
 		return false
 	}
+	return event.ResolvedSource().Confidence() >= f.MinConfidence
+}
 
-	database.DB.Create(&models.ActivityEvent{
-		DockerAccountID: account.ID,
-		EventType:       eventType,
-		EventDate:       normalizedDate,
-		Repository:      repo,
-		Tag:             tag,
-		Count:           1,
-	})
-	return true
+func (f ActivityFilter) weight(eventType models.EventType) float64 {
+	if w, ok := f.Weights[eventType]; ok {
+		return w
+	}
+	return 1
+}
+
+func (s *DockerHubService) GetActivitySummary(dockerUsername string, days int, filter ActivityFilter) ([]models.ActivitySummary, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := s.clock.Now().In(loc)
+	return s.GetActivitySummaryRange(dockerUsername, now.AddDate(0, 0, -days), now, filter)
 }
 
-func (s *DockerHubService) GetActivitySummary(dockerUsername string, days int) ([]models.ActivitySummary, error) {
+// GetActivitySummaryRange aggregates activity per day between startDate and endDate (inclusive),
+// bucketing each event into the calendar day it happened on in the filter's location.
+// Levels are computed from the weighted score so low-signal event types don't dominate.
+func (s *DockerHubService) GetActivitySummaryRange(dockerUsername string, startDate, endDate time.Time, filter ActivityFilter) ([]models.ActivitySummary, error) {
 	account, err := s.GetDockerAccountByUsername(dockerUsername)
 	if err != nil {
 		return nil, err
 	}
 
-	startDate := time.Now().UTC().AddDate(0, 0, -days)
-	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	startDate = startDate.In(loc)
+	endDate = endDate.In(loc)
+	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+
+	if usesDailyAggregates(filter, loc) {
+		events, err := loadDailyAggregates([]uint{account.ID}, startDate, endDate, filter)
+		if err != nil {
+			return nil, err
+		}
+		return summarizeEvents(events, startDate, endDate, filter), nil
+	}
+
+	// event_date is the UTC day, so widen the scan by a day on each side to cover any offset
+	query := database.DB.Where("docker_account_id = ? AND event_date >= ? AND event_date <= ?",
+		account.ID, startDate.AddDate(0, 0, -1).UTC(), endDate.AddDate(0, 0, 1).UTC())
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
 
 	var events []models.ActivityEvent
-	database.DB.Where("docker_account_id = ? AND event_date >= ?", account.ID, startDate).Find(&events)
+	query.Find(&events)
+
+	return summarizeEvents(events, startDate, endDate, filter), nil
+}
+
+// summarizeEvents buckets events into one summary per day from startDate to endDate,
+// which must be midnights in the filter's location
+func summarizeEvents(events []models.ActivityEvent, startDate, endDate time.Time, filter ActivityFilter) []models.ActivitySummary {
+	loc := startDate.Location()
+	startKey := startDate.Format("2006-01-02")
+	endKey := endDate.Format("2006-01-02")
 
 	dateMap := make(map[string]*models.ActivitySummary)
-	maxCount := 0
+	scores := make(map[string]float64)
+	maxScore := 0.0
 
 	for _, event := range events {
-		dateStr := event.EventDate.Format("2006-01-02")
+		if !filter.includes(&event) {
+			continue
+		}
+		dateStr := event.LocalDate(loc)
+		if dateStr < startKey || dateStr > endKey {
+			continue
+		}
 		if _, ok := dateMap[dateStr]; !ok {
 			dateMap[dateStr] = &models.ActivitySummary{Date: dateStr}
 		}
-		dateMap[dateStr].TotalCount += event.Count
-		if dateMap[dateStr].TotalCount > maxCount {
-			maxCount = dateMap[dateStr].TotalCount
+		summary := dateMap[dateStr]
+		summary.TotalCount += event.Count
+		switch event.EventType {
+		case models.EventTypePush:
+			summary.Pushes += event.Count
+		case models.EventTypePull:
+			summary.Pulls += event.Count
+		case models.EventTypeBuild:
+			summary.Builds += event.Count
+		}
+		if filter.RepoBreakdown && event.Repository != "" {
+			if summary.Repositories == nil {
+				summary.Repositories = make(map[string]int)
+			}
+			summary.Repositories[event.Repository] += event.Count
+		}
+
+		scores[dateStr] += float64(event.Count) * filter.weight(event.EventType)
+		if scores[dateStr] > maxScore {
+			maxScore = scores[dateStr]
 		}
 	}
 
-	summaries := make([]models.ActivitySummary, 0, days+1)
-	for d := startDate; !d.After(time.Now().UTC()); d = d.AddDate(0, 0, 1) {
+	summaries := make([]models.ActivitySummary, 0, int(endDate.Sub(startDate).Hours()/24)+1)
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
 		dateStr := d.Format("2006-01-02")
 		summary := models.ActivitySummary{Date: dateStr}
 		if s, ok := dateMap[dateStr]; ok {
-			summary.TotalCount = s.TotalCount
-			summary.Level = calculateLevel(s.TotalCount, maxCount)
+			summary = *s
+			summary.Level = calculateLevel(scores[dateStr], maxScore)
 		}
 		summaries = append(summaries, summary)
 	}
 
-	return summaries, nil
+	return summaries
+}
+
+// GetPunchcard counts activity by weekday (Sunday = 0) and hour of day over the last
+// days, in the filter's location. Only events with a recorded timestamp are included.
+func (s *DockerHubService) GetPunchcard(dockerUsername string, days int, filter ActivityFilter) ([7][24]int, error) {
+	var grid [7][24]int
+
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return grid, err
+	}
+
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	query := database.DB.Where("docker_account_id = ? AND event_at IS NOT NULL AND event_at >= ?",
+		account.ID, s.clock.Now().UTC().AddDate(0, 0, -days))
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+
+	var events []models.ActivityEvent
+	query.Find(&events)
+
+	for _, event := range events {
+		if !filter.includes(&event) {
+			continue
+		}
+		t := event.EventAt.In(loc)
+		grid[t.Weekday()][t.Hour()] += event.Count
+	}
+
+	return grid, nil
+}
+
+// GetRawEvents returns an account's individual events from the last days, newest first,
+// skipping events whose timestamp source ranks below minConfidence
+func (s *DockerHubService) GetRawEvents(accountID uint, days, limit int, minConfidence models.Confidence) ([]models.ActivityEvent, error) {
+	var events []models.ActivityEvent
+	err := database.DB.Where("docker_account_id = ? AND event_date >= ?", accountID, s.clock.Now().UTC().AddDate(0, 0, -days-1)).
+		Order("event_date DESC, id DESC").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]models.ActivityEvent, 0, len(events))
+	for _, event := range events {
+		if event.ResolvedSource().Confidence() < minConfidence {
+			continue
+		}
+		event.Source = event.ResolvedSource()
+		filtered = append(filtered, event)
+		if len(filtered) == limit {
+			break
+		}
+	}
+	return filtered, nil
+}
+
+// ParseEventTypes converts a comma-separated list like "push,pull" into event types,
+// ignoring unknown values
+func ParseEventTypes(value string) []models.EventType {
+	var types []models.EventType
+	for _, part := range strings.Split(value, ",") {
+		switch models.EventType(strings.ToLower(strings.TrimSpace(part))) {
+		case models.EventTypePush:
+			types = append(types, models.EventTypePush)
+		case models.EventTypePull:
+			types = append(types, models.EventTypePull)
+		case models.EventTypeBuild:
+			types = append(types, models.EventTypeBuild)
+		}
+	}
+	return types
+}
+
+// GetAccountOwner returns the user that connected the given Docker username, unless they
+// were suspended or are about to be deleted
+func (s *DockerHubService) GetAccountOwner(dockerUsername string) (*models.User, error) {
+	var user models.User
+	err := database.DB.Joins("JOIN docker_accounts ON docker_accounts.user_id = users.id AND docker_accounts.deleted_at IS NULL").
+		Where("docker_accounts.docker_username = ? AND users.suspended_at IS NULL AND users.deletion_scheduled_at IS NULL",
+			dockerUsername).First(&user).Error
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// ResolveLocation picks the timezone used to bucket a user's activity: an explicit tz
+// override when valid, otherwise the account owner's preference, otherwise UTC
+func (s *DockerHubService) ResolveLocation(dockerUsername, tz string) *time.Location {
+	if tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+
+	if user, err := s.GetAccountOwner(dockerUsername); err == nil && user.Timezone != "" {
+		if loc, err := time.LoadLocation(user.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	return time.UTC
+}
+
+// ResolveWeekStart picks the first day of the week for rendering: an explicit
+// week_start override, otherwise the account owner's preference, otherwise Sunday
+func (s *DockerHubService) ResolveWeekStart(dockerUsername, weekStart string) time.Weekday {
+	if day, ok := ParseWeekStart(weekStart); ok {
+		return day
+	}
+
+	if user, err := s.GetAccountOwner(dockerUsername); err == nil {
+		if day, ok := ParseWeekStart(user.WeekStart); ok {
+			return day
+		}
+	}
+
+	return time.Sunday
 }
 
-func calculateLevel(count, maxCount int) int {
-	if count == 0 || maxCount == 0 {
+// ParseWeekStart converts a week_start value ("sunday" or "monday") to a weekday
+func ParseWeekStart(value string) (time.Weekday, bool) {
+	switch strings.ToLower(value) {
+	case "sunday", "sun":
+		return time.Sunday, true
+	case "monday", "mon":
+		return time.Monday, true
+	}
+	return time.Sunday, false
+}
+
+func calculateLevel(score, maxScore float64) int {
+	if score <= 0 || maxScore <= 0 {
 		return 0
 	}
-	ratio := float64(count) / float64(maxCount)
+	ratio := score / maxScore
 	if ratio > 0.75 {
 		return 4
 	}
@@ -261,19 +658,69 @@ func (s *DockerHubService) GetDockerAccount(userID uint) (*models.DockerAccount,
 	return &account, nil
 }
 
+// GetDockerAccountByUsername looks up an account for public use; accounts with a pending
+// disconnect, or whose owner was suspended, are treated as gone
 func (s *DockerHubService) GetDockerAccountByUsername(dockerUsername string) (*models.DockerAccount, error) {
 	var account models.DockerAccount
-	if err := database.DB.Where("docker_username = ?", dockerUsername).First(&account).Error; err != nil {
+	err := database.DB.Where("docker_username = ? AND disconnect_scheduled_at IS NULL", dockerUsername).
+		Where("user_id NOT IN (?)", UnavailableUserIDs()).
+		First(&account).Error
+	if err != nil {
 		return nil, ErrDockerAccountNotFound
 	}
 	return &account, nil
 }
 
+// DisconnectAccount permanently removes an account and its activity
 func (s *DockerHubService) DisconnectAccount(userID, accountID uint) error {
-	database.DB.Unscoped().Where("docker_account_id = ?", accountID).Delete(&models.ActivityEvent{})
-	result := database.DB.Unscoped().Where("id = ? AND user_id = ?", accountID, userID).Delete(&models.DockerAccount{})
-	if result.RowsAffected == 0 {
-		return ErrDockerAccountNotFound
-	}
-	return nil
+	// Dependent rows go first, in one transaction, so a failure partway can't leave an
+	// account that still exists but has lost its activity
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("docker_account_id = ?", accountID).Delete(&models.ActivityEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.DailyActivityAggregate{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.DailyViewCount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.NamespaceClaim{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.ActivityArchive{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.DockerRepository{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.IngestReceipt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.WrappedReport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.Achievement{}).Error; err != nil {
+			return err
+		}
+		// A queued sync would otherwise run against the missing account until it gave up
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.SyncJob{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.SyncRun{}).Error; err != nil {
+			return err
+		}
+		// Snapshots are frozen renders of the activity, so they go with it
+		if err := tx.Where("docker_account_id = ?", accountID).Delete(&models.HeatmapSnapshot{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id = ? AND user_id = ?", accountID, userID).Delete(&models.DockerAccount{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDockerAccountNotFound
+		}
+		return nil
+	})
 }
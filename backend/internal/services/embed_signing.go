@@ -0,0 +1,56 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/config"
+)
+
+const (
+	// DefaultEmbedSignatureDays and MaxEmbedSignatureDays bound how long a signed embed URL works
+	DefaultEmbedSignatureDays = 30
+	MaxEmbedSignatureDays     = 365
+)
+
+var (
+	ErrEmbedSignatureInvalid = errors.New("embed signature is missing or invalid")
+	ErrEmbedSignatureExpired = errors.New("embed signature has expired")
+)
+
+// SignEmbed returns the exp and sig query params that let anyone holding the URL see a
+// private user's heatmap images and activity until expires. The signature covers the
+// Docker username and expiry only, so display options can still be changed on the URL.
+func SignEmbed(dockerUsername string, expires time.Time) (exp, sig string) {
+	exp = strconv.FormatInt(expires.Unix(), 10)
+	return exp, embedSignature(dockerUsername, exp)
+}
+
+// VerifyEmbed checks the exp and sig query params of a signed embed URL
+func VerifyEmbed(dockerUsername, exp, sig string) error {
+	if exp == "" || sig == "" || !hmac.Equal([]byte(sig), []byte(embedSignature(dockerUsername, exp))) {
+		return ErrEmbedSignatureInvalid
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrEmbedSignatureInvalid
+	}
+	if time.Now().Unix() >= expires {
+		return ErrEmbedSignatureExpired
+	}
+	return nil
+}
+
+// embedSignature is an HMAC-SHA256 over the username and expiry, keyed with a key
+// derived from JWT_SECRET so a token signature can't double as an embed signature
+func embedSignature(dockerUsername, exp string) string {
+	keyMAC := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	keyMAC.Write([]byte("docker-heatmap embed url"))
+	mac := hmac.New(sha256.New, keyMAC.Sum(nil))
+	mac.Write([]byte(dockerUsername + "\n" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
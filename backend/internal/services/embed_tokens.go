@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+)
+
+const (
+	// MaxEmbedTokens caps how many embed tokens one user can have
+	MaxEmbedTokens = 20
+	// MaxEmbedTokenOrigins caps how many sites one embed token can be limited to
+	MaxEmbedTokenOrigins = 10
+
+	embedTokenPrefix = "dhe_"
+	// embedTokenUseInterval is how stale last_used_at may get, so busy embeds don't
+	// write on every view
+	embedTokenUseInterval = time.Hour
+)
+
+var (
+	ErrEmbedTokenLimit    = errors.New("embed token limit reached; revoke one first")
+	ErrEmbedTokenNotFound = errors.New("embed token not found")
+	ErrEmbedTokenInvalid  = errors.New("embed token is invalid or revoked")
+	ErrEmbedTokenOrigins  = fmt.Errorf("allowed origins must be at most %d http(s) origins, such as https://wiki.example.com", MaxEmbedTokenOrigins)
+)
+
+// EmbedTokenService manages named tokens that show a private profile's heatmap until
+// revoked
+type EmbedTokenService struct{}
+
+func NewEmbedTokenService() *EmbedTokenService {
+	return &EmbedTokenService{}
+}
+
+// List returns the user's embed tokens, newest first
+func (s *EmbedTokenService) List(userID uint) ([]models.EmbedToken, error) {
+	var tokens []models.EmbedToken
+	err := database.DB.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Create mints a token for the user, usable from allowedOrigins or anywhere if it is
+// empty, and returns it with its value, which can't be read back later
+func (s *EmbedTokenService) Create(userID uint, name string, allowedOrigins []string) (*models.EmbedToken, string, error) {
+	origins, err := NormalizeOrigins(allowedOrigins)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.EmbedToken{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count >= MaxEmbedTokens {
+		return nil, "", ErrEmbedTokenLimit
+	}
+
+	random, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+	value := embedTokenPrefix + random
+	token := &models.EmbedToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    value[:len(embedTokenPrefix)+6],
+		TokenHash: hashEmbedToken(value),
+
+		AllowedOrigins: origins,
+	}
+	if err := database.DB.Create(token).Error; err != nil {
+		return nil, "", err
+	}
+	return token, value, nil
+}
+
+// SetAllowedOrigins changes which sites may use one of the user's tokens; an empty list
+// allows any
+func (s *EmbedTokenService) SetAllowedOrigins(userID, id uint, allowedOrigins []string) (*models.EmbedToken, error) {
+	origins, err := NormalizeOrigins(allowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+
+	var token models.EmbedToken
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&token).Error; err != nil {
+		return nil, ErrEmbedTokenNotFound
+	}
+	token.AllowedOrigins = origins
+	if err := database.DB.Model(&token).Update("allowed_origins", origins).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke deletes one of the user's tokens; URLs carrying it stop working at once
+func (s *EmbedTokenService) Revoke(userID, id uint) error {
+	result := database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.EmbedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmbedTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate checks that value is one of the user's tokens, records that it was used and
+// returns it
+func (s *EmbedTokenService) Authenticate(userID uint, value string) (*models.EmbedToken, error) {
+	var token models.EmbedToken
+	err := database.DB.Where("token_hash = ? AND user_id = ?", hashEmbedToken(value), userID).First(&token).Error
+	if err != nil {
+		return nil, ErrEmbedTokenInvalid
+	}
+
+	now := time.Now()
+	database.DB.Model(&models.EmbedToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", token.ID, now.Add(-embedTokenUseInterval)).
+		Update("last_used_at", now)
+	return &token, nil
+}
+
+// NormalizeOrigins reduces each entry to its scheme://host[:port] origin, the form browsers
+// send, and joins them for storage
+func NormalizeOrigins(entries []string) (string, error) {
+	if len(entries) > MaxEmbedTokenOrigins {
+		return "", ErrEmbedTokenOrigins
+	}
+	origins := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		origin := OriginOf(entry)
+		if origin == "" {
+			return "", ErrEmbedTokenOrigins
+		}
+		origins = append(origins, origin)
+	}
+	return strings.Join(origins, ","), nil
+}
+
+// OriginOf returns the origin of an http(s) URL, such as an Origin or Referer header, or
+// "" if it has none
+func OriginOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+func hashEmbedToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+)
+
+// reencryptBatchSize is how many secrets are loaded at a time while re-encrypting
+const reencryptBatchSize = 200
+
+// encryptedColumn is a column holding secrets from utils.Encrypt, with its IV column
+type encryptedColumn struct {
+	model    interface{}
+	column   string
+	ivColumn string
+}
+
+// encryptedColumns lists every secret stored encrypted. Soft-deleted rows are included,
+// since a disconnect can be undone.
+var encryptedColumns = []encryptedColumn{
+	{&models.DockerAccount{}, "encrypted_token", "token_iv"},
+	{&models.IngestKey{}, "encrypted_secret", "secret_iv"},
+	{&models.NotificationChannel{}, "encrypted_settings", "settings_iv"},
+}
+
+type encryptedRow struct {
+	ID         uint
+	Ciphertext string
+	IV         string
+}
+
+// CountSecretsByKey returns how many stored secrets each encryption key protects, by key id
+func CountSecretsByKey() (map[string]int, error) {
+	counts := map[string]int{}
+	for _, col := range encryptedColumns {
+		err := eachEncryptedRow(col, func(row encryptedRow) error {
+			counts[utils.CiphertextKeyID(row.Ciphertext)]++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// ReencryptSecrets re-encrypts every stored secret not already under the primary key and
+// returns how many it rewrote. Once it finishes, retired keys can be removed from config.
+// It is safe to run again after a failure, or while the server is running.
+func ReencryptSecrets() (int, error) {
+	primary, err := utils.PrimaryKeyID()
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, col := range encryptedColumns {
+		err := eachEncryptedRow(col, func(row encryptedRow) error {
+			if utils.CiphertextKeyID(row.Ciphertext) == primary {
+				return nil
+			}
+			plaintext, err := utils.Decrypt(row.Ciphertext, row.IV)
+			if err != nil {
+				return fmt.Errorf("decrypting %s of row %d: %w", col.column, row.ID, err)
+			}
+			ciphertext, iv, err := utils.Encrypt(plaintext)
+			if err != nil {
+				return err
+			}
+			// Only replace the secret if nothing changed it in the meantime
+			err = database.DB.Unscoped().Model(col.model).
+				Where("id = ? AND "+col.column+" = ?", row.ID, row.Ciphertext).
+				Updates(map[string]interface{}{col.column: ciphertext, col.ivColumn: iv}).Error
+			if err != nil {
+				return err
+			}
+			rewritten++
+			return nil
+		})
+		if err != nil {
+			return rewritten, err
+		}
+	}
+	return rewritten, nil
+}
+
+// eachEncryptedRow calls fn for every secret in a column, loading them in batches
+func eachEncryptedRow(col encryptedColumn, fn func(encryptedRow) error) error {
+	var lastID uint
+	for {
+		var rows []encryptedRow
+		err := database.DB.Unscoped().Model(col.model).
+			Select("id, "+col.column+" AS ciphertext, "+col.ivColumn+" AS iv").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(reencryptBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+			lastID = row.ID
+		}
+		if len(rows) < reencryptBatchSize {
+			return nil
+		}
+	}
+}
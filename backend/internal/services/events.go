@@ -0,0 +1,158 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Event list orders
+const (
+	EventOrderNewest = "newest"
+	EventOrderOldest = "oldest"
+)
+
+var ErrEventCursorInvalid = errors.New("cursor is invalid or belongs to a different sort")
+
+// eventScanLimit bounds how many rows one page reads while applying the tag and
+// confidence filters, so a sparse filter returns a short page instead of scanning the
+// whole history. The page's cursor still moves past everything read.
+const eventScanLimit = 5000
+
+// EventQuery selects and orders an account's raw events
+type EventQuery struct {
+	Repository    string // Exact repository name, as shown in activity
+	Tags          []string
+	EventTypes    []models.EventType
+	From, To      time.Time // UTC days (inclusive); zero for no bound
+	MinConfidence models.Confidence
+	Order         string // EventOrderNewest (default) or EventOrderOldest
+	Cursor        string // NextCursor of the previous page
+	Limit         int
+}
+
+// EventPage is one page of events. NextCursor is empty on the last page.
+type EventPage struct {
+	Events     []models.ActivityEvent
+	NextCursor string
+}
+
+// eventCursor is the position after the last event read, in the query's order
+type eventCursor struct {
+	Date  string `json:"d"`
+	ID    uint   `json:"id"`
+	Order string `json:"o"`
+}
+
+func (c eventCursor) encode() string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeEventCursor(value, order string) (*eventCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrEventCursorInvalid
+	}
+	var cursor eventCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.Order != order {
+		return nil, ErrEventCursorInvalid
+	}
+	if _, err := time.Parse("2006-01-02", cursor.Date); err != nil {
+		return nil, ErrEventCursorInvalid
+	}
+	return &cursor, nil
+}
+
+// ListEvents pages through an account's raw events by date, each with its resolved
+// timestamp source. Pages are keyed on (event_date, id), so events recorded by a sync
+// between requests don't shift or repeat the pages that follow.
+func (s *DockerHubService) ListEvents(accountID uint, q EventQuery) (*EventPage, error) {
+	if q.Order != EventOrderOldest {
+		q.Order = EventOrderNewest
+	}
+
+	var cursor *eventCursor
+	if q.Cursor != "" {
+		var err error
+		if cursor, err = decodeEventCursor(q.Cursor, q.Order); err != nil {
+			return nil, err
+		}
+	}
+
+	query := database.DB.Where("docker_account_id = ?", accountID)
+	if q.Repository != "" {
+		query = query.Where("repository = ?", q.Repository)
+	}
+	if len(q.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", q.EventTypes)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("event_date >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("event_date <= ?", q.To)
+	}
+	if q.Order == EventOrderOldest {
+		query = query.Order("event_date ASC, id ASC")
+	} else {
+		query = query.Order("event_date DESC, id DESC")
+	}
+	// Each batch below adds its own cursor condition to a copy of the query
+	query = query.Session(&gorm.Session{})
+
+	page := &EventPage{Events: make([]models.ActivityEvent, 0, q.Limit)}
+	batch := q.Limit + 1
+	if batch < 200 {
+		batch = 200
+	}
+
+	scanned := 0
+	for scanned < eventScanLimit {
+		scope := query
+		if cursor != nil {
+			date, _ := time.Parse("2006-01-02", cursor.Date)
+			if q.Order == EventOrderOldest {
+				scope = scope.Where("event_date > ? OR (event_date = ? AND id > ?)", date, date, cursor.ID)
+			} else {
+				scope = scope.Where("event_date < ? OR (event_date = ? AND id < ?)", date, date, cursor.ID)
+			}
+		}
+
+		var events []models.ActivityEvent
+		if err := scope.Limit(batch).Find(&events).Error; err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			if len(page.Events) == q.Limit {
+				// More events remain, so the cursor stays on this page's last one
+				return page, nil
+			}
+			scanned++
+			cursor = &eventCursor{Date: event.EventDate.Format("2006-01-02"), ID: event.ID, Order: q.Order}
+			page.NextCursor = cursor.encode()
+
+			if len(q.Tags) > 0 && !matchesTag(event.Tag, q.Tags) {
+				continue
+			}
+			event.Source = event.ResolvedSource()
+			if event.Source.Confidence() < q.MinConfidence {
+				continue
+			}
+			page.Events = append(page.Events, event)
+		}
+		if len(events) < batch {
+			// Read to the end of the history
+			page.NextCursor = ""
+			return page, nil
+		}
+	}
+	return page, nil
+}
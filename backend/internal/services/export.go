@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// MaxExportDays bounds the period of a single activity report
+const MaxExportDays = 366
+
+var ErrExportPeriodInvalid = errors.New("export period must be 1-366 days with from before to")
+
+// ReportTotals are activity counts over a report period
+type ReportTotals struct {
+	Activities int `json:"activities"`
+	Pushes     int `json:"pushes"`
+	Pulls      int `json:"pulls"`
+	Builds     int `json:"builds"`
+	ActiveDays int `json:"active_days"`
+}
+
+func (t *ReportTotals) add(other ReportTotals) {
+	t.Activities += other.Activities
+	t.Pushes += other.Pushes
+	t.Pulls += other.Pulls
+	t.Builds += other.Builds
+	t.ActiveDays += other.ActiveDays
+}
+
+// MemberReport is one account's activity over the report period
+type MemberReport struct {
+	DockerUsername string       `json:"docker_username"`
+	Totals         ReportTotals `json:"totals"`
+}
+
+// ActivityReport holds per-member and aggregate activity for a period. Reports are built
+// from a list of accounts so the same format can cover a single user or a group.
+type ActivityReport struct {
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Members     []MemberReport `json:"members"`
+	Aggregate   ReportTotals   `json:"aggregate"`
+}
+
+type ExportService struct {
+	dockerService *DockerHubService
+}
+
+func NewExportService(dockerService *DockerHubService) *ExportService {
+	return &ExportService{
+		dockerService: dockerService,
+	}
+}
+
+// BuildActivityReport aggregates activity for each account between from and to (inclusive, UTC days)
+func (s *ExportService) BuildActivityReport(accounts []models.DockerAccount, from, to time.Time) (*ActivityReport, error) {
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days < 1 || days > MaxExportDays {
+		return nil, ErrExportPeriodInvalid
+	}
+
+	report := &ActivityReport{
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		GeneratedAt: time.Now().UTC(),
+		Members:     make([]MemberReport, 0, len(accounts)),
+	}
+
+	for _, account := range accounts {
+		activities, err := s.dockerService.GetActivitySummaryRange(account.DockerUsername, from, to, ActivityFilter{Location: time.UTC})
+		if err != nil {
+			return nil, err
+		}
+
+		member := MemberReport{DockerUsername: account.DockerUsername}
+		for _, a := range activities {
+			member.Totals.Activities += a.TotalCount
+			member.Totals.Pushes += a.Pushes
+			member.Totals.Pulls += a.Pulls
+			member.Totals.Builds += a.Builds
+			if a.TotalCount > 0 {
+				member.Totals.ActiveDays++
+			}
+		}
+		report.Members = append(report.Members, member)
+		report.Aggregate.add(member.Totals)
+	}
+
+	return report, nil
+}
+
+// CSV encodes the report with one row per member followed by an aggregate row
+func (r *ActivityReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	row := func(name string, t ReportTotals) []string {
+		return []string{
+			name, r.From, r.To,
+			strconv.Itoa(t.Activities), strconv.Itoa(t.Pushes), strconv.Itoa(t.Pulls), strconv.Itoa(t.Builds),
+			strconv.Itoa(t.ActiveDays),
+		}
+	}
+
+	w.Write([]string{"member", "from", "to", "activities", "pushes", "pulls", "builds", "active_days"})
+	for _, m := range r.Members {
+		w.Write(row(m.DockerUsername, m.Totals))
+	}
+	w.Write(row("(aggregate)", r.Aggregate))
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
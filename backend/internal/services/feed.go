@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// GetActiveDays returns up to limit (0 for all) days with activity over the last days,
+// newest first and broken down like GetDayDetail. Private repositories are left out, since
+// feeds are syndicated.
+func (s *DockerHubService) GetActiveDays(dockerUsername string, days, limit int, filter ActivityFilter) ([]DayDetail, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	var private []string
+	if err := database.DB.Model(&models.DockerRepository{}).
+		Where("docker_account_id = ? AND is_private = ?", account.ID, true).
+		Pluck("name", &private).Error; err != nil {
+		return nil, err
+	}
+	isPrivate := make(map[string]bool, len(private))
+	for _, name := range private {
+		isPrivate[name] = true
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -days+1)
+
+	query := database.DB.Where("docker_account_id = ? AND event_date >= ?",
+		account.ID, start.AddDate(0, 0, -1).UTC())
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+
+	var events []models.ActivityEvent
+	if err := query.Order("event_at, id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	startKey := start.Format("2006-01-02")
+	byDate := make(map[string]*DayDetail)
+	byRepo := make(map[string]map[string]int)
+	for i := range events {
+		event := &events[i]
+		date := event.LocalDate(loc)
+		if !filter.includes(event) || isPrivate[event.Repository] || date < startKey {
+			continue
+		}
+		detail, ok := byDate[date]
+		if !ok {
+			detail = &DayDetail{Date: date, Timezone: loc.String(), Repositories: []DayRepository{}}
+			byDate[date] = detail
+			byRepo[date] = make(map[string]int)
+		}
+		detail.add(event, byRepo[date])
+	}
+
+	result := make([]DayDetail, 0, len(byDate))
+	for d := today; !d.Before(start) && (limit == 0 || len(result) < limit); d = d.AddDate(0, 0, -1) {
+		if detail, ok := byDate[d.Format("2006-01-02")]; ok {
+			detail.sortRepositories()
+			result = append(result, *detail)
+		}
+	}
+	return result, nil
+}
+
+// ActivityFeedOptions describes where a feed and its entries live
+type ActivityFeedOptions struct {
+	Username   string
+	SelfURL    string // The feed itself
+	ProfileURL string // The user's profile page
+	DayURL     string // Day detail endpoint; "/YYYY-MM-DD" is appended per entry
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Content atomText   `xml:"content"`
+}
+
+// RenderActivityFeed renders active days, newest first, as an Atom feed with one entry per day
+func RenderActivityFeed(days []DayDetail, opts ActivityFeedOptions) ([]byte, error) {
+	feed := atomFeed{
+		ID:    opts.SelfURL,
+		Title: fmt.Sprintf("%s's Docker Hub activity", opts.Username),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: opts.SelfURL},
+			{Rel: "alternate", Type: "text/html", Href: opts.ProfileURL},
+		},
+		Author:  atomAuthor{Name: opts.Username, URI: opts.ProfileURL},
+		Entries: make([]atomEntry, 0, len(days)),
+	}
+
+	var latest time.Time
+	for _, day := range days {
+		updated := dayUpdated(day)
+		if updated.After(latest) {
+			latest = updated
+		}
+
+		summaries := daySummaries(day)
+		title := summaries[0]
+		if len(summaries) > 1 {
+			title = fmt.Sprintf("%s and %d more", title, len(summaries)-1)
+		}
+
+		var content strings.Builder
+		content.WriteString("<ul>")
+		for _, summary := range summaries {
+			content.WriteString("<li>" + html.EscapeString(summary) + "</li>")
+		}
+		content.WriteString("</ul>")
+
+		dayURL := opts.DayURL + "/" + day.Date
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      dayURL,
+			Title:   day.Date + ": " + title,
+			Updated: updated.Format(time.RFC3339),
+			Links: []atomLink{
+				{Rel: "alternate", Type: "text/html", Href: opts.ProfileURL},
+				{Rel: "related", Type: "application/json", Href: dayURL},
+			},
+			Content: atomText{Type: "html", Body: content.String()},
+		})
+	}
+	if latest.IsZero() {
+		latest = time.Now()
+	}
+	feed.Updated = latest.UTC().Format(time.RFC3339)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// dayUpdated is when a day's last event happened, or the end of the day when none of its
+// events kept a timestamp
+func dayUpdated(day DayDetail) time.Time {
+	var latest time.Time
+	for _, repo := range day.Repositories {
+		for _, tag := range repo.Tags {
+			if tag.EventAt != nil && tag.EventAt.After(latest) {
+				latest = *tag.EventAt
+			}
+		}
+	}
+	if latest.IsZero() {
+		loc, err := time.LoadLocation(day.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		date, _ := time.ParseInLocation("2006-01-02", day.Date, loc)
+		latest = date.AddDate(0, 0, 1).Add(-time.Second)
+		if now := time.Now(); latest.After(now) {
+			latest = now
+		}
+	}
+	return latest
+}
+
+// daySummaries describes a day's activity one repository tag at a time, such as
+// "3 pushes to app:latest", busiest repository first
+func daySummaries(day DayDetail) []string {
+	type key struct {
+		tag       string
+		eventType models.EventType
+	}
+
+	var summaries []string
+	for _, repo := range day.Repositories {
+		counts := make(map[key]int)
+		var order []key
+		for _, tag := range repo.Tags {
+			k := key{tag.Tag, tag.EventType}
+			if _, ok := counts[k]; !ok {
+				order = append(order, k)
+			}
+			counts[k] += tag.Count
+		}
+
+		for _, k := range order {
+			target := repo.Repository
+			if target == "" {
+				target = "an unknown repository"
+			}
+			if k.tag != "" {
+				target += ":" + k.tag
+			}
+			summaries = append(summaries, eventPhrase(k.eventType, counts[k])+" "+target)
+		}
+	}
+	return summaries
+}
+
+// eventPhrase counts events of a type, leading into what they touched
+func eventPhrase(eventType models.EventType, count int) string {
+	switch eventType {
+	case models.EventTypePush:
+		return pluralize(count, "push to", "pushes to")
+	case models.EventTypePull:
+		return pluralize(count, "pull of", "pulls of")
+	case models.EventTypeBuild:
+		return pluralize(count, "build of", "builds of")
+	}
+	return pluralize(count, "event on", "events on")
+}
+
+func pluralize(count int, one, many string) string {
+	if count == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", count, many)
+}
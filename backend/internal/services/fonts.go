@@ -0,0 +1,79 @@
+package services
+
+import (
+	"encoding/base64"
+	"html/template"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+// Font size bounds for the font_size option (base label size in px)
+const (
+	DefaultFontSize = 10
+	MinFontSize     = 8
+	MaxFontSize     = 16
+)
+
+// BundledFont is embedded into the SVG itself, so it renders identically everywhere
+// (including <img> embeds that can't load external fonts). It adds ~230KB to the SVG.
+const BundledFont = "go-mono"
+
+// Fonts is the whitelist of selectable font stacks. Only these values ever reach the
+// SVG <style> block, so user input can't inject CSS.
+var Fonts = map[string]string{
+	"default":   defaultFontFamily,
+	"system-ui": "system-ui, -apple-system, 'Segoe UI', Roboto, sans-serif",
+	"monospace": "ui-monospace, SFMono-Regular, Menlo, Consolas, 'Liberation Mono', monospace",
+	"serif":     "Georgia, Cambria, 'Times New Roman', Times, serif",
+	BundledFont: "'Go Mono', ui-monospace, monospace",
+}
+
+// fontStyle is the resolved, template-safe font configuration for a render
+type fontStyle struct {
+	Family template.CSS
+	Face   template.CSS // @font-face rule for bundled fonts, empty otherwise
+	Size   int
+}
+
+var (
+	bundledFontFace     template.CSS
+	bundledFontFaceOnce sync.Once
+)
+
+// resolveFont maps a whitelisted font name and size to a font style, falling back to defaults
+func resolveFont(name string, size int) fontStyle {
+	family, ok := Fonts[strings.ToLower(name)]
+	if !ok {
+		name = "default"
+		family = defaultFontFamily
+	}
+	if size < MinFontSize || size > MaxFontSize {
+		size = DefaultFontSize
+	}
+
+	style := fontStyle{
+		Family: template.CSS(family), // trusted: whitelisted above
+		Size:   size,
+	}
+	if strings.ToLower(name) == BundledFont {
+		bundledFontFaceOnce.Do(func() {
+			bundledFontFace = template.CSS("@font-face { font-family: 'Go Mono'; src: url(data:font/ttf;base64," +
+				base64.StdEncoding.EncodeToString(gomono.TTF) + ") format('truetype'); }")
+		})
+		style.Face = bundledFontFace
+	}
+	return style
+}
+
+// GetAvailableFonts returns the names accepted by the font option
+func GetAvailableFonts() []string {
+	names := make([]string, 0, len(Fonts))
+	for name := range Fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Per-account sync intervals: accounts with their own interval are checked on
+// AccountSyncCheckSpec rather than synced on SYNC_CRON
+const (
+	AccountSyncCheckSpec   = "*/15 * * * *"
+	MinAccountSyncInterval = 30 * time.Minute
+	MaxAccountSyncInterval = 7 * 24 * time.Hour
+
+	defaultSyncSpec = "0 */6 * * *"
+)
+
+var ErrSyncIntervalOutOfRange = errors.New("sync interval out of range")
+
+// Cache lifetimes for rendered activity (seconds)
+const (
+	DefaultCacheMaxAge = 7200
+	MinCacheMaxAge     = 60
+	MaxCacheMaxAge     = 6 * 60 * 60
+
+	// syncGracePeriod leaves time for a scheduled sync to finish before caches expire
+	syncGracePeriod = 5 * time.Minute
+)
+
+var (
+	syncScheduleOnce sync.Once
+	syncSpec         string
+	scheduledSync    cron.Schedule
+
+	accountSyncCheck = mustParseSchedule(AccountSyncCheckSpec)
+)
+
+// SyncSchedule returns the scheduled sync's cron spec (SYNC_CRON) and its parsed schedule,
+// falling back to every 6 hours when SYNC_CRON doesn't parse
+func SyncSchedule() (string, cron.Schedule) {
+	syncScheduleOnce.Do(func() {
+		syncSpec = config.AppConfig.SyncCron
+		schedule, err := cron.ParseStandard(syncSpec)
+		if err != nil {
+			slog.Warn("Invalid SYNC_CRON, using the default", "sync_cron", syncSpec, "default", defaultSyncSpec, "error", err)
+			syncSpec, schedule = defaultSyncSpec, mustParseSchedule(defaultSyncSpec)
+		}
+		scheduledSync = schedule
+	})
+	return syncSpec, scheduledSync
+}
+
+// AccountSyncInterval returns the account's own sync interval, or false when it follows
+// the scheduled sync
+func AccountSyncInterval(account *models.DockerAccount) (time.Duration, bool) {
+	if account.SyncIntervalMinutes <= 0 {
+		return 0, false
+	}
+	return time.Duration(account.SyncIntervalMinutes) * time.Minute, true
+}
+
+// SetSyncInterval sets how often the account is synced: every minutes minutes, between
+// 30 minutes and a week, or on the scheduled sync when minutes is 0
+func (s *DockerHubService) SetSyncInterval(account *models.DockerAccount, minutes int) error {
+	interval := time.Duration(minutes) * time.Minute
+	if minutes != 0 && (interval < MinAccountSyncInterval || interval > MaxAccountSyncInterval) {
+		return ErrSyncIntervalOutOfRange
+	}
+
+	account.SyncIntervalMinutes = minutes
+	return database.DB.Model(account).Update("sync_interval_minutes", minutes).Error
+}
+
+func mustParseSchedule(spec string) cron.Schedule {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// NextExpectedSync returns when the worker will next sync the account, or false when
+// the account isn't scheduled for automatic syncs
+func NextExpectedSync(account *models.DockerAccount, now time.Time) (time.Time, bool) {
+	if !account.IsActive || !account.AutoRefresh || account.SyncDeadLetteredAt != nil || account.DisabledAt != nil {
+		return time.Time{}, false
+	}
+
+	if interval, ok := AccountSyncInterval(account); ok {
+		due := now
+		if account.LastSyncAt != nil && account.LastSyncAt.Add(interval).After(now) {
+			due = account.LastSyncAt.Add(interval)
+		}
+		// The first check at or after the account comes due
+		return accountSyncCheck.Next(due.Add(-time.Second)), true
+	}
+
+	_, schedule := SyncSchedule()
+	next := schedule.Next(now)
+	if account.LastSyncAt != nil {
+		for next.Sub(*account.LastSyncAt) < config.AppConfig.SyncMinInterval {
+			next = schedule.Next(next)
+		}
+	}
+	return next, true
+}
+
+// CacheMaxAge returns how long rendered activity for an account can be cached: until just
+// after its next expected sync, so embeds refresh when new data can exist and not before
+func (s *DockerHubService) CacheMaxAge(dockerUsername string) int {
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return DefaultCacheMaxAge
+	}
+	if account.SyncInProgress {
+		return MinCacheMaxAge
+	}
+
+	next, ok := NextExpectedSync(account, time.Now())
+	if !ok {
+		return MaxCacheMaxAge
+	}
+
+	maxAge := int((time.Until(next) + syncGracePeriod).Seconds())
+	if maxAge < MinCacheMaxAge {
+		return MinCacheMaxAge
+	}
+	if maxAge > MaxCacheMaxAge {
+		return MaxCacheMaxAge
+	}
+	return maxAge
+}
+
+// ResponseETag identifies a rendered response for conditional GETs. It changes when the
+// account syncs, when the owner's settings or the attribution policy change, when the
+// day rolls over in the rendering timezone, and with variant (the request's path and options).
+func (s *DockerHubService) ResponseETag(dockerUsername, tz, variant string, now time.Time) (string, error) {
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%d|%s|%s|%s", account.ID, account.UpdatedAt.UnixNano(), variant,
+		config.AppConfig.AttributionMode, config.AppConfig.AttributionText)
+	if account.LastSyncAt != nil {
+		fmt.Fprintf(h, "|sync:%d", account.LastSyncAt.UnixNano())
+	}
+	if owner, err := s.GetAccountOwner(dockerUsername); err == nil {
+		fmt.Fprintf(h, "|owner:%d", owner.UpdatedAt.UnixNano())
+	}
+	fmt.Fprintf(h, "|day:%s", now.In(s.ResolveLocation(dockerUsername, tz)).Format("2006-01-02"))
+
+	// Weak: the same options can be spelled differently, but the rendered content matches
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`, nil
+}
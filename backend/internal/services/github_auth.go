@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"docker-heatmap/internal/config"
 	"docker-heatmap/internal/database"
@@ -44,13 +45,18 @@ func NewGitHubAuthService() *GitHubAuthService {
 	}
 }
 
-// GetAuthURL returns the GitHub OAuth authorization URL
-func (s *GitHubAuthService) GetAuthURL(state string) string {
+// Name identifies GitHub in routes and on users
+func (s *GitHubAuthService) Name() string {
+	return "github"
+}
+
+// AuthURL returns the GitHub OAuth authorization URL
+func (s *GitHubAuthService) AuthURL(state string) string {
 	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOnline)
 }
 
-// ExchangeCode exchanges the authorization code for access token and fetches user data
-func (s *GitHubAuthService) ExchangeCode(ctx context.Context, code string) (*models.User, error) {
+// Identify exchanges the authorization code for an access token and fetches who signed in
+func (s *GitHubAuthService) Identify(ctx context.Context, code string) (*OAuthIdentity, error) {
 	// Exchange code for token
 	token, err := s.oauthConfig.Exchange(ctx, code)
 	if err != nil {
@@ -63,13 +69,23 @@ func (s *GitHubAuthService) ExchangeCode(ctx context.Context, code string) (*mod
 		return nil, err
 	}
 
-	// Find or create user in database
-	user, err := s.findOrCreateUser(githubUser)
-	if err != nil {
-		return nil, err
-	}
-
-	return user, nil
+	// The public email on the profile is always verified; otherwise use the primary email
+	// if it is
+	verified := githubUser.Email != ""
+	if githubUser.Email == "" {
+		email, ok, _ := s.fetchPrimaryEmail(ctx, token.AccessToken)
+		githubUser.Email, verified = email, ok
+	}
+
+	return &OAuthIdentity{
+		Provider:      s.Name(),
+		ID:            strconv.FormatInt(githubUser.ID, 10),
+		Username:      githubUser.Login,
+		Email:         githubUser.Email,
+		EmailVerified: verified,
+		Name:          githubUser.Name,
+		AvatarURL:     githubUser.AvatarURL,
+	}, nil
 }
 
 func (s *GitHubAuthService) fetchGitHubUser(ctx context.Context, accessToken string) (*GitHubUser, error) {
@@ -97,19 +113,14 @@ func (s *GitHubAuthService) fetchGitHubUser(ctx context.Context, accessToken str
 		return nil, err
 	}
 
-	// Fetch primary email if not public
-	if githubUser.Email == "" {
-		email, _ := s.fetchPrimaryEmail(ctx, accessToken)
-		githubUser.Email = email
-	}
-
 	return &githubUser, nil
 }
 
-func (s *GitHubAuthService) fetchPrimaryEmail(ctx context.Context, accessToken string) (string, error) {
+// fetchPrimaryEmail returns the user's primary email and whether it is verified
+func (s *GitHubAuthService) fetchPrimaryEmail(ctx context.Context, accessToken string) (string, bool, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user/emails", nil)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
@@ -118,57 +129,26 @@ func (s *GitHubAuthService) fetchPrimaryEmail(ctx context.Context, accessToken s
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	defer resp.Body.Close()
 
 	var emails []struct {
-		Email   string `json:"email"`
-		Primary bool   `json:"primary"`
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	for _, e := range emails {
 		if e.Primary {
-			return e.Email, nil
+			return e.Email, e.Verified, nil
 		}
 	}
 
-	return "", nil
-}
-
-func (s *GitHubAuthService) findOrCreateUser(githubUser *GitHubUser) (*models.User, error) {
-	var user models.User
-
-	// Try to find existing user
-	result := database.DB.Where("github_id = ?", githubUser.ID).First(&user)
-	if result.Error == nil {
-		// Update user data
-		user.GitHubUsername = githubUser.Login
-		user.GitHubEmail = githubUser.Email
-		user.AvatarURL = githubUser.AvatarURL
-		user.Name = githubUser.Name
-		database.DB.Save(&user)
-		return &user, nil
-	}
-
-	// Create new user
-	user = models.User{
-		GitHubID:       githubUser.ID,
-		GitHubUsername: githubUser.Login,
-		GitHubEmail:    githubUser.Email,
-		AvatarURL:      githubUser.AvatarURL,
-		Name:           githubUser.Name,
-		PublicProfile:  true,
-	}
-
-	if err := database.DB.Create(&user).Error; err != nil {
-		return nil, err
-	}
-
-	return &user, nil
+	return "", false, nil
 }
 
 // GetUserByID fetches a user by their ID
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"docker-heatmap/internal/config"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+type googleUser struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// GoogleAuthService signs users in with a Google account
+type GoogleAuthService struct {
+	oauthConfig *oauth2.Config
+}
+
+func NewGoogleAuthService() *GoogleAuthService {
+	return &GoogleAuthService{
+		oauthConfig: &oauth2.Config{
+			ClientID:     config.AppConfig.GoogleClientID,
+			ClientSecret: config.AppConfig.GoogleClientSecret,
+			RedirectURL:  config.AppConfig.GoogleCallbackURL,
+			Scopes:       []string{"openid", "email", "profile"},
+			Endpoint:     endpoints.Google,
+		},
+	}
+}
+
+// Name identifies Google in routes and on users
+func (s *GoogleAuthService) Name() string {
+	return "google"
+}
+
+// AuthURL returns the Google OAuth authorization URL
+func (s *GoogleAuthService) AuthURL(state string) string {
+	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOnline)
+}
+
+// Identify exchanges the authorization code for an access token and fetches who signed in
+func (s *GoogleAuthService) Identify(ctx context.Context, code string) (*OAuthIdentity, error) {
+	token, err := s.oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthFailed, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://openidconnect.googleapis.com/v1/userinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: google returned %d", ErrOAuthFailed, resp.StatusCode)
+	}
+
+	var user googleUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	// Google accounts have no username, so the handle is the email's local part
+	username, _, _ := strings.Cut(user.Email, "@")
+	return &OAuthIdentity{
+		Provider:      s.Name(),
+		ID:            user.Sub,
+		Username:      username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		AvatarURL:     user.Picture,
+	}, nil
+}
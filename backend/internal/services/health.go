@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/cache"
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+)
+
+// Health check statuses, of one dependency and of the instance as a whole
+const (
+	HealthUp       = "up"
+	HealthDown     = "down"
+	HealthDisabled = "disabled" // not configured
+	HealthUnknown  = "unknown"  // not checked yet
+
+	HealthReady    = "ready"
+	HealthDegraded = "degraded" // ready, but an optional dependency is down
+	HealthNotReady = "not_ready"
+)
+
+const (
+	// healthPingTimeout bounds each dependency ping, well inside a probe's timeout
+	healthPingTimeout = 2 * time.Second
+
+	// dockerHubCheckTTL is how long a Docker Hub check is reused, so probes every few seconds
+	// from every pod don't become traffic to Docker Hub
+	dockerHubCheckTTL = time.Minute
+	dockerHubTimeout  = 5 * time.Second
+)
+
+// HealthCheck is the state of one dependency
+type HealthCheck struct {
+	Status    string    `json:"status"`
+	Required  bool      `json:"required"` // whether the instance is ready without it
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthReport is the readiness of the instance, with what each dependency contributed
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// Ready reports whether the instance can serve: its required dependencies are up
+func (r *HealthReport) Ready() bool {
+	return r.Status != HealthNotReady
+}
+
+// HealthService checks the dependencies the API needs to serve
+type HealthService struct {
+	hub   DockerHubClient
+	clock Clock
+
+	mu            sync.Mutex
+	dockerHub     HealthCheck
+	dockerHubBusy bool
+}
+
+func NewHealthService(hub DockerHubClient, clock Clock) *HealthService {
+	return &HealthService{
+		hub:       hub,
+		clock:     clock,
+		dockerHub: HealthCheck{Status: HealthUnknown},
+	}
+}
+
+// Check pings the database and Redis and reports the last Docker Hub check. The database is
+// required; Redis only caches renders and Docker Hub only matters to syncs, so either being
+// down leaves the instance degraded but still ready.
+func (s *HealthService) Check(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Status: HealthReady,
+		Checks: map[string]HealthCheck{
+			"database":   s.checkDatabase(ctx),
+			"redis":      s.checkRedis(ctx),
+			"docker_hub": s.dockerHubCheck(),
+		},
+	}
+	for _, check := range report.Checks {
+		if check.Status != HealthDown {
+			continue
+		}
+		if check.Required {
+			report.Status = HealthNotReady
+			break
+		}
+		report.Status = HealthDegraded
+	}
+	return report
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) HealthCheck {
+	return s.ping(ctx, true, healthPingTimeout, func(ctx context.Context) error {
+		sqlDB, err := database.DB.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+func (s *HealthService) checkRedis(ctx context.Context) HealthCheck {
+	if config.AppConfig.RedisURL == "" {
+		return HealthCheck{Status: HealthDisabled, CheckedAt: s.clock.Now()}
+	}
+	return s.ping(ctx, false, healthPingTimeout, func(ctx context.Context) error {
+		if !cache.Enabled() {
+			return errors.New("not connected since startup")
+		}
+		return cache.Client.Ping(ctx).Err()
+	})
+}
+
+// dockerHubCheck returns the last Docker Hub check, starting another in the background once
+// it's stale so a probe never waits on Docker Hub
+func (s *HealthService) dockerHubCheck() HealthCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	check := s.dockerHub
+	if !s.dockerHubBusy && (check.Status == HealthUnknown || s.clock.Now().Sub(check.CheckedAt) >= dockerHubCheckTTL) {
+		s.dockerHubBusy = true
+		go s.refreshDockerHub()
+	}
+	return check
+}
+
+// refreshDockerHub checks Docker Hub answers an unauthenticated request. Any answer short of
+// a server error counts as reachable.
+func (s *HealthService) refreshDockerHub() {
+	check := s.ping(context.Background(), false, dockerHubTimeout, func(ctx context.Context) error {
+		status, _, err := s.hub.ProbeRepositories(ctx, "library", 0)
+		if err != nil {
+			return err
+		}
+		if status >= http.StatusInternalServerError {
+			return fmt.Errorf("docker hub returned status %d", status)
+		}
+		return nil
+	})
+
+	s.mu.Lock()
+	s.dockerHub = check
+	s.dockerHubBusy = false
+	s.mu.Unlock()
+}
+
+// ping runs and times one dependency's ping, giving up after timeout
+func (s *HealthService) ping(ctx context.Context, required bool, timeout time.Duration,
+	ping func(ctx context.Context) error) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	check := HealthCheck{Status: HealthUp, Required: required, CheckedAt: s.clock.Now()}
+	started := time.Now()
+	err := ping(ctx)
+	check.LatencyMS = time.Since(started).Milliseconds()
+	if err != nil {
+		check.Status = HealthDown
+		check.Error = err.Error()
+	}
+	return check
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"bytes"
 	"fmt"
-	"html"
 	"html/template"
 	"strings"
 	"time"
@@ -13,30 +12,52 @@ import (
 
 type HeatmapService struct {
 	dockerService *DockerHubService
+	clock         Clock
 }
 
-func NewHeatmapService() *HeatmapService {
+func NewHeatmapService(dockerService *DockerHubService, clock Clock) *HeatmapService {
 	return &HeatmapService{
-		dockerService: NewDockerHubService(),
+		dockerService: dockerService,
+		clock:         clock,
 	}
 }
 
+// MaxHeatmapYears is the maximum number of stacked calendar years in a single SVG
+const MaxHeatmapYears = 5
+
 // SVGOptions represents customizable options for the SVG heatmap
 type SVGOptions struct {
-	Theme       string // Theme name or "custom"
-	CellSize    int    // Size of each cell (default 11)
-	CellRadius  int    // Border radius of cells (default 2)
-	Days        int    // Number of days to show (default 365)
-	HideLegend  bool   // Hide the legend
-	HideTotal   bool   // Hide total count
-	HideLabels  bool   // Hide month/day labels
-	FontFamily  string // Custom font family
-	CustomTitle string // Custom title instead of default
+	Theme       string    // Theme name or "custom"
+	CellSize    int       // Size of each cell (default 11)
+	CellRadius  int       // Border radius of cells (default 2)
+	Days        int       // Number of days to show (default 365)
+	Years       int       // Stack one row per calendar year instead of a trailing window (1-5)
+	HideLegend  bool      // Hide the legend
+	HideTotal   bool      // Hide total count
+	HideLabels  bool      // Hide month/day labels
+	Font        string    // Whitelisted font name (see Fonts)
+	FontSize    int       // Base label size in px (8-16, default 10)
+	CustomTitle string    // Custom title instead of default
+	StartDate   time.Time // First day to render; with EndDate, replaces Days and Years
+	EndDate     time.Time // Last day to render (defaults to today)
+	Timezone    string    // IANA timezone for day bucketing (defaults to the owner's preference)
+	WeekStart   string    // First row of each week: "sunday" or "monday" (defaults to the owner's preference)
+
+	// Event filtering and weighting
+	EventTypes   []models.EventType           // Event types to include (empty means all)
+	EventWeights map[models.EventType]float64 // Per-type weight used for levels
+	// Lowest timestamp source confidence to include (zero means all)
+	MinConfidence models.Confidence
+	Tags          []string // Tag names or glob patterns to include (empty means all)
+
+	AutoContrast bool  // Adjust the text color when it is unreadable on the background
+	Gradient     *bool // Override the theme's gradient fills (nil keeps the theme default)
 
 	// Custom colors (when theme is "custom")
 	BgColor      string   // Background color
 	TextColor    string   // Text color
 	CustomColors []string // Level 0-4 colors
+	BorderColor  string   // Cell border color (overrides the theme's)
 }
 
 // Theme represents a color theme for the heatmap
@@ -45,6 +66,9 @@ type Theme struct {
 	BgColor   string
 	TextColor string
 	Colors    []string // Level 0-4 colors
+
+	Gradient    bool   // Fill active cells with a gradient from the previous level's color
+	BorderColor string // Optional cell border color
 }
 
 var Themes = map[string]Theme{
@@ -56,10 +80,11 @@ var Themes = map[string]Theme{
 		Colors:    []string{"#161b22", "#0e4429", "#006d32", "#26a641", "#39d353"},
 	},
 	"github-light": {
-		Name:      "GitHub Light",
-		BgColor:   "#ffffff",
-		TextColor: "#57606a",
-		Colors:    []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"},
+		Name:        "GitHub Light",
+		BgColor:     "#ffffff",
+		TextColor:   "#57606a",
+		Colors:      []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"},
+		BorderColor: "#e1e4e8",
 	},
 	"docker": {
 		Name:      "Docker",
@@ -98,12 +123,14 @@ var Themes = map[string]Theme{
 		BgColor:   "transparent",
 		TextColor: "#a9b1d6",
 		Colors:    []string{"#1a1b26", "#24283b", "#7aa2f7", "#bb9af7", "#73daca"},
+		Gradient:  true,
 	},
 	"catppuccin": {
 		Name:      "Catppuccin",
 		BgColor:   "transparent",
 		TextColor: "#cdd6f4",
 		Colors:    []string{"#1e1e2e", "#313244", "#89b4fa", "#a6e3a1", "#f5c2e7"},
+		Gradient:  true,
 	},
 
 	// Color themes
@@ -118,6 +145,7 @@ var Themes = map[string]Theme{
 		BgColor:   "transparent",
 		TextColor: "#b38867",
 		Colors:    []string{"#2d1f1f", "#6b3030", "#b54040", "#e06050", "#ff8c66"},
+		Gradient:  true,
 	},
 	"forest": {
 		Name:      "Forest",
@@ -140,10 +168,11 @@ var Themes = map[string]Theme{
 
 	// Minimal/Grayscale
 	"minimal": {
-		Name:      "Minimal",
-		BgColor:   "transparent",
-		TextColor: "#666666",
-		Colors:    []string{"#f0f0f0", "#d4d4d4", "#a8a8a8", "#6b6b6b", "#333333"},
+		Name:        "Minimal",
+		BgColor:     "transparent",
+		TextColor:   "#666666",
+		Colors:      []string{"#f0f0f0", "#d4d4d4", "#a8a8a8", "#6b6b6b", "#333333"},
+		BorderColor: "#e0e0e0",
 	},
 	"minimal-dark": {
 		Name:      "Minimal Dark",
@@ -153,16 +182,64 @@ var Themes = map[string]Theme{
 	},
 }
 
+// ThemeOrder lists the built-in themes in the order pickers show them
+var ThemeOrder = []string{
+	"github", "github-light", "docker",
+	"dracula", "nord", "monokai", "one-dark", "tokyo-night", "catppuccin",
+	"ocean", "sunset", "forest", "purple", "rose",
+	"minimal", "minimal-dark",
+}
+
+const defaultFontFamily = "-apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif"
+
 type HeatmapConfig struct {
-	CellSize   int
-	CellMargin int
-	CellRadius int
-	Rows       int // Always 7 for days of week
-	FontSize   int
-	Colors     []string
-	TextColor  string
-	BgColor    string
-	FontFamily string
+	CellSize    int
+	CellMargin  int
+	CellRadius  int
+	Rows        int // Always 7 for days of week
+	FontSize    int
+	Colors      []string
+	Fills       []string // Per-level fill: the color, or a url(#id) gradient reference
+	Gradients   []LevelGradient
+	TextColor   string
+	BgColor     string
+	BorderColor string
+	FontFamily  template.CSS
+	FontFace    template.CSS
+}
+
+// LevelGradient is a diagonal gradient fill for one activity level
+type LevelGradient struct {
+	ID   string
+	From string
+	To   string
+}
+
+// levelFills returns the fill for each level. With gradients, each active level fades in
+// from halfway between it and the previous level, which softens the steps between levels.
+func levelFills(colors []string, gradient bool) ([]string, []LevelGradient) {
+	fills := make([]string, len(colors))
+	copy(fills, colors)
+	if !gradient {
+		return fills, nil
+	}
+
+	var gradients []LevelGradient
+	for i := 1; i < len(colors); i++ {
+		prev, ok1 := parseColor(colors[i-1])
+		cur, ok2 := parseColor(colors[i])
+		if !ok1 || !ok2 {
+			continue
+		}
+		id := fmt.Sprintf("level-%d-gradient", i)
+		gradients = append(gradients, LevelGradient{
+			ID:   id,
+			From: mixColors(prev, cur, 0.5).hex(),
+			To:   cur.hex(),
+		})
+		fills[i] = "url(#" + id + ")"
+	}
+	return fills, gradients
 }
 
 // SVGData represents the data needed to render the SVG
@@ -172,6 +249,7 @@ type SVGData struct {
 	Cells        []Cell
 	MonthLabels  []MonthLabel
 	DayLabels    []DayLabel
+	YearLabels   []YearLabel
 	Config       HeatmapConfig
 	Username     string
 	TotalCount   int
@@ -192,7 +270,9 @@ type Cell struct {
 	Height int
 	Radius int
 	Color  string
+	Level  int
 	Date   string
+	Day    string // YYYY-MM-DD, for renders that look up more than the cell's total
 	Count  int
 }
 
@@ -208,14 +288,44 @@ type DayLabel struct {
 	Label string
 }
 
+type YearLabel struct {
+	X     int
+	Y     int
+	Label string
+}
+
+// weekRow returns the row of a weekday in a grid whose weeks begin on firstDay
+func weekRow(day, firstDay time.Weekday) int {
+	return (int(day) - int(firstDay) + 7) % 7
+}
+
+// gridSection is one 7-row block of the heatmap (the whole window, or a single year when stacked)
+type gridSection struct {
+	Start time.Time
+	End   time.Time
+	Weeks int
+	Label string
+}
+
 const svgTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="xMidYMid meet" xmlns="http://www.w3.org/2000/svg">
   <style>
-    .day { shape-rendering: geometricPrecision; outline: 1px solid rgba(27, 31, 35, 0.06); outline-offset: -1px; }
+    {{.Config.FontFace}}
+    .day { shape-rendering: geometricPrecision; {{if .Config.BorderColor}}stroke: {{.Config.BorderColor}}; stroke-width: 1px;{{else}}outline: 1px solid rgba(27, 31, 35, 0.06); outline-offset: -1px;{{end}} }
     .month-label { font-size: {{.Config.FontSize}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
-    .day-label { font-size: 9px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
-    .title { font-size: 11px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; font-weight: 600; }
-    .legend-label { font-size: 9px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+    .day-label { font-size: {{subtract .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+    .title { font-size: {{add .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; font-weight: 600; }
+    .legend-label { font-size: {{subtract .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
   </style>
+  {{if .Config.Gradients}}
+  <defs>
+    {{range .Config.Gradients}}
+    <linearGradient id="{{.ID}}" x1="0" y1="0" x2="1" y2="1">
+      <stop offset="0%" stop-color="{{.From}}"/>
+      <stop offset="100%" stop-color="{{.To}}"/>
+    </linearGradient>
+    {{end}}
+  </defs>
+  {{end}}
   <rect width="{{.Width}}" height="{{.Height}}" fill="{{.Config.BgColor}}" rx="6"/>
   {{if not .HideLabels}}
   <!-- Month labels -->
@@ -227,6 +337,10 @@ const svgTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.
   {{range .DayLabels}}
   <text x="{{.X}}" y="{{.Y}}" class="day-label">{{.Label}}</text>
   {{end}}
+
+  {{range .YearLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="day-label">{{.Label}}</text>
+  {{end}}
   {{end}}
   
   <!-- Activity cells -->
@@ -244,11 +358,11 @@ const svgTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.
   {{if not .HideLegend}}
   <!-- Legend -->
   <g transform="translate({{.LegendX}}, {{.LegendY}})">
-    <text x="-25" y="10" class="legend-label">Less</text>
-    {{range $i, $color := .Config.Colors}}
+    <text x="-4" y="10" class="legend-label" text-anchor="end">Less</text>
+    {{range $i, $color := .Config.Fills}}
     <rect x="{{multiply $i 14}}" y="0" width="11" height="11" fill="{{$color}}" rx="2"/>
     {{end}}
-    <text x="75" y="10" class="legend-label">More</text>
+    <text x="71" y="10" class="legend-label">More</text>
   </g>
   {{end}}
 </svg>`
@@ -263,75 +377,172 @@ func (s *HeatmapService) GenerateSVG(dockerUsername string, days int) ([]byte, e
 
 // GenerateSVGWithOptions generates an SVG heatmap with custom options
 func (s *HeatmapService) GenerateSVGWithOptions(dockerUsername string, opts SVGOptions) ([]byte, error) {
-	// Set defaults
-	if opts.Days <= 0 || opts.Days > 365 {
-		opts.Days = 365
+	svg, _, err := s.GenerateSVGWithWarnings(dockerUsername, opts)
+	return svg, err
+}
+
+// GenerateSVGWithWarnings generates an SVG heatmap and reports contrast problems in the
+// colors it was rendered with, so custom themes can be flagged without failing the render
+func (s *HeatmapService) GenerateSVGWithWarnings(dockerUsername string, opts SVGOptions) ([]byte, []ThemeWarning, error) {
+	data, warnings, err := s.buildHeatmapData(dockerUsername, opts)
+	if err != nil {
+		return nil, nil, err
 	}
-	if opts.CellSize <= 0 {
-		opts.CellSize = 11
+
+	svg, err := renderHeatmapSVG(data)
+	if err != nil {
+		return nil, nil, err
 	}
-	if opts.CellSize > 20 {
-		opts.CellSize = 20
+
+	return s.applyAttribution(dockerUsername, svg), warnings, nil
+}
+
+func renderHeatmapSVG(data *SVGData) ([]byte, error) {
+	// Create template with helper functions
+	funcMap := template.FuncMap{
+		"add":      func(a, b int) int { return a + b },
+		"subtract": func(a, b int) int { return a - b },
+		"multiply": func(a, b int) int { return a * b },
 	}
-	if opts.CellRadius < 0 {
-		opts.CellRadius = 2
+
+	tmpl, err := template.New("heatmap").Funcs(funcMap).Parse(svgTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ActivitySource supplies the activity and owner preferences a heatmap is laid out from.
+// DockerHubService reads real accounts; sampleActivitySource generates preview data.
+type ActivitySource interface {
+	ResolveLocation(dockerUsername, tz string) *time.Location
+	ResolveWeekStart(dockerUsername, weekStart string) time.Weekday
+	GetActivitySummaryRange(dockerUsername string, startDate, endDate time.Time, filter ActivityFilter) ([]models.ActivitySummary, error)
+}
+
+// buildHeatmapData loads activity and lays out the heatmap grid, labels and legend.
+// The SVG and raster renderers both draw from its result.
+func (s *HeatmapService) buildHeatmapData(dockerUsername string, opts SVGOptions) (*SVGData, []ThemeWarning, error) {
+	return buildHeatmapDataFrom(s.dockerService, s.clock.Now(), dockerUsername, opts)
+}
+
+func buildHeatmapDataFrom(source ActivitySource, now time.Time, dockerUsername string, opts SVGOptions) (*SVGData, []ThemeWarning, error) {
+	// Set defaults
+	normalizeRenderOptions(&opts)
+	if opts.Years > MaxHeatmapYears {
+		opts.Years = MaxHeatmapYears
 	}
 	if opts.Theme == "" {
 		opts.Theme = "github"
 	}
-	if opts.FontFamily == "" {
-		opts.FontFamily = "-apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif"
-	}
+	font := resolveFont(opts.Font, opts.FontSize)
 
 	// Get theme or use custom colors
-	var bgColor, textColor string
-	var colors []string
-
-	if opts.Theme == "custom" && len(opts.CustomColors) == 5 {
-		bgColor = opts.BgColor
-		if bgColor == "" {
-			bgColor = "transparent"
-		}
-		textColor = opts.TextColor
-		if textColor == "" {
-			textColor = "#8b949e"
+	theme := resolveTheme(opts)
+	if opts.AutoContrast {
+		theme.TextColor = AdjustTextContrast(theme)
+	}
+	bgColor, textColor, colors := theme.BgColor, theme.TextColor, theme.Colors
+	if opts.Gradient != nil {
+		theme.Gradient = *opts.Gradient
+	}
+	if opts.BorderColor != "" {
+		theme.BorderColor = opts.BorderColor
+	}
+	fills, gradients := levelFills(colors, theme.Gradient)
+	warnings := ValidateThemeContrast(theme)
+
+	// Build the date sections to render: a single trailing window, or one row per calendar year
+	loc := source.ResolveLocation(dockerUsername, opts.Timezone)
+	firstDay := source.ResolveWeekStart(dockerUsername, opts.WeekStart)
+	today := now.In(loc)
+	if !opts.EndDate.IsZero() {
+		today = time.Date(opts.EndDate.Year(), opts.EndDate.Month(), opts.EndDate.Day(), 0, 0, 0, 0, loc)
+	}
+	var sections []gridSection
+	if !opts.StartDate.IsZero() && !opts.EndDate.IsZero() {
+		start := time.Date(opts.StartDate.Year(), opts.StartDate.Month(), opts.StartDate.Day(), 0, 0, 0, 0, loc)
+		days := int(today.Sub(start).Hours()/24) + 1
+		sections = []gridSection{{
+			Start: start,
+			End:   today,
+			Weeks: (weekRow(start.Weekday(), firstDay)+days-1)/7 + 1,
+		}}
+	} else if opts.Years > 1 {
+		for i := 0; i < opts.Years; i++ {
+			year := today.Year() - i
+			start := time.Date(year, time.January, 1, 0, 0, 0, 0, today.Location())
+			yearEnd := time.Date(year, time.December, 31, 0, 0, 0, 0, today.Location())
+			end := yearEnd
+			if end.After(today) {
+				end = today
+			}
+			// Reserve columns for the whole year so stacked rows line up
+			leadingDays := weekRow(start.Weekday(), firstDay)
+			sections = append(sections, gridSection{
+				Start: start,
+				End:   end,
+				Weeks: (leadingDays+yearEnd.YearDay()-1)/7 + 1,
+				Label: fmt.Sprintf("%d", year),
+			})
 		}
-		colors = opts.CustomColors
 	} else {
-		theme, ok := Themes[opts.Theme]
-		if !ok {
-			theme = Themes["github"]
-		}
-		bgColor = theme.BgColor
-		textColor = theme.TextColor
-		colors = theme.Colors
+		sections = []gridSection{{
+			Start: today.AddDate(0, 0, -opts.Days+1),
+			End:   today,
+			Weeks: (opts.Days + 6) / 7,
+		}}
 	}
 
 	// Get activity data
-	activities, err := s.dockerService.GetActivitySummary(dockerUsername, opts.Days)
+	rangeStart := sections[len(sections)-1].Start
+	if opts.Years <= 1 && opts.StartDate.IsZero() {
+		rangeStart = today.AddDate(0, 0, -opts.Days)
+	}
+	activities, err := source.GetActivitySummaryRange(dockerUsername, rangeStart, today, ActivityFilter{
+		Location:      loc,
+		EventTypes:    opts.EventTypes,
+		Weights:       opts.EventWeights,
+		MinConfidence: opts.MinConfidence,
+		Tags:          opts.Tags,
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Calculate dimensions
 	cellMargin := 3
 	cellTotal := opts.CellSize + cellMargin
-	numWeeks := (opts.Days + 6) / 7
+	numWeeks := 0
+	for _, section := range sections {
+		if section.Weeks > numWeeks {
+			numWeeks = section.Weeks
+		}
+	}
 
 	leftMargin := 40
 	if opts.HideLabels {
 		leftMargin = 10
 	}
 
-	// Calculate cells area dimensions
+	// Each section is a 7-row grid; stacked sections are separated by room for month labels
+	topMargin := 25
+	sectionHeight := 7 * cellTotal
+	cellsHeight := len(sections)*sectionHeight + (len(sections)-1)*topMargin
 	cellsWidth := numWeeks * cellTotal
-	cellsHeight := 7 * cellTotal
 
-	// Calculate total width
+	// Calculate total width (stacked years need extra room for the year label)
 	width := leftMargin + cellsWidth + 20
+	if len(sections) > 1 && !opts.HideLabels {
+		width += 30
+	}
 
 	// Calculate height based on what's shown
-	topMargin := 25
 	bottomMargin := 10
 	if !opts.HideTotal || !opts.HideLegend {
 		bottomMargin = 30
@@ -340,84 +551,106 @@ func (s *HeatmapService) GenerateSVGWithOptions(dockerUsername string, opts SVGO
 
 	// Build config
 	config := HeatmapConfig{
-		CellSize:   opts.CellSize,
-		CellMargin: cellMargin,
-		CellRadius: opts.CellRadius,
-		Rows:       7,
-		FontSize:   10,
-		Colors:     colors,
-		TextColor:  textColor,
-		BgColor:    bgColor,
-		FontFamily: opts.FontFamily,
-	}
-
-	// Create cells
-	cells := make([]Cell, 0, len(activities))
-	totalCount := 0
-
-	startDate := time.Now().AddDate(0, 0, -opts.Days+1)
-	// Align to start of week (Sunday)
-	for startDate.Weekday() != time.Sunday {
-		startDate = startDate.AddDate(0, 0, -1)
+		CellSize:    opts.CellSize,
+		CellMargin:  cellMargin,
+		CellRadius:  opts.CellRadius,
+		Rows:        7,
+		FontSize:    font.Size,
+		Colors:      colors,
+		Fills:       fills,
+		Gradients:   gradients,
+		TextColor:   textColor,
+		BgColor:     bgColor,
+		BorderColor: theme.BorderColor,
+		FontFamily:  font.Family,
+		FontFace:    font.Face,
 	}
 
 	activityMap := make(map[string]models.ActivitySummary)
+	totalCount := 0
 	for _, a := range activities {
 		activityMap[a.Date] = a
 		totalCount += a.TotalCount
 	}
 
-	currentDate := startDate
-	col := 0
-	today := time.Now()
-	for !currentDate.After(today) {
-		row := int(currentDate.Weekday())
-		dateStr := currentDate.Format("2006-01-02")
-
-		activity := activityMap[dateStr]
-		color := config.Colors[activity.Level]
-
-		cells = append(cells, Cell{
-			X:      col * cellTotal,
-			Y:      row * cellTotal,
-			Width:  opts.CellSize,
-			Height: opts.CellSize,
-			Radius: opts.CellRadius,
-			Color:  color,
-			Date:   currentDate.Format("Jan 2, 2006"),
-			Count:  activity.TotalCount,
-		})
+	cells := make([]Cell, 0, len(activities))
+	monthLabels := make([]MonthLabel, 0)
+	var dayLabels []DayLabel
+	var yearLabels []YearLabel
+
+	for i, section := range sections {
+		offsetY := i * (sectionHeight + topMargin)
+		startDate := section.Start
+		// Align to start of week
+		for startDate.Weekday() != firstDay {
+			startDate = startDate.AddDate(0, 0, -1)
+		}
+
+		col := 0
+		for currentDate := startDate; !currentDate.After(section.End); currentDate = currentDate.AddDate(0, 0, 1) {
+			// Stacked years only show days belonging to that year
+			if !currentDate.Before(section.Start) {
+				row := weekRow(currentDate.Weekday(), firstDay)
+				activity := activityMap[currentDate.Format("2006-01-02")]
+
+				cells = append(cells, Cell{
+					X:      col * cellTotal,
+					Y:      offsetY + row*cellTotal,
+					Width:  opts.CellSize,
+					Height: opts.CellSize,
+					Radius: opts.CellRadius,
+					Color:  config.Fills[activity.Level],
+					Level:  activity.Level,
+					Date:   currentDate.Format("Jan 2, 2006"),
+					Day:    currentDate.Format("2006-01-02"),
+					Count:  activity.TotalCount,
+				})
+			}
 
-		if currentDate.Weekday() == time.Saturday {
-			col++
+			if weekRow(currentDate.Weekday(), firstDay) == 6 {
+				col++
+			}
 		}
-		currentDate = currentDate.AddDate(0, 0, 1)
-	}
 
-	// Create month labels
-	monthLabels := make([]MonthLabel, 0)
-	if !opts.HideLabels {
-		currentMonth := startDate.Month()
-		for i := 0; i < numWeeks; i++ {
-			checkDate := startDate.AddDate(0, 0, i*7)
-			if checkDate.Month() != currentMonth || i == 0 {
+		if opts.HideLabels {
+			continue
+		}
+
+		// Create month labels
+		currentMonth := section.Start.Month()
+		for w := 0; w < section.Weeks; w++ {
+			checkDate := startDate.AddDate(0, 0, w*7)
+			if checkDate.After(section.End) {
+				break
+			}
+			if checkDate.Before(section.Start) {
+				checkDate = section.Start
+			}
+			if checkDate.Month() != currentMonth || w == 0 {
 				currentMonth = checkDate.Month()
 				monthLabels = append(monthLabels, MonthLabel{
-					X:     leftMargin + (i * cellTotal),
-					Y:     15,
+					X:     leftMargin + (w * cellTotal),
+					Y:     offsetY + 15,
 					Label: checkDate.Format("Jan"),
 				})
 			}
 		}
-	}
 
-	// Create day labels
-	var dayLabels []DayLabel
-	if !opts.HideLabels {
-		dayLabels = []DayLabel{
-			{X: 5, Y: 25 + (1 * cellTotal) + 8, Label: "Mon"},
-			{X: 5, Y: 25 + (3 * cellTotal) + 8, Label: "Wed"},
-			{X: 5, Y: 25 + (5 * cellTotal) + 8, Label: "Fri"},
+		// Create day labels
+		for _, day := range []time.Weekday{time.Monday, time.Wednesday, time.Friday} {
+			dayLabels = append(dayLabels, DayLabel{
+				X:     5,
+				Y:     offsetY + 25 + (weekRow(day, firstDay) * cellTotal) + 8,
+				Label: day.String()[:3],
+			})
+		}
+
+		if section.Label != "" {
+			yearLabels = append(yearLabels, YearLabel{
+				X:     leftMargin + cellsWidth + 5,
+				Y:     offsetY + 25 + (3 * cellTotal) + 8,
+				Label: section.Label,
+			})
 		}
 	}
 
@@ -426,46 +659,52 @@ func (s *HeatmapService) GenerateSVGWithOptions(dockerUsername string, opts SVGO
 	legendY := topMargin + cellsHeight + 5
 	legendX := width - 120
 
-	// Security: Escape user-provided content to prevent XSS in SVG
-	safeUsername := html.EscapeString(dockerUsername)
-	safeCustomTitle := html.EscapeString(opts.CustomTitle)
-
 	data := SVGData{
 		Width:        width,
 		Height:       height,
 		Cells:        cells,
 		MonthLabels:  monthLabels,
 		DayLabels:    dayLabels,
+		YearLabels:   yearLabels,
 		Config:       config,
-		Username:     safeUsername,
+		Username:     SanitizeText(dockerUsername, 0), // Escaped for its context by the template
 		TotalCount:   totalCount,
 		HideLegend:   opts.HideLegend,
 		HideTotal:    opts.HideTotal,
 		HideLabels:   opts.HideLabels,
-		CustomTitle:  safeCustomTitle,
+		CustomTitle:  opts.CustomTitle, // Sanitized by normalizeRenderOptions
 		LegendX:      legendX,
 		LegendY:      legendY,
 		FooterY:      footerY,
 		CellsOffsetX: leftMargin,
 	}
 
-	// Create template with helper functions
-	funcMap := template.FuncMap{
-		"subtract": func(a, b int) int { return a - b },
-		"multiply": func(a, b int) int { return a * b },
-	}
+	return &data, warnings, nil
+}
 
-	tmpl, err := template.New("heatmap").Funcs(funcMap).Parse(svgTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse template: %w", err)
+// resolveTheme returns the named theme, or a theme built from the custom colors
+func resolveTheme(opts SVGOptions) Theme {
+	if opts.Theme == "custom" && len(opts.CustomColors) == 5 {
+		theme := Theme{
+			Name:      "Custom",
+			BgColor:   opts.BgColor,
+			TextColor: opts.TextColor,
+			Colors:    opts.CustomColors,
+		}
+		if theme.BgColor == "" {
+			theme.BgColor = "transparent"
+		}
+		if theme.TextColor == "" {
+			theme.TextColor = "#8b949e"
+		}
+		return theme
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return nil, fmt.Errorf("failed to execute template: %w", err)
+	theme, ok := Themes[opts.Theme]
+	if !ok {
+		theme = Themes["github"]
 	}
-
-	return buf.Bytes(), nil
+	return theme
 }
 
 // GetAvailableThemes returns all available theme names
@@ -479,12 +718,7 @@ func GetAvailableThemes() []string {
 
 // ParseSVGOptionsFromQuery parses SVG options from query parameters
 func ParseSVGOptionsFromQuery(params map[string]string) SVGOptions {
-	opts := SVGOptions{
-		Theme:      "github",
-		Days:       365,
-		CellSize:   11,
-		CellRadius: 2,
-	}
+	opts := DefaultSVGOptions()
 
 	if v, ok := params["theme"]; ok {
 		opts.Theme = strings.ToLower(v)
@@ -492,6 +726,9 @@ func ParseSVGOptionsFromQuery(params map[string]string) SVGOptions {
 	if v, ok := params["days"]; ok {
 		fmt.Sscanf(v, "%d", &opts.Days)
 	}
+	if v, ok := params["years"]; ok {
+		fmt.Sscanf(v, "%d", &opts.Years)
+	}
 	if v, ok := params["cell_size"]; ok {
 		fmt.Sscanf(v, "%d", &opts.CellSize)
 	}
@@ -510,6 +747,30 @@ func ParseSVGOptionsFromQuery(params map[string]string) SVGOptions {
 	if v, ok := params["title"]; ok {
 		opts.CustomTitle = v
 	}
+	if v, ok := params["tz"]; ok {
+		opts.Timezone = v
+	}
+	if v, ok := params["week_start"]; ok {
+		opts.WeekStart = strings.ToLower(v)
+	}
+	if v, ok := params["events"]; ok {
+		opts.EventTypes = ParseEventTypes(v)
+	}
+	if v, ok := params["min_confidence"]; ok {
+		opts.MinConfidence, _ = models.ParseConfidence(strings.ToLower(v))
+	}
+	if v, ok := params["tag"]; ok {
+		opts.Tags = ParseTagPatterns(v)
+	}
+	if v, ok := params["font"]; ok {
+		opts.Font = strings.ToLower(v)
+	}
+	if v, ok := params["font_size"]; ok {
+		fmt.Sscanf(v, "%d", &opts.FontSize)
+	}
+	if v, ok := params["auto_contrast"]; ok && (v == "true" || v == "1") {
+		opts.AutoContrast = true
+	}
 
 	// Custom colors support
 	if v, ok := params["bg_color"]; ok {
@@ -518,6 +779,13 @@ func ParseSVGOptionsFromQuery(params map[string]string) SVGOptions {
 	if v, ok := params["text_color"]; ok {
 		opts.TextColor = v
 	}
+	if v, ok := params["border_color"]; ok {
+		opts.BorderColor = v
+	}
+	if v, ok := params["gradient"]; ok {
+		gradient := v == "true" || v == "1"
+		opts.Gradient = &gradient
+	}
 	// Custom level colors: color0, color1, color2, color3, color4
 	customColors := make([]string, 0, 5)
 	for i := 0; i < 5; i++ {
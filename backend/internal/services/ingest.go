@@ -0,0 +1,402 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ingest limits
+const (
+	MaxIngestKeys  = 5
+	MaxIngestBatch = 500
+	// IngestClockSkew is how far a request's signed timestamp may be from the server's
+	// clock, which bounds how long a delivery must be remembered to catch a replay
+	IngestClockSkew = 5 * time.Minute
+)
+
+// ingestKeyPrefix starts every public key id, so keys are recognisable in CI settings
+const ingestKeyPrefix = "dhik_"
+
+// IngestSignaturePrefix starts the X-Ingest-Signature header, ahead of the hex digest
+const IngestSignaturePrefix = "sha256="
+
+var (
+	ErrIngestKeyNotFound = errors.New("ingest key not found")
+	ErrIngestKeyLimit    = errors.New("ingest key limit reached")
+	ErrIngestSignature   = errors.New("invalid ingest key or signature")
+	ErrIngestTimestamp   = fmt.Errorf("request timestamp must be unix seconds within %s of the server clock", IngestClockSkew)
+	ErrIngestBatchSize   = fmt.Errorf("a batch must have between 1 and %d events", MaxIngestBatch)
+	ErrIngestReplay      = errors.New("this signed request was already delivered; sign a retry with a new timestamp")
+)
+
+var (
+	// ingestRepositoryPattern is a Docker repository name, optionally under a namespace
+	ingestRepositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*(?:/[a-z0-9]+(?:[._-][a-z0-9]+)*)?$`)
+	// ingestTagPattern is a Docker tag name
+	ingestTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// IngestEvent is one event in a signed batch
+type IngestEvent struct {
+	Timestamp  string `json:"timestamp"` // RFC 3339
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"` // Optional
+	EventType  string `json:"event_type"`
+}
+
+// IngestEventError says why one event in a batch was rejected
+type IngestEventError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// IngestValidationError rejects a batch with invalid events. Nothing in the batch is
+// recorded, so the sender can fix it and resend it whole.
+type IngestValidationError struct {
+	Events []IngestEventError
+}
+
+func (e *IngestValidationError) Error() string {
+	return fmt.Sprintf("%d invalid events", len(e.Events))
+}
+
+// BuildReport is a finished build reported by a CI step, such as after docker push
+type BuildReport struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`       // Optional
+	RunURL     string `json:"run_url"`   // Optional link to the CI run, such as a GitHub Actions workflow run
+	Timestamp  string `json:"timestamp"` // Optional RFC 3339; defaults to now
+}
+
+// IngestResult counts what a batch recorded
+type IngestResult struct {
+	Accepted int `json:"accepted"`
+	// Duplicates were already ingested, or repeated within the batch
+	Duplicates int `json:"duplicates"`
+}
+
+// ListIngestKeys returns the user's ingest keys, without their secrets
+func (s *DockerHubService) ListIngestKeys(userID uint) ([]models.IngestKey, error) {
+	var keys []models.IngestKey
+	err := database.DB.Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// CreateIngestKey creates a key for the user and returns it with its secret, which can't
+// be read back later
+func (s *DockerHubService) CreateIngestKey(userID uint, name string) (*models.IngestKey, string, error) {
+	var count int64
+	if err := database.DB.Model(&models.IngestKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count >= MaxIngestKeys {
+		return nil, "", ErrIngestKeyLimit
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	secret, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, "", err
+	}
+	encrypted, iv, err := utils.Encrypt(secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.IngestKey{
+		UserID:          userID,
+		Name:            name,
+		KeyID:           ingestKeyPrefix + hex.EncodeToString(id),
+		EncryptedSecret: encrypted,
+		SecretIV:        iv,
+	}
+	if err := database.DB.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// DeleteIngestKey revokes one of the user's keys. Events it recorded are kept.
+func (s *DockerHubService) DeleteIngestKey(userID, id uint) error {
+	result := database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.IngestKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIngestKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateIngest checks a request's signature, the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the secret of keyID, and returns the key that signed it
+func (s *DockerHubService) AuthenticateIngest(keyID, timestamp, signature string, body []byte) (*models.IngestKey, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrIngestTimestamp
+	}
+	skew := time.Since(time.Unix(seconds, 0))
+	if skew > IngestClockSkew || skew < -IngestClockSkew {
+		return nil, ErrIngestTimestamp
+	}
+
+	var key models.IngestKey
+	if err := database.DB.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		return nil, ErrIngestSignature
+	}
+	secret, err := utils.Decrypt(key.EncryptedSecret, key.SecretIV)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := IngestSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrIngestSignature
+	}
+	if err := userUnavailable(key.UserID); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ClaimIngestDelivery records that a request signed with signature was accepted, and
+// returns ErrIngestReplay if it already was. Requests older than IngestClockSkew are
+// rejected by their timestamp, so deliveries only need to be remembered that long.
+func (s *DockerHubService) ClaimIngestDelivery(key *models.IngestKey, signature string) error {
+	delivery := models.IngestDelivery{KeyID: key.KeyID, DeliveryID: ingestDeliveryID(signature)}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIngestReplay
+	}
+	return nil
+}
+
+// ReleaseIngestDelivery forgets a delivery whose request failed on the server, so the
+// client can retry it unchanged
+func (s *DockerHubService) ReleaseIngestDelivery(key *models.IngestKey, signature string) {
+	database.DB.Where("key_id = ? AND delivery_id = ?", key.KeyID, ingestDeliveryID(signature)).
+		Delete(&models.IngestDelivery{})
+}
+
+// PurgeIngestDeliveries forgets deliveries whose timestamps are too old to be accepted again
+func PurgeIngestDeliveries() (int64, error) {
+	result := database.DB.Where("created_at < ?", time.Now().Add(-2*IngestClockSkew)).Delete(&models.IngestDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+// ingestDeliveryID is the hex digest of a verified signature
+func ingestDeliveryID(signature string) string {
+	return strings.TrimPrefix(signature, IngestSignaturePrefix)
+}
+
+// IngestEvents records a batch for the key owner's account as manually imported events.
+// Every event is validated before any is recorded, and events already ingested (same
+// repository, tag, type and timestamp) are skipped, so a retried batch isn't counted twice.
+func (s *DockerHubService) IngestEvents(key *models.IngestKey, events []IngestEvent) (*IngestResult, error) {
+	if len(events) == 0 || len(events) > MaxIngestBatch {
+		return nil, ErrIngestBatchSize
+	}
+
+	now := time.Now()
+	records := make([]ingestRecord, len(events))
+	var invalid []IngestEventError
+	for i, event := range events {
+		at, err := validateIngestEvent(event, now)
+		if err != nil {
+			invalid = append(invalid, IngestEventError{Index: i, Error: err.Error()})
+			continue
+		}
+		records[i] = ingestRecord{
+			at:          at,
+			eventType:   models.EventType(event.EventType),
+			repository:  event.Repository,
+			tag:         event.Tag,
+			fingerprint: ingestFingerprint(event.Repository, event.Tag, event.EventType, at.Format(time.RFC3339Nano)),
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, &IngestValidationError{Events: invalid}
+	}
+
+	account, err := s.GetDockerAccount(key.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recordIngested(key, account, records, now)
+}
+
+// IngestBuild records a build reported by a CI step. A report with a run URL is counted
+// once per run, so re-running the step doesn't add another build.
+func (s *DockerHubService) IngestBuild(key *models.IngestKey, report BuildReport) (*IngestResult, error) {
+	now := time.Now()
+	if report.Timestamp == "" {
+		report.Timestamp = now.UTC().Format(time.RFC3339Nano)
+	}
+
+	event := IngestEvent{Timestamp: report.Timestamp, Repository: report.Repository, Tag: report.Tag, EventType: string(models.EventTypeBuild)}
+	at, err := validateIngestEvent(event, now)
+	if err == nil && report.RunURL != "" {
+		err = validateRunURL(report.RunURL)
+	}
+	if err != nil {
+		return nil, &IngestValidationError{Events: []IngestEventError{{Index: 0, Error: err.Error()}}}
+	}
+
+	account, err := s.GetDockerAccount(key.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := at.Format(time.RFC3339Nano)
+	if report.RunURL != "" {
+		identity = report.RunURL
+	}
+	return s.recordIngested(key, account, []ingestRecord{{
+		at:          at,
+		eventType:   models.EventTypeBuild,
+		repository:  report.Repository,
+		tag:         report.Tag,
+		runURL:      report.RunURL,
+		fingerprint: ingestFingerprint(report.Repository, report.Tag, event.EventType, identity),
+	}}, now)
+}
+
+// ingestRecord is a validated event ready to record
+type ingestRecord struct {
+	at          time.Time
+	eventType   models.EventType
+	repository  string
+	tag         string
+	runURL      string
+	fingerprint string
+}
+
+// ingestFingerprint identifies an ingested event for deduplication
+func ingestFingerprint(repository, tag, eventType, identity string) string {
+	sum := sha256.Sum256([]byte(repository + "\x00" + tag + "\x00" + eventType + "\x00" + identity))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordIngested records validated events for the key owner's account, skipping those
+// with a receipt
+func (s *DockerHubService) recordIngested(key *models.IngestKey, account *models.DockerAccount, records []ingestRecord, now time.Time) (*IngestResult, error) {
+	result := &IngestResult{}
+	seen := make(map[string]bool, len(records))
+	previousDay := latestActivityDay(account.ID)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, record := range records {
+			if seen[record.fingerprint] {
+				result.Duplicates++
+				continue
+			}
+			seen[record.fingerprint] = true
+
+			// The receipt is the dedup check, so concurrent retries of a batch can't both count
+			receipt := models.IngestReceipt{DockerAccountID: account.ID, Fingerprint: record.fingerprint, EventAt: record.at}
+			created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&receipt)
+			if created.Error != nil {
+				return created.Error
+			}
+			if created.RowsAffected == 0 {
+				result.Duplicates++
+				continue
+			}
+
+			if _, err := upsertActivity(tx, account.ID, record.eventType, models.EventSourceImport, record.at, record.repository, record.tag); err != nil {
+				return err
+			}
+			if record.runURL != "" {
+				// The row upserted above; it keeps the latest run when a day has several
+				day := time.Date(record.at.Year(), record.at.Month(), record.at.Day(), 0, 0, 0, 0, time.UTC)
+				if err := tx.Model(&models.ActivityEvent{}).
+					Where("docker_account_id = ? AND event_date = ? AND repository = ? AND tag = ? AND event_type = ?",
+						account.ID, day, record.repository, record.tag, record.eventType).
+					Update("run_url", record.runURL).Error; err != nil {
+					return err
+				}
+			}
+			result.Accepted++
+		}
+		if result.Accepted == 0 {
+			return nil
+		}
+		// Moves the account's ETag, so cached heatmaps aren't revalidated as current
+		if err := tx.Model(account).Update("updated_at", now).Error; err != nil {
+			return err
+		}
+		return rebuildDailyAggregates(tx, account.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	database.DB.Model(key).Update("last_used_at", now)
+	if result.Accepted > 0 {
+		s.renderCache.Invalidate(account.DockerUsername)
+		s.notifyNewActivity(account, previousDay)
+		if err := s.evaluateAchievements(account); err != nil {
+			slog.Error("Failed to evaluate achievements", "docker_username", account.DockerUsername, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// validateIngestEvent checks one event and returns its timestamp in UTC
+func validateIngestEvent(event IngestEvent, now time.Time) (time.Time, error) {
+	oldest := now.AddDate(0, 0, -ActivityRetentionDays())
+	at, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return time.Time{}, errors.New("timestamp must be RFC 3339, such as 2024-05-01T12:00:00Z")
+	}
+	switch {
+	case at.After(now.Add(IngestClockSkew)):
+		return time.Time{}, errors.New("timestamp is in the future")
+	case at.Before(oldest):
+		return time.Time{}, fmt.Errorf("timestamp is older than the %d-day retention window", ActivityRetentionDays())
+	case len(event.Repository) > 255 || !ingestRepositoryPattern.MatchString(event.Repository):
+		return time.Time{}, errors.New("repository must be a Docker repository name, such as app or namespace/app")
+	case event.Tag != "" && !ingestTagPattern.MatchString(event.Tag):
+		return time.Time{}, errors.New("tag must be a Docker tag name")
+	}
+	switch models.EventType(event.EventType) {
+	case models.EventTypePush, models.EventTypePull, models.EventTypeBuild:
+	default:
+		return time.Time{}, errors.New("event_type must be push, pull or build")
+	}
+	return at.UTC(), nil
+}
+
+// validateRunURL checks that a build's run URL is an absolute http(s) link
+func validateRunURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(value) > 512 {
+		return errors.New("run_url must be an http(s) URL of at most 512 characters")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultInstanceStatsDays = 30
+	MaxInstanceStatsDays     = 365
+
+	// topEmbeddedLimit is how many of the most embedded usernames the stats list
+	topEmbeddedLimit = 10
+)
+
+// InstanceStats are totals across the instance, and how it grew day by day
+type InstanceStats struct {
+	Users          int64 `json:"users"`
+	SuspendedUsers int64 `json:"suspended_users"`
+	DockerAccounts int64 `json:"docker_accounts"`
+	Events         int64 `json:"events"`
+
+	SyncingAccounts      int64 `json:"syncing_accounts"`
+	FailingAccounts      int64 `json:"failing_accounts"`
+	DeadLetteredAccounts int64 `json:"dead_lettered_accounts"`
+	DisabledAccounts     int64 `json:"disabled_accounts"`
+	PendingSyncJobs      int64 `json:"pending_sync_jobs"`
+
+	Days        int                `json:"days"`
+	Daily       []InstanceDay      `json:"daily"` // oldest first, quiet days included
+	TopEmbedded []EmbeddedUsername `json:"top_embedded"`
+}
+
+// InstanceDay counts what happened on one UTC day
+type InstanceDay struct {
+	Date              string `json:"date"` // YYYY-MM-DD
+	Signups           int64  `json:"signups"`
+	AccountsConnected int64  `json:"accounts_connected"`
+	EventsIngested    int64  `json:"events_ingested"`
+	APIRequests       int64  `json:"api_requests"`
+	APIErrors         int64  `json:"api_errors"` // 5xx responses
+	SyncsSucceeded    int64  `json:"syncs_succeeded"`
+	SyncsFailed       int64  `json:"syncs_failed"`
+}
+
+// EmbeddedUsername is an account whose images were rendered most over the stats window
+type EmbeddedUsername struct {
+	DockerUsername string `json:"docker_username"`
+	Renders        int64  `json:"renders"`
+}
+
+// dayCount is one day's count of one bucket, from a GROUP BY over utcDate
+type dayCount struct {
+	Day    time.Time
+	Bucket string
+	Count  int64
+}
+
+// GetInstanceStats counts users, accounts, events and sync outcomes across the instance, and
+// breaks down signups, connections, ingested events, API requests and syncs over the last
+// days UTC days, including today
+func GetInstanceStats(now time.Time, days int) (*InstanceStats, error) {
+	stats := &InstanceStats{Days: days}
+	counts := []struct {
+		model interface{}
+		where string
+		dest  *int64
+	}{
+		{&models.User{}, "", &stats.Users},
+		{&models.User{}, "suspended_at IS NOT NULL", &stats.SuspendedUsers},
+		{&models.DockerAccount{}, "", &stats.DockerAccounts},
+		{&models.ActivityEvent{}, "", &stats.Events},
+		{&models.DockerAccount{}, "sync_in_progress = true", &stats.SyncingAccounts},
+		{&models.DockerAccount{}, "last_sync_error <> ''", &stats.FailingAccounts},
+		{&models.DockerAccount{}, "sync_dead_lettered_at IS NOT NULL", &stats.DeadLetteredAccounts},
+		{&models.DockerAccount{}, "disabled_at IS NOT NULL", &stats.DisabledAccounts},
+		{&models.SyncJob{}, "status = '" + models.SyncJobPending + "'", &stats.PendingSyncJobs},
+	}
+	for _, count := range counts {
+		query := database.DB.Model(count.model)
+		if count.where != "" {
+			query = query.Where(count.where)
+		}
+		if err := query.Count(count.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	start := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	stats.Daily = make([]InstanceDay, days)
+	byDate := make(map[string]*InstanceDay, days)
+	for i := range stats.Daily {
+		stats.Daily[i].Date = start.AddDate(0, 0, i).Format("2006-01-02")
+		byDate[stats.Daily[i].Date] = &stats.Daily[i]
+	}
+
+	series := []struct {
+		query *gorm.DB
+		add   func(day *InstanceDay, bucket string, count int64)
+	}{
+		{
+			countPerDay(database.DB.Model(&models.User{}), "created_at", "", start),
+			func(day *InstanceDay, _ string, count int64) { day.Signups += count },
+		},
+		{
+			countPerDay(database.DB.Model(&models.DockerAccount{}), "created_at", "", start),
+			func(day *InstanceDay, _ string, count int64) { day.AccountsConnected += count },
+		},
+		{
+			countPerDay(database.DB.Model(&models.ActivityEvent{}), "created_at", "", start),
+			func(day *InstanceDay, _ string, count int64) { day.EventsIngested += count },
+		},
+		{
+			countPerDay(database.DB.Model(&models.SyncRun{}), "started_at", "status", start),
+			func(day *InstanceDay, status string, count int64) {
+				if status == models.SyncRunSucceeded {
+					day.SyncsSucceeded += count
+				} else {
+					day.SyncsFailed += count
+				}
+			},
+		},
+		{
+			// Already one row per day and status class
+			database.DB.Model(&models.DailyRequestCount{}).
+				Select("day, status_class AS bucket, count").
+				Where("day >= ?", start.Format("2006-01-02")),
+			func(day *InstanceDay, statusClass string, count int64) {
+				day.APIRequests += count
+				if statusClass == "5xx" {
+					day.APIErrors += count
+				}
+			},
+		},
+	}
+	for _, s := range series {
+		var rows []dayCount
+		if err := s.query.Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if day, ok := byDate[row.Day.Format("2006-01-02")]; ok {
+				s.add(day, row.Bucket, row.Count)
+			}
+		}
+	}
+
+	topEmbedded, err := topEmbeddedUsernames(start)
+	if err != nil {
+		return nil, err
+	}
+	stats.TopEmbedded = topEmbedded
+
+	return stats, nil
+}
+
+// topEmbeddedUsernames returns the accounts whose images were rendered most from start on,
+// most rendered first
+func topEmbeddedUsernames(start time.Time) ([]EmbeddedUsername, error) {
+	top := []EmbeddedUsername{}
+	err := database.DB.Table("daily_view_counts").
+		Select("docker_accounts.docker_username, SUM(daily_view_counts.count) AS renders").
+		Joins("JOIN docker_accounts ON docker_accounts.id = daily_view_counts.docker_account_id").
+		Where("daily_view_counts.kind = ? AND daily_view_counts.day >= ? AND docker_accounts.deleted_at IS NULL",
+			models.ViewKindRender, start.Format("2006-01-02")).
+		Group("docker_accounts.docker_username").
+		Order("renders DESC").
+		Limit(topEmbeddedLimit).
+		Scan(&top).Error
+	if err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+// countPerDay counts query's rows from start on by the UTC day of column, and by bucket, a
+// column, unless it's empty
+func countPerDay(query *gorm.DB, column, bucket string, start time.Time) *gorm.DB {
+	query = query.Where(column+" >= ?", start)
+	if bucket == "" {
+		return query.Select(utcDate(column) + " AS day, COUNT(*) AS count").Group("day")
+	}
+	return query.Select(utcDate(column) + " AS day, " + bucket + " AS bucket, COUNT(*) AS count").
+		Group("day, " + bucket)
+}
+
+// utcDate is the SQL for the UTC date of a timestamp column. MySQL columns already hold
+// UTC; Postgres ones are converted from the session's time zone.
+func utcDate(column string) string {
+	if database.IsMySQL() {
+		return "DATE(" + column + ")"
+	}
+	return "DATE(" + column + " AT TIME ZONE 'UTC')"
+}
@@ -0,0 +1,38 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+)
+
+func TestTopEmbeddedIncludesSVGViews(t *testing.T) {
+	testutil.OpenDB(t)
+	testutil.CreateAccount(t, "alice", true)
+	testutil.CreateAccount(t, "bob", true)
+	testutil.CreateAccount(t, "carol", true)
+
+	serveViews(t,
+		"/api/v1/heatmap/alice.svg",
+		"/api/v1/heatmap/alice.svg",
+		"/api/v1/heatmap/alice.svg",
+		"/api/v1/heatmap/bob",
+		"/api/v1/profile/carol",
+	)
+
+	top, err := services.TopEmbeddedUsernames(time.Now().UTC().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []services.EmbeddedUsername{{DockerUsername: "alice", Renders: 3}, {DockerUsername: "bob", Renders: 1}}
+	if len(top) != len(want) {
+		t.Fatalf("top embedded %v, want %v", top, want)
+	}
+	for i := range want {
+		if top[i] != want[i] {
+			t.Errorf("top embedded %v, want %v", top, want)
+		}
+	}
+}
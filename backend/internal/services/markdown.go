@@ -0,0 +1,50 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// MaxBioLength is the maximum length of a markdown bio in bytes
+const MaxBioLength = 2000
+
+var (
+	markdownRenderer = goldmark.New(
+		// Raw HTML is dropped (goldmark's default), so only markdown constructs reach the output
+		goldmark.WithExtensions(extension.GFM),
+	)
+
+	bioPolicy     *bluemonday.Policy
+	bioPolicyOnce sync.Once
+)
+
+// getBioPolicy allows the usual user-generated content elements, keeps code fence
+// language classes for client-side highlighting, and hardens links
+func getBioPolicy() *bluemonday.Policy {
+	bioPolicyOnce.Do(func() {
+		bioPolicy = bluemonday.UGCPolicy()
+		bioPolicy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+		bioPolicy.RequireNoFollowOnLinks(true)
+		bioPolicy.AddTargetBlankToFullyQualifiedLinks(true)
+		bioPolicy.AllowURLSchemes("http", "https", "mailto")
+	})
+	return bioPolicy
+}
+
+// RenderMarkdown converts a markdown bio into sanitized HTML that is safe to inject into a page
+func RenderMarkdown(source string) string {
+	if source == "" {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		return ""
+	}
+	return getBioPolicy().Sanitize(buf.String())
+}
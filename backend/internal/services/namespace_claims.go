@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/logging"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// MaxNamespaceClaims caps how many extra namespaces one account can sync
+const MaxNamespaceClaims = 5
+
+// NamespaceClaimTTL is how long a pending claim's token can be used to verify it
+const NamespaceClaimTTL = 48 * time.Hour
+
+// namespaceTokenPrefix starts every verification token, so tokens are valid tag names and
+// recognisable in a repository description
+const namespaceTokenPrefix = "dhverify-"
+
+var (
+	ErrNamespaceClaimNotFound = errors.New("namespace claim not found")
+	ErrNamespaceClaimLimit    = errors.New("too many namespace claims")
+	ErrNamespaceOwn           = errors.New("your own namespace is already included")
+	ErrNamespaceTaken         = errors.New("namespace is already claimed by another account")
+	ErrNamespaceClaimExpired  = errors.New("verification token has expired; claim the namespace again")
+	ErrNamespaceNotVerified   = errors.New("verification token not found in the namespace")
+)
+
+// ProofRepository is the public metadata of one repository that a claim is checked against
+type ProofRepository struct {
+	Name        string
+	Description string
+}
+
+// NamespaceProofSource reads the public metadata namespace verification looks at. Proof
+// only needs repository descriptions and tag names that anyone can read, so it doesn't
+// depend on the owner's credentials and any registry exposing these can back it.
+type NamespaceProofSource interface {
+	Repositories(ctx context.Context, namespace string) ([]ProofRepository, error)
+	Tags(ctx context.Context, namespace, repository string) ([]string, error)
+}
+
+// dockerHubProofSource reads public Docker Hub metadata without authenticating
+type dockerHubProofSource struct {
+	s *DockerHubService
+}
+
+func (p dockerHubProofSource) Repositories(ctx context.Context, namespace string) ([]ProofRepository, error) {
+	repos, err := p.s.hub.FetchRepositories(ctx, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	proof := make([]ProofRepository, 0, len(repos))
+	for _, repo := range repos {
+		if repo.IsPrivate {
+			continue
+		}
+		proof = append(proof, ProofRepository{Name: repo.Name, Description: repo.Description})
+	}
+	return proof, nil
+}
+
+func (p dockerHubProofSource) Tags(ctx context.Context, namespace, repository string) ([]string, error) {
+	tags, err := p.s.hub.FetchTags(ctx, namespace, repository, "")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names, nil
+}
+
+// ListNamespaceClaims returns an account's pending and verified namespace claims
+func (s *DockerHubService) ListNamespaceClaims(accountID uint) ([]models.NamespaceClaim, error) {
+	var claims []models.NamespaceClaim
+	err := database.DB.Where("docker_account_id = ?", accountID).Order("namespace").Find(&claims).Error
+	return claims, err
+}
+
+// ClaimNamespace starts (or restarts) a claim on a namespace and returns it with a fresh
+// verification token. The owner proves control by pushing a tag named after the token
+// to any public repository in the namespace, or by adding it to one's description.
+func (s *DockerHubService) ClaimNamespace(account *models.DockerAccount, namespace string) (*models.NamespaceClaim, error) {
+	namespace = strings.ToLower(namespace)
+	if namespace == strings.ToLower(account.DockerUsername) {
+		return nil, ErrNamespaceOwn
+	}
+	if err := checkNamespaceAvailable(account.ID, namespace); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRandomString(20)
+	if err != nil {
+		return nil, err
+	}
+
+	var claim models.NamespaceClaim
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("docker_account_id = ? AND namespace = ?", account.ID, namespace).Limit(1).Find(&claim)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 && claim.Verified() {
+			return nil
+		}
+
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&models.NamespaceClaim{}).Where("docker_account_id = ?", account.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= MaxNamespaceClaims {
+				return ErrNamespaceClaimLimit
+			}
+			claim = models.NamespaceClaim{DockerAccountID: account.ID, Namespace: namespace, CreatedAt: time.Now()}
+		}
+
+		claim.Token = namespaceTokenPrefix + token
+		claim.ExpiresAt = time.Now().Add(NamespaceClaimTTL)
+		return tx.Save(&claim).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// VerifyNamespace checks the namespace's public repositories for the claim's token and
+// marks the claim verified when it's found, then starts a sync to pull in its activity
+func (s *DockerHubService) VerifyNamespace(ctx context.Context, account *models.DockerAccount, namespace string) (*models.NamespaceClaim, error) {
+	namespace = strings.ToLower(namespace)
+
+	var claim models.NamespaceClaim
+	if err := database.DB.Where("docker_account_id = ? AND namespace = ?", account.ID, namespace).First(&claim).Error; err != nil {
+		return nil, ErrNamespaceClaimNotFound
+	}
+	if claim.Verified() {
+		return &claim, nil
+	}
+	if time.Now().After(claim.ExpiresAt) {
+		return nil, ErrNamespaceClaimExpired
+	}
+	if err := checkNamespaceAvailable(account.ID, namespace); err != nil {
+		return nil, err
+	}
+
+	found, err := findNamespaceToken(ctx, dockerHubProofSource{s: s}, namespace, claim.Token)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNamespaceNotVerified
+	}
+
+	now := time.Now()
+	claim.VerifiedAt = &now
+	if err := database.DB.Model(&claim).Update("verified_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	if err := EnqueueSync(account.ID, models.SyncTriggerNamespace, now); err != nil {
+		logging.FromContext(ctx).Error("Failed to queue sync of a namespace", "namespace", namespace, "docker_username", account.DockerUsername, "error", err)
+	}
+
+	return &claim, nil
+}
+
+// RemoveNamespace drops a claim and any activity already synced from its namespace
+func (s *DockerHubService) RemoveNamespace(account *models.DockerAccount, namespace string) error {
+	namespace = strings.ToLower(namespace)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("docker_account_id = ? AND namespace = ?", account.ID, namespace).Delete(&models.NamespaceClaim{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNamespaceClaimNotFound
+		}
+		if err := tx.Unscoped().Where("docker_account_id = ? AND repository LIKE ?", account.ID, likeEscaper.Replace(namespace)+"/%").
+			Delete(&models.ActivityEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("docker_account_id = ? AND name LIKE ?", account.ID, likeEscaper.Replace(namespace)+"/%").
+			Delete(&models.DockerRepository{}).Error; err != nil {
+			return err
+		}
+		return rebuildDailyAggregates(tx, account.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.renderCache.Invalidate(account.DockerUsername)
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// verifiedNamespaces returns the extra namespaces an account syncs
+func verifiedNamespaces(accountID uint) []string {
+	var namespaces []string
+	database.DB.Model(&models.NamespaceClaim{}).
+		Where("docker_account_id = ? AND verified_at IS NOT NULL", accountID).
+		Order("namespace").Pluck("namespace", &namespaces)
+	return namespaces
+}
+
+// checkNamespaceAvailable rejects namespaces that are another account's username or
+// already verified by another account
+func checkNamespaceAvailable(accountID uint, namespace string) error {
+	var count int64
+	database.DB.Model(&models.DockerAccount{}).
+		Where("LOWER(docker_username) = ? AND id <> ?", namespace, accountID).Count(&count)
+	if count > 0 {
+		return ErrNamespaceTaken
+	}
+
+	database.DB.Model(&models.NamespaceClaim{}).
+		Where("namespace = ? AND docker_account_id <> ? AND verified_at IS NOT NULL", namespace, accountID).Count(&count)
+	if count > 0 {
+		return ErrNamespaceTaken
+	}
+	return nil
+}
+
+// findNamespaceToken looks for the token in repository descriptions first, since that
+// takes one request, then in each repository's tag names
+func findNamespaceToken(ctx context.Context, source NamespaceProofSource, namespace, token string) (bool, error) {
+	repos, err := source.Repositories(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	for _, repo := range repos {
+		if strings.Contains(repo.Description, token) {
+			return true, nil
+		}
+	}
+
+	for _, repo := range repos {
+		tags, err := source.Tags(ctx, namespace, repo.Name)
+		if err != nil {
+			continue
+		}
+		for _, tag := range tags {
+			if tag == token {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/notify"
+	"docker-heatmap/internal/utils"
+)
+
+// Delivery retry policy: exponential backoff from the base delay, up to the max attempts
+const (
+	MaxDeliveryAttempts = 6
+	deliveryBaseDelay   = time.Minute
+	deliveryMaxDelay    = 2 * time.Hour
+	deliveryBatchSize   = 100
+	maxChannelsPerUser  = 10
+)
+
+var (
+	ErrChannelNotFound     = errors.New("notification channel not found")
+	ErrTooManyChannels     = errors.New("notification channel limit reached")
+	ErrUnknownNotification = errors.New("unknown notification event")
+)
+
+// NotificationEvents are the events users can subscribe channels to
+var NotificationEvents = []string{
+	notify.EventSyncFailed,
+	notify.EventSyncCompleted,
+	notify.EventNewActivityDay,
+	notify.EventStreakMilestone,
+	notify.EventWeeklyDigest,
+}
+
+// defaultNotificationEvents are what a channel created without events is subscribed to:
+// alerts, rather than the events integrations want on every sync
+var defaultNotificationEvents = []string{notify.EventSyncFailed}
+
+type NotificationService struct{}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// CreateChannel validates and stores a channel for the user, encrypting its settings
+func (s *NotificationService) CreateChannel(userID uint, channelType, name string, settings map[string]string, events []string) (*models.NotificationChannel, error) {
+	ch, err := notify.Get(channelType)
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Validate(settings); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if !isNotificationEvent(event) {
+			return nil, ErrUnknownNotification
+		}
+	}
+	if len(events) == 0 {
+		events = defaultNotificationEvents
+	}
+
+	var count int64
+	database.DB.Model(&models.NotificationChannel{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxChannelsPerUser {
+		return nil, ErrTooManyChannels
+	}
+
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, iv, err := utils.Encrypt(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	channel := &models.NotificationChannel{
+		UserID:            userID,
+		Type:              channelType,
+		Name:              name,
+		EncryptedSettings: encrypted,
+		SettingsIV:        iv,
+		Events:            strings.Join(events, ","),
+		Enabled:           true,
+	}
+	if err := database.DB.Create(channel).Error; err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+// ListChannels returns the user's channels
+func (s *NotificationService) ListChannels(userID uint) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	err := database.DB.Where("user_id = ?", userID).Order("id").Find(&channels).Error
+	return channels, err
+}
+
+// GetChannel returns one of the user's channels
+func (s *NotificationService) GetChannel(userID, channelID uint) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := database.DB.Where("id = ? AND user_id = ?", channelID, userID).First(&channel).Error; err != nil {
+		return nil, ErrChannelNotFound
+	}
+	return &channel, nil
+}
+
+// DeleteChannel removes a channel and its queued deliveries
+func (s *NotificationService) DeleteChannel(userID, channelID uint) error {
+	channel, err := s.GetChannel(userID, channelID)
+	if err != nil {
+		return err
+	}
+	database.DB.Where("channel_id = ?", channel.ID).Delete(&models.NotificationDelivery{})
+	return database.DB.Unscoped().Delete(channel).Error
+}
+
+// Notify queues a message for every enabled channel of the user subscribed to its event,
+// and returns how many it queued it for. Producers only call this; delivery and retries
+// happen in ProcessPending.
+func (s *NotificationService) Notify(userID uint, msg notify.Message) (int, error) {
+	var channels []models.NotificationChannel
+	if err := database.DB.Where("user_id = ? AND enabled = ?", userID, true).Find(&channels).Error; err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	queued := 0
+	for _, channel := range channels {
+		if !channel.Subscribes(msg.Event) {
+			continue
+		}
+		if err := database.DB.Create(&models.NotificationDelivery{
+			ChannelID:     channel.ID,
+			Event:         msg.Event,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		}).Error; err == nil {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// SendTest delivers a test message immediately so users get direct feedback on a channel
+func (s *NotificationService) SendTest(ctx context.Context, userID, channelID uint) error {
+	channel, err := s.GetChannel(userID, channelID)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, channel, notify.Message{
+		Event: notify.EventTest,
+		Title: "Docker Heatmap test notification",
+		Body:  "This channel is set up correctly.",
+	})
+}
+
+// ProcessPending delivers due messages, rescheduling failures with exponential backoff
+func (s *NotificationService) ProcessPending(ctx context.Context) {
+	var deliveries []models.NotificationDelivery
+	err := database.DB.Preload("Channel").
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(deliveryBatchSize).
+		Find(&deliveries).Error
+	if err != nil {
+		slog.Error("Failed to load pending notifications", "error", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var msg notify.Message
+		err := json.Unmarshal([]byte(delivery.Payload), &msg)
+		if err == nil {
+			sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			err = s.deliver(sendCtx, &delivery.Channel, msg)
+			cancel()
+		}
+
+		delivery.Attempts++
+		if err == nil {
+			delivery.Status = models.DeliverySent
+			delivery.LastError = ""
+		} else {
+			delivery.LastError = err.Error()
+			if delivery.Attempts >= MaxDeliveryAttempts {
+				delivery.Status = models.DeliveryFailed
+			} else {
+				delivery.NextAttemptAt = time.Now().Add(deliveryBackoff(delivery.Attempts))
+			}
+		}
+		database.DB.Omit("Channel").Save(&delivery)
+	}
+}
+
+// deliver decrypts the channel settings and sends, recording the outcome on the channel
+func (s *NotificationService) deliver(ctx context.Context, channel *models.NotificationChannel, msg notify.Message) error {
+	ch, err := notify.Get(channel.Type)
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := utils.Decrypt(channel.EncryptedSettings, channel.SettingsIV)
+	if err != nil {
+		return err
+	}
+	var settings map[string]string
+	if err := json.Unmarshal([]byte(decrypted), &settings); err != nil {
+		return err
+	}
+
+	err = ch.Send(ctx, settings, msg)
+	if err != nil {
+		channel.LastError = err.Error()
+	} else {
+		now := time.Now()
+		channel.LastDeliveredAt = &now
+		channel.LastError = ""
+	}
+	database.DB.Model(channel).Select("last_error", "last_delivered_at").Updates(channel)
+	return err
+}
+
+// deliveryBackoff returns the wait before the next attempt: 1m, 2m, 4m... capped at 2h
+func deliveryBackoff(attempts int) time.Duration {
+	delay := deliveryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > deliveryMaxDelay {
+		return deliveryMaxDelay
+	}
+	return delay
+}
+
+func isNotificationEvent(event string) bool {
+	for _, e := range NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
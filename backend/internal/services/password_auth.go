@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/notify"
+	"docker-heatmap/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// MinPasswordLength and MaxPasswordLength bound passwords; bcrypt ignores bytes past 72
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+
+	verifyTokenLifetime = 24 * time.Hour
+	resetTokenLifetime  = time.Hour
+	deleteTokenLifetime = time.Hour
+)
+
+var (
+	ErrInvalidEmail       = errors.New("a valid email address is required")
+	ErrPasswordLength     = fmt.Errorf("password must be %d to %d characters", MinPasswordLength, MaxPasswordLength)
+	ErrInvalidCredentials = errors.New("incorrect email or password")
+	ErrEmailNotVerified   = errors.New("verify your email address before signing in; check your inbox for the link")
+	ErrEmailTokenInvalid  = errors.New("the link is invalid or has expired")
+)
+
+// dummyPasswordHash is compared against when an email has no account, so a failed login
+// takes as long whether or not the account exists. It is a hash at bcrypt.DefaultCost.
+var dummyPasswordHash = []byte("$2a$10$OB/3Fd7tLm3dMtqnwNB7mehrVnvDXy8OpBxwLFd5KbfwXqr0R/sFC")
+
+// PasswordAuthService signs users up and in with an email and password, for deployments
+// that enable AUTH_PASSWORD_ENABLED instead of, or alongside, an OAuth app
+type PasswordAuthService struct{}
+
+func NewPasswordAuthService() *PasswordAuthService {
+	return &PasswordAuthService{}
+}
+
+// Signup creates an account and mails a link to verify its address. To avoid revealing
+// which emails have accounts, an email already in use is not an error: an unverified
+// password account gets a new link and any other account gets nothing. Existing
+// accounts can set a password through a reset instead.
+func (s *PasswordAuthService) Signup(email, password, name string) error {
+	address, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	var existing models.User
+	err = database.DB.Where("LOWER(github_email) = ?", address).Order("id").First(&existing).Error
+	if err == nil {
+		if existing.Provider == "password" && !existing.EmailVerified {
+			return sendEmailToken(&existing, models.EmailTokenVerify)
+		}
+		return nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	hashed := string(hash)
+	username, _, _ := strings.Cut(address, "@")
+	user := &models.User{
+		Provider:       "password",
+		GitHubUsername: username,
+		GitHubEmail:    address,
+		PasswordHash:   &hashed,
+		Name:           SanitizeText(name, 100),
+		PublicProfile:  true,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		return err
+	}
+	return sendEmailToken(user, models.EmailTokenVerify)
+}
+
+// Login checks an email and password and returns the account
+func (s *PasswordAuthService) Login(email, password string) (*models.User, error) {
+	address, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var user models.User
+	err = database.DB.Where("LOWER(github_email) = ? AND password_hash IS NOT NULL", address).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	return &user, nil
+}
+
+// VerifyEmail uses a verification link's token and returns the now verified account
+func (s *PasswordAuthService) VerifyEmail(token string) (*models.User, error) {
+	user, err := useEmailToken(token, models.EmailTokenVerify)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(user).Update("email_verified", true).Error; err != nil {
+		return nil, err
+	}
+	user.EmailVerified = true
+	return user, nil
+}
+
+// RequestPasswordReset mails a reset link if an account has the email. Accounts made
+// with a provider qualify once the provider verified the email, which is how they add a
+// password. Nothing says whether an account exists.
+func (s *PasswordAuthService) RequestPasswordReset(email string) error {
+	address, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	err = database.DB.
+		Where("LOWER(github_email) = ? AND (email_verified = ? OR password_hash IS NOT NULL)", address, true).
+		Order("id").
+		First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return sendEmailToken(&user, models.EmailTokenReset)
+}
+
+// ResetPassword sets a new password with a reset link's token. Following the link
+// proves the user owns the email, so it is verified too. Every existing session is
+// revoked, in case the reset is because the password leaked.
+func (s *PasswordAuthService) ResetPassword(token, password string) (*models.User, error) {
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+	user, err := useEmailToken(token, models.EmailTokenReset)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	hashed := string(hash)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"password_hash":  hashed,
+			"email_verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = &hashed
+	user.EmailVerified = true
+	return user, nil
+}
+
+// sendEmailToken mails the user a link with a new single-use token. Without SMTP the link
+// is logged instead, so the operator of a small deployment can still pass it on.
+func sendEmailToken(user *models.User, purpose string) error {
+	token, err := utils.GenerateRandomString(43)
+	if err != nil {
+		return err
+	}
+	lifetime, path, msg := verifyTokenLifetime, "/auth/verify", notify.Message{
+		Title: "Verify your email for Docker Heatmap",
+		Body:  "Follow the link to verify your email address and sign in. It expires in 24 hours.",
+	}
+	switch purpose {
+	case models.EmailTokenReset:
+		lifetime, path, msg = resetTokenLifetime, "/auth/reset", notify.Message{
+			Title: "Reset your Docker Heatmap password",
+			Body:  "Follow the link to choose a new password. It expires in an hour. If you didn't ask for this, ignore this email.",
+		}
+	case models.EmailTokenDelete:
+		lifetime, path, msg = deleteTokenLifetime, "/account/delete", notify.Message{
+			Title: "Confirm deleting your Docker Heatmap account",
+			Body: "Follow the link to delete your account, your Docker accounts and all their activity. It expires in an hour. " +
+				"If you didn't ask for this, ignore this email and sign out your other sessions.",
+		}
+	}
+
+	now := time.Now()
+	// Only the newest link for a purpose works
+	database.DB.Model(&models.EmailToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+		Update("used_at", now)
+	if err := database.DB.Create(&models.EmailToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashEmailToken(token),
+		ExpiresAt: now.Add(lifetime),
+	}).Error; err != nil {
+		return err
+	}
+
+	msg.URL = config.AppConfig.FrontendURL + path + "?token=" + token
+	if config.AppConfig.SMTPHost == "" {
+		// The link is a live credential, so it's only logged on a development instance
+		if config.AppConfig.Environment == "development" {
+			slog.Info("Email link", "purpose", purpose, "user_id", user.ID, "url", msg.URL)
+		} else {
+			slog.Warn("Email link not sent, SMTP_HOST is not set", "purpose", purpose, "user_id", user.ID)
+		}
+		return nil
+	}
+	ch, err := notify.Get("email")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return ch.Send(ctx, map[string]string{"to": user.GitHubEmail}, msg)
+}
+
+// useEmailToken marks a token used and returns its user. The update is conditional, so a
+// token raced through twice only works once.
+func useEmailToken(token, purpose string) (*models.User, error) {
+	var emailToken models.EmailToken
+	err := database.DB.Where("token_hash = ? AND purpose = ?", hashEmailToken(token), purpose).First(&emailToken).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrEmailTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := database.DB.Model(&models.EmailToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", emailToken.ID, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrEmailTokenInvalid
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, emailToken.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrEmailTokenInvalid
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" || len(addr.Address) > 255 {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrPasswordLength
+	}
+	return nil
+}
+
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
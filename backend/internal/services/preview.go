@@ -0,0 +1,85 @@
+package services
+
+import (
+	"math/rand"
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// SampleUsername is the handle shown on sample previews
+const SampleUsername = "sample"
+
+// DefaultSampleSeed keeps sample previews identical between requests unless a seed is given
+const DefaultSampleSeed = 42
+
+// sampleActivitySource generates seeded synthetic activity so heatmaps can be previewed
+// without a connected account. It reuses the distribution used to seed staging data.
+type sampleActivitySource struct {
+	seed int64
+}
+
+func (src sampleActivitySource) ResolveLocation(_, tz string) *time.Location {
+	if tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+func (src sampleActivitySource) ResolveWeekStart(_, weekStart string) time.Weekday {
+	day, _ := ParseWeekStart(weekStart)
+	return day
+}
+
+func (src sampleActivitySource) GetActivitySummaryRange(_ string, startDate, endDate time.Time, filter ActivityFilter) ([]models.ActivitySummary, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	startDate = startDate.In(loc)
+	endDate = endDate.In(loc)
+	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+
+	days := int(time.Now().Sub(startDate).Hours()/24) + 2
+	events := generateSeedEvents(rand.New(rand.NewSource(src.seed)), 0, days)
+	if len(filter.EventTypes) > 0 {
+		filtered := events[:0]
+		for _, event := range events {
+			for _, eventType := range filter.EventTypes {
+				if event.EventType == eventType {
+					filtered = append(filtered, event)
+					break
+				}
+			}
+		}
+		events = filtered
+	}
+
+	return summarizeEvents(events, startDate, endDate, filter), nil
+}
+
+// GenerateSampleSVG renders a heatmap from generated sample data with any options,
+// for the docs site and theme builder
+func (s *HeatmapService) GenerateSampleSVG(opts SVGOptions, seed int64) ([]byte, []ThemeWarning, error) {
+	if seed == 0 {
+		seed = DefaultSampleSeed
+	}
+	// Sample data always runs up to today; a frozen end date would only show an empty grid
+	opts.EndDate = time.Time{}
+
+	data, warnings, err := buildHeatmapDataFrom(sampleActivitySource{seed: seed}, time.Now(), SampleUsername, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svg, err := renderHeatmapSVG(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// No account owns sample data, so only the deployment-wide attribution policy applies
+	return s.applyAttribution("", svg), warnings, nil
+}
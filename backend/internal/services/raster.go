@@ -0,0 +1,391 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// PNGScale renders PNGs and WebPs at twice the SVG size so they stay sharp on high-DPI
+	// screens
+	PNGScale = 2
+	// GIFScale keeps animated GIFs at the SVG size; every frame adds to the file
+	GIFScale = 1
+
+	gifFrames     = 12
+	gifFrameDelay = 8   // hundredths of a second
+	gifFinalDelay = 300 // hold the finished heatmap before looping
+)
+
+var (
+	rasterFonts     map[string]*opentype.Font
+	rasterFontsErr  error
+	rasterFontsOnce sync.Once
+)
+
+// rasterFont returns the parsed Go font used to draw text into raster images.
+// CSS font stacks can't be resolved server-side, so only go-mono is honoured.
+func rasterFont(name string, bold bool) (*opentype.Font, error) {
+	rasterFontsOnce.Do(func() {
+		rasterFonts = make(map[string]*opentype.Font)
+		for key, ttf := range map[string][]byte{"regular": goregular.TTF, "bold": gobold.TTF, "mono": gomono.TTF} {
+			f, err := opentype.Parse(ttf)
+			if err != nil {
+				rasterFontsErr = err
+				return
+			}
+			rasterFonts[key] = f
+		}
+	})
+	if rasterFontsErr != nil {
+		return nil, rasterFontsErr
+	}
+	switch {
+	case strings.ToLower(name) == BundledFont:
+		return rasterFonts["mono"], nil
+	case bold:
+		return rasterFonts["bold"], nil
+	default:
+		return rasterFonts["regular"], nil
+	}
+}
+
+// rasterCanvas draws heatmap layout data onto an RGBA image at a fixed scale
+type rasterCanvas struct {
+	img   *image.RGBA
+	scale int
+	font  string
+	faces map[string]font.Face
+}
+
+func newRasterCanvas(width, height, scale int, fontName string) *rasterCanvas {
+	return &rasterCanvas{
+		img:   image.NewRGBA(image.Rect(0, 0, width*scale, height*scale)),
+		scale: scale,
+		font:  fontName,
+		faces: make(map[string]font.Face),
+	}
+}
+
+func (rc *rasterCanvas) close() {
+	for _, face := range rc.faces {
+		face.Close()
+	}
+}
+
+// fillRoundedRect fills a rectangle given in SVG units. Unparseable colors (e.g.
+// "transparent") leave the canvas untouched.
+func (rc *rasterCanvas) fillRoundedRect(x, y, w, h, radius int, value string) {
+	c, ok := parseColor(value)
+	if !ok {
+		return
+	}
+	fill := color.RGBA{c.R, c.G, c.B, 0xff}
+	s := rc.scale
+	x0, y0, x1, y1 := x*s, y*s, (x+w)*s, (y+h)*s
+	r := radius * s
+	if r*2 > x1-x0 {
+		r = (x1 - x0) / 2
+	}
+
+	bounds := rc.img.Bounds()
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			if !(image.Point{px, py}).In(bounds) || !insideRoundedRect(px-x0, py-y0, x1-x0, y1-y0, r) {
+				continue
+			}
+			rc.img.SetRGBA(px, py, fill)
+		}
+	}
+}
+
+// insideRoundedRect reports whether the pixel at (px, py) within a w×h box falls
+// inside its corners of radius r
+func insideRoundedRect(px, py, w, h, r int) bool {
+	if r <= 0 {
+		return true
+	}
+	cx, cy := -1, -1
+	switch {
+	case px < r:
+		cx = r
+	case px >= w-r:
+		cx = w - r - 1
+	}
+	switch {
+	case py < r:
+		cy = r
+	case py >= h-r:
+		cy = h - r - 1
+	}
+	if cx < 0 || cy < 0 {
+		return true
+	}
+	dx, dy := px-cx, py-cy
+	return dx*dx+dy*dy <= r*r
+}
+
+// drawText draws text with its baseline at (x, y) in SVG units. anchorEnd right-aligns it.
+func (rc *rasterCanvas) drawText(x, y int, text string, size int, value string, bold, anchorEnd bool) error {
+	c, ok := parseColor(value)
+	if !ok || text == "" {
+		return nil
+	}
+
+	key := fmt.Sprintf("%d/%t", size, bold)
+	face, ok := rc.faces[key]
+	if !ok {
+		f, err := rasterFont(rc.font, bold)
+		if err != nil {
+			return err
+		}
+		face, err = opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    float64(size * rc.scale),
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return err
+		}
+		rc.faces[key] = face
+	}
+
+	drawer := &font.Drawer{
+		Dst:  rc.img,
+		Src:  image.NewUniform(color.RGBA{c.R, c.G, c.B, 0xff}),
+		Face: face,
+		Dot:  fixed.P(x*rc.scale, y*rc.scale),
+	}
+	if anchorEnd {
+		drawer.Dot.X -= drawer.MeasureString(text)
+	}
+	drawer.DrawString(text)
+	return nil
+}
+
+// drawFrame draws everything except the activity cells
+func (rc *rasterCanvas) drawFrame(data *SVGData, attribution string) error {
+	cfg := data.Config
+	rc.fillRoundedRect(0, 0, data.Width, data.Height, 6, cfg.BgColor)
+
+	if !data.HideLabels {
+		for _, label := range data.MonthLabels {
+			if err := rc.drawText(label.X, label.Y, label.Label, cfg.FontSize, cfg.TextColor, false, false); err != nil {
+				return err
+			}
+		}
+		for _, label := range data.DayLabels {
+			if err := rc.drawText(label.X, label.Y, label.Label, cfg.FontSize-1, cfg.TextColor, false, false); err != nil {
+				return err
+			}
+		}
+		for _, label := range data.YearLabels {
+			if err := rc.drawText(label.X, label.Y, label.Label, cfg.FontSize-1, cfg.TextColor, false, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !data.HideTotal {
+		title := data.CustomTitle
+		if title == "" {
+			title = fmt.Sprintf("@%s Docker Activity • %d total", data.Username, data.TotalCount)
+		}
+		if err := rc.drawText(data.CellsOffsetX, data.FooterY, title, cfg.FontSize+1, cfg.TextColor, true, false); err != nil {
+			return err
+		}
+	}
+
+	if !data.HideLegend {
+		if err := rc.drawText(data.LegendX-4, data.LegendY+10, "Less", cfg.FontSize-1, cfg.TextColor, false, true); err != nil {
+			return err
+		}
+		for i, c := range cfg.Colors {
+			rc.fillRoundedRect(data.LegendX+i*14, data.LegendY, 11, 11, 2, c)
+		}
+		if err := rc.drawText(data.LegendX+71, data.LegendY+10, "More", cfg.FontSize-1, cfg.TextColor, false, false); err != nil {
+			return err
+		}
+	}
+
+	if attribution != "" {
+		return rc.drawText(data.Width-6, data.Height-3, attribution, 7, "#8b949e", false, true)
+	}
+	return nil
+}
+
+// drawCell draws a cell at the given level. Gradient fills are flattened to the level color.
+func (rc *rasterCanvas) drawCell(data *SVGData, cell Cell, level int) {
+	if level < 0 || level >= len(data.Config.Colors) {
+		level = 0
+	}
+	if data.Config.BorderColor != "" {
+		rc.fillRoundedRect(data.CellsOffsetX+cell.X, 25+cell.Y, cell.Width, cell.Height, cell.Radius, data.Config.BorderColor)
+		rc.fillRoundedRect(data.CellsOffsetX+cell.X+1, 25+cell.Y+1, cell.Width-2, cell.Height-2, cell.Radius, data.Config.Colors[level])
+		return
+	}
+	rc.fillRoundedRect(data.CellsOffsetX+cell.X, 25+cell.Y, cell.Width, cell.Height, cell.Radius, data.Config.Colors[level])
+}
+
+// GeneratePNG renders the heatmap as a PNG using the same layout as the SVG
+func (s *HeatmapService) GeneratePNG(dockerUsername string, opts SVGOptions) ([]byte, error) {
+	img, err := s.renderRaster(dockerUsername, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateWebP renders the heatmap as a lossless WebP, the same image as GeneratePNG in
+// fewer bytes
+func (s *HeatmapService) GenerateWebP(dockerUsername string, opts SVGOptions) ([]byte, error) {
+	img, err := s.renderRaster(dockerUsername, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode webp: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderRaster draws the finished heatmap at PNGScale
+func (s *HeatmapService) renderRaster(dockerUsername string, opts SVGOptions) (*image.RGBA, error) {
+	data, _, err := s.buildHeatmapData(dockerUsername, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := newRasterCanvas(data.Width, data.Height, PNGScale, opts.Font)
+	defer rc.close()
+
+	if err := rc.drawFrame(data, s.dockerService.AttributionFor(dockerUsername)); err != nil {
+		return nil, fmt.Errorf("failed to draw heatmap: %w", err)
+	}
+	for _, cell := range data.Cells {
+		rc.drawCell(data, cell, cell.Level)
+	}
+	return rc.img, nil
+}
+
+// GenerateAnimatedGIF renders the heatmap as a looping GIF that fills in cells in
+// chronological order, then holds on the finished heatmap
+func (s *HeatmapService) GenerateAnimatedGIF(dockerUsername string, opts SVGOptions) ([]byte, error) {
+	data, _, err := s.buildHeatmapData(dockerUsername, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := newRasterCanvas(data.Width, data.Height, GIFScale, opts.Font)
+	defer rc.close()
+
+	if err := rc.drawFrame(data, s.dockerService.AttributionFor(dockerUsername)); err != nil {
+		return nil, fmt.Errorf("failed to draw heatmap: %w", err)
+	}
+	for _, cell := range data.Cells {
+		rc.drawCell(data, cell, 0)
+	}
+
+	cells := make([]Cell, 0, len(data.Cells))
+	for _, cell := range data.Cells {
+		if cell.Level > 0 {
+			cells = append(cells, cell)
+		}
+	}
+	sort.SliceStable(cells, func(i, j int) bool { return cells[i].Date < cells[j].Date })
+
+	palette := gifPalette(data.Config)
+	anim := &gif.GIF{}
+	revealed := 0
+	for frame := 1; frame <= gifFrames; frame++ {
+		target := len(cells) * frame / gifFrames
+		for ; revealed < target; revealed++ {
+			rc.drawCell(data, cells[revealed], cells[revealed].Level)
+		}
+
+		paletted := image.NewPaletted(rc.img.Bounds(), palette)
+		draw.Draw(paletted, paletted.Bounds(), rc.img, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+
+		delay := gifFrameDelay
+		if frame == gifFrames {
+			delay = gifFinalDelay
+		}
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, fmt.Errorf("failed to encode gif: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gifPalette builds a palette from the theme colors, plus blends of the text color
+// into the background so antialiased labels keep their shape
+func gifPalette(cfg HeatmapConfig) color.Palette {
+	palette := color.Palette{color.RGBA{}}
+	seen := map[color.RGBA]bool{{}: true}
+	add := func(c rgb) {
+		value := color.RGBA{c.R, c.G, c.B, 0xff}
+		if !seen[value] {
+			seen[value] = true
+			palette = append(palette, value)
+		}
+	}
+
+	for _, value := range append(append([]string{}, cfg.Colors...), cfg.BgColor, cfg.BorderColor, "#8b949e") {
+		if c, ok := parseColor(value); ok {
+			add(c)
+		}
+	}
+
+	text, ok := parseColor(cfg.TextColor)
+	if !ok {
+		return palette
+	}
+	add(text)
+	bg, ok := parseColor(cfg.BgColor)
+	if !ok {
+		// Transparent backgrounds blend text into alpha instead; approximate with half-tones
+		for _, alpha := range []uint8{0x40, 0x80, 0xc0} {
+			value := color.RGBA{
+				uint8(uint16(text.R) * uint16(alpha) / 0xff),
+				uint8(uint16(text.G) * uint16(alpha) / 0xff),
+				uint8(uint16(text.B) * uint16(alpha) / 0xff),
+				alpha,
+			}
+			if !seen[value] {
+				seen[value] = true
+				palette = append(palette, value)
+			}
+		}
+		return palette
+	}
+	for _, t := range []float64{0.25, 0.5, 0.75} {
+		add(mixColors(bg, text, t))
+	}
+	return palette
+}
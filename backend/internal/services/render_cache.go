@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Render cache entries live no longer than the longest Cache-Control we hand out
+const renderCacheTTL = MaxCacheMaxAge * time.Second
+
+// renderCacheTimeout keeps a slow Redis from holding up a render it was meant to speed up
+const renderCacheTimeout = 200 * time.Millisecond
+
+// RenderCache stores rendered SVG/JSON bodies in Redis, shared across instances. Entries
+// are keyed by username and the response ETag, which already tracks options, owner settings
+// and day rollover; a per-account generation counter drops everything when a sync completes.
+// All methods are no-ops when Redis is not configured.
+type RenderCache struct{}
+
+func NewRenderCache() *RenderCache {
+	return &RenderCache{}
+}
+
+func renderGenerationKey(username string) string {
+	return "render:gen:" + strings.ToLower(username)
+}
+
+// key returns the entry key for an ETag under the account's current generation
+func (rc *RenderCache) key(ctx context.Context, username, etag string) (string, error) {
+	gen, err := cache.Client.Get(ctx, renderGenerationKey(username)).Result()
+	if err == redis.Nil {
+		gen = "0"
+	} else if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(etag))
+	return "render:" + strings.ToLower(username) + ":" + gen + ":" + hex.EncodeToString(sum[:16]), nil
+}
+
+// Get returns a cached body for the account's response with this ETag
+func (rc *RenderCache) Get(username, etag string) ([]byte, bool) {
+	if !cache.Enabled() {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), renderCacheTimeout)
+	defer cancel()
+
+	key, err := rc.key(ctx, username, etag)
+	if err != nil {
+		return nil, false
+	}
+	body, err := cache.Client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Warn("Render cache read failed", "docker_username", username, "error", err)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// Set stores a rendered body for the account's response with this ETag
+func (rc *RenderCache) Set(username, etag string, body []byte) {
+	if !cache.Enabled() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), renderCacheTimeout)
+	defer cancel()
+
+	key, err := rc.key(ctx, username, etag)
+	if err != nil {
+		return
+	}
+	if err := cache.Client.Set(ctx, key, body, renderCacheTTL).Err(); err != nil {
+		slog.Warn("Render cache write failed", "docker_username", username, "error", err)
+	}
+}
+
+// Invalidate drops every cached render for the account by moving it to a new generation;
+// old entries are never read again and expire on their own
+func (rc *RenderCache) Invalidate(username string) {
+	if !cache.Enabled() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), renderCacheTimeout)
+	defer cancel()
+
+	if err := cache.Client.Incr(ctx, renderGenerationKey(username)).Err(); err != nil {
+		slog.Warn("Render cache invalidation failed", "docker_username", username, "error", err)
+	}
+}
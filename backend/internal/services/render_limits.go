@@ -0,0 +1,46 @@
+package services
+
+import "docker-heatmap/internal/config"
+
+// ValidDays reports whether days is an accepted trailing window for this deployment
+func ValidDays(days int) bool {
+	return days > 0 && days <= config.AppConfig.Render.MaxDays
+}
+
+// ValidCellSize reports whether size is an accepted cell size for this deployment
+func ValidCellSize(size int) bool {
+	return size >= config.AppConfig.Render.MinCellSize && size <= config.AppConfig.Render.MaxCellSize
+}
+
+// ValidRadius reports whether radius is an accepted cell radius for this deployment
+func ValidRadius(radius int) bool {
+	return radius >= 0 && radius <= config.AppConfig.Render.MaxRadius
+}
+
+// DefaultSVGOptions returns render options set to the deployment's defaults
+func DefaultSVGOptions() SVGOptions {
+	return SVGOptions{
+		Theme:      "github",
+		Days:       config.AppConfig.Render.DefaultDays,
+		CellSize:   config.AppConfig.Render.DefaultCellSize,
+		CellRadius: config.AppConfig.Render.DefaultRadius,
+	}
+}
+
+// normalizeRenderOptions replaces out-of-bounds size options with the deployment's
+// defaults and sanitizes user-supplied text and colors, so renderers are safe however
+// their options were built
+func normalizeRenderOptions(opts *SVGOptions) {
+	sanitizeRenderOptions(opts)
+
+	limits := config.AppConfig.Render
+	if !ValidDays(opts.Days) {
+		opts.Days = limits.DefaultDays
+	}
+	if !ValidCellSize(opts.CellSize) {
+		opts.CellSize = limits.DefaultCellSize
+	}
+	if !ValidRadius(opts.CellRadius) {
+		opts.CellRadius = limits.DefaultRadius
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// RepoHeatHalfLife is how long it takes an event's contribution to a repository's heat to halve
+const RepoHeatHalfLife = 14 * 24 * time.Hour
+
+// Sort orders accepted by GetRepositoryStats
+const (
+	RepoSortHeat   = "heat"
+	RepoSortEvents = "events"
+	RepoSortRecent = "recent"
+	RepoSortName   = "name"
+)
+
+// RepoSortOptions lists the accepted repository sort orders, default first
+var RepoSortOptions = []string{RepoSortHeat, RepoSortEvents, RepoSortRecent, RepoSortName}
+
+// RepoStats summarizes a repository's activity over a window
+type RepoStats struct {
+	Repository   string    `json:"repository"`
+	Events       int       `json:"events"`
+	LastActivity time.Time `json:"last_activity"`
+	// Heat is the weighted event count decayed by age, so recent pushes count for more
+	Heat float64 `json:"heat"`
+	// RelativeHeat is Heat scaled against the hottest repository (0-1)
+	RelativeHeat float64 `json:"relative_heat"`
+}
+
+// repoHeatDecay returns the weight of an event that happened age ago
+func repoHeatDecay(age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(RepoHeatHalfLife))
+}
+
+// GetRepositoryStats ranks an account's repositories by activity over the last days
+func (s *DockerHubService) GetRepositoryStats(dockerUsername string, days int, filter ActivityFilter, sortBy string) ([]RepoStats, error) {
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	query := database.DB.Where("docker_account_id = ? AND event_date >= ? AND repository <> ''",
+		account.ID, now.AddDate(0, 0, -days-1))
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+
+	var events []models.ActivityEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	byRepo := make(map[string]*RepoStats)
+	for _, event := range events {
+		if !filter.includes(&event) {
+			continue
+		}
+		at := event.EventDate
+		if event.EventAt != nil {
+			at = *event.EventAt
+		}
+
+		stats, ok := byRepo[event.Repository]
+		if !ok {
+			stats = &RepoStats{Repository: event.Repository}
+			byRepo[event.Repository] = stats
+		}
+		stats.Events += event.Count
+		stats.Heat += float64(event.Count) * filter.weight(event.EventType) * repoHeatDecay(now.Sub(at))
+		if at.After(stats.LastActivity) {
+			stats.LastActivity = at
+		}
+	}
+
+	result := make([]RepoStats, 0, len(byRepo))
+	maxHeat := 0.0
+	for _, stats := range byRepo {
+		stats.Heat = math.Round(stats.Heat*100) / 100
+		if stats.Heat > maxHeat {
+			maxHeat = stats.Heat
+		}
+		result = append(result, *stats)
+	}
+	for i := range result {
+		if maxHeat > 0 {
+			result[i].RelativeHeat = math.Round(result[i].Heat/maxHeat*100) / 100
+		}
+	}
+
+	SortRepoStats(result, sortBy)
+	return result, nil
+}
+
+// SortRepoStats orders repositories in place; unknown orders fall back to heat.
+// Ties break by name so the order is stable between requests.
+func SortRepoStats(stats []RepoStats, sortBy string) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		switch strings.ToLower(sortBy) {
+		case RepoSortEvents:
+			if a.Events != b.Events {
+				return a.Events > b.Events
+			}
+		case RepoSortRecent:
+			if !a.LastActivity.Equal(b.LastActivity) {
+				return a.LastActivity.After(b.LastActivity)
+			}
+		case RepoSortName:
+		default:
+			if a.Heat != b.Heat {
+				return a.Heat > b.Heat
+			}
+		}
+		return a.Repository < b.Repository
+	})
+}
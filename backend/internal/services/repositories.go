@@ -0,0 +1,132 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RepositoryDetail combines a repository's Docker Hub metadata with its synced activity
+type RepositoryDetail struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	PullCount   int64      `json:"pull_count"`
+	StarCount   int        `json:"star_count"`
+	IsPrivate   bool       `json:"is_private"`
+	LastUpdated *time.Time `json:"last_updated"`
+
+	Events       int        `json:"events"`
+	Pushes       int        `json:"pushes"`
+	Pulls        int        `json:"pulls"`
+	Builds       int        `json:"builds"`
+	LastActivity *time.Time `json:"last_activity"`
+}
+
+// storeRepositories replaces the stored metadata of one namespace's repositories with
+// what Docker Hub just returned. Names get prefix, like their activity events; the
+// account's own repositories have none.
+func storeRepositories(accountID uint, prefix string, repos []DockerHubRepository) error {
+	rows := make([]models.DockerRepository, 0, len(repos))
+	for _, repo := range repos {
+		row := models.DockerRepository{
+			DockerAccountID: accountID,
+			Name:            prefix + repo.Name,
+			Description:     repo.Description,
+			PullCount:       repo.PullCount,
+			StarCount:       repo.StarCount,
+			IsPrivate:       repo.IsPrivate,
+		}
+		if t, err := parseDockerHubTime(repo.LastUpdated); err == nil {
+			row.LastUpdated = &t
+		}
+		rows = append(rows, row)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		scope := tx.Where("docker_account_id = ?", accountID)
+		if prefix == "" {
+			scope = scope.Where("name NOT LIKE ?", "%/%")
+		} else {
+			scope = scope.Where("name LIKE ?", likeEscaper.Replace(prefix)+"%")
+		}
+		if err := scope.Delete(&models.DockerRepository{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+}
+
+// ListRepositories returns an account's repositories with their Docker Hub metadata and
+// activity totals over the last days, most pulled first. Repositories that only appear in
+// activity (synced before metadata was kept) are included with zero counts.
+func (s *DockerHubService) ListRepositories(accountID uint, days int, includePrivate bool) ([]RepositoryDetail, error) {
+	var stored []models.DockerRepository
+	if err := database.DB.Where("docker_account_id = ?", accountID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	var events []models.ActivityEvent
+	if err := database.DB.Where("docker_account_id = ? AND event_date >= ? AND repository <> ''",
+		accountID, time.Now().UTC().AddDate(0, 0, -days-1)).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*RepositoryDetail, len(stored))
+	for _, repo := range stored {
+		byName[repo.Name] = &RepositoryDetail{
+			Name:        repo.Name,
+			Description: repo.Description,
+			PullCount:   repo.PullCount,
+			StarCount:   repo.StarCount,
+			IsPrivate:   repo.IsPrivate,
+			LastUpdated: repo.LastUpdated,
+		}
+	}
+
+	for _, event := range events {
+		detail, ok := byName[event.Repository]
+		if !ok {
+			detail = &RepositoryDetail{Name: event.Repository}
+			byName[event.Repository] = detail
+		}
+		detail.Events += event.Count
+		switch event.EventType {
+		case models.EventTypePush:
+			detail.Pushes += event.Count
+		case models.EventTypePull:
+			detail.Pulls += event.Count
+		case models.EventTypeBuild:
+			detail.Builds += event.Count
+		}
+
+		at := event.EventDate
+		if event.EventAt != nil {
+			at = *event.EventAt
+		}
+		if detail.LastActivity == nil || at.After(*detail.LastActivity) {
+			detail.LastActivity = &at
+		}
+	}
+
+	result := make([]RepositoryDetail, 0, len(byName))
+	for _, detail := range byName {
+		if detail.IsPrivate && !includePrivate {
+			continue
+		}
+		result = append(result, *detail)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PullCount != result[j].PullCount {
+			return result[i].PullCount > result[j].PullCount
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
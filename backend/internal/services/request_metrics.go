@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/database"
+)
+
+type requestKey struct {
+	day         string
+	statusClass string
+}
+
+var (
+	pendingRequestsMu sync.Mutex
+	pendingRequests   = make(map[requestKey]int64)
+)
+
+// RecordRequest counts one answered API request by the class of its status. Like views,
+// counts are held in memory and written out by FlushRequestCounts.
+func RecordRequest(status int) {
+	key := requestKey{day: time.Now().UTC().Format("2006-01-02"), statusClass: fmt.Sprintf("%dxx", status/100)}
+
+	pendingRequestsMu.Lock()
+	pendingRequests[key]++
+	pendingRequestsMu.Unlock()
+}
+
+// FlushRequestCounts adds the counts recorded since the last flush to the daily totals.
+// Counts that fail to write are kept for the next flush.
+func FlushRequestCounts() {
+	pendingRequestsMu.Lock()
+	batch := pendingRequests
+	pendingRequests = make(map[requestKey]int64)
+	pendingRequestsMu.Unlock()
+
+	upsertRequestCount := `
+		ON CONFLICT (day, status_class)
+		DO UPDATE SET count = daily_request_counts.count + ?
+	`
+	if database.IsMySQL() {
+		upsertRequestCount = `ON DUPLICATE KEY UPDATE count = count + ?`
+	}
+
+	failed := 0
+	for key, count := range batch {
+		err := database.DB.Exec(`
+			INSERT INTO daily_request_counts (day, status_class, count) VALUES (?, ?, ?)
+		`+upsertRequestCount, key.day, key.statusClass, count, count).Error
+		if err != nil {
+			failed++
+			pendingRequestsMu.Lock()
+			pendingRequests[key] += count
+			pendingRequestsMu.Unlock()
+		}
+	}
+
+	if failed > 0 {
+		slog.Warn("Failed to flush request counters, retrying next flush", "count", failed)
+	}
+}
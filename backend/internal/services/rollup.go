@@ -0,0 +1,78 @@
+package services
+
+import (
+	"time"
+
+	"docker-heatmap/internal/models"
+)
+
+// Granularities accepted by RollupActivity
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// IsValidGranularity reports whether value is a supported rollup granularity
+func IsValidGranularity(value string) bool {
+	switch value {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// RollupActivity merges daily summaries into weekly or monthly buckets. Each bucket's
+// Date is its first day (the week's first weekday, or the 1st of the month), and the
+// first and last buckets may be partial. Levels are recomputed from bucket counts,
+// since per-day weighted scores don't carry over.
+func RollupActivity(daily []models.ActivitySummary, granularity string, firstDay time.Weekday) []models.ActivitySummary {
+	if granularity != GranularityWeek && granularity != GranularityMonth {
+		return daily
+	}
+
+	var buckets []models.ActivitySummary
+	index := make(map[string]int)
+	for _, day := range daily {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			continue
+		}
+		if granularity == GranularityWeek {
+			date = date.AddDate(0, 0, -weekRow(date.Weekday(), firstDay))
+		} else {
+			date = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+		key := date.Format("2006-01-02")
+
+		i, ok := index[key]
+		if !ok {
+			i = len(buckets)
+			index[key] = i
+			buckets = append(buckets, models.ActivitySummary{Date: key})
+		}
+		bucket := &buckets[i]
+		bucket.TotalCount += day.TotalCount
+		bucket.Pushes += day.Pushes
+		bucket.Pulls += day.Pulls
+		bucket.Builds += day.Builds
+		for repo, count := range day.Repositories {
+			if bucket.Repositories == nil {
+				bucket.Repositories = make(map[string]int)
+			}
+			bucket.Repositories[repo] += count
+		}
+	}
+
+	maxCount := 0
+	for _, bucket := range buckets {
+		if bucket.TotalCount > maxCount {
+			maxCount = bucket.TotalCount
+		}
+	}
+	for i := range buckets {
+		buckets[i].Level = calculateLevel(float64(buckets[i].TotalCount), float64(maxCount))
+	}
+
+	return buckets
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTitleLength caps user-supplied title text, in characters
+const MaxTitleLength = 120
+
+// SanitizeText prepares user-supplied text for every rendered output (SVG, PNG/GIF and
+// terminal). It drops invalid UTF-8 and characters that are invalid in XML 1.0 or can
+// corrupt or disguise the output: control characters (including ANSI escapes), bidi
+// overrides and isolates, invisible characters and noncharacters. Whitespace runs collapse
+// to one space and the result is cut to maxRunes (0 means no limit).
+//
+// Sanitizing is not escaping: the SVG templates still escape for their context through
+// html/template, and SVG built by hand must use EscapeSVGText.
+func SanitizeText(text string, maxRunes int) string {
+	var b strings.Builder
+	count := 0
+	pendingSpace := false
+	for _, r := range text {
+		switch {
+		case r == utf8.RuneError:
+			continue
+		case unicode.IsSpace(r):
+			pendingSpace = count > 0
+			continue
+		case !isRenderableRune(r):
+			continue
+		}
+
+		if pendingSpace {
+			if maxRunes > 0 && count+1 >= maxRunes {
+				break
+			}
+			b.WriteByte(' ')
+			count++
+			pendingSpace = false
+		}
+		if maxRunes > 0 && count >= maxRunes {
+			break
+		}
+		b.WriteRune(r)
+		count++
+	}
+	return b.String()
+}
+
+// isRenderableRune reports whether r may appear in rendered text
+func isRenderableRune(r rune) bool {
+	switch {
+	case unicode.IsControl(r): // C0, DEL and C1, which includes ESC and CSI
+		return false
+	case r == 0x061C, r == 0x200E, r == 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069: // Bidi marks, embeddings, overrides and isolates
+		return false
+	case r == 0x200B, r == 0x2060, r == 0xFEFF: // Zero-width space, word joiner, BOM
+		return false
+	case r >= 0xFDD0 && r <= 0xFDEF, r&0xFFFE == 0xFFFE: // Noncharacters
+		return false
+	}
+	return true
+}
+
+// EscapeSVGText sanitizes text and escapes it for SVG character data or a quoted attribute
+func EscapeSVGText(text string) string {
+	return svgEscaper.Replace(SanitizeText(text, 0))
+}
+
+var svgEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&#34;",
+	"'", "&#39;",
+)
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// SanitizeColor returns color if it is a #hex color or "transparent", and "" otherwise
+func SanitizeColor(color string) string {
+	color = strings.TrimSpace(color)
+	if color == "transparent" || hexColorPattern.MatchString(color) {
+		return color
+	}
+	return ""
+}
+
+// sanitizeRenderOptions cleans every user-controlled string in opts before it reaches a
+// renderer. Invalid colors are dropped so the theme's own colors apply.
+func sanitizeRenderOptions(opts *SVGOptions) {
+	opts.CustomTitle = SanitizeText(opts.CustomTitle, MaxTitleLength)
+	opts.BgColor = SanitizeColor(opts.BgColor)
+	opts.TextColor = SanitizeColor(opts.TextColor)
+	opts.BorderColor = SanitizeColor(opts.BorderColor)
+
+	for _, color := range opts.CustomColors {
+		if SanitizeColor(color) == "" {
+			opts.CustomColors = nil
+			break
+		}
+	}
+}
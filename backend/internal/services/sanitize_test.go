@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"docker-heatmap/internal/testutil"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"plain", "My Docker year", 0, "My Docker year"},
+		{"whitespace collapses", "  a \t\n b  ", 0, "a b"},
+		{"ansi escape", "\x1b[31mred\x1b[0m", 0, "[31mred[0m"},
+		{"c0 and del", "a\x00b\x07c\x7fd", 0, "abcd"},
+		{"c1", "a\u0080b\u009bc", 0, "abc"},
+		{"next line is whitespace", "a\u0085b", 0, "a b"},
+		{"bidi override", "admin\u202egnp.exe", 0, "admingnp.exe"},
+		{"bidi isolate", "\u2066evil\u2069", 0, "evil"},
+		{"zero width", "a\u200bb\u2060c\ufeffd", 0, "abcd"},
+		{"noncharacters", "a\ufdd0b\uffffc\U0001fffed", 0, "abcd"},
+		{"invalid utf-8", "a\xffb\xc0c", 0, "abc"},
+		{"markup is left to escaping", "<b>&amp;</b>", 0, "<b>&amp;</b>"},
+		{"truncates", "abcdef", 3, "abc"},
+		{"no trailing space at the limit", "abc def", 4, "abc"},
+		{"emoji", "ship it 🚢", 0, "ship it 🚢"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeText(tt.in, tt.max); got != tt.want {
+			t.Errorf("%s: SanitizeText(%q, %d) = %q, want %q", tt.name, tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeSVGText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`<script>alert(1)</script>`, `&lt;script&gt;alert(1)&lt;/script&gt;`},
+		{`"><svg onload=alert(1)>`, `&#34;&gt;&lt;svg onload=alert(1)&gt;`},
+		{`' onmouseover='x`, `&#39; onmouseover=&#39;x`},
+		// An entity in the input is text, not markup: it mustn't decode back to a tag
+		{`&lt;script&gt;`, `&amp;lt;script&amp;gt;`},
+		{`&#60;img src=x&#62;`, `&amp;#60;img src=x&amp;#62;`},
+		{`<![CDATA[<script>]]>`, `&lt;![CDATA[&lt;script&gt;]]&gt;`},
+		{"a\x00<b\x1b>", `a&lt;b&gt;`},
+	}
+	for _, tt := range tests {
+		if got := EscapeSVGText(tt.in); got != tt.want {
+			t.Errorf("EscapeSVGText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"#fff", "#fff"},
+		{" #0d1117 ", "#0d1117"},
+		{"#0d1117cc", "#0d1117cc"},
+		{"transparent", "transparent"},
+		{"red", ""},
+		{"#12345", ""},
+		{`#fff" onload="alert(1)`, ""},
+		{"url(javascript:alert(1))", ""},
+		{"#fff;background:url(x)", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeColor(tt.in); got != tt.want {
+			t.Errorf("SanitizeColor(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHostileTitleRendersAsText(t *testing.T) {
+	testutil.LoadConfig()
+
+	titles := []string{
+		`<script>alert(document.domain)</script>`,
+		`"/><foreignObject><iframe src="javascript:alert(1)"/></foreignObject><text x="`,
+		`&lt;script&gt;alert(1)&lt;/script&gt;`,
+		"]]><script>alert(1)</script>\x00\x1b[2J\u202e",
+	}
+	for _, title := range titles {
+		opts := DefaultSVGOptions()
+		opts.CustomTitle = title
+		opts.BgColor = `#fff" onload="alert(1)`
+
+		svg, _, err := (&HeatmapService{}).GenerateSampleSVG(opts, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Well-formed XML with no element or attribute smuggled in by the title
+		decoder := xml.NewDecoder(bytes.NewReader(svg))
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("title %q: SVG doesn't parse: %v", title, err)
+			}
+			start, ok := token.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			switch start.Name.Local {
+			case "script", "foreignObject", "iframe":
+				t.Errorf("title %q: SVG has a <%s> element", title, start.Name.Local)
+			}
+			for _, attr := range start.Attr {
+				if strings.HasPrefix(strings.ToLower(attr.Name.Local), "on") {
+					t.Errorf("title %q: SVG has a %s attribute", title, attr.Name.Local)
+				}
+			}
+		}
+		if bytes.ContainsAny(svg, "\x00\x1b\u202e") {
+			t.Errorf("title %q: control characters reached the SVG", title)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+// Services are the services the API and the sync worker share, each constructed once.
+// main wires them; tests can build them around a fake Docker Hub, an in-memory activity
+// store and a fixed clock.
+type Services struct {
+	Docker        *DockerHubService
+	Heatmap       *HeatmapService
+	Snapshot      *SnapshotService
+	Wrapped       *WrappedService
+	Diagnostics   *DiagnosticsService
+	Export        *ExportService
+	Notifications *NotificationService
+	Digest        *DigestService
+	Sessions      *SessionService
+	DeviceAuth    *DeviceAuthService
+	PasswordAuth  *PasswordAuthService
+	EmbedTokens   *EmbedTokenService
+	Audit         *AuditService
+	RenderCache   *RenderCache
+	Standby       *StandbyStore
+	Health        *HealthService
+	AuthProviders []AuthProvider
+}
+
+// NewServices builds the services around the given Docker Hub API, activity store and clock
+func NewServices(hub DockerHubClient, activity ActivityRepository, clock Clock) *Services {
+	docker := NewDockerHubService(hub, activity, clock)
+	heatmap := NewHeatmapService(docker, clock)
+	notifications := NewNotificationService()
+	return &Services{
+		Docker:        docker,
+		Heatmap:       heatmap,
+		Snapshot:      NewSnapshotService(heatmap, docker),
+		Wrapped:       NewWrappedService(docker, clock),
+		Diagnostics:   NewDiagnosticsService(docker),
+		Export:        NewExportService(docker),
+		Notifications: notifications,
+		Digest:        NewDigestService(docker, heatmap, notifications, clock),
+		Sessions:      NewSessionService(),
+		DeviceAuth:    NewDeviceAuthService(),
+		PasswordAuth:  NewPasswordAuthService(),
+		EmbedTokens:   NewEmbedTokenService(),
+		Audit:         NewAuditService(),
+		RenderCache:   NewRenderCache(),
+		Standby:       NewStandbyStore(),
+		Health:        NewHealthService(hub, clock),
+		AuthProviders: EnabledAuthProviders(),
+	}
+}
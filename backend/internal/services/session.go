@@ -0,0 +1,183 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// refreshReuseGrace is how long after a rotation the old refresh token is merely
+// rejected rather than treated as stolen, so two tabs refreshing at once don't end
+// the session
+const refreshReuseGrace = 30 * time.Second
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// TokenPair is what a login or refresh returns: a short-lived access token and the
+// refresh token that gets the next one
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int // Seconds until the access token expires
+}
+
+type SessionService struct{}
+
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// Create starts a session for a user who just logged in and returns its first tokens.
+// name labels the session, such as a device login's client name, and may be empty.
+func (s *SessionService) Create(user *models.User, name, userAgent, ipAddress string) (*TokenPair, error) {
+	if user.Suspended() {
+		return nil, ErrUserSuspended
+	}
+
+	refreshToken, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		Name:             SanitizeText(name, 64),
+		UserAgent:        SanitizeText(userAgent, 255),
+		IPAddress:        ipAddress,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(config.AppConfig.RefreshTokenTTL),
+	}
+	if err := database.DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return s.tokens(user, session.ID, refreshToken)
+}
+
+// Refresh trades a refresh token for new tokens, rotating the refresh token. A token
+// that was already rotated out means it was copied, so the whole session is revoked.
+func (s *SessionService) Refresh(refreshToken, userAgent, ipAddress string) (*TokenPair, error) {
+	hash := hashRefreshToken(refreshToken)
+	now := time.Now()
+
+	var session models.Session
+	err := database.DB.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		err = database.DB.Where("previous_token_hash = ?", hash).First(&session).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !session.Active(now) || now.Sub(session.LastUsedAt) < refreshReuseGrace {
+			return nil, ErrRefreshTokenInvalid
+		}
+		database.DB.Model(&session).Update("revoked_at", now)
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active(now) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, session.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, err
+	}
+	if user.Suspended() {
+		return nil, ErrUserSuspended
+	}
+
+	next, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, err
+	}
+	// Conditional on the token presented, so a token raced through twice only rotates once
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  hashRefreshToken(next),
+			"previous_token_hash": hash,
+			"user_agent":          SanitizeText(userAgent, 255),
+			"ip_address":          ipAddress,
+			"last_used_at":        now,
+			"expires_at":          now.Add(config.AppConfig.RefreshTokenTTL),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRefreshTokenInvalid
+	}
+	return s.tokens(&user, session.ID, next)
+}
+
+// List returns a user's active sessions, most recently used first
+func (s *SessionService) List(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC, id DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke ends one of a user's sessions; its refresh token stops working at once and its
+// access tokens are rejected
+func (s *SessionService) Revoke(userID, sessionID uint) error {
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// IsActive reports whether an access token's session hasn't been revoked or expired
+func (s *SessionService) IsActive(userID, sessionID uint) bool {
+	var count int64
+	database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+func (s *SessionService) tokens(user *models.User, sessionID uint, refreshToken string) (*TokenPair, error) {
+	accessToken, err := utils.GenerateToken(user.ID, user.GitHubUsername, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrSigningDisabled = errors.New("activity signing is not configured")
+
+// JWK is a public key in JSON Web Key form, as published for verifiers
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+var (
+	signingKeyOnce sync.Once
+	signingKey     ed25519.PrivateKey
+	signingKeyID   string
+)
+
+// activitySigningKey returns the Ed25519 key from SIGNING_KEY and its key ID, loading it on
+// first use. A missing or unreadable key disables signing rather than failing requests
+// that don't need it.
+func activitySigningKey() (ed25519.PrivateKey, string, error) {
+	signingKeyOnce.Do(func() {
+		if config.AppConfig.SigningKey == "" {
+			return
+		}
+		key, err := ParseSigningKey(config.AppConfig.SigningKey)
+		if err != nil {
+			slog.Warn("Activity signing disabled, invalid SIGNING_KEY", "error", err)
+			return
+		}
+		signingKey = key
+		signingKeyID = publicJWK(key.Public().(ed25519.PublicKey), "").thumbprint()
+	})
+	if signingKey == nil {
+		return nil, "", ErrSigningDisabled
+	}
+	return signingKey, signingKeyID, nil
+}
+
+// ParseSigningKey reads an Ed25519 private key from a PKCS#8 PEM block (as written by
+// `openssl genpkey -algorithm ed25519`) or a base64-encoded 32-byte seed
+func ParseSigningKey(value string) (ed25519.PrivateKey, error) {
+	value = strings.TrimSpace(value)
+	if block, _ := pem.Decode([]byte(value)); block != nil {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("PEM key is not an Ed25519 key")
+		}
+		return key, nil
+	}
+
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if seed, err := enc.DecodeString(value); err == nil {
+			if len(seed) != ed25519.SeedSize {
+				return nil, fmt.Errorf("seed must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+			}
+			return ed25519.NewKeyFromSeed(seed), nil
+		}
+	}
+	return nil, errors.New("expected a PEM private key or a base64 seed")
+}
+
+func publicJWK(key ed25519.PublicKey, kid string) JWK {
+	return JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(key),
+		Kid: kid,
+		Use: "sig",
+		Alg: "EdDSA",
+	}
+}
+
+// thumbprint is the key's RFC 7638 thumbprint, used as its key ID so the ID changes
+// whenever the key does
+func (k JWK) thumbprint() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, k.Crv, k.Kty, k.X)))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// SigningKeys returns the public keys signed activity can be verified with; empty when
+// signing is disabled
+func SigningKeys() []JWK {
+	key, kid, err := activitySigningKey()
+	if err != nil {
+		return []JWK{}
+	}
+	return []JWK{publicJWK(key.Public().(ed25519.PublicKey), kid)}
+}
+
+// SignActivity wraps an activity response in a compact JWS (EdDSA). The claims carry the
+// response under "activity", the Docker username as the subject, and this deployment's
+// public URL as the issuer, so a verifier can check where the data came from and for whom.
+func SignActivity(dockerUsername string, activity interface{}) (string, error) {
+	key, kid, err := activitySigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"iss":      config.AppConfig.FrontendURL,
+		"sub":      dockerUsername,
+		"iat":      time.Now().Unix(),
+		"activity": activity,
+	})
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSnapshotDateInvalid = errors.New("snapshot date must be in the past")
+
+type SnapshotService struct {
+	heatmapService *HeatmapService
+	dockerService  *DockerHubService
+}
+
+func NewSnapshotService(heatmapService *HeatmapService, dockerService *DockerHubService) *SnapshotService {
+	return &SnapshotService{
+		heatmapService: heatmapService,
+		dockerService:  dockerService,
+	}
+}
+
+// GetOrCreateSnapshot returns the stored snapshot for (username, until, options),
+// rendering and persisting it on first request. Only completed days can be frozen.
+func (s *SnapshotService) GetOrCreateSnapshot(dockerUsername string, until time.Time, opts SVGOptions) ([]byte, error) {
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+	todayUTC := time.Now().UTC()
+	if !until.Before(time.Date(todayUTC.Year(), todayUTC.Month(), todayUTC.Day(), 0, 0, 0, 0, time.UTC)) {
+		return nil, ErrSnapshotDateInvalid
+	}
+
+	account, err := s.dockerService.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.EndDate = until
+	hash := hashSVGOptions(opts)
+
+	var snapshot models.HeatmapSnapshot
+	err = database.DB.Where(&models.HeatmapSnapshot{DockerAccountID: account.ID, Until: until, OptionsHash: hash}).First(&snapshot).Error
+	if err == nil {
+		return snapshot.SVG, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if config.IsReadOnly() {
+		return nil, ErrReadOnly
+	}
+
+	svg, err := s.heatmapService.GenerateSVGWithOptions(dockerUsername, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot = models.HeatmapSnapshot{
+		DockerAccountID: account.ID,
+		Until:           until,
+		OptionsHash:     hash,
+		SVG:             svg,
+	}
+	// A concurrent request may have stored the same snapshot first; serve whichever landed
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshot)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var stored models.HeatmapSnapshot
+		if err := database.DB.Where(&models.HeatmapSnapshot{DockerAccountID: account.ID, Until: until, OptionsHash: hash}).First(&stored).Error; err != nil {
+			return nil, err
+		}
+		return stored.SVG, nil
+	}
+
+	return svg, nil
+}
+
+// hashSVGOptions returns a stable identifier for a set of render options
+func hashSVGOptions(opts SVGOptions) string {
+	// JSON rather than %+v so pointer fields hash by value, not address
+	encoded, _ := json.Marshal(opts)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/logging"
+	"docker-heatmap/internal/models"
+)
+
+// maxConcurrentStaleSyncs bounds on-demand syncs per instance; requests past it are left
+// for the scheduled sync
+const maxConcurrentStaleSyncs = 4
+
+var staleSyncSlots = make(chan struct{}, maxConcurrentStaleSyncs)
+
+// SyncIfStale starts a background sync when the account was last synced more than
+// STALE_SYNC_MINUTES ago, so popular embeds stay fresh between scheduled syncs. The caller
+// keeps serving what it already has.
+func (s *DockerHubService) SyncIfStale(dockerUsername string) {
+	threshold := time.Duration(config.AppConfig.StaleSyncMinutes) * time.Minute
+	if threshold <= 0 || config.IsReadOnly() {
+		return
+	}
+
+	account, err := s.GetDockerAccountByUsername(dockerUsername)
+	if err != nil || !account.IsActive || !account.AutoRefresh || account.SyncInProgress ||
+		account.SyncDeadLetteredAt != nil || account.DisabledAt != nil {
+		return
+	}
+	if account.LastSyncAt != nil && time.Since(*account.LastSyncAt) < threshold {
+		return
+	}
+
+	select {
+	case staleSyncSlots <- struct{}{}:
+	default:
+		return
+	}
+
+	// Claim the account so concurrent requests and other instances don't sync it too
+	result := database.DB.Model(&models.DockerAccount{}).
+		Where("id = ? AND sync_in_progress = ?", account.ID, false).
+		Update("sync_in_progress", true)
+	if result.Error != nil || result.RowsAffected == 0 {
+		<-staleSyncSlots
+		return
+	}
+
+	go func() {
+		defer func() { <-staleSyncSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if err := s.SyncActivity(ctx, account.ID, models.SyncTriggerStale); err != nil {
+			logging.FromContext(ctx).Warn("On-demand sync failed", "error", err)
+		}
+	}()
+}
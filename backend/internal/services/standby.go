@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/storage"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// standbyTimeout bounds standby reads and writes, which happen off the normal render path
+const standbyTimeout = 10 * time.Second
+
+// maxStandbyTracked caps how many uploaded body hashes are remembered before the set is reset
+const maxStandbyTracked = 10000
+
+// StandbyStore keeps a copy of every successful render in S3-compatible storage, keyed by
+// username and the request's options, so public embeds can still be served while the
+// database is unavailable. All methods are no-ops when standby storage is not configured.
+type StandbyStore struct{}
+
+func NewStandbyStore() *StandbyStore {
+	return &StandbyStore{}
+}
+
+// standbyUploaded remembers the last body written per key, so unchanged renders aren't re-uploaded
+var standbyUploaded = struct {
+	sync.Mutex
+	hashes map[string]string
+}{hashes: make(map[string]string)}
+
+func standbyObjectKey(username, variant string) string {
+	sum := sha256.Sum256([]byte(variant))
+	return "renders/" + strings.ToLower(username) + "/" + hex.EncodeToString(sum[:16])
+}
+
+// Put copies a rendered body to standby storage in the background
+func (ss *StandbyStore) Put(username, variant, contentType string, body []byte) {
+	if !storage.Enabled() {
+		return
+	}
+
+	key := standbyObjectKey(username, variant)
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	standbyUploaded.Lock()
+	if standbyUploaded.hashes[key] == hash {
+		standbyUploaded.Unlock()
+		return
+	}
+	if len(standbyUploaded.hashes) >= maxStandbyTracked {
+		standbyUploaded.hashes = make(map[string]string)
+	}
+	standbyUploaded.hashes[key] = hash
+	standbyUploaded.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), standbyTimeout)
+		defer cancel()
+
+		_, err := storage.Client.PutObject(ctx, storage.Bucket, key, bytes.NewReader(body), int64(len(body)),
+			minio.PutObjectOptions{ContentType: contentType})
+		if err != nil {
+			slog.Warn("Standby upload failed", "docker_username", username, "error", err)
+			standbyUploaded.Lock()
+			delete(standbyUploaded.hashes, key)
+			standbyUploaded.Unlock()
+		}
+	}()
+}
+
+// Get returns the last rendered body stored for the account and options
+func (ss *StandbyStore) Get(username, variant string) ([]byte, bool) {
+	if !storage.Enabled() {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), standbyTimeout)
+	defer cancel()
+
+	object, err := storage.Client.GetObject(ctx, storage.Bucket, standbyObjectKey(username, variant), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, false
+	}
+	defer object.Close()
+
+	body, err := io.ReadAll(object)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code != "NoSuchKey" {
+			slog.Warn("Standby read failed", "docker_username", username, "error", err)
+		}
+		return nil, false
+	}
+	return body, true
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserSuspended is returned for sign-ins, ingests and syncs of a user an admin suspended
+	ErrUserSuspended             = errors.New("account suspended")
+	ErrUserNotSuspended          = errors.New("user isn't suspended")
+	ErrDockerUsernameBlocked     = errors.New("this Docker username can't be connected")
+	ErrDockerUsernameNotBlocked  = errors.New("docker username isn't blocked")
+	ErrDockerUsernameBlockExists = errors.New("docker username is already blocked")
+)
+
+// SuspendUser stops the user signing in, serving their heatmaps and having their accounts
+// synced, until the suspension is lifted. Their sessions are revoked and pending sync jobs
+// failed; their data is kept.
+func (s *DockerHubService) SuspendUser(userID uint, reason string) (*models.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"suspended_at":     now,
+			"suspended_reason": SanitizeText(reason, 255),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SyncJob{}).
+			Where("status = ? AND docker_account_id IN (?)", models.SyncJobPending,
+				tx.Model(&models.DockerAccount{}).Select("id").Where("user_id = ?", user.ID)).
+			Updates(map[string]interface{}{"status": models.SyncJobFailed, "last_error": ErrUserSuspended.Error()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateUserRenders(user.ID)
+	return &user, nil
+}
+
+// UnsuspendUser lifts a user's suspension. Their accounts are synced again on schedule.
+func (s *DockerHubService) UnsuspendUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if !user.Suspended() {
+		return nil, ErrUserNotSuspended
+	}
+
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{
+		"suspended_at":     nil,
+		"suspended_reason": "",
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	s.invalidateUserRenders(user.ID)
+	return &user, nil
+}
+
+// invalidateUserRenders drops the cached renders of every account of the user
+func (s *DockerHubService) invalidateUserRenders(userID uint) {
+	var usernames []string
+	database.DB.Model(&models.DockerAccount{}).Where("user_id = ?", userID).Pluck("docker_username", &usernames)
+	for _, username := range usernames {
+		s.renderCache.Invalidate(username)
+	}
+}
+
+// ListBlockedDockerUsernames returns a page of blocked Docker usernames, most recently
+// blocked first. before is the id of the last entry of the previous page, or 0 for the
+// first page.
+func ListBlockedDockerUsernames(before uint, limit int) ([]models.BlockedDockerUsername, error) {
+	query := database.DB.Model(&models.BlockedDockerUsername{})
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	blocked := []models.BlockedDockerUsername{}
+	err := query.Order("id DESC").Limit(limit).Find(&blocked).Error
+	return blocked, err
+}
+
+// BlockDockerUsername stops the Docker username being connected by anyone. An account
+// already connected with it stays connected; disable the account or suspend its owner to
+// take it down.
+func BlockDockerUsername(dockerUsername, reason string, adminID uint) (*models.BlockedDockerUsername, error) {
+	blocked := &models.BlockedDockerUsername{
+		DockerUsername:  strings.ToLower(dockerUsername),
+		Reason:          SanitizeText(reason, 255),
+		CreatedByUserID: &adminID,
+	}
+	if DockerUsernameBlocked(blocked.DockerUsername) {
+		return nil, ErrDockerUsernameBlockExists
+	}
+	if err := database.DB.Create(blocked).Error; err != nil {
+		return nil, err
+	}
+	return blocked, nil
+}
+
+// UnblockDockerUsername lets the Docker username be connected again
+func UnblockDockerUsername(dockerUsername string) error {
+	result := database.DB.Where("docker_username = ?", strings.ToLower(dockerUsername)).
+		Delete(&models.BlockedDockerUsername{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDockerUsernameNotBlocked
+	}
+	return nil
+}
+
+// DockerUsernameBlocked reports whether an admin blocked the Docker username
+func DockerUsernameBlocked(dockerUsername string) bool {
+	var count int64
+	database.DB.Model(&models.BlockedDockerUsername{}).
+		Where("docker_username = ?", strings.ToLower(dockerUsername)).Count(&count)
+	return count > 0
+}
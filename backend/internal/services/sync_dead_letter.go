@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/notify"
+)
+
+var (
+	// ErrSyncDeadLettered wraps the error of a failed sync of a dead-lettered account
+	ErrSyncDeadLettered    = errors.New("too many failed syncs in a row, automatic syncs stopped")
+	ErrSyncNotDeadLettered = errors.New("account syncs aren't dead-lettered")
+)
+
+// DeadLetteredSync is an account whose syncs were given up on, as admins see it
+type DeadLetteredSync struct {
+	DockerAccountID uint      `json:"docker_account_id"`
+	DockerUsername  string    `json:"docker_username"`
+	UserID          uint      `json:"user_id"`
+	Failures        int       `json:"failures"`
+	LastError       string    `json:"last_error,omitempty"`
+	DeadLetteredAt  time.Time `json:"dead_lettered_at"`
+}
+
+// countSyncResult keeps count of the account's failed syncs in a row after a sync ends with
+// err, and reports whether this failure dead-letters the account. A sync cut short by a
+// shutdown isn't the account's fault and doesn't count.
+func countSyncResult(account *models.DockerAccount, now time.Time, err error) bool {
+	switch {
+	case err == nil:
+		account.SyncFailures = 0
+		account.SyncDeadLetteredAt = nil
+	case errors.Is(err, context.Canceled):
+	default:
+		account.SyncFailures++
+		if account.SyncDeadLetteredAt == nil && account.SyncFailures >= config.AppConfig.SyncDeadLetterAfter {
+			account.SyncDeadLetteredAt = &now
+			return true
+		}
+	}
+	return false
+}
+
+// notifySyncDeadLettered tells the owner their account stopped being synced, through their
+// channels subscribed to sync failures, or by email when they have none
+func (s *DockerHubService) notifySyncDeadLettered(account *models.DockerAccount) {
+	msg := notify.Message{
+		Event: notify.EventSyncFailed,
+		Title: "Docker Hub syncs stopped for " + account.DockerUsername,
+		Body: fmt.Sprintf("The last %d syncs failed (%s), so your heatmap is no longer updated automatically. "+
+			"Check your access token, then sync from the dashboard to start again.", account.SyncFailures, account.LastSyncError),
+		URL: config.AppConfig.FrontendURL + "/dashboard",
+	}
+
+	queued, err := s.notifications.Notify(account.UserID, msg)
+	if err != nil {
+		slog.Error("Failed to notify user of dead-lettered syncs", "user_id", account.UserID, "error", err)
+	}
+	if queued > 0 || config.AppConfig.SMTPHost == "" {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, account.UserID).Error; err != nil || user.GitHubEmail == "" || !user.EmailVerified {
+		return
+	}
+	ch, err := notify.Get("email")
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ch.Send(ctx, map[string]string{"to": user.GitHubEmail}, msg); err != nil {
+		slog.Error("Failed to email user about dead-lettered syncs", "user_id", account.UserID, "error", err)
+	}
+}
+
+// ListDeadLetteredSyncs returns the accounts that stopped being synced automatically,
+// most recently dead-lettered first
+func ListDeadLetteredSyncs() ([]DeadLetteredSync, error) {
+	var accounts []models.DockerAccount
+	if err := database.DB.Where("sync_dead_lettered_at IS NOT NULL").
+		Order("sync_dead_lettered_at DESC").
+		Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]DeadLetteredSync, len(accounts))
+	for i, account := range accounts {
+		entries[i] = DeadLetteredSync{
+			DockerAccountID: account.ID,
+			DockerUsername:  account.DockerUsername,
+			UserID:          account.UserID,
+			Failures:        account.SyncFailures,
+			LastError:       account.LastSyncError,
+			DeadLetteredAt:  *account.SyncDeadLetteredAt,
+		}
+	}
+	return entries, nil
+}
+
+// RequeueDeadLetteredSync takes the account out of the dead-letter state, with a fresh count
+// of failures, and queues a sync of it now
+func (s *DockerHubService) RequeueDeadLetteredSync(accountID uint) error {
+	var account models.DockerAccount
+	if err := database.DB.First(&account, accountID).Error; err != nil {
+		return err
+	}
+	if account.SyncDeadLetteredAt == nil {
+		return ErrSyncNotDeadLettered
+	}
+
+	if err := database.DB.Model(&account).Updates(map[string]interface{}{
+		"sync_failures":         0,
+		"sync_dead_lettered_at": nil,
+	}).Error; err != nil {
+		return err
+	}
+	return EnqueueSync(account.ID, models.SyncTriggerRequeue, s.clock.Now())
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/logging"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/reporting"
+
+	"gorm.io/gorm"
+)
+
+// Sync job retry policy: exponential backoff from the base delay, up to the max attempts
+const (
+	MaxSyncAttempts = 5
+	syncBaseDelay   = time.Minute
+	syncMaxDelay    = time.Hour
+	syncJobTimeout  = 5 * time.Minute
+
+	// syncJobStaleAfter is how long a job may stay running before it's taken to belong to an
+	// instance that died, and is run again
+	syncJobStaleAfter = 3 * syncJobTimeout
+
+	// SyncJobRetentionDays is how long finished jobs are kept for troubleshooting
+	SyncJobRetentionDays = 30
+)
+
+// syncJobWake nudges this instance's worker when a job is queued, so a manual sync doesn't
+// wait for the next poll
+var syncJobWake = make(chan struct{}, 1)
+
+// SyncJobQueued is signalled when this instance queues a sync job
+func SyncJobQueued() <-chan struct{} {
+	return syncJobWake
+}
+
+// EnqueueSync queues a sync of the account to run at runAt. An account has at most one
+// pending job: queueing another moves that job's run time earlier instead.
+func EnqueueSync(accountID uint, triggeredBy string, runAt time.Time) error {
+	var existing models.SyncJob
+	err := database.DB.Where("docker_account_id = ? AND status IN ?", accountID,
+		[]string{models.SyncJobPending, models.SyncJobRunning}).
+		Order("id DESC").First(&existing).Error
+	switch {
+	case err == nil && existing.Status == models.SyncJobRunning:
+		// Whatever prompted this will be picked up by the sync already under way
+		return nil
+	case err == nil:
+		if runAt.Before(existing.NextRunAt) {
+			err = database.DB.Model(&existing).Update("next_run_at", runAt).Error
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = database.DB.Create(&models.SyncJob{
+			DockerAccountID: accountID,
+			TriggeredBy:     triggeredBy,
+			Status:          models.SyncJobPending,
+			NextRunAt:       runAt,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if !runAt.After(time.Now()) {
+		ReportSyncQueued(accountID)
+		select {
+		case syncJobWake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// InSyncShard limits query to the accounts this instance syncs (SYNC_SHARD of
+// SYNC_SHARD_COUNT). column holds the account id.
+func InSyncShard(query *gorm.DB, column string) *gorm.DB {
+	if config.AppConfig.SyncShardCount <= 1 {
+		return query
+	}
+	return query.Where("MOD("+column+", ?) = ?", config.AppConfig.SyncShardCount, config.AppConfig.SyncShard)
+}
+
+// ProcessSyncJobs runs due sync jobs of this instance's shard one after another until none
+// are due or ctx is done. Several workers, here or on other instances, can share the queue:
+// each job is claimed before it runs.
+func (s *DockerHubService) ProcessSyncJobs(ctx context.Context) {
+	if config.IsReadOnly() {
+		return
+	}
+
+	s.requeueStaleSyncJobs()
+	for ctx.Err() == nil {
+		job, err := s.claimSyncJob()
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				slog.Error("Failed to claim a sync job", "error", err)
+			}
+			return
+		}
+		s.runSyncJob(ctx, job)
+	}
+}
+
+// claimSyncJob marks the next due job of this instance's shard as running and returns it
+func (s *DockerHubService) claimSyncJob() (*models.SyncJob, error) {
+	for {
+		var job models.SyncJob
+		err := InSyncShard(database.DB, "docker_account_id").
+			Where("status = ? AND next_run_at <= ?", models.SyncJobPending, s.clock.Now()).
+			Order("next_run_at").
+			First(&job).Error
+		if err != nil {
+			return nil, err
+		}
+
+		now := s.clock.Now()
+		result := database.DB.Model(&models.SyncJob{}).
+			Where("id = ? AND status = ?", job.ID, models.SyncJobPending).
+			Updates(map[string]interface{}{"status": models.SyncJobRunning, "started_at": now})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.SyncJobRunning
+			job.StartedAt = &now
+			return &job, nil
+		}
+		// Another instance claimed it first; try the next one
+	}
+}
+
+// runSyncJob syncs the job's account and records the outcome, rescheduling failures
+func (s *DockerHubService) runSyncJob(ctx context.Context, job *models.SyncJob) {
+	ctx = logging.With(ctx, "sync_job_id", job.ID, "docker_account_id", job.DockerAccountID)
+	logger := logging.FromContext(ctx)
+
+	syncCtx, cancel := context.WithTimeout(ctx, syncJobTimeout)
+	err := s.SyncActivity(syncCtx, job.DockerAccountID, job.TriggeredBy)
+	cancel()
+
+	job.Attempts++
+	switch {
+	case err == nil:
+		job.Status = models.SyncJobSucceeded
+		job.LastError = ""
+	case errors.Is(err, ErrReadOnly) || ctx.Err() != nil:
+		// Not the job's fault: run it again once writes are back or after the restart,
+		// without using an attempt
+		job.Attempts--
+		job.Status = models.SyncJobPending
+		job.NextRunAt = s.clock.Now().Add(syncBaseDelay)
+	case errors.Is(err, ErrSyncDeadLettered):
+		// Not retried until the owner syncs or an admin requeues the account
+		job.Status = models.SyncJobDeadLettered
+		job.LastError = err.Error()
+	case errors.Is(err, ErrInvalidDockerToken) || errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, ErrAccountDisabled) || errors.Is(err, ErrUserSuspended) ||
+		errors.Is(err, ErrAccountDeletionScheduled) || job.Attempts >= MaxSyncAttempts:
+		// Retrying won't help a rejected token, a removed or disabled account or a suspended or
+		// departing owner
+		job.Status = models.SyncJobFailed
+		job.LastError = err.Error()
+	default:
+		job.Status = models.SyncJobPending
+		job.LastError = err.Error()
+		job.NextRunAt = s.clock.Now().Add(syncBackoff(job.Attempts))
+	}
+	if err != nil {
+		logger.Warn("Sync job failed", "attempt", job.Attempts, "status", job.Status, "error", err)
+	}
+	if (job.Status == models.SyncJobFailed || job.Status == models.SyncJobDeadLettered) &&
+		!errors.Is(err, ErrAccountDisabled) && !errors.Is(err, ErrUserSuspended) &&
+		!errors.Is(err, ErrAccountDeletionScheduled) {
+		// Given up on: worth someone looking at, unlike a failure that's retried
+		var userID uint
+		database.DB.Model(&models.DockerAccount{}).Where("id = ?", job.DockerAccountID).
+			Select("user_id").Scan(&userID)
+		reporting.Capture(reporting.Event{
+			Err:    err,
+			Level:  reporting.LevelWarning,
+			UserID: userID,
+			Tags: map[string]string{
+				"docker_account_id": fmt.Sprint(job.DockerAccountID),
+				"sync_status":       job.Status,
+				"sync_trigger":      job.TriggeredBy,
+			},
+			Extra: map[string]any{"sync_job_id": job.ID, "attempts": job.Attempts},
+		})
+	}
+	if err := database.DB.Save(job).Error; err != nil {
+		logger.Error("Failed to record sync job", "error", err)
+	}
+}
+
+// requeueStaleSyncJobs puts back jobs left running by an instance that stopped mid-sync
+func (s *DockerHubService) requeueStaleSyncJobs() {
+	var stale []models.SyncJob
+	err := database.DB.Where("status = ? AND started_at < ?", models.SyncJobRunning, s.clock.Now().Add(-syncJobStaleAfter)).
+		Find(&stale).Error
+	if err != nil {
+		slog.Error("Failed to load stale sync jobs", "error", err)
+		return
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	jobIDs := make([]uint, len(stale))
+	accountIDs := make([]uint, len(stale))
+	for i, job := range stale {
+		jobIDs[i] = job.ID
+		accountIDs[i] = job.DockerAccountID
+	}
+	database.DB.Model(&models.SyncJob{}).
+		Where("id IN ? AND status = ?", jobIDs, models.SyncJobRunning).
+		Updates(map[string]interface{}{"status": models.SyncJobPending, "next_run_at": s.clock.Now()})
+	// Their accounts are still marked as syncing by the sync that never finished
+	database.DB.Model(&models.DockerAccount{}).Where("id IN ?", accountIDs).Update("sync_in_progress", false)
+	slog.Info("Requeued sync jobs left running", "count", len(stale))
+}
+
+// syncBackoff returns the wait before the next attempt: 1m, 2m, 4m... capped at 1h
+func syncBackoff(attempts int) time.Duration {
+	delay := syncBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > syncMaxDelay {
+		return syncMaxDelay
+	}
+	return delay
+}
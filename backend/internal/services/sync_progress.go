@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/cache"
+)
+
+// Sync progress stages, in the order a sync goes through them
+const (
+	SyncStageQueued   = "queued"   // Accepted, waiting to start
+	SyncStageFetching = "fetching" // Listing the account's repositories
+	SyncStageSyncing  = "syncing"  // Recording repositories; Done of Total finished
+	SyncStageDone     = "done"
+	SyncStageFailed   = "failed"
+)
+
+// SyncProgress is one step of an account's sync
+type SyncProgress struct {
+	Stage string `json:"stage"`
+	// Repository is the one just finished while syncing, named as in activity
+	Repository string    `json:"repository,omitempty"`
+	Done       int       `json:"done"`
+	Total      int       `json:"total"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Finished reports whether the sync is over
+func (p SyncProgress) Finished() bool {
+	return p.Stage == SyncStageDone || p.Stage == SyncStageFailed
+}
+
+// syncProgressChannel is the Redis channel progress is relayed on, so a client streaming
+// from one instance sees syncs that run on another
+const syncProgressChannel = "sync:progress"
+
+// syncProgressStale is how long a step is replayed to new streams. It outlasts the sync
+// timeout, so a sync whose instance died without finishing stops showing as running.
+const syncProgressStale = 10 * time.Minute
+
+// syncProgressMessage is a progress step relayed between instances
+type syncProgressMessage struct {
+	Instance  string       `json:"instance"`
+	AccountID uint         `json:"account_id"`
+	Progress  SyncProgress `json:"progress"`
+}
+
+// syncProgressHub fans progress out to the streams open on this instance and remembers
+// each running sync's latest step for streams that open partway through
+type syncProgressHub struct {
+	mu       sync.Mutex
+	subs     map[uint]map[chan SyncProgress]struct{}
+	latest   map[uint]SyncProgress
+	instance string
+	relay    sync.Once
+}
+
+var syncProgress = newSyncProgressHub()
+
+func newSyncProgressHub() *syncProgressHub {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &syncProgressHub{
+		subs:     make(map[uint]map[chan SyncProgress]struct{}),
+		latest:   make(map[uint]SyncProgress),
+		instance: hex.EncodeToString(id),
+	}
+}
+
+// SubscribeSyncProgress streams an account's sync progress, starting with the latest
+// step of a sync already running. Call cancel once done reading.
+func SubscribeSyncProgress(accountID uint) (<-chan SyncProgress, func()) {
+	h := syncProgress
+	if cache.Enabled() {
+		h.relay.Do(func() { go h.listen() })
+	}
+
+	ch := make(chan SyncProgress, 16)
+	h.mu.Lock()
+	if h.subs[accountID] == nil {
+		h.subs[accountID] = make(map[chan SyncProgress]struct{})
+	}
+	h.subs[accountID][ch] = struct{}{}
+	if latest, ok := h.latest[accountID]; ok && time.Since(latest.At) < syncProgressStale {
+		ch <- latest
+	}
+	h.mu.Unlock()
+
+	cancel := func() {
+		h.mu.Lock()
+		delete(h.subs[accountID], ch)
+		if len(h.subs[accountID]) == 0 {
+			delete(h.subs, accountID)
+		}
+		h.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// ReportSyncQueued announces a sync that was accepted but hasn't started yet
+func ReportSyncQueued(accountID uint) {
+	publishSyncProgress(accountID, SyncProgress{Stage: SyncStageQueued})
+}
+
+// publishSyncProgress delivers a step to this instance's streams and relays it to the others
+func publishSyncProgress(accountID uint, p SyncProgress) {
+	p.At = time.Now().UTC()
+	syncProgress.deliver(accountID, p)
+
+	if !cache.Enabled() {
+		return
+	}
+	payload, err := json.Marshal(syncProgressMessage{Instance: syncProgress.instance, AccountID: accountID, Progress: p})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), renderCacheTimeout)
+	defer cancel()
+	if err := cache.Client.Publish(ctx, syncProgressChannel, payload).Err(); err != nil {
+		slog.Warn("Failed to relay sync progress", "docker_account_id", accountID, "error", err)
+	}
+}
+
+func (h *syncProgressHub) deliver(accountID uint, p SyncProgress) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if p.Finished() {
+		delete(h.latest, accountID)
+	} else {
+		h.latest[accountID] = p
+	}
+
+	for ch := range h.subs[accountID] {
+		select {
+		case ch <- p:
+		default:
+			// A slow reader only needs the latest step, so drop its oldest
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- p:
+			default:
+			}
+		}
+	}
+}
+
+// listen delivers progress relayed by other instances for as long as the process runs
+func (h *syncProgressHub) listen() {
+	pubsub := cache.Client.Subscribe(context.Background(), syncProgressChannel)
+	for msg := range pubsub.Channel() {
+		var relayed syncProgressMessage
+		if err := json.Unmarshal([]byte(msg.Payload), &relayed); err != nil || relayed.Instance == h.instance {
+			continue
+		}
+		h.deliver(relayed.AccountID, relayed.Progress)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+const (
+	// SyncRunRetentionDays is how long sync history is kept before cleanup
+	SyncRunRetentionDays = 90
+
+	DefaultSyncRunPageSize = 20
+	MaxSyncRunPageSize     = 100
+)
+
+// countingDockerHub counts the Docker Hub requests one sync makes
+type countingDockerHub struct {
+	DockerHubClient
+	calls int
+}
+
+func (h *countingDockerHub) Login(ctx context.Context, username, pat string) (string, error) {
+	h.calls++
+	return h.DockerHubClient.Login(ctx, username, pat)
+}
+
+func (h *countingDockerHub) FetchRepositories(ctx context.Context, username, token string) ([]DockerHubRepository, error) {
+	h.calls++
+	return h.DockerHubClient.FetchRepositories(ctx, username, token)
+}
+
+func (h *countingDockerHub) FetchTags(ctx context.Context, username, repoName, token string) ([]DockerHubTag, error) {
+	h.calls++
+	return h.DockerHubClient.FetchTags(ctx, username, repoName, token)
+}
+
+// recordSyncRun saves a finished run. A failure is logged rather than returned, so it
+// never fails the sync being recorded.
+func recordSyncRun(run *models.SyncRun, finishedAt time.Time, err error) {
+	run.FinishedAt = finishedAt
+	run.DurationMS = finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Status = models.SyncRunSucceeded
+	if err != nil {
+		run.Status = models.SyncRunFailed
+		run.Error = SanitizeText(err.Error(), 500)
+	}
+	if err := database.DB.Create(run).Error; err != nil {
+		slog.Error("Failed to record sync run", "docker_account_id", run.DockerAccountID, "error", err)
+	}
+}
+
+// ListSyncRuns returns a page of the account's sync history, newest first. before is the id
+// of the last run of the previous page, or 0 for the first page.
+func (s *DockerHubService) ListSyncRuns(accountID, before uint, limit int) ([]models.SyncRun, error) {
+	query := database.DB.Where("docker_account_id = ?", accountID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	runs := []models.SyncRun{}
+	err := query.Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,157 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+	"docker-heatmap/internal/utils"
+)
+
+// memoryActivity is an ActivityRepository that keeps what a sync records in memory
+type memoryActivity struct {
+	mu       sync.Mutex
+	repos    map[uint][]string
+	events   map[string]int
+	rebuilds int
+}
+
+func newMemoryActivity() *memoryActivity {
+	return &memoryActivity{repos: make(map[uint][]string), events: make(map[string]int)}
+}
+
+func (m *memoryActivity) StoreRepositories(accountID uint, prefix string, repos []services.DockerHubRepository) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, repo := range repos {
+		m.repos[accountID] = append(m.repos[accountID], prefix+repo.Name)
+	}
+	return nil
+}
+
+func (m *memoryActivity) UpsertActivity(accountID uint, eventType models.EventType, source models.EventSource, eventDate time.Time, repo, tag string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := strings.Join([]string{eventDate.UTC().Format(time.DateOnly), string(source), repo, tag}, " ")
+	m.events[key]++
+	return m.events[key] == 1, nil
+}
+
+func (m *memoryActivity) RebuildDailyAggregates(accountID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rebuilds++
+	return nil
+}
+
+func (m *memoryActivity) eventKeys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.events))
+	for key := range m.events {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// connectAccount adds an account whose stored access token is pat
+func connectAccount(t *testing.T, dockerUsername, pat string) *models.DockerAccount {
+	t.Helper()
+
+	account := testutil.CreateAccount(t, dockerUsername, true)
+	encrypted, iv, err := utils.Encrypt(pat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.EncryptedToken, account.TokenIV = encrypted, iv
+	if err := database.DB.Save(account).Error; err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func TestSyncActivityRecordsDockerHubActivity(t *testing.T) {
+	testutil.OpenDB(t)
+	hub := testutil.NewDockerHub(t)
+	hub.AddUser("alice", "alice-pat",
+		testutil.DockerHubRepo{
+			Name:        "api",
+			LastUpdated: "2026-10-14T09:30:00.123456Z",
+			Tags: []testutil.DockerHubTag{
+				{Name: "latest", LastPushed: "2026-10-14T09:30:00.123456Z"},
+				{Name: "1.0", LastPushed: "2026-10-02T17:05:00Z"},
+			},
+		},
+		testutil.DockerHubRepo{Name: "web", LastUpdated: "2026-10-15T08:00:00Z"},
+	)
+	account := connectAccount(t, "alice", "alice-pat")
+
+	activity := newMemoryActivity()
+	svc := services.NewDockerHubService(services.NewDockerHubAPI(hub.URL, hub.Client()), activity, services.SystemClock)
+	if err := svc.SyncActivity(context.Background(), account.ID, models.SyncTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"2026-10-02 tag_last_pushed api 1.0",
+		"2026-10-14 repo_last_updated api ",
+		"2026-10-14 tag_last_pushed api latest",
+		"2026-10-15 repo_last_updated web ",
+	}
+	if got := activity.eventKeys(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events %q, want %q", got, want)
+	}
+	if got := activity.repos[account.ID]; strings.Join(got, ",") != "api,web" {
+		t.Errorf("repositories %q, want api,web", got)
+	}
+	if activity.rebuilds != 1 {
+		t.Errorf("aggregates rebuilt %d times, want 1", activity.rebuilds)
+	}
+
+	var run models.SyncRun
+	if err := database.DB.Where("docker_account_id = ?", account.ID).First(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != models.SyncRunSucceeded || run.EventsCreated != len(want) || run.Repositories != 2 {
+		t.Errorf("sync run %s with %d events over %d repositories, want succeeded with %d over 2",
+			run.Status, run.EventsCreated, run.Repositories, len(want))
+	}
+	// A login, the repository listing and each repository's tags
+	if run.APICalls != 4 {
+		t.Errorf("%d API calls, want 4", run.APICalls)
+	}
+}
+
+func TestSyncActivityRejectedToken(t *testing.T) {
+	testutil.OpenDB(t)
+	hub := testutil.NewDockerHub(t)
+	hub.AddUser("alice", "new-pat", testutil.DockerHubRepo{Name: "api", LastUpdated: "2026-10-14T09:30:00Z"})
+	account := connectAccount(t, "alice", "revoked-pat")
+
+	activity := newMemoryActivity()
+	svc := services.NewDockerHubService(services.NewDockerHubAPI(hub.URL, hub.Client()), activity, services.SystemClock)
+	err := svc.SyncActivity(context.Background(), account.ID, models.SyncTriggerManual)
+	if !errors.Is(err, services.ErrInvalidDockerToken) {
+		t.Fatalf("error %v, want %v", err, services.ErrInvalidDockerToken)
+	}
+	if keys := activity.eventKeys(); len(keys) != 0 {
+		t.Errorf("recorded %q after a failed login", keys)
+	}
+
+	var stored models.DockerAccount
+	if err := database.DB.First(&stored, account.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.LastSyncError != "Authentication failed" || stored.SyncInProgress {
+		t.Errorf("account left with error %q, in progress %v", stored.LastSyncError, stored.SyncInProgress)
+	}
+}
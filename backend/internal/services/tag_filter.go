@@ -0,0 +1,43 @@
+package services
+
+import (
+	"path"
+	"strings"
+)
+
+// MaxTagPatterns caps how many tag patterns a single request can filter by
+const MaxTagPatterns = 10
+
+// ParseTagPatterns parses a comma-separated list of tag names or glob patterns
+// (e.g. "latest,v1.*,nightly-*"). Malformed patterns are dropped.
+func ParseTagPatterns(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			continue
+		}
+		patterns = append(patterns, p)
+		if len(patterns) == MaxTagPatterns {
+			break
+		}
+	}
+	return patterns
+}
+
+// matchesTag reports whether tag matches any of the patterns. Events without a tag
+// (repository-level updates) never match.
+func matchesTag(tag string, patterns []string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, tag); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,521 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// MaxTeamMembers caps how many accounts share one team heatmap, so the member colors
+// stay distinguishable in a single cell
+const MaxTeamMembers = 5
+
+// Team render modes
+const (
+	TeamModeSplit = "split" // Each cell is divided into one stripe per contributing member
+	TeamModeMix   = "mix"   // Each cell is the member colors blended by contribution
+)
+
+// TeamPalette is the default color of each member, in the order members are listed
+var TeamPalette = []string{"#2f81f7", "#3fb950", "#f0883e", "#db61a2", "#a371f7"}
+
+var (
+	ErrTeamMembersRequired = errors.New("at least one team member is required")
+	ErrTooManyTeamMembers  = fmt.Errorf("a team heatmap can include at most %d members", MaxTeamMembers)
+)
+
+// teamLevelIntensity is how far each level's cells move from the empty color toward the
+// member color, so busier days still read as brighter
+var teamLevelIntensity = []float64{0, 0.45, 0.65, 0.85, 1}
+
+// TeamDay is one day of a team's activity. Counts holds each member's activity in the
+// order the members were requested; Level is computed from the team's weighted total.
+type TeamDay struct {
+	Date   string
+	Counts []int
+	Total  int
+	Level  int
+}
+
+// ParseTeamMembers splits a comma-separated member list, dropping blanks and duplicates
+func ParseTeamMembers(value string) ([]string, error) {
+	var members []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		members = append(members, name)
+	}
+
+	if len(members) == 0 {
+		return nil, ErrTeamMembersRequired
+	}
+	if len(members) > MaxTeamMembers {
+		return nil, ErrTooManyTeamMembers
+	}
+	return members, nil
+}
+
+// GetTeamActivityRange aggregates activity per day and member between startDate and endDate
+// (inclusive), reading every member's events in one query. It fails with
+// ErrDockerAccountNotFound if any member isn't a live account.
+func (s *DockerHubService) GetTeamActivityRange(usernames []string, startDate, endDate time.Time, filter ActivityFilter) ([]TeamDay, error) {
+	var accounts []models.DockerAccount
+	err := database.DB.Where("docker_username IN ? AND disconnect_scheduled_at IS NULL", usernames).Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	member := make(map[uint]int, len(accounts))
+	accountIDs := make([]uint, 0, len(accounts))
+	for i, name := range usernames {
+		found := false
+		for _, account := range accounts {
+			if account.DockerUsername == name {
+				member[account.ID] = i
+				accountIDs = append(accountIDs, account.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrDockerAccountNotFound
+		}
+	}
+
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	startDate = startDate.In(loc)
+	endDate = endDate.In(loc)
+	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+
+	var events []models.ActivityEvent
+	if usesDailyAggregates(filter, loc) {
+		events, err = loadDailyAggregates(accountIDs, startDate, endDate, filter)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// event_date is the UTC day, so widen the scan by a day on each side to cover any offset
+		query := database.DB.Where("docker_account_id IN ? AND event_date >= ? AND event_date <= ?",
+			accountIDs, startDate.AddDate(0, 0, -1).UTC(), endDate.AddDate(0, 0, 1).UTC())
+		if len(filter.EventTypes) > 0 {
+			query = query.Where("event_type IN ?", filter.EventTypes)
+		}
+		if err := query.Find(&events).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return summarizeTeamEvents(events, member, len(usernames), startDate, endDate, filter), nil
+}
+
+// summarizeTeamEvents buckets events into one TeamDay per day from startDate to endDate,
+// which must be midnights in the filter's location
+func summarizeTeamEvents(events []models.ActivityEvent, member map[uint]int, members int, startDate, endDate time.Time, filter ActivityFilter) []TeamDay {
+	loc := startDate.Location()
+	startKey := startDate.Format("2006-01-02")
+	endKey := endDate.Format("2006-01-02")
+
+	dateMap := make(map[string]*TeamDay)
+	scores := make(map[string]float64)
+	maxScore := 0.0
+
+	for _, event := range events {
+		if !filter.includes(&event) {
+			continue
+		}
+		dateStr := event.LocalDate(loc)
+		if dateStr < startKey || dateStr > endKey {
+			continue
+		}
+		day, ok := dateMap[dateStr]
+		if !ok {
+			day = &TeamDay{Date: dateStr, Counts: make([]int, members)}
+			dateMap[dateStr] = day
+		}
+		day.Counts[member[event.DockerAccountID]] += event.Count
+		day.Total += event.Count
+
+		scores[dateStr] += float64(event.Count) * filter.weight(event.EventType)
+		if scores[dateStr] > maxScore {
+			maxScore = scores[dateStr]
+		}
+	}
+
+	days := make([]TeamDay, 0, int(endDate.Sub(startDate).Hours()/24)+1)
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		dateStr := d.Format("2006-01-02")
+		day := TeamDay{Date: dateStr, Counts: make([]int, members)}
+		if existing, ok := dateMap[dateStr]; ok {
+			day = *existing
+			day.Level = calculateLevel(scores[dateStr], maxScore)
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// TeamResponseETag combines the members' ETags, so a team render changes whenever any
+// member's would
+func (s *DockerHubService) TeamResponseETag(usernames []string, tz, variant string, now time.Time) (string, error) {
+	h := sha256.New()
+	for _, name := range usernames {
+		etag, err := s.ResponseETag(name, tz, variant, now)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s|", etag)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`, nil
+}
+
+// TeamCacheMaxAge is the shortest cache lifetime among the members
+func (s *DockerHubService) TeamCacheMaxAge(usernames []string) int {
+	maxAge := MaxCacheMaxAge
+	for _, name := range usernames {
+		if age := s.CacheMaxAge(name); age < maxAge {
+			maxAge = age
+		}
+	}
+	return maxAge
+}
+
+// teamActivitySource lays a team out on the regular heatmap grid. The grid sees the team's
+// combined totals, and the per-member split is kept for the team renderer to draw.
+type teamActivitySource struct {
+	s       *DockerHubService
+	members []string
+	days    map[string]TeamDay
+}
+
+func (src *teamActivitySource) ResolveLocation(_, tz string) *time.Location {
+	return src.s.ResolveLocation(src.members[0], tz)
+}
+
+func (src *teamActivitySource) ResolveWeekStart(_, weekStart string) time.Weekday {
+	return src.s.ResolveWeekStart(src.members[0], weekStart)
+}
+
+func (src *teamActivitySource) GetActivitySummaryRange(_ string, startDate, endDate time.Time, filter ActivityFilter) ([]models.ActivitySummary, error) {
+	days, err := src.s.GetTeamActivityRange(src.members, startDate, endDate, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	src.days = make(map[string]TeamDay, len(days))
+	summaries := make([]models.ActivitySummary, 0, len(days))
+	for _, day := range days {
+		src.days[day.Date] = day
+		summaries = append(summaries, models.ActivitySummary{Date: day.Date, TotalCount: day.Total, Level: day.Level})
+	}
+	return summaries, nil
+}
+
+// TeamMember is one member's entry in the team legend
+type TeamMember struct {
+	Username string
+	Color    string
+	Total    int
+	X        int
+	Y        int
+}
+
+// TeamSegment is one member's stripe of a split cell
+type TeamSegment struct {
+	X     int
+	Width int
+	Color string
+}
+
+// TeamCell is one day of the team heatmap: a single blended fill, or stripes over the
+// empty color when split
+type TeamCell struct {
+	X         int
+	Y         int
+	Color     string
+	Segments  []TeamSegment
+	Date      string
+	Count     int
+	Breakdown string
+}
+
+// TeamSVGData represents the data needed to render the team heatmap
+type TeamSVGData struct {
+	Width        int
+	Height       int
+	CellSize     int
+	CellRadius   int
+	Cells        []TeamCell
+	Members      []TeamMember
+	MonthLabels  []MonthLabel
+	DayLabels    []DayLabel
+	YearLabels   []YearLabel
+	Config       HeatmapConfig
+	Title        string
+	HideLegend   bool
+	HideTotal    bool
+	HideLabels   bool
+	FooterY      int
+	CellsOffsetX int
+}
+
+const teamSVGTemplate = `<svg width="100%" height="auto" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="xMidYMid meet" xmlns="http://www.w3.org/2000/svg">
+  <style>
+    {{.Config.FontFace}}
+    .day { shape-rendering: geometricPrecision; }
+    .month-label { font-size: {{.Config.FontSize}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+    .day-label { font-size: {{subtract .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+    .title { font-size: {{add .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; font-weight: 600; }
+    .legend-label { font-size: {{subtract .Config.FontSize 1}}px; fill: {{.Config.TextColor}}; font-family: {{.Config.FontFamily}}; }
+  </style>
+  <defs>
+    <clipPath id="team-cell">
+      <rect width="{{.CellSize}}" height="{{.CellSize}}" rx="{{.CellRadius}}"/>
+    </clipPath>
+  </defs>
+  <rect width="{{.Width}}" height="{{.Height}}" fill="{{.Config.BgColor}}" rx="6"/>
+  {{if not .HideLabels}}
+  {{range .MonthLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="month-label">{{.Label}}</text>
+  {{end}}
+  {{range .DayLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="day-label">{{.Label}}</text>
+  {{end}}
+  {{range .YearLabels}}
+  <text x="{{.X}}" y="{{.Y}}" class="day-label">{{.Label}}</text>
+  {{end}}
+  {{end}}
+  <!-- Activity cells -->
+  <g transform="translate({{.CellsOffsetX}}, 25)">
+    {{range .Cells}}
+    <g class="day" transform="translate({{.X}}, {{.Y}})" clip-path="url(#team-cell)">
+      <title>{{.Date}}: {{.Count}} activities{{if .Breakdown}} ({{.Breakdown}}){{end}}</title>
+      <rect width="{{$.CellSize}}" height="{{$.CellSize}}" fill="{{.Color}}"{{if $.Config.BorderColor}} stroke="{{$.Config.BorderColor}}"{{end}}/>
+      {{range .Segments}}
+      <rect x="{{.X}}" width="{{.Width}}" height="{{$.CellSize}}" fill="{{.Color}}"/>
+      {{end}}
+    </g>
+    {{end}}
+  </g>
+  {{if not .HideLegend}}
+  <!-- Members -->
+  {{range .Members}}
+  <rect x="{{.X}}" y="{{.Y}}" width="11" height="11" fill="{{.Color}}" rx="2"/>
+  <text x="{{add .X 15}}" y="{{add .Y 10}}" class="legend-label">{{.Username}} ({{.Total}})</text>
+  {{end}}
+  {{end}}
+  {{if not .HideTotal}}
+  <text x="{{.CellsOffsetX}}" y="{{.FooterY}}" class="title">{{.Title}}</text>
+  {{end}}
+</svg>`
+
+// GenerateTeamSVG renders several members on one calendar, coloring each day by who was
+// active. In split mode a cell is divided into one stripe per contributing member, sized
+// by their share of the day; in mix mode the member colors are blended by share. The
+// team's combined level sets how strongly the colors show over the empty cell color.
+// memberColors overrides TeamPalette per member; blank entries keep the default.
+func (s *HeatmapService) GenerateTeamSVG(members []string, mode string, memberColors []string, opts SVGOptions) ([]byte, error) {
+	if len(members) == 0 {
+		return nil, ErrTeamMembersRequired
+	}
+	if len(members) > MaxTeamMembers {
+		return nil, ErrTooManyTeamMembers
+	}
+
+	source := &teamActivitySource{s: s.dockerService, members: members}
+	// The team grid draws its own legend, so keep the base layout's bottom row free of it
+	layoutOpts := opts
+	layoutOpts.HideLegend = true
+	data, _, err := buildHeatmapDataFrom(source, s.clock.Now(), members[0], layoutOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	empty, ok := parseColor(data.Config.Colors[0])
+	if !ok {
+		empty = rgb{0x16, 0x1b, 0x22}
+	}
+	colors := make([]rgb, len(members))
+	for i := range members {
+		color := TeamPalette[i%len(TeamPalette)]
+		if i < len(memberColors) && memberColors[i] != "" {
+			color = memberColors[i]
+		}
+		colors[i], ok = parseColor(color)
+		if !ok {
+			colors[i], _ = parseColor(TeamPalette[i%len(TeamPalette)])
+		}
+	}
+
+	totals := make([]int, len(members))
+	cells := make([]TeamCell, 0, len(data.Cells))
+	for _, cell := range data.Cells {
+		day := source.days[cell.Day]
+		teamCell := TeamCell{
+			X:     cell.X,
+			Y:     cell.Y,
+			Color: data.Config.Colors[0],
+			Date:  cell.Date,
+			Count: cell.Count,
+		}
+		if cell.Count > 0 && len(day.Counts) == len(members) {
+			for i, count := range day.Counts {
+				totals[i] += count
+			}
+			teamCell.Breakdown = teamBreakdown(members, day.Counts)
+			intensity := teamLevelIntensity[cell.Level]
+			if mode == TeamModeMix {
+				teamCell.Color = mixColors(empty, blendMemberColors(colors, day.Counts), intensity).hex()
+			} else {
+				teamCell.Segments = splitSegments(colors, day.Counts, empty, intensity, opts.CellSize)
+			}
+		}
+		cells = append(cells, teamCell)
+	}
+
+	title := opts.CustomTitle
+	if title == "" {
+		total := 0
+		for _, t := range totals {
+			total += t
+		}
+		title = fmt.Sprintf("Team Docker Activity • %d total", total)
+	}
+
+	legend, height, footerY := layoutTeamLegend(data, members, colors, totals, opts)
+	svg, err := executeChartTemplate("team", teamSVGTemplate, TeamSVGData{
+		Width:        data.Width,
+		Height:       height,
+		CellSize:     opts.CellSize,
+		CellRadius:   opts.CellRadius,
+		Cells:        cells,
+		Members:      legend,
+		MonthLabels:  data.MonthLabels,
+		DayLabels:    data.DayLabels,
+		YearLabels:   data.YearLabels,
+		Config:       data.Config,
+		Title:        title,
+		HideLegend:   opts.HideLegend,
+		HideTotal:    opts.HideTotal,
+		HideLabels:   opts.HideLabels,
+		FooterY:      footerY,
+		CellsOffsetX: data.CellsOffsetX,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Credit is due unless every member's owner has opted out
+	for _, name := range members {
+		if text := s.dockerService.AttributionFor(name); text != "" {
+			return stampAttribution(svg, text), nil
+		}
+	}
+	return svg, nil
+}
+
+// layoutTeamLegend places the member legend rows under the cells, wrapping to the
+// heatmap's width, with the footer title below them. It returns the legend and the
+// render's resulting height and footer position.
+func layoutTeamLegend(data *SVGData, members []string, colors []rgb, totals []int, opts SVGOptions) ([]TeamMember, int, int) {
+	if opts.HideLegend {
+		return nil, data.Height, data.FooterY
+	}
+
+	var legend []TeamMember
+	x, y := data.CellsOffsetX, data.LegendY
+	for i, name := range members {
+		label := SanitizeText(name, 0)
+		itemWidth := 15 + (len(label)+8)*data.Config.FontSize*6/10 + 14
+		if x > data.CellsOffsetX && x+itemWidth > data.Width-10 {
+			x = data.CellsOffsetX
+			y += 16
+		}
+		legend = append(legend, TeamMember{
+			Username: label,
+			Color:    colors[i].hex(),
+			Total:    totals[i],
+			X:        x,
+			Y:        y,
+		})
+		x += itemWidth
+	}
+	legendBottom := y + 16
+	footerY := legendBottom + 13
+	height := legendBottom + 5
+	if !opts.HideTotal {
+		height = footerY + 12
+	}
+	return legend, height, footerY
+}
+
+// teamBreakdown lists the members active on a day, e.g. "alice 3, bob 1"
+func teamBreakdown(members []string, counts []int) string {
+	parts := make([]string, 0, len(members))
+	for i, count := range counts {
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", members[i], count))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// blendMemberColors averages the member colors weighted by each member's share of the day
+func blendMemberColors(colors []rgb, counts []int) rgb {
+	var r, g, b, total float64
+	for i, count := range counts {
+		w := float64(count)
+		r += float64(colors[i].R) * w
+		g += float64(colors[i].G) * w
+		b += float64(colors[i].B) * w
+		total += w
+	}
+	if total == 0 {
+		return colors[0]
+	}
+	return rgb{uint8(math.Round(r / total)), uint8(math.Round(g / total)), uint8(math.Round(b / total))}
+}
+
+// splitSegments divides a cell's width between the active members in proportion to their
+// share of the day. Boundaries are rounded from the running total, so the stripes always
+// fill the cell exactly.
+func splitSegments(colors []rgb, counts []int, empty rgb, intensity float64, cellSize int) []TeamSegment {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	var segments []TeamSegment
+	running := 0
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		start := int(math.Round(float64(running) / float64(total) * float64(cellSize)))
+		running += count
+		end := int(math.Round(float64(running) / float64(total) * float64(cellSize)))
+		if end <= start {
+			continue
+		}
+		segments = append(segments, TeamSegment{
+			X:     start,
+			Width: end - start,
+			Color: mixColors(empty, colors[i], intensity).hex(),
+		})
+	}
+	return segments
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// terminalBlocks are used per level when colors are disabled
+var terminalBlocks = []string{"·", "░", "▒", "▓", "█"}
+
+const terminalCell = "■"
+
+// GenerateTextHeatmap renders the heatmap as text for terminals, using 24-bit ANSI colors
+// from the theme, or block shading per level when noColor is set
+func (s *HeatmapService) GenerateTextHeatmap(dockerUsername string, opts SVGOptions, noColor bool) (string, error) {
+	normalizeRenderOptions(&opts)
+	if opts.Theme == "" {
+		opts.Theme = "github"
+	}
+	theme := resolveTheme(opts)
+
+	loc := s.dockerService.ResolveLocation(dockerUsername, opts.Timezone)
+	firstDay := s.dockerService.ResolveWeekStart(dockerUsername, opts.WeekStart)
+	today := s.clock.Now().In(loc)
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -opts.Days+1)
+
+	activities, err := s.dockerService.GetActivitySummaryRange(dockerUsername, start, today, ActivityFilter{
+		Location:      loc,
+		EventTypes:    opts.EventTypes,
+		Weights:       opts.EventWeights,
+		MinConfidence: opts.MinConfidence,
+		Tags:          opts.Tags,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	levels := make(map[string]int, len(activities))
+	total := 0
+	for _, a := range activities {
+		levels[a.Date] = a.Level
+		total += a.TotalCount
+	}
+
+	title := opts.CustomTitle
+	if title == "" {
+		title = fmt.Sprintf("@%s Docker Activity • %d total", dockerUsername, total)
+	}
+
+	return renderTextHeatmap(levels, start, today, firstDay, theme, SanitizeText(title, 0), opts, noColor), nil
+}
+
+// renderTextHeatmap lays out the level grid as text, one row per weekday
+func renderTextHeatmap(levels map[string]int, start, today time.Time, firstDay time.Weekday, theme Theme, title string, opts SVGOptions, noColor bool) string {
+	gridStart := start
+	for gridStart.Weekday() != firstDay {
+		gridStart = gridStart.AddDate(0, 0, -1)
+	}
+	numWeeks := int(today.Sub(gridStart).Hours()/24)/7 + 1
+
+	cell := func(level int) string {
+		if noColor {
+			return terminalBlocks[level]
+		}
+		return ansiColor(theme.Colors[level], terminalCell)
+	}
+
+	var b strings.Builder
+
+	// Month labels, each cell is two columns wide
+	if !opts.HideLabels {
+		header := []byte(strings.Repeat(" ", 4+numWeeks*2))
+		lastMonth := time.Month(0)
+		lastEnd := 0
+		for w := 0; w < numWeeks; w++ {
+			weekStart := gridStart.AddDate(0, 0, w*7)
+			if weekStart.Before(start) {
+				weekStart = start
+			}
+			col := 4 + w*2
+			if weekStart.Month() != lastMonth && col >= lastEnd && col+3 <= len(header) {
+				copy(header[col:], weekStart.Format("Jan"))
+				lastMonth = weekStart.Month()
+				lastEnd = col + 4
+			}
+		}
+		b.WriteString(strings.TrimRight(string(header), " "))
+		b.WriteString("\n")
+	}
+
+	for row := 0; row < 7; row++ {
+		weekday := time.Weekday((int(firstDay) + row) % 7)
+		if !opts.HideLabels {
+			label := "   "
+			if weekday == time.Monday || weekday == time.Wednesday || weekday == time.Friday {
+				label = weekday.String()[:3]
+			}
+			b.WriteString(label + " ")
+		}
+		for w := 0; w < numWeeks; w++ {
+			date := gridStart.AddDate(0, 0, w*7+row)
+			if date.Before(start) || date.After(today) {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(cell(levels[date.Format("2006-01-02")]) + " ")
+		}
+		b.WriteString("\n")
+	}
+
+	if !opts.HideTotal || !opts.HideLegend {
+		b.WriteString("\n")
+	}
+	if !opts.HideTotal {
+		b.WriteString(title)
+		if !opts.HideLegend {
+			b.WriteString("    ")
+		}
+	}
+	if !opts.HideLegend {
+		b.WriteString("Less ")
+		for level := range theme.Colors {
+			b.WriteString(cell(level) + " ")
+		}
+		b.WriteString("More")
+	}
+	if !opts.HideTotal || !opts.HideLegend {
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// ansiColor wraps text in a 24-bit foreground color escape, leaving it plain if the color can't be parsed
+func ansiColor(color, text string) string {
+	c, ok := parseColor(color)
+	if !ok {
+		return text
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", c.R, c.G, c.B, text)
+}
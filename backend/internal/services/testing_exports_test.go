@@ -0,0 +1,4 @@
+package services
+
+// Exported for the services_test package, whose tests go through the middleware
+var TopEmbeddedUsernames = topEmbeddedUsernames
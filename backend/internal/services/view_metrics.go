@@ -0,0 +1,157 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// maxPendingViews bounds the in-memory counters between flushes; views for new
+// usernames past it are dropped rather than letting junk usernames grow memory
+const maxPendingViews = 10000
+
+// MaxViewHistoryDays caps how far back the owner's view history can be requested
+const MaxViewHistoryDays = 365
+
+type viewKey struct {
+	username string
+	day      string
+	kind     string
+}
+
+var (
+	pendingViewsMu sync.Mutex
+	pendingViews   = make(map[viewKey]int64)
+)
+
+// RecordView counts one served render or profile view for a username. Counts are held in
+// memory and written out by FlushViews, so the request path never waits on the database.
+func RecordView(dockerUsername, kind string) {
+	key := viewKey{username: dockerUsername, day: time.Now().UTC().Format("2006-01-02"), kind: kind}
+
+	pendingViewsMu.Lock()
+	defer pendingViewsMu.Unlock()
+	if _, ok := pendingViews[key]; !ok && len(pendingViews) >= maxPendingViews {
+		return
+	}
+	pendingViews[key]++
+}
+
+// FlushViews adds the counts recorded since the last flush to each account's daily totals.
+// Usernames that don't belong to a live account are discarded; counts that fail to write
+// are kept for the next flush.
+func FlushViews() {
+	pendingViewsMu.Lock()
+	batch := pendingViews
+	pendingViews = make(map[viewKey]int64)
+	pendingViewsMu.Unlock()
+
+	upsertViewCount := `
+		ON CONFLICT (docker_account_id, day, kind)
+		DO UPDATE SET count = daily_view_counts.count + ?
+	`
+	if database.IsMySQL() {
+		upsertViewCount = `ON DUPLICATE KEY UPDATE count = count + ?`
+	}
+
+	failed := 0
+	for key, count := range batch {
+		err := database.DB.Exec(`
+			INSERT INTO daily_view_counts (docker_account_id, day, kind, count)
+			SELECT id, ?, ?, ? FROM docker_accounts
+			WHERE docker_username = ? AND deleted_at IS NULL AND disconnect_scheduled_at IS NULL
+		`+upsertViewCount, key.day, key.kind, count, key.username, count).Error
+		if err != nil {
+			failed++
+			pendingViewsMu.Lock()
+			pendingViews[key] += count
+			pendingViewsMu.Unlock()
+		}
+	}
+
+	if failed > 0 {
+		slog.Warn("Failed to flush view counters, retrying next flush", "count", failed)
+	}
+}
+
+// RecentRenderCounts returns how many images were rendered for each account over the
+// last days UTC days, including today. Accounts without renders are left out.
+func RecentRenderCounts(days int) (map[uint]int64, error) {
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+
+	var rows []struct {
+		DockerAccountID uint
+		Total           int64
+	}
+	err := database.DB.Model(&models.DailyViewCount{}).
+		Select("docker_account_id, SUM(count) AS total").
+		Where("kind = ? AND day >= ?", models.ViewKindRender, start.Format("2006-01-02")).
+		Group("docker_account_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.DockerAccountID] = row.Total
+	}
+	return counts, nil
+}
+
+// ViewDay is one day of an account's view history
+type ViewDay struct {
+	Date     string `json:"date"`
+	Renders  int64  `json:"renders"`
+	Profiles int64  `json:"profile_views"`
+}
+
+// ViewHistory is an account's daily views over a trailing window, oldest day first
+type ViewHistory struct {
+	Days          int       `json:"days"`
+	TotalRenders  int64     `json:"total_renders"`
+	TotalProfiles int64     `json:"total_profile_views"`
+	History       []ViewDay `json:"history"`
+}
+
+// GetViewHistory returns the account's daily render and profile view counts for the last
+// days UTC days, including today. Days without views are reported as zero.
+func (s *DockerHubService) GetViewHistory(accountID uint, days int) (*ViewHistory, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	var rows []models.DailyViewCount
+	err := database.DB.Where("docker_account_id = ? AND day >= ?", accountID, start.Format("2006-01-02")).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	history := &ViewHistory{Days: days, History: make([]ViewDay, days)}
+	index := make(map[string]int, days)
+	for i := range history.History {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		history.History[i].Date = date
+		index[date] = i
+	}
+
+	for _, row := range rows {
+		i, ok := index[row.Day.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		switch row.Kind {
+		case models.ViewKindRender:
+			history.History[i].Renders += row.Count
+			history.TotalRenders += row.Count
+		case models.ViewKindProfile:
+			history.History[i].Profiles += row.Count
+			history.TotalProfiles += row.Count
+		}
+	}
+
+	return history, nil
+}
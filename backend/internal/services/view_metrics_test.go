@@ -0,0 +1,69 @@
+package services_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"docker-heatmap/internal/middleware"
+	"docker-heatmap/internal/services"
+	"docker-heatmap/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// serveViews requests each path through the analytics middleware, in front of the heatmap
+// routes in the router's order, and flushes the counts
+func serveViews(t *testing.T, paths ...string) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Use(middleware.AnalyticsMiddleware())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/api/v1/heatmap/:username.png", ok)
+	app.Get("/api/v1/heatmap/:username", ok)
+	app.Get("/api/v1/heatmap/:username.svg", ok)
+	app.Get("/api/v1/profile/:username", ok)
+
+	for _, path := range paths {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: status %d", path, resp.StatusCode)
+		}
+	}
+	services.FlushViews()
+}
+
+func TestRecentRenderCountsIncludeSVGOnlyAccounts(t *testing.T) {
+	testutil.OpenDB(t)
+	svgOnly := testutil.CreateAccount(t, "alice", true)
+	bare := testutil.CreateAccount(t, "bob", true)
+	viewed := testutil.CreateAccount(t, "carol", true)
+
+	serveViews(t,
+		"/api/v1/heatmap/alice.svg",
+		"/api/v1/heatmap/alice.svg",
+		"/api/v1/heatmap/bob",
+		"/api/v1/heatmap/bob.png",
+		"/api/v1/profile/carol",
+	)
+
+	counts, err := services.RecentRenderCounts(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint]int64{svgOnly.ID: 2, bare.ID: 2}
+	if len(counts) != len(want) {
+		t.Errorf("counts %v, want %v", counts, want)
+	}
+	for id, n := range want {
+		if counts[id] != n {
+			t.Errorf("account %d: %d renders, want %d", id, counts[id], n)
+		}
+	}
+	if _, ok := counts[viewed.ID]; ok {
+		t.Errorf("profile views counted as renders: %v", counts)
+	}
+}
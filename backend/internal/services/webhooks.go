@@ -0,0 +1,107 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/utils"
+)
+
+// webhookChannelType is the notification channel type webhooks are stored as
+const webhookChannelType = "webhook"
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook is a webhook channel as integrations see it, with its URL but not its secret
+type Webhook struct {
+	ID              uint       `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	Name            string     `json:"name"`
+	URL             string     `json:"url"`
+	Events          []string   `json:"events"`
+	Signed          bool       `json:"signed"`
+	Enabled         bool       `json:"enabled"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// ListWebhooks returns the user's webhook channels, those added as notification channels
+// included
+func (s *NotificationService) ListWebhooks(userID uint) ([]Webhook, error) {
+	var channels []models.NotificationChannel
+	err := database.DB.Where("user_id = ? AND type = ?", userID, webhookChannelType).Order("id").Find(&channels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]Webhook, 0, len(channels))
+	for i := range channels {
+		webhook, err := toWebhook(&channels[i])
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, nil
+}
+
+// CreateWebhook adds a webhook channel posting the events to url, and returns it with the
+// secret its payloads are signed with, which can't be read back later. Webhooks count
+// towards the user's notification channel limit.
+func (s *NotificationService) CreateWebhook(userID uint, name, url string, events []string) (*Webhook, string, error) {
+	secret, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, "", err
+	}
+
+	channel, err := s.CreateChannel(userID, webhookChannelType, name, map[string]string{"url": url, "secret": secret}, events)
+	if err != nil {
+		return nil, "", err
+	}
+	webhook, err := toWebhook(channel)
+	if err != nil {
+		return nil, "", err
+	}
+	return webhook, secret, nil
+}
+
+// DeleteWebhook removes one of the user's webhooks and its queued deliveries
+func (s *NotificationService) DeleteWebhook(userID, id uint) error {
+	channel, err := s.GetChannel(userID, id)
+	if err != nil || channel.Type != webhookChannelType {
+		return ErrWebhookNotFound
+	}
+	return s.DeleteChannel(userID, id)
+}
+
+// toWebhook decrypts a webhook channel's settings for its URL
+func toWebhook(channel *models.NotificationChannel) (*Webhook, error) {
+	decrypted, err := utils.Decrypt(channel.EncryptedSettings, channel.SettingsIV)
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]string
+	if err := json.Unmarshal([]byte(decrypted), &settings); err != nil {
+		return nil, err
+	}
+
+	events := NotificationEvents
+	if channel.Events != "" {
+		events = strings.Split(channel.Events, ",")
+	}
+	return &Webhook{
+		ID:              channel.ID,
+		CreatedAt:       channel.CreatedAt,
+		Name:            channel.Name,
+		URL:             settings["url"],
+		Events:          events,
+		Signed:          settings["secret"] != "",
+		Enabled:         channel.Enabled,
+		LastDeliveredAt: channel.LastDeliveredAt,
+		LastError:       channel.LastError,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+	"docker-heatmap/internal/testutil"
+	"docker-heatmap/internal/utils"
+)
+
+func TestWebhookToLoopbackHostnameIsRefused(t *testing.T) {
+	testutil.OpenDB(t)
+	account := testutil.CreateAccount(t, "alice", true)
+
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	svc := NewNotificationService()
+	webhook, _, err := svc.CreateWebhook(account.UserID, "hooks", "https://hooks.example.com/heatmap", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The name passed validation, but now resolves to the server's own loopback
+	target, _ := url.Parse(server.URL)
+	target.Host = "localhost:" + target.Port()
+	settings, _ := json.Marshal(map[string]string{"url": target.String(), "secret": strings.Repeat("s", 48)})
+	encrypted, iv, err := utils.Encrypt(string(settings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = database.DB.Model(&models.NotificationChannel{}).Where("id = ?", webhook.ID).
+		Updates(map[string]interface{}{"encrypted_settings": encrypted, "settings_iv": iv}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.SendTest(context.Background(), account.UserID, webhook.ID)
+	if err == nil || !strings.Contains(err.Error(), "internal address") {
+		t.Errorf("error %v, want the internal address refused", err)
+	}
+	if hit {
+		t.Error("webhook reached a loopback server")
+	}
+
+	webhooks, err := svc.ListWebhooks(account.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(webhooks) != 1 || !strings.Contains(webhooks[0].LastError, "internal address") {
+		t.Errorf("webhooks %+v, want the refusal recorded", webhooks)
+	}
+}
@@ -0,0 +1,466 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// Lossless WebP (VP8L) encoding. The standard library and x/image only decode WebP, so
+// raster renders are encoded here. Heatmaps are mostly flat runs of a few colors, which
+// the subtract-green transform and backward references to the pixel to the left or above
+// compress well, without the other transforms or a color cache.
+
+const (
+	vp8lSignature      = 0x2f
+	vp8lMaxDimension   = 1 << 14
+	vp8lSubtractGreen  = 2
+	vp8lLengthCodes    = 24
+	vp8lDistanceCodes  = 40
+	vp8lMaxCodeLength  = 15
+	vp8lMaxCLCodeBits  = 7
+	vp8lMinMatch       = 3
+	vp8lMaxMatch       = 4096
+	vp8lMaxDistance    = 1 << 18
+	vp8lChainDepth     = 32
+	vp8lHashBits       = 16
+	vp8lNeighborhood   = 120 // distance codes reserved for the 2D neighborhood
+	vp8lCodeLengthReps = 16
+	vp8lZeroRun        = 17
+	vp8lLongZeroRun    = 18
+)
+
+// vp8lCodeLengthOrder is the order the code length code's lengths are written in
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+var errWebPTooLarge = errors.New("image too large for webp")
+
+// encodeWebP writes img as a lossless WebP
+func encodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errWebPTooLarge
+	}
+
+	argb := make([]uint32, 0, width*height)
+	hasAlpha := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			// Subtract green from red and blue, which flat colors make near-constant
+			r, b := c.R-c.G, c.B-c.G
+			argb = append(argb, uint32(c.A)<<24|uint32(r)<<16|uint32(c.G)<<8|uint32(b))
+		}
+	}
+
+	bw := &vp8lBitWriter{}
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // version
+
+	bw.write(1, 1) // a transform follows
+	bw.write(vp8lSubtractGreen, 2)
+	bw.write(0, 1) // no more transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // one set of prefix codes for the whole image
+
+	symbols := vp8lBackwardReferences(argb, width)
+
+	var histograms [5][]int
+	histograms[0] = make([]int, 256+vp8lLengthCodes)
+	for i := 1; i < 4; i++ {
+		histograms[i] = make([]int, 256)
+	}
+	histograms[4] = make([]int, vp8lDistanceCodes)
+	for _, s := range symbols {
+		if s.length == 0 {
+			histograms[0][s.argb>>8&0xff]++
+			histograms[1][s.argb>>16&0xff]++
+			histograms[2][s.argb&0xff]++
+			histograms[3][s.argb>>24]++
+			continue
+		}
+		lengthCode, _, _ := vp8lPrefixEncode(s.length)
+		distanceCode, _, _ := vp8lPrefixEncode(s.distance)
+		histograms[0][256+lengthCode]++
+		histograms[4][distanceCode]++
+	}
+
+	var codes [5]vp8lHuffmanCode
+	for i, histogram := range histograms {
+		codes[i] = vp8lWriteHuffmanCode(bw, histogram)
+	}
+
+	for _, s := range symbols {
+		if s.length == 0 {
+			codes[0].write(bw, int(s.argb>>8&0xff))
+			codes[1].write(bw, int(s.argb>>16&0xff))
+			codes[2].write(bw, int(s.argb&0xff))
+			codes[3].write(bw, int(s.argb>>24))
+			continue
+		}
+		code, bits, extra := vp8lPrefixEncode(s.length)
+		codes[0].write(bw, 256+code)
+		bw.write(extra, bits)
+		code, bits, extra = vp8lPrefixEncode(s.distance)
+		codes[4].write(bw, code)
+		bw.write(extra, bits)
+	}
+
+	data := bw.bytes()
+	size := len(data)
+	padding := size & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+size+padding))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(size))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// vp8lSymbol is a literal pixel, or a copy of length pixels from distance back as the
+// plane code the decoder reads
+type vp8lSymbol struct {
+	argb     uint32
+	length   int
+	distance int
+}
+
+// vp8lBackwardReferences greedily replaces runs that repeat earlier pixels with copies.
+// The pixel to the left and the one above are tried first, since their distances have
+// the shortest codes.
+func vp8lBackwardReferences(argb []uint32, width int) []vp8lSymbol {
+	n := len(argb)
+	head := make([]int32, 1<<vp8lHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, n)
+	hash := func(i int) uint32 {
+		return (argb[i]*0x9e3779b1 ^ argb[i+1]*0x85ebca6b) >> (32 - vp8lHashBits)
+	}
+	insert := func(i int) {
+		if i+1 < n {
+			h := hash(i)
+			prev[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+	matchLength := func(i, j int) int {
+		limit := min(n-i, vp8lMaxMatch)
+		length := 0
+		for length < limit && argb[i+length] == argb[j+length] {
+			length++
+		}
+		return length
+	}
+
+	var symbols []vp8lSymbol
+	for i := 0; i < n; {
+		bestLength, bestDistance := 0, 0
+		for _, distance := range [2]int{1, width} {
+			if distance <= i {
+				if length := matchLength(i, i-distance); length > bestLength {
+					bestLength, bestDistance = length, distance
+				}
+			}
+		}
+		if bestLength < vp8lMaxMatch && i+1 < n {
+			for j, depth := head[hash(i)], 0; j >= 0 && depth < vp8lChainDepth && i-int(j) <= vp8lMaxDistance; j, depth = prev[j], depth+1 {
+				// A longer match has to beat the cheap codes of the neighbors by a margin
+				if length := matchLength(i, int(j)); length > bestLength+1 {
+					bestLength, bestDistance = length, i-int(j)
+				}
+			}
+		}
+
+		if bestLength < vp8lMinMatch {
+			symbols = append(symbols, vp8lSymbol{argb: argb[i]})
+			insert(i)
+			i++
+			continue
+		}
+		symbols = append(symbols, vp8lSymbol{length: bestLength, distance: vp8lPlaneCode(bestDistance, width)})
+		for k := i; k < i+bestLength; k++ {
+			insert(k)
+		}
+		i += bestLength
+	}
+	return symbols
+}
+
+// vp8lPlaneCode maps a distance in pixels to the code the decoder reads. The pixels above
+// and to the left have their own short codes; other distances follow the 120 codes kept
+// for the neighborhood.
+func vp8lPlaneCode(distance, width int) int {
+	switch distance {
+	case width:
+		return 1
+	case 1:
+		return 2
+	}
+	return distance + vp8lNeighborhood
+}
+
+// vp8lPrefixEncode splits a length or distance code into its prefix symbol and the extra
+// bits that follow it
+func vp8lPrefixEncode(value int) (code int, bits uint, extra uint32) {
+	value--
+	if value < 4 {
+		return value, 0, 0
+	}
+	highest := 31
+	for value>>highest == 0 {
+		highest--
+	}
+	second := (value >> (highest - 1)) & 1
+	bits = uint(highest - 1)
+	return 2*highest + second, bits, uint32(value) & (1<<bits - 1)
+}
+
+// vp8lHuffmanCode holds each symbol's code, bit-reversed to be written least significant
+// bit first, and its length in the stream
+type vp8lHuffmanCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+func (h vp8lHuffmanCode) write(bw *vp8lBitWriter, symbol int) {
+	bw.write(h.codes[symbol], uint(h.lengths[symbol]))
+}
+
+// vp8lWriteHuffmanCode writes the prefix code for a histogram and returns it
+func vp8lWriteHuffmanCode(bw *vp8lBitWriter, histogram []int) vp8lHuffmanCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// Up to two 8-bit symbols fit a simple code. One symbol takes no bits in the stream.
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		lengths := make([]uint8, len(histogram))
+		bw.write(1, 1)
+		switch len(used) {
+		case 0:
+			bw.write(0, 1)
+			bw.write(0, 1)
+			bw.write(0, 1)
+		case 1:
+			bw.write(0, 1)
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		case 2:
+			bw.write(1, 1)
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+			bw.write(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return vp8lCanonicalCode(lengths)
+	}
+
+	lengths := vp8lCodeLengths(histogram, vp8lMaxCodeLength)
+
+	// The lengths themselves are coded: zero runs get their own symbols
+	type token struct {
+		symbol int
+		extra  uint32
+		bits   uint
+	}
+	var tokens []token
+	for i := 0; i < len(lengths); {
+		if lengths[i] != 0 {
+			tokens = append(tokens, token{symbol: int(lengths[i])})
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run >= 11:
+			tokens = append(tokens, token{vp8lLongZeroRun, uint32(run - 11), 7})
+		case run >= 3:
+			tokens = append(tokens, token{vp8lZeroRun, uint32(run - 3), 3})
+		default:
+			run = 1
+			tokens = append(tokens, token{symbol: 0})
+		}
+		i += run
+	}
+
+	clHistogram := make([]int, 19)
+	for _, t := range tokens {
+		clHistogram[t.symbol]++
+	}
+	clLengths := vp8lCodeLengths(clHistogram, vp8lMaxCLCodeBits)
+	count := 19
+	for count > 4 && clLengths[vp8lCodeLengthOrder[count-1]] == 0 {
+		count--
+	}
+
+	bw.write(0, 1) // normal code
+	bw.write(uint32(count-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:count] {
+		bw.write(uint32(clLengths[symbol]), 3)
+	}
+	bw.write(0, 1) // lengths for the whole alphabet follow
+
+	clCode := vp8lCanonicalCode(clLengths)
+	for _, t := range tokens {
+		clCode.write(bw, t.symbol)
+		bw.write(t.extra, t.bits)
+	}
+	return vp8lCanonicalCode(lengths)
+}
+
+// vp8lCodeLengths builds Huffman code lengths no longer than limit, flattening the
+// histogram until the tree is shallow enough
+func vp8lCodeLengths(histogram []int, limit int) []uint8 {
+	lengths := make([]uint8, len(histogram))
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	switch len(used) {
+	case 0:
+		return lengths
+	case 1:
+		// A lone symbol is coded with zero bits, but declared with length 1
+		lengths[used[0]] = 1
+		return lengths
+	}
+
+	for floor := 1; ; floor *= 2 {
+		type node struct {
+			weight      int
+			symbol      int // leaves only, -1 otherwise
+			left, right int
+		}
+		nodes := make([]node, 0, 2*len(used))
+		for _, symbol := range used {
+			nodes = append(nodes, node{weight: max(histogram[symbol], floor), symbol: symbol})
+		}
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+
+		// Two-queue Huffman construction over leaves sorted by weight
+		leaf, inner := 0, len(nodes)
+		pick := func() int {
+			if leaf < len(used) && (inner >= len(nodes) || nodes[leaf].weight <= nodes[inner].weight) {
+				leaf++
+				return leaf - 1
+			}
+			inner++
+			return inner - 1
+		}
+		for merged := 0; merged < len(used)-1; merged++ {
+			a, b := pick(), pick()
+			nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		}
+
+		depths := make([]int, len(nodes))
+		deepest := 0
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if nodes[i].symbol >= 0 {
+				lengths[nodes[i].symbol] = uint8(depths[i])
+				deepest = max(deepest, depths[i])
+				continue
+			}
+			depths[nodes[i].left] = depths[i] + 1
+			depths[nodes[i].right] = depths[i] + 1
+		}
+		if deepest <= limit {
+			return lengths
+		}
+	}
+}
+
+// vp8lCanonicalCode assigns canonical codes to the lengths, as the decoder rebuilds them
+func vp8lCanonicalCode(lengths []uint8) vp8lHuffmanCode {
+	code := vp8lHuffmanCode{codes: make([]uint32, len(lengths)), lengths: make([]uint8, len(lengths))}
+
+	var used, lengthCount [vp8lMaxCodeLength + 1]int
+	total := 0
+	for _, length := range lengths {
+		if length > 0 {
+			lengthCount[length]++
+			total++
+		}
+	}
+	if total <= 1 {
+		// A lone symbol takes no bits
+		return code
+	}
+
+	var next [vp8lMaxCodeLength + 2]uint32
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		next[length+1] = (next[length] + uint32(lengthCount[length])) << 1
+	}
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		value := next[length] + uint32(used[length])
+		used[length]++
+		reversed := uint32(0)
+		for i := uint8(0); i < length; i++ {
+			reversed = reversed<<1 | (value>>i)&1
+		}
+		code.codes[symbol] = reversed
+		code.lengths[symbol] = length
+	}
+	return code
+}
+
+// vp8lBitWriter packs values least significant bit first, as VP8L reads them
+type vp8lBitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (bw *vp8lBitWriter) write(value uint32, bits uint) {
+	bw.acc |= uint64(value) << bw.nbits
+	bw.nbits += bits
+	for bw.nbits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nbits -= 8
+	}
+}
+
+func (bw *vp8lBitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nbits = 0, 0
+	}
+	return bw.buf
+}
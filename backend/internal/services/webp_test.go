@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrips(t *testing.T) {
+	noise := image.NewNRGBA(image.Rect(0, 0, 61, 37))
+	rng := rand.New(rand.NewSource(1))
+	for i := range noise.Pix {
+		noise.Pix[i] = byte(rng.Intn(256))
+	}
+
+	// Geometrically distributed values need codes longer than VP8L allows unless limited
+	skewed := image.NewNRGBA(image.Rect(0, 0, 512, 256))
+	for i := 0; i < len(skewed.Pix); i += 4 {
+		skewed.Pix[i+1] = byte(bits.TrailingZeros32(rng.Uint32()) * 7)
+		skewed.Pix[i+3] = 0xff
+	}
+
+	flat := image.NewNRGBA(image.Rect(0, 0, 300, 120))
+	for y := 0; y < 120; y++ {
+		for x := 0; x < 300; x++ {
+			flat.SetNRGBA(x, y, color.NRGBA{0x0d, 0x11, 0x17, 0xff})
+			if x%14 < 11 && y%14 < 11 {
+				flat.SetNRGBA(x, y, color.NRGBA{0x26, byte(0xa6 - x/14*4), 0x41, 0xff})
+			}
+		}
+	}
+
+	tests := map[string]image.Image{
+		"flat":   flat,
+		"noise":  noise,
+		"skewed": skewed,
+		"pixel":  image.NewNRGBA(image.Rect(0, 0, 1, 1)),
+	}
+	for name, img := range tests {
+		var buf bytes.Buffer
+		if err := encodeWebP(&buf, img); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		decoded, err := webp.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: decoding: %v", name, err)
+		}
+		if !decoded.Bounds().Eq(img.Bounds()) {
+			t.Fatalf("%s: bounds %v, want %v", name, decoded.Bounds(), img.Bounds())
+		}
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				got := color.NRGBAModel.Convert(decoded.At(x, y))
+				want := color.NRGBAModel.Convert(img.At(x, y))
+				if got != want {
+					t.Fatalf("%s: pixel (%d, %d) is %v, want %v", name, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeWebPIsSmallerThanPNG(t *testing.T) {
+	data := &SVGData{
+		Width:  400,
+		Height: 140,
+		Config: HeatmapConfig{BgColor: "#0d1117", TextColor: "#c9d1d9", FontSize: 10,
+			Colors: []string{"#161b22", "#0e4429", "#006d32", "#26a641", "#39d353"}},
+		Username:     "alice",
+		TotalCount:   1234,
+		CellsOffsetX: 30,
+		FooterY:      130,
+		LegendX:      300,
+		LegendY:      120,
+	}
+	rc := newRasterCanvas(data.Width, data.Height, PNGScale, "")
+	defer rc.close()
+	if err := rc.drawFrame(data, ""); err != nil {
+		t.Fatal(err)
+	}
+	for week := 0; week < 26; week++ {
+		for day := 0; day < 7; day++ {
+			rc.drawCell(data, Cell{X: week * 14, Y: day * 14, Width: 11, Height: 11, Radius: 2}, (week*day)%5)
+		}
+	}
+
+	var pngBuf, webpBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, rc.img); err != nil {
+		t.Fatal(err)
+	}
+	if err := encodeWebP(&webpBuf, rc.img); err != nil {
+		t.Fatal(err)
+	}
+	if webpBuf.Len() >= pngBuf.Len() {
+		t.Errorf("webp is %d bytes, png %d", webpBuf.Len(), pngBuf.Len())
+	}
+	if _, err := webp.Decode(&webpBuf); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WrappedFreezeSpec is when last year's reports are stored for every account: early on
+// January 1st, after the year's final syncs and before cleanup trims its first day
+const WrappedFreezeSpec = "0 13 1 1 *"
+
+// wrappedSettleTime is how long after a year ends its report is considered final, giving
+// scheduled syncs time to pick up its last activity
+const wrappedSettleTime = 12 * time.Hour
+
+// wrappedTopRepositories is how many repositories a report lists
+const wrappedTopRepositories = 5
+
+// Share image size, the usual size for link previews
+const (
+	wrappedImageWidth  = 1200
+	wrappedImageHeight = 630
+)
+
+var (
+	ErrWrappedYearInvalid     = errors.New("year must not be in the future")
+	ErrWrappedYearUnavailable = errors.New("activity for that year is no longer kept")
+)
+
+// WrappedRepository is one of the year's most active repositories
+type WrappedRepository struct {
+	Repository string `json:"repository"`
+	Count      int    `json:"count"`
+}
+
+// Wrapped is an account's year in review, over the UTC calendar year
+type Wrapped struct {
+	Username string `json:"username"`
+	Year     int    `json:"year"`
+	// Final is false while the year is still under way; its highlights can still change
+	Final bool `json:"final"`
+
+	Total         int    `json:"total"`
+	Pushes        int    `json:"pushes"`
+	Pulls         int    `json:"pulls"`
+	Builds        int    `json:"builds"`
+	ActiveDays    int    `json:"active_days"`
+	BusiestDate   string `json:"busiest_date,omitempty"`
+	BusiestCount  int    `json:"busiest_count"`
+	LongestStreak int    `json:"longest_streak"`
+
+	// Months is the total for each month, January first
+	Months          [12]int             `json:"months"`
+	TopRepositories []WrappedRepository `json:"top_repositories"`
+
+	// Percentile is the share of other accounts with less activity in the year
+	Percentile int `json:"percentile"`
+
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// WrappedService builds year-in-review reports and stores them once the year is over
+type WrappedService struct {
+	dockerService *DockerHubService
+	clock         Clock
+}
+
+func NewWrappedService(dockerService *DockerHubService, clock Clock) *WrappedService {
+	return &WrappedService{
+		dockerService: dockerService,
+		clock:         clock,
+	}
+}
+
+// GetWrapped returns the account's report for the year. A finished year is built once and
+// stored, so it can still be shown after its activity ages out; the current year is built
+// on every request. A finished year that was never stored can only be built while all of
+// it is within retention.
+func (s *WrappedService) GetWrapped(dockerUsername string, year int) (*Wrapped, error) {
+	account, err := s.dockerService.GetDockerAccountByUsername(dockerUsername)
+	if err != nil {
+		return nil, err
+	}
+	return s.getOrCreateWrapped(account, year)
+}
+
+func (s *WrappedService) getOrCreateWrapped(account *models.DockerAccount, year int) (*Wrapped, error) {
+	now := s.clock.Now().UTC()
+	if year < 1 || year > now.Year() {
+		return nil, ErrWrappedYearInvalid
+	}
+
+	if wrapped, err := loadWrapped(account.ID, year); err == nil {
+		return wrapped, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	if start.Before(now.AddDate(0, 0, -ActivityRetentionDays())) {
+		return nil, ErrWrappedYearUnavailable
+	}
+
+	wrapped, err := s.buildWrapped(account, year, now)
+	if err != nil {
+		return nil, err
+	}
+	// Read-only instances serve the report without keeping it
+	if !wrapped.Final || config.IsReadOnly() {
+		return wrapped, nil
+	}
+
+	highlights, err := json.Marshal(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	report := models.WrappedReport{
+		DockerAccountID: account.ID,
+		Year:            year,
+		Highlights:      string(highlights),
+	}
+	// A concurrent request may have stored the report first; serve whichever landed
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return loadWrapped(account.ID, year)
+	}
+	return wrapped, nil
+}
+
+func loadWrapped(accountID uint, year int) (*Wrapped, error) {
+	var report models.WrappedReport
+	err := database.DB.Where(&models.WrappedReport{DockerAccountID: accountID, Year: year}).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	var wrapped Wrapped
+	if err := json.Unmarshal([]byte(report.Highlights), &wrapped); err != nil {
+		return nil, err
+	}
+	return &wrapped, nil
+}
+
+// FreezeWrapped stores the year's report for every account that doesn't have one yet and
+// returns how many were stored
+func (s *WrappedService) FreezeWrapped(year int) (int, error) {
+	if config.IsReadOnly() {
+		return 0, ErrReadOnly
+	}
+
+	var accounts []models.DockerAccount
+	err := database.DB.Where("disconnect_scheduled_at IS NULL").
+		Where("user_id NOT IN (?)", UnavailableUserIDs()).
+		Where("id NOT IN (?)", database.DB.Model(&models.WrappedReport{}).Select("docker_account_id").Where("year = ?", year)).
+		Find(&accounts).Error
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for i := range accounts {
+		wrapped, err := s.getOrCreateWrapped(&accounts[i], year)
+		if err != nil {
+			slog.Error("Failed to store year in review", "docker_username", accounts[i].DockerUsername, "year", year, "error", err)
+			continue
+		}
+		if wrapped.Final {
+			stored++
+		}
+	}
+	return stored, nil
+}
+
+func (s *WrappedService) buildWrapped(account *models.DockerAccount, year int, now time.Time) (*Wrapped, error) {
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := start.AddDate(1, 0, 0)
+	end := next.AddDate(0, 0, -1)
+	if end.After(now) {
+		end = now
+	}
+
+	days, err := s.dockerService.GetActivitySummaryRange(account.DockerUsername, start, end, ActivityFilter{Location: time.UTC})
+	if err != nil {
+		return nil, err
+	}
+	stats := compareStats(account.DockerUsername, days)
+	wrapped := &Wrapped{
+		Username:        account.DockerUsername,
+		Year:            year,
+		Final:           !now.Before(next.Add(wrappedSettleTime)),
+		Total:           stats.Total,
+		Pushes:          stats.Pushes,
+		Pulls:           stats.Pulls,
+		Builds:          stats.Builds,
+		ActiveDays:      stats.ActiveDays,
+		BusiestDate:     stats.BusiestDate,
+		BusiestCount:    stats.BusiestCount,
+		LongestStreak:   stats.LongestStreak,
+		TopRepositories: []WrappedRepository{},
+		GeneratedAt:     now,
+	}
+	for _, day := range days {
+		if month, err := strconv.Atoi(day.Date[5:7]); err == nil && month >= 1 && month <= 12 {
+			wrapped.Months[month-1] += day.TotalCount
+		}
+	}
+
+	err = database.DB.Model(&models.ActivityEvent{}).
+		Select("repository, SUM(count) AS count").
+		Where("docker_account_id = ? AND event_date >= ? AND event_date < ?", account.ID, start, next).
+		Group("repository").
+		Order("count DESC").
+		Limit(wrappedTopRepositories).
+		Scan(&wrapped.TopRepositories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped.Percentile, err = wrappedPercentile(account.ID, wrapped.Total, start, next)
+	if err != nil {
+		return nil, err
+	}
+	return wrapped, nil
+}
+
+// wrappedPercentile returns the share of other accounts, rounded down, with less activity
+// than total between start and end (exclusive). Accounts without any activity count as
+// having none; with no other accounts it's 100.
+func wrappedPercentile(accountID uint, total int, start, end time.Time) (int, error) {
+	var others int64
+	err := database.DB.Model(&models.DockerAccount{}).
+		Where("id <> ? AND disconnect_scheduled_at IS NULL", accountID).
+		Where("user_id NOT IN (?)", UnavailableUserIDs()).
+		Count(&others).Error
+	if err != nil {
+		return 0, err
+	}
+	if others == 0 {
+		return 100, nil
+	}
+
+	var atLeast int64
+	err = database.DB.Table("(?) AS totals",
+		database.DB.Model(&models.DailyActivityAggregate{}).
+			Select("docker_account_id, SUM(count) AS total").
+			Where("docker_account_id <> ? AND event_date >= ? AND event_date < ?", accountID, start, end).
+			Where("docker_account_id IN (?)", database.DB.Model(&models.DockerAccount{}).Select("id").
+				Where("disconnect_scheduled_at IS NULL").
+				Where("user_id NOT IN (?)", UnavailableUserIDs())).
+			Group("docker_account_id")).
+		Where("total >= ?", total).
+		Count(&atLeast).Error
+	if err != nil {
+		return 0, err
+	}
+	// Accounts without activity don't appear in the totals
+	if total == 0 {
+		atLeast = others
+	}
+	return int((others - atLeast) * 100 / others), nil
+}
+
+// RenderWrappedPNG draws the report as a share image in the options' theme. Themes with a
+// transparent background get a solid one matching their empty-cell color, since link
+// previews don't show transparency well.
+func (s *WrappedService) RenderWrappedPNG(wrapped *Wrapped, opts SVGOptions) ([]byte, error) {
+	theme := resolveTheme(opts)
+	bg := theme.BgColor
+	if _, ok := parseColor(bg); !ok {
+		bg = "#ffffff"
+		if empty, ok := parseColor(theme.Colors[0]); !ok || relativeLuminance(empty) < 0.5 {
+			bg = "#0d1117"
+		}
+	}
+	text, accent := theme.TextColor, theme.Colors[len(theme.Colors)-1]
+
+	rc := newRasterCanvas(wrappedImageWidth, wrappedImageHeight, 1, opts.Font)
+	defer rc.close()
+	rc.fillRoundedRect(0, 0, wrappedImageWidth, wrappedImageHeight, 0, bg)
+
+	title := fmt.Sprintf("@%s's %d on Docker Hub", SanitizeText(wrapped.Username, 0), wrapped.Year)
+	if !wrapped.Final {
+		title += " so far"
+	}
+	subtitle := fmt.Sprintf("%s events • more active than %d%% of users", groupThousands(wrapped.Total), wrapped.Percentile)
+	if err := rc.drawText(60, 90, title, 40, text, true, false); err != nil {
+		return nil, err
+	}
+	if err := rc.drawText(60, 130, subtitle, 22, text, false, false); err != nil {
+		return nil, err
+	}
+
+	busiest := "-"
+	if day, err := time.Parse("2006-01-02", wrapped.BusiestDate); err == nil {
+		busiest = day.Format("Jan 2")
+	}
+	tiles := []struct{ value, label string }{
+		{groupThousands(wrapped.Pulls), "pulls"},
+		{groupThousands(wrapped.Pushes), "pushes"},
+		{groupThousands(wrapped.Builds), "builds"},
+		{groupThousands(wrapped.ActiveDays), "active days"},
+		{fmt.Sprintf("%d days", wrapped.LongestStreak), "longest streak"},
+		{busiest, fmt.Sprintf("busiest day • %s events", groupThousands(wrapped.BusiestCount))},
+	}
+	for i, tile := range tiles {
+		x, y := 60+(i%3)*370, 170+(i/3)*130
+		rc.fillRoundedRect(x, y, 340, 110, 12, theme.Colors[0])
+		if err := rc.drawText(x+24, y+58, tile.value, 38, accent, true, false); err != nil {
+			return nil, err
+		}
+		if err := rc.drawText(x+24, y+92, tile.label, 18, text, false, false); err != nil {
+			return nil, err
+		}
+	}
+
+	busiestMonth := 0
+	for _, count := range wrapped.Months {
+		if count > busiestMonth {
+			busiestMonth = count
+		}
+	}
+	for i, count := range wrapped.Months {
+		x := 60 + i*44
+		height := 4
+		if busiestMonth > 0 {
+			height = max(height, count*120/busiestMonth)
+		}
+		level := calculateLevel(float64(count), float64(busiestMonth))
+		rc.fillRoundedRect(x, 580-height, 32, height, 4, theme.Colors[level])
+		if err := rc.drawText(x+10, 605, "JFMAMJJASOND"[i:i+1], 14, text, false, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rc.drawText(640, 460, "Top repositories", 20, text, true, false); err != nil {
+		return nil, err
+	}
+	for i, repo := range wrapped.TopRepositories {
+		y := 492 + i*26
+		name := fmt.Sprintf("%d. %s", i+1, SanitizeText(repo.Repository, 36))
+		if err := rc.drawText(640, y, name, 18, text, false, false); err != nil {
+			return nil, err
+		}
+		if err := rc.drawText(1140, y, groupThousands(repo.Count), 18, accent, true, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if attribution := s.dockerService.AttributionFor(wrapped.Username); attribution != "" {
+		if err := rc.drawText(wrappedImageWidth-6, wrappedImageHeight-6, attribution, 12, "#8b949e", false, true); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rc.img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// groupThousands formats n with comma thousands separators
+func groupThousands(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b bytes.Buffer
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"docker-heatmap/internal/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Client is nil when STANDBY_S3_BUCKET is not set, which disables the warm standby
+var Client *minio.Client
+
+// Bucket is the bucket standby objects are written to
+var Bucket string
+
+func Connect() error {
+	cfg := config.AppConfig
+	if cfg.StandbyS3Bucket == "" {
+		slog.Info("STANDBY_S3_BUCKET not set, warm standby disabled")
+		return nil
+	}
+
+	client, err := minio.New(cfg.StandbyS3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.StandbyS3AccessKey, cfg.StandbyS3SecretKey, ""),
+		Secure: cfg.StandbyS3UseSSL,
+		Region: cfg.StandbyS3Region,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, cfg.StandbyS3Bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %q does not exist", cfg.StandbyS3Bucket)
+	}
+
+	Client = client
+	Bucket = cfg.StandbyS3Bucket
+	slog.Info("Standby storage connected")
+	return nil
+}
+
+// Enabled reports whether standby storage is available
+func Enabled() bool {
+	return Client != nil
+}
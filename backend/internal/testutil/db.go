@@ -0,0 +1,86 @@
+// Package testutil sets up the globals the services read, for tests
+package testutil
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"docker-heatmap/internal/config"
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var loadConfig sync.Once
+
+// testEncryptionKey stands in for ENCRYPTION_KEY, whose default is not a usable key
+const testEncryptionKey = "docker-heatmap-test-key-32-bytes"
+
+// LoadConfig loads the configuration from its defaults and the environment, once
+func LoadConfig() {
+	loadConfig.Do(func() {
+		config.Load()
+		if len(config.AppConfig.EncryptionKey) != 32 {
+			config.AppConfig.EncryptionKey = testEncryptionKey
+		}
+	})
+}
+
+// OpenDB points database.DB at an empty in-memory database with every model's table, and
+// restores the previous database when the test ends
+func OpenDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	LoadConfig()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=foreign_keys(0)", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection, so every query sees the same in-memory database
+	sqlDB.SetMaxOpenConns(1)
+
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.UserIdentity{},
+		&models.Session{},
+		&models.EmailToken{},
+		&models.EmbedToken{},
+		&models.DockerAccount{},
+		&models.DockerRepository{},
+		&models.NamespaceClaim{},
+		&models.ActivityEvent{},
+		&models.DailyActivityAggregate{},
+		&models.ActivityArchive{},
+		&models.IngestReceipt{},
+		&models.SyncRun{},
+		&models.SyncJob{},
+		&models.DailyViewCount{},
+		&models.DailyRequestCount{},
+		&models.HeatmapSnapshot{},
+		&models.NotificationChannel{},
+		&models.NotificationDelivery{},
+		&models.Achievement{},
+		&models.WrappedReport{},
+		&models.AuditLog{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		sqlDB.Close()
+	})
+	return db
+}
@@ -0,0 +1,172 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// DockerHub is a fake Docker Hub API serving the users added to it. Point
+// services.NewDockerHubAPI at URL.
+type DockerHub struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	users map[string]*dockerHubUser
+}
+
+type dockerHubUser struct {
+	pat   string
+	repos []DockerHubRepo
+}
+
+// DockerHubRepo is a repository the fake serves, with its tags
+type DockerHubRepo struct {
+	Name        string
+	LastUpdated string
+	PullCount   int64
+	Tags        []DockerHubTag
+}
+
+// DockerHubTag is a tag the fake serves
+type DockerHubTag struct {
+	Name       string
+	LastPushed string
+}
+
+// NewDockerHub starts a fake Docker Hub, stopped when the test ends
+func NewDockerHub(t testing.TB) *DockerHub {
+	t.Helper()
+
+	hub := &DockerHub{users: make(map[string]*dockerHubUser)}
+	hub.Server = httptest.NewServer(http.HandlerFunc(hub.serve))
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+// AddUser adds a user who logs in with pat and owns repos
+func (h *DockerHub) AddUser(username, pat string, repos ...DockerHubRepo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.users[username] = &dockerHubUser{pat: pat, repos: repos}
+}
+
+// Token is the JWT the fake hands username at login
+func (h *DockerHub) Token(username string) string {
+	return "jwt-" + username
+}
+
+// serve routes the endpoints services.DockerHubAPI calls
+func (h *DockerHub) serve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/users/login":
+		h.login(w, r)
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "users":
+		h.user(w, r, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "repositories":
+		h.repositories(w, r, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "repositories" && parts[3] == "tags":
+		h.tags(w, r, parts[1], parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *DockerHub) lookup(username string) *dockerHubUser {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.users[username]
+}
+
+func (h *DockerHub) login(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	user := h.lookup(body.Username)
+	if user == nil || user.pat != body.Password {
+		http.Error(w, `{"detail":"Incorrect authentication credentials"}`, http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]string{"token": h.Token(body.Username)})
+}
+
+func (h *DockerHub) user(w http.ResponseWriter, r *http.Request, username string) {
+	if h.lookup(username) == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]string{"username": username})
+}
+
+// authorized reports whether the request may list username's repositories: anonymously,
+// or with the token username was given
+func (h *DockerHub) authorized(r *http.Request, username string) bool {
+	auth := r.Header.Get("Authorization")
+	return auth == "" || auth == "JWT "+h.Token(username)
+}
+
+func (h *DockerHub) repositories(w http.ResponseWriter, r *http.Request, username string) {
+	user := h.lookup(username)
+	if user == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.authorized(r, username) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	results := make([]map[string]any, 0, len(user.repos))
+	for _, repo := range user.repos {
+		results = append(results, map[string]any{
+			"name":         repo.Name,
+			"namespace":    username,
+			"last_updated": repo.LastUpdated,
+			"pull_count":   repo.PullCount,
+		})
+	}
+	writeJSON(w, map[string]any{"count": len(results), "results": results})
+}
+
+func (h *DockerHub) tags(w http.ResponseWriter, r *http.Request, username, name string) {
+	user := h.lookup(username)
+	if user == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.authorized(r, username) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	for _, repo := range user.repos {
+		if !strings.EqualFold(repo.Name, name) {
+			continue
+		}
+		results := make([]map[string]any, 0, len(repo.Tags))
+		for _, tag := range repo.Tags {
+			results = append(results, map[string]any{
+				"name":            tag.Name,
+				"last_updated":    tag.LastPushed,
+				"tag_last_pushed": tag.LastPushed,
+			})
+		}
+		writeJSON(w, map[string]any{"count": len(results), "results": results})
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,28 @@
+package testutil
+
+import (
+	"testing"
+
+	"docker-heatmap/internal/database"
+	"docker-heatmap/internal/models"
+)
+
+// CreateAccount adds a user with a connected Docker account named dockerUsername
+func CreateAccount(t testing.TB, dockerUsername string, public bool) *models.DockerAccount {
+	t.Helper()
+
+	user := models.User{Provider: "password", GitHubUsername: dockerUsername}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Create skips a false PublicProfile in favor of the column default
+	if err := database.DB.Model(&user).Update("public_profile", public).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	account := models.DockerAccount{UserID: user.ID, DockerUsername: dockerUsername, IsActive: true}
+	if err := database.DB.Create(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &account
+}
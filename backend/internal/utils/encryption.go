@@ -1,14 +1,13 @@
 package utils
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
 	"io"
-
-	"docker-heatmap/internal/config"
+	"strings"
+	"time"
 )
 
 var (
@@ -17,21 +16,24 @@ var (
 	ErrInvalidIV     = errors.New("invalid initialization vector")
 )
 
-// Encrypt encrypts plaintext using AES-256-GCM (Industry Standard)
-// Returns the base64-encoded ciphertext and IV (nonce)
+// keyTimeout bounds wrapping and unwrapping a data key, which may call Vault
+const keyTimeout = 10 * time.Second
+
+// Encrypt encrypts plaintext using AES-256-GCM (Industry Standard) with a new random data
+// key, which is wrapped with the primary encryption key. Returns the ciphertext as
+// "<key id>.<wrapped data key>.<base64 ciphertext>" and the base64 IV (nonce).
 func Encrypt(plaintext string) (ciphertext, iv string, err error) {
-	key := []byte(config.AppConfig.EncryptionKey)
-	if len(key) != 32 {
-		return "", "", ErrInvalidKey
+	if err := LoadKeys(); err != nil {
+		return "", "", err
 	}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
 		return "", "", err
 	}
 
 	// GCM provides both confidentiality and authenticity (AEAD)
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(dataKey)
 	if err != nil {
 		return "", "", err
 	}
@@ -45,20 +47,23 @@ func Encrypt(plaintext string) (ciphertext, iv string, err error) {
 	// Seal handles the encryption and appends an authentication tag
 	encrypted := gcm.Seal(nil, nonce, []byte(plaintext), nil)
 
-	return base64.StdEncoding.EncodeToString(encrypted),
+	ctx, cancel := context.WithTimeout(context.Background(), keyTimeout)
+	defer cancel()
+	wrapped, err := loadedKeys.keys[loadedKeys.primaryID].Wrap(ctx, dataKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	return loadedKeys.primaryID + "." + wrapped + "." + base64.StdEncoding.EncodeToString(encrypted),
 		base64.StdEncoding.EncodeToString(nonce),
 		nil
 }
 
-// Decrypt decrypts base64-encoded ciphertext using AES-256-GCM
+// Decrypt decrypts ciphertext from Encrypt with whichever configured key wrapped its data
+// key. Ciphertext stored before key IDs is plain base64, encrypted directly with
+// ENCRYPTION_KEY.
 func Decrypt(ciphertext, iv string) (string, error) {
-	key := []byte(config.AppConfig.EncryptionKey)
-	if len(key) != 32 {
-		return "", ErrInvalidKey
-	}
-
-	encryptedData, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
+	if err := LoadKeys(); err != nil {
 		return "", err
 	}
 
@@ -67,12 +72,33 @@ func Decrypt(ciphertext, iv string) (string, error) {
 		return "", ErrInvalidIV
 	}
 
-	block, err := aes.NewCipher(key)
+	var dataKey []byte
+	id, wrapped, encoded := LegacyKeyID, "", ciphertext
+	if parts := strings.SplitN(ciphertext, ".", 3); len(parts) == 3 {
+		id, wrapped, encoded = parts[0], parts[1], parts[2]
+		key, ok := loadedKeys.keys[id]
+		if !ok {
+			return "", ErrUnknownKey
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), keyTimeout)
+		defer cancel()
+		if dataKey, err = key.Unwrap(ctx, wrapped); err != nil {
+			return "", err
+		}
+	} else {
+		key, ok := loadedKeys.keys[LegacyKeyID].(*localKey)
+		if !ok {
+			return "", ErrUnknownKey
+		}
+		dataKey = key.key
+	}
+
+	encryptedData, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
 		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(dataKey)
 	if err != nil {
 		return "", err
 	}
@@ -86,6 +112,15 @@ func Decrypt(ciphertext, iv string) (string, error) {
 	return string(plaintext), nil
 }
 
+// CiphertextKeyID returns the id of the key that wrapped a ciphertext's data key, or
+// LegacyKeyID for ciphertext stored before key IDs
+func CiphertextKeyID(ciphertext string) string {
+	if parts := strings.SplitN(ciphertext, ".", 3); len(parts) == 3 {
+		return parts[0]
+	}
+	return LegacyKeyID
+}
+
 // GenerateRandomString generates a cryptographically secure random string
 func GenerateRandomString(length int) (string, error) {
 	bytes := make([]byte, length)
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"docker-heatmap/internal/config"
+)
+
+// LegacyKeyID names ENCRYPTION_KEY, which also decrypts secrets stored before key IDs
+const LegacyKeyID = "default"
+
+var (
+	ErrUnknownKey    = errors.New("secret is encrypted with a key that isn't configured")
+	ErrNoPrimaryKey  = errors.New("the encryption key set by ENCRYPTION_KEY_ID isn't configured")
+	ErrInvalidKeyID  = errors.New("key ids may only contain letters, digits, '-' and '_'")
+	ErrVaultResponse = errors.New("unexpected response from Vault")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// KeyWrapper is a key-encryption key, which wraps the random data key each secret is
+// encrypted with. Only wrapped data keys are stored, so a key held outside the app, as
+// Vault's are, never leaves its service.
+type KeyWrapper interface {
+	Wrap(ctx context.Context, dataKey []byte) (string, error)
+	Unwrap(ctx context.Context, wrapped string) ([]byte, error)
+}
+
+// keyring is every configured key by id and the id of the one that wraps new data keys
+type keyring struct {
+	keys      map[string]KeyWrapper
+	primaryID string
+}
+
+var (
+	loadKeysOnce sync.Once
+	loadedKeys   *keyring
+	loadKeysErr  error
+)
+
+// LoadKeys reads the encryption keys from config on first use. The server calls it at
+// startup so a bad key fails fast instead of on the first secret.
+func LoadKeys() error {
+	loadKeysOnce.Do(func() {
+		loadedKeys, loadKeysErr = buildKeyring()
+	})
+	return loadKeysErr
+}
+
+// PrimaryKeyID is the id of the key new secrets are encrypted with
+func PrimaryKeyID() (string, error) {
+	if err := LoadKeys(); err != nil {
+		return "", err
+	}
+	return loadedKeys.primaryID, nil
+}
+
+func buildKeyring() (*keyring, error) {
+	kr := &keyring{keys: map[string]KeyWrapper{}}
+
+	if key := config.AppConfig.EncryptionKey; len(key) == 32 {
+		kr.keys[LegacyKeyID] = &localKey{id: LegacyKeyID, key: []byte(key)}
+	}
+
+	var pairs []string
+	if config.AppConfig.EncryptionKeys != "" {
+		pairs = append(pairs, strings.Split(config.AppConfig.EncryptionKeys, ",")...)
+	}
+	if path := config.AppConfig.EncryptionKeysFile; path != "" {
+		lines, err := readKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading ENCRYPTION_KEYS_FILE: %w", err)
+		}
+		pairs = append(pairs, lines...)
+	}
+	for _, pair := range pairs {
+		id, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("encryption keys must be id=key pairs, got %q", pair)
+		}
+		id = strings.TrimSpace(id)
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyID, id)
+		}
+		key, err := parseKey(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		kr.keys[id] = &localKey{id: id, key: key}
+	}
+
+	if config.AppConfig.VaultAddr != "" {
+		kr.keys["vault"] = &vaultKey{
+			addr:  config.AppConfig.VaultAddr,
+			token: config.AppConfig.VaultToken,
+			name:  config.AppConfig.VaultTransitKey,
+		}
+	}
+
+	kr.primaryID = config.AppConfig.EncryptionKeyID
+	if kr.primaryID == "" {
+		kr.primaryID = LegacyKeyID
+		if config.AppConfig.VaultAddr != "" {
+			kr.primaryID = "vault"
+		}
+	}
+	if _, ok := kr.keys[kr.primaryID]; !ok {
+		if kr.primaryID == LegacyKeyID {
+			return nil, ErrInvalidKey
+		}
+		return nil, ErrNoPrimaryKey
+	}
+	return kr, nil
+}
+
+// readKeyFile returns the id=key lines of a key file, skipping blanks and # comments
+func readKeyFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseKey accepts 32 raw bytes, like ENCRYPTION_KEY, or 32 bytes in base64 as printed by
+// openssl rand -base64 32
+func parseKey(value string) ([]byte, error) {
+	if len(value) == 32 {
+		return []byte(value), nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// localKey is a key from the environment or a key file
+type localKey struct {
+	id  string
+	key []byte
+}
+
+// Wrap seals the data key with the key id as associated data, so a wrapped key can't be
+// passed off as another key's
+func (k *localKey) Wrap(ctx context.Context, dataKey []byte) (string, error) {
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, dataKey, []byte(k.id))), nil
+}
+
+func (k *localKey) Unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrDecryptFailed
+	}
+	dataKey, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(k.id))
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return dataKey, nil
+}
+
+// vaultKey wraps data keys with a HashiCorp Vault transit key, so the key-encryption key
+// never leaves Vault. Vault rotates the transit key itself and still unwraps data keys
+// wrapped with older versions.
+type vaultKey struct {
+	addr  string
+	token string
+	name  string
+}
+
+func (k *vaultKey) Wrap(ctx context.Context, dataKey []byte) (string, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err := k.call(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(dataKey),
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.Data.Ciphertext == "" {
+		return "", ErrVaultResponse
+	}
+	return resp.Data.Ciphertext, nil
+}
+
+func (k *vaultKey) Unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := k.call(ctx, "decrypt", map[string]string{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, err
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, ErrVaultResponse
+	}
+	return dataKey, nil
+}
+
+// call posts to the transit engine's encrypt or decrypt endpoint for the key
+func (k *vaultKey) call(ctx context.Context, op string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/v1/transit/%s/%s", k.addr, op, k.name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", k.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ShortTimeoutClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: transit %s returned %d", ErrVaultResponse, op, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
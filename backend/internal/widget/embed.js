@@ -0,0 +1,281 @@
+/*!
+ * Docker Heatmap embeddable widget
+ *
+ * Renders an interactive activity heatmap into every element carrying a
+ * data-docker-heatmap attribute:
+ *
+ *   <div data-docker-heatmap="username" data-theme="github" data-days="365"></div>
+ *   <script src="https://example.com/embed.js" async></script>
+ *
+ * Optional attributes: data-theme, data-days (30-365), data-tz (IANA name),
+ * data-week-start ("monday"), data-theme-switcher ("false" hides it).
+ * Elements added later can be rendered with DockerHeatmap.render(element).
+ */
+(function () {
+  "use strict";
+
+  var CONFIG = __DOCKER_HEATMAP_CONFIG__;
+
+  var script = document.currentScript;
+  var origin = script && script.src ? new URL(script.src).origin : window.location.origin;
+  var apiBase = origin + CONFIG.apiPrefix;
+
+  var CELL = 11;
+  var GAP = 3;
+  var LABEL_WIDTH = 28;
+  var HEADER_HEIGHT = 16;
+  var SVG_NS = "http://www.w3.org/2000/svg";
+  var MONTHS = ["Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"];
+
+  function el(tag, attrs, text) {
+    var node = tag.indexOf("svg:") === 0
+      ? document.createElementNS(SVG_NS, tag.slice(4))
+      : document.createElement(tag);
+    for (var key in attrs) {
+      if (Object.prototype.hasOwnProperty.call(attrs, key)) {
+        node.setAttribute(key, attrs[key]);
+      }
+    }
+    if (text !== undefined) {
+      node.textContent = text;
+    }
+    return node;
+  }
+
+  function parseDate(value) {
+    var parts = value.split("-");
+    return new Date(Date.UTC(+parts[0], +parts[1] - 1, +parts[2]));
+  }
+
+  function plural(count, one, many) {
+    return count + " " + (count === 1 ? one : many);
+  }
+
+  function describe(day) {
+    var date = parseDate(day.date);
+    var label = MONTHS[date.getUTCMonth()] + " " + date.getUTCDate() + ", " + date.getUTCFullYear();
+    if (!day.count) {
+      return "No activity on " + label;
+    }
+    var parts = [];
+    if (day.pushes) parts.push(plural(day.pushes, "push", "pushes"));
+    if (day.pulls) parts.push(plural(day.pulls, "pull", "pulls"));
+    if (day.builds) parts.push(plural(day.builds, "build", "builds"));
+    return plural(day.count, "activity", "activities") +
+      " on " + label + (parts.length ? " (" + parts.join(", ") + ")" : "");
+  }
+
+  function theme(id) {
+    return CONFIG.themes[id] || CONFIG.themes[CONFIG.defaultTheme];
+  }
+
+  // paint applies a theme to a rendered widget without re-fetching its activity
+  function paint(widget, id) {
+    var t = theme(id);
+    widget.root.style.background = t.bg_color === "transparent" ? "transparent" : t.bg_color;
+    widget.root.style.color = t.text_color;
+    widget.root.style.borderColor = t.border_color || "rgba(128, 128, 128, 0.3)";
+    for (var i = 0; i < widget.cells.length; i++) {
+      var cell = widget.cells[i];
+      cell.node.setAttribute("fill", t.colors[cell.level] || t.colors[0]);
+    }
+    var swatches = widget.legend;
+    for (var j = 0; j < swatches.length; j++) {
+      swatches[j].setAttribute("fill", t.colors[j]);
+    }
+  }
+
+  function draw(container, data, opts) {
+    var days = data.activity || [];
+    var weekStart = opts.weekStart === "monday" ? 1 : 0;
+    var first = days.length ? parseDate(days[0].date) : new Date();
+    var offset = (first.getUTCDay() - weekStart + 7) % 7;
+    var weeks = Math.ceil((days.length + offset) / 7);
+
+    var width = LABEL_WIDTH + weeks * (CELL + GAP);
+    var height = HEADER_HEIGHT + 7 * (CELL + GAP);
+
+    var root = el("div", { "class": "docker-heatmap-widget" });
+    root.style.cssText = "position:relative;display:inline-block;max-width:100%;box-sizing:border-box;" +
+      "padding:12px;border:1px solid;border-radius:8px;font:12px -apple-system,BlinkMacSystemFont," +
+      "'Segoe UI',Helvetica,Arial,sans-serif;line-height:1.4;";
+
+    var header = el("div");
+    header.style.cssText = "display:flex;align-items:center;justify-content:space-between;gap:12px;margin-bottom:8px;";
+    var title = el("a", { href: CONFIG.profileURL + encodeURIComponent(data.username), target: "_blank", rel: "noopener" },
+      plural(data.totals.activities, "activity", "activities") +
+      " in the last " + data.days + " days");
+    title.style.cssText = "color:inherit;text-decoration:none;font-weight:600;";
+    header.appendChild(title);
+
+    var select = null;
+    if (opts.themeSwitcher) {
+      select = el("select", { "aria-label": "Heatmap theme" });
+      select.style.cssText = "font:inherit;color:#24292f;background:#fff;border:1px solid #d0d7de;border-radius:4px;padding:1px 4px;";
+      for (var t = 0; t < CONFIG.order.length; t++) {
+        var id = CONFIG.order[t];
+        var option = el("option", { value: id }, CONFIG.themes[id].name);
+        if (id === opts.theme) option.selected = true;
+        select.appendChild(option);
+      }
+      header.appendChild(select);
+    }
+    root.appendChild(header);
+
+    var scroller = el("div");
+    scroller.style.cssText = "overflow-x:auto;";
+    var svg = el("svg:svg", {
+      width: width,
+      height: height,
+      viewBox: "0 0 " + width + " " + height,
+      role: "img",
+      "aria-label": "Docker Hub activity heatmap for " + data.username
+    });
+    svg.style.display = "block";
+
+    var dayNames = weekStart === 1 ? ["Mon", "", "Wed", "", "Fri", "", ""] : ["", "Mon", "", "Wed", "", "Fri", ""];
+    for (var r = 0; r < 7; r++) {
+      if (!dayNames[r]) continue;
+      svg.appendChild(el("svg:text", {
+        x: 0, y: HEADER_HEIGHT + r * (CELL + GAP) + CELL - 2, "font-size": 9, fill: "currentColor"
+      }, dayNames[r]));
+    }
+
+    var widget = { root: root, cells: [], legend: [], tooltip: null };
+    var lastMonth = -1;
+    for (var i = 0; i < days.length; i++) {
+      var slot = i + offset;
+      var col = Math.floor(slot / 7);
+      var row = slot % 7;
+      var date = parseDate(days[i].date);
+      if (row === 0 || i === 0) {
+        var month = date.getUTCMonth();
+        if (month !== lastMonth && col < weeks - 1) {
+          svg.appendChild(el("svg:text", {
+            x: LABEL_WIDTH + col * (CELL + GAP), y: 10, "font-size": 9, fill: "currentColor"
+          }, MONTHS[month]));
+          lastMonth = month;
+        }
+      }
+      var rect = el("svg:rect", {
+        x: LABEL_WIDTH + col * (CELL + GAP),
+        y: HEADER_HEIGHT + row * (CELL + GAP),
+        width: CELL,
+        height: CELL,
+        rx: 2,
+        ry: 2,
+        "data-index": i
+      });
+      rect.style.cursor = "pointer";
+      svg.appendChild(rect);
+      widget.cells.push({ node: rect, level: days[i].level || 0 });
+    }
+    scroller.appendChild(svg);
+    root.appendChild(scroller);
+
+    var footer = el("div");
+    footer.style.cssText = "display:flex;align-items:center;justify-content:flex-end;gap:3px;margin-top:6px;font-size:10px;opacity:.85;";
+    footer.appendChild(el("span", {}, "Less"));
+    var legend = el("svg:svg", { width: 5 * (CELL + GAP), height: CELL });
+    for (var l = 0; l < 5; l++) {
+      var swatch = el("svg:rect", { x: l * (CELL + GAP), y: 0, width: CELL, height: CELL, rx: 2, ry: 2 });
+      legend.appendChild(swatch);
+      widget.legend.push(swatch);
+    }
+    footer.appendChild(legend);
+    footer.appendChild(el("span", {}, "More"));
+    root.appendChild(footer);
+
+    var tooltip = el("div", { role: "tooltip" });
+    tooltip.style.cssText = "position:absolute;z-index:10;display:none;pointer-events:none;white-space:nowrap;" +
+      "padding:4px 8px;border-radius:4px;font-size:11px;background:#24292f;color:#fff;";
+    root.appendChild(tooltip);
+    widget.tooltip = tooltip;
+
+    svg.addEventListener("mouseover", function (event) {
+      var index = event.target.getAttribute && event.target.getAttribute("data-index");
+      if (index === null || index === undefined) return;
+      tooltip.textContent = describe(days[+index]);
+      tooltip.style.display = "block";
+      var box = event.target.getBoundingClientRect();
+      var parent = root.getBoundingClientRect();
+      var left = box.left - parent.left + CELL / 2 - tooltip.offsetWidth / 2;
+      tooltip.style.left = Math.max(0, Math.min(left, parent.width - tooltip.offsetWidth)) + "px";
+      tooltip.style.top = (box.top - parent.top - tooltip.offsetHeight - 6) + "px";
+    });
+    svg.addEventListener("mouseout", function () {
+      tooltip.style.display = "none";
+    });
+
+    if (select) {
+      select.addEventListener("change", function () {
+        paint(widget, select.value);
+      });
+    }
+
+    paint(widget, opts.theme);
+    container.textContent = "";
+    container.appendChild(root);
+  }
+
+  function fail(container, message) {
+    container.textContent = "";
+    var note = el("span", {}, message);
+    note.style.cssText = "font:12px sans-serif;color:#57606a;";
+    container.appendChild(note);
+  }
+
+  function render(container, options) {
+    if (typeof container === "string") {
+      container = document.querySelector(container);
+    }
+    if (!container) return;
+    options = options || {};
+
+    var ds = container.dataset || {};
+    var username = options.username || ds.dockerHeatmap;
+    if (!username) return;
+
+    var opts = {
+      theme: options.theme || ds.theme || CONFIG.defaultTheme,
+      days: options.days || ds.days,
+      tz: options.tz || ds.tz,
+      weekStart: options.weekStart || ds.weekStart,
+      themeSwitcher: options.themeSwitcher !== undefined ? options.themeSwitcher : ds.themeSwitcher !== "false"
+    };
+    if (!CONFIG.themes[opts.theme]) opts.theme = CONFIG.defaultTheme;
+
+    var query = [];
+    if (opts.days) query.push("days=" + encodeURIComponent(opts.days));
+    if (opts.tz) query.push("tz=" + encodeURIComponent(opts.tz));
+    var url = apiBase + "/activity/" + encodeURIComponent(username) + ".json" + (query.length ? "?" + query.join("&") : "");
+
+    container.setAttribute("data-docker-heatmap-rendered", "");
+    fetch(url, { headers: { Accept: "application/json" } })
+      .then(function (res) {
+        if (!res.ok) throw new Error(res.status === 404 ? "No Docker Hub activity for " + username : "Failed to load activity");
+        return res.json();
+      })
+      .then(function (data) {
+        draw(container, data, opts);
+      })
+      .catch(function (err) {
+        fail(container, err.message);
+      });
+  }
+
+  function renderAll() {
+    var targets = document.querySelectorAll("[data-docker-heatmap]:not([data-docker-heatmap-rendered])");
+    for (var i = 0; i < targets.length; i++) {
+      render(targets[i]);
+    }
+  }
+
+  window.DockerHeatmap = { render: render, renderAll: renderAll, themes: CONFIG.order };
+
+  if (document.readyState === "loading") {
+    document.addEventListener("DOMContentLoaded", renderAll);
+  } else {
+    renderAll();
+  }
+})();
@@ -0,0 +1,43 @@
+// Package widget holds the embeddable script that renders an interactive heatmap on other
+// sites from the public activity JSON. The script is plain JavaScript with no build step.
+package widget
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+)
+
+//go:embed embed.js
+var source []byte
+
+// configPlaceholder is replaced with the JSON-encoded Config when the script is built
+var configPlaceholder = []byte("__DOCKER_HEATMAP_CONFIG__")
+
+// Theme is a built-in theme's palette as the script applies it
+type Theme struct {
+	Name        string   `json:"name"`
+	BgColor     string   `json:"bg_color"`
+	TextColor   string   `json:"text_color"`
+	Colors      []string `json:"colors"`
+	BorderColor string   `json:"border_color"`
+}
+
+// Config is what the script needs from the server. It fetches activity from APIPrefix
+// on the origin it was loaded from.
+type Config struct {
+	APIPrefix    string           `json:"apiPrefix"`
+	ProfileURL   string           `json:"profileURL"` // Username is appended
+	DefaultTheme string           `json:"defaultTheme"`
+	Order        []string         `json:"order"`
+	Themes       map[string]Theme `json:"themes"`
+}
+
+// Script returns the widget with cfg built in
+func Script(cfg Config) ([]byte, error) {
+	payload, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Replace(source, configPlaceholder, payload, 1), nil
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"docker-heatmap/internal/reporting"
+
+	"github.com/robfig/cron/v3"
+)
+
+// errJobSkipped is returned by a job that had nothing to do because the deployment is in
+// read-only mode
+var errJobSkipped = errors.New("skipped: read-only mode")
+
+// Results of a cron job run
+const (
+	JobResultOK      = "ok"
+	JobResultFailed  = "failed"
+	JobResultSkipped = "skipped"
+)
+
+// JobStatus is what this instance's worker knows about one of its cron jobs
+type JobStatus struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	Running   bool       `json:"running"`
+	Runs      int        `json:"runs"`     // since this instance started
+	Failures  int        `json:"failures"` // since this instance started
+
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMS int64      `json:"last_duration_ms"`
+	LastResult     string     `json:"last_result,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// cronJob is a job added to the worker's cron, with the outcome of its runs
+type cronJob struct {
+	entryID cron.EntryID
+	run     func() error
+
+	mu     sync.Mutex
+	status JobStatus
+}
+
+// Run runs the job and records how it went
+func (j *cronJob) Run() {
+	started := time.Now()
+	j.mu.Lock()
+	j.status.Running = true
+	j.mu.Unlock()
+
+	err := j.run()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Running = false
+	j.status.Runs++
+	j.status.LastRunAt = &started
+	j.status.LastDurationMS = time.Since(started).Milliseconds()
+	j.status.LastError = ""
+	switch {
+	case err == nil:
+		j.status.LastResult = JobResultOK
+	case errors.Is(err, errJobSkipped):
+		j.status.LastResult = JobResultSkipped
+	default:
+		j.status.Failures++
+		j.status.LastResult = JobResultFailed
+		j.status.LastError = err.Error()
+		slog.Error("Cron job failed", "job", j.status.Name, "error", err)
+		reporting.Capture(reporting.Event{Err: err, Tags: map[string]string{"cron_job": j.status.Name}})
+	}
+}
+
+// addJob adds a named job to the worker's cron on spec. wrappers go around the recording,
+// so a run they skip, such as with cron.SkipIfStillRunning, isn't counted.
+func (w *SyncWorker) addJob(name, spec string, run func() error, wrappers ...cron.JobWrapper) {
+	job := &cronJob{run: run, status: JobStatus{Name: name, Schedule: spec}}
+	id, err := w.cron.AddJob(spec, cron.NewChain(wrappers...).Then(job))
+	if err != nil {
+		slog.Error("Failed to add cron job", "job", name, "error", err)
+		return
+	}
+	job.entryID = id
+	w.jobs = append(w.jobs, job)
+}
+
+// Jobs returns the status of the worker's cron jobs on this instance, by name
+func (w *SyncWorker) Jobs() []JobStatus {
+	statuses := make([]JobStatus, 0, len(w.jobs))
+	for _, job := range w.jobs {
+		job.mu.Lock()
+		status := job.status
+		job.mu.Unlock()
+
+		if next := w.cron.Entry(job.entryID).Next; !next.IsZero() {
+			status.NextRunAt = &next
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
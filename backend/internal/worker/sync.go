@@ -2,117 +2,402 @@ package worker
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
+	"docker-heatmap/internal/config"
 	"docker-heatmap/internal/database"
 	"docker-heatmap/internal/models"
 	"docker-heatmap/internal/services"
 
 	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
 )
 
+// syncJobPollInterval is how often the worker checks the queue for sync jobs queued by
+// other instances or due for a retry
+const syncJobPollInterval = 10 * time.Second
+
+// syncPriorityDays is the window of embed traffic that orders the scheduled syncs
+const syncPriorityDays = 7
+
+// scheduledSyncSpacing staggers the scheduled syncs of the accounts, to stay clear of
+// Docker Hub's rate limits
+const scheduledSyncSpacing = 2 * time.Second
+
 type SyncWorker struct {
-	cron          *cron.Cron
-	dockerService *services.DockerHubService
+	cron                *cron.Cron
+	dockerService       *services.DockerHubService
+	notificationService *services.NotificationService
+	exportService       *services.ExportService
+	digestService       *services.DigestService
+	wrappedService      *services.WrappedService
+	jobs                []*cronJob
+
+	stopJobs context.CancelFunc
+	jobsDone chan struct{}
 }
 
-func NewSyncWorker() *SyncWorker {
+func NewSyncWorker(svc *services.Services) *SyncWorker {
 	return &SyncWorker{
-		cron:          cron.New(),
-		dockerService: services.NewDockerHubService(),
+		cron:                cron.New(),
+		dockerService:       svc.Docker,
+		notificationService: svc.Notifications,
+		exportService:       svc.Export,
+		digestService:       svc.Digest,
+		wrappedService:      svc.Wrapped,
 	}
 }
 
 // Start begins the background sync worker
 func (w *SyncWorker) Start() {
-	log.Println("Starting sync worker...")
+	slog.Info("Starting sync worker")
 
 	// Run cleanup daily at midnight
-	if _, err := w.cron.AddFunc("0 0 * * *", w.cleanupOldData); err != nil {
-		log.Printf("Failed to add cleanup cron job: %v", err)
-	}
+	w.addJob("cleanup", "0 0 * * *", w.cleanupOldData)
 
-	// Run scheduled sync for all accounts every 6 hours
-	if _, err := w.cron.AddFunc("0 */6 * * *", w.syncAllAccounts); err != nil {
-		log.Printf("Failed to add scheduled sync cron job: %v", err)
-	}
+	// Run scheduled sync for all accounts (SYNC_CRON, every 6 hours by default)
+	syncSpec, _ := services.SyncSchedule()
+	w.addJob("scheduled_sync", syncSpec, w.syncAllAccounts)
+
+	// Sync accounts with their own interval as they come due
+	w.addJob("account_sync", services.AccountSyncCheckSpec, w.syncDueAccounts)
+
+	// Purge accounts whose disconnect grace period has ended
+	w.addJob("disconnect_purge", "@hourly", w.purgeDisconnectedAccounts)
+
+	// Delete users whose account deletion grace period has ended
+	w.addJob("account_deletion", "@hourly", w.purgeDeletedUsers)
+
+	// Forget ingest deliveries too old to be replayed
+	w.addJob("ingest_delivery_purge", "@hourly", w.purgeIngestDeliveries)
+
+	// Write buffered view and request counts every minute
+	w.addJob("metrics_flush", "@every 1m", flushMetrics)
+
+	// Deliver queued notifications every minute; a slow run must not overlap the next one
+	w.addJob("notification_delivery", "@every 1m", w.deliverNotifications, cron.SkipIfStillRunning(cron.DefaultLogger))
+
+	// Build data exports a restart cut off, and delete expired ones; new exports start
+	// building as soon as they're requested
+	w.addJob("data_exports", "@every 5m", w.processDataExports, cron.SkipIfStillRunning(cron.DefaultLogger))
+
+	// Send last week's digest to the users who opted in
+	w.addJob("weekly_digest", services.WeeklyDigestSpec, w.sendWeeklyDigests, cron.SkipIfStillRunning(cron.DefaultLogger))
+
+	// Store last year's year in review for every account before its activity ages out
+	w.addJob("wrapped_freeze", services.WrappedFreezeSpec, w.freezeWrapped)
 
 	w.cron.Start()
-	log.Println("Sync worker started - (scheduled sync every 6 hours)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.stopJobs = cancel
+	w.jobsDone = make(chan struct{})
+	go w.runSyncJobs(ctx)
+
+	slog.Info("Sync worker started", "sync_cron", syncSpec, "concurrency", config.AppConfig.SyncConcurrency,
+		"shard", config.AppConfig.SyncShard, "shard_count", config.AppConfig.SyncShardCount)
 }
 
 // Stop gracefully stops the worker
 func (w *SyncWorker) Stop() {
-	log.Println("Stopping sync worker...")
+	slog.Info("Stopping sync worker")
 	ctx := w.cron.Stop()
 	<-ctx.Done()
-	log.Println("Sync worker stopped")
+	// A sync cut short is put back on the queue for the next start
+	w.stopJobs()
+	<-w.jobsDone
+	flushMetrics()
+	slog.Info("Sync worker stopped")
+}
+
+// flushMetrics writes the buffered view and request counts. In read-only mode they stay
+// buffered, up to the buffers' limits, for a flush once writes are allowed again.
+func flushMetrics() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
+
+	services.FlushViews()
+	services.FlushRequestCounts()
+	return nil
 }
 
-// syncAllAccounts syncs activity for all active Docker accounts
-func (w *SyncWorker) syncAllAccounts() {
-	log.Println("Starting scheduled sync for all accounts...")
+// deliverNotifications sends queued notifications that are due
+func (w *SyncWorker) deliverNotifications() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	w.notificationService.ProcessPending(ctx)
+	return nil
+}
+
+// runSyncJobs runs queued sync jobs as they come due, SYNC_CONCURRENCY at a time, until ctx
+// is cancelled
+func (w *SyncWorker) runSyncJobs(ctx context.Context) {
+	defer close(w.jobsDone)
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.AppConfig.SyncConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.processSyncJobs(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// processSyncJobs is one of the loops taking jobs off the queue
+func (w *SyncWorker) processSyncJobs(ctx context.Context) {
+	ticker := time.NewTicker(syncJobPollInterval)
+	defer ticker.Stop()
+	for {
+		w.dockerService.ProcessSyncJobs(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-services.SyncJobQueued():
+		}
+	}
+}
+
+// syncAllAccounts queues a sync of every active Docker account on the scheduled sync that
+// wasn't synced recently
+func (w *SyncWorker) syncAllAccounts() error {
+	if config.IsReadOnly() {
+		slog.Info("Skipping scheduled sync, read-only mode enabled")
+		return errJobSkipped
+	}
+
+	slog.Info("Starting scheduled sync for all accounts")
+	return w.queueSyncs(database.DB.Where("sync_interval_minutes = ?", 0), func(account *models.DockerAccount) bool {
+		return account.LastSyncAt == nil || time.Since(*account.LastSyncAt) >= config.AppConfig.SyncMinInterval
+	})
+}
+
+// syncDueAccounts queues a sync of every active Docker account with its own interval whose
+// interval has passed since its last sync
+func (w *SyncWorker) syncDueAccounts() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
+
+	return w.queueSyncs(database.DB.Where("sync_interval_minutes > ?", 0), func(account *models.DockerAccount) bool {
+		interval, _ := services.AccountSyncInterval(account)
+		return account.LastSyncAt == nil || time.Since(*account.LastSyncAt) >= interval
+	})
+}
+
+// queueSyncs queues a sync of the active, auto-refreshing accounts of this instance's shard
+// that query finds due, leaving out dead-lettered and disabled ones and those of suspended or deleted users. They're spaced out so they don't all
+// hit Docker Hub at once: each batch of SYNC_CONCURRENCY accounts starts a little after the
+// last. Accounts whose heatmaps were rendered most over the last week go first, and
+// accounts nobody views go last.
+func (w *SyncWorker) queueSyncs(query *gorm.DB, due func(account *models.DockerAccount) bool) error {
 	var accounts []models.DockerAccount
-	err := database.DB.Where("is_active = ? AND auto_refresh = ?", true, true).Find(&accounts).Error
+	err := services.InSyncShard(query, "id").
+		Where("is_active = ? AND auto_refresh = ? AND sync_dead_lettered_at IS NULL AND disabled_at IS NULL", true, true).
+		Where("user_id NOT IN (?)", services.UnavailableUserIDs()).
+		Order("id").Find(&accounts).Error
 	if err != nil {
-		log.Printf("Failed to fetch accounts: %v", err)
-		return
+		return fmt.Errorf("failed to fetch accounts: %w", err)
 	}
 
-	log.Printf("Found %d accounts to sync", len(accounts))
+	renders, err := services.RecentRenderCounts(syncPriorityDays)
+	if err != nil {
+		// Still sync everything, just without the ordering
+		slog.Warn("Failed to load render counts for sync priority", "error", err)
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return renders[accounts[i].ID] > renders[accounts[j].ID]
+	})
 
+	runAt := time.Now()
+	queued := 0
 	for _, account := range accounts {
 		// Skip if sync is already in progress
 		if account.SyncInProgress {
-			log.Printf("Skipping account %s - sync already in progress", account.DockerUsername)
+			slog.Debug("Skipping account, sync already in progress", "docker_username", account.DockerUsername)
+			continue
+		}
+		if !due(&account) {
 			continue
 		}
 
-		// Check if we synced recently (within last 4 hours)
-		if account.LastSyncAt != nil && time.Since(*account.LastSyncAt) < 4*time.Hour {
-			log.Printf("Skipping account %s - synced recently", account.DockerUsername)
+		if err := services.EnqueueSync(account.ID, models.SyncTriggerScheduled, runAt); err != nil {
+			slog.Error("Failed to queue sync", "docker_username", account.DockerUsername, "error", err)
 			continue
 		}
+		queued++
+		if queued%config.AppConfig.SyncConcurrency == 0 {
+			runAt = runAt.Add(scheduledSyncSpacing)
+		}
+	}
+
+	if queued > 0 {
+		slog.Info("Queued syncs", "queued", queued, "accounts", len(accounts))
+	}
+	return nil
+}
 
-		log.Printf("Syncing account: %s", account.DockerUsername)
+// cleanupOldData removes activity data older than the configured retention window
+func (w *SyncWorker) cleanupOldData() error {
+	if config.IsReadOnly() {
+		slog.Info("Skipping cleanup, read-only mode enabled")
+		return errJobSkipped
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		err := w.dockerService.SyncActivity(ctx, account.ID)
-		cancel()
+	slog.Info("Starting cleanup of old activity data")
 
+	cutoff := time.Now().AddDate(0, 0, -services.ActivityRetentionDays())
+	if config.AppConfig.ActivityArchive {
+		archived, err := services.ArchiveActivity(cutoff)
 		if err != nil {
-			log.Printf("Failed to sync account %s: %v", account.DockerUsername, err)
-		} else {
-			log.Printf("Successfully synced account: %s", account.DockerUsername)
+			return fmt.Errorf("failed to archive old data after archiving %d records: %w", archived, err)
+		}
+		slog.Info("Archived old activity records", "count", archived)
+	} else {
+		result := database.DB.Where("event_date < ?", cutoff).Delete(&models.ActivityEvent{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to cleanup old data: %w", result.Error)
 		}
+		slog.Info("Cleaned up old activity records", "count", result.RowsAffected)
+	}
+	database.DB.Where("event_date < ?", cutoff).Delete(&models.DailyActivityAggregate{})
+	// Ingest rejects events this old, so their receipts can't catch a duplicate any more
+	database.DB.Where("event_at < ?", cutoff).Delete(&models.IngestReceipt{})
+
+	// Finished notification deliveries are only kept for troubleshooting
+	database.DB.Where("status <> ? AND updated_at < ?", models.DeliveryPending, time.Now().AddDate(0, 0, -30)).
+		Delete(&models.NotificationDelivery{})
+
+	// Sessions that can't be refreshed any more; revoked ones are kept a week so a reused
+	// refresh token is still recognized
+	database.DB.Where("expires_at < ? OR revoked_at < ?", time.Now(), time.Now().AddDate(0, 0, -7)).
+		Delete(&models.Session{})
+
+	// Verification and password reset links that can't be used any more
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.EmailToken{})
+
+	// Finished sync jobs are only kept for troubleshooting
+	database.DB.Where("status IN ? AND updated_at < ?",
+		[]string{models.SyncJobSucceeded, models.SyncJobFailed, models.SyncJobDeadLettered},
+		time.Now().AddDate(0, 0, -services.SyncJobRetentionDays)).
+		Delete(&models.SyncJob{})
+
+	// Sync history past its retention
+	database.DB.Where("started_at < ?", time.Now().AddDate(0, 0, -services.SyncRunRetentionDays)).
+		Delete(&models.SyncRun{})
+
+	// Audit log entries past their retention
+	database.DB.Where("created_at < ?", time.Now().AddDate(0, 0, -services.AuditRetentionDays)).
+		Delete(&models.AuditLog{})
+	return nil
+}
+
+// purgeDisconnectedAccounts permanently removes accounts past their disconnect grace period
+func (w *SyncWorker) purgeDisconnectedAccounts() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
+
+	purged, err := w.dockerService.PurgeDisconnectedAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to purge disconnected accounts: %w", err)
+	}
+	if purged > 0 {
+		slog.Info("Purged disconnected accounts", "count", purged)
+	}
+	return nil
+}
+
+// sendWeeklyDigests queues last week's digest for the users who opted in
+func (w *SyncWorker) sendWeeklyDigests() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
 
-		// Small delay between accounts to avoid rate limiting
-		time.Sleep(2 * time.Second)
+	queued, err := w.digestService.SendWeeklyDigests()
+	if err != nil {
+		return fmt.Errorf("failed to send weekly digests: %w", err)
+	}
+	slog.Info("Queued weekly digests", "count", queued)
+	return nil
+}
+
+// freezeWrapped stores last year's year in review for the accounts that don't have one
+func (w *SyncWorker) freezeWrapped() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
 	}
 
-	log.Println("Scheduled sync completed")
+	year := time.Now().UTC().Year() - 1
+	stored, err := w.wrappedService.FreezeWrapped(year)
+	if err != nil {
+		return fmt.Errorf("failed to store year in review: %w", err)
+	}
+	slog.Info("Stored year in review", "year", year, "count", stored)
+	return nil
 }
 
-// cleanupOldData removes activity data older than 1 year
-func (w *SyncWorker) cleanupOldData() {
-	log.Println("Starting cleanup of old activity data...")
+// purgeDeletedUsers permanently deletes users past their account deletion grace period
+func (w *SyncWorker) purgeDeletedUsers() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
 
-	cutoff := time.Now().AddDate(-1, 0, 0) // 1 year ago
-	result := database.DB.Where("event_date < ?", cutoff).Delete(&models.ActivityEvent{})
+	deleted, err := w.dockerService.PurgeDeletedUsers()
+	if err != nil {
+		return fmt.Errorf("failed to delete users: %w", err)
+	}
+	if deleted > 0 {
+		slog.Info("Deleted users", "count", deleted)
+	}
+	return nil
+}
 
-	if result.Error != nil {
-		log.Printf("Failed to cleanup old data: %v", result.Error)
-		return
+// purgeIngestDeliveries removes replay records whose requests would now be rejected by
+// their timestamps anyway
+func (w *SyncWorker) purgeIngestDeliveries() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
 	}
 
-	log.Printf("Cleaned up %d old activity records", result.RowsAffected)
+	if _, err := services.PurgeIngestDeliveries(); err != nil {
+		return fmt.Errorf("failed to purge ingest deliveries: %w", err)
+	}
+	return nil
 }
 
-// SyncSingleAccount syncs a specific account (for manual triggers)
-func (w *SyncWorker) SyncSingleAccount(accountID uint) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+// processDataExports builds data exports left queued and deletes expired ones
+func (w *SyncWorker) processDataExports() error {
+	if config.IsReadOnly() {
+		return errJobSkipped
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
-	return w.dockerService.SyncActivity(ctx, accountID)
+	w.exportService.ProcessDataExports(ctx)
+
+	purged, err := services.PurgeExpiredDataExports()
+	if err != nil {
+		return fmt.Errorf("failed to purge data exports: %w", err)
+	}
+	if purged > 0 {
+		slog.Info("Purged expired data exports", "count", purged)
+	}
+	return nil
+}
+
+// SyncSingleAccount queues a sync of a specific account (for manual triggers)
+func (w *SyncWorker) SyncSingleAccount(accountID uint) error {
+	return services.EnqueueSync(accountID, models.SyncTriggerManual, time.Now())
 }
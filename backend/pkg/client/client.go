@@ -0,0 +1,314 @@
+// Package client is a Go client for the Docker Heatmap API.
+//
+//	c := client.New("https://api.dockerheatmap.dev", client.WithToken(token))
+//	activity, err := c.GetActivity(ctx, "alice", &client.ActivityOptions{Days: 90})
+//
+// Public endpoints work without a token. Requests that fail with a rate limit, a
+// gateway error or a network error are retried with exponential backoff.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the hosted deployment
+	DefaultBaseURL = "https://api.dockerheatmap.dev"
+
+	apiPrefix         = "/api/v1"
+	defaultMaxRetries = 3
+	defaultRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+)
+
+// Client calls the API of one deployment. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+	userAgent  string
+
+	mu           sync.Mutex // guards the tokens, which change when they are refreshed
+	token        string
+	refreshToken string
+	onRefresh    func(accessToken, refreshToken string)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithToken authenticates requests with the bearer token issued after GitHub login
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithRefreshToken refreshes the token when it expires, so a long-running tool stays
+// logged in. Refresh tokens rotate, so onRefresh (if set) is called with the new pair
+// to save; the old refresh token stops working.
+func WithRefreshToken(refreshToken string, onRefresh func(accessToken, refreshToken string)) Option {
+	return func(c *Client) {
+		c.refreshToken = refreshToken
+		c.onRefresh = onRefresh
+	}
+}
+
+// WithHTTPClient sends requests through httpClient instead of one with a 30 second timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetries sets how many times a failed request is retried (default 3, 0 disables)
+func WithRetries(n int) Option {
+	return func(c *Client) { c.maxRetries = n }
+}
+
+// WithUserAgent identifies the calling tool in the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New returns a client for the deployment at baseURL, such as DefaultBaseURL
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
+		userAgent:  "docker-heatmap-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ErrUnauthenticated is returned by endpoints that need a token when none was set
+var ErrUnauthenticated = errors.New("client: this endpoint requires WithToken")
+
+// APIError is an error response from the API
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is how long the server asked to wait, when it said
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker heatmap API: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404, such as an unknown username
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// request describes one API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	accept string // defaults to JSON
+	auth   bool
+	// idempotent requests are also retried after gateway and network errors, which may
+	// have happened after the server acted on them
+	idempotent bool
+}
+
+// do sends req, retrying transient failures, and returns the response body
+func (c *Client) do(ctx context.Context, req request) ([]byte, error) {
+	token, _ := c.tokens()
+	if req.auth && token == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	var payload []byte
+	if req.body != nil {
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		body, retryAfter, err := c.send(ctx, req, u, payload, token)
+		if err == nil {
+			return body, nil
+		}
+		// An expired token is refreshed once, and the request sent again with the new one
+		var apiErr *APIError
+		if req.auth && !refreshed && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			refreshed = true
+			if next, ok := c.refresh(ctx, token); ok {
+				token = next
+				attempt--
+				continue
+			}
+		}
+		if attempt >= c.maxRetries || !retryable(err, req.idempotent) {
+			return nil, err
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, u string, payload []byte, token string) ([]byte, time.Duration, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	accept := req.accept
+	if accept == "" {
+		accept = "application/json"
+	}
+	httpReq.Header.Set("Accept", accept)
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.auth {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, 0, nil
+	}
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var errBody struct {
+		Error      string  `json:"error"`
+		RetryAfter float64 `json:"retry_after"` // seconds, sent with rate limit errors
+	}
+	if json.Unmarshal(body, &errBody) == nil && errBody.Error != "" {
+		apiErr.Message = errBody.Error
+		apiErr.RetryAfter = time.Duration(errBody.RetryAfter * float64(time.Second))
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	if apiErr.RetryAfter > maxRetryDelay {
+		apiErr.RetryAfter = maxRetryDelay
+	}
+	return nil, apiErr.RetryAfter, apiErr
+}
+
+// tokens returns the current access and refresh tokens
+func (c *Client) tokens() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token, c.refreshToken
+}
+
+// refresh replaces a rejected access token using the refresh token and returns the new
+// access token. When another request already refreshed it, the newer token is returned
+// without refreshing again.
+func (c *Client) refresh(ctx context.Context, rejected string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != rejected {
+		return c.token, true
+	}
+	if c.refreshToken == "" {
+		return "", false
+	}
+
+	payload, err := json.Marshal(map[string]string{"refresh_token": c.refreshToken})
+	if err != nil {
+		return "", false
+	}
+	body, _, err := c.send(ctx, request{method: http.MethodPost}, c.baseURL+apiPrefix+"/auth/refresh", payload, "")
+	if err != nil {
+		return "", false
+	}
+	var tokens Tokens
+	if err := json.Unmarshal(body, &tokens); err != nil || tokens.AccessToken == "" {
+		return "", false
+	}
+
+	c.token, c.refreshToken = tokens.AccessToken, tokens.RefreshToken
+	if c.onRefresh != nil {
+		c.onRefresh(tokens.AccessToken, tokens.RefreshToken)
+	}
+	return c.token, true
+}
+
+// retryable reports whether a failed request is worth sending again. Rate limits and
+// maintenance (503) reject requests before they are acted on, so any request can be
+// retried after them; other gateway and network errors only for idempotent requests.
+func retryable(err error, idempotent bool) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return idempotent
+		}
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return idempotent
+}
+
+// backoff doubles the delay after each attempt, with jitter so clients rate limited
+// together don't retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, auth bool, out interface{}) error {
+	body, err := c.do(ctx, request{method: http.MethodGet, path: path, query: query, auth: auth, idempotent: true})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Errors WaitForDeviceLogin ends with when the login won't be approved
+var (
+	ErrDeviceLoginDenied  = errors.New("client: the device login was denied")
+	ErrDeviceLoginExpired = errors.New("client: the device login expired before it was approved")
+)
+
+// DeviceLogin is a pending login for a device without a browser. Show the user
+// UserCode and VerificationURI, then call WaitForDeviceLogin.
+type DeviceLogin struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"` // seconds
+	Interval                int    `json:"interval"`   // seconds between polls
+}
+
+// StartDeviceLogin begins a device login. clientName is shown to the user when they
+// approve it.
+func (c *Client) StartDeviceLogin(ctx context.Context, clientName string) (*DeviceLogin, error) {
+	body, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/device",
+		body:   map[string]string{"client_name": clientName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var login DeviceLogin
+	if err := json.Unmarshal(body, &login); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// WaitForDeviceLogin polls until the user approves or denies the login, or it expires,
+// and returns the tokens and the user they belong to
+func (c *Client) WaitForDeviceLogin(ctx context.Context, login *DeviceLogin) (*Tokens, error) {
+	interval := time.Duration(login.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		body, err := c.do(ctx, request{
+			method: http.MethodPost,
+			path:   "/auth/device/token",
+			body:   map[string]string{"device_code": login.DeviceCode},
+		})
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			switch apiErr.Message {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += 5 * time.Second
+				continue
+			case "access_denied":
+				return nil, ErrDeviceLoginDenied
+			case "expired_token":
+				return nil, ErrDeviceLoginExpired
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var tokens Tokens
+		if err := json.Unmarshal(body, &tokens); err != nil {
+			return nil, err
+		}
+		return &tokens, nil
+	}
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ActivityOptions narrows an activity request; the zero value is the deployment's default
+// window in the owner's timezone
+type ActivityOptions struct {
+	// Days is the trailing window, ignored when From and To are set
+	Days int
+	// From and To select an explicit range of calendar days (inclusive)
+	From, To time.Time
+	// Granularity rolls days up into weeks or months (GranularityDay by default)
+	Granularity string
+	// Timezone is an IANA name for day bucketing
+	Timezone string
+	// EventTypes limits activity to push, pull and/or build
+	EventTypes []string
+	// Tags limits activity to tags matching any of these glob patterns
+	Tags []string
+	// RepoBreakdown fills each day's per-repository counts
+	RepoBreakdown bool
+}
+
+func (o *ActivityOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if o.Days > 0 {
+		q.Set("days", strconv.Itoa(o.Days))
+	}
+	if !o.From.IsZero() && !o.To.IsZero() {
+		q.Set("from", o.From.Format("2006-01-02"))
+		q.Set("to", o.To.Format("2006-01-02"))
+	}
+	if o.Granularity != "" {
+		q.Set("granularity", o.Granularity)
+	}
+	if o.Timezone != "" {
+		q.Set("tz", o.Timezone)
+	}
+	if len(o.EventTypes) > 0 {
+		q.Set("events", strings.Join(o.EventTypes, ","))
+	}
+	if len(o.Tags) > 0 {
+		q.Set("tag", strings.Join(o.Tags, ","))
+	}
+	if o.RepoBreakdown {
+		q.Set("breakdown", "repo")
+	}
+	return q
+}
+
+// HeatmapOptions customizes a rendered heatmap; the zero value renders the default
+type HeatmapOptions struct {
+	Days       int
+	Theme      string
+	CellSize   int
+	Radius     *int // nil keeps the default, 0 draws square cells
+	HideLegend bool
+	HideTotal  bool
+	HideLabels bool
+	Title      string
+	Timezone   string
+	// Params carries any other customization parameter, such as bg_color or color0-color4
+	Params url.Values
+}
+
+func (o *HeatmapOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	for key, values := range o.Params {
+		q[key] = append([]string(nil), values...)
+	}
+	if o.Days > 0 {
+		q.Set("days", strconv.Itoa(o.Days))
+	}
+	if o.Theme != "" {
+		q.Set("theme", o.Theme)
+	}
+	if o.CellSize > 0 {
+		q.Set("cell_size", strconv.Itoa(o.CellSize))
+	}
+	if o.Radius != nil {
+		q.Set("radius", strconv.Itoa(*o.Radius))
+	}
+	if o.HideLegend {
+		q.Set("hide_legend", "true")
+	}
+	if o.HideTotal {
+		q.Set("hide_total", "true")
+	}
+	if o.HideLabels {
+		q.Set("hide_labels", "true")
+	}
+	if o.Title != "" {
+		q.Set("title", o.Title)
+	}
+	if o.Timezone != "" {
+		q.Set("tz", o.Timezone)
+	}
+	return q
+}
+
+// GetActivity returns a Docker Hub account's activity
+func (c *Client) GetActivity(ctx context.Context, dockerUsername string, opts *ActivityOptions) (*Activity, error) {
+	var activity Activity
+	if err := c.getJSON(ctx, "/activity/"+url.PathEscape(dockerUsername)+".json", opts.query(), false, &activity); err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// GetHeatmapSVG renders a Docker Hub account's heatmap as SVG
+func (c *Client) GetHeatmapSVG(ctx context.Context, dockerUsername string, opts *HeatmapOptions) ([]byte, error) {
+	return c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/heatmap/" + url.PathEscape(dockerUsername) + ".svg",
+		query:      opts.query(),
+		accept:     "image/svg+xml",
+		idempotent: true,
+	})
+}
+
+// GetRepositories returns a Docker Hub account's repositories ranked by heat over the
+// last days (0 for the default of 90)
+func (c *Client) GetRepositories(ctx context.Context, dockerUsername string, days int) (*Repositories, error) {
+	q := url.Values{}
+	if days > 0 {
+		q.Set("days", strconv.Itoa(days))
+	}
+	var repos Repositories
+	if err := c.getJSON(ctx, "/repos/"+url.PathEscape(dockerUsername), q, false, &repos); err != nil {
+		return nil, err
+	}
+	return &repos, nil
+}
+
+// GetCurrentUser returns the user the token belongs to
+func (c *Client) GetCurrentUser(ctx context.Context) (*User, error) {
+	var resp struct {
+		User User `json:"user"`
+	}
+	if err := c.getJSON(ctx, "/user/me", nil, true, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.User, nil
+}
+
+// Logout ends the token's session on the server, so its refresh token stops working
+func (c *Client) Logout(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/auth/logout", auth: true})
+	return err
+}
+
+// GetDockerAccount returns the Docker Hub account connected to the authenticated user
+func (c *Client) GetDockerAccount(ctx context.Context) (*DockerAccount, error) {
+	var resp struct {
+		Account DockerAccount `json:"account"`
+	}
+	if err := c.getJSON(ctx, "/docker/account", nil, true, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Account, nil
+}
+
+// ConnectDocker connects a Docker Hub account to the authenticated user and starts its
+// first sync. A read-only personal access token is enough.
+func (c *Client) ConnectDocker(ctx context.Context, dockerUsername, accessToken string) (*DockerAccount, error) {
+	body, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/docker/connect",
+		body: map[string]string{
+			"docker_username": dockerUsername,
+			"access_token":    accessToken,
+		},
+		auth: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Account DockerAccount `json:"account"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Account, nil
+}
+
+// TriggerSync starts syncing the authenticated user's account in the background. It
+// returns an APIError with status 409 when a sync is already running.
+func (c *Client) TriggerSync(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/docker/sync", auth: true})
+	return err
+}
@@ -0,0 +1,3 @@
+module github.com/sagargujarathi/docker-heatmap/backend/pkg/client
+
+go 1.21
@@ -0,0 +1,94 @@
+package client
+
+import "time"
+
+// Granularities accepted by ActivityOptions
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// ActivityDay is one day, or one week or month bucket dated by its first day
+type ActivityDay struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Count  int    `json:"count"`
+	Pushes int    `json:"pushes"`
+	Pulls  int    `json:"pulls"`
+	Builds int    `json:"builds"`
+	Level  int    `json:"level"` // 0-4
+	// Repositories maps repository name to count; only filled with RepoBreakdown
+	Repositories map[string]int `json:"repositories,omitempty"`
+}
+
+// ActivityTotals sums activity over the whole window
+type ActivityTotals struct {
+	Activities int `json:"activities"`
+	Pushes     int `json:"pushes"`
+	Pulls      int `json:"pulls"`
+	Builds     int `json:"builds"`
+}
+
+// Activity is an account's activity over a window
+type Activity struct {
+	Username    string         `json:"username"`
+	Days        int            `json:"days"`
+	Granularity string         `json:"granularity"`
+	Timezone    string         `json:"timezone"`
+	Totals      ActivityTotals `json:"totals"`
+	Activity    []ActivityDay  `json:"activity"`
+}
+
+// Repository summarizes one repository's recent activity
+type Repository struct {
+	Repository   string    `json:"repository"`
+	Events       int       `json:"events"`
+	LastActivity time.Time `json:"last_activity"`
+	// Heat is the weighted event count decayed by age
+	Heat float64 `json:"heat"`
+	// RelativeHeat is Heat scaled against the hottest repository (0-1)
+	RelativeHeat float64 `json:"relative_heat"`
+}
+
+// Repositories is an account's repositories ranked by recent activity
+type Repositories struct {
+	Username     string       `json:"username"`
+	Days         int          `json:"days"`
+	Sort         string       `json:"sort"`
+	Total        int          `json:"total"`
+	Repositories []Repository `json:"repositories"`
+}
+
+// Tokens are what a login or refresh returns. Access tokens are short-lived; the
+// refresh token gets the next one and changes each time it is used.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until the access token expires
+	// User is who logged in; only set by WaitForDeviceLogin
+	User *User `json:"user,omitempty"`
+}
+
+// User is the user a token belongs to
+type User struct {
+	ID uint `json:"id"`
+	// Provider is the sign-in provider the account was created with: "github", "gitlab"
+	// or "google"
+	Provider string `json:"provider"`
+	// GitHubUsername is the user's handle on that provider; the field predates the others
+	GitHubUsername string `json:"github_username"`
+	Name           string `json:"name,omitempty"`
+	Email          string `json:"email,omitempty"`
+}
+
+// DockerAccount is the Docker Hub account connected to the authenticated user
+type DockerAccount struct {
+	ID                    uint       `json:"id"`
+	DockerUsername        string     `json:"docker_username"`
+	IsActive              bool       `json:"is_active"`
+	AutoRefresh           bool       `json:"auto_refresh"`
+	LastSyncAt            *time.Time `json:"last_sync_at"`
+	LastSyncError         string     `json:"last_sync_error"`
+	SyncInProgress        bool       `json:"sync_in_progress"`
+	DisconnectScheduledAt *time.Time `json:"disconnect_scheduled_at"`
+}
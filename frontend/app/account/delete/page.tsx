@@ -0,0 +1,79 @@
+"use client";
+
+import { Suspense, useEffect, useRef, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import Link from "next/link";
+import Image from "next/image";
+import { Loader2 } from "lucide-react";
+import { userApi } from "@/lib/api";
+import { Button } from "@/components/ui/button";
+
+// Follows the link mailed to confirm deleting an account
+function DeleteContent() {
+  const searchParams = useSearchParams();
+  const processed = useRef(false);
+  const [message, setMessage] = useState("");
+  const [error, setError] = useState("");
+
+  useEffect(() => {
+    if (processed.current) return;
+    processed.current = true;
+
+    userApi
+      .confirmAccountDeletion(searchParams.get("token") || "")
+      .then(({ delete_at }) => {
+        if (!delete_at) {
+          // Deleted straight away, so this browser's tokens no longer work
+          localStorage.removeItem("token");
+          localStorage.removeItem("refresh_token");
+          setMessage("Your account and all its data have been deleted.");
+          return;
+        }
+        setMessage(
+          `Your account will be deleted on ${new Date(
+            delete_at,
+          ).toLocaleString()}. Sign in before then to keep it.`,
+        );
+      })
+      .catch((e) => setError((e as Error).message));
+  }, [searchParams]);
+
+  if (error || message) {
+    return (
+      <>
+        <p className="text-muted-foreground mb-6">{error || message}</p>
+        <Link href="/">
+          <Button>Back to home</Button>
+        </Link>
+      </>
+    );
+  }
+
+  return (
+    <>
+      <Loader2 className="h-6 w-6 animate-spin mx-auto mb-4" />
+      <p className="text-sm text-muted-foreground">Deleting your account...</p>
+    </>
+  );
+}
+
+export default function DeleteAccountPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-background p-4">
+      <div className="max-w-md w-full text-center">
+        <Image
+          src="/logo.webp"
+          alt="Logo"
+          width={80}
+          height={80}
+          className="mx-auto mb-6"
+        />
+        <Suspense
+          fallback={<Loader2 className="h-6 w-6 animate-spin mx-auto" />}
+        >
+          <DeleteContent />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
@@ -19,9 +19,17 @@ function CallbackContent() {
 
     if (token) {
       localStorage.setItem("token", token);
+      const refreshToken = searchParams.get("refresh_token");
+      if (refreshToken) localStorage.setItem("refresh_token", refreshToken);
+      // Pages that sent the user to sign in, such as /device, ask to come back
+      const returnTo = sessionStorage.getItem("returnTo");
+      sessionStorage.removeItem("returnTo");
       refreshUser().then(() => {
         // Use window.location.href for a full page reload to ensure all contexts are updated
-        window.location.href = "/dashboard";
+        window.location.href =
+          returnTo && returnTo.startsWith("/") && !returnTo.startsWith("//")
+            ? returnTo
+            : "/dashboard";
       });
     } else {
       window.location.href = "/auth/error?message=no_token";
@@ -9,9 +9,10 @@ import Link from "next/link";
 const errorMessages: Record<string, string> = {
   missing_params: "Missing required parameters. Please try signing in again.",
   invalid_state: "Invalid OAuth state. The link may have expired.",
-  auth_failed: "Authentication with GitHub failed. Please try again.",
+  auth_failed: "Sign-in with your provider failed. Please try again.",
   token_failed: "Failed to generate authentication token.",
   no_token: "No authentication token received.",
+  suspended: "This account has been suspended.",
   default: "An unexpected error occurred during authentication.",
 };
 
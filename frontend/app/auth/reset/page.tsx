@@ -0,0 +1,88 @@
+"use client";
+
+import { Suspense, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import Image from "next/image";
+import { Loader2 } from "lucide-react";
+import { useAuth } from "@/context/auth-context";
+import { authApi } from "@/lib/api";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+
+// Sets a new password from the link mailed by "Forgot password?" and signs in;
+// every other session is logged out
+function ResetContent() {
+  const searchParams = useSearchParams();
+  const { completeSignIn } = useAuth();
+  const [password, setPassword] = useState("");
+  const [error, setError] = useState("");
+  const [busy, setBusy] = useState(false);
+
+  const submit = async () => {
+    setBusy(true);
+    setError("");
+    try {
+      completeSignIn(
+        await authApi.resetPassword({
+          token: searchParams.get("token") || "",
+          password,
+        }),
+      );
+    } catch (e) {
+      setError((e as Error).message);
+      setBusy(false);
+    }
+  };
+
+  return (
+    <form
+      className="flex flex-col gap-2"
+      onSubmit={(e) => {
+        e.preventDefault();
+        submit();
+      }}
+    >
+      <p className="text-sm text-muted-foreground mb-4">
+        Choose a new password. You&apos;ll be signed out everywhere else.
+      </p>
+      <Input
+        type="password"
+        value={password}
+        onChange={(e) => setPassword(e.target.value)}
+        placeholder="New password (8+ characters)"
+        autoComplete="new-password"
+        minLength={8}
+        maxLength={72}
+        required
+        autoFocus
+      />
+      <Button type="submit" disabled={busy}>
+        {busy && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+        Set password
+      </Button>
+      {error && <p className="text-sm text-destructive">{error}</p>}
+    </form>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-background p-4">
+      <div className="max-w-sm w-full text-center">
+        <Image
+          src="/logo.webp"
+          alt="Logo"
+          width={64}
+          height={64}
+          className="mx-auto mb-6"
+        />
+        <h1 className="text-xl font-bold mb-2">Reset Password</h1>
+        <Suspense
+          fallback={<Loader2 className="h-6 w-6 animate-spin mx-auto" />}
+        >
+          <ResetContent />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
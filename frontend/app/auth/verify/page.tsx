@@ -0,0 +1,67 @@
+"use client";
+
+import { Suspense, useEffect, useRef, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import Link from "next/link";
+import Image from "next/image";
+import { Loader2 } from "lucide-react";
+import { useAuth } from "@/context/auth-context";
+import { authApi } from "@/lib/api";
+import { Button } from "@/components/ui/button";
+
+// Follows the link mailed after an email and password sign-up, then signs in
+function VerifyContent() {
+  const searchParams = useSearchParams();
+  const { completeSignIn } = useAuth();
+  const processed = useRef(false);
+  const [error, setError] = useState("");
+
+  useEffect(() => {
+    if (processed.current) return;
+    processed.current = true;
+
+    authApi
+      .verifyEmail(searchParams.get("token") || "")
+      .then(completeSignIn)
+      .catch((e) => setError((e as Error).message));
+  }, [searchParams, completeSignIn]);
+
+  if (error) {
+    return (
+      <>
+        <p className="text-muted-foreground mb-6">{error}</p>
+        <Link href="/login">
+          <Button>Back to sign in</Button>
+        </Link>
+      </>
+    );
+  }
+
+  return (
+    <>
+      <Loader2 className="h-6 w-6 animate-spin mx-auto mb-4" />
+      <p className="text-sm text-muted-foreground">Verifying your email...</p>
+    </>
+  );
+}
+
+export default function VerifyEmailPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-background p-4">
+      <div className="max-w-md w-full text-center">
+        <Image
+          src="/logo.webp"
+          alt="Logo"
+          width={80}
+          height={80}
+          className="mx-auto mb-6"
+        />
+        <Suspense
+          fallback={<Loader2 className="h-6 w-6 animate-spin mx-auto" />}
+        >
+          <VerifyContent />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
@@ -5,9 +5,13 @@ import { useRouter } from "next/navigation";
 import { useAuth } from "@/context/auth-context";
 import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
 import { useToast } from "@/hooks/use-toast";
-import { dockerApi, publicApi } from "@/lib/api";
+import { dockerApi, publicApi, userApi } from "@/lib/api";
 import { useForm } from "react-hook-form";
-import type { ConnectDockerRequest, SVGOptions } from "@/lib/schemas";
+import type {
+  ConnectDockerRequest,
+  SVGOptions,
+  SyncProgress,
+} from "@/lib/schemas";
 
 import {
   Card,
@@ -20,6 +24,17 @@ import { Loader2, Sparkles } from "lucide-react";
 import { DockerConnectionCard } from "@/components/dashboard/docker-connection-card";
 import { SVGCustomizationCard } from "@/components/dashboard/svg-customization-card";
 import { EmbedCodesCard } from "@/components/dashboard/embed-codes-card";
+import { ViewsCard } from "@/components/dashboard/views-card";
+import { NamespacesCard } from "@/components/dashboard/namespaces-card";
+import { IngestKeysCard } from "@/components/dashboard/ingest-keys-card";
+import { SessionsCard } from "@/components/dashboard/sessions-card";
+import { AuditLogCard } from "@/components/dashboard/audit-log-card";
+import { DataExportCard } from "@/components/dashboard/data-export-card";
+import { DeleteAccountCard } from "@/components/dashboard/delete-account-card";
+import { SyncScheduleCard } from "@/components/dashboard/sync-schedule-card";
+import { SyncHistoryCard } from "@/components/dashboard/sync-history-card";
+import { WeeklyDigestCard } from "@/components/dashboard/weekly-digest-card";
+import { EmbedTokensCard } from "@/components/dashboard/embed-tokens-card";
 
 // Default themes in case API fails
 const DEFAULT_THEMES = [
@@ -84,6 +99,17 @@ export function DashboardClient() {
     },
   });
 
+  // A private profile's heatmap is only served from a signed URL, the preview's too
+  const dockerUsername = dockerData?.account?.docker_username;
+  const isPrivate = user?.public_profile === false;
+  const { data: previewSignature } = useQuery({
+    queryKey: ["embed-preview-signature", dockerUsername],
+    queryFn: () =>
+      userApi.signEmbed({ docker_username: dockerUsername!, days: 1 }),
+    enabled: isPrivate && !!dockerUsername,
+    staleTime: 1000 * 60 * 60, // Signed for a day; renewed hourly
+  });
+
   // Fetch available themes
   const { data: themesData } = useQuery({
     queryKey: ["themes"],
@@ -114,15 +140,38 @@ export function DashboardClient() {
   // Disconnect mutation
   const disconnectMutation = useMutation({
     mutationFn: dockerApi.disconnect,
-    onSuccess: () => {
+    onSuccess: (data) => {
       toast({
         title: "Disconnected",
-        description: "Docker Hub account removed.",
+        description: data.purge_at
+          ? `Your data will be deleted on ${new Date(
+              data.purge_at,
+            ).toLocaleDateString()}. You can undo this until then.`
+          : "Docker Hub account removed.",
       });
       queryClient.invalidateQueries({ queryKey: ["docker-account"] });
     },
   });
 
+  // Undo disconnect mutation
+  const undoDisconnectMutation = useMutation({
+    mutationFn: dockerApi.undoDisconnect,
+    onSuccess: () => {
+      toast({
+        title: "Restored",
+        description: "Your Docker Hub account is connected again.",
+      });
+      queryClient.invalidateQueries({ queryKey: ["docker-account"] });
+    },
+    onError: (error: Error) => {
+      toast({
+        title: "Restore Failed",
+        description: error.message,
+        variant: "destructive",
+      });
+    },
+  });
+
   // Sync mutation
   const syncMutation = useMutation({
     mutationFn: dockerApi.sync,
@@ -134,6 +183,26 @@ export function DashboardClient() {
     },
   });
 
+  // Live sync progress, streamed for as long as an account is connected so scheduled
+  // syncs show up too
+  const [syncProgress, setSyncProgress] = useState<SyncProgress | null>(null);
+  const hasDockerAccount = !!dockerData?.account;
+  useEffect(() => {
+    if (!hasDockerAccount) return;
+    const controller = new AbortController();
+    dockerApi
+      .streamSyncProgress((progress) => {
+        setSyncProgress(progress);
+        if (progress.stage === "done" || progress.stage === "failed") {
+          queryClient.invalidateQueries({ queryKey: ["docker-account"] });
+        }
+      }, controller.signal)
+      .catch(() => {
+        // Polling the account still notices when the sync ends
+      });
+    return () => controller.abort();
+  }, [hasDockerAccount, queryClient]);
+
   // Form
   const form = useForm<ConnectDockerRequest>({
     defaultValues: { docker_username: "", access_token: "" },
@@ -161,6 +230,12 @@ export function DashboardClient() {
     return `${url}${url.includes("?") ? "&" : "?"}t=${Date.now()}`;
   };
 
+  const getPreviewUrl = () => {
+    const url = getCustomSvgUrl();
+    if (!isPrivate || !previewSignature) return url;
+    return `${url}&exp=${previewSignature.exp}&sig=${previewSignature.sig}`;
+  };
+
   // Loading state
   if (authLoading) {
     return (
@@ -173,7 +248,6 @@ export function DashboardClient() {
   // Not authenticated - will redirect
   if (!user) return null;
 
-  const hasDockerAccount = !!dockerData?.account;
   const themes = themesData?.themes || DEFAULT_THEMES;
 
   return (
@@ -204,12 +278,15 @@ export function DashboardClient() {
             onSubmit={onSubmit}
             onSync={() => syncMutation.mutate()}
             onDisconnect={() => disconnectMutation.mutate()}
+            onUndoDisconnect={() => undoDisconnectMutation.mutate()}
             isConnecting={connectMutation.isPending}
             isSyncing={
               syncMutation.isPending ||
               (dockerData?.account?.sync_in_progress ?? false)
             }
+            syncProgress={syncProgress}
             isDisconnecting={disconnectMutation.isPending}
+            isRestoring={undoDisconnectMutation.isPending}
           />
         </section>
 
@@ -248,7 +325,7 @@ export function DashboardClient() {
                       <div className="flex justify-center items-center">
                         <img
                           key={JSON.stringify(svgOptions)}
-                          src={getCustomSvgUrl()}
+                          src={getPreviewUrl()}
                           alt="Your Docker activity heatmap"
                           className="w-full h-auto max-w-full shadow-sm rounded bg-background"
                         />
@@ -271,10 +348,28 @@ export function DashboardClient() {
               <EmbedCodesCard
                 customUrl={getCustomSvgUrl()}
                 dockerUsername={dockerData.account!.docker_username}
+                isPrivate={isPrivate}
                 copied={copied}
                 onCopy={copyToClipboard}
               />
             </section>
+
+            {/* Views, sync, namespaces, keys, tokens, sessions, audit log */}
+            <section className="mt-8 grid gap-8 lg:grid-cols-2">
+              <ViewsCard />
+              <SyncScheduleCard account={dockerData.account!} />
+              <SyncHistoryCard />
+              <WeeklyDigestCard />
+              <NamespacesCard />
+              <IngestKeysCard />
+              <EmbedTokensCard
+                dockerUsername={dockerData.account!.docker_username}
+              />
+              <SessionsCard />
+              <AuditLogCard />
+              <DataExportCard />
+              <DeleteAccountCard />
+            </section>
           </>
         )}
       </main>
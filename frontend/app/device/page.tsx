@@ -0,0 +1,176 @@
+"use client";
+
+import { Suspense, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import Image from "next/image";
+import { Check, Github, Loader2, X } from "lucide-react";
+import { useAuth } from "@/context/auth-context";
+import { authApi } from "@/lib/api";
+import type { DeviceLogin } from "@/lib/schemas";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+
+// Approves logins from the CLI and other devices without a browser: the device shows a
+// code, and the user enters it here while signed in
+function DeviceContent() {
+  const searchParams = useSearchParams();
+  const { user, isLoading, login, providers, passwordEnabled } = useAuth();
+  const [code, setCode] = useState(searchParams.get("user_code") || "");
+  const [device, setDevice] = useState<DeviceLogin | null>(null);
+  const [result, setResult] = useState<"approved" | "denied" | null>(null);
+  const [error, setError] = useState("");
+  const [busy, setBusy] = useState(false);
+
+  const signIn = () => {
+    // Come back here, code included, once GitHub sign-in finishes
+    sessionStorage.setItem(
+      "returnTo",
+      `/device?user_code=${encodeURIComponent(code)}`,
+    );
+    login();
+  };
+
+  const lookUp = async () => {
+    setBusy(true);
+    setError("");
+    try {
+      const { device } = await authApi.getDeviceLogin(code);
+      setDevice(device);
+    } catch (e) {
+      setError((e as Error).message);
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const decide = async (approve: boolean) => {
+    if (!device) return;
+    setBusy(true);
+    setError("");
+    try {
+      await authApi.decideDeviceLogin({
+        user_code: device.user_code,
+        approve,
+      });
+      setResult(approve ? "approved" : "denied");
+    } catch (e) {
+      setError((e as Error).message);
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  if (isLoading) {
+    return <Loader2 className="h-6 w-6 animate-spin mx-auto" />;
+  }
+
+  if (!user) {
+    return (
+      <>
+        <p className="text-sm text-muted-foreground mb-6">
+          Sign in to approve the login from your device.
+        </p>
+        <Button onClick={signIn}>
+          <Github className="mr-2 h-4 w-4" />
+          {providers.length === 1 && !passwordEnabled
+            ? "Sign in with GitHub"
+            : "Sign in"}
+        </Button>
+      </>
+    );
+  }
+
+  if (result) {
+    return (
+      <p className="text-sm text-muted-foreground">
+        {result === "approved"
+          ? "Done! Your device is now signed in. You can close this page."
+          : "Login denied. Your device won't be signed in."}
+      </p>
+    );
+  }
+
+  if (device) {
+    return (
+      <>
+        <p className="text-sm text-muted-foreground mb-2">
+          {device.client_name || "A device"} wants to sign in as{" "}
+          <span className="font-medium text-foreground">
+            {user.github_username}
+          </span>
+          .
+        </p>
+        <p className="text-sm text-muted-foreground mb-6">
+          Only approve if the code{" "}
+          <code className="font-mono bg-muted px-1 rounded">
+            {device.user_code}
+          </code>{" "}
+          is the one your device shows.
+        </p>
+        <div className="flex justify-center gap-2">
+          <Button onClick={() => decide(true)} disabled={busy}>
+            <Check className="mr-2 h-4 w-4" />
+            Approve
+          </Button>
+          <Button
+            variant="outline"
+            onClick={() => decide(false)}
+            disabled={busy}
+          >
+            <X className="mr-2 h-4 w-4" />
+            Deny
+          </Button>
+        </div>
+        {error && <p className="text-sm text-destructive mt-4">{error}</p>}
+      </>
+    );
+  }
+
+  return (
+    <form
+      onSubmit={(e) => {
+        e.preventDefault();
+        lookUp();
+      }}
+    >
+      <p className="text-sm text-muted-foreground mb-6">
+        Enter the code shown on your device.
+      </p>
+      <div className="flex gap-2">
+        <Input
+          value={code}
+          onChange={(e) => setCode(e.target.value.toUpperCase())}
+          placeholder="BCDF-GHJK"
+          className="font-mono text-center tracking-widest"
+          autoFocus
+        />
+        <Button type="submit" disabled={busy || code.trim() === ""}>
+          {busy ? <Loader2 className="h-4 w-4 animate-spin" /> : "Continue"}
+        </Button>
+      </div>
+      {error && <p className="text-sm text-destructive mt-4">{error}</p>}
+    </form>
+  );
+}
+
+export default function DevicePage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-background p-4">
+      <div className="max-w-md w-full text-center">
+        <Image
+          src="/logo.webp"
+          alt="Logo"
+          width={64}
+          height={64}
+          className="mx-auto mb-6"
+        />
+        <h1 className="text-xl font-bold mb-2">Device Login</h1>
+        <Suspense
+          fallback={<Loader2 className="h-6 w-6 animate-spin mx-auto" />}
+        >
+          <DeviceContent />
+        </Suspense>
+      </div>
+    </div>
+  );
+}
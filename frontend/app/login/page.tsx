@@ -0,0 +1,179 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { useRouter } from "next/navigation";
+import Image from "next/image";
+import { Github, Gitlab, Loader2, Mail } from "lucide-react";
+import { useAuth } from "@/context/auth-context";
+import { authApi } from "@/lib/api";
+import type { AuthProvider } from "@/lib/schemas";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+
+const providerLabels: Record<
+  AuthProvider,
+  { label: string; icon: typeof Github }
+> = {
+  github: { label: "Continue with GitHub", icon: Github },
+  gitlab: { label: "Continue with GitLab", icon: Gitlab },
+  google: { label: "Continue with Google", icon: Mail },
+};
+
+type Mode = "login" | "signup" | "forgot";
+
+// Email and password sign-in, sign-up and reset requests
+function PasswordForm() {
+  const { completeSignIn } = useAuth();
+  const [mode, setMode] = useState<Mode>("login");
+  const [email, setEmail] = useState("");
+  const [password, setPassword] = useState("");
+  const [message, setMessage] = useState("");
+  const [error, setError] = useState("");
+  const [busy, setBusy] = useState(false);
+
+  const submit = async () => {
+    setBusy(true);
+    setError("");
+    setMessage("");
+    try {
+      if (mode === "login") {
+        completeSignIn(await authApi.passwordLogin({ email, password }));
+        return;
+      }
+      const result =
+        mode === "signup"
+          ? await authApi.signup({ email, password })
+          : await authApi.forgotPassword(email);
+      setMessage(result.message);
+    } catch (e) {
+      setError((e as Error).message);
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const switchTo = (next: Mode) => {
+    setMode(next);
+    setError("");
+    setMessage("");
+  };
+
+  return (
+    <form
+      className="flex flex-col gap-2 text-left"
+      onSubmit={(e) => {
+        e.preventDefault();
+        submit();
+      }}
+    >
+      <Input
+        type="email"
+        value={email}
+        onChange={(e) => setEmail(e.target.value)}
+        placeholder="you@example.com"
+        autoComplete="email"
+        required
+      />
+      {mode !== "forgot" && (
+        <Input
+          type="password"
+          value={password}
+          onChange={(e) => setPassword(e.target.value)}
+          placeholder={
+            mode === "signup" ? "Password (8+ characters)" : "Password"
+          }
+          autoComplete={
+            mode === "signup" ? "new-password" : "current-password"
+          }
+          minLength={mode === "signup" ? 8 : undefined}
+          maxLength={72}
+          required
+        />
+      )}
+      <Button type="submit" disabled={busy}>
+        {busy && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+        {mode === "login"
+          ? "Sign in"
+          : mode === "signup"
+            ? "Create account"
+            : "Send reset link"}
+      </Button>
+      {message && (
+        <p className="text-sm text-muted-foreground">{message}</p>
+      )}
+      {error && <p className="text-sm text-destructive">{error}</p>}
+      <div className="flex justify-between text-xs text-muted-foreground">
+        {mode === "login" ? (
+          <>
+            <button type="button" onClick={() => switchTo("signup")}>
+              Create an account
+            </button>
+            <button type="button" onClick={() => switchTo("forgot")}>
+              Forgot password?
+            </button>
+          </>
+        ) : (
+          <button type="button" onClick={() => switchTo("login")}>
+            Back to sign in
+          </button>
+        )}
+      </div>
+    </form>
+  );
+}
+
+// Offers every way to sign in the deployment has enabled
+export default function LoginPage() {
+  const router = useRouter();
+  const { user, isLoading, providers, passwordEnabled, login } = useAuth();
+
+  useEffect(() => {
+    if (user) router.replace("/dashboard");
+  }, [user, router]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-background p-4">
+      <div className="max-w-sm w-full text-center">
+        <Image
+          src="/logo.webp"
+          alt="Logo"
+          width={64}
+          height={64}
+          className="mx-auto mb-6"
+        />
+        <h1 className="text-xl font-bold mb-2">Sign in</h1>
+        <p className="text-sm text-muted-foreground mb-6">
+          Accounts with the same verified email are linked, so you can use
+          any of them.
+        </p>
+        {isLoading ? (
+          <Loader2 className="h-6 w-6 animate-spin mx-auto" />
+        ) : (
+          <div className="flex flex-col gap-2">
+            {providers.map((provider) => {
+              const { label, icon: Icon } = providerLabels[provider];
+              return (
+                <Button
+                  key={provider}
+                  variant={provider === "github" ? "default" : "outline"}
+                  onClick={() => login(provider)}
+                >
+                  <Icon className="mr-2 h-4 w-4" />
+                  {label}
+                </Button>
+              );
+            })}
+            {passwordEnabled && (
+              <>
+                {providers.length > 0 && (
+                  <p className="text-xs text-muted-foreground my-2">or</p>
+                )}
+                <PasswordForm />
+              </>
+            )}
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
@@ -136,7 +136,7 @@ const HomePage = async () => {
 
 ## Docker Activity
 
-![Docker Heatmap](https://dockerheatmap.dev/api/heatmap/username.svg)
+![Docker Heatmap](https://dockerheatmap.dev/api/v1/heatmap/username.svg)
 
 ## About
 ...`}</code>
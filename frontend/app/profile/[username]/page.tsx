@@ -2,9 +2,10 @@ import { Metadata } from "next";
 import { publicApi } from "@/lib/api";
 import { Button } from "@/components/ui/button";
 import { Avatar, AvatarFallback, AvatarImage } from "@/components/ui/avatar";
-import { ArrowLeft, Github, Share2 } from "lucide-react";
+import { ArrowLeft, Github, Gitlab, Share2 } from "lucide-react";
 import Link from "next/link";
 import { HeatmapViewer } from "@/components/dashboard/heatmap-viewer";
+import { MarkdownBio } from "@/components/shared/markdown-bio";
 import { ShareProfileButton } from "./share-profile";
 
 interface PageProps {
@@ -23,6 +24,11 @@ export async function generateMetadata({
     return {
       title: `${displayName} (@${profile.user.github_username})`,
       description: `${displayName}'s Docker Hub activity heatmap. Visualize container activity like GitHub commits.`,
+      alternates: {
+        types: {
+          "application/atom+xml": publicApi.getFeedUrl(username),
+        },
+      },
       openGraph: {
         title: `${displayName}'s Docker Activity | Docker Heatmap`,
         description: `Visualize ${displayName}'s Docker Hub activity like GitHub commits.`,
@@ -67,14 +73,24 @@ const ProfilePage = async ({ params }: PageProps) => {
                   {profile.user.name || profile.user.github_username}
                 </h1>
                 <div className="flex flex-wrap items-center gap-3 text-muted-foreground">
-                  <Link
-                    href={`https://github.com/${profile.user.github_username}`}
-                    target="_blank"
-                    className="flex items-center gap-1.5 hover:text-primary transition-colors bg-muted/50 px-2 py-0.5 rounded-md text-sm border"
-                  >
-                    <Github className="h-3.5 w-3.5" />
-                    {profile.user.github_username}
-                  </Link>
+                  {profile.user.profile_url ? (
+                    <Link
+                      href={profile.user.profile_url}
+                      target="_blank"
+                      className="flex items-center gap-1.5 hover:text-primary transition-colors bg-muted/50 px-2 py-0.5 rounded-md text-sm border"
+                    >
+                      {profile.user.provider === "gitlab" ? (
+                        <Gitlab className="h-3.5 w-3.5" />
+                      ) : (
+                        <Github className="h-3.5 w-3.5" />
+                      )}
+                      {profile.user.github_username}
+                    </Link>
+                  ) : (
+                    <span className="bg-muted/50 px-2 py-0.5 rounded-md text-sm border">
+                      {profile.user.github_username}
+                    </span>
+                  )}
                   <span className="text-muted-foreground/30 hidden sm:inline">
                     |
                   </span>
@@ -82,10 +98,8 @@ const ProfilePage = async ({ params }: PageProps) => {
                     {profile.docker.username} (Docker Hub)
                   </span>
                 </div>
-                {profile.user.bio && (
-                  <p className="text-base text-muted-foreground mt-3 max-w-xl leading-relaxed">
-                    {profile.user.bio}
-                  </p>
+                {profile.user.bio_html && (
+                  <MarkdownBio html={profile.user.bio_html} />
                 )}
               </div>
             </div>
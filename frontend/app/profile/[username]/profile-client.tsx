@@ -9,16 +9,21 @@ import {
   ArrowLeft,
   Loader2,
   Github,
+  Gitlab,
   Share2,
   Copy,
   Check,
   FileJson,
+  Award,
 } from "lucide-react";
 import Link from "next/link";
 import { useState } from "react";
 import { ProfileData } from "@/lib/schemas";
 import { useToast } from "@/hooks/use-toast";
+import { MarkdownBio } from "@/components/shared/markdown-bio";
 import { HeatmapViewer } from "@/components/dashboard/heatmap-viewer";
+import { TopReposCard } from "@/components/shared/top-repos-card";
+import { DayDetailCard } from "@/components/shared/day-detail-card";
 
 interface ProfileClientProps {
   username: string;
@@ -27,6 +32,7 @@ interface ProfileClientProps {
 export function ProfileClient({ username }: ProfileClientProps) {
   const { toast } = useToast();
   const [copied, setCopied] = useState(false);
+  const [selectedDay, setSelectedDay] = useState<string | null>(null);
   const {
     data: profile,
     isLoading,
@@ -75,14 +81,24 @@ export function ProfileClient({ username }: ProfileClientProps) {
                 {profile.user.name || profile.user.github_username}
               </h1>
               <div className="flex flex-wrap items-center gap-3 text-muted-foreground">
-                <Link
-                  href={`https://github.com/${profile.user.github_username}`}
-                  target="_blank"
-                  className="flex items-center gap-1.5 hover:text-primary transition-colors bg-muted/50 px-2 py-0.5 rounded-md text-sm border shrink-0"
-                >
-                  <Github className="h-3.5 w-3.5" />
-                  {profile.user.github_username}
-                </Link>
+                {profile.user.profile_url ? (
+                  <Link
+                    href={profile.user.profile_url}
+                    target="_blank"
+                    className="flex items-center gap-1.5 hover:text-primary transition-colors bg-muted/50 px-2 py-0.5 rounded-md text-sm border shrink-0"
+                  >
+                    {profile.user.provider === "gitlab" ? (
+                      <Gitlab className="h-3.5 w-3.5" />
+                    ) : (
+                      <Github className="h-3.5 w-3.5" />
+                    )}
+                    {profile.user.github_username}
+                  </Link>
+                ) : (
+                  <span className="bg-muted/50 px-2 py-0.5 rounded-md text-sm border shrink-0">
+                    {profile.user.github_username}
+                  </span>
+                )}
                 <span className="text-muted-foreground/30 hidden sm:inline">
                   |
                 </span>
@@ -90,10 +106,24 @@ export function ProfileClient({ username }: ProfileClientProps) {
                   {profile.docker.username} (Docker Hub)
                 </span>
               </div>
-              {profile.user.bio && (
-                <p className="text-base text-muted-foreground mt-3 max-w-xl leading-relaxed">
-                  {profile.user.bio}
-                </p>
+              {profile.user.bio_html && (
+                <MarkdownBio html={profile.user.bio_html} />
+              )}
+              {profile.achievements && profile.achievements.length > 0 && (
+                <div className="flex flex-wrap gap-2 pt-2">
+                  {profile.achievements.map((achievement) => (
+                    <span
+                      key={achievement.key}
+                      title={`${achievement.description} (${new Date(
+                        achievement.earned_at,
+                      ).toLocaleDateString()})`}
+                      className="flex items-center gap-1.5 bg-amber-500/10 text-amber-600 dark:text-amber-400 px-2 py-0.5 rounded-md text-xs border border-amber-500/20 font-medium"
+                    >
+                      <Award className="h-3.5 w-3.5" />
+                      {achievement.name}
+                    </span>
+                  ))}
+                </div>
               )}
             </div>
           </div>
@@ -156,14 +186,30 @@ export function ProfileClient({ username }: ProfileClientProps) {
               <HeatmapViewer
                 username={username}
                 options={{ theme: "github", days: 365 }}
+                onDayClick={setSelectedDay}
               />
               <p className="text-xs text-muted-foreground mt-6 text-center">
-                Hover over tiles to see activity details
+                Hover over tiles to see activity details, click for the full day
               </p>
             </div>
           </CardContent>
         </Card>
 
+        {selectedDay && (
+          <div className="mt-8">
+            <DayDetailCard
+              username={profile.docker.username}
+              date={selectedDay}
+              onClose={() => setSelectedDay(null)}
+            />
+          </div>
+        )}
+
+        {/* Repositories */}
+        <div className="mt-8">
+          <TopReposCard username={profile.docker.username} />
+        </div>
+
         {/* CTA */}
         <div className="mt-20 text-center border-t pt-16 pb-12">
           <h2 className="text-2xl md:text-3xl font-bold mb-4">
@@ -0,0 +1,120 @@
+"use client";
+
+import { useInfiniteQuery } from "@tanstack/react-query";
+import { Loader2, ScrollText } from "lucide-react";
+import { userApi } from "@/lib/api";
+import { Button } from "@/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+const actionLabels: Record<string, string> = {
+  "docker.connect": "Connected Docker Hub",
+  "docker.disconnect": "Disconnected Docker Hub",
+  "docker.restore": "Restored Docker Hub",
+  "docker.sync": "Started a sync",
+  "docker.update": "Changed the sync schedule",
+  "docker.token_invalid": "Docker Hub rejected the access token",
+  "profile.update": "Updated profile",
+  "auth.login": "Signed in",
+  "auth.password_reset": "Reset password",
+  "auth.device_approve": "Approved a device login",
+  "session.revoke": "Revoked a session",
+  "embed_token.create": "Created an embed token",
+  "embed_token.update": "Changed where an embed token works",
+  "embed_token.revoke": "Revoked an embed token",
+  "ingest_key.create": "Created an ingest key",
+  "ingest_key.revoke": "Revoked an ingest key",
+  "webhook.create": "Created a webhook",
+  "webhook.delete": "Deleted a webhook",
+  "data_export.request": "Requested a data export",
+  "data_export.download": "Downloaded a data export",
+  "activity.import": "Imported activity",
+  "account.delete_request": "Asked to delete the account",
+  "account.delete_confirm": "Confirmed deleting the account",
+  "account.delete_cancel": "Cancelled deleting the account",
+  "admin.sync": "An admin started a sync",
+  "admin.disable": "An admin disabled syncs",
+  "admin.enable": "An admin enabled syncs",
+  "admin.role": "An admin changed your admin role",
+  "admin.suspend": "An admin suspended your account",
+  "admin.unsuspend": "An admin lifted your suspension",
+};
+
+export function AuditLogCard() {
+  const { data, isLoading, fetchNextPage, hasNextPage, isFetchingNextPage } =
+    useInfiniteQuery({
+      queryKey: ["audit-log"],
+      queryFn: ({ pageParam }) => userApi.getAuditLog(pageParam),
+      initialPageParam: undefined as number | undefined,
+      getNextPageParam: (page) => page.next_before ?? undefined,
+    });
+
+  const events = data?.pages.flatMap((page) => page.events) ?? [];
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <ScrollText className="h-4 w-4" />
+          Audit Log
+        </CardTitle>
+        <CardDescription>
+          Changes to your account and sign-ins, with where they came from.
+          Entries are kept for a year.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : events.length === 0 ? (
+          <p className="text-sm text-muted-foreground">Nothing recorded yet.</p>
+        ) : (
+          <div className="space-y-3">
+            <ul className="max-h-80 space-y-2 overflow-y-auto">
+              {events.map((event) => (
+                <li
+                  key={event.id}
+                  className="rounded-md border p-3 text-sm"
+                  title={event.user_agent}
+                >
+                  <p className="font-medium">
+                    {actionLabels[event.action] || event.action}
+                    {event.detail && (
+                      <span className="ml-2 font-normal text-muted-foreground">
+                        {event.detail}
+                      </span>
+                    )}
+                  </p>
+                  <p className="text-xs text-muted-foreground">
+                    {new Date(event.created_at).toLocaleString()}
+                    {event.ip_address && ` · ${event.ip_address}`}
+                  </p>
+                </li>
+              ))}
+            </ul>
+            {hasNextPage && (
+              <Button
+                variant="outline"
+                size="sm"
+                onClick={() => fetchNextPage()}
+                disabled={isFetchingNextPage}
+              >
+                {isFetchingNextPage && (
+                  <Loader2 className="mr-2 h-4 w-4 animate-spin" />
+                )}
+                Load more
+              </Button>
+            )}
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,188 @@
+"use client";
+
+import { type ChangeEvent, useRef, useState } from "react";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { Download, Loader2, PackageOpen, Upload } from "lucide-react";
+import { userApi } from "@/lib/api";
+import type { ImportFormat } from "@/lib/schemas";
+import { useToast } from "@/hooks/use-toast";
+import { Button } from "@/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+function formatSize(bytes: number): string {
+  if (bytes < 1024 * 1024) return `${Math.max(1, Math.round(bytes / 1024))} KB`;
+  return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+}
+
+export function DataExportCard() {
+  const { toast } = useToast();
+  const queryClient = useQueryClient();
+
+  const { data: dataExport, isLoading } = useQuery({
+    queryKey: ["data-export"],
+    queryFn: userApi.getDataExport,
+    // Check back while the archive is being built
+    refetchInterval: (query) => {
+      const status = query.state.data?.status;
+      return status === "pending" || status === "running" ? 5000 : false;
+    },
+  });
+
+  const requestMutation = useMutation({
+    mutationFn: userApi.requestDataExport,
+    onSuccess: (created) => queryClient.setQueryData(["data-export"], created),
+    onError: (error: Error) =>
+      toast({
+        title: "Error",
+        description: error.message,
+        variant: "destructive",
+      }),
+  });
+
+  const fileInput = useRef<HTMLInputElement>(null);
+  const [githubImport, setGithubImport] = useState(false);
+
+  const importMutation = useMutation({
+    mutationFn: ({ file, format }: { file: File; format: ImportFormat }) =>
+      userApi.importActivity(file, format),
+    onSuccess: ({ imported, duplicates, expired }) => {
+      toast({
+        title: `Imported ${imported} ${imported === 1 ? "row" : "rows"}`,
+        description:
+          `Skipped ${duplicates} already recorded and ` +
+          `${expired} too old to keep.`,
+      });
+      queryClient.invalidateQueries({ queryKey: ["activity"] });
+    },
+    onError: (error: Error) =>
+      toast({
+        title: "Import failed",
+        description: error.message,
+        variant: "destructive",
+      }),
+  });
+
+  const chooseFile = (github: boolean) => {
+    setGithubImport(github);
+    fileInput.current?.click();
+  };
+
+  const onFile = (e: ChangeEvent<HTMLInputElement>) => {
+    const file = e.target.files?.[0];
+    e.target.value = "";
+    if (!file) return;
+    // Events exports are CSV or JSON; contributions calendars are JSON
+    let format: ImportFormat = file.name.endsWith(".csv") ? "csv" : "json";
+    if (githubImport) format = "github";
+    importMutation.mutate({ file, format });
+  };
+
+  const building =
+    dataExport?.status === "pending" || dataExport?.status === "running";
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <PackageOpen className="h-4 w-4" />
+          Your Data
+        </CardTitle>
+        <CardDescription>
+          Download a zip of everything stored about you: your profile, Docker
+          accounts and every activity event. Access tokens and other secrets
+          are left out.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3 text-sm">
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : (
+          <>
+            {building && (
+              <p className="flex items-center gap-2 text-muted-foreground">
+                <Loader2 className="h-4 w-4 animate-spin" />
+                Preparing your export…
+              </p>
+            )}
+            {dataExport?.status === "ready" && dataExport.download_url && (
+              <div className="flex items-center justify-between gap-2 rounded-md border p-3">
+                <p className="text-xs text-muted-foreground">
+                  Ready · {formatSize(dataExport.size_bytes)} · Link expires{" "}
+                  {new Date(dataExport.expires_at!).toLocaleDateString()}
+                </p>
+                <Button asChild size="sm" variant="outline">
+                  <a href={dataExport.download_url}>
+                    <Download className="mr-2 h-4 w-4" />
+                    Download
+                  </a>
+                </Button>
+              </div>
+            )}
+            {dataExport?.status === "failed" && (
+              <p className="text-destructive">
+                {dataExport.failure || "The export failed."} Please try again.
+              </p>
+            )}
+            {!building && (
+              <Button
+                size="sm"
+                onClick={() => requestMutation.mutate()}
+                disabled={requestMutation.isPending}
+              >
+                {requestMutation.isPending && (
+                  <Loader2 className="mr-2 h-4 w-4 animate-spin" />
+                )}
+                Request export
+              </Button>
+            )}
+          </>
+        )}
+        <div className="space-y-2 border-t pt-3">
+          <p className="text-xs text-muted-foreground">
+            Import history from an events CSV or JSON export, or seed it from
+            a GitHub contributions calendar. Days already recorded are
+            skipped.
+          </p>
+          <input
+            ref={fileInput}
+            type="file"
+            accept=".csv,.json,.jsonl"
+            className="hidden"
+            onChange={onFile}
+          />
+          <div className="flex flex-wrap gap-2">
+            <Button
+              size="sm"
+              variant="outline"
+              onClick={() => chooseFile(false)}
+              disabled={importMutation.isPending}
+            >
+              {importMutation.isPending ? (
+                <Loader2 className="mr-2 h-4 w-4 animate-spin" />
+              ) : (
+                <Upload className="mr-2 h-4 w-4" />
+              )}
+              Import events
+            </Button>
+            <Button
+              size="sm"
+              variant="ghost"
+              onClick={() => chooseFile(true)}
+              disabled={importMutation.isPending}
+            >
+              Import GitHub contributions
+            </Button>
+          </div>
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,121 @@
+"use client";
+
+import { useState } from "react";
+import { useMutation } from "@tanstack/react-query";
+import { Loader2, Trash2 } from "lucide-react";
+import { userApi } from "@/lib/api";
+import { useAuth } from "@/context/auth-context";
+import { useToast } from "@/hooks/use-toast";
+import { Button } from "@/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+export function DeleteAccountCard() {
+  const { toast } = useToast();
+  const { user, refreshUser } = useAuth();
+  const [confirming, setConfirming] = useState(false);
+
+  const onError = (error: Error) =>
+    toast({
+      title: "Error",
+      description: error.message,
+      variant: "destructive",
+    });
+
+  const deleteMutation = useMutation({
+    mutationFn: userApi.deleteAccount,
+    onSuccess: ({ message }) => {
+      setConfirming(false);
+      toast({ title: "Check your email", description: message });
+    },
+    onError,
+  });
+
+  const cancelMutation = useMutation({
+    mutationFn: userApi.cancelAccountDeletion,
+    onSuccess: () => {
+      toast({ title: "Your account will be kept" });
+      refreshUser();
+    },
+    onError,
+  });
+
+  const deleteAt = user?.deletion_scheduled_at;
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <Trash2 className="h-4 w-4" />
+          Delete Account
+        </CardTitle>
+        <CardDescription>
+          Delete your account, your Docker accounts and all their activity.
+          We&apos;ll email you a link to confirm first.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3 text-sm">
+        {deleteAt ? (
+          <>
+            <p className="text-destructive">
+              Your account will be deleted on{" "}
+              {new Date(deleteAt).toLocaleString()}. Its heatmaps are no
+              longer shown.
+            </p>
+            <Button
+              size="sm"
+              variant="outline"
+              onClick={() => cancelMutation.mutate()}
+              disabled={cancelMutation.isPending}
+            >
+              {cancelMutation.isPending && (
+                <Loader2 className="mr-2 h-4 w-4 animate-spin" />
+              )}
+              Keep my account
+            </Button>
+          </>
+        ) : confirming ? (
+          <>
+            <p>
+              This can&apos;t be undone once the grace period is over. Download
+              your data first if you want a copy.
+            </p>
+            <div className="flex gap-2">
+              <Button
+                size="sm"
+                variant="destructive"
+                onClick={() => deleteMutation.mutate()}
+                disabled={deleteMutation.isPending}
+              >
+                {deleteMutation.isPending && (
+                  <Loader2 className="mr-2 h-4 w-4 animate-spin" />
+                )}
+                Email me a confirmation link
+              </Button>
+              <Button
+                size="sm"
+                variant="ghost"
+                onClick={() => setConfirming(false)}
+              >
+                Cancel
+              </Button>
+            </div>
+          </>
+        ) : (
+          <Button
+            size="sm"
+            variant="destructive"
+            onClick={() => setConfirming(true)}
+          >
+            Delete account
+          </Button>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
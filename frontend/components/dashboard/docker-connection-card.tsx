@@ -1,12 +1,12 @@
 "use client";
 
-import { Check, Loader2, RefreshCw, Clock } from "lucide-react";
+import { Check, Loader2, RefreshCw, Clock, Trash2 } from "lucide-react";
 import { useForm } from "react-hook-form";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { Card, CardContent } from "@/components/ui/card";
-import { ConnectDockerRequest } from "@/lib/schemas";
+import { ConnectDockerRequest, SyncProgress } from "@/lib/schemas";
 
 interface DockerConnectionCardProps {
   isLoading: boolean;
@@ -14,14 +14,18 @@ interface DockerConnectionCardProps {
   account?: {
     docker_username: string;
     last_sync_at: string | null;
+    disconnect_scheduled_at?: string | null;
   } | null;
   form: ReturnType<typeof useForm<ConnectDockerRequest>>;
   onSubmit: (data: ConnectDockerRequest) => void;
   onSync: () => void;
   onDisconnect: () => void;
+  onUndoDisconnect: () => void;
   isConnecting: boolean;
   isSyncing: boolean;
+  syncProgress?: SyncProgress | null;
   isDisconnecting: boolean;
+  isRestoring: boolean;
 }
 
 export function DockerConnectionCard({
@@ -32,10 +36,30 @@ export function DockerConnectionCard({
   onSubmit,
   onSync,
   onDisconnect,
+  onUndoDisconnect,
   isConnecting,
   isSyncing,
+  syncProgress,
   isDisconnecting,
+  isRestoring,
 }: DockerConnectionCardProps) {
+  const showProgress =
+    isSyncing &&
+    !!syncProgress &&
+    syncProgress.stage !== "done" &&
+    syncProgress.stage !== "failed";
+
+  const progressLabel = (progress: SyncProgress) => {
+    switch (progress.stage) {
+      case "queued":
+        return "Waiting to start...";
+      case "fetching":
+        return "Listing repositories...";
+      default:
+        return `${progress.done} of ${progress.total} repositories`;
+    }
+  };
+
   const formatSyncTime = (dateStr: string) => {
     const date = new Date(dateStr);
     return date.toLocaleString(undefined, {
@@ -53,6 +77,33 @@ export function DockerConnectionCard({
           <div className="py-8 flex justify-center">
             <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
           </div>
+        ) : hasAccount && account?.disconnect_scheduled_at ? (
+          <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-6">
+            <div className="flex items-center gap-4">
+              <div className="flex-shrink-0 flex items-center justify-center w-10 h-10 rounded-full bg-destructive/10">
+                <Trash2 className="h-5 w-5 text-destructive" />
+              </div>
+              <div className="min-w-0">
+                <p className="font-medium truncate">
+                  {account.docker_username}
+                </p>
+                <p className="text-sm text-muted-foreground mt-0.5">
+                  Disconnected. Your activity data will be deleted on{" "}
+                  {formatSyncTime(account.disconnect_scheduled_at)}.
+                </p>
+              </div>
+            </div>
+            <Button
+              variant="outline"
+              size="sm"
+              onClick={onUndoDisconnect}
+              disabled={isRestoring}
+              className="whitespace-nowrap"
+            >
+              {isRestoring && <Loader2 className="h-4 w-4 mr-2 animate-spin" />}
+              Undo Disconnect
+            </Button>
+          </div>
         ) : hasAccount && account ? (
           <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-6">
             <div className="flex items-center gap-4">
@@ -71,6 +122,28 @@ export function DockerConnectionCard({
                       : "Syncing..."}
                   </span>
                 </div>
+                {showProgress && syncProgress && (
+                  <div className="mt-2 w-56 max-w-full">
+                    <div className="h-1.5 rounded-full bg-muted overflow-hidden">
+                      <div
+                        className="h-full bg-primary transition-all"
+                        style={{
+                          width: `${
+                            syncProgress.total > 0
+                              ? Math.round(
+                                  (syncProgress.done / syncProgress.total) *
+                                    100,
+                                )
+                              : 0
+                          }%`,
+                        }}
+                      />
+                    </div>
+                    <p className="text-xs text-muted-foreground mt-1 truncate">
+                      {progressLabel(syncProgress)}
+                    </p>
+                  </div>
+                )}
               </div>
             </div>
             <div className="flex flex-col items-start sm:items-end gap-2">
@@ -98,7 +171,7 @@ export function DockerConnectionCard({
                 </Button>
               </div>
               <p className="text-[10px] text-muted-foreground mr-2">
-                Permanently removes all your activity data.
+                Deletes your activity data after a grace period.
               </p>
             </div>
           </div>
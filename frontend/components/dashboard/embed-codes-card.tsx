@@ -1,9 +1,19 @@
 "use client";
 
+import { useState } from "react";
 import Link from "next/link";
-import { Check, Copy, ExternalLink, Link as LinkIcon } from "lucide-react";
+import { useMutation } from "@tanstack/react-query";
+import {
+  Check,
+  Copy,
+  ExternalLink,
+  Link as LinkIcon,
+  Loader2,
+  Lock,
+} from "lucide-react";
 import { Button } from "@/components/ui/button";
-import { publicApi } from "@/lib/api";
+import { publicApi, userApi } from "@/lib/api";
+import type { SignedEmbed } from "@/lib/schemas";
 import {
   Card,
   CardContent,
@@ -12,10 +22,21 @@ import {
   CardTitle,
 } from "@/components/ui/card";
 import { Label } from "@/components/ui/label";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+
+const signatureDays = [7, 30, 90, 365];
 
 interface EmbedCodesCardProps {
   customUrl: string;
   dockerUsername: string;
+  // Private profiles' images are only served from signed URLs
+  isPrivate: boolean;
   copied: string | null;
   onCopy: (text: string, type: string) => void;
 }
@@ -23,13 +44,35 @@ interface EmbedCodesCardProps {
 export function EmbedCodesCard({
   customUrl,
   dockerUsername,
+  isPrivate,
   copied,
   onCopy,
 }: EmbedCodesCardProps) {
-  const markdownCode = `![Docker Activity](${customUrl})`;
-  const htmlCode = `<img src="${customUrl}" alt="Docker Activity Heatmap" />`;
+  const [days, setDays] = useState("30");
+  const [signed, setSigned] = useState<SignedEmbed | null>(null);
+
+  const signMutation = useMutation({
+    mutationFn: () => {
+      // Sign the display options in use, minus the preview's cache buster
+      const query = new URL(customUrl).searchParams;
+      query.delete("t");
+      return userApi.signEmbed({
+        docker_username: dockerUsername,
+        days: Number(days),
+        query: query.toString(),
+      });
+    },
+    onSuccess: setSigned,
+  });
 
-  const activityJsonUrl = publicApi.getActivityUrl(dockerUsername, 365);
+  const embedUrl = signed?.svg_url ?? customUrl;
+  const markdownCode = `![Docker Activity](${embedUrl})`;
+  const htmlCode = `<img src="${embedUrl}" alt="Docker Activity Heatmap" />`;
+
+  const widgetCode = `<div data-docker-heatmap="${dockerUsername}"></div> <script src="${publicApi.getEmbedScriptUrl()}" async></script>`;
+
+  const activityJsonUrl =
+    signed?.json_url ?? publicApi.getActivityUrl(dockerUsername, 365);
 
   const codes = [
     {
@@ -40,7 +83,7 @@ export function EmbedCodesCard({
     },
     {
       label: "Image URL",
-      value: customUrl,
+      value: embedUrl,
       type: "url",
       icon: "🔗",
     },
@@ -50,13 +93,19 @@ export function EmbedCodesCard({
       type: "html",
       icon: "🌐",
     },
+    {
+      label: "Interactive Widget (tooltips and theme switcher)",
+      value: widgetCode,
+      type: "widget",
+      icon: "✨",
+    },
     {
       label: "Raw JSON Data",
       value: activityJsonUrl,
       type: "json",
       icon: "📊",
     },
-  ];
+  ].filter(({ type }) => !isPrivate || type !== "widget");
 
   return (
     <Card>
@@ -70,30 +119,79 @@ export function EmbedCodesCard({
         </CardDescription>
       </CardHeader>
       <CardContent className="space-y-4">
-        {codes.map(({ label, value, type, icon }) => (
-          <div key={type} className="space-y-2">
-            <Label className="text-sm text-muted-foreground flex items-center gap-2">
-              <span>{icon}</span> {label}
-            </Label>
-            <div className="flex gap-2">
-              <code className="flex-1 text-xs bg-muted px-3 py-2.5 rounded-md overflow-x-auto whitespace-nowrap font-mono">
-                {value}
-              </code>
+        {isPrivate && (
+          <div className="rounded-md border bg-muted/30 p-4 space-y-3">
+            <p className="text-sm flex items-center gap-2">
+              <Lock className="h-4 w-4" />
+              Your profile is private, so embeds need a signed URL.
+            </p>
+            <p className="text-xs text-muted-foreground">
+              Anyone with a signed URL can see your heatmap and activity until
+              it expires, so only put it somewhere private, such as an
+              internal wiki.
+            </p>
+            <div className="flex flex-col sm:flex-row gap-2">
+              <Select value={days} onValueChange={setDays}>
+                <SelectTrigger className="h-9 sm:w-40">
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  {signatureDays.map((d) => (
+                    <SelectItem key={d} value={String(d)}>
+                      Expires in {d} days
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
               <Button
-                variant="outline"
-                size="icon"
-                className="h-9 w-9 shrink-0"
-                onClick={() => onCopy(value, type)}
+                variant="secondary"
+                className="gap-2"
+                onClick={() => signMutation.mutate()}
+                disabled={signMutation.isPending}
               >
-                {copied === type ? (
-                  <Check className="h-4 w-4 text-green-500" />
-                ) : (
-                  <Copy className="h-4 w-4" />
+                {signMutation.isPending && (
+                  <Loader2 className="h-4 w-4 animate-spin" />
                 )}
+                {signed ? "Create new signed URL" : "Create signed URL"}
               </Button>
             </div>
+            {signed && (
+              <p className="text-xs text-muted-foreground">
+                Works until {new Date(signed.expires_at).toLocaleDateString()}.
+              </p>
+            )}
+            {signMutation.isError && (
+              <p className="text-xs text-destructive">
+                {signMutation.error.message}
+              </p>
+            )}
           </div>
-        ))}
+        )}
+        {(!isPrivate || signed) &&
+          codes.map(({ label, value, type, icon }) => (
+            <div key={type} className="space-y-2">
+              <Label className="text-sm text-muted-foreground flex items-center gap-2">
+                <span>{icon}</span> {label}
+              </Label>
+              <div className="flex gap-2">
+                <code className="flex-1 text-xs bg-muted px-3 py-2.5 rounded-md overflow-x-auto whitespace-nowrap font-mono">
+                  {value}
+                </code>
+                <Button
+                  variant="outline"
+                  size="icon"
+                  className="h-9 w-9 shrink-0"
+                  onClick={() => onCopy(value, type)}
+                >
+                  {copied === type ? (
+                    <Check className="h-4 w-4 text-green-500" />
+                  ) : (
+                    <Copy className="h-4 w-4" />
+                  )}
+                </Button>
+              </div>
+            </div>
+          ))}
 
         <div className="pt-6 border-t space-y-4">
           <div className="flex flex-col sm:flex-row items-center gap-3">
@@ -0,0 +1,218 @@
+"use client";
+
+import { useState } from "react";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { Loader2, Ticket, X } from "lucide-react";
+import { publicApi, userApi } from "@/lib/api";
+import { useToast } from "@/hooks/use-toast";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+interface EmbedTokensCardProps {
+  dockerUsername: string;
+}
+
+// Splits a comma or space separated list of sites
+function parseOrigins(value: string): string[] {
+  return value.split(/[\s,]+/).filter(Boolean);
+}
+
+export function EmbedTokensCard({ dockerUsername }: EmbedTokensCardProps) {
+  const { toast } = useToast();
+  const queryClient = useQueryClient();
+  const [name, setName] = useState("");
+  const [origins, setOrigins] = useState("");
+  // The token whose allowed sites are being edited
+  const [editing, setEditing] = useState<{ id: number; origins: string }>();
+  // Shown until dismissed, since the token can't be read back
+  const [created, setCreated] = useState<string | null>(null);
+
+  const { data, isLoading } = useQuery({
+    queryKey: ["embed-tokens"],
+    queryFn: userApi.getEmbedTokens,
+  });
+
+  const onError = (error: Error) =>
+    toast({
+      title: "Error",
+      description: error.message,
+      variant: "destructive",
+    });
+  const refresh = () =>
+    queryClient.invalidateQueries({ queryKey: ["embed-tokens"] });
+
+  const createMutation = useMutation({
+    mutationFn: userApi.createEmbedToken,
+    onSuccess: (result) => {
+      setName("");
+      setOrigins("");
+      setCreated(result.value);
+      refresh();
+    },
+    onError,
+  });
+
+  const updateMutation = useMutation({
+    mutationFn: userApi.updateEmbedToken,
+    onSuccess: () => {
+      setEditing(undefined);
+      refresh();
+    },
+    onError,
+  });
+
+  const revokeMutation = useMutation({
+    mutationFn: userApi.revokeEmbedToken,
+    onSuccess: refresh,
+    onError,
+  });
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <Ticket className="h-4 w-4" />
+          Embed Tokens
+        </CardTitle>
+        <CardDescription>
+          Show a private profile&apos;s heatmap in one place, such as a
+          company wiki, by adding <code className="font-mono">?token=</code>{" "}
+          to the image URL. Limit a token to the sites that should use it,
+          and revoke it to take it back.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {created && (
+          <div className="rounded-md border border-yellow-500/50 bg-yellow-500/10 p-3 text-sm space-y-1">
+            <p className="font-medium">
+              Copy the URL now; the token won&apos;t be shown again.
+            </p>
+            <p className="break-all">
+              <code className="font-mono bg-muted px-1 rounded">
+                {publicApi.getHeatmapUrl(dockerUsername)}?token={created}
+              </code>
+            </p>
+            <Button variant="outline" size="sm" onClick={() => setCreated(null)}>
+              Done
+            </Button>
+          </div>
+        )}
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : (
+          <ul className="space-y-3">
+            {data?.tokens.map((token) => (
+              <li
+                key={token.id}
+                className="flex items-center justify-between gap-2 rounded-md border p-3 text-sm"
+              >
+                <div className="min-w-0 flex-1">
+                  <p className="font-medium">{token.name}</p>
+                  <p className="text-xs text-muted-foreground">
+                    <code className="font-mono">{token.prefix}…</code>
+                    {" · "}
+                    {token.last_used_at
+                      ? `Last used ${new Date(token.last_used_at).toLocaleDateString()}`
+                      : "Never used"}
+                  </p>
+                  {editing?.id === token.id ? (
+                    <form
+                      className="mt-2 flex gap-2"
+                      onSubmit={(e) => {
+                        e.preventDefault();
+                        updateMutation.mutate({
+                          id: token.id,
+                          allowedOrigins: parseOrigins(editing.origins),
+                        });
+                      }}
+                    >
+                      <Input
+                        value={editing.origins}
+                        onChange={(e) =>
+                          setEditing({ id: token.id, origins: e.target.value })
+                        }
+                        placeholder="Any site"
+                        className="h-8"
+                        autoFocus
+                      />
+                      <Button
+                        type="submit"
+                        size="sm"
+                        disabled={updateMutation.isPending}
+                      >
+                        Save
+                      </Button>
+                    </form>
+                  ) : (
+                    <button
+                      type="button"
+                      className="text-xs text-muted-foreground underline-offset-2 hover:underline break-all text-left"
+                      onClick={() =>
+                        setEditing({
+                          id: token.id,
+                          origins: token.allowed_origins.replace(/,/g, ", "),
+                        })
+                      }
+                    >
+                      {token.allowed_origins
+                        ? `Only ${token.allowed_origins.replace(/,/g, ", ")}`
+                        : "Any site"}
+                    </button>
+                  )}
+                </div>
+                <Button
+                  variant="ghost"
+                  size="sm"
+                  onClick={() => revokeMutation.mutate(token.id)}
+                  disabled={revokeMutation.isPending}
+                  aria-label={`Revoke ${token.name}`}
+                >
+                  <X className="h-4 w-4" />
+                </Button>
+              </li>
+            ))}
+          </ul>
+        )}
+        <form
+          className="space-y-2"
+          onSubmit={(e) => {
+            e.preventDefault();
+            if (name.trim())
+              createMutation.mutate({
+                name: name.trim(),
+                allowedOrigins: parseOrigins(origins),
+              });
+          }}
+        >
+          <div className="flex gap-2">
+            <Input
+              value={name}
+              onChange={(e) => setName(e.target.value)}
+              placeholder="company-wiki"
+              maxLength={100}
+              className="h-9"
+            />
+            <Button type="submit" size="sm" disabled={createMutation.isPending}>
+              Create
+            </Button>
+          </div>
+          <Input
+            value={origins}
+            onChange={(e) => setOrigins(e.target.value)}
+            placeholder="Allowed sites, such as https://wiki.example.com (optional)"
+            className="h-9"
+          />
+        </form>
+      </CardContent>
+    </Card>
+  );
+}
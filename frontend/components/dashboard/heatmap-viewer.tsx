@@ -9,12 +9,17 @@ import { HeatmapGrid } from "@/components/shared/heatmap-grid";
 interface HeatmapViewerProps {
   username: string;
   options?: SVGOptions;
+  onDayClick?: (date: string) => void;
 }
 
 import { useTheme } from "next-themes";
 import { useEffect, useState } from "react";
 
-export const HeatmapViewer = ({ username, options }: HeatmapViewerProps) => {
+export const HeatmapViewer = ({
+  username,
+  options,
+  onDayClick,
+}: HeatmapViewerProps) => {
   const days = options?.days || 365;
   const { resolvedTheme } = useTheme();
   const [mounted, setMounted] = useState(false);
@@ -25,8 +30,8 @@ export const HeatmapViewer = ({ username, options }: HeatmapViewerProps) => {
   }, []);
 
   const { data, isLoading, error } = useQuery({
-    queryKey: ["activity", username, days],
-    queryFn: () => publicApi.getActivity(username, days),
+    queryKey: ["activity", username, days, "repo"],
+    queryFn: () => publicApi.getActivity(username, days, "repo"),
     staleTime: 1000 * 60 * 5, // 5 minutes
   });
 
@@ -60,6 +65,7 @@ export const HeatmapViewer = ({ username, options }: HeatmapViewerProps) => {
       themeId={getThemeId()}
       hideLegend={options?.hide_legend}
       totalCount={data.totals?.activities}
+      onDayClick={onDayClick}
     />
   );
 };
@@ -0,0 +1,148 @@
+"use client";
+
+import { useState } from "react";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { KeyRound, Loader2, X } from "lucide-react";
+import { dockerApi } from "@/lib/api";
+import { useToast } from "@/hooks/use-toast";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+export function IngestKeysCard() {
+  const { toast } = useToast();
+  const queryClient = useQueryClient();
+  const [name, setName] = useState("");
+  // Shown until dismissed, since the secret can't be read back
+  const [created, setCreated] = useState<{
+    keyId: string;
+    secret: string;
+  } | null>(null);
+
+  const { data, isLoading } = useQuery({
+    queryKey: ["ingest-keys"],
+    queryFn: dockerApi.getIngestKeys,
+  });
+
+  const onError = (error: Error) =>
+    toast({
+      title: "Error",
+      description: error.message,
+      variant: "destructive",
+    });
+  const refresh = () =>
+    queryClient.invalidateQueries({ queryKey: ["ingest-keys"] });
+
+  const createMutation = useMutation({
+    mutationFn: dockerApi.createIngestKey,
+    onSuccess: (result) => {
+      setName("");
+      setCreated({ keyId: result.key.key_id, secret: result.secret });
+      refresh();
+    },
+    onError,
+  });
+
+  const deleteMutation = useMutation({
+    mutationFn: dockerApi.deleteIngestKey,
+    onSuccess: refresh,
+    onError,
+  });
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <KeyRound className="h-4 w-4" />
+          Ingest Keys
+        </CardTitle>
+        <CardDescription>
+          Record builds, pulls or private registry pushes from CI with signed
+          requests to <code className="font-mono">/ingest/events</code>
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {created && (
+          <div className="rounded-md border border-yellow-500/50 bg-yellow-500/10 p-3 text-sm space-y-1">
+            <p className="font-medium">
+              Copy the secret now; it won&apos;t be shown again.
+            </p>
+            <p>
+              Key:{" "}
+              <code className="font-mono bg-muted px-1 rounded">
+                {created.keyId}
+              </code>
+            </p>
+            <p className="break-all">
+              Secret:{" "}
+              <code className="font-mono bg-muted px-1 rounded">
+                {created.secret}
+              </code>
+            </p>
+            <Button variant="outline" size="sm" onClick={() => setCreated(null)}>
+              Done
+            </Button>
+          </div>
+        )}
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : (
+          <ul className="space-y-3">
+            {data?.keys.map((key) => (
+              <li
+                key={key.id}
+                className="flex items-center justify-between gap-2 rounded-md border p-3 text-sm"
+              >
+                <div>
+                  <p className="font-medium">{key.name}</p>
+                  <p className="text-xs text-muted-foreground">
+                    <code className="font-mono">{key.key_id}</code>
+                    {" · "}
+                    {key.last_used_at
+                      ? `Last used ${new Date(key.last_used_at).toLocaleDateString()}`
+                      : "Never used"}
+                  </p>
+                </div>
+                <Button
+                  variant="ghost"
+                  size="sm"
+                  onClick={() => deleteMutation.mutate(key.id)}
+                  disabled={deleteMutation.isPending}
+                  aria-label={`Revoke ${key.name}`}
+                >
+                  <X className="h-4 w-4" />
+                </Button>
+              </li>
+            ))}
+          </ul>
+        )}
+        <form
+          className="flex gap-2"
+          onSubmit={(e) => {
+            e.preventDefault();
+            if (name.trim()) createMutation.mutate(name.trim());
+          }}
+        >
+          <Input
+            value={name}
+            onChange={(e) => setName(e.target.value)}
+            placeholder="GitHub Actions"
+            maxLength={100}
+            className="h-9"
+          />
+          <Button type="submit" size="sm" disabled={createMutation.isPending}>
+            Create
+          </Button>
+        </form>
+      </CardContent>
+    </Card>
+  );
+}
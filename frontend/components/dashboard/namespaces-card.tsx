@@ -0,0 +1,162 @@
+"use client";
+
+import { useState } from "react";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { Building2, Check, Loader2, X } from "lucide-react";
+import { dockerApi } from "@/lib/api";
+import { useToast } from "@/hooks/use-toast";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+export function NamespacesCard() {
+  const { toast } = useToast();
+  const queryClient = useQueryClient();
+  const [namespace, setNamespace] = useState("");
+
+  const { data, isLoading } = useQuery({
+    queryKey: ["docker-namespaces"],
+    queryFn: dockerApi.getNamespaces,
+  });
+
+  const onError = (error: Error) =>
+    toast({
+      title: "Error",
+      description: error.message,
+      variant: "destructive",
+    });
+  const refresh = () =>
+    queryClient.invalidateQueries({ queryKey: ["docker-namespaces"] });
+
+  const claimMutation = useMutation({
+    mutationFn: dockerApi.claimNamespace,
+    onSuccess: () => {
+      setNamespace("");
+      refresh();
+    },
+    onError,
+  });
+
+  const verifyMutation = useMutation({
+    mutationFn: dockerApi.verifyNamespace,
+    onSuccess: (result) => {
+      toast({ title: "Verified", description: result.message });
+      refresh();
+    },
+    onError,
+  });
+
+  const removeMutation = useMutation({
+    mutationFn: dockerApi.removeNamespace,
+    onSuccess: refresh,
+    onError,
+  });
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <Building2 className="h-4 w-4" />
+          Organizations
+        </CardTitle>
+        <CardDescription>
+          Include activity from another namespace, such as an organization,
+          once you prove you control it
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : (
+          <ul className="space-y-3">
+            {data?.namespaces.map((claim) => (
+              <li
+                key={claim.namespace}
+                className="rounded-md border p-3 text-sm"
+              >
+                <div className="flex items-center justify-between gap-2">
+                  <span className="font-medium flex items-center gap-1.5">
+                    {claim.verified && (
+                      <Check className="h-4 w-4 text-green-500" />
+                    )}
+                    {claim.namespace}
+                  </span>
+                  <div className="flex items-center gap-1">
+                    {!claim.verified && (
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        onClick={() => verifyMutation.mutate(claim.namespace)}
+                        disabled={verifyMutation.isPending || claim.expired}
+                      >
+                        Verify
+                      </Button>
+                    )}
+                    <Button
+                      variant="ghost"
+                      size="sm"
+                      onClick={() => removeMutation.mutate(claim.namespace)}
+                      disabled={removeMutation.isPending}
+                      aria-label={`Remove ${claim.namespace}`}
+                    >
+                      <X className="h-4 w-4" />
+                    </Button>
+                  </div>
+                </div>
+                {!claim.verified && (
+                  <div className="mt-2 space-y-1 text-muted-foreground">
+                    {claim.expired ? (
+                      <p>
+                        This token has expired. Remove the claim and add it
+                        again.
+                      </p>
+                    ) : (
+                      <>
+                        <p>
+                          Token:{" "}
+                          <code className="font-mono bg-muted px-1 rounded">
+                            {claim.token}
+                          </code>
+                        </p>
+                        {claim.instructions?.map((step) => (
+                          <p key={step} className="text-xs">
+                            {step}
+                          </p>
+                        ))}
+                      </>
+                    )}
+                  </div>
+                )}
+              </li>
+            ))}
+          </ul>
+        )}
+        <form
+          className="flex gap-2"
+          onSubmit={(e) => {
+            e.preventDefault();
+            if (namespace) claimMutation.mutate(namespace);
+          }}
+        >
+          <Input
+            value={namespace}
+            onChange={(e) => setNamespace(e.target.value)}
+            placeholder="organization"
+            className="h-9"
+          />
+          <Button type="submit" size="sm" disabled={claimMutation.isPending}>
+            Claim
+          </Button>
+        </form>
+      </CardContent>
+    </Card>
+  );
+}
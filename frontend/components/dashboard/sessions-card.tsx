@@ -0,0 +1,129 @@
+"use client";
+
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { Loader2, MonitorSmartphone, X } from "lucide-react";
+import { userApi } from "@/lib/api";
+import type { Session } from "@/lib/schemas";
+import { useAuth } from "@/context/auth-context";
+import { useToast } from "@/hooks/use-toast";
+import { Button } from "@/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+// A short label for a session: the device's own name, or the browser and OS guessed
+// from its user agent
+function describe(session: Session): string {
+  if (session.name) return session.name;
+  const ua = session.user_agent || "";
+  const browser =
+    [
+      ["Edg/", "Edge"],
+      ["Firefox/", "Firefox"],
+      ["Chrome/", "Chrome"],
+      ["Safari/", "Safari"],
+    ].find(([token]) => ua.includes(token))?.[1] || "";
+  const os =
+    [
+      ["Windows", "Windows"],
+      ["Android", "Android"],
+      ["iPhone", "iOS"],
+      ["iPad", "iOS"],
+      ["Mac OS X", "macOS"],
+      ["Linux", "Linux"],
+    ].find(([token]) => ua.includes(token))?.[1] || "";
+  if (browser && os) return `${browser} on ${os}`;
+  return browser || os || ua.split(" ")[0] || "Unknown device";
+}
+
+export function SessionsCard() {
+  const { toast } = useToast();
+  const { logout } = useAuth();
+  const queryClient = useQueryClient();
+
+  const { data, isLoading } = useQuery({
+    queryKey: ["sessions"],
+    queryFn: userApi.getSessions,
+  });
+
+  const revokeMutation = useMutation({
+    mutationFn: (session: Session) => userApi.revokeSession(session.id),
+    onSuccess: (_, session) => {
+      // This browser's tokens no longer work
+      if (session.current) {
+        logout();
+        return;
+      }
+      queryClient.invalidateQueries({ queryKey: ["sessions"] });
+    },
+    onError: (error: Error) =>
+      toast({
+        title: "Error",
+        description: error.message,
+        variant: "destructive",
+      }),
+  });
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <MonitorSmartphone className="h-4 w-4" />
+          Sessions
+        </CardTitle>
+        <CardDescription>
+          Browsers and devices signed in to your account. Revoke any you
+          don&apos;t recognize.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : (
+          <ul className="space-y-3">
+            {data?.sessions.map((session) => (
+              <li
+                key={session.id}
+                className="flex items-center justify-between gap-2 rounded-md border p-3 text-sm"
+              >
+                <div>
+                  <p className="font-medium">
+                    {describe(session)}
+                    {session.current && (
+                      <span className="ml-2 text-xs font-normal text-muted-foreground">
+                        This browser
+                      </span>
+                    )}
+                  </p>
+                  <p className="text-xs text-muted-foreground">
+                    {session.ip_address && `${session.ip_address} · `}
+                    Signed in{" "}
+                    {new Date(session.created_at).toLocaleDateString()}
+                    {" · "}
+                    Last active{" "}
+                    {new Date(session.last_used_at).toLocaleDateString()}
+                  </p>
+                </div>
+                <Button
+                  variant="ghost"
+                  size="sm"
+                  onClick={() => revokeMutation.mutate(session)}
+                  disabled={revokeMutation.isPending}
+                  aria-label={`Revoke ${describe(session)}`}
+                >
+                  <X className="h-4 w-4" />
+                </Button>
+              </li>
+            ))}
+          </ul>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,106 @@
+"use client";
+
+import { useInfiniteQuery } from "@tanstack/react-query";
+import { History, Loader2 } from "lucide-react";
+import { dockerApi } from "@/lib/api";
+import { SyncRun } from "@/lib/schemas";
+import { Button } from "@/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+const triggerLabels: Record<SyncRun["triggered_by"], string> = {
+  connect: "Initial sync",
+  manual: "Manual sync",
+  scheduled: "Scheduled sync",
+  namespace: "Organization added",
+  stale: "Refreshed on view",
+  requeue: "Restarted by an admin",
+  admin: "Started by an admin",
+};
+
+export function SyncHistoryCard() {
+  const { data, isLoading, fetchNextPage, hasNextPage, isFetchingNextPage } =
+    useInfiniteQuery({
+      queryKey: ["sync-history"],
+      queryFn: ({ pageParam }) => dockerApi.getSyncHistory(pageParam),
+      initialPageParam: undefined as number | undefined,
+      getNextPageParam: (page) => page.next_before ?? undefined,
+    });
+
+  const runs = data?.pages.flatMap((page) => page.runs) ?? [];
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <History className="h-4 w-4" />
+          Sync History
+        </CardTitle>
+        <CardDescription>
+          What each sync found, to explain changes in your counts. Kept for 90
+          days.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {isLoading ? (
+          <div className="flex justify-center py-4">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : runs.length === 0 ? (
+          <p className="text-sm text-muted-foreground">No syncs yet.</p>
+        ) : (
+          <div className="space-y-3">
+            <ul className="max-h-80 space-y-2 overflow-y-auto">
+              {runs.map((run) => (
+                <li key={run.id} className="rounded-md border p-3 text-sm">
+                  <p className="font-medium">
+                    {triggerLabels[run.triggered_by] || run.triggered_by}
+                    <span
+                      className={`ml-2 font-normal ${
+                        run.status === "failed"
+                          ? "text-destructive"
+                          : "text-muted-foreground"
+                      }`}
+                    >
+                      {run.status === "failed"
+                        ? "failed"
+                        : `${run.events_created} new events`}
+                    </span>
+                  </p>
+                  <p className="text-xs text-muted-foreground">
+                    {new Date(run.started_at).toLocaleString()} ·{" "}
+                    {(run.duration_ms / 1000).toFixed(1)}s ·{" "}
+                    {run.repositories} repositories · {run.api_calls} API calls
+                  </p>
+                  {run.error && (
+                    <p className="mt-1 text-xs text-destructive break-words">
+                      {run.error}
+                    </p>
+                  )}
+                </li>
+              ))}
+            </ul>
+            {hasNextPage && (
+              <Button
+                variant="outline"
+                size="sm"
+                onClick={() => fetchNextPage()}
+                disabled={isFetchingNextPage}
+              >
+                {isFetchingNextPage && (
+                  <Loader2 className="mr-2 h-4 w-4 animate-spin" />
+                )}
+                Load more
+              </Button>
+            )}
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
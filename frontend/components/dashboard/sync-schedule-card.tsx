@@ -0,0 +1,111 @@
+"use client";
+
+import { useMutation, useQueryClient } from "@tanstack/react-query";
+import { CalendarClock } from "lucide-react";
+import { dockerApi } from "@/lib/api";
+import { DockerAccount } from "@/lib/schemas";
+import { useToast } from "@/hooks/use-toast";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+
+// Minutes between syncs; 0 follows the deployment's scheduled sync
+const intervals = [
+  { minutes: 0, label: "Default schedule" },
+  { minutes: 30, label: "Every 30 minutes" },
+  { minutes: 60, label: "Every hour" },
+  { minutes: 180, label: "Every 3 hours" },
+  { minutes: 720, label: "Every 12 hours" },
+  { minutes: 1440, label: "Once a day" },
+  { minutes: 10080, label: "Once a week" },
+];
+
+export function SyncScheduleCard({ account }: { account: DockerAccount }) {
+  const { toast } = useToast();
+  const queryClient = useQueryClient();
+
+  const current = account.sync_interval_minutes ?? 0;
+  const options = intervals.some((i) => i.minutes === current)
+    ? intervals
+    : [...intervals, { minutes: current, label: `Every ${current} minutes` }];
+
+  const updateMutation = useMutation({
+    mutationFn: dockerApi.updateAccount,
+    onSuccess: () =>
+      queryClient.invalidateQueries({ queryKey: ["docker-account"] }),
+    onError: (error: Error) =>
+      toast({
+        title: "Error",
+        description: error.message,
+        variant: "destructive",
+      }),
+  });
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <CalendarClock className="h-4 w-4" />
+          Sync Schedule
+        </CardTitle>
+        <CardDescription>
+          How often new activity is fetched from Docker Hub
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3">
+        <Select
+          value={String(current)}
+          onValueChange={(value) =>
+            updateMutation.mutate({ sync_interval_minutes: Number(value) })
+          }
+          disabled={updateMutation.isPending}
+        >
+          <SelectTrigger className="h-9 sm:w-56">
+            <SelectValue />
+          </SelectTrigger>
+          <SelectContent>
+            {options.map((i) => (
+              <SelectItem key={i.minutes} value={String(i.minutes)}>
+                {i.label}
+              </SelectItem>
+            ))}
+          </SelectContent>
+        </Select>
+        {account.disabled_at && (
+          <p className="text-xs text-destructive">
+            Syncs of this account were disabled by an administrator
+            {account.disabled_reason ? `: ${account.disabled_reason}` : "."}
+          </p>
+        )}
+        {account.sync_dead_lettered_at && (
+          <p className="text-xs text-destructive">
+            Automatic syncs stopped after repeated failures. Check your access
+            token, then sync now to start them again.
+          </p>
+        )}
+        {account.next_sync_at && (
+          <p className="text-xs text-muted-foreground">
+            Next sync around{" "}
+            {new Date(account.next_sync_at).toLocaleString(undefined, {
+              month: "short",
+              day: "numeric",
+              hour: "2-digit",
+              minute: "2-digit",
+            })}
+          </p>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
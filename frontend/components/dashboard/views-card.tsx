@@ -0,0 +1,83 @@
+"use client";
+
+import { useQuery } from "@tanstack/react-query";
+import { Eye, Loader2 } from "lucide-react";
+import { userApi } from "@/lib/api";
+import { ViewsResponse } from "@/lib/schemas";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+export function ViewsCard() {
+  const { data, isLoading, error } = useQuery<ViewsResponse>({
+    queryKey: ["user-views"],
+    queryFn: () => userApi.getViews(30),
+  });
+
+  const max = Math.max(
+    1,
+    ...(data?.history.map((day) => day.renders + day.profile_views) ?? []),
+  );
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <Eye className="h-4 w-4" />
+          Views
+        </CardTitle>
+        <CardDescription>
+          How often your embeds were loaded and your profile viewed over the
+          last 30 days
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {isLoading ? (
+          <div className="flex justify-center py-6">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : error || !data ? (
+          <p className="text-sm text-muted-foreground">
+            View history isn&apos;t available right now.
+          </p>
+        ) : (
+          <div className="space-y-4">
+            <div className="flex gap-8 text-sm">
+              <div>
+                <p className="text-2xl font-semibold">{data.total_renders}</p>
+                <p className="text-muted-foreground">Embed renders</p>
+              </div>
+              <div>
+                <p className="text-2xl font-semibold">
+                  {data.total_profile_views}
+                </p>
+                <p className="text-muted-foreground">Profile views</p>
+              </div>
+            </div>
+            <div
+              className="flex items-end gap-0.5 h-24"
+              role="img"
+              aria-label="Daily views over the last 30 days"
+            >
+              {data.history.map((day) => {
+                const total = day.renders + day.profile_views;
+                return (
+                  <div
+                    key={day.date}
+                    className="flex-1 rounded-sm bg-primary/70 min-h-[2px]"
+                    style={{ height: `${(total / max) * 100}%` }}
+                    title={`${day.date}: ${day.renders} renders, ${day.profile_views} profile views`}
+                  />
+                );
+              })}
+            </div>
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,58 @@
+"use client";
+
+import { useMutation } from "@tanstack/react-query";
+import { Mail } from "lucide-react";
+import { userApi } from "@/lib/api";
+import { useAuth } from "@/context/auth-context";
+import { useToast } from "@/hooks/use-toast";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+
+export function WeeklyDigestCard() {
+  const { toast } = useToast();
+  const { user, refreshUser } = useAuth();
+
+  const updateMutation = useMutation({
+    mutationFn: (weekly_digest: boolean) =>
+      userApi.updateProfile({ weekly_digest }),
+    onSuccess: () => refreshUser(),
+    onError: (error: Error) =>
+      toast({
+        title: "Error",
+        description: error.message,
+        variant: "destructive",
+      }),
+  });
+
+  return (
+    <Card>
+      <CardHeader className="pb-4">
+        <CardTitle className="text-base flex items-center gap-2">
+          <Mail className="h-4 w-4" />
+          Weekly Digest
+        </CardTitle>
+        <CardDescription>
+          A summary of your week every Monday: pushes, pulls, your most active
+          repository and streak. It goes to the notification channels
+          subscribed to weekly_digest, such as email or Slack.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="flex items-center gap-3">
+        <Switch
+          id="weekly-digest"
+          checked={user?.weekly_digest ?? false}
+          onCheckedChange={(checked) => updateMutation.mutate(checked)}
+          disabled={updateMutation.isPending}
+        />
+        <Label htmlFor="weekly-digest">Send me a weekly digest</Label>
+      </CardContent>
+    </Card>
+  );
+}
@@ -10,7 +10,8 @@ interface HeroCTAProps {
 }
 
 export const HeroCTA = ({ stars }: HeroCTAProps) => {
-  const { isAuthenticated, login, isLoading } = useAuth();
+  const { isAuthenticated, login, isLoading, providers, passwordEnabled } =
+    useAuth();
 
   if (isLoading) {
     return (
@@ -31,9 +32,15 @@ export const HeroCTA = ({ stars }: HeroCTAProps) => {
           </Button>
         </Link>
       ) : (
-        <Button size="lg" onClick={login} className="w-full sm:w-auto">
+        <Button
+          size="lg"
+          onClick={() => login()}
+          className="w-full sm:w-auto"
+        >
           <Github className="mr-2 h-4 w-4" />
-          Get started with GitHub
+          {providers.length === 1 && !passwordEnabled
+            ? "Get started with GitHub"
+            : "Get started"}
         </Button>
       )}
       <Link
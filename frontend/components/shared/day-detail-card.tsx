@@ -0,0 +1,81 @@
+"use client";
+
+import { useQuery } from "@tanstack/react-query";
+import { CalendarDays, Loader2, X } from "lucide-react";
+import { publicApi } from "@/lib/api";
+import { DayDetailResponse } from "@/lib/schemas";
+import { Button } from "@/components/ui/button";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+
+interface DayDetailCardProps {
+  username: string;
+  date: string;
+  onClose: () => void;
+}
+
+export function DayDetailCard({ username, date, onClose }: DayDetailCardProps) {
+  const { data, isLoading, error } = useQuery<DayDetailResponse>({
+    queryKey: ["activity-day", username, date],
+    queryFn: () => publicApi.getActivityDay(username, date),
+  });
+
+  const title = new Date(`${date}T00:00:00`).toLocaleDateString("en-US", {
+    weekday: "long",
+    month: "long",
+    day: "numeric",
+    year: "numeric",
+  });
+
+  return (
+    <Card className="overflow-hidden shadow-md">
+      <CardHeader className="bg-muted/20 border-b py-4 flex flex-row items-center justify-between space-y-0">
+        <CardTitle className="text-sm font-semibold flex items-center gap-2">
+          <CalendarDays className="h-4 w-4" />
+          {title}
+        </CardTitle>
+        <Button variant="ghost" size="sm" onClick={onClose} aria-label="Close">
+          <X className="h-4 w-4" />
+        </Button>
+      </CardHeader>
+      <CardContent className="p-4 sm:p-6">
+        {isLoading ? (
+          <div className="flex justify-center py-6">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : error || !data ? (
+          <p className="text-sm text-muted-foreground">
+            Activity details aren&apos;t available for this day.
+          </p>
+        ) : data.day.repositories.length === 0 ? (
+          <p className="text-sm text-muted-foreground">No activity.</p>
+        ) : (
+          <ul className="space-y-4">
+            {data.day.repositories.map((repo) => (
+              <li key={repo.repository} className="space-y-1.5">
+                <div className="flex items-center justify-between gap-4 text-sm">
+                  <span className="font-medium truncate">{repo.repository}</span>
+                  <span className="text-muted-foreground shrink-0">
+                    {repo.count} {repo.count === 1 ? "activity" : "activities"}
+                  </span>
+                </div>
+                <div className="flex flex-wrap gap-1.5">
+                  {repo.tags
+                    .filter((tag) => tag.tag)
+                    .map((tag, i) => (
+                      <span
+                        key={`${tag.tag}-${i}`}
+                        className="text-xs font-mono bg-muted px-1.5 py-0.5 rounded border"
+                        title={tag.event_at ?? undefined}
+                      >
+                        {tag.tag}
+                      </span>
+                    ))}
+                </div>
+              </li>
+            ))}
+          </ul>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -12,6 +12,7 @@ export interface HeatmapData {
   date: string;
   count: number;
   level: number;
+  repositories?: Record<string, number>;
 }
 
 interface HeatmapGridProps {
@@ -20,6 +21,7 @@ interface HeatmapGridProps {
   themeId?: string;
   hideLegend?: boolean;
   totalCount?: number;
+  onDayClick?: (date: string) => void;
 }
 
 export const HeatmapGrid = ({
@@ -28,6 +30,7 @@ export const HeatmapGrid = ({
   themeId = "github",
   hideLegend = false,
   totalCount,
+  onDayClick,
 }: HeatmapGridProps) => {
   const theme = themes[themeId] || themes.github;
   const isLightTheme = themeId === "github-light";
@@ -90,8 +93,17 @@ export const HeatmapGrid = ({
                     <Tooltip key={`${row}-${col}`}>
                       <TooltipTrigger asChild>
                         <div
-                          className="w-[11px] h-[11px] rounded-[1.5px] transition-transform hover:scale-150 active:scale-95 cursor-default flex-shrink-0 relative z-0 hover:z-10"
+                          className={`w-[11px] h-[11px] rounded-[1.5px] transition-transform hover:scale-150 active:scale-95 flex-shrink-0 relative z-0 hover:z-10 ${
+                            onDayClick && day.count > 0
+                              ? "cursor-pointer"
+                              : "cursor-default"
+                          }`}
                           style={{ backgroundColor: theme.colors[day.level] }}
+                          onClick={
+                            onDayClick && day.count > 0
+                              ? () => onDayClick(day.date)
+                              : undefined
+                          }
                         />
                       </TooltipTrigger>
                       <TooltipContent
@@ -104,6 +116,15 @@ export const HeatmapGrid = ({
                             ? "No activities"
                             : `${day.count} ${day.count === 1 ? "activity" : "activities"}`}
                         </p>
+                        {day.repositories &&
+                          Object.entries(day.repositories)
+                            .sort(([, a], [, b]) => b - a)
+                            .slice(0, 3)
+                            .map(([repo, count]) => (
+                              <p key={repo} className="text-muted-foreground">
+                                {repo}: {count}
+                              </p>
+                            ))}
                       </TooltipContent>
                     </Tooltip>
                   );
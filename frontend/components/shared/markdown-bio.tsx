@@ -0,0 +1,24 @@
+import { cn } from "@/lib/utils";
+
+interface MarkdownBioProps {
+  // HTML rendered and sanitized by the API (bio_html)
+  html: string;
+  className?: string;
+}
+
+export const MarkdownBio = ({ html, className }: MarkdownBioProps) => {
+  return (
+    <div
+      className={cn(
+        "text-base text-muted-foreground mt-3 max-w-xl leading-relaxed space-y-2",
+        "[&_a]:text-primary [&_a]:underline [&_a]:underline-offset-2",
+        "[&_code]:font-mono [&_code]:text-sm [&_code]:bg-muted [&_code]:px-1 [&_code]:rounded",
+        "[&_pre]:bg-muted [&_pre]:p-3 [&_pre]:rounded-md [&_pre]:overflow-x-auto [&_pre_code]:p-0",
+        "[&_ul]:list-disc [&_ul]:pl-5 [&_ol]:list-decimal [&_ol]:pl-5",
+        "[&_h1]:text-lg [&_h1]:font-semibold [&_h2]:font-semibold [&_h3]:font-medium",
+        className,
+      )}
+      dangerouslySetInnerHTML={{ __html: html }}
+    />
+  );
+};
@@ -0,0 +1,92 @@
+"use client";
+
+import { useState } from "react";
+import { useQuery } from "@tanstack/react-query";
+import { Flame, Loader2 } from "lucide-react";
+import { publicApi } from "@/lib/api";
+import { ReposResponse, RepoSort } from "@/lib/schemas";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+
+const sortLabels: Record<RepoSort, string> = {
+  heat: "Heat",
+  events: "Most events",
+  recent: "Most recent",
+  name: "Name",
+};
+
+interface TopReposCardProps {
+  username: string;
+}
+
+export function TopReposCard({ username }: TopReposCardProps) {
+  const [sort, setSort] = useState<RepoSort>("heat");
+  const { data, isLoading } = useQuery<ReposResponse>({
+    queryKey: ["repos", username, sort],
+    queryFn: () => publicApi.getRepos(username, sort),
+  });
+
+  if (!isLoading && (!data || data.repositories.length === 0)) {
+    return null;
+  }
+
+  return (
+    <Card className="overflow-hidden shadow-md">
+      <CardHeader className="bg-muted/20 border-b py-4 flex flex-row items-center justify-between space-y-0">
+        <CardTitle className="text-sm font-semibold flex items-center gap-2">
+          <Flame className="h-4 w-4 text-orange-500" />
+          Top Repositories
+        </CardTitle>
+        <Select value={sort} onValueChange={(value) => setSort(value as RepoSort)}>
+          <SelectTrigger className="h-8 w-36">
+            <SelectValue />
+          </SelectTrigger>
+          <SelectContent>
+            {(data?.sort_options ?? (Object.keys(sortLabels) as RepoSort[])).map(
+              (option) => (
+                <SelectItem key={option} value={option}>
+                  {sortLabels[option] ?? option}
+                </SelectItem>
+              ),
+            )}
+          </SelectContent>
+        </Select>
+      </CardHeader>
+      <CardContent className="p-4 sm:p-6">
+        {isLoading || !data ? (
+          <div className="flex justify-center py-6">
+            <Loader2 className="h-5 w-5 animate-spin text-muted-foreground" />
+          </div>
+        ) : (
+          <ul className="space-y-3">
+            {data.repositories.map((repo) => (
+              <li key={repo.repository} className="space-y-1.5">
+                <div className="flex items-center justify-between gap-4 text-sm">
+                  <span className="font-medium truncate">{repo.repository}</span>
+                  <span className="text-muted-foreground shrink-0">
+                    {repo.events} events
+                  </span>
+                </div>
+                <div
+                  className="h-1.5 rounded-full bg-muted overflow-hidden"
+                  title={`Heat ${repo.heat} (half-life ${data.half_life_days} days)`}
+                >
+                  <div
+                    className="h-full rounded-full bg-gradient-to-r from-amber-400 to-orange-600"
+                    style={{ width: `${Math.max(repo.relative_heat * 100, 2)}%` }}
+                  />
+                </div>
+              </li>
+            ))}
+          </ul>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
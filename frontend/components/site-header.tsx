@@ -86,7 +86,7 @@ export function SiteHeader() {
             <Button
               size="sm"
               variant="default"
-              onClick={login}
+              onClick={() => login()}
               className="ml-2"
             >
               <Github className="mr-2 h-4 w-4" />
@@ -7,14 +7,20 @@ import React, {
   useState,
   useCallback,
 } from "react";
+import { useRouter } from "next/navigation";
 import { authApi } from "@/lib/api";
-import type { User } from "@/lib/schemas";
+import type { AuthProvider, AuthTokens, User } from "@/lib/schemas";
 
 interface AuthContextType {
   user: User | null;
   isLoading: boolean;
   isAuthenticated: boolean;
-  login: () => void;
+  providers: AuthProvider[];
+  passwordEnabled: boolean;
+  // Signs in with provider, or offers the choice when there is more than one way
+  login: (provider?: AuthProvider) => void;
+  // Keeps the tokens from an email and password sign-in and moves on
+  completeSignIn: (tokens: AuthTokens) => void;
   logout: () => void;
   refreshUser: () => Promise<void>;
 }
@@ -24,6 +30,9 @@ const AuthContext = createContext<AuthContextType | undefined>(undefined);
 export function AuthProvider({ children }: { children: React.ReactNode }) {
   const [user, setUser] = useState<User | null>(null);
   const [isLoading, setIsLoading] = useState(true);
+  const [providers, setProviders] = useState<AuthProvider[]>(["github"]);
+  const [passwordEnabled, setPasswordEnabled] = useState(false);
+  const router = useRouter();
 
   const refreshUser = useCallback(async () => {
     setIsLoading(true);
@@ -41,6 +50,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       setUser(user);
     } catch {
       localStorage.removeItem("token");
+      localStorage.removeItem("refresh_token");
       setUser(null);
     } finally {
       setIsLoading(false);
@@ -51,17 +61,49 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     refreshUser();
   }, [refreshUser]);
 
-  const login = useCallback(() => {
+  useEffect(() => {
     authApi
-      .getAuthUrl()
-      .then(({ auth_url }) => {
-        window.location.href = auth_url;
+      .getProviders()
+      .then(({ providers, password }) => {
+        setProviders(providers);
+        setPasswordEnabled(password);
       })
-      .catch((error) => {
-        console.error("Login failed:", error);
+      .catch(() => {
+        // GitHub is always available
       });
   }, []);
 
+  const login = useCallback(
+    (provider?: AuthProvider) => {
+      if (!provider && (providers.length !== 1 || passwordEnabled)) {
+        router.push("/login");
+        return;
+      }
+      authApi
+        .getAuthUrl(provider)
+        .then(({ auth_url }) => {
+          window.location.href = auth_url;
+        })
+        .catch((error) => {
+          console.error("Login failed:", error);
+        });
+    },
+    [providers, passwordEnabled, router],
+  );
+
+  const completeSignIn = useCallback((tokens: AuthTokens) => {
+    localStorage.setItem("token", tokens.access_token);
+    localStorage.setItem("refresh_token", tokens.refresh_token);
+    // Pages that sent the user to sign in, such as /device, ask to come back
+    const returnTo = sessionStorage.getItem("returnTo");
+    sessionStorage.removeItem("returnTo");
+    // A full page load, so every context starts signed in
+    window.location.href =
+      returnTo && returnTo.startsWith("/") && !returnTo.startsWith("//")
+        ? returnTo
+        : "/dashboard";
+  }, []);
+
   const logout = useCallback(async () => {
     try {
       await authApi.logout();
@@ -69,6 +111,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       // Ignore logout errors
     } finally {
       localStorage.removeItem("token");
+      localStorage.removeItem("refresh_token");
       setUser(null);
       window.location.href = "/";
     }
@@ -80,7 +123,10 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
         user,
         isLoading,
         isAuthenticated: !!user,
+        providers,
+        passwordEnabled,
         login,
+        completeSignIn,
         logout,
         refreshUser,
       }}
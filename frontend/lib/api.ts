@@ -5,12 +5,32 @@ import type {
   UpdateProfileRequest,
   ActivityResponse,
   ProfileData,
+  ReposResponse,
+  RepositoryDetailsResponse,
+  RepoSort,
+  DayDetailResponse,
   EmbedCodes,
+  SignedEmbed,
+  EmbedToken,
+  LimitsResponse,
+  ViewsResponse,
+  NamespaceClaim,
+  IngestKey,
+  DeviceLogin,
+  AuthProvider,
+  AuthTokens,
+  Session,
+  AuditLog,
+  DataExport,
+  ImportFormat,
+  ImportResult,
   ThemesResponse,
   SVGOptions,
+  SyncProgress,
+  SyncRun,
 } from "./schemas";
 
-const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080/api";
+const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080/api/v1";
 
 class ApiError extends Error {
   constructor(
@@ -22,9 +42,45 @@ class ApiError extends Error {
   }
 }
 
+// One refresh at a time, shared by every request that found its token expired
+let refreshing: Promise<boolean> | null = null;
+
+// Trades the stored refresh token for new tokens. Refresh tokens rotate, so both are
+// replaced; false means the session is over and the user has to sign in again.
+function refreshTokens(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = localStorage.getItem("refresh_token");
+      if (!refreshToken) return false;
+      try {
+        const response = await fetch(`${API_URL}/auth/refresh`, {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+        });
+        if (!response.ok) {
+          // Another tab may have refreshed first and stored the next token
+          return localStorage.getItem("refresh_token") !== refreshToken;
+        }
+        const tokens: { access_token: string; refresh_token: string } =
+          await response.json();
+        localStorage.setItem("token", tokens.access_token);
+        localStorage.setItem("refresh_token", tokens.refresh_token);
+        return true;
+      } catch {
+        return false;
+      }
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+}
+
 async function fetchApi<T>(
   endpoint: string,
   options: RequestInit = {},
+  retried = false,
 ): Promise<T> {
   const token =
     typeof window !== "undefined" ? localStorage.getItem("token") : null;
@@ -45,6 +101,16 @@ async function fetchApi<T>(
     },
   });
 
+  // Access tokens are short-lived; refresh once and send the request again
+  if (
+    response.status === 401 &&
+    token &&
+    !retried &&
+    (await refreshTokens())
+  ) {
+    return fetchApi(endpoint, options, true);
+  }
+
   if (!response.ok) {
     let message = "An error occurred";
     try {
@@ -59,24 +125,155 @@ async function fetchApi<T>(
   return response.json();
 }
 
+// Reads a Server-Sent Events stream with fetch, since EventSource can't send the
+// Authorization header. Resolves when the stream ends or signal aborts it.
+async function streamEvents(
+  endpoint: string,
+  onEvent: (event: string, data: string) => void,
+  signal: AbortSignal,
+  retried = false,
+): Promise<void> {
+  const token =
+    typeof window !== "undefined" ? localStorage.getItem("token") : null;
+
+  const response = await fetch(`${API_URL}${endpoint}`, {
+    headers: {
+      Accept: "text/event-stream",
+      ...(token ? { Authorization: `Bearer ${token}` } : {}),
+    },
+    signal,
+  });
+  if (
+    response.status === 401 &&
+    token &&
+    !retried &&
+    (await refreshTokens())
+  ) {
+    return streamEvents(endpoint, onEvent, signal, true);
+  }
+  if (!response.ok || !response.body) {
+    throw new ApiError(response.status, "Failed to open event stream");
+  }
+
+  const reader = response.body.getReader();
+  const decoder = new TextDecoder();
+  let buffer = "";
+  try {
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) return;
+      buffer += decoder.decode(value, { stream: true });
+
+      let end: number;
+      while ((end = buffer.indexOf("\n\n")) !== -1) {
+        const block = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+
+        let event = "message";
+        const data: string[] = [];
+        for (const line of block.split("\n")) {
+          if (line.startsWith("event:")) event = line.slice(6).trim();
+          else if (line.startsWith("data:")) data.push(line.slice(5).trim());
+        }
+        if (data.length > 0) onEvent(event, data.join("\n"));
+      }
+    }
+  } catch (error) {
+    if (!signal.aborted) throw error;
+  }
+}
+
 // Auth API
 export const authApi = {
-  getAuthUrl: (): Promise<{ auth_url: string }> => {
-    return fetchApi("/auth/github");
+  getProviders: (): Promise<{
+    providers: AuthProvider[];
+    password: boolean;
+  }> => {
+    return fetchApi("/auth/providers");
+  },
+
+  getAuthUrl: (
+    provider: AuthProvider = "github",
+  ): Promise<{ auth_url: string }> => {
+    return fetchApi(`/auth/${provider}`);
   },
 
   getCurrentUser: (): Promise<{ user: User }> => {
     return fetchApi("/user/me");
   },
 
+  // Email and password sign-in, when the deployment enables it
+  signup: (data: {
+    email: string;
+    password: string;
+    name?: string;
+  }): Promise<{ message: string }> => {
+    return fetchApi("/auth/password/signup", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  },
+
+  passwordLogin: (data: {
+    email: string;
+    password: string;
+  }): Promise<AuthTokens> => {
+    return fetchApi("/auth/password/login", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  },
+
+  verifyEmail: (token: string): Promise<AuthTokens> => {
+    return fetchApi("/auth/password/verify", {
+      method: "POST",
+      body: JSON.stringify({ token }),
+    });
+  },
+
+  forgotPassword: (email: string): Promise<{ message: string }> => {
+    return fetchApi("/auth/password/forgot", {
+      method: "POST",
+      body: JSON.stringify({ email }),
+    });
+  },
+
+  resetPassword: (data: {
+    token: string;
+    password: string;
+  }): Promise<AuthTokens> => {
+    return fetchApi("/auth/password/reset", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  },
+
   logout: (): Promise<{ message: string }> => {
     return fetchApi("/auth/logout", { method: "POST" });
   },
+
+  getDeviceLogin: (userCode: string): Promise<{ device: DeviceLogin }> => {
+    return fetchApi(`/auth/device?user_code=${encodeURIComponent(userCode)}`);
+  },
+
+  decideDeviceLogin: (data: {
+    user_code: string;
+    approve: boolean;
+  }): Promise<{ message: string }> => {
+    return fetchApi("/auth/device/approve", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  },
 };
 
 // User API
 export const userApi = {
-  getProfile: (): Promise<{ user: User }> => {
+  getProfile: (): Promise<{
+    user: User;
+    security_events: AuditLog[];
+    is_admin: boolean;
+  }> => {
     return fetchApi("/user/me");
   },
 
@@ -92,6 +289,127 @@ export const userApi = {
   getEmbedCodes: (dockerUsername: string): Promise<EmbedCodes> => {
     return fetchApi(`/user/embed?docker_username=${dockerUsername}`);
   },
+
+  // Expiring URLs that show a private profile's heatmap to whoever has them
+  signEmbed: (data: {
+    docker_username: string;
+    days?: number;
+    query?: string;
+  }): Promise<SignedEmbed> => {
+    return fetchApi("/user/embed/sign", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  },
+
+  getEmbedTokens: (): Promise<{ tokens: EmbedToken[] }> => {
+    return fetchApi("/user/embed/tokens");
+  },
+
+  // The token's value is only returned here
+  createEmbedToken: ({
+    name,
+    allowedOrigins,
+  }: {
+    name: string;
+    allowedOrigins: string[];
+  }): Promise<{ token: EmbedToken; value: string; message: string }> => {
+    return fetchApi("/user/embed/tokens", {
+      method: "POST",
+      body: JSON.stringify({ name, allowed_origins: allowedOrigins }),
+    });
+  },
+
+  // An empty list lets any site use the token
+  updateEmbedToken: ({
+    id,
+    allowedOrigins,
+  }: {
+    id: number;
+    allowedOrigins: string[];
+  }): Promise<{ token: EmbedToken; message: string }> => {
+    return fetchApi(`/user/embed/tokens/${id}`, {
+      method: "PUT",
+      body: JSON.stringify({ allowed_origins: allowedOrigins }),
+    });
+  },
+
+  revokeEmbedToken: (id: number): Promise<{ message: string }> => {
+    return fetchApi(`/user/embed/tokens/${id}`, { method: "DELETE" });
+  },
+
+  getLimits: (): Promise<LimitsResponse> => {
+    return fetchApi("/user/limits");
+  },
+
+  getViews: (days = 30): Promise<ViewsResponse> => {
+    return fetchApi(`/user/views?days=${days}`);
+  },
+
+  getRepositories: (days = 365): Promise<RepositoryDetailsResponse> => {
+    return fetchApi(`/user/repositories?days=${days}`);
+  },
+
+  getSessions: (): Promise<{ sessions: Session[] }> => {
+    return fetchApi("/user/sessions");
+  },
+
+  revokeSession: (id: number): Promise<{ message: string }> => {
+    return fetchApi(`/user/sessions/${id}`, { method: "DELETE" });
+  },
+
+  // Resolves to null when no export was ever requested
+  getDataExport: async (): Promise<DataExport | null> => {
+    try {
+      return await fetchApi<DataExport>("/user/export");
+    } catch (error) {
+      if (error instanceof ApiError && error.status === 404) return null;
+      throw error;
+    }
+  },
+
+  requestDataExport: (): Promise<DataExport> => {
+    return fetchApi("/user/export", { method: "POST" });
+  },
+
+  importActivity: async (
+    file: File,
+    format: ImportFormat,
+  ): Promise<ImportResult> => {
+    return fetchApi(`/user/import?format=${format}`, {
+      method: "POST",
+      headers: {
+        "Content-Type": format === "csv" ? "text/csv" : "application/json",
+      },
+      body: await file.text(),
+    });
+  },
+
+  // Mails a link to confirm the deletion; nothing is deleted until it's followed
+  deleteAccount: (): Promise<{ message: string }> => {
+    return fetchApi("/user/me", { method: "DELETE" });
+  },
+
+  // delete_at is left out when the account was deleted straight away
+  confirmAccountDeletion: (
+    token: string,
+  ): Promise<{ message: string; delete_at?: string }> => {
+    return fetchApi("/account/delete/confirm", {
+      method: "POST",
+      body: JSON.stringify({ token }),
+    });
+  },
+
+  cancelAccountDeletion: (): Promise<{ message: string }> => {
+    return fetchApi("/user/me/deletion", { method: "DELETE" });
+  },
+
+  // Pass next_before from a page as before to get the next one
+  getAuditLog: (
+    before?: number,
+  ): Promise<{ events: AuditLog[]; next_before: number | null }> => {
+    return fetchApi(`/user/audit${before ? `?before=${before}` : ""}`);
+  },
 };
 
 // Docker API
@@ -109,17 +427,100 @@ export const dockerApi = {
     return fetchApi("/docker/account");
   },
 
-  disconnect: (): Promise<{ message: string }> => {
+  updateAccount: (data: {
+    sync_interval_minutes: number;
+  }): Promise<{ account: DockerAccount }> => {
+    return fetchApi("/docker/account", {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+  },
+
+  disconnect: (): Promise<{ message: string; purge_at?: string }> => {
     return fetchApi("/docker/disconnect", { method: "DELETE" });
   },
 
+  undoDisconnect: (): Promise<{ account: DockerAccount; message: string }> => {
+    return fetchApi("/docker/disconnect/undo", { method: "POST" });
+  },
+
   sync: (): Promise<{ message: string }> => {
     return fetchApi("/docker/sync", { method: "POST" });
   },
+
+  // Streams each step of the account's syncs until signal aborts
+  streamSyncProgress: (
+    onProgress: (progress: SyncProgress) => void,
+    signal: AbortSignal,
+  ): Promise<void> => {
+    return streamEvents(
+      "/docker/sync/events",
+      (event, data) => {
+        if (event === "progress") onProgress(JSON.parse(data));
+      },
+      signal,
+    );
+  },
+
+  getSyncHistory: (
+    before?: number,
+  ): Promise<{ runs: SyncRun[]; next_before: number | null }> => {
+    return fetchApi(`/docker/sync/history${before ? `?before=${before}` : ""}`);
+  },
+
+  getNamespaces: (): Promise<{ namespaces: NamespaceClaim[] }> => {
+    return fetchApi("/docker/namespaces");
+  },
+
+  claimNamespace: (
+    namespace: string,
+  ): Promise<{ namespace: NamespaceClaim }> => {
+    return fetchApi("/docker/namespaces", {
+      method: "POST",
+      body: JSON.stringify({ namespace }),
+    });
+  },
+
+  verifyNamespace: (
+    namespace: string,
+  ): Promise<{ namespace: NamespaceClaim; message: string }> => {
+    return fetchApi(
+      `/docker/namespaces/${encodeURIComponent(namespace)}/verify`,
+      { method: "POST" },
+    );
+  },
+
+  removeNamespace: (namespace: string): Promise<{ message: string }> => {
+    return fetchApi(`/docker/namespaces/${encodeURIComponent(namespace)}`, {
+      method: "DELETE",
+    });
+  },
+
+  getIngestKeys: (): Promise<{ keys: IngestKey[] }> => {
+    return fetchApi("/docker/ingest-keys");
+  },
+
+  // The secret is only returned here
+  createIngestKey: (
+    name: string,
+  ): Promise<{ key: IngestKey; secret: string; message: string }> => {
+    return fetchApi("/docker/ingest-keys", {
+      method: "POST",
+      body: JSON.stringify({ name }),
+    });
+  },
+
+  deleteIngestKey: (id: number): Promise<{ message: string }> => {
+    return fetchApi(`/docker/ingest-keys/${id}`, { method: "DELETE" });
+  },
 };
 
 // Helper to build SVG URL with options
 function buildSVGUrl(username: string, options?: SVGOptions): string {
+  return `${API_URL}/heatmap/${username}.svg${buildSVGQuery(options)}`;
+}
+
+function buildSVGQuery(options?: SVGOptions): string {
   const params = new URLSearchParams();
 
   if (options) {
@@ -135,7 +536,7 @@ function buildSVGUrl(username: string, options?: SVGOptions): string {
   }
 
   const queryString = params.toString();
-  return `${API_URL}/heatmap/${username}.svg${queryString ? `?${queryString}` : ""}`;
+  return queryString ? `?${queryString}` : "";
 }
 
 // Public API (no auth required)
@@ -145,6 +546,11 @@ export const publicApi = {
     return buildSVGUrl(username, options);
   },
 
+  // Preview any theme/options against generated sample data (no account needed)
+  getSampleHeatmapUrl: (options?: SVGOptions): string => {
+    return `${API_URL}/preview/sample.svg${buildSVGQuery(options)}`;
+  },
+
   // Legacy method for backwards compatibility
   getHeatmapUrlSimple: (username: string, days = 365): string => {
     return buildSVGUrl(username, { days });
@@ -154,14 +560,47 @@ export const publicApi = {
     return `${API_URL}/activity/${username}.json?days=${days}`;
   },
 
-  getActivity: (username: string, days = 365): Promise<ActivityResponse> => {
-    return fetchApi(`/activity/${username}?days=${days}`);
+  getFeedUrl: (username: string): string => {
+    return `${API_URL}/feed/${username}.atom`;
+  },
+
+  // The interactive widget is served from the API's origin, outside /api
+  getEmbedScriptUrl: (): string => {
+    return new URL("/embed.js", API_URL).toString();
+  },
+
+  getActivity: (
+    username: string,
+    days = 365,
+    breakdown?: "repo",
+  ): Promise<ActivityResponse> => {
+    const query = breakdown ? `&breakdown=${breakdown}` : "";
+    return fetchApi(`/activity/${username}?days=${days}${query}`);
+  },
+
+  getActivityDay: (username: string, date: string): Promise<DayDetailResponse> => {
+    return fetchApi(`/activity/${username}/${date}`);
+  },
+
+  getRepos: (
+    username: string,
+    sort: RepoSort = "heat",
+    limit = 10,
+  ): Promise<ReposResponse> => {
+    return fetchApi(`/repos/${username}?sort=${sort}&limit=${limit}`);
   },
 
   getProfile: (username: string): Promise<ProfileData> => {
     return fetchApi(`/profile/${username}`);
   },
 
+  getProfileRepositories: (
+    username: string,
+    days = 365,
+  ): Promise<RepositoryDetailsResponse> => {
+    return fetchApi(`/profile/${username}/repositories?days=${days}`);
+  },
+
   // Get available themes
   getThemes: (): Promise<ThemesResponse> => {
     return fetchApi("/themes");
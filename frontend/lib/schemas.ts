@@ -3,19 +3,34 @@ import { z } from "zod";
 // User schema
 export const userSchema = z.object({
   id: z.number(),
-  github_id: z.number(),
-  github_username: z.string(),
+  provider: z.enum(["github", "gitlab", "google", "password"]),
+  github_id: z.number().optional(), // set once the account signs in with GitHub
+  github_username: z.string(), // handle on the provider above
   email: z.string().nullable(),
   avatar_url: z.string(),
   name: z.string().nullable(),
   bio: z.string().nullable(),
   public_profile: z.boolean(),
+  weekly_digest: z.boolean().optional(),
+  // set once the user confirmed deleting the account, until it's deleted
+  deletion_scheduled_at: z.string().optional(),
   created_at: z.string(),
   updated_at: z.string(),
 });
 
 export type User = z.infer<typeof userSchema>;
 
+export type AuthProvider = Exclude<User["provider"], "password">;
+
+// Issued by email and password sign-in, like the OAuth callback's query parameters
+export interface AuthTokens {
+  access_token: string;
+  refresh_token: string;
+  token_type: "Bearer";
+  expires_in: number;
+  user: User;
+}
+
 // Docker Account schema
 export const dockerAccountSchema = z.object({
   id: z.number(),
@@ -25,6 +40,12 @@ export const dockerAccountSchema = z.object({
   last_sync_at: z.string().nullable(),
   last_sync_error: z.string().nullable().optional(),
   sync_in_progress: z.boolean().optional(),
+  sync_interval_minutes: z.number().optional(), // 0 follows the scheduled sync
+  next_sync_at: z.string().nullable().optional(),
+  sync_dead_lettered_at: z.string().nullable().optional(), // automatic syncs stopped after repeated failures
+  disabled_at: z.string().nullable().optional(), // an admin disabled syncs of the account
+  disabled_reason: z.string().optional(),
+  disconnect_scheduled_at: z.string().nullable().optional(), // purge time while a disconnect is pending
 });
 
 export type DockerAccount = z.infer<typeof dockerAccountSchema>;
@@ -37,6 +58,7 @@ export const activityEventSchema = z.object({
   pushes: z.number().optional(),
   pulls: z.number().optional(),
   builds: z.number().optional(),
+  repositories: z.record(z.string(), z.number()).optional(), // with breakdown=repo
 });
 
 export type ActivityEvent = z.infer<typeof activityEventSchema>;
@@ -74,8 +96,9 @@ export type ConnectDockerRequest = z.infer<typeof connectDockerSchema>;
 
 export const updateProfileSchema = z.object({
   name: z.string().optional(),
-  bio: z.string().optional(),
+  bio: z.string().max(2000, "Bio must be at most 2000 characters").optional(),
   public_profile: z.boolean().optional(),
+  weekly_digest: z.boolean().optional(),
 });
 
 export type UpdateProfileRequest = z.infer<typeof updateProfileSchema>;
@@ -84,6 +107,7 @@ export type UpdateProfileRequest = z.infer<typeof updateProfileSchema>;
 export interface ActivityResponse {
   username: string;
   days: number;
+  granularity?: "day" | "week" | "month";
   totals: {
     activities: number;
     pushes: number;
@@ -96,8 +120,11 @@ export interface ActivityResponse {
 export interface ProfileData {
   user: {
     github_username: string;
+    provider?: AuthProvider;
+    profile_url?: string; // empty for Google accounts
     name: string | null;
     bio: string | null;
+    bio_html?: string; // sanitized HTML rendered from the markdown bio
     avatar_url: string;
   };
   docker: {
@@ -107,15 +134,239 @@ export interface ProfileData {
   stats: {
     total_activities: number;
   };
+  achievements?: Achievement[];
   available_themes?: string[];
 }
 
+export interface Achievement {
+  key: "first_push" | "active_days_100" | "streak_30" | "pulls_1m";
+  name: string;
+  description: string;
+  earned_at: string;
+}
+
+export interface DayTag {
+  tag: string;
+  event_type: string;
+  count: number;
+  event_at?: string;
+  source: string;
+}
+
+export interface DayDetailResponse {
+  username: string;
+  day: {
+    date: string;
+    timezone: string;
+    count: number;
+    pushes: number;
+    pulls: number;
+    builds: number;
+    repositories: { repository: string; count: number; tags: DayTag[] }[];
+  };
+}
+
+export type RepoSort = "heat" | "events" | "recent" | "name";
+
+export interface RepoStats {
+  repository: string;
+  events: number;
+  last_activity: string;
+  heat: number; // event count decayed by age
+  relative_heat: number; // 0-1, against the hottest repository
+}
+
+export interface ReposResponse {
+  username: string;
+  days: number;
+  sort: RepoSort;
+  sort_options: RepoSort[];
+  half_life_days: number;
+  total: number;
+  repositories: RepoStats[];
+}
+
+export interface RepositoryDetail {
+  name: string;
+  description?: string;
+  pull_count: number;
+  star_count: number;
+  is_private: boolean;
+  last_updated: string | null;
+  events: number;
+  pushes: number;
+  pulls: number;
+  builds: number;
+  last_activity: string | null;
+}
+
+export interface RepositoryDetailsResponse {
+  username: string;
+  days: number;
+  last_sync_at: string | null;
+  total: number;
+  repositories: RepositoryDetail[];
+}
+
+export type SyncStage = "queued" | "fetching" | "syncing" | "done" | "failed";
+
+export interface SyncProgress {
+  stage: SyncStage;
+  repository?: string; // the one just finished while syncing
+  done: number;
+  total: number;
+  error?: string;
+  at: string;
+}
+
+// One sync of the Docker account and what it did
+export interface SyncRun {
+  id: number;
+  triggered_by:
+    | "connect"
+    | "manual"
+    | "scheduled"
+    | "namespace"
+    | "stale"
+    | "requeue"
+    | "admin";
+  started_at: string;
+  finished_at: string;
+  duration_ms: number;
+  repositories: number; // scanned
+  events_created: number; // new activity rows
+  api_calls: number; // Docker Hub requests
+  status: "succeeded" | "failed";
+  error?: string;
+}
+
 export interface EmbedCodes {
   svg_url: string;
   json_url: string;
   markdown: string;
   html: string;
   html_link: string;
+  widget: string;
+}
+
+export interface SignedEmbed {
+  exp: string;
+  sig: string;
+  expires_at: string;
+  svg_url: string;
+  png_url: string;
+  json_url: string;
+  markdown: string;
+  html: string;
+}
+
+export interface RateLimitStatus {
+  class: "public" | "api" | "auth";
+  limit: number;
+  window_seconds: number;
+  remaining: number;
+  reset_at: string;
+  requests_24h: number;
+  rejected_24h: number;
+}
+
+export interface LimitsResponse {
+  tier: string;
+  ip: string;
+  limits: RateLimitStatus[];
+}
+
+export interface ViewDay {
+  date: string;
+  renders: number;
+  profile_views: number;
+}
+
+export interface ViewsResponse {
+  days: number;
+  total_renders: number;
+  total_profile_views: number;
+  history: ViewDay[];
+}
+
+export interface NamespaceClaim {
+  namespace: string;
+  verified: boolean;
+  created_at: string;
+  verified_at?: string;
+  token?: string; // while pending
+  expires_at?: string;
+  expired?: boolean;
+  instructions?: string[];
+}
+
+export interface IngestKey {
+  id: number;
+  name: string;
+  key_id: string; // sent as X-Ingest-Key
+  created_at: string;
+  last_used_at?: string;
+}
+
+// Shows a private profile's heatmap to URLs carrying it as ?token=, until revoked
+export interface EmbedToken {
+  id: number;
+  name: string;
+  prefix: string;
+  allowed_origins: string; // comma-separated; empty allows any site
+  created_at: string;
+  last_used_at?: string;
+}
+
+// A browser or device the user is logged in on
+export interface Session {
+  id: number;
+  name?: string; // set by device logins, such as "dhm on laptop"
+  user_agent?: string;
+  ip_address?: string;
+  created_at: string;
+  last_used_at: string;
+  expires_at: string;
+  current: boolean;
+}
+
+// An account-level action, such as connecting Docker Hub or signing in
+export interface AuditLog {
+  id: number;
+  created_at: string;
+  action: string;
+  detail?: string;
+  ip_address?: string; // omitted for actions the app took by itself
+  user_agent?: string;
+}
+
+// An archive of everything stored about the user, built in the background
+export interface DataExport {
+  id: number;
+  status: "pending" | "running" | "ready" | "failed" | "expired";
+  created_at: string;
+  completed_at?: string;
+  expires_at?: string;
+  size_bytes: number;
+  failure?: string;
+  download_url?: string; // once ready, until it expires
+}
+
+export type ImportFormat = "csv" | "json" | "github";
+
+// Rows an activity import recorded, and those it skipped
+export interface ImportResult {
+  imported: number;
+  duplicates: number; // already recorded that day, or repeated in the file
+  expired: number; // older than the retention window
+}
+
+// A pending login from the CLI or another device without a browser
+export interface DeviceLogin {
+  user_code: string;
+  client_name?: string;
+  created_at: string;
+  expires_at: string;
 }
 
 export interface ThemesResponse {